@@ -1,21 +1,43 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"image/jpeg"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"phopy/internal/app"
 	"phopy/internal/config"
 	"phopy/internal/domain"
 	appErrors "phopy/internal/errors"
+	"phopy/internal/format"
+	"phopy/internal/hash"
 	"phopy/internal/infra/exif"
 	"phopy/internal/infra/fs"
+	"phopy/internal/infra/termimage"
+	"phopy/internal/journal"
+	"phopy/internal/lifecycle"
 	"phopy/internal/logging"
+	"phopy/internal/manifest"
+	"phopy/internal/planfile"
+	"phopy/internal/presentation"
+	"phopy/internal/runid"
+	"phopy/internal/trace"
 	"phopy/internal/tui"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
 	"github.com/spf13/cobra"
 )
 
@@ -30,188 +52,2027 @@ func main() {
 }
 
 type cliOptions struct {
-	sourceDir string
-	targetDir string
-	dryRun    bool
-	verbose   bool
-	override  bool
-	fromDate  string
-	untilDate string
+	sourceDir      string
+	targetDir      string
+	dryRun         bool
+	verbose        bool
+	override       bool
+	keepGoing      bool
+	confirmDefault string
+	yes            bool
+	no             bool
+	layout         string
+	rename         string
+	flatten        bool
+	keepExtCase    bool
+	sniff          bool
+	sniffFixExt    bool
+	checkTimezone  bool
+	manifest       bool
+	label          string
+	dateFloor      string
+	boundary       string
+	fromDate       string
+	untilDate      string
+	timeZone       string
+	plain          bool
+	quiet          bool
+	showAll        bool
+	page           int
+	overrideCap    int
+	previewItems   int
+	previewCount   int
+	latestLink     string
+	noLock         bool
+	noHeuristics   bool
+	preserveBTime  bool
+	ignoreHazards  bool
+	dedupe         bool
+	onConflict     string
+	onSourceChange string
+	fastPlan       bool
+	leftovers      string
+	// trace is the file --trace writes the Chrome trace of the run to
+	trace          string
+	exportScript   string
+	progress       string
+	extCase        string
+	exifWorkers    int
+	targetWorkers  int
+	scriptFormat   string
+	dirDates       string
+	dateTags       string
+	stampXattr     bool
+	noBenchmark    bool
+	statsOnly      bool
+	statsJSON      bool
+	skipLocked     bool
+	companionGlobs string
+	includeMisc    bool
+	keepJunk       bool
+	includeProxies bool
+	thumbnails     bool
+	include        []string
+	exclude        []string
+	overrideStale  bool
+	hash           string
+	fsync          bool
+	verify         bool
+	linkDupes      bool
+	barStyle       string
+	barMaxWidth    int
+	barPercent     bool
+
+	// planOut and planIn are the plan files of `phopy plan` and `phopy copy`
+	planOut string
+	planIn  string
+
+	// auto infers the run from the newest memory card, the profile and the
+	// card's last import (--auto, or no flags with a saved profile).
+	// autoSummary lists what was inferred and since is the start of the
+	// range found in the journal
+	auto        bool
+	autoSummary []string
+	since       time.Time
+
+	// onboarding is set when neither flags, environment nor a saved profile
+	// name the source and target
+	onboarding bool
+	// savedPlan is the plan loaded from planIn
+	savedPlan *planfile.File
+	// runID identifies this invocation in the log, the journal, the
+	// manifest and the completion summary
+	runID string
+}
+
+func newRootCmd() *cobra.Command {
+	opts := cliOptions{}
+	cmd := &cobra.Command{
+		Use:           "phopy",
+		Short:         "Copy photos into dated folders",
+		Long:          "phopy copies photos from a source directory into a target directory, grouped by date.\n\nEnvironment variables:\n  PHOPY_SOURCE_DIR     Source directory to copy from\n  PHOPY_TARGET_DIR     Target directory to copy to\n  PHOPY_VERBOSE        Verbose output (true/1/yes)\n  PHOPY_FROM           Start date (YYYY-MM-DD)\n  PHOPY_START_DATE     Start date (YYYY-MM-DD)\n  PHOPY_UNTIL          End date, exclusive (YYYY-MM-DD)\n  PHOPY_END_DATE       End date, exclusive (YYYY-MM-DD)",
+		Example:       "  phopy --source ~/Photos --target ~/Archive\n  phopy -s ./in -t ./out --from 2024-01-01 --until 2025-01-01 --dry-run",
+		Args:          runArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return resolvePaths(cmd, &opts)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	addRunFlags(cmd, &opts)
+
+	cmd.AddCommand(newPlanCmd())
+	cmd.AddCommand(newCopyCmd())
+	cmd.AddCommand(newCompletionCmd())
+	cmd.AddCommand(newVersionCmd())
+	cmd.AddCommand(newWarningsCmd())
+	cmd.AddCommand(newAuditCmd())
+	cmd.AddCommand(newJournalCmd())
+	cmd.AddCommand(newVerifyCmd())
+
+	return cmd
+}
+
+// newPlanCmd returns `phopy plan`, which prints the plan without copying and
+// can save it for `phopy copy --plan-in`.
+func newPlanCmd() *cobra.Command {
+	opts := cliOptions{}
+	cmd := &cobra.Command{
+		Use:     "plan",
+		Short:   "Print what would be copied without copying anything",
+		Long:    "plan scans the source like a dry run and prints the plan as plain text. With --plan-out the plan is also saved as JSON for phopy copy --plan-in.",
+		Example: "  phopy plan -s /Volumes/CARD -t ~/Archive --layout {yyyy}/{date} --plan-out card.json",
+		Args:    runArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			opts.dryRun = true
+			opts.plain = true
+			return resolvePaths(cmd, &opts)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), opts)
+		},
+	}
+	addRunFlags(cmd, &opts)
+	cmd.Flags().StringVar(&opts.planOut, "plan-out", "", "Save the plan as JSON to this file for phopy copy --plan-in")
+	cmd.AddCommand(newPlanDiffCmd())
+	return cmd
+}
+
+// newPlanDiffCmd returns `phopy plan diff`, which compares two plans saved
+// by --plan-out, e.g. of the same card with two layouts.
+func newPlanDiffCmd() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:     "diff OLD NEW",
+		Short:   "Show how a saved plan differs from an earlier one",
+		Long:    "diff compares two plans saved by phopy plan --plan-out: the files whose target changed, the files only one of them copies, and the summary counts that changed. Files are matched by their source path. Both plans must have the plan file version of this build; save older ones again.",
+		Example: "  phopy plan -s /Volumes/CARD -t ~/Archive --plan-out old.json\n  phopy plan -s /Volumes/CARD -t ~/Archive --layout {yyyy}/{date} --plan-out new.json\n  phopy plan diff old.json new.json",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var plans [2]planfile.File
+			for i, path := range args {
+				saved, err := planfile.Read(path)
+				if err != nil {
+					return appErrors.Wrap(appErrors.InvalidConfig, "read plan", path, err)
+				}
+				plans[i] = saved
+			}
+			diff := domain.DiffPlans(plans[0].Plan, plans[1].Plan)
+			if asJSON {
+				data, err := json.MarshalIndent(diff, "", "  ")
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+				return err
+			}
+			presentation.Printer{Writer: os.Stdout}.PrintPlanDiff(diff)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the differences as JSON")
+	return cmd
+}
+
+// newJournalCmd returns `phopy journal`, which maintains the import
+// journal of a target.
+func newJournalCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "journal",
+		Short: "Maintain the import journal of a target",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newJournalCompactCmd())
+	return cmd
+}
+
+// newJournalCompactCmd returns `phopy journal compact`, which shrinks the
+// journal of a target to the latest copy of each file.
+func newJournalCompactCmd() *cobra.Command {
+	opts := cliOptions{}
+	cmd := &cobra.Command{
+		Use:     "compact",
+		Short:   "Rewrite the journal with only the latest copy of each file",
+		Long:    "compact rewrites <target>/.phopy/journal.jsonl: every target path is only listed by the latest run that copied it, runs left without files and lines that cannot be read are dropped. The journal is replaced in one step, so an interrupted compact leaves it as it was.",
+		Example: "  phopy journal compact -t ~/Archive",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := opts.targetDir
+			if target == "" {
+				target = os.Getenv("PHOPY_TARGET_DIR")
+			}
+			if target == "" {
+				return appErrors.WithHint(appErrors.InvalidConfig, "config", "", "pass the target as --target", errors.New("no target"))
+			}
+			path := journal.Path(target)
+			if _, err := os.Stat(path); err != nil {
+				return appErrors.Wrap(appErrors.NotFound, "stat", path, err)
+			}
+			release, err := lockTarget(config.Config{TargetDir: target, NoLock: opts.noLock})
+			if err != nil {
+				return err
+			}
+			defer release()
+			if err := journal.Compact(target); err != nil {
+				return appErrors.Wrap(appErrors.IOFailure, "compact journal", path, err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&opts.targetDir, "target", "t", "", "Target whose journal to compact (env: PHOPY_TARGET_DIR)")
+	cmd.Flags().BoolVar(&opts.noLock, "no-lock", false, "Compact even while another phopy run holds the lock of the target")
+	return cmd
+}
+
+// newVerifyCmd returns `phopy verify`, which checks the files earlier runs
+// imported into a target for bit rot.
+func newVerifyCmd() *cobra.Command {
+	opts := cliOptions{}
+	cmd := &cobra.Command{
+		Use:     "verify",
+		Short:   "Check the files imported into a target against the hashes their manifests recorded",
+		Long:    "verify re-reads every file the manifests of the target (--manifest) list as copied and compares it with the hash recorded at import. Files are reported as target corrupted when the copy no longer matches, as source modified since import when the copy matches but its source that is still there does not, and as differs when an old manifest recorded no hash and source and copy differ. With --source, the files below it that no manifest lists are reported as never imported. Nothing is written; any finding fails the run.",
+		Example: "  phopy verify -t ~/Archive\n  phopy verify -t ~/Archive -s /Volumes/CARD",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerify(cmd.Context(), opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.targetDir, "target", "t", "", "Target whose imports to check (env: PHOPY_TARGET_DIR)")
+	cmd.Flags().StringVarP(&opts.sourceDir, "source", "s", "", "Source to report the files of that were never imported")
+	cmd.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "Log every file that is not intact while checking")
+	return cmd
+}
+
+// runVerify checks the imports of the target of opts and prints what is
+// not intact.
+func runVerify(ctx context.Context, opts cliOptions) error {
+	target := opts.targetDir
+	if target == "" {
+		target = os.Getenv("PHOPY_TARGET_DIR")
+	}
+	if target == "" {
+		return appErrors.WithHint(appErrors.InvalidConfig, "config", "", "pass the target as --target", errors.New("no target to verify"))
+	}
+	manifests, err := manifest.ReadAll(target)
+	if err != nil {
+		return appErrors.Wrap(appErrors.IOFailure, "read manifests", target, err)
+	}
+	if len(manifests) == 0 {
+		return appErrors.WithHint(appErrors.InvalidConfig, "verify", target, "copy with --manifest to record the files of a run", errors.New("the target has no manifests"))
+	}
+
+	// A file copied again is checked against its latest import
+	var copies []app.ImportedCopy
+	latest := make(map[string]int)
+	listed := make(map[string]bool)
+	copied := domain.ItemCopied.String()
+	for _, m := range manifests {
+		for _, entry := range m.Entries {
+			if entry.Status != copied {
+				listed[entry.SourcePath] = true
+				continue
+			}
+			c := app.ImportedCopy{SourcePath: entry.SourcePath, TargetPath: entry.TargetPath, Hash: entry.Hash, Algorithm: m.Algorithm()}
+			if i, ok := latest[entry.TargetPath]; ok {
+				copies[i] = c
+				continue
+			}
+			latest[entry.TargetPath] = len(copies)
+			copies = append(copies, c)
+		}
+	}
+
+	progress := &presentation.Progress{Writer: os.Stderr, Mode: presentation.ProgressLine, Terminal: isTerminal(os.Stderr)}
+	progress.Phase(fmt.Sprintf("Verifying %d files in %s", len(copies), target))
+	verifier := app.Verifier{FS: fs.OSFS{}, Logger: logging.New(os.Stderr, opts.verbose), OnProgress: progress.Update, Listed: listed}
+	report, err := verifier.Verify(ctx, copies, opts.sourceDir)
+	progress.Done()
+	if err != nil {
+		return appErrors.Wrap(appErrors.IOFailure, "verify", target, err)
+	}
+
+	presentation.Printer{Writer: os.Stdout}.PrintIntegrity(report)
+	if mismatches := report.Summary.Mismatches(); mismatches > 0 {
+		return appErrors.Wrap(appErrors.IOFailure, "verify", target, fmt.Errorf("%d files are not intact", mismatches))
+	}
+	return nil
+}
+
+// newAuditCmd returns `phopy audit`, which checks an existing archive
+// against the layout and the planner's rules without writing to it.
+func newAuditCmd() *cobra.Command {
+	opts := cliOptions{}
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:     "audit",
+		Short:   "Check an existing archive against the layout without changing it",
+		Long:    "audit reads the target like a source: its files are dated, paired and compared like those of a card. It reports the files in another folder than the layout gives their date, JPEGs in another folder than their RAW of the same name, duplicate captures and junk files, grouped by issue. Nothing is written. Only the folders are checked, file names (--rename) are not. Without --layout the layout of the saved profile is used.",
+		Example: "  phopy audit -t ~/Archive --layout {yyyy}/{date}\n  phopy audit -t ~/Archive --json > findings.json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAudit(cmd.Context(), opts, asJSON)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.targetDir, "target", "t", "", "Archive to audit (env: PHOPY_TARGET_DIR)")
+	cmd.Flags().StringVar(&opts.layout, "layout", "", "Directory template the archive should follow, e.g. {yyyy}/{date} (default: the saved profile's)")
+	cmd.Flags().StringVar(&opts.hash, "hash", "", "Content hash to find duplicates without a capture key: xxh3 (default), sha256 or blake3")
+	cmd.Flags().StringArrayVar(&opts.exclude, "exclude", nil, "Leave out files whose path below the archive matches this glob; repeatable")
+	addDatingFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the findings as JSON")
+	return cmd
+}
+
+// runAudit audits the archive of opts and prints the findings.
+func runAudit(ctx context.Context, opts cliOptions, asJSON bool) error {
+	archive := opts.targetDir
+	if archive == "" {
+		archive = os.Getenv("PHOPY_TARGET_DIR")
+	}
+	if archive == "" {
+		return appErrors.WithHint(appErrors.InvalidConfig, "config", "", "pass the archive as --target", errors.New("no archive to audit"))
+	}
+	if opts.layout == "" {
+		profile, _, err := config.LoadProfile()
+		if err != nil {
+			return appErrors.Wrap(appErrors.InvalidConfig, "profile", "", err)
+		}
+		opts.layout = profile.Layout
+	}
+	if opts.layout == "" {
+		return appErrors.WithHint(appErrors.InvalidConfig, "config", "", "pass the layout the archive should follow, e.g. --layout {yyyy}/{date}", errors.New("no layout to audit against"))
+	}
+
+	cfg, err := config.FromOptions(config.Options{
+		SourceDir:      archive,
+		TargetDir:      archive,
+		Verbose:        opts.verbose,
+		Layout:         opts.layout,
+		DateFloor:      opts.dateFloor,
+		DirDatePattern: opts.dirDates,
+		DateTagOrder:   opts.dateTags,
+		Hash:           opts.hash,
+		Exclude:        opts.exclude,
+	})
+	if err != nil {
+		return appErrors.Wrap(appErrors.InvalidConfig, "config", "", err)
+	}
+	if info, err := os.Stat(cfg.TargetDir); err != nil {
+		return appErrors.Wrap(appErrors.NotFound, "stat", cfg.TargetDir, err)
+	} else if !info.IsDir() {
+		return appErrors.Wrap(appErrors.InvalidConfig, "stat", cfg.TargetDir, errors.New("the archive is not a folder"))
+	}
+
+	planner := app.Planner{
+		FS:          fs.OSFS{},
+		Exif:        exif.Reader{DateTags: cfg.DateTags},
+		ExifWorkers: cfg.ExifWorkers,
+		Logger:      logging.New(os.Stderr, cfg.Verbose),
+		Layout:      cfg.Layout,
+		TimeZone:    cfg.Layout.Days.Zone,
+		DateFloor:   cfg.DateFloor,
+		DirDates:    cfg.DirDates,
+		DedupeHash:  cfg.DedupeHash,
+		Paths:       cfg.Paths,
+	}
+	report, err := planner.Audit(ctx, cfg.TargetDir)
+	if err != nil {
+		return appErrors.Wrap(appErrors.IOFailure, "audit", cfg.TargetDir, err)
+	}
+	if asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+		return err
+	}
+	presentation.Printer{Writer: os.Stdout}.PrintAudit(report)
+	return nil
+}
+
+// newCopyCmd returns `phopy copy`, the flow of phopy without a command. With
+// --plan-in it executes a saved plan instead of scanning the source.
+func newCopyCmd() *cobra.Command {
+	opts := cliOptions{}
+	cmd := &cobra.Command{
+		Use:     "copy",
+		Short:   "Plan and copy photos, like phopy without a command",
+		Long:    "copy plans and copies like phopy without a command. With --plan-in it executes a plan saved by phopy plan --plan-out; the source is not scanned again and the planning flags (layout, dates, ...) are ignored.",
+		Example: "  phopy copy -s /Volumes/CARD -t ~/Archive\n  phopy copy --plan-in card.json --plain",
+		Args:    runArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return resolvePaths(cmd, &opts)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), opts)
+		},
+	}
+	addRunFlags(cmd, &opts)
+	cmd.Flags().StringVar(&opts.planIn, "plan-in", "", "Execute the plan saved in this file by phopy plan --plan-out")
+	return cmd
+}
+
+// runArgs rejects arguments like cobra.NoArgs. A bare --latest-link takes
+// the name latest, so the name of --latest-link NAME ends up as an
+// argument; the error then shows the form that names the link.
+func runArgs(cmd *cobra.Command, args []string) error {
+	if flag := cmd.Flags().Lookup("latest-link"); len(args) > 0 && flag != nil && flag.Changed {
+		return fmt.Errorf("unknown argument %q, name the link with --latest-link=%s", args[0], args[0])
+	}
+	return cobra.NoArgs(cmd, args)
+}
+
+// addRunFlags registers the flags shared by the root command, plan and copy.
+func addRunFlags(cmd *cobra.Command, opts *cliOptions) {
+	cmd.Flags().StringVarP(&opts.sourceDir, "source", "s", "", "Source directory to copy from (env: PHOPY_SOURCE_DIR)")
+	cmd.Flags().StringVarP(&opts.targetDir, "target", "t", "", "Target directory to copy to (env: PHOPY_TARGET_DIR)")
+	cmd.Flags().BoolVarP(&opts.dryRun, "dry-run", "d", false, "Dry run (no copy)")
+	cmd.Flags().BoolVarP(&opts.override, "override", "o", false, "Allow overwriting existing files in target directory")
+	cmd.Flags().BoolVar(&opts.keepGoing, "keep-going", false, "Continue copying the remaining files after a copy fails")
+	cmd.Flags().StringVar(&opts.confirmDefault, "confirm-default", "", "Default answer of the override prompt: yes, no (default) or none (none requires an explicit y/n)")
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "Overwrite existing files without asking in plain mode")
+	cmd.Flags().BoolVar(&opts.no, "no", false, "Skip existing files without asking in plain mode")
+	cmd.Flags().BoolVar(&opts.overrideStale, "auto-override-stale", false, "With --override, overwrite existing files older than their source and of another size without asking, like those of an interrupted import")
+	cmd.Flags().BoolVar(&opts.auto, "auto", false, "Copy everything new from the newest memory card into the target of the saved profile (the default without flags once a profile exists)")
+	cmd.Flags().StringVar(&opts.layout, "layout", "", "Directory template below the target, e.g. {yyyy}/{date} (tokens: {yyyy} {mm} {dd} {date} {source_dir} {name} {ext})")
+	cmd.Flags().StringVar(&opts.rename, "rename", "", "File name template, e.g. {date}_{name}.{ext} (default: keep the source name)")
+	cmd.Flags().BoolVar(&opts.flatten, "flatten", false, "Drop the source directory structure below the layout directory")
+	cmd.Flags().BoolVar(&opts.keepExtCase, "keep-ext-case", false, "Same as --ext-case keep")
+	_ = cmd.Flags().MarkDeprecated("keep-ext-case", "use --ext-case keep instead")
+	cmd.Flags().StringVar(&opts.extCase, "ext-case", "", "Case of the target files' extensions and the {ext} token: keep, lower or upper; lower and upper match existing targets in any case (default: the source's, {ext} lowercase)")
+	cmd.Flags().BoolVar(&opts.sniff, "sniff", false, "Detect JPEG/TIFF/HEIF/MP4 content of files with unknown or missing extensions")
+	cmd.Flags().BoolVar(&opts.sniffFixExt, "sniff-fix-ext", false, "Give sniffed files the extension of their detected type on the target")
+	cmd.Flags().BoolVar(&opts.fastPlan, "fast-plan", false, "Preview without reading EXIF, dating files by their modification time (dry runs only)")
+	cmd.Flags().BoolVar(&opts.checkTimezone, "check-timezone", false, "Warn about files whose date folder differs between the camera's recorded UTC offset and --timezone (default: the local zone)")
+	cmd.Flags().StringVar(&opts.label, "label", "", "Label of this import for the {label} template token and the manifest; ask prompts for it")
+	cmd.Flags().StringVar(&opts.leftovers, "leftovers", "", "Write the discovered files left out of the plan, with the reason, to this file")
+	cmd.Flags().StringVar(&opts.trace, "trace", "", "Write the timing of the walk, EXIF scan, override check and copy to this file as Chrome trace JSON (chrome://tracing, Perfetto)")
+	cmd.Flags().StringVar(&opts.exportScript, "export-script", "", "Write the plan as a script of mkdir and cp commands to this file instead of copying (dry runs only)")
+	cmd.Flags().StringVar(&opts.scriptFormat, "script-format", "sh", "Shell of --export-script: sh (POSIX) or powershell")
+	cmd.Flags().BoolVar(&opts.manifest, "manifest", false, "Write a JSON manifest of the run to <target>/.phopy/manifests")
+	cmd.Flags().StringVar(&opts.latestLink, "latest-link", "", "After a successful copy, point a symlink named latest, or NAME with --latest-link=NAME, in the target at the newest dated folder (name.txt on Windows)")
+	cmd.Flags().Lookup("latest-link").NoOptDefVal = "latest"
+	cmd.Flags().BoolVar(&opts.preserveBTime, "preserve-btime", false, "Give copies the creation time of their source (macOS and Windows; skipped where unsupported)")
+	cmd.Flags().BoolVar(&opts.stampXattr, "stamp-xattr", false, "Stamp every copy with its source path and run id in the extended attributes user.phopy.src and user.phopy.run (skipped where unsupported)")
+	cmd.Flags().BoolVar(&opts.dedupe, "dedupe", false, "Copy each capture once when the source holds it twice, matched by camera serial and shutter count or else by content")
+	cmd.Flags().StringVar(&opts.onConflict, "on-conflict", "fail", "In plain mode, what to do with files that appear in the target while copying: fail, skip or overwrite (the TUI asks)")
+	cmd.Flags().StringVar(&opts.onSourceChange, "on-source-change", "copy", "What to do with source files whose size or modification time changed since planning: copy their current version, skip or fail them")
+	cmd.Flags().BoolVar(&opts.ignoreHazards, "i-know-what-im-doing", false, "Copy without confirmation when running as root or into a system or home directory")
+	cmd.Flags().BoolVar(&opts.fsync, "fsync", false, "Flush every copy and its folder to disk, and the manifest and journal before reporting success, so a power cut cannot lose files")
+	cmd.Flags().BoolVar(&opts.verify, "verify", false, "Re-read every copy and compare it with its source as read while copying; copies that differ fail and are removed")
+	cmd.Flags().BoolVar(&opts.linkDupes, "link-dupes", false, "Hard link files identical to one an earlier run recorded in its manifest instead of copying them again (implies --manifest; copies across volumes)")
+	cmd.Flags().StringVar(&opts.hash, "hash", "", "Content hash for --dedupe, manifests and --link-dupes: xxh3, sha256 or blake3 (default: xxh3 for --dedupe, sha256 for manifests)")
+	cmd.Flags().BoolVar(&opts.noBenchmark, "no-benchmark", false, "Do not write a few MB to the target to estimate how long the copy takes")
+	cmd.Flags().BoolVar(&opts.statsOnly, "stats-only", false, "Scan and write a few MB to the target, then print how long the scan took, what the plan copies and an estimate of the copy time instead of copying")
+	cmd.Flags().BoolVar(&opts.statsJSON, "stats-json", false, "Print the report of --stats-only as JSON; every other output stays text")
+	cmd.Flags().BoolVar(&opts.skipLocked, "skip-locked", runtime.GOOS == "windows", "Defer files another program holds open, retry them once at the end and skip those still locked (default on for Windows)")
+	cmd.Flags().BoolVar(&opts.noLock, "no-lock", false, "Do not lock the target against concurrent phopy runs")
+	cmd.Flags().BoolVar(&opts.noHeuristics, "no-source-heuristics", false, "Do not warn when the source looks like an organized archive instead of a camera card")
+	cmd.Flags().StringVar(&opts.barStyle, "bar-style", "gradient", "Progress bar fill: gradient or solid (solid stays visible in monochrome themes)")
+	cmd.Flags().IntVar(&opts.barMaxWidth, "bar-max-width", tui.DefaultBarMaxWidth, "Maximum width of the progress bars in columns")
+	cmd.Flags().BoolVar(&opts.barPercent, "bar-percent", false, "Render the percentage inside the progress bars")
+	cmd.Flags().BoolVar(&opts.plain, "plain", false, "Print plain text instead of the interactive TUI")
+	cmd.Flags().BoolVarP(&opts.quiet, "quiet", "q", false, "Print only the DRY-RUN verdict line of a dry run, or only the outcome of a copy (implies --plain)")
+	cmd.Flags().StringVar(&opts.progress, "progress", "full", "Progress output of plain mode: full, line (one status line, snapshots every 30s without a terminal) or none (phases and summary only); line and none imply --plain")
+	cmd.Flags().BoolVar(&opts.showAll, "show-all", false, "Print every planned file in plain mode instead of a sample")
+	cmd.Flags().IntVar(&opts.overrideCap, "override-preview", presentation.DefaultOverrideCap, "Number of override items listed before the rest is summarized; page through them with PgUp/PgDn in the TUI")
+	cmd.Flags().IntVar(&opts.exifWorkers, "exif-workers", 0, "Source files inspected at once (default: one per CPU)")
+	cmd.Flags().IntVar(&opts.targetWorkers, "target-workers", 0, fmt.Sprintf("Targets checked for existence at once (default: one per CPU, %d on network storage)", networkTargetWorkers))
+	cmd.Flags().IntVar(&opts.previewCount, "preview-count", presentation.DefaultPreviewCount, "Number of planned files listed in the preview, the first, the last and evenly spaced ones between them")
+	cmd.Flags().IntVar(&opts.previewItems, "preview-items", domain.DefaultPreviewItems, "Number of planned files the TUI keeps for its preview and filter; the rest are only counted")
+	cmd.Flags().IntVar(&opts.page, "page", 0, "Print the plain mode file lists in pages of N lines, waiting for Enter between pages on a terminal")
+	cmd.Flags().StringVar(&opts.companionGlobs, "companion-globs", "", "File name globs of files copied next to the file they belong to by name, e.g. C0001M01.XML next to C0001.MP4, or none (default C*.XML with --sniff)")
+	cmd.Flags().BoolVar(&opts.includeMisc, "include-misc", false, "Copy known camera housekeeping files like MEDIAPRO.XML below MISC in the target")
+	cmd.Flags().BoolVar(&opts.keepJunk, "keep-junk", false, "Plan .DS_Store, ._ AppleDouble, Thumbs.db and desktop.ini files instead of skipping them")
+	cmd.Flags().BoolVar(&opts.includeProxies, "include-proxies", false, "Plan the low-resolution proxies cameras record next to their clips, like Sony's PRIVATE/M4ROOT/SUB, instead of skipping them")
+	cmd.Flags().BoolVar(&opts.thumbnails, "thumbnails", false, "Show the EXIF thumbnail of the file highlighted with the arrow keys in the preview, in terminals that draw images (kitty, Ghostty, iTerm2, WezTerm)")
+	cmd.Flags().StringArrayVar(&opts.include, "include", nil, "Plan only files whose path below the source matches this glob, e.g. DCIM/100MSDCF; repeatable, a file matching any include is planned")
+	cmd.Flags().StringArrayVar(&opts.exclude, "exclude", nil, "Leave out files whose path below the source matches this glob, e.g. *.MP4 or DCIM/100MSDCF/TEST; repeatable, wins over --include")
+	cmd.Flags().StringVarP(&opts.fromDate, "from", "f", "", "Start date (YYYY-MM-DD, or YYYY-MM-DDThh:mm:ss+hh:mm for a moment with --timezone) (env: PHOPY_FROM, PHOPY_START_DATE)")
+	cmd.Flags().StringVarP(&opts.untilDate, "until", "u", "", "End date (YYYY-MM-DD, or YYYY-MM-DDThh:mm:ss+hh:mm with --timezone), exclusive: photos from this day on are skipped (env: PHOPY_UNTIL, PHOPY_END_DATE)")
+	cmd.Flags().StringVar(&opts.timeZone, "timezone", "", "Zone of the date range and the date folders, e.g. Asia/Tokyo or +09:00 (default: dates are midnight in the local zone, photos are dated by the camera's clock)")
+	cmd.Flags().StringVar(&opts.boundary, "boundary", "exclusive", "How --until ends the range: exclusive (at the start of that day) or inclusive (after it, like earlier versions)")
+	addDatingFlags(cmd, opts)
+}
+
+// addDatingFlags registers the flags that decide how files are dated, and
+// --verbose, which runs and audits read alike.
+func addDatingFlags(cmd *cobra.Command, opts *cliOptions) {
+	cmd.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "Verbose output (env: PHOPY_VERBOSE)")
+	cmd.Flags().StringVar(&opts.dateFloor, "date-floor", "1990-01-01", "Treat EXIF capture dates before this day (YYYY-MM-DD) as invalid and use the filesystem time")
+	cmd.Flags().StringVar(&opts.dirDates, "dir-date-pattern", "", "Date files without EXIF by the name of the deepest folder matching this regular expression with year, month and day groups; auto matches names like 1998-07 or 19980714")
+	cmd.Flags().StringVar(&opts.dateTags, "date-tag-order", "", "EXIF tags to read the capture date from, first found wins, e.g. CreateDate,DateTimeOriginal (default DateTimeOriginal,ModifyDate)")
+}
+
+// resolvePaths fills in source and target from the environment, a saved plan
+// and the saved profile, and checks that both are known. Without any paths
+// the TUI starts the first-run setup.
+func resolvePaths(cmd *cobra.Command, opts *cliOptions) error {
+	// Only plain mode can be quiet or report progress on a line
+	progress := strings.ToLower(strings.TrimSpace(opts.progress))
+	opts.plain = opts.plain || opts.quiet || opts.statsOnly || progress == "line" || progress == "none"
+
+	// Validate required flags (also checking environment variables)
+	source := opts.sourceDir
+	if source == "" {
+		source = os.Getenv("PHOPY_SOURCE_DIR")
+	}
+	target := opts.targetDir
+	if target == "" {
+		target = os.Getenv("PHOPY_TARGET_DIR")
+	}
+
+	// A saved plan brings the paths it was made for
+	if opts.planIn != "" {
+		saved, err := planfile.Read(opts.planIn)
+		if err != nil {
+			return appErrors.Wrap(appErrors.InvalidConfig, "read plan", opts.planIn, err)
+		}
+		if source == "" {
+			source, opts.sourceDir = saved.SourceDir, saved.SourceDir
+		}
+		if target == "" {
+			target, opts.targetDir = saved.TargetDir, saved.TargetDir
+		}
+		if filepath.Clean(source) != filepath.Clean(saved.SourceDir) || filepath.Clean(target) != filepath.Clean(saved.TargetDir) {
+			hint := fmt.Sprintf("the plan was made for %s → %s; drop --source and --target to use its paths", saved.SourceDir, saved.TargetDir)
+			return appErrors.WithHint(appErrors.InvalidConfig, "read plan", opts.planIn, hint, errors.New("source or target differ from the saved plan"))
+		}
+		if saved.Plan.ApproximateDates && !opts.dryRun {
+			hint := "the plan was made with --fast-plan; save it again without --fast-plan to copy it"
+			return appErrors.WithHint(appErrors.InvalidConfig, "read plan", opts.planIn, hint, errors.New("the saved plan has approximate dates"))
+		}
+		opts.savedPlan = &saved
+	}
+
+	// The profile is only read when a path is missing, so an unreadable
+	// one does not break runs that name both
+	loadProfile := onceProfile(autoSources.profile)
+
+	// Without any flags a saved profile makes the run automatic
+	if opts.planIn == "" && (opts.auto || cmd.Flags().NFlag() == 0) {
+		var err error
+		if source, target, err = inferAuto(opts, source, target, loadProfile); err != nil {
+			return err
+		}
+	}
+
+	// A saved profile fills in what flags and environment leave open
+	if source == "" || target == "" {
+		profile, hasProfile, err := loadProfile()
+		if err != nil {
+			return appErrors.Wrap(appErrors.InvalidConfig, "profile", "", err)
+		}
+		if hasProfile {
+			if source == "" {
+				source, opts.sourceDir = profile.SourceDir, profile.SourceDir
+			}
+			if target == "" {
+				target, opts.targetDir = profile.TargetDir, profile.TargetDir
+			}
+			if opts.layout == "" {
+				opts.layout = profile.Layout
+				opts.flatten = opts.flatten || profile.Flatten
+			}
+		}
+	}
+
+	// Without any paths the first run walks through the setup
+	if source == "" && target == "" && !opts.plain {
+		opts.onboarding = true
+		return nil
+	}
+
+	var missing []string
+	if source == "" {
+		missing = append(missing, "source (-s, --source, or PHOPY_SOURCE_DIR)")
+	}
+	if target == "" {
+		missing = append(missing, "target (-t, --target, or PHOPY_TARGET_DIR)")
+	}
+
+	if len(missing) > 0 {
+		_ = cmd.Help()
+		return fmt.Errorf("\nError: required flag(s) %q not set", missing)
+	}
+	return nil
+}
+
+// autoProviders are what --auto infers the run from.
+type autoProviders struct {
+	// cards returns the mounted memory cards, most recent first
+	cards   func() []string
+	profile func() (config.Profile, bool, error)
+	// watermark returns the newest capture time imported from the card
+	// volume named card into targetDir, zero without an earlier import
+	watermark func(targetDir, card string, volume domain.Volume) (time.Time, error)
+}
+
+// onceProfile returns a loader that reads the profile with load on its
+// first call and returns that result on every later one.
+func onceProfile(load func() (config.Profile, bool, error)) func() (config.Profile, bool, error) {
+	var (
+		profile config.Profile
+		found   bool
+		err     error
+	)
+	once := sync.OnceFunc(func() { profile, found, err = load() })
+	return func() (config.Profile, bool, error) {
+		once()
+		return profile, found, err
+	}
+}
+
+// autoSources is replaced by tests.
+var autoSources = autoProviders{
+	cards:   func() []string { return fs.CardVolumes(fs.VolumeRoots()) },
+	profile: config.LoadProfile,
+	watermark: func(targetDir, card string, volume domain.Volume) (time.Time, error) {
+		entries, err := journal.Read(targetDir)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return journal.Watermark(entries, card, volume), nil
+	},
+}
+
+// inferAuto fills in what --auto infers and flags and environment leave
+// open: the newest memory card as source, target and layout from the
+// profile and the last import of the card as start of the range. Without
+// --auto it only applies when a profile exists; then a missing card falls
+// back to the profile's source. It returns the resulting source and target
+// and records the inferences in opts.autoSummary. loadProfile reads the
+// saved profile; without --auto it is not read when both paths are known.
+func inferAuto(opts *cliOptions, source, target string, loadProfile func() (config.Profile, bool, error)) (string, string, error) {
+	if !opts.auto && source != "" && target != "" {
+		return source, target, nil
+	}
+	profile, hasProfile, err := loadProfile()
+	if err != nil {
+		return "", "", appErrors.Wrap(appErrors.InvalidConfig, "profile", "", err)
+	}
+	if !opts.auto && !hasProfile {
+		return source, target, nil
+	}
+
+	card := ""
+	var summary []string
+	switch cards := autoSources.cards(); {
+	case source != "":
+		summary = append(summary, "Source: "+source)
+	case len(cards) > 0:
+		source, card = cards[0], filepath.Base(cards[0])
+		summary = append(summary, fmt.Sprintf("Source: %s (newest memory card)", source))
+	case !opts.auto && profile.SourceDir != "":
+		source = profile.SourceDir
+		summary = append(summary, fmt.Sprintf("Source: %s (profile)", source))
+	default:
+		return "", "", appErrors.WithHint(appErrors.InvalidConfig, "auto", "", "insert a memory card with a DCIM folder or pass --source", errors.New("no memory card found"))
+	}
+
+	switch {
+	case target != "":
+		summary = append(summary, "Target: "+target)
+	case profile.TargetDir != "":
+		target = profile.TargetDir
+		summary = append(summary, fmt.Sprintf("Target: %s (profile)", target))
+	default:
+		return "", "", appErrors.WithHint(appErrors.InvalidConfig, "auto", "", "pass --target, or run phopy without flags once to save a default target", errors.New("no target"))
+	}
+
+	if opts.layout == "" && profile.Layout != "" {
+		opts.layout = profile.Layout
+		opts.flatten = opts.flatten || profile.Flatten
+		summary = append(summary, fmt.Sprintf("Layout: %s (profile)", opts.layout))
+	} else if opts.layout != "" {
+		summary = append(summary, "Layout: "+opts.layout)
+	}
+
+	explicitFrom := opts.fromDate != "" || os.Getenv("PHOPY_FROM") != "" || os.Getenv("PHOPY_START_DATE") != ""
+	if card != "" && !explicitFrom {
+		volume, _ := probeVolume(source)
+		watermark, err := autoSources.watermark(target, card, volume)
+		if err != nil {
+			return "", "", appErrors.Wrap(appErrors.IOFailure, "read journal", target, err)
+		}
+		if watermark.IsZero() {
+			summary = append(summary, fmt.Sprintf("Since: everything, nothing was imported from %s yet", card))
+		} else {
+			opts.since = watermark
+			summary = append(summary, fmt.Sprintf("Since: %s (last import from %s)", watermark.Local().Format("2006-01-02 15:04:05"), card))
+		}
+	}
+
+	opts.sourceDir, opts.targetDir = source, target
+	opts.autoSummary = summary
+	return source, target, nil
+}
+
+func run(ctx context.Context, opts cliOptions) error {
+	cfgOpts := config.Options{
+		SourceDir:      opts.sourceDir,
+		TargetDir:      opts.targetDir,
+		DryRun:         opts.dryRun,
+		Verbose:        opts.verbose,
+		Override:       opts.override,
+		ConfirmDefault: opts.confirmDefault,
+		Layout:         opts.layout,
+		Rename:         opts.rename,
+		Flatten:        opts.flatten,
+		KeepExtCase:    opts.keepExtCase,
+		Sniff:          opts.sniff,
+		SniffFixExt:    opts.sniffFixExt,
+		CheckTimezone:  opts.checkTimezone,
+		LatestLink:     opts.latestLink,
+		NoLock:         opts.noLock,
+		BarStyle:       opts.barStyle,
+		BarMaxWidth:    opts.barMaxWidth,
+		BarPercent:     opts.barPercent,
+		Manifest:       opts.manifest,
+		Label:          opts.label,
+		DateFloor:      opts.dateFloor,
+		OverrideCap:    opts.overrideCap,
+		PreviewItems:   opts.previewItems,
+		PreviewCount:   opts.previewCount,
+		Boundary:       opts.boundary,
+		FromDate:       opts.fromDate,
+		UntilDate:      opts.untilDate,
+		TimeZone:       opts.timeZone,
+		Since:          opts.since,
+
+		PreserveBirthTime: opts.preserveBTime,
+		IgnoreHazards:     opts.ignoreHazards,
+		Dedupe:            opts.dedupe,
+		OnConflict:        opts.onConflict,
+		OnSourceChange:    opts.onSourceChange,
+		FastPlan:          opts.fastPlan,
+		Leftovers:         opts.leftovers,
+		ExportScript:      opts.exportScript,
+		ScriptFormat:      opts.scriptFormat,
+		Progress:          opts.progress,
+		ExtCase:           opts.extCase,
+		ExifWorkers:       opts.exifWorkers,
+		TargetWorkers:     opts.targetWorkers,
+		DirDatePattern:    opts.dirDates,
+		DateTagOrder:      opts.dateTags,
+		StampXattr:        opts.stampXattr,
+		Yes:               opts.yes,
+		NoBenchmark:       opts.noBenchmark,
+		StatsOnly:         opts.statsOnly,
+		StatsJSON:         opts.statsJSON,
+		SkipLocked:        opts.skipLocked,
+		CompanionGlobs:    opts.companionGlobs,
+		IncludeMisc:       opts.includeMisc,
+		KeepJunk:          opts.keepJunk,
+		IncludeProxies:    opts.includeProxies,
+		Thumbnails:        opts.thumbnails,
+		Include:           opts.include,
+		Exclude:           opts.exclude,
+		AutoOverrideStale: opts.overrideStale,
+		Hash:              opts.hash,
+		Fsync:             opts.fsync,
+		Verify:            opts.verify,
+		LinkDupes:         opts.linkDupes,
+		No:                opts.no,
+	}
+
+	// The first-run setup picks source, target and layout before the config
+	// can be built; everything else is prepared with it
+	var cfg config.Config
+	if !opts.onboarding {
+		var err error
+		if cfg, err = prepareConfig(cfgOpts); err != nil {
+			return err
+		}
+		if err := confirmHazards(os.Stdin, os.Stdout, cfg, isTerminal(os.Stdin)); err != nil {
+			return err
+		}
+	}
+
+	// Create infrastructure
+	filesystem := fs.OSFS{}
+	exifReader := exif.Reader{DateTags: cfg.DateTags}
+	// Logs would break the JSON on stdout
+	logOut := os.Stdout
+	if cfg.StatsJSON {
+		logOut = os.Stderr
+	}
+	logger := logging.New(logOut, opts.verbose)
+	if opts.trace != "" {
+		logger.Trace = trace.New()
+		defer writeTrace(opts.trace, logger.Trace)
+	}
+	opts.runID = runid.New(time.Now())
+	logger.Verbosef("Run %s", opts.runID)
+	defer logHashRates(logger)
+
+	if cfg.StatsOnly {
+		return runStats(ctx, cfg, opts, logger)
+	}
+	if opts.plain {
+		return runPlain(ctx, cfg, opts, logger)
+	}
+
+	// Planner and executor stream their events here; forwardEvents feeds
+	// them into the TUI once the program exists
+	events := make(chan app.Event, 64)
+
+	// Planning, the copy and the event bridge run in the background; they
+	// are all stopped and waited for before run returns
+	background := lifecycle.New(ctx)
+	defer background.Stop()
+
+	// The TUI only gets a preview of the plan; the full plan is kept here
+	// for the copy
+	var full latestPlan
+
+	// Copy the full plan for the preview the TUI confirmed
+	copyPlan := func(ctx context.Context, preview domain.CopyPlan, includeOverrides bool) tea.Msg {
+		plan := full.get()
+		if len(preview.Reviewed) > 0 {
+			// The review acted on the preview; its decisions go by
+			// warning code, so they apply to the full plan as well
+			reviewed, err := plan.Review(cfg.TargetDir, preview.Reviewed, filesystem.Exists)
+			if err != nil {
+				return tui.ErrorMsg{Err: appErrors.Wrap(appErrors.IOFailure, "review", cfg.TargetDir, err)}
+			}
+			plan = reviewed
+		}
+		// Ensure target directory exists
+		if err := filesystem.MkdirAll(cfg.TargetDir, 0o755); err != nil {
+			return tui.ErrorMsg{Err: appErrors.Wrap(appErrors.IOFailure, "mkdir", cfg.TargetDir, err)}
+		}
+
+		release, err := lockTarget(cfg)
+		if err != nil {
+			return tui.ErrorMsg{Err: err}
+		}
+		defer release()
+
+		runJournal := newJournal(cfg, opts.runID, plan)
+		executor := app.Executor{
+			FS:        copyFS(cfg, logger),
+			Logger:    logger,
+			KeepGoing: opts.keepGoing,
+			Journal:   runJournal,
+			StampRun:  stampRun(cfg, runJournal),
+			// Locked sources are listed in the completion summary
+			SkipLocked: cfg.SkipLocked,
+			Fsync:      cfg.Fsync,
+			LinkIndex:  linkIndex(cfg, logger),
+			LinkHash:   cfg.ManifestHash,
+			Hash:       copyHash(cfg),
+			Verify:     cfg.Verify,
+			// Targets that appear while copying are asked about in
+			// the TUI, see forwardEvents
+			OnConflict:     app.AskConflicts(events),
+			OnSourceChange: cfg.OnSourceChange,
+			RunID:          opts.runID,
+		}
+
+		result, err := executor.ExecuteWithEvents(ctx, plan, plan.Decide(includeOverrides), events)
+		err = finishExecution(cfg, plan, result, err)
+		var diskFull *app.DiskFullError
+		if errors.As(err, &diskFull) {
+			return tui.DiskFullMsg{
+				Result:         result,
+				Remaining:      diskFull.Remaining,
+				RemainingBytes: diskFull.RemainingBytes,
+				Missing:        diskFull.Missing(),
+				Err:            copyFailed(cfg.TargetDir, err),
+			}
+		}
+		if err != nil {
+			return tui.ErrorMsg{Err: copyFailed(cfg.TargetDir, err)}
+		}
+
+		// Signal copy is done
+		overrides := 0
+		if includeOverrides {
+			overrides = len(plan.OverrideItems)
+		}
+		return tui.CopyDoneMsg{OverridesConfirmed: overrides, Result: result}
+	}
+
+	// Create the ExecuteCopy function that will be called by the TUI. The
+	// copy runs on a goroutine of Bubble Tea, so quitting stops it and run
+	// waits for the journal to be written
+	executeCopy := func(preview domain.CopyPlan, includeOverrides bool) tea.Cmd {
+		return func() tea.Msg {
+			var msg tea.Msg
+			background.Do(func(ctx context.Context) {
+				msg = copyPlan(ctx, preview, includeOverrides)
+			})
+			return msg
+		}
+	}
+
+	// stopPlanning ends the EXIF scan of the running planner early; each
+	// scan gets its own, so a stop cannot carry over to a replan
+	var stopMu sync.Mutex
+	var stopPlanning func()
+
+	// Run planning in background; the outcome arrives as a PlanDoneEvent
+	startPlanning := func() {
+		logger.Verbose = cfg.Verbose
+		if opts.savedPlan != nil {
+			background.Go(func(ctx context.Context) {
+				select {
+				case events <- app.PlanDoneEvent{Plan: opts.savedPlan.Plan}:
+				case <-ctx.Done():
+				}
+			})
+			return
+		}
+		limits := targetPathLimits(cfg, logger)
+		spill := &warningLog{}
+		planner := app.Planner{
+			FS:            filesystem,
+			Exif:          exifReader,
+			ExifWorkers:   cfg.ExifWorkers,
+			Logger:        logger,
+			AllowOverride: cfg.Override,
+			Layout:        cfg.Layout,
+			Sniff:         cfg.Sniff,
+			CheckTimezone: cfg.CheckTimezone,
+			TimeZone:      cfg.Layout.Days.Zone,
+			DateFloor:     cfg.DateFloor,
+			DirDates:      cfg.DirDates,
+			InclusiveEnd:  cfg.InclusiveEnd,
+			History:       importHistory(cfg, logger),
+			Dedupe:        cfg.Dedupe,
+			Fast:          cfg.FastPlan,
+			WarningSpill:  spill,
+
+			CompanionGlobs: cfg.CompanionGlobs,
+			IncludeMisc:    cfg.IncludeMisc,
+			KeepJunk:       cfg.KeepJunk,
+			PathLimits:     limits,
+			SourceVolume:   sourceVolume(cfg, logger),
+			TargetWorkers:  targetWorkers(cfg, limits),
+			IncludeProxies: cfg.IncludeProxies,
+			Paths:          cfg.Paths,
+
+			AutoOverrideStale: cfg.AutoOverrideStale,
+			DedupeHash:        cfg.DedupeHash,
+		}
+		stop := make(chan struct{})
+		planner.Stop = stop
+		stopMu.Lock()
+		stopPlanning = sync.OnceFunc(func() { close(stop) })
+		stopMu.Unlock()
+		background.Go(func(ctx context.Context) {
+			defer spill.Close()
+			_, _ = planner.PlanWithEvents(ctx, cfg.SourceDir, cfg.TargetDir, cfg.StartDate, cfg.EndDate, events)
+		})
+	}
+
+	// Finish the first-run setup: build the config from the picks, save them
+	// as the default profile if asked to, then start planning
+	startScan := func(result tui.OnboardingResult) tea.Cmd {
+		return func() tea.Msg {
+			cfgOpts.SourceDir = result.SourceDir
+			cfgOpts.TargetDir = result.TargetDir
+			cfgOpts.Layout = result.Layout
+			cfgOpts.Flatten = cfgOpts.Flatten || result.Flatten
+			prepared, err := prepareConfig(cfgOpts)
+			if err != nil {
+				return tui.ErrorMsg{Err: err}
+			}
+			if err := confirmHazards(nil, nil, prepared, false); err != nil {
+				return tui.ErrorMsg{Err: err}
+			}
+			if result.SaveProfile {
+				profile := config.Profile{SourceDir: result.SourceDir, TargetDir: result.TargetDir, Layout: result.Layout, Flatten: result.Flatten}
+				if err := config.SaveProfile(profile); err != nil {
+					return tui.ErrorMsg{Err: appErrors.Wrap(appErrors.IOFailure, "save profile", "", err)}
+				}
+			}
+			cfg = prepared
+			startPlanning()
+			return nil
+		}
+	}
+
+	// Apply the answer of the label prompt; without the first-run setup or
+	// the source warning planning can start right away
+	var tuiConfig tui.Config
+	setLabel := func(label string) tea.Cmd {
+		return func() tea.Msg {
+			cfgOpts.Label = label
+			if opts.onboarding {
+				return nil
+			}
+			cfg.Layout.Label = label
+			if tuiConfig.SourceWarning == "" && len(tuiConfig.AutoSummary) == 0 {
+				startPlanning()
+			}
+			return nil
+		}
+	}
+
+	// Scan a source that looks like an organized archive, or the inferred
+	// configuration of --auto, once accepted
+	continueScan := func() tea.Cmd {
+		return func() tea.Msg {
+			startPlanning()
+			return nil
+		}
+	}
+
+	// Upgrade a --fast-plan preview to a plan with EXIF dates
+	replan := func() tea.Cmd {
+		return func() tea.Msg {
+			cfg.FastPlan = false
+			startPlanning()
+			return nil
+		}
+	}
+
+	// Plan the files scanned so far; the planner sends the partial plan
+	stopScan := func() tea.Cmd {
+		return func() tea.Msg {
+			stopMu.Lock()
+			stop := stopPlanning
+			stopMu.Unlock()
+			if stop != nil {
+				stop()
+			}
+			return nil
+		}
+	}
+
+	// Create TUI config with the ExecuteCopy callback
+	tuiConfig = tui.Config{
+		SourceDir:      cfg.SourceDir,
+		TargetDir:      cfg.TargetDir,
+		DryRun:         opts.dryRun,
+		Verbose:        cfg.Verbose,
+		ConfirmDefault: tui.ConfirmDefault(cfg.ConfirmDefault),
+		ExecuteCopy:    executeCopy,
+		Replan:         replan,
+		StopScan:       stopScan,
+		Bar:            tui.BarStyle{MaxWidth: cfg.BarMaxWidth, Solid: cfg.BarSolid, ShowPercent: cfg.BarPercent},
+		OverrideCap:    cfg.OverrideCap,
+		PreviewCount:   cfg.PreviewCount,
+		Label:          cfg.Layout.Label,
+		AskLabel:       cfg.AskLabel || (opts.onboarding && strings.TrimSpace(opts.label) == "ask"),
+		SetLabel:       setLabel,
+		SourceWarning:  sourceWarning(cfg, opts),
+		Continue:       continueScan,
+		AutoSummary:    opts.autoSummary,
+		TargetExists:   filesystem.Exists,
+	}
+	if protocol := termimage.Detect(os.Getenv); cfg.Thumbnails && protocol != termimage.None {
+		tuiConfig.Thumbnail = loadThumbnail(protocol)
+		tuiConfig.ThumbnailClear = termimage.Clear(protocol)
+	}
+	if opts.onboarding {
+		tuiConfig.Onboarding = true
+		tuiConfig.Volumes = fs.CardVolumes(fs.VolumeRoots())
+		tuiConfig.DefaultTarget = defaultTarget()
+		tuiConfig.StartScan = startScan
+	}
+
+	// Create the TUI model and program. A panic of the model ends the
+	// program but keeps the model, see recoverFromPanic
+	guard := tui.Recover(tui.NewModel(tuiConfig))
+	p := tea.NewProgram(guard, tea.WithAltScreen(), tea.WithContext(ctx))
+	guard.Quit = func() { go p.Quit() }
+
+	ready := func(plan domain.CopyPlan) (tui.PlanReadyMsg, error) {
+		if err := writeLeftovers(cfg, plan); err != nil {
+			return tui.PlanReadyMsg{}, err
+		}
+		if err := writeScript(cfg, plan); err != nil {
+			return tui.PlanReadyMsg{}, err
+		}
+		full.set(plan)
+		sample := presentation.SampleIndexes(len(plan.Items), presentation.EffectivePreviewCount(cfg.PreviewCount))
+		return tui.PlanReadyMsg{Plan: plan.Preview(cfg.PreviewItems, sample), Estimate: estimateCopy(cfg, plan, logger)}, nil
+	}
+	background.Go(func(ctx context.Context) {
+		forwardEvents(ctx, p, events, func(err error) error { return planError(cfg, err) }, ready)
+	})
+
+	if !opts.onboarding && !tuiConfig.AskLabel && tuiConfig.SourceWarning == "" && len(tuiConfig.AutoSummary) == 0 {
+		startPlanning()
+	}
+
+	// Run the TUI
+	restoreTerminal := saveTerminal()
+	_, err := p.Run()
+	if guard.Panic != nil {
+		restoreTerminal()
+		cfg.AskLabel = false
+		return recoverFromPanic(ctx, cfg, opts, logger, guard.Model.(tui.Model), full.get(), guard.Panic)
+	}
+	if errors.Is(err, tea.ErrProgramPanic) {
+		restoreTerminal()
+		return appErrors.WithHint(appErrors.Internal, "tui", "", "run phopy again with --plain", err)
+	}
+	if err != nil {
+		return appErrors.Wrap(appErrors.Internal, "tui", "", err)
+	}
+
+	return tuiOutcome(os.Stdout, guard.Model.(tui.Model), cfg, opts)
+}
+
+// tuiOutcome reports how the TUI ended with final: its error, the abort at
+// the override confirmation, or the summary of the copy.
+func tuiOutcome(w io.Writer, final tui.Model, cfg config.Config, opts cliOptions) error {
+	if (final.Phase == tui.PhaseError || final.Phase == tui.PhaseDiskFull) && final.Err != nil {
+		return final.Err
+	}
+	if final.AbortedAtConfirm() {
+		return appErrors.WithHint(appErrors.Aborted, "the override confirmation", "",
+			"answer n and press Enter to copy only the files that are not in the target yet", errors.New("nothing was copied"))
+	}
+	if final.Phase == tui.PhaseDone && !opts.dryRun {
+		return printCompletionSummary(w, final.Result, cfg.TargetDir)
+	}
+	return nil
+}
+
+// saveTerminal returns a function that puts the terminal back into the state
+// it had before the TUI, in case a crash kept Bubble Tea from restoring it.
+func saveTerminal() func() {
+	if !isTerminal(os.Stdin) {
+		return func() {}
+	}
+	state, err := term.GetState(os.Stdin.Fd())
+	if err != nil {
+		return func() {}
+	}
+	return func() {
+		_ = term.Restore(os.Stdin.Fd(), state)
+		// Leave the alternate screen and show the cursor again
+		fmt.Fprint(os.Stdout, "\x1b[?1049l\x1b[?25h")
+	}
+}
+
+// recoverFromPanic handles a crash of the TUI: it writes the panic with its
+// stack to a crash log and completes the run in plain mode when the plan is
+// ready and the copy has not started. last is the model before the panic
+// and plan the full plan it previewed.
+func recoverFromPanic(ctx context.Context, cfg config.Config, opts cliOptions, logger logging.Logger, last tui.Model, plan domain.CopyPlan, crash *tui.PanicError) error {
+	logPath, err := writeCrashLog(crash)
+	if err != nil {
+		logPath = "stderr"
+		fmt.Fprintf(os.Stderr, "%v\n\n%s\n", crash, crash.Stack)
+	}
+
+	switch {
+	case last.Phase == tui.PhaseDone && !cfg.DryRun:
+		fmt.Fprintf(os.Stdout, "The interface crashed after copying, the details are in %s.\n\n", logPath)
+		return printCompletionSummary(os.Stdout, last.Result, cfg.TargetDir)
+	case last.Phase == tui.PhasePreview || last.Phase == tui.PhaseConfirm || last.Phase == tui.PhaseReview || last.Phase == tui.PhaseDone:
+		fmt.Fprintf(os.Stdout, "The interface crashed, continuing in plain mode. The details are in %s.\n\n", logPath)
+		opts.plain = true
+		opts.autoSummary = nil
+		opts.savedPlan = &planfile.File{SourceDir: cfg.SourceDir, TargetDir: cfg.TargetDir, Plan: plan}
+		return runPlain(ctx, cfg, opts, logger)
+	case last.Phase == tui.PhaseExecuting || last.Phase == tui.PhaseConflict:
+		return appErrors.WithHint(appErrors.Internal, "tui", "", fmt.Sprintf("the copy was interrupted; run phopy again to copy the remaining files. The details are in %s", logPath), crash)
+	default:
+		return appErrors.WithHint(appErrors.Internal, "tui", "", fmt.Sprintf("run phopy again with --plain. The details are in %s", logPath), crash)
+	}
+}
+
+// latestPlan is the latest full plan of a TUI run, which the TUI itself
+// only gets a preview of. It is set by forwardEvents and read by the copy.
+type latestPlan struct {
+	mu   sync.Mutex
+	plan domain.CopyPlan
+}
+
+func (l *latestPlan) set(plan domain.CopyPlan) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.plan = plan
+}
+
+func (l *latestPlan) get() domain.CopyPlan {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.plan
+}
+
+// warningLog is the app.WarningSpill of the planner: the warnings beyond
+// its cap go to a log file in the temporary directory, created with the
+// first of them. Close it once the planner is done.
+type warningLog struct {
+	file *os.File
+}
+
+func (l *warningLog) Spill(warning domain.Warning) error {
+	if l.file == nil {
+		file, err := os.CreateTemp("", "phopy-warnings-*.log")
+		if err != nil {
+			return err
+		}
+		l.file = file
+	}
+	_, err := fmt.Fprintf(l.file, "%s: %s\n", warning.Code, warning.Text)
+	return err
+}
+
+// Close closes the log file, if there is one.
+func (l *warningLog) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+func (l *warningLog) Name() string {
+	if l.file == nil {
+		return ""
+	}
+	return l.file.Name()
 }
 
-func newRootCmd() *cobra.Command {
-	opts := cliOptions{}
-	cmd := &cobra.Command{
-		Use:           "phopy",
-		Short:         "Copy photos into dated folders",
-		Long:          "phopy copies photos from a source directory into a target directory, grouped by date.\n\nEnvironment variables:\n  PHOPY_SOURCE_DIR     Source directory to copy from\n  PHOPY_TARGET_DIR     Target directory to copy to\n  PHOPY_VERBOSE        Verbose output (true/1/yes)\n  PHOPY_FROM           Start date (YYYY-MM-DD)\n  PHOPY_START_DATE     Start date (YYYY-MM-DD)\n  PHOPY_UNTIL          End date (YYYY-MM-DD)\n  PHOPY_END_DATE       End date (YYYY-MM-DD)",
-		Example:       "  phopy --source ~/Photos --target ~/Archive\n  phopy -s ./in -t ./out --from 2024-01-01 --until 2024-12-31 --dry-run",
-		Args:          cobra.NoArgs,
-		SilenceErrors: true,
-		SilenceUsage:  true,
-		PreRunE: func(cmd *cobra.Command, args []string) error {
-			// Validate required flags (also checking environment variables)
-			source := opts.sourceDir
-			if source == "" {
-				source = os.Getenv("PHOPY_SOURCE_DIR")
-			}
-			target := opts.targetDir
-			if target == "" {
-				target = os.Getenv("PHOPY_TARGET_DIR")
-			}
+// writeCrashLog writes crash with its stack to a new file in the temporary
+// directory and returns its path.
+func writeCrashLog(crash *tui.PanicError) (string, error) {
+	file, err := os.CreateTemp("", "phopy-crash-*.log")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintf(file, "phopy %s crashed at %s: %v\n\n%s", version, time.Now().Format(time.RFC3339), crash.Value, crash.Stack); err != nil {
+		return "", err
+	}
+	return file.Name(), nil
+}
 
-			var missing []string
-			if source == "" {
-				missing = append(missing, "source (-s, --source, or PHOPY_SOURCE_DIR)")
-			}
-			if target == "" {
-				missing = append(missing, "target (-t, --target, or PHOPY_TARGET_DIR)")
-			}
+// runPlain plans and copies without the TUI, printing plain text to stdout.
+func runPlain(ctx context.Context, cfg config.Config, opts cliOptions, logger logging.Logger) error {
+	if cfg.AskLabel {
+		label, err := askLabel(os.Stdin, os.Stdout)
+		if err != nil {
+			return appErrors.Wrap(appErrors.InvalidConfig, "label", "", err)
+		}
+		cfg.Layout.Label = label
+	}
 
-			if len(missing) > 0 {
-				_ = cmd.Help()
-				return fmt.Errorf("\nError: required flag(s) %q not set", missing)
-			}
-			return nil
-		},
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return run(cmd.Context(), opts)
-		},
+	if len(opts.autoSummary) > 0 && !opts.quiet {
+		fmt.Fprintln(os.Stdout, "Copying everything new:")
+		for _, line := range opts.autoSummary {
+			fmt.Fprintln(os.Stdout, "  "+line)
+		}
+		fmt.Fprintln(os.Stdout)
+	}
+	if warning := sourceWarning(cfg, opts); warning != "" && !opts.quiet {
+		fmt.Fprintf(os.Stdout, "Warning: source looks like an organized archive: %s.\n", warning)
 	}
 
-	cmd.Flags().StringVarP(&opts.sourceDir, "source", "s", "", "Source directory to copy from (env: PHOPY_SOURCE_DIR)")
-	cmd.Flags().StringVarP(&opts.targetDir, "target", "t", "", "Target directory to copy to (env: PHOPY_TARGET_DIR)")
-	cmd.Flags().BoolVarP(&opts.dryRun, "dry-run", "d", false, "Dry run (no copy)")
-	cmd.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "Verbose output (env: PHOPY_VERBOSE)")
-	cmd.Flags().BoolVarP(&opts.override, "override", "o", false, "Allow overwriting existing files in target directory")
-	cmd.Flags().StringVarP(&opts.fromDate, "from", "f", "", "Start date (YYYY-MM-DD) (env: PHOPY_FROM, PHOPY_START_DATE)")
-	cmd.Flags().StringVarP(&opts.untilDate, "until", "u", "", "End date (YYYY-MM-DD) (env: PHOPY_UNTIL, PHOPY_END_DATE)")
+	progress := &presentation.Progress{Writer: os.Stdout, Mode: presentation.ProgressMode(cfg.Progress), Terminal: isTerminal(os.Stdout)}
+	if opts.quiet {
+		progress.Mode = presentation.ProgressFull
+	}
+	if opts.savedPlan == nil {
+		progress.Phase("Scanning " + cfg.SourceDir)
+	}
+	filesystem := fs.OSFS{}
+	plan, err := planOrLoad(ctx, cfg, opts, logger, progress.Update)
+	progress.Done()
+	if err != nil {
+		return planError(cfg, err)
+	}
+	if err := writeLeftovers(cfg, plan); err != nil {
+		return err
+	}
+	if err := writeScript(cfg, plan); err != nil {
+		return err
+	}
+	if opts.planOut != "" {
+		saved := planfile.File{CreatedAt: time.Now(), SourceDir: cfg.SourceDir, TargetDir: cfg.TargetDir, ConfigDigest: cfg.Digest(), Plan: plan}
+		if err := planfile.Write(opts.planOut, saved); err != nil {
+			return appErrors.Wrap(appErrors.IOFailure, "save plan", opts.planOut, err)
+		}
+	}
 
-	cmd.AddCommand(newCompletionCmd())
-	cmd.AddCommand(newVersionCmd())
+	printer := presentation.Printer{
+		Writer:       os.Stdout,
+		Verbose:      cfg.Verbose,
+		ShowAll:      opts.showAll,
+		PageSize:     opts.page,
+		Interactive:  isTerminal(os.Stdout) && isTerminal(os.Stdin),
+		Input:        os.Stdin,
+		Quiet:        opts.quiet,
+		OverrideCap:  cfg.OverrideCap,
+		PreviewCount: cfg.PreviewCount,
+	}
+	if cfg.DryRun {
+		printer.PrintDryRun(plan)
+		return nil
+	}
 
-	return cmd
+	if estimate := estimateCopy(cfg, plan, logger); estimate > 0 && !opts.quiet {
+		fmt.Fprintln(os.Stdout, presentation.EstimateLine(estimate))
+	}
+	includeOverrides, err := answerOverrides(os.Stdin, os.Stdout, cfg, len(plan.OverrideItems), isTerminal(os.Stdin))
+	if err != nil {
+		return err
+	}
+
+	if err := filesystem.MkdirAll(cfg.TargetDir, 0o755); err != nil {
+		return appErrors.Wrap(appErrors.IOFailure, "mkdir", cfg.TargetDir, err)
+	}
+	release, err := lockTarget(cfg)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	runJournal := newJournal(cfg, opts.runID, plan)
+	executor := app.Executor{FS: copyFS(cfg, logger), Logger: logger, KeepGoing: opts.keepGoing, Journal: runJournal, StampRun: stampRun(cfg, runJournal), SkipLocked: cfg.SkipLocked, Fsync: cfg.Fsync, LinkIndex: linkIndex(cfg, logger), LinkHash: cfg.ManifestHash, Hash: copyHash(cfg), Verify: cfg.Verify, OnConflict: app.AnswerConflicts(cfg.OnConflict), OnSourceChange: cfg.OnSourceChange, RunID: opts.runID}
+	executor.OnProgress = func(current, total int, _ string) { progress.Update(current, total) }
+	executor.OnFolderDone = func(done app.FolderDone) {
+		logger.Verbosef("Finished %s: %d files, %s", done.Dir, done.Files, format.Bytes(done.Bytes))
+	}
+	// The executor records the declined overrides as skipped; everything
+	// reported about the plan leaves them out
+	effective := plan.Effective(includeOverrides)
+	progress.Phase(fmt.Sprintf("Copying %d files to %s", len(effective.Items), cfg.TargetDir))
+	result, err := executor.Execute(ctx, plan, plan.Decide(includeOverrides))
+	progress.Done()
+	if err := finishExecution(cfg, plan, result, err); err != nil {
+		return copyFailed(cfg.TargetDir, err)
+	}
+
+	overridesConfirmed := 0
+	if includeOverrides {
+		overridesConfirmed = len(plan.OverrideItems)
+	}
+	if !opts.quiet && progress.Mode != presentation.ProgressNone {
+		printer.PrintExecution(effective, overridesConfirmed)
+		fmt.Fprintln(os.Stdout)
+	}
+	return printCompletionSummary(os.Stdout, result, cfg.TargetDir)
 }
 
-func run(ctx context.Context, opts cliOptions) error {
-	cfg, err := config.FromOptions(config.Options{
-		SourceDir: opts.sourceDir,
-		TargetDir: opts.targetDir,
-		DryRun:    opts.dryRun,
-		Verbose:   opts.verbose,
-		Override:  opts.override,
-		FromDate:  opts.fromDate,
-		UntilDate: opts.untilDate,
-	})
+// runStats plans like a copy, times a short write test of the target and
+// prints how long the scan took, what the plan copies and how long copying
+// it would take, without copying (--stats-only). Progress goes to stderr so
+// the JSON report can be piped.
+func runStats(ctx context.Context, cfg config.Config, opts cliOptions, logger logging.Logger) error {
+	progress := &presentation.Progress{Writer: os.Stderr, Mode: presentation.ProgressMode(cfg.Progress), Terminal: isTerminal(os.Stderr)}
+	if opts.savedPlan == nil {
+		progress.Phase("Scanning " + cfg.SourceDir)
+	}
+	plan, err := planOrLoad(ctx, cfg, opts, logger, progress.Update)
+	progress.Done()
 	if err != nil {
-		return appErrors.Wrap(appErrors.InvalidConfig, "config", "", err)
+		return planError(cfg, err)
 	}
 
-	// Create infrastructure
-	filesystem := fs.OSFS{}
-	exifReader := exif.Reader{}
-	logger := logging.New(os.Stdout, cfg.Verbose)
+	stats := presentation.NewRunStats(plan, benchmarkTarget(cfg, plan, logger))
+	if cfg.StatsJSON {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+		return err
+	}
+	_, err = fmt.Fprintln(os.Stdout, presentation.StatsLine(stats))
+	return err
+}
+
+// measureThroughput measures how fast the target can be written to;
+// replaced in tests.
+var measureThroughput = fs.MeasureThroughput
+
+// estimateCopy estimates how long copying plan takes from a short write
+// test in the target, see benchmarkTarget.
+func estimateCopy(cfg config.Config, plan domain.CopyPlan, logger logging.Logger) time.Duration {
+	return presentation.EstimateCopyTime(plan.TotalBytes(), benchmarkTarget(cfg, plan, logger))
+}
+
+// benchmarkTarget measures how fast the target writes, in bytes per
+// second. It is 0 for dry runs, which must not write, with --no-benchmark,
+// for empty plans and when the test fails.
+func benchmarkTarget(cfg config.Config, plan domain.CopyPlan, logger logging.Logger) float64 {
+	if cfg.DryRun || cfg.NoBenchmark || len(plan.Items) == 0 {
+		return 0
+	}
+	throughput, err := measureThroughput(cfg.TargetDir)
+	if err != nil {
+		logger.Verbosef("No copy time estimate: %v", err)
+		return 0
+	}
+	logger.Verbosef("The target writes %s", format.Rate(throughput))
+	return throughput
+}
 
-	// Verify source directory exists
-	if _, err := filesystem.Stat(cfg.SourceDir); err != nil {
-		return appErrors.Wrap(appErrors.NotFound, "stat", cfg.SourceDir, err)
+// copyFS returns the file system the executor copies with. Creation times
+// are only preserved when the target can take them.
+func copyFS(cfg config.Config, logger logging.Logger) fs.OSFS {
+	fsys := fs.OSFS{Fsync: cfg.Fsync}
+	if !cfg.PreserveBirthTime {
+		return fsys
 	}
+	if err := fs.ProbeBirthTime(cfg.TargetDir); err != nil {
+		logger.Verbosef("Not preserving file creation times: %v", err)
+		return fsys
+	}
+	fsys.PreserveBirthTime = true
+	return fsys
+}
 
-	// We need to declare p early so we can reference it in the ExecuteCopy callback
-	var p *tea.Program
-	var pMu sync.Mutex
+// syncMeta flushes the file at path below the target's meta directory and
+// the directory itself to disk.
+func syncMeta(path string) error {
+	if err := fs.SyncFile(path); err != nil {
+		return err
+	}
+	return fs.OSFS{}.SyncDir(filepath.Dir(path))
+}
 
-	// Create the ExecuteCopy function that will be called by the TUI
-	executeCopy := func(plan domain.CopyPlan, includeOverrides bool) tea.Cmd {
-		return func() tea.Msg {
-			// Ensure target directory exists
-			if err := filesystem.MkdirAll(cfg.TargetDir, 0o755); err != nil {
-				return tui.ErrorMsg{Err: appErrors.Wrap(appErrors.IOFailure, "mkdir", cfg.TargetDir, err)}
-			}
+// thumbnailRows is how many lines of the terminal a thumbnail is drawn
+// over, and thumbnailSize the pixels it is scaled down to first.
+const (
+	thumbnailRows = 8
+	thumbnailSize = 160
+)
 
-			// Execute the copy with progress callback
-			executor := app.Executor{
-				FS:     filesystem,
-				Logger: logger,
-				OnProgress: func(current, total int, currentFile string) {
-					pMu.Lock()
-					prog := p
-					pMu.Unlock()
-					if prog != nil {
-						prog.Send(tui.CopyProgressMsg{
-							Current: current,
-							Total:   total,
-							File:    currentFile,
-						})
-					}
-				},
+// loadThumbnail reads the EXIF thumbnail of a file and encodes it for the
+// terminal. Files without one, or with one that does not decode, answer
+// with an empty image so the preview says there is none.
+func loadThumbnail(protocol termimage.Protocol) tui.ThumbnailFunc {
+	return func(path string) tea.Cmd {
+		return func() tea.Msg {
+			msg := tui.ThumbnailMsg{Path: path, Rows: thumbnailRows}
+			data, err := exif.Reader{}.Thumbnail(context.Background(), path)
+			if err != nil {
+				return msg
 			}
-
-			if err := executor.Execute(ctx, plan, includeOverrides); err != nil {
-				return tui.ErrorMsg{Err: appErrors.Wrap(appErrors.IOFailure, "copy", cfg.TargetDir, err)}
+			img, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				return msg
 			}
-
-			// Signal copy is done
-			overrides := 0
-			if includeOverrides {
-				overrides = len(plan.OverrideItems)
+			if seq, err := termimage.Encode(protocol, termimage.Fit(img, thumbnailSize, thumbnailSize), thumbnailRows); err == nil {
+				msg.Image = seq
 			}
-			return tui.CopyDoneMsg{OverridesConfirmed: overrides}
+			return msg
 		}
 	}
+}
 
-	// Create TUI config with the ExecuteCopy callback
-	tuiConfig := tui.Config{
-		SourceDir:   cfg.SourceDir,
-		TargetDir:   cfg.TargetDir,
-		DryRun:      cfg.DryRun,
-		Verbose:     cfg.Verbose,
-		ExecuteCopy: executeCopy,
+// sourceWarning explains why the source looks like an organized archive
+// rather than a camera card, or is empty when it does not, when a saved plan
+// is copied or when --no-source-heuristics is set.
+func sourceWarning(cfg config.Config, opts cliOptions) string {
+	if opts.noHeuristics || opts.onboarding || opts.savedPlan != nil {
+		return ""
 	}
+	names, err := fs.DirNames(cfg.SourceDir)
+	if err != nil {
+		return ""
+	}
+	layout := domain.InspectSourceLayout(names)
+	if !layout.LooksOrganized() {
+		return ""
+	}
+	return layout.Summary()
+}
 
-	// Create the TUI model and program
-	m := tui.NewModel(tuiConfig)
-	pMu.Lock()
-	p = tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx))
-	pMu.Unlock()
-
-	// Create planner with progress callback
+// planOrLoad returns the plan loaded by --plan-in or scans the source.
+func planOrLoad(ctx context.Context, cfg config.Config, opts cliOptions, logger logging.Logger, onProgress app.ProgressFunc) (domain.CopyPlan, error) {
+	if opts.savedPlan != nil {
+		return opts.savedPlan.Plan, nil
+	}
+	limits := targetPathLimits(cfg, logger)
+	spill := &warningLog{}
+	defer spill.Close()
 	planner := app.Planner{
-		FS:            filesystem,
-		Exif:          exifReader,
+		FS:            fs.OSFS{},
+		Exif:          exif.Reader{DateTags: cfg.DateTags},
+		ExifWorkers:   cfg.ExifWorkers,
 		Logger:        logger,
 		AllowOverride: cfg.Override,
-		OnProgress: func(current, total int) {
-			p.Send(tui.ScanProgressMsg{Current: current, Total: total})
-		},
+		Layout:        cfg.Layout,
+		Sniff:         cfg.Sniff,
+		CheckTimezone: cfg.CheckTimezone,
+		TimeZone:      cfg.Layout.Days.Zone,
+		DateFloor:     cfg.DateFloor,
+		DirDates:      cfg.DirDates,
+		InclusiveEnd:  cfg.InclusiveEnd,
+		History:       importHistory(cfg, logger),
+		Dedupe:        cfg.Dedupe,
+		Fast:          cfg.FastPlan,
+		WarningSpill:  spill,
+		OnProgress:    onProgress,
+
+		CompanionGlobs: cfg.CompanionGlobs,
+		IncludeMisc:    cfg.IncludeMisc,
+		KeepJunk:       cfg.KeepJunk,
+		PathLimits:     limits,
+		SourceVolume:   sourceVolume(cfg, logger),
+		TargetWorkers:  targetWorkers(cfg, limits),
+		IncludeProxies: cfg.IncludeProxies,
+		Paths:          cfg.Paths,
+
+		AutoOverrideStale: cfg.AutoOverrideStale,
+		DedupeHash:        cfg.DedupeHash,
+	}
+	return planner.Plan(ctx, cfg.SourceDir, cfg.TargetDir, cfg.StartDate, cfg.EndDate)
+}
+
+// copyFailed wraps the error a copy into target ended with. A full target
+// gets a hint with the space missing; rerunning copies what is left.
+func copyFailed(target string, err error) error {
+	var diskFull *app.DiskFullError
+	if !errors.As(err, &diskFull) {
+		return appErrors.Wrap(appErrors.IOFailure, "copy", target, err)
 	}
+	hint := "free up space and run the same command again to copy the remaining files"
+	if missing := diskFull.Missing(); missing > 0 {
+		hint = fmt.Sprintf("free up at least %s and run the same command again to copy the remaining files", format.Bytes(missing))
+	}
+	return appErrors.WithHint(appErrors.IOFailure, "copy", target, hint, err)
+}
 
-	// Run planning in background
-	go func() {
-		plan, planErr := planner.Plan(ctx, cfg.SourceDir, cfg.TargetDir, cfg.StartDate, cfg.EndDate)
-		if planErr != nil {
-			p.Send(tui.ErrorMsg{Err: appErrors.Wrap(appErrors.Internal, "plan", cfg.SourceDir, planErr)})
-			return
+// finishExecution records the outcome of an execution of plan in the
+// target: the manifest when enabled, with the decisions of the warnings
+// review and the source volume, and, for fully successful runs, the latest
+// link. It returns execErr or the first error of these steps.
+func finishExecution(cfg config.Config, plan domain.CopyPlan, result domain.ExecutionResult, execErr error) error {
+	err := execErr
+	if cfg.Manifest {
+		m := manifest.FromResult(cfg.SourceDir, cfg.TargetDir, result, time.Now())
+		m.Label = cfg.Layout.Label
+		m.Review = plan.Reviewed
+		if !plan.SourceVolume.IsZero() {
+			volume := plan.SourceVolume
+			m.SourceVolume = &volume
+		}
+		if plan.Scan.Files > 0 {
+			scan := plan.Scan
+			m.Scan = &scan
+		}
+		// The executor hashed the sources with it while copying
+		m.HashAlgorithm = cfg.ManifestHash
+		path, writeErr := manifest.Write(cfg.TargetDir, m)
+		if writeErr == nil && cfg.Fsync {
+			writeErr = syncMeta(path)
+		}
+		if writeErr != nil && err == nil {
+			err = writeErr
 		}
-		p.Send(tui.PlanReadyMsg{Plan: plan})
-	}()
+	}
+	if cfg.Fsync && err == nil {
+		// The journal file is synced on every append, its entry is not
+		if syncErr := syncMeta(journal.Path(cfg.TargetDir)); syncErr != nil && !errors.Is(syncErr, os.ErrNotExist) {
+			err = syncErr
+		}
+	}
+	if cfg.LatestLink == "" || err != nil || result.Failed > 0 {
+		return err
+	}
+	if dir := result.LatestTargetDir(); dir != "" && dir != filepath.Clean(cfg.TargetDir) {
+		if linkErr := fs.UpdateLatestLink(cfg.TargetDir, cfg.LatestLink, dir); linkErr != nil {
+			return fmt.Errorf("update %s link: %w", cfg.LatestLink, linkErr)
+		}
+	}
+	return nil
+}
 
-	// Run the TUI
-	finalModel, err := p.Run()
+// newJournal returns the journal writer of the run runID of plan with cfg.
+// Runs that leave newer files on the card are journaled as partial, so
+// they do not move its watermark.
+func newJournal(cfg config.Config, runID string, plan domain.CopyPlan) journal.Writer {
+	return journal.Writer{
+		RunID:        runID,
+		ConfigDigest: cfg.Digest(),
+		SourceDir:    cfg.SourceDir,
+		TargetDir:    cfg.TargetDir,
+		Label:        cfg.Layout.Label,
+		SourceVolume: plan.SourceVolume,
+		Partial:      plan.Partial || len(cfg.Paths.Include) > 0 || cfg.EndDate != nil,
+	}
+}
+
+// copyHash is the algorithm sources are hashed with while they are
+// copied, the one the manifest records; empty without --manifest and
+// --verify.
+func copyHash(cfg config.Config) hash.Algorithm {
+	if !cfg.Manifest && !cfg.Verify {
+		return ""
+	}
+	return cfg.ManifestHash
+}
+
+// stampRun is the run id copies are stamped with, empty without
+// --stamp-xattr.
+func stampRun(cfg config.Config, runJournal journal.Writer) string {
+	if !cfg.StampXattr {
+		return ""
+	}
+	return runJournal.RunID
+}
+
+// importHistory returns the journal of the target to compare the plan
+// against, or nil without earlier imports.
+func importHistory(cfg config.Config, logger logging.Logger) app.History {
+	history, err := journal.LoadHistory(cfg.TargetDir)
 	if err != nil {
-		return appErrors.Wrap(appErrors.Internal, "tui", "", err)
+		logger.Verbosef("Not comparing with earlier imports: %v", err)
+		return nil
+	}
+	if history.LastImport().IsZero() {
+		return nil
 	}
+	return history
+}
 
-	final := finalModel.(tui.Model)
+// probeVolume identifies the volume of a directory; replaced in tests.
+var probeVolume = fs.ProbeVolume
 
-	// If there was an error in the TUI, return it
-	if final.Phase == tui.PhaseError && final.Err != nil {
-		return final.Err
+// sourceVolume returns the identity of the source's volume, or none when it
+// cannot be probed.
+func sourceVolume(cfg config.Config, logger logging.Logger) domain.Volume {
+	volume, err := probeVolume(cfg.SourceDir)
+	if err != nil {
+		logger.Verbosef("Not recording the source volume: %v", err)
+		return domain.Volume{}
+	}
+	if !volume.IsZero() {
+		logger.Verbosef("The source is volume %q, serial %q", volume.Label, volume.Serial)
+	}
+	return volume
+}
+
+// targetPathLimits returns the name and path limits of the target's file
+// system, or none when they cannot be detected.
+func targetPathLimits(cfg config.Config, logger logging.Logger) domain.PathLimits {
+	limits, err := fs.ProbePathLimits(cfg.TargetDir)
+	if err != nil {
+		logger.Verbosef("Not checking target path lengths: %v", err)
+		return domain.PathLimits{}
+	}
+	name := limits.FileSystem
+	if name == "" {
+		name = "an unknown file system"
+	}
+	logger.Verbosef("The target is on %s, names up to %d %s", name, limits.MaxName, limits.NameUnit)
+	return limits
+}
+
+// networkTargetWorkers is how many existence checks run at once on network
+// targets by default. They wait on round trips rather than the CPU, so
+// more of them overlap than there are CPUs.
+const networkTargetWorkers = 16
+
+// targetWorkers returns the --target-workers count, or the default for the
+// target's file system when it is not set; 0 is one per CPU.
+func targetWorkers(cfg config.Config, limits domain.PathLimits) int {
+	if cfg.TargetWorkers > 0 || !fs.IsNetworkFamily(limits.FileSystem) {
+		return cfg.TargetWorkers
+	}
+	return max(networkTargetWorkers, runtime.NumCPU())
+}
+
+// linkIndex returns the content hashes of the files in the target that
+// copies may be hard linked to with --link-dupes, and nil without it.
+func linkIndex(cfg config.Config, logger logging.Logger) map[string]string {
+	if !cfg.LinkDupes {
+		return nil
+	}
+	index, err := manifest.HashIndex(cfg.TargetDir, cfg.ManifestHash)
+	if err != nil {
+		logger.Verbosef("Copying instead of linking duplicates: %v", err)
+		return nil
+	}
+	logger.Verbosef("Found %d files to link duplicates to in the manifests", len(index))
+	return index
+}
+
+// logHashRates reports how fast content was hashed during the run, per
+// algorithm, and the instructions that sped it up.
+func logHashRates(logger logging.Logger) {
+	for _, stats := range hash.Report() {
+		accel := "generic"
+		if name := stats.Algorithm.Acceleration(); name != "" {
+			accel = name
+		}
+		logger.Verbosef("Hashed %s with %s at %s (%s)", format.Bytes(stats.Bytes), stats.Algorithm, format.Rate(stats.Rate()), accel)
+	}
+}
+
+// writeTrace writes the spans of the run to path for --trace. The run is
+// over by then, so a failure is only reported.
+func writeTrace(path string, recorder *trace.Recorder) {
+	recorder.NameTrack(0, "phopy")
+	if err := recorder.WriteFile(path); err != nil {
+		fmt.Fprintln(os.Stderr, appErrors.UserMessage(appErrors.Wrap(appErrors.IOFailure, "write trace", path, err)))
+	}
+}
+
+// lockTarget takes the execution lock of the target unless --no-lock is set.
+// The returned function releases it.
+func lockTarget(cfg config.Config) (func(), error) {
+	if cfg.NoLock {
+		return func() {}, nil
+	}
+	lock, err := fs.AcquireLock(cfg.TargetDir)
+	if err != nil {
+		var locked *fs.LockedError
+		if errors.As(err, &locked) {
+			return nil, appErrors.WithHint(appErrors.IOFailure, "lock", cfg.TargetDir, "another phopy run is copying into this target; wait for it to finish or pass --no-lock", err)
+		}
+		return nil, appErrors.Wrap(appErrors.IOFailure, "lock", cfg.TargetDir, err)
+	}
+	return func() { _ = lock.Release() }, nil
+}
+
+// answerOverrides decides whether count existing files may be overwritten,
+// from --yes or --no or else by asking on r. Without a terminal to ask on
+// only an explicit --confirm-default can answer, and the copy is refused
+// before anything is copied otherwise.
+func answerOverrides(r io.Reader, w io.Writer, cfg config.Config, count int, interactive bool) (bool, error) {
+	if count == 0 {
+		return false, nil
+	}
+	if cfg.AnswerOverrides != "" {
+		return cfg.AnswerOverrides == "yes", nil
+	}
+	if !interactive {
+		if cfg.Unattended == "" {
+			return false, appErrors.WithHint(appErrors.InvalidConfig, "confirm", cfg.TargetDir,
+				"pass --yes to overwrite them or --no to skip them",
+				fmt.Errorf("%d files already exist in the target and there is no terminal to ask whether to overwrite them", count))
+		}
+		return cfg.Unattended == "yes", nil
+	}
+	confirmed, err := confirmOverrides(r, w, count, cfg.ConfirmDefault)
+	if err != nil {
+		return false, appErrors.Wrap(appErrors.Internal, "confirm", "", err)
+	}
+	return confirmed, nil
+}
+
+// confirmOverrides asks on r whether existing files may be overwritten. An
+// empty answer picks confirmDefault; with "none" the question is repeated.
+func confirmOverrides(r io.Reader, w io.Writer, count int, confirmDefault string) (bool, error) {
+	choices := "[y/N]"
+	switch confirmDefault {
+	case "yes":
+		choices = "[Y/n]"
+	case "none":
+		choices = "[y/n]"
+	}
+
+	input := bufio.NewReader(r)
+	for {
+		fmt.Fprintf(w, "Override %d existing files? %s ", count, choices)
+		answer, err := input.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		case "":
+			if confirmDefault != "none" {
+				return confirmDefault == "yes", nil
+			}
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// askLabel reads the label of the import from r, asking again until it is
+// usable in file names. An empty answer means no label.
+func askLabel(r io.Reader, w io.Writer) (string, error) {
+	input := bufio.NewReader(r)
+	for {
+		fmt.Fprint(w, "Label (empty for none): ")
+		answer, err := input.ReadString('\n')
+		label := strings.TrimSpace(answer)
+		validErr := domain.ValidateLabel(label)
+		if validErr == nil {
+			return label, nil
+		}
+		if err != nil {
+			return "", validErr
+		}
+		fmt.Fprintln(w, validErr)
+	}
+}
+
+// confirmHazards lists the hazards of cfg and asks whether to copy anyway.
+// Without a terminal to ask on it fails instead.
+func confirmHazards(r io.Reader, w io.Writer, cfg config.Config, interactive bool) error {
+	if len(cfg.Hazards) == 0 {
+		return nil
+	}
+	refused := appErrors.WithHint(appErrors.InvalidConfig, "safety check", cfg.TargetDir,
+		"pass --i-know-what-im-doing to copy anyway", errors.New(strings.Join(cfg.Hazards, "; ")))
+	if !interactive {
+		return refused
+	}
+
+	fmt.Fprintln(w, "Warning:")
+	for _, hazard := range cfg.Hazards {
+		fmt.Fprintln(w, "- "+hazard)
+	}
+	input := bufio.NewReader(r)
+	for {
+		fmt.Fprint(w, "Copy anyway? [y/N] ")
+		answer, err := input.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return nil
+		case "", "n", "no":
+			return refused
+		}
+		if err != nil {
+			return refused
+		}
+	}
+}
+
+// isTerminal reports whether f is attached to a terminal. Other character
+// devices like /dev/null, the stdin of cron jobs, are not.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(f.Fd())
+}
+
+// prepareConfig builds the config and checks that the run can succeed
+// before anything is scanned.
+func prepareConfig(opts config.Options) (config.Config, error) {
+	cfg, err := config.FromOptions(opts)
+	if err != nil {
+		return config.Config{}, appErrors.Wrap(appErrors.InvalidConfig, "config", "", err)
+	}
+
+	if err := checkSource(cfg.SourceDir, cfg.Sniff); err != nil {
+		return config.Config{}, err
+	}
+
+	// Fail before scanning when the copy could never succeed
+	if !cfg.DryRun {
+		if err := checkTargetWritable(cfg.TargetDir); err != nil {
+			return config.Config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// defaultTarget is the target proposed by the first-run setup.
+func defaultTarget() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "Pictures", "phopy")
+}
+
+// forwardEvents translates planner and executor events into TUI messages.
+// Warnings are part of the plan and the copy outcome is returned by the
+// ExecuteCopy command, so neither is forwarded here. ready sees every
+// plan before the TUI does and turns it into the message the TUI gets; its
+// error is shown instead of the plan. failed turns planner errors into the
+// error the TUI shows, see planError.
+func forwardEvents(ctx context.Context, p *tea.Program, events <-chan app.Event, failed func(error) error, ready func(domain.CopyPlan) (tui.PlanReadyMsg, error)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-events:
+			switch ev := ev.(type) {
+			case app.ScanProgressEvent:
+				p.Send(tui.ScanProgressMsg{Current: ev.Current, Total: ev.Total})
+			case app.CopyProgressEvent:
+				p.Send(tui.CopyProgressMsg{Current: ev.Current, Total: ev.Total, File: ev.File})
+			case app.FileProgressEvent:
+				p.Send(tui.CopyBytesMsg{File: ev.File, Written: ev.Written, Size: ev.Size, BytesDone: ev.BytesDone, BytesTotal: ev.BytesTotal})
+			case app.PlanDoneEvent:
+				if ev.Err != nil {
+					p.Send(tui.ErrorMsg{Err: failed(ev.Err)})
+					continue
+				}
+				msg, err := ready(ev.Plan)
+				if err != nil {
+					p.Send(tui.ErrorMsg{Err: err})
+					continue
+				}
+				p.Send(msg)
+			case app.ConflictEvent:
+				p.Send(tui.ConflictMsg{Item: ev.Item, Reply: ev.Reply})
+			}
+		}
+	}
+}
+
+// planError classifies a planner error: target paths too long for the
+// target are the configuration's fault, anything else is internal.
+func planError(cfg config.Config, err error) error {
+	var limitErr *domain.PathLimitError
+	if errors.As(err, &limitErr) {
+		return appErrors.Wrap(appErrors.InvalidConfig, "plan", cfg.TargetDir, err)
+	}
+	return appErrors.Wrap(appErrors.Internal, "plan", cfg.SourceDir, err)
+}
+
+// writeLeftovers writes the --leftovers report of plan, if asked for.
+func writeLeftovers(cfg config.Config, plan domain.CopyPlan) error {
+	if cfg.Leftovers == "" {
+		return nil
+	}
+	if err := manifest.WriteLeftovers(cfg.Leftovers, plan.Leftovers); err != nil {
+		return appErrors.Wrap(appErrors.IOFailure, "write leftovers", cfg.Leftovers, err)
+	}
+	return nil
+}
+
+// writeScript writes the --export-script script of plan, if asked for.
+func writeScript(cfg config.Config, plan domain.CopyPlan) error {
+	if cfg.ExportScript == "" {
+		return nil
+	}
+	format := presentation.ScriptPOSIX
+	if cfg.ScriptPowerShell {
+		format = presentation.ScriptPowerShell
+	}
+	file, err := os.Create(cfg.ExportScript)
+	if err == nil {
+		err = presentation.WriteScript(file, plan, format, time.Now())
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return appErrors.Wrap(appErrors.IOFailure, "export script", cfg.ExportScript, err)
 	}
+	return nil
+}
+
+// printCompletionSummary prints the execution outcome once the alt screen is
+// gone and turns failed items into a non-zero exit.
+func printCompletionSummary(w io.Writer, result domain.ExecutionResult, targetDir string) error {
+	printer := presentation.Printer{Writer: w}
+	printer.PrintResult(result)
 
+	if result.Failed > 0 {
+		return appErrors.Wrap(appErrors.IOFailure, "copy", targetDir, fmt.Errorf("%d files failed to copy", result.Failed))
+	}
 	return nil
 }
 
+// checkTargetWritable probes the target (or its nearest existing ancestor)
+// so an unwritable target is reported before the user sits through a scan.
+func checkTargetWritable(target string) error {
+	err := fs.ProbeWritable(target)
+	if err == nil {
+		return nil
+	}
+	hint := "the target directory cannot be written to; choose another --target"
+	if errors.Is(err, os.ErrPermission) {
+		hint = "the target directory is not writable; check its permissions or choose another --target"
+	}
+	return appErrors.WithHint(appErrors.IOFailure, "probe", target, hint, err)
+}
+
+// archiveExtensions are card dumps phopy cannot read without extracting.
+var archiveExtensions = []string{".zip", ".tar", ".tgz", ".gz", ".7z", ".rar", ".dmg", ".iso"}
+
+// checkSource verifies that the source exists and is a folder or a photo
+// phopy copies; any file passes with sniff, which classifies by content.
+func checkSource(source string, sniff bool) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && strings.ContainsAny(filepath.Base(source), "*?[") {
+			return appErrors.WithHint(appErrors.NotFound, "stat", source, "patterns are not expanded; pass the folder with the photos as --source", err)
+		}
+		return appErrors.Wrap(appErrors.NotFound, "stat", source, err)
+	}
+	ext := strings.ToLower(filepath.Ext(source))
+	if info.IsDir() || sniff || domain.IsRawExtension(ext) || domain.IsJpegExtension(ext) {
+		return nil
+	}
+	if slices.Contains(archiveExtensions, ext) {
+		return appErrors.WithHint(appErrors.InvalidConfig, "stat", source, "extract the archive first and pass the extracted folder as --source",
+			fmt.Errorf("the source is a %s archive", ext))
+	}
+	supported := strings.Join(append(slices.Clone(domain.RawExtensions), domain.JpegExtensions...), ", ")
+	if ext == "" {
+		return appErrors.WithHint(appErrors.InvalidConfig, "stat", source, "pass a folder, or --sniff to detect the type from the content",
+			fmt.Errorf("the source file has no extension, supported are %s", supported))
+	}
+	return appErrors.WithHint(appErrors.InvalidConfig, "stat", source, "pass a folder or a photo as --source",
+		fmt.Errorf("%s files are not supported as source, supported are %s", ext, supported))
+}
+
 func newCompletionCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:       "completion [bash|zsh|fish|powershell]",
@@ -249,7 +2110,21 @@ func newVersionCmd() *cobra.Command {
 	}
 }
 
+func newWarningsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "warnings",
+		Short: "List the codes of the warnings phopy reports",
+		Long:  "List the stable codes of the warnings phopy reports. Saved plans (--plan-out) carry them next to the text, so scripts can tell warnings apart without matching sentences.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, code := range domain.WarningCodes {
+				fmt.Printf("%-22s %s\n", code.Code, code.Description)
+			}
+		},
+	}
+}
+
 func exitWithError(err error) {
 	fmt.Fprintln(os.Stderr, appErrors.UserMessage(err))
-	os.Exit(1)
+	os.Exit(appErrors.ExitCode(err))
 }
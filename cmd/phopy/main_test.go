@@ -0,0 +1,1090 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"phopy/internal/app"
+	"phopy/internal/config"
+	"phopy/internal/domain"
+	appErrors "phopy/internal/errors"
+	"phopy/internal/journal"
+	"phopy/internal/logging"
+	"phopy/internal/manifest"
+	"phopy/internal/planfile"
+	"phopy/internal/tui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCheckTargetWritableRejectsReadOnlyTarget(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+	target := t.TempDir()
+	if err := os.Chmod(target, 0o555); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(target, 0o755) })
+
+	err := checkTargetWritable(filepath.Join(target, "2024"))
+	if err == nil {
+		t.Fatalf("expected an error for a read-only target")
+	}
+	msg := appErrors.UserMessage(err)
+	if !strings.Contains(msg, "not writable") || !strings.Contains(msg, "--target") {
+		t.Fatalf("unexpected message: %q", msg)
+	}
+}
+
+func TestCheckTargetWritableRejectsFileAncestor(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "archive")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	err := checkTargetWritable(filepath.Join(file, "photos"))
+	if err == nil {
+		t.Fatalf("expected an error when an ancestor is a file")
+	}
+	if !strings.Contains(appErrors.UserMessage(err), "Hint:") {
+		t.Fatalf("expected a hint in %q", appErrors.UserMessage(err))
+	}
+}
+
+func TestCheckSourceRejectsUnsupportedFiles(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"notes.txt", []string{"Invalid configuration", ".txt files are not supported", ".arw", ".jpeg", "Hint:"}},
+		{"card-dump.zip", []string{"Invalid configuration", ".zip archive", "Hint: extract the archive first"}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte("not a photo"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		err := checkSource(path, false)
+		if appErrors.ExitCode(err) != appErrors.ExitInvalidConfig {
+			t.Fatalf("%s: expected an invalid configuration, got %v", tt.name, err)
+		}
+		msg := appErrors.UserMessage(err)
+		for _, want := range tt.want {
+			if !strings.Contains(msg, want) {
+				t.Errorf("%s: expected %q in %q", tt.name, want, msg)
+			}
+		}
+	}
+}
+
+func TestCheckSourceAcceptsFoldersAndPhotos(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"DSC0001.ARW", "DSC0002.jpg", "DSC0003"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	for _, path := range []string{dir, filepath.Join(dir, "DSC0001.ARW"), filepath.Join(dir, "DSC0002.jpg")} {
+		if err := checkSource(path, false); err != nil {
+			t.Errorf("expected %s to be accepted, got %v", path, err)
+		}
+	}
+	if err := checkSource(filepath.Join(dir, "DSC0003"), true); err != nil {
+		t.Errorf("expected any file to be accepted with --sniff, got %v", err)
+	}
+}
+
+func TestCheckSourceExplainsUnexpandedPatterns(t *testing.T) {
+	pattern := filepath.Join(t.TempDir(), "DCIM", "*.ARW")
+	err := checkSource(pattern, false)
+	msg := appErrors.UserMessage(err)
+	if !strings.Contains(msg, "Path not found") || !strings.Contains(msg, "Hint: patterns are not expanded") {
+		t.Fatalf("unexpected message: %q", msg)
+	}
+}
+
+func TestCheckTargetWritableAcceptsMissingTarget(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "new", "archive")
+	if err := checkTargetWritable(target); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(filepath.Dir(target)))
+	if len(entries) != 0 {
+		t.Fatalf("expected the probe file to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestConfirmOverridesUsesDefaultOnEmptyAnswer(t *testing.T) {
+	tests := []struct {
+		input, def string
+		want       bool
+	}{
+		{input: "\n", def: "no", want: false},
+		{input: "\n", def: "yes", want: true},
+		{input: "\ny\n", def: "none", want: true},
+		{input: "n\n", def: "yes", want: false},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		got, err := confirmOverrides(strings.NewReader(tt.input), &out, 3, tt.def)
+		if err != nil {
+			t.Fatalf("%q/%s: unexpected error: %v", tt.input, tt.def, err)
+		}
+		if got != tt.want {
+			t.Fatalf("%q/%s: got %v, want %v", tt.input, tt.def, got, tt.want)
+		}
+	}
+}
+
+func TestAskLabelRepeatsUntilValid(t *testing.T) {
+	var out strings.Builder
+	label, err := askLabel(strings.NewReader("iceland/day3\n iceland-day3 \n"), &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if label != "iceland-day3" {
+		t.Fatalf("unexpected label %q", label)
+	}
+	if strings.Count(out.String(), "Label (empty for none):") != 2 {
+		t.Fatalf("expected the question to be repeated, got %q", out.String())
+	}
+}
+
+// runCLI executes phopy with args and returns what it printed to stdout.
+func runCLI(t *testing.T, args ...string) string {
+	t.Helper()
+	return captureStdout(t, func() {
+		cmd := newRootCmd()
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("phopy %s: %v", strings.Join(args, " "), err)
+		}
+	})
+}
+
+// captureStdout returns what fn printed to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	out, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatalf("create stdout: %v", err)
+	}
+	defer out.Close()
+
+	stdout := os.Stdout
+	os.Stdout = out
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatalf("read stdout: %v", err)
+	}
+	return string(data)
+}
+
+// cardFixture creates a small memory card and isolates the run from the
+// environment and any saved profile.
+func cardFixture(t *testing.T) (source, target string) {
+	t.Helper()
+	isolate(t)
+
+	source = t.TempDir()
+	target = filepath.Join(t.TempDir(), "archive")
+	taken := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	for i, name := range []string{"DSC0001.ARW", "DSC0001.JPG", "DSC0002.JPG", "DSC0003.ARW"} {
+		path := filepath.Join(source, "DCIM", "100MSDCF", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		mtime := taken.Add(time.Duration(i) * 24 * time.Hour)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	return source, target
+}
+
+// exifJPEG returns a minimal JPEG whose EXIF records the capture as
+// DateTimeOriginal (e.g. "2024:03:31 11:30:00") and OffsetTimeOriginal
+// (e.g. "+01:00").
+func exifJPEG(taken, offset string) []byte {
+	le := binary.LittleEndian
+	entry := func(b []byte, tag, typ uint16, count, value uint32) []byte {
+		b = le.AppendUint16(b, tag)
+		b = le.AppendUint16(b, typ)
+		b = le.AppendUint32(b, count)
+		return le.AppendUint32(b, value)
+	}
+	// IFD0 at 8 points to the EXIF IFD at 26, whose values follow it at 56
+	tiff := le.AppendUint32([]byte("II*\x00"), 8)
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = entry(tiff, 0x8769, 4, 1, 26)
+	tiff = le.AppendUint32(tiff, 0)
+	tiff = le.AppendUint16(tiff, 2)
+	tiff = entry(tiff, 0x9003, 2, uint32(len(taken)+1), 56)
+	tiff = entry(tiff, 0x9011, 2, uint32(len(offset)+1), uint32(56+len(taken)+1))
+	tiff = le.AppendUint32(tiff, 0)
+	tiff = append(append(tiff, taken...), 0)
+	tiff = append(append(tiff, offset...), 0)
+
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	jpeg = binary.BigEndian.AppendUint16(jpeg, uint16(2+6+len(tiff)))
+	jpeg = append(append(jpeg, "Exif\x00\x00"...), tiff...)
+	return append(jpeg, 0xFF, 0xD9)
+}
+
+func TestCheckTimezoneComparesInTheGivenZone(t *testing.T) {
+	isolate(t)
+	source := t.TempDir()
+	// 10:30 UTC, past midnight only fourteen hours ahead
+	path := filepath.Join(source, "DCIM", "100MSDCF", "DSC0001.JPG")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, exifJPEG("2024:03:31 11:30:00", "+01:00"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	out := runCLI(t, "plan", "-s", source, "-t", t.TempDir(), "--timezone", "+14:00", "--check-timezone", "--layout", "{date}", "--flatten", "--show-all")
+	if !strings.Contains(out, "2024-04-01") {
+		t.Fatalf("expected the photo filed on 2024-04-01 in +14:00, got %q", out)
+	}
+	if strings.Contains(out, "another date") {
+		t.Fatalf("expected no zone warning when the folder follows --timezone, got %q", out)
+	}
+}
+
+func TestEntryPointsPlanIdentically(t *testing.T) {
+	source, target := cardFixture(t)
+	flags := []string{"-s", source, "-t", target, "--layout", "{yyyy}/{date}", "--flatten", "--show-all"}
+
+	root := runCLI(t, append([]string{"--dry-run", "--plain"}, flags...)...)
+	plan := runCLI(t, append([]string{"plan"}, flags...)...)
+	copyDry := runCLI(t, append([]string{"copy", "--dry-run", "--plain"}, flags...)...)
+
+	if !strings.Contains(root, "DRY-RUN: would copy 3 files") {
+		t.Fatalf("unexpected root plan:\n%s", root)
+	}
+	if plan != root {
+		t.Fatalf("plan differs from the root command:\n%s\nvs\n%s", plan, root)
+	}
+	if copyDry != root {
+		t.Fatalf("copy differs from the root command:\n%s\nvs\n%s", copyDry, root)
+	}
+}
+
+func TestCopyExecutesSavedPlan(t *testing.T) {
+	source, target := cardFixture(t)
+	planPath := filepath.Join(t.TempDir(), "card.json")
+	runCLI(t, "plan", "-s", source, "-t", target, "--layout", "{date}", "--flatten", "--quiet", "--plan-out", planPath)
+
+	saved, err := planfile.Read(planPath)
+	if err != nil {
+		t.Fatalf("read plan: %v", err)
+	}
+	if saved.SourceDir != source || saved.TargetDir != target || len(saved.Plan.Items) != 3 {
+		t.Fatalf("unexpected saved plan: %+v", saved)
+	}
+
+	// The layout of the saved plan wins over the flags of copy; the safety
+	// check is skipped since tests may run as root
+	runCLI(t, "copy", "--plan-in", planPath, "--quiet", "--i-know-what-im-doing")
+	for _, item := range saved.Plan.Items {
+		if _, err := os.Stat(item.TargetPath); err != nil {
+			t.Fatalf("expected %s to be copied: %v", item.TargetPath, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(target, "DCIM")); err == nil {
+		t.Fatalf("did not expect the source layout in the target")
+	}
+}
+
+func TestQuitAtTheConfirmationAbortsWithAMessage(t *testing.T) {
+	item := domain.CopyItem{FileMeta: domain.FileMeta{Name: "DSC0001.ARW", IsRAW: true}, Exists: true}
+	model := tui.NewModel(tui.Config{SourceDir: "/source", TargetDir: "/target"})
+	next, _ := model.Update(tui.PlanReadyMsg{Plan: domain.CopyPlan{Items: []domain.CopyItem{item}, OverrideItems: []domain.CopyItem{item}, RawCount: 1}})
+	next, _ = next.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+
+	var out bytes.Buffer
+	err := tuiOutcome(&out, next.(tui.Model), config.Config{TargetDir: "/target"}, cliOptions{})
+	if err == nil || appErrors.ExitCode(err) != appErrors.ExitAborted {
+		t.Fatalf("expected an abort with exit code %d, got %v", appErrors.ExitAborted, err)
+	}
+	if msg := appErrors.UserMessage(err); !strings.HasPrefix(msg, "Aborted at the override confirmation: nothing was copied\nHint: answer n") {
+		t.Fatalf("unexpected message %q", msg)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no completion summary, got %q", out.String())
+	}
+}
+
+func TestDiskFullExitsWithAResumeHint(t *testing.T) {
+	diskFull := &app.DiskFullError{Copied: 2, Remaining: 3, RemainingBytes: 3 << 20, Free: 1 << 20, Err: syscall.ENOSPC}
+	err := copyFailed("/target", diskFull)
+	model := tui.NewModel(tui.Config{SourceDir: "/source", TargetDir: "/target"})
+	next, _ := model.Update(tui.DiskFullMsg{Remaining: 3, RemainingBytes: diskFull.RemainingBytes, Missing: diskFull.Missing(), Err: err})
+
+	var out bytes.Buffer
+	err = tuiOutcome(&out, next.(tui.Model), config.Config{TargetDir: "/target"}, cliOptions{})
+	if err == nil || appErrors.ExitCode(err) != appErrors.ExitFailure {
+		t.Fatalf("expected a failure, got %v", err)
+	}
+	if msg := appErrors.UserMessage(err); !strings.Contains(msg, "Hint: free up at least 2.0 MiB and run the same command again") {
+		t.Fatalf("unexpected message %q", msg)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no completion summary, got %q", out.String())
+	}
+}
+
+func TestPlanDiffComparesTwoLayouts(t *testing.T) {
+	source, target := cardFixture(t)
+	dir := t.TempDir()
+	oldPath, newPath := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	runCLI(t, "plan", "-s", source, "-t", target, "--quiet", "--plan-out", oldPath)
+	runCLI(t, "plan", "-s", source, "-t", target, "--layout", "{date}", "--flatten", "--quiet", "--plan-out", newPath)
+
+	out := runCLI(t, "plan", "diff", oldPath, newPath)
+	if !strings.HasPrefix(out, "Moved 3 files:\n") || strings.Contains(out, "Only in") {
+		t.Fatalf("expected every file moved and none added or removed, got:\n%s", out)
+	}
+
+	var diff domain.PlanDiff
+	if err := json.Unmarshal([]byte(runCLI(t, "plan", "diff", "--json", newPath, newPath)), &diff); err != nil {
+		t.Fatalf("expected JSON: %v", err)
+	}
+	if !diff.Empty() {
+		t.Fatalf("expected a plan to equal itself, got %+v", diff)
+	}
+}
+
+func TestLeftoversAndManifestAccountForEveryFile(t *testing.T) {
+	source, target := cardFixture(t)
+	card := filepath.Join(source, "DCIM", "100MSDCF")
+	for _, name := range []string{"DSC0003.xmp", "C0001.MP4", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(card, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	leftoversPath := filepath.Join(t.TempDir(), "leftovers.tsv")
+
+	// DSC0001.ARW is dated the day before --from, DSC0001.JPG pairs with it
+	runCLI(t, "copy", "-s", source, "-t", target, "--from", "2024-10-03", "--manifest", "--leftovers", leftoversPath, "--quiet", "--i-know-what-im-doing")
+
+	seen := map[string]int{}
+
+	data, err := os.ReadFile(leftoversPath)
+	if err != nil {
+		t.Fatalf("read leftovers: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		path, reason, ok := strings.Cut(line, "\t")
+		if !ok || reason == "" {
+			t.Fatalf("malformed leftovers line %q", line)
+		}
+		seen[path]++
+	}
+	manifests, _ := filepath.Glob(filepath.Join(target, manifest.MetaDir, "manifests", "*.json"))
+	if len(manifests) != 1 {
+		t.Fatalf("expected one manifest, got %v", manifests)
+	}
+	m, err := manifest.Read(manifests[0])
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	for _, entry := range m.Entries {
+		seen[entry.SourcePath]++
+	}
+
+	walked := 0
+	err = filepath.WalkDir(source, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		walked++
+		if seen[path] != 1 {
+			t.Errorf("expected %s once in the manifest or the leftovers, got %d", path, seen[path])
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if walked != len(seen) {
+		t.Fatalf("expected only walked files to be listed, got %d for %d files", len(seen), walked)
+	}
+	if m.Scan == nil || m.Scan.Files != walked {
+		t.Fatalf("expected the manifest to record a scan of %d files, got %+v", walked, m.Scan)
+	}
+}
+
+func TestAutoCopiesEverythingNewFromTheNewestCard(t *testing.T) {
+	source, target := cardFixture(t)
+	saved := autoSources
+	defer func() { autoSources = saved }()
+
+	var watermarkCard string
+	autoSources = autoProviders{
+		cards: func() []string { return []string{source} },
+		profile: func() (config.Profile, bool, error) {
+			return config.Profile{TargetDir: target, Layout: "{date}", Flatten: true}, true, nil
+		},
+		watermark: func(targetDir, card string, _ domain.Volume) (time.Time, error) {
+			watermarkCard = card
+			return time.Date(2024, 10, 3, 12, 0, 0, 0, time.Local), nil
+		},
+	}
+
+	runCLI(t, "--auto", "--plain", "--quiet", "--i-know-what-im-doing")
+	if watermarkCard != filepath.Base(source) {
+		t.Fatalf("expected the watermark of card %s, got %q", filepath.Base(source), watermarkCard)
+	}
+	copied := map[string]bool{}
+	filepath.WalkDir(target, func(path string, d iofs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && !strings.Contains(path, manifest.MetaDir) {
+			copied[filepath.Base(path)] = true
+		}
+		return nil
+	})
+	for _, name := range []string{"DSC0002.JPG", "DSC0003.ARW"} {
+		if !copied[name] {
+			t.Errorf("expected %s to be copied, got %v", name, copied)
+		}
+	}
+	if copied["DSC0001.ARW"] || copied["DSC0001.JPG"] {
+		t.Fatalf("did not expect captures before the last import, got %v", copied)
+	}
+
+	// Flags win over the profile
+	other := filepath.Join(t.TempDir(), "other")
+	runCLI(t, "--auto", "-t", other, "--plain", "--quiet", "--i-know-what-im-doing")
+	if _, err := os.Stat(filepath.Join(other, "2024-10-05", "DSC0003.ARW")); err != nil {
+		t.Fatalf("expected --target to override the profile: %v", err)
+	}
+}
+
+func TestAutoRecognizesACardMountedUnderAnotherName(t *testing.T) {
+	source, target := cardFixture(t)
+	saved, probe := autoSources, probeVolume
+	defer func() { autoSources, probeVolume = saved, probe }()
+
+	card := domain.Volume{Label: "UNTITLED", Serial: "1234-ABCD"}
+	probeVolume = func(string) (domain.Volume, error) { return card, nil }
+	autoSources = autoProviders{
+		cards: func() []string { return []string{source} },
+		profile: func() (config.Profile, bool, error) {
+			return config.Profile{TargetDir: target, Layout: "{date}", Flatten: true}, true, nil
+		},
+		watermark: saved.watermark,
+	}
+	// The last import from this card, while it was mounted as UNTITLED
+	earlier := journal.Entry{
+		SourceDir:    "/Volumes/UNTITLED",
+		TargetDir:    target,
+		SourceVolume: &card,
+		Files:        []journal.File{{TakenAt: time.Date(2024, 10, 3, 12, 0, 0, 0, time.Local)}},
+	}
+	if err := journal.Append(target, earlier); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	runCLI(t, "--auto", "--plain", "--quiet", "--i-know-what-im-doing")
+	if _, err := os.Stat(filepath.Join(target, "2024-10-02", "DSC0001.ARW")); err == nil {
+		t.Fatalf("did not expect captures before the card's last import")
+	}
+	if _, err := os.Stat(filepath.Join(target, "2024-10-05", "DSC0003.ARW")); err != nil {
+		t.Fatalf("expected captures after the card's last import: %v", err)
+	}
+	entries, err := journal.Read(target)
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+	if last := entries[len(entries)-1]; last.SourceVolume == nil || *last.SourceVolume != card {
+		t.Fatalf("expected the run to record volume %v, got %v", card, last.SourceVolume)
+	}
+}
+
+func TestExplicitPathsDoNotReadTheProfile(t *testing.T) {
+	source, target := cardFixture(t)
+	saved := autoSources
+	defer func() { autoSources = saved }()
+
+	reads := 0
+	autoSources.profile = func() (config.Profile, bool, error) {
+		reads++
+		return config.Profile{}, false, errors.New("profile.json: invalid character")
+	}
+
+	out := runCLI(t, "-s", source, "-t", target, "--dry-run", "--plain")
+	if !strings.Contains(out, "DRY-RUN: would copy 3 files") {
+		t.Fatalf("expected the run to ignore the unreadable profile, got:\n%s", out)
+	}
+	if reads != 0 {
+		t.Fatalf("expected no profile read with both paths given, got %d", reads)
+	}
+
+	// A missing target needs the profile, which is read once
+	cmd := newRootCmd()
+	cmd.SetArgs([]string{"-s", source, "--dry-run", "--plain"})
+	cmd.SetOut(new(bytes.Buffer))
+	if err := cmd.Execute(); appErrors.ExitCode(err) != appErrors.ExitInvalidConfig {
+		t.Fatalf("expected the unreadable profile to fail the run, got %v", err)
+	}
+	if reads != 1 {
+		t.Fatalf("expected one profile read, got %d", reads)
+	}
+}
+
+func TestTUIPanicFallsBackToPlainMode(t *testing.T) {
+	source, target := cardFixture(t)
+	t.Setenv("TMPDIR", t.TempDir())
+	cfg, err := config.FromOptions(config.Options{SourceDir: source, TargetDir: target, DryRun: true})
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	plan, err := planOrLoad(context.Background(), cfg, cliOptions{}, logging.Logger{}, nil)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	crash := &tui.PanicError{Value: "negative repeat count", Stack: []byte("goroutine 1 [running]:")}
+
+	// With the plan shown the run completes in plain mode, with the full
+	// plan rather than the preview of the TUI
+	last := tui.NewModel(tui.Config{SourceDir: source, TargetDir: target, DryRun: true})
+	last.Phase, last.Plan = tui.PhaseDone, plan.Preview(1, nil)
+	out := captureStdout(t, func() {
+		if err := recoverFromPanic(context.Background(), cfg, cliOptions{dryRun: true, showAll: true}, logging.Logger{}, last, plan, crash); err != nil {
+			t.Fatalf("expected the plain run to succeed, got %v", err)
+		}
+	})
+	if !strings.Contains(out, "continuing in plain mode") || !strings.Contains(out, "DRY-RUN: would copy 3 files") {
+		t.Fatalf("expected the plain plan, got:\n%s", out)
+	}
+	logs, _ := filepath.Glob(filepath.Join(os.TempDir(), "phopy-crash-*.log"))
+	if len(logs) != 1 {
+		t.Fatalf("expected a crash log, got %v", logs)
+	}
+	if data, _ := os.ReadFile(logs[0]); !strings.Contains(string(data), "negative repeat count") || !strings.Contains(string(data), "goroutine 1") {
+		t.Fatalf("expected the panic and stack in the crash log, got:\n%s", data)
+	}
+
+	// Without a plan the run ends with an internal error
+	last.Phase = tui.PhaseScanning
+	err = recoverFromPanic(context.Background(), cfg, cliOptions{}, logging.Logger{}, last, plan, crash)
+	var appErr *appErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Kind != appErrors.Internal || !strings.Contains(appErr.Hint, "--plain") {
+		t.Fatalf("expected an internal error with a hint, got %v", err)
+	}
+}
+
+func TestPlainCopyLeavesDeclinedOverridesOutOfTheSummary(t *testing.T) {
+	source, target := cardFixture(t)
+	runCLI(t, "copy", "-s", source, "-t", target, "--quiet", "--i-know-what-im-doing")
+	if err := os.Remove(filepath.Join(target, "DCIM", "100MSDCF", "DSC0003.ARW")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	out := runCLI(t, "copy", "-s", source, "-t", target, "--override", "--no", "--plain", "--i-know-what-im-doing")
+	for _, want := range []string{
+		"Copied 1 RAW and 0 JPEG files from",
+		"Override confirmation declined for 1 RAW and 1 JPEG files.",
+		"Skipped 2 files that already existed in the target.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestPlainCopyWithoutTerminalNeedsAnOverrideAnswer(t *testing.T) {
+	source, target := cardFixture(t)
+	runCLI(t, "copy", "-s", source, "-t", target, "--quiet", "--i-know-what-im-doing")
+	existing := filepath.Join(target, "DCIM", "100MSDCF", "DSC0001.ARW")
+
+	tests := []struct {
+		name      string
+		flags     []string
+		overwrite bool
+	}{
+		{"yes", []string{"--yes"}, true},
+		{"no", []string{"--no"}, false},
+		{"confirm default yes", []string{"--confirm-default", "yes"}, true},
+		{"confirm default no", []string{"--confirm-default", "no"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(existing, []byte("edited"), 0o644); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			args := append([]string{"copy", "-s", source, "-t", target, "--override", "--quiet", "--i-know-what-im-doing"}, tt.flags...)
+			runCLI(t, args...)
+			data, err := os.ReadFile(existing)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if overwritten := string(data) == "DSC0001.ARW"; overwritten != tt.overwrite {
+				t.Fatalf("expected overwritten %v, got %q", tt.overwrite, data)
+			}
+		})
+	}
+
+	t.Run("unanswered", func(t *testing.T) {
+		if err := os.WriteFile(existing, []byte("edited"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		var err error
+		captureStdout(t, func() {
+			cmd := newRootCmd()
+			cmd.SetArgs([]string{"copy", "-s", source, "-t", target, "--override", "--quiet", "--i-know-what-im-doing"})
+			err = cmd.Execute()
+		})
+		if appErrors.ExitCode(err) != appErrors.ExitInvalidConfig {
+			t.Fatalf("expected an invalid config exit, got %v", err)
+		}
+		if msg := appErrors.UserMessage(err); !strings.Contains(msg, "3 files already exist") || !strings.Contains(msg, "--yes") {
+			t.Fatalf("expected the conflict count and the resolving flags, got %q", msg)
+		}
+		if data, _ := os.ReadFile(existing); string(data) != "edited" {
+			t.Fatalf("expected nothing to be copied, got %q", data)
+		}
+	})
+}
+
+func TestPlainCopyPrintsEstimate(t *testing.T) {
+	benchmarked := 0
+	measure := measureThroughput
+	t.Cleanup(func() { measureThroughput = measure })
+	measureThroughput = func(string) (float64, error) {
+		benchmarked++
+		return 0.05, nil // 33 bytes in 11 minutes
+	}
+
+	source, target := cardFixture(t)
+	if out := runCLI(t, "-s", source, "-t", target, "--plain", "--dry-run"); strings.Contains(out, "Estimated time") || benchmarked != 0 {
+		t.Fatalf("expected dry runs not to benchmark, got %d runs:\n%s", benchmarked, out)
+	}
+	if out := runCLI(t, "-s", source, "-t", target, "--plain", "--no-benchmark", "--i-know-what-im-doing"); strings.Contains(out, "Estimated time") || benchmarked != 0 {
+		t.Fatalf("expected --no-benchmark to skip the benchmark, got %d runs:\n%s", benchmarked, out)
+	}
+
+	source, target = cardFixture(t)
+	out := runCLI(t, "-s", source, "-t", target, "--plain", "--i-know-what-im-doing")
+	if benchmarked != 1 || !strings.Contains(out, "Estimated time: ~11 min (rough") {
+		t.Fatalf("expected an estimate, got %d runs:\n%s", benchmarked, out)
+	}
+
+	measureThroughput = func(string) (float64, error) { return 0, errors.New("read-only") }
+	source, target = cardFixture(t)
+	if out := runCLI(t, "-s", source, "-t", target, "--plain", "--i-know-what-im-doing"); strings.Contains(out, "Estimated time") {
+		t.Fatalf("expected a failed benchmark to leave out the estimate:\n%s", out)
+	}
+}
+
+func TestStatsOnlyEstimatesWithoutCopying(t *testing.T) {
+	benchmarked := 0
+	measure := measureThroughput
+	t.Cleanup(func() { measureThroughput = measure })
+	measureThroughput = func(string) (float64, error) {
+		benchmarked++
+		return 0.05, nil // 33 bytes in 11 minutes
+	}
+
+	source, target := cardFixture(t)
+	out := runCLI(t, "-s", source, "-t", target, "--stats-only")
+	if benchmarked != 1 || !strings.HasPrefix(out, "scan: ") || !strings.HasSuffix(out, ", plan: 3 files / 33 B, estimated copy: ~11 min at 0 B/s\n") {
+		t.Fatalf("expected a stats line, got %d runs:\n%s", benchmarked, out)
+	}
+
+	var stats struct {
+		Files      int     `json:"files"`
+		Bytes      int64   `json:"bytes"`
+		Throughput float64 `json:"throughput_bytes_per_second"`
+		Estimate   int64   `json:"estimate_ns"`
+		Scan       struct {
+			Files int `json:"files"`
+		} `json:"scan"`
+	}
+	if err := json.Unmarshal([]byte(runCLI(t, "-s", source, "-t", target, "--stats-only", "--stats-json", "-v")), &stats); err != nil {
+		t.Fatalf("expected JSON: %v", err)
+	}
+	if stats.Files != 3 || stats.Bytes != 33 || stats.Throughput != 0.05 || stats.Estimate != int64(660*time.Second) || stats.Scan.Files != 4 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	if _, err := os.Stat(target); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected nothing to be copied, got %v", err)
+	}
+}
+
+func TestPlainCopyWithFsync(t *testing.T) {
+	source, target := cardFixture(t)
+	runCLI(t, "-s", source, "-t", target, "--plain", "--fsync", "--manifest", "--no-benchmark", "--i-know-what-im-doing")
+
+	copied, _ := filepath.Glob(filepath.Join(target, "DCIM", "100MSDCF", "*"))
+	if len(copied) != 3 {
+		t.Fatalf("expected 3 synced copies, got %v", copied)
+	}
+	if manifests, _ := filepath.Glob(filepath.Join(target, manifest.MetaDir, "manifests", "*.json")); len(manifests) != 1 {
+		t.Fatalf("expected a synced manifest, got %v", manifests)
+	}
+}
+
+func TestVerifyReportsCorruptedAndNeverImportedFiles(t *testing.T) {
+	source, target := cardFixture(t)
+	runCLI(t, "-s", source, "-t", target, "--plain", "--manifest", "--no-benchmark", "--i-know-what-im-doing")
+	if out := runCLI(t, "verify", "-t", target); !strings.Contains(out, "Checked 3 files: 3 ok") {
+		t.Fatalf("expected every import intact, got %q", out)
+	}
+
+	corrupted := filepath.Join(target, "DCIM", "100MSDCF", "DSC0003.ARW")
+	if err := os.WriteFile(corrupted, []byte("bit rot"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	added := filepath.Join(source, "DCIM", "100MSDCF", "DSC0004.ARW")
+	if err := os.WriteFile(added, []byte("new"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var err error
+	out := captureStdout(t, func() {
+		cmd := newRootCmd()
+		cmd.SetArgs([]string{"verify", "-t", target, "-s", source})
+		err = cmd.Execute()
+	})
+	if err == nil || !strings.Contains(err.Error(), "3 files are not intact") {
+		t.Fatalf("expected the run to fail on the findings, got %v", err)
+	}
+	// DSC0001.JPG was skipped for its RAW
+	for _, want := range []string{corrupted + " (from ", ": target corrupted", added + ": never imported", "DSC0001.JPG: never imported", "Checked 5 files: 2 ok, 1 target corrupted, 2 never imported"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in %q", want, out)
+		}
+	}
+}
+
+func TestLatestLinkTakesItsNameAfterAnEqualsSign(t *testing.T) {
+	source, target := cardFixture(t)
+	for flag, name := range map[string]string{"--latest-link": "latest", "--latest-link=current": "current"} {
+		target := filepath.Join(target, name)
+		runCLI(t, "-s", source, "-t", target, "--plain", flag, "--no-benchmark", "--i-know-what-im-doing")
+		if _, err := os.Lstat(filepath.Join(target, name)); err != nil {
+			t.Fatalf("expected the %s link after %s: %v", name, flag, err)
+		}
+	}
+
+	cmd := newRootCmd()
+	cmd.SetArgs([]string{"-s", source, "-t", target, "--plain", "--latest-link", "newest"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--latest-link=newest") {
+		t.Fatalf("expected a hint at --latest-link=newest, got %v", err)
+	}
+}
+
+func TestRunIDTiesJournalManifestAndSummaryTogether(t *testing.T) {
+	source, target := cardFixture(t)
+	out := runCLI(t, "-s", source, "-t", target, "--plain", "--verbose", "--manifest", "--no-benchmark", "--i-know-what-im-doing")
+
+	entries, err := journal.Read(target)
+	if err != nil || len(entries) != 1 || entries[0].RunID == "" {
+		t.Fatalf("expected one journal entry with a run id, got %+v (%v)", entries, err)
+	}
+	runID := entries[0].RunID
+	manifests, _ := filepath.Glob(filepath.Join(target, manifest.MetaDir, "manifests", "manifest-"+runID+".json"))
+	if len(manifests) != 1 {
+		t.Fatalf("expected the manifest to be named after run %s", runID)
+	}
+	m, err := manifest.Read(manifests[0])
+	if err != nil || m.RunID != runID {
+		t.Fatalf("expected the manifest to record run %s, got %q (%v)", runID, m.RunID, err)
+	}
+	for _, want := range []string{"Verbose: Run " + runID, "Run " + runID + "."} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in the output:\n%s", want, out)
+		}
+	}
+}
+
+func TestPlainRunRefusesTargetNamesTooLongForTheTarget(t *testing.T) {
+	source, target := cardFixture(t)
+	long := strings.Repeat("x", 100) + ".JPG"
+	if err := os.WriteFile(filepath.Join(source, "DCIM", "100MSDCF", long), []byte("jpeg"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var err error
+	captureStdout(t, func() {
+		cmd := newRootCmd()
+		cmd.SetArgs([]string{"-s", source, "-t", target, "--plain", "--dry-run", "--rename", "{name}_{name}_{name}.{ext}"})
+		err = cmd.Execute()
+	})
+	if appErrors.ExitCode(err) != appErrors.ExitInvalidConfig {
+		t.Fatalf("expected an invalid config exit, got %v", err)
+	}
+	msg := appErrors.UserMessage(err)
+	for _, want := range []string{"1 target paths are too long", "name is 306 ", "51 over the limit of 255", "--flatten"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %q in %q", want, msg)
+		}
+	}
+}
+
+// errorModel quits on the first tui.ErrorMsg and keeps its error.
+type errorModel struct{ err error }
+
+func (m errorModel) Init() tea.Cmd { return nil }
+
+func (m errorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tui.ErrorMsg); ok {
+		m.err = msg.Err
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m errorModel) View() string { return "" }
+
+func TestTUIRefusesTargetNamesTooLongAsInvalidConfig(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := tea.NewProgram(errorModel{}, tea.WithInput(nil), tea.WithOutput(&bytes.Buffer{}), tea.WithoutRenderer(), tea.WithContext(ctx))
+	events := make(chan app.Event, 1)
+	events <- app.PlanDoneEvent{Err: &domain.PathLimitError{Limits: domain.PathLimits{MaxName: 255}}}
+	cfg := config.Config{SourceDir: "/card", TargetDir: "/archive"}
+	go forwardEvents(ctx, p, events, func(err error) error { return planError(cfg, err) }, nil)
+
+	final, err := p.Run()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if code := appErrors.ExitCode(final.(errorModel).err); code != appErrors.ExitInvalidConfig {
+		t.Fatalf("expected an invalid config exit, got %d for %v", code, final.(errorModel).err)
+	}
+}
+
+func TestJournalCompactKeepsTheLatestCopyOfEachFile(t *testing.T) {
+	isolate(t)
+	target := t.TempDir()
+	file := journal.File{SourcePath: "/card/DSC0001.ARW", TargetPath: filepath.Join(target, "DSC0001.ARW"), Size: 3}
+	for _, runID := range []string{"first", "second"} {
+		if err := journal.Append(target, journal.Entry{RunID: runID, Files: []journal.File{file}}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	runCLI(t, "journal", "compact", "-t", target)
+	entries, err := journal.Read(target)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(entries) != 1 || entries[0].RunID != "second" {
+		t.Fatalf("expected only the second run, got %+v", entries)
+	}
+
+	cmd := newRootCmd()
+	cmd.SetArgs([]string{"journal", "compact", "-t", t.TempDir()})
+	if err := cmd.Execute(); appErrors.ExitCode(err) != appErrors.ExitFailure || !errors.Is(err, iofs.ErrNotExist) {
+		t.Fatalf("expected a target without journal to fail, got %v", err)
+	}
+}
+
+func TestExportScriptCopiesLikeThePlan(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil || runtime.GOOS == "windows" {
+		t.Skip("no POSIX shell")
+	}
+	source, target := cardFixture(t)
+	script := filepath.Join(t.TempDir(), "copy.sh")
+	runCLI(t, "plan", "-s", source, "-t", target, "--export-script", script)
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("expected the export to copy nothing, got %v", err)
+	}
+
+	if out, err := exec.Command(sh, script).CombinedOutput(); err != nil {
+		t.Fatalf("run script: %v\n%s", err, out)
+	}
+	matches, _ := filepath.Glob(filepath.Join(target, "DCIM", "100MSDCF", "*"))
+	if len(matches) != 3 {
+		t.Fatalf("expected the script to copy 3 files, got %v", matches)
+	}
+}
+
+func TestExportScriptRefusesToCopyToo(t *testing.T) {
+	source, target := cardFixture(t)
+	var err error
+	captureStdout(t, func() {
+		cmd := newRootCmd()
+		cmd.SetArgs([]string{"-s", source, "-t", target, "--plain", "--export-script", filepath.Join(t.TempDir(), "copy.sh")})
+		err = cmd.Execute()
+	})
+	if appErrors.ExitCode(err) != appErrors.ExitInvalidConfig || !strings.Contains(appErrors.UserMessage(err), "--dry-run") {
+		t.Fatalf("expected the run to be refused, got %v", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing copied, got %v", err)
+	}
+}
+
+func TestProgressLineSnapshotsPhasesInLogs(t *testing.T) {
+	source, target := cardFixture(t)
+	out := runCLI(t, "-s", source, "-t", target, "--progress", "line", "--no-benchmark", "--i-know-what-im-doing")
+	for _, want := range []string{
+		"Scanning " + source + ": 3/3 (100%)\n",
+		"Copying 3 files to " + target + ": 3/3 (100%)\n",
+		"Copying:\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "\r") {
+		t.Fatalf("expected no carriage returns without a terminal:\n%q", out)
+	}
+}
+
+func TestProgressNonePrintsPhasesAndSummary(t *testing.T) {
+	source, target := cardFixture(t)
+	out := runCLI(t, "-s", source, "-t", target, "--progress", "none", "--no-benchmark", "--i-know-what-im-doing")
+	if !strings.HasPrefix(out, "Scanning "+source+"...\nCopying 3 files to "+target+"...\n") {
+		t.Fatalf("expected the phases first, got:\n%s", out)
+	}
+	if strings.Contains(out, "Copying:\n") || strings.Contains(out, "3/3") {
+		t.Fatalf("expected neither the file list nor progress, got:\n%s", out)
+	}
+}
+
+// isolate keeps the environment and any saved profile out of the run.
+func isolate(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, name := range []string{"PHOPY_SOURCE_DIR", "PHOPY_TARGET_DIR", "PHOPY_VERBOSE", "PHOPY_FROM", "PHOPY_START_DATE", "PHOPY_UNTIL", "PHOPY_END_DATE"} {
+		t.Setenv(name, "")
+	}
+}
+
+// archiveFixture creates a hand-built archive with one finding of every
+// audit issue. Its files carry no EXIF, so they are dated by their
+// modification time.
+func archiveFixture(t *testing.T) string {
+	t.Helper()
+	isolate(t)
+	archive := t.TempDir()
+	march15 := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+	march16 := march15.AddDate(0, 0, 1)
+	for rel, file := range map[string]struct {
+		data    string
+		modTime time.Time
+	}{
+		"2024/2024-03-15/DSC0001.ARW": {"raw 1", march15},
+		"2024/2024-03-15/DSC0001.JPG": {"jpeg 1", march15},
+		"2024/2024-03-15/DSC0002.ARW": {"raw 2", march16},
+		"2024/2024-03-16/DSC0003.ARW": {"raw 3", march16},
+		"2024/2024-03-15/DSC0003.JPG": {"jpeg 3", march16},
+		"2024/2024-03-16/DSC0004.JPG": {"jpeg 4", march16},
+		"2024/2024-03-16/DSC0005.JPG": {"jpeg 4", march16},
+		"2024/2024-03-15/.DS_Store":   {"junk", march15},
+	} {
+		path := filepath.Join(archive, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(file.data), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := os.Chtimes(path, file.modTime, file.modTime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	return archive
+}
+
+func TestAuditSharesTheDatingFlagsOfRuns(t *testing.T) {
+	run := newRootCmd()
+	audit, _, err := run.Find([]string{"audit"})
+	if err != nil {
+		t.Fatalf("find audit: %v", err)
+	}
+	for _, name := range []string{"verbose", "date-floor", "dir-date-pattern", "date-tag-order"} {
+		want, got := run.Flags().Lookup(name), audit.Flags().Lookup(name)
+		if want == nil || got == nil || got.Usage != want.Usage || got.DefValue != want.DefValue {
+			t.Errorf("expected audit --%s like the run's, got %+v and %+v", name, got, want)
+		}
+	}
+}
+
+func TestAuditReportsTheFindingsOfAnArchive(t *testing.T) {
+	archive := archiveFixture(t)
+	before := snapshotTree(t, archive)
+
+	out := runCLI(t, "audit", "-t", archive, "--layout", "{yyyy}/{date}")
+	for _, want := range []string{
+		"Audited 8 files in " + archive + ": 4 findings.\n",
+		filepath.FromSlash("- 2024/2024-03-15/DSC0002.ARW: dated 2024-03-16 by file time, belongs in 2024/2024-03-16\n"),
+		filepath.FromSlash("- 2024/2024-03-15/DSC0003.JPG: its RAW is 2024/2024-03-16/DSC0003.ARW\n"),
+		filepath.FromSlash("- 2024/2024-03-16/DSC0005.JPG: duplicate of 2024/2024-03-16/DSC0004.JPG\n"),
+		filepath.FromSlash("- 2024/2024-03-15/.DS_Store\n"),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	var report domain.AuditReport
+	if err := json.Unmarshal([]byte(runCLI(t, "audit", "-t", archive, "--layout", "{yyyy}/{date}", "--json")), &report); err != nil {
+		t.Fatalf("expected JSON: %v", err)
+	}
+	for i, group := range report.Groups {
+		if group.Issue != domain.AuditIssues[i].Issue || len(group.Findings) != 1 {
+			t.Errorf("expected one %s finding, got %+v", domain.AuditIssues[i].Issue, group)
+		}
+	}
+
+	// An archive following the layout has nothing to report
+	if out := runCLI(t, "audit", "-t", archive, "--layout", "{yyyy}/{date}", "--exclude", "*.JPG", "--exclude", ".DS_Store", "--exclude", "2024/2024-03-15/DSC0002.ARW"); !strings.HasSuffix(out, ": nothing to report.\n") {
+		t.Errorf("expected nothing to report, got:\n%s", out)
+	}
+
+	if after := snapshotTree(t, archive); !reflect.DeepEqual(after, before) {
+		t.Fatalf("expected the audit not to change the archive, got %v, was %v", after, before)
+	}
+}
+
+// snapshotTree returns the paths, sizes and modification times below root.
+func snapshotTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	snapshot := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		snapshot[path] = fmt.Sprintf("%v %d %s", info.IsDir(), info.Size(), info.ModTime())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	return snapshot
+}
@@ -0,0 +1,195 @@
+// Command copyview hosts the copy view of phopy in a Bubble Tea program of
+// its own, the way a program outside this module would: it copies the
+// files of a source directory into a target itself and drives
+// copyview.Model with the messages of the copyview package only.
+//
+//	go run ./examples/copyview /Volumes/EOS_DIGITAL/DCIM ~/Pictures/import
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"phopy/tui/copyview"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errMsg ends the program with a failed scan.
+type errMsg struct{ err error }
+
+// model hosts the copy view; it only starts the copy and quits.
+type model struct {
+	view   copyview.Model
+	copy   func() tea.Cmd
+	err    error
+	finish bool
+}
+
+func (m model) Init() tea.Cmd {
+	return m.view.Init()
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" || msg.String() == "q" || m.finish {
+			return m, tea.Quit
+		}
+	case errMsg:
+		m.err = msg.err
+		return m, tea.Quit
+	case copyview.PlanReadyMsg:
+		cmds = append(cmds, m.copy())
+	case copyview.CopyDoneMsg:
+		m.finish = true
+	}
+
+	var cmd tea.Cmd
+	m.view, cmd = m.view.Update(msg)
+	return m, tea.Batch(append(cmds, cmd)...)
+}
+
+func (m model) View() string {
+	view := m.view.View() + "\n"
+	if m.finish {
+		view += "\nPress any key to exit\n"
+	}
+	return view
+}
+
+// scan lists the files below source, reporting its progress to send.
+func scan(source string, send func(tea.Msg)) ([]string, copyview.Plan, error) {
+	var files []string
+	err := filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, copyview.Plan{}, err
+	}
+
+	plan := copyview.Plan{Files: len(files)}
+	for i, path := range files {
+		switch kind(path) {
+		case "jpeg":
+			plan.JPEG++
+		case "raw":
+			plan.RAW++
+		}
+		send(copyview.ScanProgressMsg{Current: i + 1, Total: len(files)})
+	}
+	return files, plan, nil
+}
+
+// copyFiles copies files from below source to the same relative paths
+// below target, reporting its progress to send.
+func copyFiles(ctx context.Context, source, target string, files []string, send func(tea.Msg)) copyview.Result {
+	var result copyview.Result
+	for i, path := range files {
+		if ctx.Err() != nil {
+			result.Cancelled = len(files) - i
+			break
+		}
+		name := filepath.Base(path)
+		send(copyview.CopyProgressMsg{Current: i, Total: len(files), File: name})
+		rel, err := filepath.Rel(source, path)
+		if err == nil {
+			err = copyFile(path, filepath.Join(target, rel))
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, copyview.Failure{File: name, Err: err})
+			continue
+		}
+		result.Copied++
+		switch kind(path) {
+		case "jpeg":
+			result.JPEG++
+		case "raw":
+			result.RAW++
+		}
+	}
+	send(copyview.CopyProgressMsg{Current: len(files), Total: len(files)})
+	return result
+}
+
+// kind tells JPEGs and common RAW formats apart by their extension.
+func kind(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".arw", ".cr2", ".cr3", ".nef", ".raf", ".dng", ".orf", ".rw2":
+		return "raw"
+	}
+	return ""
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: copyview <source> <target>")
+		os.Exit(2)
+	}
+	source, target := os.Args[1], os.Args[2]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var files []string
+	var p *tea.Program
+	m := model{view: copyview.New(copyview.BarStyle{})}
+	m.copy = func() tea.Cmd {
+		return func() tea.Msg {
+			return copyview.CopyDoneMsg{Result: copyFiles(ctx, source, target, files, p.Send)}
+		}
+	}
+	p = tea.NewProgram(m)
+
+	go func() {
+		scanned, plan, err := scan(source, p.Send)
+		if err != nil {
+			p.Send(errMsg{err})
+			return
+		}
+		// The copy starts on PlanReadyMsg, after files is set
+		files = scanned
+		p.Send(copyview.PlanReadyMsg{Plan: plan})
+	}()
+
+	final, err := p.Run()
+	cancel()
+	if err == nil {
+		err = final.(model).err
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "copyview:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"phopy/internal/domain"
+)
+
+// Audit checks the existing archive in dir against the rules of the
+// planner without writing anything. It plans dir as its own source, so the
+// files are dated, paired and compared like those of a card, and reports
+// the files the layout puts in another folder, the JPEGs whose RAW of the
+// same name is in another folder, duplicate captures and junk files.
+//
+// Only the folders are compared: the layout's Dir is read below dir as if
+// the source were flattened, and rename templates are ignored.
+func (p *Planner) Audit(ctx context.Context, dir string) (domain.AuditReport, error) {
+	auditor := *p
+	auditor.AllowOverride = true
+	auditor.AutoOverrideStale = false
+	auditor.Dedupe = true
+	auditor.KeepJunk = false
+	auditor.IncludeMisc = false
+	auditor.History = nil
+	auditor.Layout.Flatten = true
+	auditor.Layout.Name = ""
+
+	plan, err := auditor.Plan(ctx, dir, dir, nil, nil)
+	if err != nil {
+		return domain.AuditReport{}, err
+	}
+
+	rel := func(path string) string {
+		if r, err := filepath.Rel(dir, path); err == nil {
+			return r
+		}
+		return path
+	}
+	findings := make(map[string][]domain.AuditFinding)
+	for _, item := range plan.Items {
+		if item.CompanionOf != "" {
+			continue
+		}
+		meta := item.FileMeta
+		folder := filepath.Dir(filepath.Join(dir, auditor.Layout.TargetRel(meta)))
+		if folder == filepath.Dir(meta.SourcePath) {
+			continue
+		}
+		findings[domain.AuditMisfiled] = append(findings[domain.AuditMisfiled], domain.AuditFinding{
+			Path:   rel(meta.SourcePath),
+			Folder: rel(folder),
+			Detail: fmt.Sprintf("dated %s by %s", meta.TakenAt.Format("2006-01-02"), meta.DateSource),
+		})
+	}
+	for _, pairing := range plan.Pairings {
+		if filepath.Dir(pairing.JPEG) == filepath.Dir(pairing.RAW) {
+			continue
+		}
+		findings[domain.AuditSplitPair] = append(findings[domain.AuditSplitPair], domain.AuditFinding{
+			Path:   rel(pairing.JPEG),
+			Folder: rel(filepath.Dir(pairing.RAW)),
+			Of:     rel(pairing.RAW),
+		})
+	}
+	for _, leftover := range plan.Leftovers {
+		switch leftover.Reason {
+		case skipDuplicate:
+			findings[domain.AuditDuplicate] = append(findings[domain.AuditDuplicate], domain.AuditFinding{
+				Path: rel(leftover.SourcePath),
+				Of:   rel(leftover.Of),
+			})
+		case skipJunk:
+			findings[domain.AuditJunk] = append(findings[domain.AuditJunk], domain.AuditFinding{
+				Path: rel(leftover.SourcePath),
+			})
+		}
+	}
+	return domain.NewAuditReport(dir, plan.Scan.Files, findings), nil
+}
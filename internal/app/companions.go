@@ -0,0 +1,113 @@
+package app
+
+import (
+	"path/filepath"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+// Reasons companion and misc files are left out of the plan.
+const (
+	skipLoneCompanion = "companion without its file"
+)
+
+// planCompanions inserts every companion after the planned item it belongs
+// to, in the directory of that item's target. Companions of files that are
+// not planned are left out.
+func (p *Planner) planCompanions(items []domain.CopyItem, companions []candidate, sourceDir string, usedTargets map[string]bool) ([]domain.CopyItem, []domain.Leftover, error) {
+	if len(companions) == 0 {
+		return items, nil, nil
+	}
+	byDir := make(map[string][]candidate)
+	for _, file := range companions {
+		dir := filepath.Dir(file.path)
+		byDir[dir] = append(byDir[dir], file)
+	}
+
+	paired := make(map[string]bool)
+	var leftovers []domain.Leftover
+	planned := make([]domain.CopyItem, 0, len(items)+len(companions))
+	for _, item := range items {
+		planned = append(planned, item)
+		for _, file := range byDir[filepath.Dir(item.FileMeta.SourcePath)] {
+			if paired[file.path] || !domain.IsCompanionOf(filepath.Base(file.path), item.FileMeta.BaseName) {
+				continue
+			}
+			paired[file.path] = true
+			target := filepath.Join(filepath.Dir(item.TargetPath), filepath.Base(file.path))
+			companion, leftover, err := p.extraItem(file, sourceDir, target, item.FileMeta.TakenAt, usedTargets)
+			if err != nil {
+				return nil, nil, err
+			}
+			if leftover != "" {
+				leftovers = append(leftovers, domain.Leftover{SourcePath: file.path, Reason: leftover})
+				continue
+			}
+			companion.CompanionOf = item.FileMeta.SourcePath
+			planned = append(planned, companion)
+		}
+	}
+	for _, file := range companions {
+		if !paired[file.path] {
+			leftovers = append(leftovers, domain.Leftover{SourcePath: file.path, Reason: skipLoneCompanion})
+		}
+	}
+	return planned, leftovers, nil
+}
+
+// planMisc plans the camera's housekeeping files below MISC in the target,
+// keeping their path in the source, dated by their modification time.
+func (p *Planner) planMisc(misc []candidate, sourceDir, targetDir string, usedTargets map[string]bool) ([]domain.CopyItem, []domain.Leftover, error) {
+	var items []domain.CopyItem
+	var leftovers []domain.Leftover
+	for _, file := range misc {
+		rel, err := filepath.Rel(sourceDir, file.path)
+		if err != nil {
+			rel = filepath.Base(file.path)
+		}
+		item, leftover, err := p.extraItem(file, sourceDir, filepath.Join(targetDir, "MISC", rel), time.Time{}, usedTargets)
+		if err != nil {
+			return nil, nil, err
+		}
+		if leftover != "" {
+			leftovers = append(leftovers, domain.Leftover{SourcePath: file.path, Reason: leftover})
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, leftovers, nil
+}
+
+// extraItem plans a file copied without an EXIF read to target, dated
+// takenAt or, when zero, by its modification time. It returns a leftover
+// reason instead when the target exists and may not be overwritten.
+func (p *Planner) extraItem(file candidate, sourceDir, target string, takenAt time.Time, usedTargets map[string]bool) (domain.CopyItem, string, error) {
+	info := file.info
+	if info == nil {
+		var err error
+		if info, err = p.FS.Stat(file.path); err != nil {
+			return domain.CopyItem{}, "", err
+		}
+	}
+	if takenAt.IsZero() {
+		takenAt = info.ModTime()
+	}
+	if !p.AllowOverride {
+		_, exists, err := p.targetExists(target)
+		if err != nil {
+			return domain.CopyItem{}, "", err
+		}
+		if exists {
+			return domain.CopyItem{}, skipTargetExists, nil
+		}
+	}
+	rel, err := filepath.Rel(sourceDir, file.path)
+	if err != nil {
+		rel = filepath.Base(file.path)
+	}
+	meta := domain.NewFileMeta(file.path, rel, takenAt)
+	meta.Size = info.Size()
+	meta.ModTime = info.ModTime()
+	return domain.CopyItem{FileMeta: meta, TargetPath: domain.UniqueTargetPath(target, usedTargets)}, "", nil
+}
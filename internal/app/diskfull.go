@@ -0,0 +1,43 @@
+package app
+
+import (
+	"fmt"
+
+	"phopy/internal/format"
+)
+
+// DiskFullError stops an execution whose target ran out of space. The
+// partial file of the item that failed is removed, so running the same
+// plan again once there is room copies exactly the files still missing.
+type DiskFullError struct {
+	// Copied and CopiedBytes count what was copied before the target
+	// filled up.
+	Copied      int
+	CopiedBytes int64
+	// Remaining and RemainingBytes count the items neither copied nor
+	// skipped: the one that filled the target, those cancelled after it
+	// and those that failed before it with KeepGoing.
+	Remaining      int
+	RemainingBytes int64
+	// Free is the space left in the target, -1 when the file system
+	// cannot tell.
+	Free int64
+	Err  error
+}
+
+func (e *DiskFullError) Error() string {
+	return fmt.Sprintf("target is full, %d files (%s) were not copied: %v", e.Remaining, format.Bytes(e.RemainingBytes), e.Err)
+}
+
+func (e *DiskFullError) Unwrap() error {
+	return e.Err
+}
+
+// Missing is how much more space the remaining items need, -1 when the
+// free space is unknown.
+func (e *DiskFullError) Missing() int64 {
+	if e.Free < 0 {
+		return -1
+	}
+	return max(e.RemainingBytes-e.Free, 0)
+}
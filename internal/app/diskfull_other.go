@@ -0,0 +1,7 @@
+//go:build !unix && !windows
+
+package app
+
+func isDiskFull(error) bool {
+	return false
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package app
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isDiskFull reports whether err is a write that failed for lack of space.
+// An exceeded disk quota leaves the user as stuck as a full volume.
+func isDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EDQUOT)
+}
@@ -0,0 +1,171 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+// Event is a typed notification emitted by PlanWithEvents and
+// ExecuteWithEvents. It is one of ScanProgressEvent, CopyProgressEvent,
+// FileProgressEvent, FolderDoneEvent, WarningEvent, ConflictEvent,
+// PlanDoneEvent or ExecuteDoneEvent.
+//
+// Backpressure: progress events are sent without blocking and are dropped
+// when the channel is full, so a slow consumer only sees fewer updates.
+// Folder, warning, conflict and done events block until they are received or ctx is done,
+// so they are never lost while the run is alive. Use a buffered channel to
+// keep progress updates smooth. The channel is never closed by the sender;
+// one channel can serve a plan and the execution that follows it.
+type Event interface {
+	isEvent()
+}
+
+// ScanProgressEvent reports how many of the scanned files were inspected.
+type ScanProgressEvent struct {
+	Current int
+	Total   int
+}
+
+// CopyProgressEvent reports the file about to be copied. File is empty for
+// the final event of a completed run.
+type CopyProgressEvent struct {
+	Current int
+	Total   int
+	File    string
+}
+
+// FileProgressEvent reports the bytes written of the file being copied.
+type FileProgressEvent struct {
+	FileProgress
+}
+
+// FolderDoneEvent reports a target directory whose files have all landed,
+// see Executor.OnFolderDone.
+type FolderDoneEvent struct {
+	FolderDone
+}
+
+// WarningEvent carries a non-fatal problem, e.g. a missing EXIF date or a
+// failed copy while KeepGoing is set.
+type WarningEvent struct {
+	// Code is the stable identifier of the warning, see
+	// domain.WarningCodes.
+	Code    string
+	Message string
+}
+
+// ConflictEvent asks about a target that appeared after planning, see
+// AskConflicts. The executor waits until Reply receives the answer.
+type ConflictEvent struct {
+	Item  domain.CopyItem
+	Reply chan<- domain.ConflictAnswer
+}
+
+// PlanDoneEvent is the last event of PlanWithEvents.
+type PlanDoneEvent struct {
+	Plan domain.CopyPlan
+	Err  error
+}
+
+// ExecuteDoneEvent is the last event of ExecuteWithEvents.
+type ExecuteDoneEvent struct {
+	Result domain.ExecutionResult
+	Err    error
+}
+
+func (ScanProgressEvent) isEvent() {}
+func (CopyProgressEvent) isEvent() {}
+func (FileProgressEvent) isEvent() {}
+func (FolderDoneEvent) isEvent()   {}
+func (WarningEvent) isEvent()      {}
+func (ConflictEvent) isEvent()     {}
+func (PlanDoneEvent) isEvent()     {}
+func (ExecuteDoneEvent) isEvent()  {}
+
+// PlanWithEvents runs Plan and streams its progress, warnings and outcome to
+// events. An OnProgress callback set on the planner is still called.
+func (p *Planner) PlanWithEvents(ctx context.Context, sourceDir, targetDir string, startDate, endDate *time.Time, events chan<- Event) (domain.CopyPlan, error) {
+	planner := *p
+	planner.OnProgress = func(current, total int) {
+		trySend(events, ScanProgressEvent{Current: current, Total: total})
+		if p.OnProgress != nil {
+			p.OnProgress(current, total)
+		}
+	}
+	planner.onWarning = func(warning domain.Warning) {
+		send(ctx, events, WarningEvent{Code: warning.Code, Message: warning.Text})
+	}
+
+	plan, err := planner.Plan(ctx, sourceDir, targetDir, startDate, endDate)
+	send(ctx, events, PlanDoneEvent{Plan: plan, Err: err})
+	return plan, err
+}
+
+// ExecuteWithEvents runs Execute and streams its progress, finished
+// folders, copy failures and outcome to events. OnProgress and OnFolderDone
+// callbacks set on the executor are still called.
+func (e *Executor) ExecuteWithEvents(ctx context.Context, plan domain.CopyPlan, decisions []domain.CopyDecision, events chan<- Event) (domain.ExecutionResult, error) {
+	executor := *e
+	executor.OnProgress = func(current, total int, currentFile string) {
+		trySend(events, CopyProgressEvent{Current: current, Total: total, File: currentFile})
+		if e.OnProgress != nil {
+			e.OnProgress(current, total, currentFile)
+		}
+	}
+	executor.OnFileProgress = func(progress FileProgress) {
+		trySend(events, FileProgressEvent{FileProgress: progress})
+		if e.OnFileProgress != nil {
+			e.OnFileProgress(progress)
+		}
+	}
+	executor.OnFolderDone = func(done FolderDone) {
+		send(ctx, events, FolderDoneEvent{FolderDone: done})
+		if e.OnFolderDone != nil {
+			e.OnFolderDone(done)
+		}
+	}
+	executor.onWarning = func(warning domain.Warning) {
+		send(ctx, events, WarningEvent{Code: warning.Code, Message: warning.Text})
+	}
+
+	result, err := executor.Execute(ctx, plan, decisions)
+	send(ctx, events, ExecuteDoneEvent{Result: result, Err: err})
+	return result, err
+}
+
+// AskConflicts returns a ConflictFunc that sends each conflict to events
+// as a ConflictEvent and waits for its reply.
+func AskConflicts(events chan<- Event) ConflictFunc {
+	return func(ctx context.Context, item domain.CopyItem) (domain.ConflictAnswer, error) {
+		reply := make(chan domain.ConflictAnswer, 1)
+		select {
+		case events <- ConflictEvent{Item: item, Reply: reply}:
+		case <-ctx.Done():
+			return domain.ConflictFail, ctx.Err()
+		}
+		select {
+		case answer := <-reply:
+			return answer, nil
+		case <-ctx.Done():
+			return domain.ConflictFail, ctx.Err()
+		}
+	}
+}
+
+// send delivers ev unless ctx is done first.
+func send(ctx context.Context, events chan<- Event, ev Event) {
+	select {
+	case events <- ev:
+	case <-ctx.Done():
+	}
+}
+
+// trySend delivers ev only if the consumer has room for it.
+func trySend(events chan<- Event, ev Event) {
+	select {
+	case events <- ev:
+	default:
+	}
+}
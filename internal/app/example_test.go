@@ -0,0 +1,55 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"phopy/internal/app"
+	"phopy/internal/infra/exif"
+	"phopy/internal/infra/fs"
+)
+
+func ExamplePlanner_PlanWithEvents() {
+	source, err := os.MkdirTemp("", "phopy-example-")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(source)
+	for _, name := range []string{"DSC0001.ARW", "DSC0002.ARW", "DSC0003.ARW", "DSC0004.ARW"} {
+		if err := os.WriteFile(filepath.Join(source, name), nil, 0o644); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	// Progress events are dropped when the buffer is full; warnings and the
+	// done event are always delivered
+	events := make(chan app.Event, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev := range events {
+			switch ev := ev.(type) {
+			case app.ScanProgressEvent:
+				fmt.Printf("scanned %d%%\n", ev.Current*100/ev.Total)
+			case app.PlanDoneEvent:
+				fmt.Printf("planned %d files\n", len(ev.Plan.Items))
+				return
+			}
+		}
+	}()
+
+	planner := app.Planner{FS: fs.OSFS{}, Exif: exif.Reader{}}
+	_, _ = planner.PlanWithEvents(context.Background(), source, source+"-target", nil, nil, events)
+	<-done
+
+	// Output:
+	// scanned 25%
+	// scanned 50%
+	// scanned 75%
+	// scanned 100%
+	// planned 4 files
+}
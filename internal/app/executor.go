@@ -1,63 +1,370 @@
 package app
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"time"
 
 	"phopy/internal/domain"
+	"phopy/internal/hash"
 	"phopy/internal/logging"
 )
 
 // CopyProgressFunc is called during copy with progress updates
 type CopyProgressFunc func(current, total int, currentFile string)
 
+// FileProgress is the byte progress of the file being copied. BytesDone
+// includes Written and all files copied before it.
+type FileProgress struct {
+	File       string
+	Written    int64
+	Size       int64
+	BytesDone  int64
+	BytesTotal int64
+}
+
+// FileProgressFunc is called when a file starts copying and then at most
+// every fileProgressInterval while its bytes are written. Written stays 0
+// when the file system cannot report byte progress.
+type FileProgressFunc func(progress FileProgress)
+
+const fileProgressInterval = 250 * time.Millisecond
+
+// FolderDone describes a target directory whose last item finished. Files
+// and Bytes count the items written to it, copied or linked.
+type FolderDone struct {
+	Dir   string
+	Files int
+	Bytes int64
+}
+
+// FolderDoneFunc is called once for every target directory that files were
+// written to, as soon as no item bound for it is left.
+type FolderDoneFunc func(done FolderDone)
+
+// ConflictFunc decides what happens to item, whose target appeared after
+// planning. It may block, e.g. until the user answered.
+type ConflictFunc func(ctx context.Context, item domain.CopyItem) (domain.ConflictAnswer, error)
+
+// AnswerConflicts returns a ConflictFunc that gives every conflict answer.
+func AnswerConflicts(answer domain.ConflictAnswer) ConflictFunc {
+	return func(context.Context, domain.CopyItem) (domain.ConflictAnswer, error) {
+		return answer, nil
+	}
+}
+
+// Attributes of copied files stamped with Executor.StampRun.
+const (
+	StampSourceAttr = "user.phopy.src"
+	StampRunAttr    = "user.phopy.run"
+)
+
+// ErrLateConflict is the error of items whose target appeared after
+// planning when their conflict was answered with ConflictFail.
+var ErrLateConflict = errors.New("target appeared after planning")
+
+// ErrCopyCorrupted is the error of items whose target does not hash like
+// the source read while copying it, see Executor.Verify.
+var ErrCopyCorrupted = errors.New("copy does not match its source")
+
+// ErrSourceChanged is the error of items whose source changed after
+// planning with SourceChangeFail.
+var ErrSourceChanged = errors.New("source changed after planning")
+
 type Executor struct {
 	FS         FileSystem
 	Logger     logging.Logger
 	OnProgress CopyProgressFunc
+	// OnFileProgress reports byte progress within the current file, which
+	// keeps long copies of single large files visibly alive.
+	OnFileProgress FileProgressFunc
+	// KeepGoing continues with the remaining items after a failed copy
+	// instead of cancelling them.
+	KeepGoing bool
+	// Journal, when set, receives the result of every execution that copied
+	// files or completed without failures.
+	Journal Journal
+	// OnConflict, when set, makes the executor check that the targets of
+	// items planned as new are still missing and decides about those that
+	// are not. Without it such targets are overwritten.
+	OnConflict ConflictFunc
+	// StampRun, when set, stamps every copied file with its source path and
+	// this run id on file systems that implement Stamper. Files that cannot
+	// be stamped are only counted in the verbose output.
+	StampRun string
+	// SkipLocked probes every source on file systems that implement
+	// LockProber and defers those another process holds open. They are
+	// retried once after all other items and skipped if still locked.
+	SkipLocked bool
+	// Fsync syncs every target directory once after the last item bound
+	// for it, so the copied files survive a power cut. A failed sync fails
+	// the execution.
+	Fsync bool
+	// OnFolderDone, when set, reports every target directory once its last
+	// item finished, after it was synced with Fsync. Directories whose sync
+	// failed are not reported.
+	OnFolderDone FolderDoneFunc
+	// LinkIndex maps content hashes to files already in the target. On
+	// file systems that implement ContentHasher, items whose source hashes
+	// to an indexed file with unchanged content are hard linked to it
+	// instead of copied, falling back to a copy when the link fails, e.g.
+	// across volumes. Files copied during the run join the index.
+	LinkIndex map[string]string
+	// LinkHash is the algorithm the hashes of LinkIndex were made with;
+	// empty uses hash.DefaultManifest.
+	LinkHash hash.Algorithm
+	// OnSourceChange decides about sources whose size or modification time
+	// differs from the plan when they are copied. Items planned without a
+	// modification time are not checked.
+	OnSourceChange domain.SourceChange
+	// RunID is handed on in the result, so the summary can name the run.
+	RunID string
+	// Hash, when set, hashes every source with this algorithm while it is
+	// copied and keeps the hash in the item results for the manifest.
+	Hash hash.Algorithm
+	// Verify compares the Hash of every source with a re-read of its
+	// target on file systems that implement ContentHasher. A copy that
+	// does not match fails with ErrCopyCorrupted and is removed.
+	Verify bool
+
+	onWarning func(warning domain.Warning)
 }
 
-func (e *Executor) Execute(ctx context.Context, plan domain.CopyPlan, includeOverrides bool) error {
+// Execute copies the plan items as decided, one decision per item (see
+// CopyPlan.Decide), and reports the outcome of every item. The returned
+// error is the first copy failure (unless KeepGoing is set) or the context
+// error; the result is valid in both cases. A target that fills up stops
+// the execution even with KeepGoing, with a *DiskFullError.
+func (e *Executor) Execute(ctx context.Context, plan domain.CopyPlan, decisions []domain.CopyDecision) (domain.ExecutionResult, error) {
+	result := domain.ExecutionResult{RunID: e.RunID}
 	if e.FS == nil {
-		return errors.New("executor requires FS")
+		return result, errors.New("executor requires FS")
 	}
-
-	stop := e.Logger.Measure("Copying files")
-	defer stop()
-
-	overrideTargets := map[string]bool{}
-	if !includeOverrides {
-		for _, item := range plan.OverrideItems {
-			overrideTargets[item.TargetPath] = true
-		}
+	if len(decisions) != len(plan.Items) {
+		return result, fmt.Errorf("executor got %d decisions for %d items", len(decisions), len(plan.Items))
 	}
 
-	// Build list of items to copy
+	span := e.Logger.Measure("copy", "Copying files")
+	defer span.End()
+
+	// Build list of items to copy, remembering which are planned as new
 	var itemsToCopy []domain.CopyItem
-	for _, item := range plan.Items {
-		if !overrideTargets[item.TargetPath] {
-			itemsToCopy = append(itemsToCopy, item)
+	var planned []bool
+	for i, item := range plan.Items {
+		if decisions[i] == domain.DecisionSkip {
+			result.Record(item, domain.ItemSkippedOverride, nil)
+			continue
 		}
+		itemsToCopy = append(itemsToCopy, item)
+		planned = append(planned, decisions[i] == domain.DecisionCopy)
 	}
 
 	totalItems := len(itemsToCopy)
 	e.Logger.Verbosef("Copying %d of %d items", totalItems, len(plan.Items))
 
-	for i, item := range itemsToCopy {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+	var bytesTotal, bytesDone int64
+	for _, item := range itemsToCopy {
+		bytesTotal += item.FileMeta.Size
+	}
+	span.Set("files", totalItems)
+	span.Set("bytes", bytesTotal)
+	defer func() {
+		span.Set("copied", result.Copied)
+		span.Set("bytes_copied", result.BytesCopied)
+		span.Set("failed", result.Failed)
+	}()
+
+	var firstErr error
+	var diskFull *DiskFullError
+	conflicts := conflictResolver{resolve: e.OnConflict}
+	stamper, canStamp := e.FS.(Stamper)
+	stamped, unstamped := 0, 0
+	var folders *folderTracker
+	if e.Fsync || e.OnFolderDone != nil {
+		folders = newFolderTracker(e.FS, e.Fsync, e.OnFolderDone, itemsToCopy)
+	}
+	links := newLinker(e.FS, e.LinkIndex, cmp.Or(e.LinkHash, hash.DefaultManifest))
+	prober, canProbe := e.FS.(LockProber)
+	probeLocks := e.SkipLocked && canProbe
+
+	// Locked items are appended to the queue again for their one retry
+	queue := make([]int, totalItems)
+	for i := range queue {
+		queue[i] = i
+	}
+	deferred := 0
+	for n := 0; n < len(queue); n++ {
+		i := queue[n]
+		item := itemsToCopy[i]
+		retry := n >= totalItems
+		if firstErr == nil {
+			select {
+			case <-ctx.Done():
+				firstErr = ctx.Err()
+			default:
+			}
+		}
+		if firstErr != nil {
+			result.Record(item, domain.ItemCancelled, nil)
+			folders.done(item, false)
+			continue
+		}
+
+		if probeLocks {
+			locked, err := prober.Locked(item.FileMeta.SourcePath)
+			if err != nil {
+				// The copy reports whatever keeps the source from opening
+				e.Logger.Verbosef("Could not probe %s for locks: %v", item.FileMeta.Name, err)
+			}
+			if locked && !retry {
+				queue = append(queue, i)
+				deferred++
+				e.Logger.Verbosef("Deferred %s, it is open in another program", item.FileMeta.Name)
+				continue
+			}
+			if locked {
+				bytesDone += item.FileMeta.Size
+				result.Record(item, domain.ItemSkippedLocked, nil)
+				folders.done(item, false)
+				e.Logger.Verbosef("Skipped %s, it is still open in another program", item.FileMeta.Name)
+				continue
+			}
 		}
 
 		// Report progress before copying
 		if e.OnProgress != nil {
-			e.OnProgress(i, totalItems, item.FileMeta.Name)
+			e.OnProgress(n-deferred, totalItems, item.FileMeta.Name)
+		}
+
+		var err error
+		current, changed := e.sourceChanged(item)
+		if changed {
+			switch e.OnSourceChange {
+			case domain.SourceChangeSkip:
+				bytesDone += item.FileMeta.Size
+				result.Record(item, domain.ItemSkippedChanged, nil)
+				folders.done(item, false)
+				e.warn(domain.Warningf(domain.WarningSourceChanged, "Skipped %s, it changed after planning", item.FileMeta.Name))
+				continue
+			case domain.SourceChangeFail:
+				err = fmt.Errorf("%s: %w", item.FileMeta.SourcePath, ErrSourceChanged)
+			default:
+				item = current
+			}
+		}
+		if err == nil && e.OnConflict != nil && planned[i] {
+			var answer domain.ConflictAnswer
+			answer, err = conflicts.check(ctx, e.FS, item)
+			if err == nil && answer == domain.ConflictSkip {
+				bytesDone += item.FileMeta.Size
+				result.Record(item, domain.ItemSkippedOverride, nil)
+				folders.done(item, false)
+				e.Logger.Verbosef("Skipped %s, its target appeared after planning", item.FileMeta.Name)
+				continue
+			}
+			if err == nil && answer == domain.ConflictFail {
+				err = fmt.Errorf("%s: %w", item.TargetPath, ErrLateConflict)
+			}
+		}
+		linked := false
+		var sum string
+		if err == nil {
+			var existing string
+			existing, sum = links.find(item)
+			if existing != "" {
+				if linkErr := e.FS.Link(existing, item.TargetPath); linkErr == nil {
+					linked = true
+				} else {
+					e.Logger.Verbosef("Could not link %s, copying it instead: %v", item.FileMeta.Name, linkErr)
+				}
+			}
+		}
+		var sourceHash string
+		if err == nil && !linked {
+			sourceHash, err = e.copyFile(item, bytesDone, bytesTotal)
+		}
+		if linked && links.algorithm == e.Hash {
+			sourceHash = sum
+		}
+		if err == nil && e.Verify {
+			err = e.verify(item, sourceHash, &result)
 		}
+		if errors.Is(err, ErrCopyCorrupted) && !linked {
+			e.removePartial(item)
+		}
+		if err != nil && isDiskFull(err) {
+			e.removePartial(item)
+			diskFull = &DiskFullError{Free: e.freeSpace(item), Err: err}
+			firstErr = diskFull
+		}
+		bytesDone += item.FileMeta.Size
+		if err != nil {
+			result.Record(item, domain.ItemFailed, err)
+			folders.done(item, false)
+			e.Logger.Verbosef("Copy of %s failed: %v", item.FileMeta.Name, err)
+			if e.onWarning != nil {
+				e.onWarning(domain.Warningf(domain.WarningCopyFailed, "Copy of %s failed: %v", item.FileMeta.Name, err))
+			}
+			if !e.KeepGoing && diskFull == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if linked {
+			result.RecordLinked(item)
+		} else {
+			result.Record(item, domain.ItemCopied, nil)
+		}
+		result.Items[len(result.Items)-1].Hash = sourceHash
+		if changed {
+			result.MarkSourceChanged()
+			e.warn(domain.Warningf(domain.WarningSourceChanged, "%s changed after planning, copied its current version", item.FileMeta.Name))
+		}
+		folders.done(item, true)
+		links.add(sum, item.TargetPath)
+
+		// A link shares the inode, and so the stamp, of the file it
+		// links to
+		if e.StampRun != "" && !linked {
+			if canStamp && stamper.Stamp(item.TargetPath, map[string]string{StampSourceAttr: item.FileMeta.SourcePath, StampRunAttr: e.StampRun}) == nil {
+				stamped++
+			} else {
+				unstamped++
+			}
+		}
+	}
+	if e.StampRun != "" {
+		e.Logger.Verbosef("Stamped %d copied files, %d could not be stamped", stamped, unstamped)
+	}
 
-		if err := e.FS.CopyFile(item.FileMeta.SourcePath, item.TargetPath); err != nil {
-			return err
+	if e.LinkIndex != nil {
+		e.Logger.Verbosef("Linked %d files to identical ones in the target", result.Linked)
+	}
+
+	if diskFull != nil {
+		// Every item neither copied, linked included, nor skipped is left,
+		// those that failed before the target filled up too
+		diskFull.Copied, diskFull.CopiedBytes = result.Copied, result.BytesCopied
+		diskFull.Remaining = len(plan.Items) - result.Copied - result.SkippedOverrides - result.SkippedLocked - result.SkippedChanged
+		for _, r := range result.Items {
+			if r.Status == domain.ItemFailed || r.Status == domain.ItemCancelled {
+				diskFull.RemainingBytes += r.Item.FileMeta.Size
+			}
 		}
+		e.Logger.Verbosef("The target is full, stopped with %d files left", diskFull.Remaining)
+	}
+	if firstErr == nil {
+		firstErr = folders.error()
+	}
+
+	journalErr := e.appendJournal(result)
+	if firstErr != nil {
+		return result, firstErr
 	}
 
 	// Report completion
@@ -65,5 +372,333 @@ func (e *Executor) Execute(ctx context.Context, plan domain.CopyPlan, includeOve
 		e.OnProgress(totalItems, totalItems, "")
 	}
 
+	return result, journalErr
+}
+
+// folderTracker counts down the items bound for every target directory,
+// so each one is synced and reported once, after its last item, if any of
+// them was written.
+type folderTracker struct {
+	fs        FileSystem
+	sync      bool
+	onDone    FolderDoneFunc
+	remaining map[string]int
+	written   map[string]*FolderDone
+	err       error
+
+	// created marks the target directories that did not exist before the
+	// run. parents counts down the items bound below each directory one
+	// of them is created in, up to the first that existed, and
+	// parentsWritten marks those below which an item was written; their
+	// entries are synced after their last item too.
+	created        map[string]bool
+	parents        map[string]int
+	parentsWritten map[string]bool
+}
+
+func newFolderTracker(fsys FileSystem, sync bool, onDone FolderDoneFunc, items []domain.CopyItem) *folderTracker {
+	t := &folderTracker{fs: fsys, sync: sync, onDone: onDone, remaining: make(map[string]int), written: make(map[string]*FolderDone)}
+	for _, item := range items {
+		t.remaining[filepath.Dir(item.TargetPath)]++
+	}
+	if !sync {
+		return t
+	}
+	t.created, t.parents, t.parentsWritten = make(map[string]bool), make(map[string]int), make(map[string]bool)
+	checked := make(map[string]bool)
+	for dir, n := range t.remaining {
+		for d := dir; t.isNew(d, checked); d = filepath.Dir(d) {
+			t.parents[filepath.Dir(d)] += n
+		}
+	}
+	return t
+}
+
+// isNew reports whether the directory d did not exist before the run,
+// checking every directory once. A failed check counts as existing.
+func (t *folderTracker) isNew(d string, checked map[string]bool) bool {
+	if filepath.Dir(d) == d {
+		return false
+	}
+	if !checked[d] {
+		checked[d] = true
+		exists, err := t.fs.Exists(d)
+		t.created[d] = err == nil && !exists
+	}
+	return t.created[d]
+}
+
+// done records that item is finished, written or not. A nil folderTracker
+// does nothing.
+func (t *folderTracker) done(item domain.CopyItem, written bool) {
+	if t == nil {
+		return
+	}
+	dir := filepath.Dir(item.TargetPath)
+	if written {
+		if t.written[dir] == nil {
+			t.written[dir] = &FolderDone{Dir: dir}
+		}
+		t.written[dir].Files++
+		t.written[dir].Bytes += item.FileMeta.Size
+	}
+	t.remaining[dir]--
+	if t.remaining[dir] == 0 && t.written[dir] != nil && (!t.sync || t.syncDir(dir)) && t.onDone != nil {
+		t.onDone(*t.written[dir])
+	}
+
+	// The entries of the directories the copies created
+	for d := dir; t.created[d]; d = filepath.Dir(d) {
+		parent := filepath.Dir(d)
+		t.parents[parent]--
+		t.parentsWritten[parent] = t.parentsWritten[parent] || written
+		if t.parents[parent] == 0 && t.parentsWritten[parent] {
+			t.syncDir(parent)
+		}
+	}
+}
+
+// syncDir syncs dir, keeping the first failure, and reports whether it
+// succeeded.
+func (t *folderTracker) syncDir(dir string) bool {
+	if err := t.fs.SyncDir(dir); err != nil {
+		if t.err == nil {
+			t.err = fmt.Errorf("sync %s: %w", dir, err)
+		}
+		return false
+	}
+	return true
+}
+
+// error is the first failed sync.
+func (t *folderTracker) error() error {
+	if t == nil {
+		return nil
+	}
+	return t.err
+}
+
+// linker finds files in the target whose content matches a source, so it
+// can be linked instead of copied.
+type linker struct {
+	hasher    ContentHasher
+	algorithm hash.Algorithm
+	index     map[string]string
+}
+
+// newLinker returns nil, which finds nothing, without an index or when fsys
+// cannot hash. It copies index, which grows with the run.
+func newLinker(fsys FileSystem, index map[string]string, algorithm hash.Algorithm) *linker {
+	hasher, ok := fsys.(ContentHasher)
+	if index == nil || !ok {
+		return nil
+	}
+	return &linker{hasher: hasher, algorithm: algorithm, index: maps.Clone(index)}
+}
+
+// find returns the indexed file with the content of item's source, or ""
+// when there is none or its content changed since it was indexed, and the
+// hash of the source.
+func (l *linker) find(item domain.CopyItem) (existing, sum string) {
+	if l == nil {
+		return "", ""
+	}
+	sum, err := l.hasher.HashFile(item.FileMeta.SourcePath, l.algorithm)
+	if err != nil {
+		// The copy reports whatever keeps the source from being read
+		return "", ""
+	}
+	existing = l.index[sum]
+	if existing == "" || existing == item.TargetPath {
+		return "", sum
+	}
+	if current, err := l.hasher.HashFile(existing, l.algorithm); err != nil || current != sum {
+		delete(l.index, sum)
+		return "", sum
+	}
+	return existing, sum
+}
+
+// add indexes target under sum unless sum is already indexed.
+func (l *linker) add(sum, target string) {
+	if l == nil || sum == "" {
+		return
+	}
+	if _, ok := l.index[sum]; !ok {
+		l.index[sum] = target
+	}
+}
+
+// sourceChanged stats the source of item and reports whether its size or
+// modification time differs from the plan, with the item updated to the
+// current version. A source that cannot be stat'ed is left to the copy to
+// report.
+func (e *Executor) sourceChanged(item domain.CopyItem) (domain.CopyItem, bool) {
+	if item.FileMeta.ModTime.IsZero() {
+		return item, false
+	}
+	info, err := e.FS.Stat(item.FileMeta.SourcePath)
+	if err != nil || (info.Size() == item.FileMeta.Size && info.ModTime().Equal(item.FileMeta.ModTime)) {
+		return item, false
+	}
+	item.FileMeta.Size = info.Size()
+	item.FileMeta.ModTime = info.ModTime()
+	return item, true
+}
+
+// warn logs warning and streams it with ExecuteWithEvents.
+func (e *Executor) warn(warning domain.Warning) {
+	e.Logger.Verbosef("%s", warning.Text)
+	if e.onWarning != nil {
+		e.onWarning(warning)
+	}
+}
+
+// conflictResolver asks resolve about late conflicts and remembers the
+// "all" answers for the rest of the run.
+type conflictResolver struct {
+	resolve ConflictFunc
+	sticky  *domain.ConflictAnswer
+}
+
+// check returns ConflictOverwrite when the target of item is missing or may
+// be overwritten, and otherwise ConflictSkip or ConflictFail.
+func (c *conflictResolver) check(ctx context.Context, fsys FileSystem, item domain.CopyItem) (domain.ConflictAnswer, error) {
+	exists, err := fsys.Exists(item.TargetPath)
+	if err != nil || !exists {
+		return domain.ConflictOverwrite, err
+	}
+	if c.sticky != nil {
+		return c.sticky.Once(), nil
+	}
+	answer, err := c.resolve(ctx, item)
+	if err != nil {
+		return domain.ConflictFail, err
+	}
+	if answer.Sticky() {
+		c.sticky = &answer
+	}
+	return answer.Once(), nil
+}
+
+// appendJournal records result in the journal unless the run neither copied
+// anything nor completed cleanly.
+func (e *Executor) appendJournal(result domain.ExecutionResult) error {
+	if e.Journal == nil {
+		return nil
+	}
+	if result.Copied == 0 && (result.Failed > 0 || result.Cancelled > 0) {
+		return nil
+	}
+	if err := e.Journal.Append(result); err != nil {
+		return fmt.Errorf("append journal: %w", err)
+	}
+	e.Logger.Verbosef("Journaled %d copied files", result.Copied)
+	return nil
+}
+
+// removePartial removes what the failed copy of item left in the target,
+// so a later run copies it again instead of taking it for done.
+func (e *Executor) removePartial(item domain.CopyItem) {
+	remover, ok := e.FS.(Remover)
+	if !ok {
+		return
+	}
+	if err := remover.Remove(item.TargetPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		e.Logger.Verbosef("Could not remove the partial copy %s: %v", item.TargetPath, err)
+	}
+}
+
+// freeSpace is the space left in the folder of item's target, -1 when the
+// file system cannot tell.
+func (e *Executor) freeSpace(item domain.CopyItem) int64 {
+	reporter, ok := e.FS.(SpaceReporter)
+	if !ok {
+		return -1
+	}
+	free, err := reporter.FreeSpace(filepath.Dir(item.TargetPath))
+	if err != nil {
+		return -1
+	}
+	return free
+}
+
+// copyFile copies item, reporting byte progress when the file system
+// supports it. With Hash it returns the hash of the source as read for the
+// copy, hashing the source separately on file systems that cannot hash
+// while copying.
+func (e *Executor) copyFile(item domain.CopyItem, bytesDone, bytesTotal int64) (string, error) {
+	src, dst := item.FileMeta.SourcePath, item.TargetPath
+	hashingCopier, canHash := e.FS.(HashingCopier)
+	copier, canProgress := e.FS.(ProgressCopier)
+
+	var onProgress func(written int64)
+	if e.OnFileProgress != nil {
+		progress := FileProgress{
+			File:       item.FileMeta.Name,
+			Size:       item.FileMeta.Size,
+			BytesDone:  bytesDone,
+			BytesTotal: bytesTotal,
+		}
+		e.OnFileProgress(progress)
+
+		lastReport := time.Now()
+		onProgress = func(written int64) {
+			if time.Since(lastReport) < fileProgressInterval {
+				return
+			}
+			lastReport = time.Now()
+			progress.Written = written
+			progress.BytesDone = bytesDone + written
+			e.OnFileProgress(progress)
+		}
+	}
+
+	switch {
+	case e.Hash != "" && canHash:
+		return hashingCopier.CopyFileHash(src, dst, e.Hash, onProgress)
+	case e.Hash != "":
+		hasher, ok := e.FS.(ContentHasher)
+		if !ok {
+			break
+		}
+		sum, err := hasher.HashFile(src, e.Hash)
+		if err != nil {
+			return "", err
+		}
+		return sum, e.copy(copier, canProgress, src, dst, onProgress)
+	}
+	return "", e.copy(copier, canProgress, src, dst, onProgress)
+}
+
+// copy copies src to dst, with byte progress when onProgress is set and
+// the file system can report it.
+func (e *Executor) copy(copier ProgressCopier, canProgress bool, src, dst string, onProgress func(written int64)) error {
+	if onProgress == nil || !canProgress {
+		return e.FS.CopyFile(src, dst)
+	}
+	return copier.CopyFileProgress(src, dst, onProgress)
+}
+
+// verify re-reads the target of item and classifies it against the hash
+// of its source read for the copy, counting it in result.Integrity. It
+// fails with ErrCopyCorrupted for a target that does not match. Items
+// without a source hash, or on file systems that cannot hash, pass.
+func (e *Executor) verify(item domain.CopyItem, sourceHash string, result *domain.ExecutionResult) error {
+	hasher, ok := e.FS.(ContentHasher)
+	if !ok || sourceHash == "" {
+		return nil
+	}
+	check := domain.IntegrityCheck{Imported: true, Recorded: sourceHash}
+	var err error
+	if check.Target, err = hasher.HashFile(item.TargetPath, e.Hash); err != nil {
+		return fmt.Errorf("verify %s: %w", item.TargetPath, err)
+	}
+	if result.Integrity == nil {
+		result.Integrity = make(domain.IntegritySummary)
+	}
+	if class := result.Integrity.Add(check); class != domain.IntegrityOK {
+		return fmt.Errorf("%s: %s: %w", item.TargetPath, class, ErrCopyCorrupted)
+	}
 	return nil
 }
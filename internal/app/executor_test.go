@@ -0,0 +1,934 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+	"phopy/internal/hash"
+	"phopy/internal/logging"
+	"phopy/phopytest"
+)
+
+var testTime = time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+
+func copyItem(name string, size int64) domain.CopyItem {
+	meta := domain.NewFileMeta("/source/"+name, name, testTime)
+	meta.Size = size
+	return domain.CopyItem{FileMeta: meta, TargetPath: "/target/" + name}
+}
+
+// sourceFS holds the source files of items.
+func sourceFS(items ...domain.CopyItem) *phopytest.FS {
+	fsys := phopytest.NewFS()
+	for _, item := range items {
+		fsys.AddFile(item.FileMeta.SourcePath, phopytest.File{Size: item.FileMeta.Size, ModTime: item.FileMeta.TakenAt})
+	}
+	return fsys
+}
+
+func TestExecutorReportsPerItemStatus(t *testing.T) {
+	ok1 := copyItem("DSC0001.ARW", 100)
+	fail1 := copyItem("DSC0002.ARW", 200)
+	fail2 := copyItem("DSC0003.JPG", 300)
+	ok2 := copyItem("DSC0004.JPG", 400)
+	override := copyItem("DSC0005.ARW", 500)
+	override.Exists = true
+
+	plan := domain.CopyPlan{
+		Items:         []domain.CopyItem{ok1, fail1, fail2, ok2, override},
+		OverrideItems: []domain.CopyItem{override},
+	}
+	fsys := sourceFS(plan.Items...).
+		Fail(phopytest.OpCopy, fail1.FileMeta.SourcePath, errors.New("disk on fire")).
+		Fail(phopytest.OpCopy, fail2.FileMeta.SourcePath, errors.New("disk on fire"))
+
+	executor := Executor{FS: fsys, KeepGoing: true}
+	result, err := executor.Execute(context.Background(), plan, plan.Decide(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Copied != 2 || result.RawCopied != 1 || result.JpegCopied != 1 {
+		t.Fatalf("unexpected copy counts: %+v", result)
+	}
+	if result.Failed != 2 {
+		t.Fatalf("expected 2 failures, got %d", result.Failed)
+	}
+	if result.SkippedOverrides != 1 {
+		t.Fatalf("expected 1 skipped override, got %d", result.SkippedOverrides)
+	}
+	if result.BytesCopied != 500 {
+		t.Fatalf("expected 500 bytes copied, got %d", result.BytesCopied)
+	}
+	if len(result.Items) != len(plan.Items) {
+		t.Fatalf("expected a result for every item, got %d", len(result.Items))
+	}
+}
+
+func TestExecutorCancelsRemainingItemsAfterFailure(t *testing.T) {
+	first := copyItem("DSC0001.ARW", 100)
+	second := copyItem("DSC0002.ARW", 100)
+	third := copyItem("DSC0003.ARW", 100)
+
+	plan := domain.CopyPlan{Items: []domain.CopyItem{first, second, third}}
+	fsys := sourceFS(plan.Items...).Fail(phopytest.OpCopy, first.FileMeta.SourcePath, errors.New("boom"))
+
+	executor := Executor{FS: fsys}
+	result, err := executor.Execute(context.Background(), plan, plan.Decide(false))
+	if err == nil {
+		t.Fatalf("expected the copy error to be returned")
+	}
+	if result.Failed != 1 || result.Cancelled != 2 || result.Copied != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestExecuteWithEventsStreamsFailuresAndOutcome(t *testing.T) {
+	ok := copyItem("DSC0001.ARW", 100)
+	fail := copyItem("DSC0002.ARW", 200)
+	plan := domain.CopyPlan{Items: []domain.CopyItem{ok, fail}}
+	fsys := sourceFS(plan.Items...).Fail(phopytest.OpCopy, fail.FileMeta.SourcePath, errors.New("disk on fire"))
+
+	events := make(chan Event, 16)
+	executor := Executor{FS: fsys, KeepGoing: true}
+	if _, err := executor.ExecuteWithEvents(context.Background(), plan, plan.Decide(false), events); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(events)
+
+	var progress, warnings int
+	var folders []FolderDone
+	var done *ExecuteDoneEvent
+	for ev := range events {
+		switch ev := ev.(type) {
+		case CopyProgressEvent:
+			progress++
+		case FolderDoneEvent:
+			folders = append(folders, ev.FolderDone)
+		case WarningEvent:
+			warnings++
+		case ExecuteDoneEvent:
+			done = &ev
+		}
+	}
+	if progress != 3 {
+		t.Fatalf("expected 3 progress events, got %d", progress)
+	}
+	if warnings != 1 {
+		t.Fatalf("expected 1 warning for the failed copy, got %d", warnings)
+	}
+	if want := []FolderDone{{Dir: filepath.Dir(ok.TargetPath), Files: 1, Bytes: 100}}; !reflect.DeepEqual(folders, want) {
+		t.Fatalf("expected folder events %v, got %v", want, folders)
+	}
+	if done == nil || done.Result.Copied != 1 || done.Result.Failed != 1 {
+		t.Fatalf("unexpected done event: %+v", done)
+	}
+}
+
+func TestExecuteWithEventsDropsProgressWhenFull(t *testing.T) {
+	plan := domain.CopyPlan{Items: []domain.CopyItem{copyItem("DSC0001.ARW", 100), copyItem("DSC0002.ARW", 100)}}
+
+	// Keep the channel full while both files are copied so their progress
+	// events are dropped; the folder and done events wait for the consumer
+	events := make(chan Event, 1)
+	events <- WarningEvent{Message: "filler"}
+	copying := make(chan struct{})
+	executor := Executor{
+		FS: sourceFS(plan.Items...),
+		OnProgress: func(current, total int, currentFile string) {
+			if current == total-1 {
+				close(copying)
+			}
+		},
+	}
+	go func() {
+		_, _ = executor.ExecuteWithEvents(context.Background(), plan, plan.Decide(false), events)
+	}()
+
+	select {
+	case <-copying:
+	case <-time.After(time.Second):
+		t.Fatal("copy blocked on a full event channel")
+	}
+	<-events
+	if _, ok := (<-events).(FolderDoneEvent); !ok {
+		t.Fatal("expected progress events to be dropped and the folder event to be delivered")
+	}
+	for ev := range events {
+		switch ev := ev.(type) {
+		case CopyProgressEvent:
+			// The final one may find room once the folder event was read
+			if ev.Current != ev.Total {
+				t.Fatalf("expected progress of the copied files to be dropped, got %+v", ev)
+			}
+		case ExecuteDoneEvent:
+			return
+		default:
+			t.Fatalf("unexpected event %T", ev)
+		}
+	}
+}
+
+func TestExecutorReportsFileProgress(t *testing.T) {
+	first := copyItem("DSC0001.ARW", 100)
+	second := copyItem("DSC0002.MP4", 1000)
+	plan := domain.CopyPlan{Items: []domain.CopyItem{first, second}}
+
+	var reports []FileProgress
+	executor := Executor{
+		FS:             sourceFS(first, second),
+		OnFileProgress: func(progress FileProgress) { reports = append(reports, progress) },
+	}
+	if _, err := executor.Execute(context.Background(), plan, plan.Decide(false)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Every file reports its start; chunks arriving faster than the
+	// interval are throttled
+	if len(reports) != 2 {
+		t.Fatalf("expected one report per file start, got %+v", reports)
+	}
+	start := reports[1]
+	if start.File != "DSC0002.MP4" || start.Written != 0 || start.BytesDone != 100 || start.BytesTotal != 1100 {
+		t.Fatalf("unexpected start report %+v", start)
+	}
+}
+
+func TestExecutorFileProgressWithoutProgressCopier(t *testing.T) {
+	plan := domain.CopyPlan{Items: []domain.CopyItem{copyItem("DSC0001.ARW", 100)}}
+
+	// Hide CopyFileProgress of the fake
+	var reports []FileProgress
+	executor := Executor{
+		FS:             struct{ FileSystem }{sourceFS(plan.Items...)},
+		OnFileProgress: func(progress FileProgress) { reports = append(reports, progress) },
+	}
+	if _, err := executor.Execute(context.Background(), plan, plan.Decide(false)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reports) != 1 || reports[0].Written != 0 {
+		t.Fatalf("expected only the start report, got %+v", reports)
+	}
+}
+
+// recordingJournal keeps the results appended to it
+type recordingJournal struct {
+	results []domain.ExecutionResult
+}
+
+func (j *recordingJournal) Append(result domain.ExecutionResult) error {
+	j.results = append(j.results, result)
+	return nil
+}
+
+func TestExecutorJournalsSuccessAndPartialSuccess(t *testing.T) {
+	ok := copyItem("DSC0001.ARW", 100)
+	broken := copyItem("DSC0002.ARW", 200)
+	plan := domain.CopyPlan{Items: []domain.CopyItem{ok, broken}}
+	fsys := sourceFS(plan.Items...).Fail(phopytest.OpCopy, broken.FileMeta.SourcePath, errors.New("disk on fire"))
+
+	// Partial success: one file copied before the failure stopped the run
+	journal := &recordingJournal{}
+	executor := Executor{FS: fsys, Journal: journal}
+	if _, err := executor.Execute(context.Background(), plan, plan.Decide(false)); err == nil {
+		t.Fatalf("expected the copy failure")
+	}
+	if len(journal.results) != 1 || journal.results[0].Copied != 1 {
+		t.Fatalf("expected the partial run to be journaled, got %+v", journal.results)
+	}
+
+	// Nothing copied: the journal stays untouched
+	journal = &recordingJournal{}
+	executor = Executor{FS: fsys, Journal: journal}
+	if _, err := executor.Execute(context.Background(), domain.CopyPlan{Items: []domain.CopyItem{broken}}, []domain.CopyDecision{domain.DecisionCopy}); err == nil {
+		t.Fatalf("expected the copy failure")
+	}
+	if len(journal.results) != 0 {
+		t.Fatalf("did not expect a failed run to be journaled")
+	}
+
+	// Success
+	executor = Executor{FS: sourceFS(plan.Items...), Journal: journal}
+	if _, err := executor.Execute(context.Background(), plan, plan.Decide(false)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(journal.results) != 1 || journal.results[0].Copied != 2 {
+		t.Fatalf("expected the successful run to be journaled, got %+v", journal.results)
+	}
+}
+
+func TestExecutorStopsWhenTheTargetFillsUp(t *testing.T) {
+	plan := domain.CopyPlan{Items: []domain.CopyItem{
+		copyItem("DSC0000.ARW", 100),
+		copyItem("DSC0001.ARW", 100),
+		copyItem("DSC0002.ARW", 100),
+		copyItem("DSC0003.ARW", 100),
+		copyItem("DSC0004.ARW", 100),
+	}}
+	full := plan.Items[3]
+	fsys := sourceFS(plan.Items...).
+		Fail(phopytest.OpCopy, plan.Items[0].TargetPath, errors.New("read error")).
+		Fail(phopytest.OpCopy, full.TargetPath, &os.PathError{Op: "write", Path: full.TargetPath, Err: syscall.ENOSPC}).
+		Free(30)
+
+	// KeepGoing does not help once the target is full
+	journal := &recordingJournal{}
+	executor := Executor{FS: fsys, KeepGoing: true, Journal: journal}
+	result, err := executor.Execute(context.Background(), plan, plan.Decide(false))
+	var diskFull *DiskFullError
+	if !errors.As(err, &diskFull) || !errors.Is(err, syscall.ENOSPC) {
+		t.Fatalf("expected a DiskFullError, got %v", err)
+	}
+	// The item that failed before the target filled up is left as well
+	want := DiskFullError{Copied: 2, CopiedBytes: 200, Remaining: 3, RemainingBytes: 300, Free: 30}
+	if diskFull.Copied != want.Copied || diskFull.CopiedBytes != want.CopiedBytes || diskFull.Remaining != want.Remaining ||
+		diskFull.RemainingBytes != want.RemainingBytes || diskFull.Free != want.Free {
+		t.Fatalf("expected %+v, got %+v", want, *diskFull)
+	}
+	if diskFull.Missing() != 270 {
+		t.Fatalf("expected 270 bytes missing, got %d", diskFull.Missing())
+	}
+	if result.Copied != 2 || result.Failed != 2 || result.Cancelled != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	// The partial file is gone, so a rerun copies the remaining items
+	for i, item := range plan.Items[1:] {
+		if _, ok := fsys.File(item.TargetPath); ok != (i < 2) {
+			t.Errorf("%s: expected the target to exist: %v", item.FileMeta.Name, i < 2)
+		}
+	}
+	if len(journal.results) != 1 || journal.results[0].Copied != 2 {
+		t.Fatalf("expected the copied files to be journaled, got %+v", journal.results)
+	}
+}
+
+func TestDiskFullErrorWithoutFreeSpace(t *testing.T) {
+	plan := domain.CopyPlan{Items: []domain.CopyItem{copyItem("DSC0001.ARW", 100)}}
+	fsys := sourceFS(plan.Items...).Fail(phopytest.OpCopy, plan.Items[0].TargetPath, syscall.ENOSPC)
+
+	executor := Executor{FS: fsys}
+	_, err := executor.Execute(context.Background(), plan, plan.Decide(false))
+	var diskFull *DiskFullError
+	if !errors.As(err, &diskFull) {
+		t.Fatalf("expected a DiskFullError, got %v", err)
+	}
+	if diskFull.Free != -1 || diskFull.Missing() != -1 {
+		t.Fatalf("expected the free space to be unknown, got %+v", *diskFull)
+	}
+}
+
+func TestExecutorResolvesLateConflicts(t *testing.T) {
+	fresh := copyItem("DSC0001.ARW", 100)
+	skipped := copyItem("DSC0002.ARW", 100)
+	overwritten := copyItem("DSC0003.ARW", 100)
+	later := copyItem("DSC0004.ARW", 100)
+	override := copyItem("DSC0005.ARW", 100)
+	override.Exists = true
+	plan := domain.CopyPlan{
+		Items:         []domain.CopyItem{fresh, skipped, overwritten, later, override},
+		OverrideItems: []domain.CopyItem{override},
+	}
+
+	// All but the first target appear between planning and copying
+	fsys := sourceFS(plan.Items...)
+	for _, item := range plan.Items[1:] {
+		fsys.AddFile(item.TargetPath, phopytest.File{Size: 1})
+	}
+
+	answers := []domain.ConflictAnswer{domain.ConflictSkip, domain.ConflictOverwriteAll}
+	var asked []string
+	executor := Executor{FS: fsys, OnConflict: func(_ context.Context, item domain.CopyItem) (domain.ConflictAnswer, error) {
+		asked = append(asked, item.FileMeta.Name)
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	}}
+	result, err := executor.Execute(context.Background(), plan, plan.Decide(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(asked, ",") != "DSC0002.ARW,DSC0003.ARW" {
+		t.Fatalf("expected to be asked about the two conflicts before overwrite all, got %v", asked)
+	}
+	if result.Copied != 4 || result.SkippedOverrides != 1 || result.Items[1].Status != domain.ItemSkippedOverride {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestExecutorFailsLateConflicts(t *testing.T) {
+	item := copyItem("DSC0001.ARW", 100)
+	next := copyItem("DSC0002.ARW", 100)
+	plan := domain.CopyPlan{Items: []domain.CopyItem{item, next}}
+	fsys := sourceFS(plan.Items...).AddFile(item.TargetPath, phopytest.File{Size: 1})
+
+	executor := Executor{FS: fsys, KeepGoing: true, OnConflict: AnswerConflicts(domain.ConflictFail)}
+	result, err := executor.Execute(context.Background(), plan, plan.Decide(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Failed != 1 || result.Copied != 1 || !errors.Is(result.Items[0].Err, ErrLateConflict) {
+		t.Fatalf("expected the conflict to fail only its item, got %+v", result)
+	}
+}
+
+func TestExecuteWithEventsAsksAboutLateConflicts(t *testing.T) {
+	first := copyItem("DSC0001.ARW", 100)
+	second := copyItem("DSC0002.ARW", 100)
+	plan := domain.CopyPlan{Items: []domain.CopyItem{first, second}}
+	fsys := sourceFS(plan.Items...).
+		AddFile(first.TargetPath, phopytest.File{Size: 1}).
+		AddFile(second.TargetPath, phopytest.File{Size: 1})
+
+	events := make(chan Event)
+	asked := make(chan string, 2)
+	go func() {
+		for ev := range events {
+			if ev, ok := ev.(ConflictEvent); ok {
+				asked <- ev.Item.FileMeta.Name
+				ev.Reply <- domain.ConflictSkipAll
+			}
+		}
+	}()
+	defer close(events)
+
+	executor := Executor{FS: fsys, OnConflict: AskConflicts(events)}
+	result, err := executor.ExecuteWithEvents(context.Background(), plan, plan.Decide(false), events)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.SkippedOverrides != 2 || result.Copied != 0 {
+		t.Fatalf("expected skip all to skip both files, got %+v", result)
+	}
+	if len(asked) != 1 {
+		t.Fatalf("expected a single question, got %d", len(asked))
+	}
+}
+
+func TestExecutorStampsCopiedFiles(t *testing.T) {
+	copied := copyItem("DSC0001.ARW", 100)
+	failed := copyItem("DSC0002.ARW", 200)
+	unstampable := copyItem("DSC0003.JPG", 300)
+	plan := domain.CopyPlan{Items: []domain.CopyItem{copied, failed, unstampable}}
+	fsys := sourceFS(plan.Items...).
+		Fail(phopytest.OpCopy, failed.FileMeta.SourcePath, errors.New("disk on fire")).
+		Fail(phopytest.OpStamp, unstampable.TargetPath, errors.New("operation not supported"))
+
+	var logs bytes.Buffer
+	executor := Executor{FS: fsys, Logger: logging.New(&logs, true), KeepGoing: true, StampRun: "20241002T150100-abcd"}
+	result, err := executor.Execute(context.Background(), plan, plan.Decide(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Copied != 2 {
+		t.Fatalf("expected a failed stamp to keep the copy, got %+v", result)
+	}
+
+	want := map[string]string{StampSourceAttr: copied.FileMeta.SourcePath, StampRunAttr: "20241002T150100-abcd"}
+	if got := fsys.Stamps(copied.TargetPath); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := fsys.Stamps(failed.TargetPath); got != nil {
+		t.Fatalf("did not expect a stamp on a failed copy, got %v", got)
+	}
+	if !strings.Contains(logs.String(), "Stamped 1 copied files, 1 could not be stamped") {
+		t.Fatalf("expected the stamp counts in the log, got:\n%s", logs.String())
+	}
+
+	// Without a run id nothing is stamped
+	fsys = sourceFS(copied)
+	if _, err := (&Executor{FS: fsys}).Execute(context.Background(), domain.CopyPlan{Items: []domain.CopyItem{copied}}, []domain.CopyDecision{domain.DecisionCopy}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fsys.Stamps(copied.TargetPath); got != nil {
+		t.Fatalf("expected no stamp without StampRun, got %v", got)
+	}
+}
+
+func TestExecutorFollowsDecisionsForSharedTargets(t *testing.T) {
+	// A new item and an override that share a target path
+	fresh := copyItem("DSC0001.ARW", 100)
+	override := copyItem("DSC0001.ARW", 200)
+	override.FileMeta.SourcePath = "/source/101/DSC0001.ARW"
+	override.Exists = true
+	plan := domain.CopyPlan{
+		Items:         []domain.CopyItem{fresh, override},
+		OverrideItems: []domain.CopyItem{override},
+	}
+
+	result, err := (&Executor{FS: sourceFS(plan.Items...)}).Execute(context.Background(), plan, plan.Decide(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statuses := map[string]domain.ItemStatus{}
+	for _, item := range result.Items {
+		statuses[item.Item.FileMeta.SourcePath] = item.Status
+	}
+	if statuses[fresh.FileMeta.SourcePath] != domain.ItemCopied || statuses[override.FileMeta.SourcePath] != domain.ItemSkippedOverride {
+		t.Fatalf("expected only the override to be skipped, got %v", statuses)
+	}
+}
+
+func TestExecutorOverwritesStaleTargetsWithoutAsking(t *testing.T) {
+	fresh := copyItem("DSC0001.ARW", 100)
+	stale := copyItem("DSC0002.ARW", 200)
+	stale.Exists, stale.AutoOverride = true, true
+	asked := copyItem("DSC0003.ARW", 300)
+	asked.Exists = true
+	plan := domain.CopyPlan{
+		Items:             []domain.CopyItem{fresh, stale, asked},
+		OverrideItems:     []domain.CopyItem{asked},
+		AutoOverrideItems: []domain.CopyItem{stale},
+	}
+
+	for _, confirmed := range []bool{false, true} {
+		fsys := sourceFS(plan.Items...).
+			AddFile(stale.TargetPath, phopytest.File{Size: 20, ModTime: testTime.Add(-time.Hour)}).
+			AddFile(asked.TargetPath, phopytest.File{Size: 300, ModTime: testTime.Add(time.Hour)})
+		result, err := (&Executor{FS: fsys}).Execute(context.Background(), plan, plan.Decide(confirmed))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]domain.ItemStatus{
+			fresh.FileMeta.SourcePath: domain.ItemCopied,
+			stale.FileMeta.SourcePath: domain.ItemCopied,
+			asked.FileMeta.SourcePath: domain.ItemSkippedOverride,
+		}
+		if confirmed {
+			want[asked.FileMeta.SourcePath] = domain.ItemCopied
+		}
+		statuses := map[string]domain.ItemStatus{}
+		for _, item := range result.Items {
+			statuses[item.Item.FileMeta.SourcePath] = item.Status
+		}
+		if !reflect.DeepEqual(statuses, want) {
+			t.Fatalf("confirmed %t: expected %v, got %v", confirmed, want, statuses)
+		}
+		if info, err := fsys.Stat(stale.TargetPath); err != nil || info.Size() != 200 {
+			t.Fatalf("confirmed %t: expected the stale target to be overwritten, got %v, %v", confirmed, info, err)
+		}
+	}
+}
+
+func TestExecutorNeedsADecisionPerItem(t *testing.T) {
+	plan := domain.CopyPlan{Items: []domain.CopyItem{copyItem("DSC0001.ARW", 100)}}
+	if _, err := (&Executor{FS: sourceFS(plan.Items...)}).Execute(context.Background(), plan, nil); err == nil {
+		t.Fatalf("expected an error without decisions")
+	}
+}
+
+func TestExecutorRetriesLockedFilesOnceAtTheEnd(t *testing.T) {
+	released := copyItem("DSC0001.ARW", 100)
+	free := copyItem("DSC0002.ARW", 200)
+	held := copyItem("DSC0003.ARW", 300)
+	plan := domain.CopyPlan{Items: []domain.CopyItem{released, free, held}}
+	fsys := sourceFS(plan.Items...)
+	probes := map[string]int{}
+	fsys.Locks = func(path string) bool {
+		probes[path]++
+		switch path {
+		case released.FileMeta.SourcePath:
+			// The other program closes it before the retry
+			return probes[path] == 1
+		case held.FileMeta.SourcePath:
+			return true
+		}
+		return false
+	}
+
+	executor := Executor{FS: fsys, SkipLocked: true}
+	result, err := executor.Execute(context.Background(), plan, plan.Decide(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Copied != 2 || result.SkippedLocked != 1 || result.Failed != 0 {
+		t.Fatalf("expected 2 copied and 1 still locked, got %+v", result)
+	}
+	locked := result.LockedItems()
+	if len(locked) != 1 || locked[0].Item.FileMeta.Name != "DSC0003.ARW" || locked[0].Status.String() != "skipped-locked" {
+		t.Fatalf("expected DSC0003.ARW to stay locked, got %+v", locked)
+	}
+	var order []string
+	for _, c := range fsys.Copies() {
+		order = append(order, filepath.Base(c.Src))
+	}
+	if want := []string{"DSC0002.ARW", "DSC0001.ARW"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("expected the locked file to be copied after the others, got %v", order)
+	}
+	if probes[held.FileMeta.SourcePath] != 2 {
+		t.Fatalf("expected a locked file to be probed twice, got %d", probes[held.FileMeta.SourcePath])
+	}
+
+	// Without SkipLocked nothing is probed
+	probes = map[string]int{}
+	fsys = sourceFS(free)
+	fsys.Locks = func(string) bool { probes[free.FileMeta.SourcePath]++; return true }
+	result, err = (&Executor{FS: fsys}).Execute(context.Background(), domain.CopyPlan{Items: []domain.CopyItem{free}}, []domain.CopyDecision{domain.DecisionCopy})
+	if err != nil || result.Copied != 1 || len(probes) != 0 {
+		t.Fatalf("expected an unprobed copy, got %+v, %v, %v", result, err, probes)
+	}
+}
+
+func TestExecutorSyncsEachTargetDirectoryOnce(t *testing.T) {
+	inDir := func(dir, name string) domain.CopyItem {
+		item := copyItem(name, 10)
+		item.TargetPath = filepath.Join("/target", dir, name)
+		return item
+	}
+	plan := domain.CopyPlan{Items: []domain.CopyItem{
+		inDir("2024-10-02", "DSC0001.ARW"),
+		inDir("2024-10-03", "DSC0002.ARW"),
+		inDir("2024-10-02", "DSC0003.ARW"),
+		inDir("2024-10-04", "DSC0004.ARW"), // fails, so nothing to sync
+		inDir("2024-10-03", "DSC0005.ARW"), // fails after its folder was written
+	}}
+	fsys := sourceFS(plan.Items...).
+		Fail(phopytest.OpCopy, plan.Items[3].FileMeta.SourcePath, errors.New("disk on fire")).
+		Fail(phopytest.OpCopy, plan.Items[4].FileMeta.SourcePath, errors.New("disk on fire"))
+	if err := fsys.MkdirAll("/target", 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	executor := Executor{FS: fsys, KeepGoing: true, Fsync: true}
+	if _, err := executor.Execute(context.Background(), plan, plan.Decide(false)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Each folder is synced after its last item, not after every file, and
+	// the target once the folders created in it are done
+	want := []string{"/target/2024-10-02", "/target/2024-10-03", "/target"}
+	if got := fsys.Syncs(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected one sync per written folder %v, got %v", want, got)
+	}
+
+	// Every folder created up to the existing target has its entry synced
+	nested := []domain.CopyItem{inDir("2024/2024-10-02", "DSC0001.ARW"), inDir("2024/2024-10-03", "DSC0002.ARW")}
+	fsys = sourceFS(nested...)
+	if err := fsys.MkdirAll("/target", 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	executor = Executor{FS: fsys, Fsync: true}
+	if _, err := executor.Execute(context.Background(), domain.CopyPlan{Items: nested}, []domain.CopyDecision{domain.DecisionCopy, domain.DecisionCopy}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = []string{"/target/2024/2024-10-02", "/target/2024/2024-10-03", "/target/2024", "/target"}
+	if got := fsys.Syncs(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the new folders and their parents synced %v, got %v", want, got)
+	}
+
+	// A failed sync fails the execution
+	fsys = sourceFS(plan.Items[0]).Fail(phopytest.OpSyncDir, "/target/2024-10-02", errors.New("I/O error"))
+	executor = Executor{FS: fsys, Fsync: true}
+	_, err := executor.Execute(context.Background(), domain.CopyPlan{Items: plan.Items[:1]}, []domain.CopyDecision{domain.DecisionCopy})
+	if err == nil || !strings.Contains(err.Error(), "sync /target/2024-10-02") {
+		t.Fatalf("expected the sync error, got %v", err)
+	}
+
+	// Without Fsync nothing is synced
+	fsys = sourceFS(plan.Items[0])
+	if _, err := (&Executor{FS: fsys}).Execute(context.Background(), domain.CopyPlan{Items: plan.Items[:1]}, []domain.CopyDecision{domain.DecisionCopy}); err != nil || len(fsys.Syncs()) != 0 {
+		t.Fatalf("expected no syncs without Fsync, got %v, %v", fsys.Syncs(), err)
+	}
+}
+
+func TestExecutorLinksIdenticalFilesAlreadyInTheTarget(t *testing.T) {
+	plan := domain.CopyPlan{Items: []domain.CopyItem{
+		copyItem("DSC0001.ARW", 1), // same as an imported file
+		copyItem("DSC0002.ARW", 1), // new content
+		copyItem("DSC0003.ARW", 1), // same as the copy of DSC0002
+		copyItem("DSC0004.ARW", 1), // indexed file changed since
+		copyItem("DSC0005.ARW", 1), // link fails across volumes
+	}}
+	fsys := phopytest.NewFS()
+	for i, data := range []string{"a", "b", "b", "c", "d"} {
+		fsys.AddFile(plan.Items[i].FileMeta.SourcePath, phopytest.File{Data: []byte(data)})
+	}
+	fsys.AddFile("/target/old/a.ARW", phopytest.File{Data: []byte("a")})
+	fsys.AddFile("/target/old/c.ARW", phopytest.File{Data: []byte("changed")})
+	fsys.AddFile("/target/other-volume/d.ARW", phopytest.File{Data: []byte("d")})
+	fsys.Fail(phopytest.OpLink, plan.Items[4].TargetPath, &os.LinkError{Op: "link", Err: syscall.EXDEV})
+	// The index was recorded with another hash than the default one
+	sumOf := func(path string) string {
+		sum, err := fsys.HashFile(path, hash.BLAKE3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return sum
+	}
+	index := map[string]string{
+		sumOf(plan.Items[0].FileMeta.SourcePath): "/target/old/a.ARW",
+		sumOf(plan.Items[3].FileMeta.SourcePath): "/target/old/c.ARW",
+		sumOf(plan.Items[4].FileMeta.SourcePath): "/target/other-volume/d.ARW",
+	}
+
+	executor := Executor{FS: fsys, LinkIndex: index, LinkHash: hash.BLAKE3, StampRun: "20241002T150100-abcd"}
+	result, err := executor.Execute(context.Background(), plan, plan.Decide(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Copied != 5 || result.Linked != 2 {
+		t.Fatalf("expected 5 copied of which 2 linked, got %d and %d", result.Copied, result.Linked)
+	}
+	if result.BytesCopied != 3 {
+		t.Fatalf("expected only the 3 bytes written counted as copied, got %d", result.BytesCopied)
+	}
+	for i, item := range plan.Items {
+		if stamped := fsys.Stamps(item.TargetPath) != nil; stamped == (i == 0 || i == 2) {
+			t.Fatalf("expected only the written copies stamped, got %v for %s", stamped, item.FileMeta.Name)
+		}
+	}
+	wantLinks := []phopytest.Copy{
+		{Src: "/target/old/a.ARW", Dst: "/target/DSC0001.ARW"},
+		{Src: "/target/DSC0002.ARW", Dst: "/target/DSC0003.ARW"},
+	}
+	if got := fsys.Links(); !reflect.DeepEqual(got, wantLinks) {
+		t.Fatalf("expected links %v, got %v", wantLinks, got)
+	}
+	var copied []string
+	for _, c := range fsys.Copies() {
+		copied = append(copied, filepath.Base(c.Dst))
+	}
+	if want := []string{"DSC0002.ARW", "DSC0004.ARW", "DSC0005.ARW"}; !reflect.DeepEqual(copied, want) {
+		t.Fatalf("expected copies of %v, got %v", want, copied)
+	}
+	for i, item := range result.Items {
+		if item.Status != domain.ItemCopied || item.Linked != (i == 0 || i == 2) {
+			t.Fatalf("unexpected result for %s: %+v", item.Item.FileMeta.Name, item)
+		}
+	}
+	if _, ok := index[sumOf(plan.Items[1].FileMeta.SourcePath)]; ok {
+		t.Fatalf("expected the caller's index to stay unchanged")
+	}
+}
+
+func TestExecutorReportsEachFolderWhenItsLastItemLands(t *testing.T) {
+	inDir := func(dir, name string, size int64) domain.CopyItem {
+		item := copyItem(name, size)
+		item.TargetPath = filepath.Join("/target", dir, name)
+		return item
+	}
+	plan := domain.CopyPlan{Items: []domain.CopyItem{
+		inDir("2024-10-02", "DSC0001.ARW", 10),
+		inDir("2024-10-03", "DSC0002.ARW", 20),
+		inDir("2024-10-02", "DSC0003.ARW", 30),
+		inDir("2024-10-04", "DSC0004.ARW", 40), // fails, nothing landed
+		inDir("2024-10-03", "DSC0005.ARW", 50), // fails after its folder was written
+		inDir("2024-10-05", "DSC0006.ARW", 60),
+	}}
+	fsys := sourceFS(plan.Items...).
+		Fail(phopytest.OpCopy, plan.Items[3].FileMeta.SourcePath, errors.New("disk on fire")).
+		Fail(phopytest.OpCopy, plan.Items[4].FileMeta.SourcePath, errors.New("disk on fire"))
+
+	// The log interleaves the files about to be copied with finished folders
+	var log []string
+	executor := Executor{
+		FS:         fsys,
+		KeepGoing:  true,
+		OnProgress: func(_, _ int, file string) { log = append(log, file) },
+		OnFolderDone: func(done FolderDone) {
+			log = append(log, fmt.Sprintf("%s: %d files, %d bytes", filepath.Base(done.Dir), done.Files, done.Bytes))
+		},
+	}
+	if _, err := executor.Execute(context.Background(), plan, plan.Decide(false)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"DSC0001.ARW", "DSC0002.ARW", "DSC0003.ARW",
+		"2024-10-02: 2 files, 40 bytes",
+		"DSC0004.ARW", "DSC0005.ARW",
+		"2024-10-03: 1 files, 20 bytes",
+		"DSC0006.ARW",
+		"2024-10-05: 1 files, 60 bytes",
+		"",
+	}
+	if !reflect.DeepEqual(log, want) {
+		t.Fatalf("expected\n%q\ngot\n%q", want, log)
+	}
+
+	// With Fsync a folder is reported after its sync, and not when it failed
+	fsys = sourceFS(plan.Items[:2]...).Fail(phopytest.OpSyncDir, "/target/2024-10-03", errors.New("I/O error"))
+	var reported []string
+	executor = Executor{FS: fsys, Fsync: true, OnFolderDone: func(done FolderDone) {
+		if synced := fsys.Syncs(); synced[len(synced)-1] != done.Dir {
+			t.Errorf("expected %s synced before it was reported, got %v", done.Dir, synced)
+		}
+		reported = append(reported, done.Dir)
+	}}
+	_, err := executor.Execute(context.Background(), domain.CopyPlan{Items: plan.Items[:2]}, []domain.CopyDecision{domain.DecisionCopy, domain.DecisionCopy})
+	if err == nil || !reflect.DeepEqual(reported, []string{"/target/2024-10-02"}) {
+		t.Fatalf("expected only the synced folder reported, got %v, %v", reported, err)
+	}
+}
+
+func TestPlanAndCopyNeverWriteToAReadOnlySource(t *testing.T) {
+	sourceDir := "/card"
+	clip := filepath.Join(sourceDir, "PRIVATE", "M4ROOT", "CLIP", "C0001.MP4")
+	photo := filepath.Join(sourceDir, "DCIM", "100MSDCF", "DSC0001.ARW")
+	fsys := phopytest.NewFS().
+		AddFile(clip, phopytest.File{ModTime: testTime, Data: []byte("\x00\x00\x00\x20ftypXAVC\x00\x00\x00\x00")}).
+		AddFile(filepath.Join(sourceDir, "PRIVATE", "M4ROOT", "CLIP", "C0001M01.XML"), phopytest.File{ModTime: testTime, Size: 2}).
+		AddFile(photo, phopytest.File{ModTime: testTime, Size: 100}).
+		AddFile("/target/2024-10-02/DSC0001.ARW", phopytest.File{ModTime: testTime}).
+		ReadOnly(sourceDir)
+
+	planner := Planner{
+		FS:             fsys,
+		Exif:           phopytest.NewExif().SetTakenAt(photo, testTime),
+		Layout:         domain.Layout{Dir: "{date}", Flatten: true},
+		Sniff:          true,
+		AllowOverride:  true,
+		CompanionGlobs: domain.DefaultCompanionGlobs,
+	}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 3 {
+		t.Fatalf("expected the clip, its XML and the photo, got %+v", plan.Items)
+	}
+
+	executor := Executor{FS: fsys, StampRun: "20241002T150100-abcd", Fsync: true}
+	result, err := executor.Execute(context.Background(), plan, plan.Decide(true))
+	if err != nil {
+		t.Fatalf("expected a read-only source to copy, got %v", err)
+	}
+	if result.Copied != 3 || result.Failed != 0 {
+		t.Fatalf("expected every file copied, got %+v", result)
+	}
+}
+
+func TestExecutorHandlesSourcesChangedAfterPlanning(t *testing.T) {
+	sourceDir := "/card"
+	rewritten := filepath.Join(sourceDir, "DSC0001.JPG")
+	untouched := filepath.Join(sourceDir, "DSC0002.JPG")
+	plan := func(t *testing.T) (*phopytest.FS, domain.CopyPlan) {
+		t.Helper()
+		fsys := phopytest.NewFS().
+			AddFile(rewritten, phopytest.File{Data: []byte("planned"), ModTime: testTime}).
+			AddFile(untouched, phopytest.File{Data: []byte("same"), ModTime: testTime})
+		exif := phopytest.NewExif().SetTakenAt(rewritten, testTime).SetTakenAt(untouched, testTime)
+		plan, err := (&Planner{FS: fsys, Exif: exif}).Plan(context.Background(), sourceDir, "/target", nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// A sync client rewrites the JPEG after planning
+		fsys.AddFile(rewritten, phopytest.File{Data: []byte("rewritten by sync"), ModTime: testTime.Add(time.Minute)})
+		return fsys, plan
+	}
+
+	t.Run("copy", func(t *testing.T) {
+		fsys, plan := plan(t)
+		var warnings []domain.Warning
+		executor := Executor{FS: fsys}
+		executor.onWarning = func(w domain.Warning) { warnings = append(warnings, w) }
+		result, err := executor.Execute(context.Background(), plan, plan.Decide(false))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Copied != 2 || result.SourceChanged != 1 {
+			t.Fatalf("expected both copied, one changed, got %+v", result)
+		}
+		changed := result.Items[0]
+		if !changed.SourceChanged || changed.Item.FileMeta.Size != int64(len("rewritten by sync")) || !changed.Item.FileMeta.ModTime.Equal(testTime.Add(time.Minute)) {
+			t.Fatalf("expected the result to record the copied version, got %+v", changed)
+		}
+		if result.Items[1].SourceChanged {
+			t.Fatalf("did not expect the untouched file to be marked")
+		}
+		if file, _ := fsys.File("/target/DSC0001.JPG"); string(file.Data) != "rewritten by sync" {
+			t.Fatalf("expected the current version copied, got %q", file.Data)
+		}
+		if len(warnings) != 1 || warnings[0].Code != domain.WarningSourceChanged {
+			t.Fatalf("expected a source_changed warning, got %v", warnings)
+		}
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		fsys, plan := plan(t)
+		result, err := (&Executor{FS: fsys, OnSourceChange: domain.SourceChangeSkip}).Execute(context.Background(), plan, plan.Decide(false))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Copied != 1 || result.SkippedChanged != 1 || result.Items[0].Status != domain.ItemSkippedChanged {
+			t.Fatalf("expected the changed file skipped, got %+v", result)
+		}
+		if _, ok := fsys.File("/target/DSC0001.JPG"); ok {
+			t.Fatalf("did not expect the changed file to be copied")
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		fsys, plan := plan(t)
+		result, err := (&Executor{FS: fsys, OnSourceChange: domain.SourceChangeFail, KeepGoing: true}).Execute(context.Background(), plan, plan.Decide(false))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Failed != 1 || !errors.Is(result.Items[0].Err, ErrSourceChanged) || result.Copied != 1 {
+			t.Fatalf("expected the changed file to fail, got %+v", result)
+		}
+	})
+}
+
+// corruptingFS copies like FS but writes other content to targets of bad.
+type corruptingFS struct {
+	*phopytest.FS
+	bad string
+}
+
+func (f corruptingFS) CopyFile(src, dst string) error {
+	if err := f.FS.CopyFile(src, dst); err != nil {
+		return err
+	}
+	if src == f.bad {
+		f.AddFile(dst, phopytest.File{Data: []byte("bit rot")})
+	}
+	return nil
+}
+
+func TestExecutorVerifiesCopiesAgainstTheSourceRead(t *testing.T) {
+	good := copyItem("DSC0001.ARW", 3)
+	bad := copyItem("DSC0002.ARW", 3)
+	plan := domain.CopyPlan{Items: []domain.CopyItem{good, bad}}
+	fsys := phopytest.NewFS().
+		AddFile(good.FileMeta.SourcePath, phopytest.File{Data: []byte("one"), ModTime: testTime}).
+		AddFile(bad.FileMeta.SourcePath, phopytest.File{Data: []byte("two"), ModTime: testTime})
+
+	executor := Executor{FS: corruptingFS{FS: fsys, bad: bad.FileMeta.SourcePath}, KeepGoing: true, Hash: hash.SHA256, Verify: true}
+	result, err := executor.Execute(context.Background(), plan, plan.Decide(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, _ := hash.SHA256.Sum(strings.NewReader("one"))
+	if result.Copied != 1 || result.Items[0].Hash != want {
+		t.Fatalf("expected the good copy with its source hash, got %+v", result.Items[0])
+	}
+	if result.Failed != 1 || !errors.Is(result.Items[1].Err, ErrCopyCorrupted) {
+		t.Fatalf("expected the bad copy to fail verification, got %+v", result.Items[1])
+	}
+	if result.Integrity[domain.IntegrityOK] != 1 || result.Integrity[domain.IntegrityTargetCorrupted] != 1 {
+		t.Fatalf("expected one intact and one corrupted copy, got %v", result.Integrity)
+	}
+	if _, ok := fsys.File(bad.TargetPath); ok {
+		t.Fatalf("expected the corrupted copy to be removed")
+	}
+
+	// Without Verify the hashes are recorded, the targets not re-read
+	executor = Executor{FS: corruptingFS{FS: fsys, bad: bad.FileMeta.SourcePath}, Hash: hash.SHA256}
+	result, err = executor.Execute(context.Background(), plan, plan.Decide(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Copied != 2 || result.Items[0].Hash != want || result.Integrity != nil {
+		t.Fatalf("expected both copies with their hashes and no verification, got %+v", result)
+	}
+}
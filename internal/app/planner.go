@@ -1,6 +1,7 @@
 package app
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -12,12 +13,22 @@ import (
 	"time"
 
 	"phopy/internal/domain"
+	"phopy/internal/hash"
 	"phopy/internal/logging"
+
+	"golang.org/x/sync/errgroup"
 )
 
 // ProgressFunc is called during scanning to report progress
 type ProgressFunc func(current, total int)
 
+// errExifSkipped stands in for the EXIF result of fast plans.
+var errExifSkipped = errors.New("EXIF skipped")
+
+// DefaultDateFloor is the earliest capture date trusted when
+// Planner.DateFloor is not set.
+var DefaultDateFloor = time.Date(1990, 1, 1, 0, 0, 0, 0, time.Local)
+
 type Planner struct {
 	FS            FileSystem
 	Exif          ExifReader
@@ -25,55 +36,224 @@ type Planner struct {
 	Logger        logging.Logger
 	OnProgress    ProgressFunc
 	AllowOverride bool
+	Layout        domain.Layout
+	// Sniff classifies files with unknown extensions by their content.
+	Sniff bool
+	// CheckTimezone warns about files whose date folder would change if the
+	// camera's recorded UTC offset were converted to TimeZone.
+	CheckTimezone bool
+	// TimeZone is the zone dates are compared in; nil uses the local zone.
+	TimeZone *time.Location
+	// DateFloor is the earliest plausible capture date; EXIF dates before it
+	// (like 1970 from a reset clock) are treated as missing. The zero value
+	// uses DefaultDateFloor.
+	DateFloor time.Time
+	// DirDates dates files without a usable EXIF date by the folders they
+	// are in, before falling back to the modification time.
+	DirDates domain.DirDatePattern
+	// InclusiveEnd keeps the range boundary of earlier versions: endDate is
+	// the last included second. By default the range is half-open, so
+	// endDate is the first excluded instant and capture times are compared
+	// with their sub-second fraction.
+	InclusiveEnd bool
+	// Dedupe leaves out files that are the same capture as another source
+	// file, see dedupe.
+	Dedupe bool
+	// DedupeHash hashes the files Dedupe compares by content; empty uses
+	// hash.DefaultDedupe.
+	DedupeHash hash.Algorithm
+	// History is the journal of the target. With earlier imports the plan
+	// reports what changed since the last one.
+	History History
+	// Fast skips EXIF entirely and dates every file by its modification
+	// time, for quick previews of large cards. The plan is marked as
+	// having approximate dates.
+	Fast bool
+	// WarningCap is how many warnings the plan keeps; 0 uses
+	// domain.DefaultWarningCap. Further warnings are counted in
+	// TruncatedWarnings and handed to WarningSpill when it is set.
+	WarningCap   int
+	WarningSpill WarningSpill
+	// CompanionGlobs match files, like Sony clip metadata, that are copied
+	// next to the planned file they belong to by name (see
+	// domain.IsCompanionOf). Companions without one are left out.
+	CompanionGlobs []string
+	// IncludeMisc copies known housekeeping files of the camera (see
+	// domain.IsMiscFile) below MISC in the target, by their source path.
+	IncludeMisc bool
+	// KeepJunk plans the metadata files operating systems leave on cards
+	// (see domain.IsJunkFile) like any other file instead of skipping them.
+	KeepJunk bool
+	// SameSecondLimit is how many files may share a capture second before
+	// their dates are reported as suspect; 0 uses
+	// domain.DefaultSameSecondLimit. Known default dates of camera clocks
+	// are reported regardless.
+	SameSecondLimit int
+	// PathLimits are the longest names and paths the target file system
+	// takes. A plan with targets beyond them fails with a
+	// *domain.PathLimitError rather than have them truncated or refused
+	// while copying. The zero value checks nothing.
+	PathLimits domain.PathLimits
+	// SourceVolume is the volume of the source, recorded in the plan.
+	SourceVolume domain.Volume
+	// TargetWorkers is how many targets are checked for existence at once,
+	// apart from ExifWorkers since the target may be network storage while
+	// the source is a local card; 0 uses one per CPU.
+	TargetWorkers int
+	// Stop, when closed, ends the EXIF scan early: the files inspected so
+	// far are planned and the rest are left out of a plan marked Partial.
+	Stop <-chan struct{}
+	// IncludeProxies plans the low-resolution proxies cameras record
+	// alongside their clips (see domain.IsVideoProxy) instead of skipping
+	// them.
+	IncludeProxies bool
+	// Paths selects the files planned by their path relative to the
+	// source (--include and --exclude). Folders no file can be selected
+	// from are not walked.
+	Paths domain.PathFilter
+	// AutoOverrideStale approves the overrides of stale targets without
+	// asking, see domain.IsStaleTarget; it takes AllowOverride.
+	AutoOverrideStale bool
+
+	onWarning func(warning domain.Warning)
+	// targets lists the target directories of a plan, see targetExists
+	targets *targetNames
+}
+
+// scanResult is what scan collected before the plan is assembled.
+type scanResult struct {
+	metas           []domain.FileMeta
+	warnings        *warningList
+	skippedJPEGs    int
+	skippedRAWsDupl int
+	sniffedFiles    int
+	junkFiles       int
+	proxyClips      int
+	zoneBoundary    int
+	invalidDates    int
+	outsideRange    domain.RangeExclusions
+	pairings        []domain.JPEGPairing
+	leftovers       []domain.Leftover
+	discovered      int
+	unscanned       int
+	// companions and misc files are planned once the items are known
+	companions []candidate
+	misc       []candidate
+
+	// RAWs skipped by the date filter, by the modification time shortcut
+	// or by their capture date
+	skippedByModTime     int
+	skippedByCaptureDate int
 }
 
 // shouldIncludeSource checks if a source file should be included in the plan.
 // Returns false if the target file already exists and AllowOverride is false.
+// Layouts that depend on the capture date can only be checked after the EXIF
+// read, so those files are always included here.
 func (p *Planner) shouldIncludeSource(sourcePath, sourceDir, targetDir string) bool {
-	if p.AllowOverride {
+	if p.AllowOverride || p.Layout.NeedsDate() {
 		return true
 	}
 	rel, err := filepath.Rel(sourceDir, sourcePath)
 	if err != nil {
 		return true // fallback to include
 	}
-	targetPath := filepath.Join(targetDir, rel)
-	exists, _ := p.FS.Exists(targetPath)
+	targetPath := filepath.Join(targetDir, p.Layout.TargetRel(domain.NewFileMeta(sourcePath, rel, time.Time{})))
+	_, exists, _ := p.targetExists(targetPath)
 	return !exists
 }
 
 func (p *Planner) Plan(ctx context.Context, sourceDir, targetDir string, startDate, endDate *time.Time) (domain.CopyPlan, error) {
-	if p.FS == nil || p.Exif == nil {
+	if p.FS == nil || (p.Exif == nil && !p.Fast) {
 		return domain.CopyPlan{}, errors.New("planner requires FS and Exif")
 	}
 
-	stop := p.Logger.Measure("Planning copy")
-	defer stop()
+	span := p.Logger.Measure("plan", "Planning copy")
+	defer span.End()
+	started := time.Now()
+	p.targets = newTargetNames(p.FS)
 
-	metas, warnings, skippedJPEGs, skippedRAWsDate, skippedRAWsDupl, err := p.scan(ctx, sourceDir, targetDir, startDate, endDate)
+	scanned, err := p.scan(ctx, sourceDir, targetDir, startDate, endDate)
 	if err != nil {
 		return domain.CopyPlan{}, err
 	}
-	p.Logger.Verbosef("Collected %d candidate files (%d warnings)", len(metas), len(warnings))
+	metas := scanned.metas
+	warnings := scanned.warnings
+	skippedJPEGs := scanned.skippedJPEGs
+	skippedRAWsDate := scanned.skippedByModTime + scanned.skippedByCaptureDate
+	skippedRAWsDupl := scanned.skippedRAWsDupl
+	leftovers := scanned.leftovers
+	p.Logger.Verbosef("Collected %d candidate files (%d warnings)", len(metas), warnings.len())
 
-	sort.Slice(metas, func(i, j int) bool {
-		if metas[i].TakenAt.Equal(metas[j].TakenAt) {
+	// The order is part of the plan's contract, see CopyPlan.Items: the
+	// workers finish in any order, so every tie is broken down to the
+	// source path, which no two files share
+	sort.SliceStable(metas, func(i, j int) bool {
+		if !metas[i].TakenAt.Equal(metas[j].TakenAt) {
+			return metas[i].TakenAt.Before(metas[j].TakenAt)
+		}
+		if metas[i].Name != metas[j].Name {
 			return metas[i].Name < metas[j].Name
 		}
-		return metas[i].TakenAt.Before(metas[j].TakenAt)
+		if metas[i].RelativePath != metas[j].RelativePath {
+			return metas[i].RelativePath < metas[j].RelativePath
+		}
+		return metas[i].SourcePath < metas[j].SourcePath
 	})
+	for _, warning := range p.suspectDateWarnings(metas) {
+		warnings.add(warning)
+		if p.onWarning != nil {
+			p.onWarning(warning)
+		}
+	}
+
+	duplicates := 0
+	if p.Dedupe {
+		var dupWarnings []domain.Warning
+		var dropped []domain.Leftover
+		metas, dropped, dupWarnings, err = p.dedupe(ctx, metas)
+		if err != nil {
+			return domain.CopyPlan{}, err
+		}
+		leftovers = append(leftovers, dropped...)
+		duplicates = len(dupWarnings)
+		warnings.add(dupWarnings...)
+	}
 
 	var items []domain.CopyItem
 	rawCount := 0
 	jpegCount := 0
+	extensionCounts := make(map[string]int)
+	ratings := make(map[int]int)
+	usedTargets := make(map[string]bool)
 
 	for _, meta := range metas {
-		targetPath := filepath.Join(targetDir, meta.RelativePath)
+		targetPath := filepath.Join(targetDir, p.Layout.TargetRel(meta))
+		// Targets that could not be checked before the scan are checked now
+		if !p.AllowOverride && (p.Layout.NeedsDate() || meta.Sniffed) {
+			_, exists, err := p.targetExists(targetPath)
+			if err != nil {
+				return domain.CopyPlan{}, err
+			}
+			if exists {
+				if meta.IsRAW {
+					skippedRAWsDupl++
+				}
+				leftovers = append(leftovers, domain.Leftover{SourcePath: meta.SourcePath, Reason: skipTargetExists})
+				continue
+			}
+		}
+		targetPath = domain.UniqueTargetPath(targetPath, usedTargets)
+
 		items = append(items, domain.CopyItem{
 			FileMeta:   meta,
 			TargetPath: targetPath,
 		})
 
+		extensionCounts[meta.Ext]++
+		if meta.Rated {
+			ratings[meta.Rating]++
+		}
 		if meta.IsRAW {
 			rawCount++
 		} else if meta.IsJPEG {
@@ -81,17 +261,52 @@ func (p *Planner) Plan(ctx context.Context, sourceDir, targetDir string, startDa
 		}
 	}
 
+	// Companions and misc files follow the dated items, which alone make
+	// up the derived range
+	items, companionLeftovers, err := p.planCompanions(items, scanned.companions, sourceDir, usedTargets)
+	if err != nil {
+		return domain.CopyPlan{}, err
+	}
+	dated := items
+	miscItems, miscLeftovers, err := p.planMisc(scanned.misc, sourceDir, targetDir, usedTargets)
+	if err != nil {
+		return domain.CopyPlan{}, err
+	}
+	items = append(items, miscItems...)
+	leftovers = append(leftovers, companionLeftovers...)
+	leftovers = append(leftovers, miscLeftovers...)
+	for _, item := range items {
+		if item.CompanionOf != "" {
+			extensionCounts[item.FileMeta.Ext]++
+		}
+	}
+	for _, item := range miscItems {
+		extensionCounts[item.FileMeta.Ext]++
+	}
+	if err := p.checkPathLimits(items, targetDir); err != nil {
+		return domain.CopyPlan{}, err
+	}
+
 	// Only detect overrides when AllowOverride is true
-	var overrides []domain.CopyItem
+	var overrides, autoOverrides []domain.CopyItem
 	rawOverrides := 0
 	jpegOverrides := 0
 	if p.AllowOverride {
-		for _, item := range items {
-			exists, err := p.FS.Exists(item.TargetPath)
-			if err != nil {
-				return domain.CopyPlan{}, err
-			}
-			if exists {
+		existing, err := p.existingTargets(ctx, items)
+		if err != nil {
+			return domain.CopyPlan{}, err
+		}
+		for i := range items {
+			if existing[i] != "" {
+				// An existing name in another case is the one overwritten
+				items[i].TargetPath = existing[i]
+				items[i].Exists = true
+				if p.AutoOverrideStale && p.staleTarget(items[i]) {
+					items[i].AutoOverride = true
+					autoOverrides = append(autoOverrides, items[i])
+					continue
+				}
+				item := items[i]
 				overrides = append(overrides, item)
 				if item.FileMeta.IsRAW {
 					rawOverrides++
@@ -102,10 +317,15 @@ func (p *Planner) Plan(ctx context.Context, sourceDir, targetDir string, startDa
 		}
 	}
 
-	rangeStart, rangeEnd := deriveRange(items, startDate, endDate)
-	p.Logger.Verbosef("Planned %d items (%d RAW, %d JPEG), %d JPEGs skipped, %d RAWs skipped (date), %d RAWs skipped (dupl), %d overrides", len(items), rawCount, jpegCount, skippedJPEGs, skippedRAWsDate, skippedRAWsDupl, rawOverrides+jpegOverrides)
+	sinceLast, changed := p.compareHistory(items)
+	warnings.add(changed...)
+
+	sort.Slice(leftovers, func(i, j int) bool { return leftovers[i].SourcePath < leftovers[j].SourcePath })
 
-	return domain.CopyPlan{
+	rangeStart, rangeEnd := deriveRange(dated, startDate, endDate, p.InclusiveEnd, p.Layout.Days)
+	p.Logger.Verbosef("Planned %d items (%d RAW, %d JPEG), %d JPEGs skipped, %d RAWs skipped (date), %d RAWs skipped (dupl), %d overrides, %d stale targets", len(items), rawCount, jpegCount, skippedJPEGs, skippedRAWsDate, skippedRAWsDupl, rawOverrides+jpegOverrides, len(autoOverrides))
+
+	plan := domain.CopyPlan{
 		Items:           items,
 		OverrideItems:   overrides,
 		SkippedJPEGs:    skippedJPEGs,
@@ -117,195 +337,817 @@ func (p *Planner) Plan(ctx context.Context, sourceDir, targetDir string, startDa
 		JpegCount:       jpegCount,
 		RawOverrides:    rawOverrides,
 		JpegOverrides:   jpegOverrides,
-		Warnings:        warnings,
-	}, nil
+		SniffedFiles:    scanned.sniffedFiles,
+		JunkFiles:       scanned.junkFiles,
+		ProxyClips:      scanned.proxyClips,
+		InvalidDates:    scanned.invalidDates,
+		ZoneBoundary:    scanned.zoneBoundary,
+		OutsideRange:    scanned.outsideRange,
+		ExtensionCounts: extensionCounts,
+		Ratings:         ratings,
+		Pairings:        scanned.pairings,
+		Duplicates:      duplicates,
+		SourceVolume:    p.SourceVolume,
+		Warnings:        warnings.kept,
+		SinceLastImport: sinceLast,
+
+		Leftovers:           leftovers,
+		ApproximateDates:    p.Fast,
+		TruncatedWarnings:   warnings.truncated,
+		TruncatedReviewable: warnings.reviewable,
+		WarningLog:          warnings.log(),
+
+		SkippedByMtimeShortcut: scanned.skippedByModTime,
+		SkippedByCaptureDate:   scanned.skippedByCaptureDate,
+
+		Scan:      domain.ScanStats{Files: scanned.discovered, Duration: time.Since(started), Warnings: warnings.len()},
+		Partial:   scanned.unscanned > 0,
+		Unscanned: scanned.unscanned,
+
+		AutoOverrideItems: autoOverrides,
+	}
+	plan.Folders = domain.FolderStatsOf(plan, sourceDir, p.Layout.Days)
+	span.Set("items", len(plan.Items))
+	span.Set("bytes", plan.TotalBytes())
+	span.Set("warnings", warnings.len())
+	return plan, nil
 }
 
-func (p *Planner) scan(ctx context.Context, sourceDir, targetDir string, startDate, endDate *time.Time) ([]domain.FileMeta, []string, int, int, int, error) {
-	stop := p.Logger.Measure("Scanning source directory")
-	defer stop()
+// checkPathLimits fails with every target of items beyond PathLimits.
+func (p *Planner) checkPathLimits(items []domain.CopyItem, targetDir string) error {
+	var violations []domain.PathViolation
+	for _, item := range items {
+		if violation, ok := p.PathLimits.Check(targetDir, item.TargetPath); ok {
+			violations = append(violations, violation)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &domain.PathLimitError{Limits: p.PathLimits, Violations: violations}
+}
+
+// dedupe leaves out the metas that are the same capture as an earlier one
+// and returns them as leftovers with a warning for each. Files whose camera
+// recorded a capture key are compared by it, which needs no file access; the
+// others are hashed when the file system can, but only if another file has
+// the same size.
+func (p *Planner) dedupe(ctx context.Context, metas []domain.FileMeta) ([]domain.FileMeta, []domain.Leftover, []domain.Warning, error) {
+	span := p.Logger.Measure("dedupe", "Finding duplicate captures")
+	defer span.End()
+	span.Set("files", len(metas))
+
+	original := make([]int, len(metas))
+	for i := range original {
+		original[i] = -1
+	}
+	byKey := make(map[string]int)
+	bySize := make(map[int64][]int)
+	for i, meta := range metas {
+		if meta.CaptureKey == "" {
+			bySize[meta.Size] = append(bySize[meta.Size], i)
+			continue
+		}
+		key := meta.Ext + "|" + meta.CaptureKey
+		if first, ok := byKey[key]; ok {
+			original[i] = first
+		} else {
+			byKey[key] = i
+		}
+	}
+
+	byKeyCount := 0
+	for _, first := range original {
+		if first >= 0 {
+			byKeyCount++
+		}
+	}
+	hasher, canHash := p.FS.(ContentHasher)
+	algorithm := cmp.Or(p.DedupeHash, hash.DefaultDedupe)
+	if canHash {
+		for _, group := range bySize {
+			if len(group) < 2 {
+				continue
+			}
+			byHash := make(map[string]int)
+			for _, i := range group {
+				if err := ctx.Err(); err != nil {
+					return nil, nil, nil, err
+				}
+				sum, err := hasher.HashFile(metas[i].SourcePath, algorithm)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				if first, ok := byHash[sum]; ok {
+					original[i] = first
+				} else {
+					byHash[sum] = i
+				}
+			}
+		}
+	}
+
+	kept := metas[:0:0]
+	var dropped []domain.Leftover
+	var warnings []domain.Warning
+	for i, meta := range metas {
+		if original[i] < 0 {
+			kept = append(kept, meta)
+			continue
+		}
+		dropped = append(dropped, domain.Leftover{SourcePath: meta.SourcePath, Reason: skipDuplicate, Of: metas[original[i]].SourcePath})
+		warnings = append(warnings, domain.Warningf(domain.WarningDuplicateCapture, "%s is a duplicate of %s", meta.RelativePath, metas[original[i]].RelativePath))
+	}
+	p.Logger.Verbosef("Left out %d duplicate captures (%d by EXIF capture key, %d by content)", len(warnings), byKeyCount, len(warnings)-byKeyCount)
+	return kept, dropped, warnings, nil
+}
+
+// compareHistory counts the planned items that are new, imported before or
+// changed since they were imported, and warns about each changed one.
+func (p *Planner) compareHistory(items []domain.CopyItem) (*domain.ImportDiff, []domain.Warning) {
+	if p.History == nil || p.History.LastImport().IsZero() {
+		return nil, nil
+	}
+	diff := &domain.ImportDiff{LastImport: p.History.LastImport()}
+	var warnings []domain.Warning
+	for _, item := range items {
+		imported, ok := p.History.Lookup(item.FileMeta.SourcePath)
+		switch {
+		case !ok:
+			diff.New++
+		case imported.Changed(item.FileMeta):
+			diff.Modified++
+			warnings = append(warnings, domain.Warningf(domain.WarningChangedSinceImport, "%s changed since it was imported on %s", item.FileMeta.SourcePath, imported.ImportedAt.Format("2006-01-02")))
+		default:
+			diff.Unchanged++
+		}
+	}
+	p.Logger.Verbosef("Since the last import on %s: %d new, %d unchanged, %d modified", diff.LastImport.Format("2006-01-02"), diff.New, diff.Unchanged, diff.Modified)
+	return diff, warnings
+}
+
+// existingTargets checks which target paths of items exist, using up to
+// TargetWorkers concurrent checks since each one may be a round trip to
+// network storage. The result is indexed like items and holds the path
+// each target exists at, see targetExists, or "" when it does not.
+func (p *Planner) existingTargets(ctx context.Context, items []domain.CopyItem) ([]string, error) {
+	span := p.Logger.Measure("override-check", "Checking override targets")
+	defer span.End()
+	span.Set("items", len(items))
+
+	existing := make([]string, len(items))
+	workerCount := effectiveWorkers(p.TargetWorkers, len(items))
+	p.Logger.Verbosef("Using %d target workers", workerCount)
+	g, gctx := errgroup.WithContext(ctx)
+	jobs := make(chan int)
+
+	g.Go(func() error {
+		defer close(jobs)
+		for i := range items {
+			select {
+			case <-gctx.Done():
+				return gctx.Err()
+			case jobs <- i:
+			}
+		}
+		return nil
+	})
+
+	for range workerCount {
+		g.Go(func() error {
+			for i := range jobs {
+				path, exists, err := p.targetExists(items[i].TargetPath)
+				if err != nil {
+					return err
+				}
+				if exists {
+					existing[i] = path
+				}
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// staleTarget reports whether the existing target of item is stale, see
+// domain.IsStaleTarget. A target that cannot be read is left to the user.
+func (p *Planner) staleTarget(item domain.CopyItem) bool {
+	info, err := p.FS.Stat(item.TargetPath)
+	if err != nil {
+		p.Logger.Verbosef("Could not compare %s to its source: %v", item.TargetPath, err)
+		return false
+	}
+	return domain.IsStaleTarget(item.FileMeta, info.Size(), info.ModTime())
+}
+
+func (p *Planner) scan(ctx context.Context, sourceDir, targetDir string, startDate, endDate *time.Time) (scanResult, error) {
+	span := p.Logger.Measure("scan", "Scanning source directory")
+	defer span.End()
 
 	// Phase 1: Walk directory and separate RAW and JPEG paths, build RAW base names set
-	var rawPaths []string
-	var jpegPaths []string
-	rawBaseNames := make(map[string]bool)
+	var rawFiles []candidate
+	var jpegFiles []candidate
+	var unknownFiles []candidate
+	var companions []candidate
+	var misc []candidate
+	sidecars := make(map[string]string)
+	tally := scanTally{skipped: make(map[string]int), rejected: make(map[string]int)}
 
+	walk := p.Logger.Measure("walk", "Walking source directory")
 	err := p.FS.WalkDir(sourceDir, func(path string, d fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
 		}
+		rel, relErr := filepath.Rel(sourceDir, path)
 		if d.IsDir() {
+			// The metadata of an earlier import is not part of the source
+			if path != sourceDir && domain.IsMetaDir(d.Name()) {
+				p.Logger.Verbosef("Skipping phopy metadata in %s", path)
+				return fs.SkipDir
+			}
+			if relErr == nil && p.Paths.SkipsDir(rel) {
+				p.Logger.Verbosef("Skipping %s (--include, --exclude)", path)
+				return fs.SkipDir
+			}
+			return nil
+		}
+		tally.discovered++
+		if relErr == nil && !p.Paths.Selects(rel) {
+			tally.skip(path, skipPathFilter)
+			return nil
+		}
+		if !p.KeepJunk && domain.IsJunkFile(d.Name()) {
+			tally.skip(path, skipJunk)
+			return nil
+		}
+		if !p.IncludeProxies && relErr == nil && domain.IsVideoProxy(rel) {
+			tally.skip(path, skipProxy)
 			return nil
 		}
 		ext := filepath.Ext(d.Name())
-		name := d.Name()
-		baseName := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+		file := candidate{path: path}
+		// The walk already knows regular files, so their info saves a Stat
+		if d.Type().IsRegular() {
+			file.info, _ = d.Info()
+		}
 
 		if domain.IsRawExtension(ext) {
-			rawPaths = append(rawPaths, path)
-			rawBaseNames[baseName] = true
+			rawFiles = append(rawFiles, file)
 		} else if domain.IsJpegExtension(ext) {
-			jpegPaths = append(jpegPaths, path)
+			jpegFiles = append(jpegFiles, file)
+		} else if p.IncludeMisc && domain.IsMiscFile(d.Name()) {
+			// Before companions, since C*.XML also matches CUEUP.XML
+			misc = append(misc, file)
+		} else if domain.MatchesCompanion(p.CompanionGlobs, d.Name()) {
+			companions = append(companions, file)
+		} else if domain.IsSidecarExtension(ext) {
+			sidecars[domain.SidecarKey(path)] = path
+			tally.skip(path, skipSidecar)
+		} else if p.Sniff {
+			unknownFiles = append(unknownFiles, file)
+		} else {
+			tally.skip(path, skipUnsupported)
 		}
 		return nil
 	})
+	walk.Set("files", tally.discovered)
+	walk.Set("raw", len(rawFiles))
+	walk.Set("jpeg", len(jpegFiles))
+	walk.End()
 	if err != nil {
-		return nil, nil, 0, 0, 0, err
+		return scanResult{}, err
+	}
+
+	// The FileSystem contract promises a lexical walk; sorting once more
+	// keeps the plan stable with implementations that break it, since the
+	// first RAW of a base name wins the pairing
+	for _, files := range [][]candidate{rawFiles, jpegFiles, unknownFiles, companions, misc} {
+		sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	}
+	rawBaseNames := make(map[string]string)
+	for _, file := range rawFiles {
+		name := filepath.Base(file.path)
+		baseName := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+		if _, ok := rawBaseNames[baseName]; !ok {
+			rawBaseNames[baseName] = file.path
+		}
 	}
 
-	// Phase 2: Filter paths based on target existence and RAW counterparts
-	var pathsToProcess []string
+	// Phase 2: Apply every check that needs no EXIF read, so only files that
+	// can still be included reach the workers. JPEGs with a RAW never do, and
+	// the queue holds RAWs before unpaired JPEGs and sniffed files, so the
+	// files that matter are read first and a scan cancelled early has them.
+	var filesToProcess []candidate
 	skippedJPEGs := 0
 	skippedRAWsDupl := 0
+	outsideRange := domain.RangeExclusions{}
+	var pairings []domain.JPEGPairing
+
+	beforeStart := func(file candidate) bool {
+		if startDate == nil || file.info == nil || p.Layout.Days.Compare(file.info.ModTime(), *startDate) >= 0 {
+			return false
+		}
+		outsideRange.Add(file.info.ModTime())
+		tally.skip(file.path, skipModifiedBefore)
+		return true
+	}
 
 	// Add RAW files that should be included
-	for _, path := range rawPaths {
-		if p.shouldIncludeSource(path, sourceDir, targetDir) {
-			pathsToProcess = append(pathsToProcess, path)
-		} else {
+	skippedByModTime := 0
+	for _, file := range rawFiles {
+		if beforeStart(file) {
+			skippedByModTime++
+			continue
+		}
+		if !p.shouldIncludeSource(file.path, sourceDir, targetDir) {
 			skippedRAWsDupl++
+			tally.skip(file.path, skipTargetExists)
+			continue
 		}
+		filesToProcess = append(filesToProcess, file)
 	}
 
 	// Add JPEG files that should be included (no RAW counterpart and target doesn't exist)
-	for _, path := range jpegPaths {
-		name := filepath.Base(path)
+	for _, file := range jpegFiles {
+		name := filepath.Base(file.path)
 		baseName := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
 
-		if rawBaseNames[baseName] {
+		if rawPath, ok := rawBaseNames[baseName]; ok {
 			// Skip JPEG because RAW exists
 			skippedJPEGs++
+			pairings = append(pairings, domain.JPEGPairing{JPEG: file.path, RAW: rawPath})
+			tally.skip(file.path, skipPairedJPEG)
+			continue
+		}
+		if beforeStart(file) {
+			continue
+		}
+		if !p.shouldIncludeSource(file.path, sourceDir, targetDir) {
+			tally.skip(file.path, skipTargetExists)
 			continue
 		}
+		filesToProcess = append(filesToProcess, file)
+	}
 
-		if p.shouldIncludeSource(path, sourceDir, targetDir) {
-			pathsToProcess = append(pathsToProcess, path)
+	// Files with unknown extensions are only classified once sniffed, so
+	// their target existence is checked after the scan
+	sniffPaths := make(map[string]bool, len(unknownFiles))
+	for _, file := range unknownFiles {
+		if beforeStart(file) {
+			continue
 		}
+		sniffPaths[file.path] = true
+		filesToProcess = append(filesToProcess, file)
+	}
+	tally.queued = len(filesToProcess)
+	for i := range filesToProcess {
+		filesToProcess[i].sidecar = sidecarFor(filesToProcess[i].path, sidecars)
 	}
 
-	totalFound := len(rawPaths) + len(jpegPaths)
-	p.Logger.Verbosef("Found %d candidate files in %s (%d RAW, %d JPEG)", totalFound, sourceDir, len(rawPaths), len(jpegPaths))
-	p.Logger.Verbosef("Processing %d files after filtering (%d JPEGs skipped for RAW, %d RAWs skipped for duplicate)", len(pathsToProcess), skippedJPEGs, skippedRAWsDupl)
+	totalFound := len(rawFiles) + len(jpegFiles)
+	p.Logger.Verbosef("Found %d candidate files in %s (%d RAW, %d JPEG, %d to sniff)", totalFound, sourceDir, len(rawFiles), len(jpegFiles), len(unknownFiles))
+	p.Logger.Verbosef("Processing %d files after filtering (%d JPEGs skipped for RAW, %d RAWs skipped for duplicate)", len(filesToProcess), skippedJPEGs, skippedRAWsDupl)
 
 	// Phase 3: Process remaining files with EXIF workers
-	workerCount := p.ExifWorkers
-	if workerCount <= 0 {
-		workerCount = runtime.NumCPU()
-	}
-	if workerCount < 1 {
-		workerCount = 1
-	}
+	workerCount := effectiveWorkers(p.ExifWorkers, len(filesToProcess))
 	p.Logger.Verbosef("Using %d EXIF workers", workerCount)
+	started := time.Now()
+	exifScan := p.Logger.Measure("exif-scan", "Reading EXIF")
+	exifScan.Set("files", len(filesToProcess))
+	exifScan.Set("bytes", candidateBytes(filesToProcess))
+	exifScan.Set("workers", workerCount)
 
-	type result struct {
-		meta        domain.FileMeta
-		warning     string
-		skip        bool
-		skipRAWDate bool
-		err         error
-	}
+	g, gctx := errgroup.WithContext(ctx)
+	jobs := make(chan int)
+	results := make(chan scanItem)
 
-	jobs := make(chan string)
-	results := make(chan result)
+	g.Go(func() error {
+		defer close(jobs)
+		for i := range filesToProcess {
+			// Once stopped, no file is handed out even to a waiting worker
+			select {
+			case <-p.Stop:
+				return nil
+			default:
+			}
+			select {
+			case <-gctx.Done():
+				return gctx.Err()
+			case <-p.Stop:
+				return nil
+			case jobs <- i:
+			}
+		}
+		return nil
+	})
 
 	for i := 0; i < workerCount; i++ {
-		go func() {
-			for path := range jobs {
-				info, statErr := p.FS.Stat(path)
-				if statErr != nil {
-					results <- result{err: statErr}
-					continue
-				}
-
-				ext := filepath.Ext(path)
-				isRAW := domain.IsRawExtension(ext)
-
-				// Early exit: if ModTime is before startDate, EXIF date will also be before
-				// (EXIF date is typically <= ModTime in real photo workflows)
-				if startDate != nil && info.ModTime().Before(*startDate) {
-					results <- result{skip: true, skipRAWDate: isRAW}
-					continue
+		g.Go(func() error {
+			worker := newWorkerSpan(p.Logger.Trace, i)
+			defer worker.end()
+			for index := range jobs {
+				file := filesToProcess[index]
+				sample := worker.inspect(file)
+				item, err := p.inspect(gctx, file, sourceDir, sniffPaths[file.path], startDate, endDate)
+				sample.End()
+				if err != nil {
+					return err
 				}
-
-				takenAt, exifErr := p.Exif.DateTimeOriginal(ctx, path)
-				warning := ""
-				if exifErr != nil {
-					if errors.Is(exifErr, context.Canceled) || errors.Is(exifErr, context.DeadlineExceeded) {
-						results <- result{err: exifErr}
-						continue
-					}
-					takenAt = info.ModTime()
-					warning = fmt.Sprintf("EXIF not found for %s, using filesystem time", filepath.Base(path))
+				item.path = file.path
+				item.index = index
+				select {
+				case <-gctx.Done():
+					return gctx.Err()
+				case results <- item:
 				}
+			}
+			return nil
+		})
+	}
 
-				if startDate != nil && takenAt.Before(*startDate) {
-					results <- result{skip: true, skipRAWDate: isRAW}
-					continue
-				}
-				if endDate != nil && takenAt.After(*endDate) {
-					results <- result{skip: true, skipRAWDate: isRAW}
-					continue
-				}
+	// Close results once every worker is done so the collection loop below
+	// always terminates, whether the scan finished or failed
+	go func() {
+		_ = g.Wait()
+		close(results)
+	}()
 
-				rel, relErr := filepath.Rel(sourceDir, path)
-				if relErr != nil {
-					rel = filepath.Base(path)
-				}
+	scanned := scanResult{
+		skippedJPEGs:    skippedJPEGs,
+		skippedRAWsDupl: skippedRAWsDupl,
+		outsideRange:    outsideRange,
+		pairings:        pairings,
+		warnings:        newWarningList(p.WarningCap, p.WarningSpill),
 
-				results <- result{
-					meta:    domain.NewFileMeta(path, rel, takenAt),
-					warning: warning,
-				}
-			}
-		}()
+		skippedByModTime: skippedByModTime,
 	}
+	total := len(filesToProcess)
+	processed := 0
+	firstRAW := false
+	inspected := make([]scanItem, total)
+	done := make([]bool, total)
+	for res := range results {
+		processed++
+		inspected[res.index] = res
+		done[res.index] = true
+		if !firstRAW && !res.skip && res.meta.IsRAW {
+			firstRAW = true
+			p.Logger.Verbosef("First RAW inspected after %s", time.Since(started).Round(time.Millisecond))
+		}
+		if res.warning.Text != "" && p.onWarning != nil {
+			p.onWarning(res.warning)
+		}
 
-	go func() {
-		defer close(jobs)
-		for _, path := range pathsToProcess {
-			select {
-			case <-ctx.Done():
-				return
-			case jobs <- path:
-			}
+		// Report progress, including for skipped files
+		if p.OnProgress != nil {
+			p.OnProgress(processed, total)
 		}
-	}()
+	}
+	err = g.Wait()
+	exifScan.Set("scanned", processed)
+	exifScan.End()
+	if err != nil {
+		return scanResult{}, err
+	}
 
-	var metas []domain.FileMeta
-	var warnings []string
-	skippedRAWsDate := 0
-	total := len(pathsToProcess)
-	for i := range pathsToProcess {
-		res := <-results
-		if res.err != nil {
-			return nil, nil, 0, 0, 0, res.err
+	// Workers finish in any order; collecting in queue order keeps warnings
+	// and metas the same from run to run
+	for i, res := range inspected {
+		if !done[i] {
+			scanned.unscanned++
+			tally.reject(filesToProcess[i].path, skipNotScanned)
+			continue
 		}
-		if res.warning != "" {
-			warnings = append(warnings, res.warning)
+		if res.warning.Text != "" {
+			scanned.warnings.add(res.warning)
 		}
 		if res.skip {
-			if res.skipRAWDate {
-				skippedRAWsDate++
+			if res.skipRAWDate && res.byModTime {
+				scanned.skippedByModTime++
+			} else if res.skipRAWDate {
+				scanned.skippedByCaptureDate++
 			}
-			// Still report progress for skipped files
-			if p.OnProgress != nil {
-				p.OnProgress(i+1, total)
+			if res.outsideRange {
+				scanned.outsideRange.Add(res.date)
+				tally.reject(res.path, skipOutsideRange)
+			} else {
+				tally.reject(res.path, skipUnrecognized)
 			}
-			continue
+		} else {
+			if res.sniffed {
+				scanned.sniffedFiles++
+			}
+			if res.zoneBoundary {
+				scanned.zoneBoundary++
+			}
+			if res.invalidDate {
+				scanned.invalidDates++
+			}
+			scanned.metas = append(scanned.metas, res.meta)
 		}
-		metas = append(metas, res.meta)
+	}
 
-		// Report progress
-		if p.OnProgress != nil {
-			p.OnProgress(i+1, total)
+	if len(unknownFiles) > 0 {
+		p.Logger.Verbosef("Sniffed %d files with unknown extensions, %d recognized", len(unknownFiles), scanned.sniffedFiles)
+	}
+	if scanned.unscanned > 0 {
+		p.Logger.Verbosef("Scan stopped early, %d files not scanned", scanned.unscanned)
+	}
+	p.Logger.Verbosef("Accounted for %s", tally)
+	scanned.leftovers = tally.leftovers
+	scanned.junkFiles = tally.skipped[skipJunk]
+	scanned.proxyClips = tally.skipped[skipProxy]
+	scanned.discovered = tally.discovered
+	scanned.companions = companions
+	scanned.misc = misc
+
+	return scanned, nil
+}
+
+// candidate is a discovered file on its way to the EXIF workers. info is set
+// when the walk already provided it, sidecar when an XMP sidecar was found.
+type candidate struct {
+	path    string
+	info    fs.FileInfo
+	sidecar string
+}
+
+// sidecarFor returns the XMP sidecar of path found by the walk, or "".
+func sidecarFor(path string, sidecars map[string]string) string {
+	for _, key := range domain.SidecarKeys(path) {
+		if sidecar, ok := sidecars[key]; ok {
+			return sidecar
 		}
 	}
+	return ""
+}
 
-	return metas, warnings, skippedJPEGs, skippedRAWsDate, skippedRAWsDupl, nil
+// Reasons a discovered file is not included, in the order they are checked.
+const (
+	skipPathFilter     = "path filter"
+	skipJunk           = "junk file"
+	skipProxy          = "video proxy"
+	skipUnsupported    = "unsupported extension"
+	skipSidecar        = "sidecar"
+	skipPairedJPEG     = "JPEG with RAW"
+	skipModifiedBefore = "modified before start date"
+	skipTargetExists   = "target exists"
+	skipOutsideRange   = "outside date range"
+	skipUnrecognized   = "unrecognized content"
+	skipNotScanned     = "not scanned"
+	// Files left out after the scan
+	skipDuplicate = "duplicate capture"
+)
+
+var skipReasons = []string{skipPathFilter, skipJunk, skipProxy, skipUnsupported, skipSidecar, skipPairedJPEG, skipModifiedBefore, skipTargetExists, skipOutsideRange, skipUnrecognized, skipNotScanned}
+
+// scanTally accounts for every file the walk discovered: skipped before the
+// workers, or queued and then included or rejected by them.
+type scanTally struct {
+	discovered int
+	queued     int
+	skipped    map[string]int
+	rejected   map[string]int
+	// leftovers records the path and reason of every skip and rejection
+	leftovers []domain.Leftover
 }
 
-func deriveRange(items []domain.CopyItem, startDate, endDate *time.Time) (*time.Time, *time.Time) {
+func (t *scanTally) skip(path, reason string) {
+	t.skipped[reason]++
+	t.leftovers = append(t.leftovers, domain.Leftover{SourcePath: path, Reason: reason})
+}
+
+func (t *scanTally) reject(path, reason string) {
+	t.rejected[reason]++
+	t.leftovers = append(t.leftovers, domain.Leftover{SourcePath: path, Reason: reason})
+}
+
+func (t scanTally) String() string {
+	included := t.queued
+	for _, n := range t.rejected {
+		included -= n
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d discovered files: %d included, %d queued", t.discovered, included, t.queued)
+	for _, reason := range skipReasons {
+		if n := t.skipped[reason] + t.rejected[reason]; n > 0 {
+			fmt.Fprintf(&b, ", %d skipped (%s)", n, reason)
+		}
+	}
+	return b.String()
+}
+
+// scanItem is the outcome of inspecting a single source file.
+type scanItem struct {
+	// index is the position of the file in the worker queue
+	index       int
+	path        string
+	meta        domain.FileMeta
+	warning     domain.Warning
+	skip        bool
+	skipRAWDate bool
+	// outsideRange marks date-filter skips, dated at the time the filter
+	// saw (mtime for the shortcut, capture time otherwise)
+	outsideRange bool
+	date         time.Time
+	sniffed      bool
+	zoneBoundary bool
+	invalidDate  bool
+	// byModTime marks date-filter skips by the modification time shortcut
+	byModTime bool
+}
+
+// inspect stats (unless the walk did), optionally sniffs, and reads the EXIF
+// date of a single file. Only errors that should abort the whole scan are
+// returned.
+func (p *Planner) inspect(ctx context.Context, file candidate, sourceDir string, sniff bool, startDate, endDate *time.Time) (scanItem, error) {
+	path := file.path
+	info := file.info
+	if info == nil {
+		var err error
+		if info, err = p.FS.Stat(path); err != nil {
+			return scanItem{}, err
+		}
+	}
+
+	ext := filepath.Ext(path)
+	isRAW := domain.IsRawExtension(ext)
+
+	// Early exit: if ModTime is before startDate, EXIF date will also be before
+	// (EXIF date is typically <= ModTime in real photo workflows)
+	if startDate != nil && p.Layout.Days.Compare(info.ModTime(), *startDate) < 0 {
+		return scanItem{skip: true, skipRAWDate: isRAW, outsideRange: true, byModTime: true, date: info.ModTime()}, nil
+	}
+
+	sniffedExt := ""
+	if sniff {
+		header, readErr := p.FS.ReadHeader(path, domain.SniffHeaderSize)
+		if readErr == nil {
+			sniffedExt = domain.SniffExtension(header)
+		}
+		if sniffedExt == "" {
+			return scanItem{skip: true}, nil
+		}
+		isRAW = domain.IsRawExtension(sniffedExt)
+	}
+
+	// Fast plans date every file by its filesystem time without a warning;
+	// the plan as a whole is marked as approximate. Types that never carry
+	// EXIF, like videos, are dated the same way without reading it
+	hasExif := domain.HasExif(cmp.Or(sniffedExt, ext))
+	var photoMeta domain.PhotoMeta
+	exifErr := errExifSkipped
+	if !p.Fast && hasExif {
+		photoMeta, exifErr = p.Exif.ReadMeta(ctx, path)
+	}
+	if exifErr == nil && photoMeta.TakenAt.Before(p.dateFloor()) {
+		exifErr = fmt.Errorf("%w: %s", domain.ErrInvalidCaptureDate, photoMeta.TakenAt.Format("2006-01-02"))
+	}
+	rel, relErr := filepath.Rel(sourceDir, path)
+	if relErr != nil {
+		rel = filepath.Base(path)
+	}
+
+	takenAt := p.captureTime(photoMeta)
+	dateSource := domain.DateSourceExif
+	var warning domain.Warning
+	invalidDate := errors.Is(exifErr, domain.ErrInvalidCaptureDate)
+	if exifErr != nil {
+		if errors.Is(exifErr, context.Canceled) || errors.Is(exifErr, context.DeadlineExceeded) {
+			return scanItem{}, exifErr
+		}
+		// A dated folder is what the user chose to date the files by, so
+		// it needs no warning
+		takenAt, dateSource = info.ModTime(), domain.DateSourceFileTime
+		fallback := "filesystem time"
+		if date, ok := p.DirDates.DateOf(filepath.Dir(rel)); ok {
+			takenAt, dateSource = date, domain.DateSourceDirectory
+			fallback = "directory date"
+		} else if !p.Fast && hasExif {
+			warning = domain.Warningf(domain.WarningExifMissing, "EXIF not found for %s, using filesystem time", filepath.Base(path)).About(domain.ExiflessActions, path)
+		}
+		if invalidDate {
+			warning = domain.Warningf(domain.WarningExifDateInvalid, "Invalid EXIF date for %s, using %s", filepath.Base(path), fallback).About(domain.ExiflessActions, path)
+		}
+	}
+
+	capturedAt := takenAt
+	if exifErr == nil {
+		capturedAt = takenAt.Add(photoMeta.SubSec)
+	}
+	if !p.inRange(capturedAt, startDate, endDate) {
+		return scanItem{skip: true, skipRAWDate: isRAW, outsideRange: true, date: p.Layout.Days.In(takenAt)}, nil
+	}
+
+	meta := domain.NewFileMeta(path, rel, p.Layout.Days.In(takenAt))
+	meta.Size = info.Size()
+	meta.ModTime = info.ModTime()
+	meta.DateSource = dateSource
+	if exifErr == nil {
+		meta.DateTag = photoMeta.DateTag
+		meta.CaptureKey, _ = photoMeta.CaptureKey()
+	}
+	if sniffedExt != "" {
+		meta = meta.WithSniffedExt(sniffedExt)
+	}
+	if file.sidecar != "" {
+		// A sidecar that cannot be read simply contributes no rating
+		if data, err := p.FS.ReadHeader(file.sidecar, domain.SidecarMaxSize); err == nil {
+			meta.Rating, meta.Rated = domain.ParseXMPRating(data)
+		}
+	}
+	item := scanItem{meta: meta, warning: warning, sniffed: sniffedExt != "", invalidDate: invalidDate}
+	if p.CheckTimezone && exifErr == nil {
+		item.warning, item.zoneBoundary = p.zoneBoundaryWarning(filepath.Base(path), photoMeta)
+	}
+	return item, nil
+}
+
+func (p *Planner) dateFloor() time.Time {
+	if p.DateFloor.IsZero() {
+		return DefaultDateFloor
+	}
+	return p.DateFloor
+}
+
+// captureTime is the time photoMeta is dated by: the camera's wall clock,
+// or the moment of capture when the layout reads days in a zone and the
+// camera recorded its UTC offset.
+func (p *Planner) captureTime(photoMeta domain.PhotoMeta) time.Time {
+	if instant, ok := photoMeta.CaptureInstant(); ok && p.Layout.Days.Zone != nil {
+		return instant
+	}
+	return photoMeta.TakenAt
+}
+
+// zoneBoundaryWarning reports a file whose capture date differs between the
+// camera's wall clock (which picks the date folder) and TimeZone.
+func (p *Planner) zoneBoundaryWarning(name string, photoMeta domain.PhotoMeta) (domain.Warning, bool) {
+	instant, ok := photoMeta.CaptureInstant()
+	if !ok {
+		return domain.Warning{}, false
+	}
+	zone := p.TimeZone
+	if zone == nil {
+		zone = time.Local
+	}
+	cameraDate := domain.DayOf(p.captureTime(photoMeta), p.Layout.Days).Format("2006-01-02")
+	zoneDate := domain.DayOf(instant, domain.DayPolicy{Zone: zone}).Format("2006-01-02")
+	if cameraDate == zoneDate {
+		return domain.Warning{}, false
+	}
+	return domain.Warningf(domain.WarningZoneBoundary, "%s was taken on %s at UTC%s but falls on %s in %s", name, cameraDate, instant.Format("-07:00"), zoneDate, zone), true
+}
+
+// effectiveWorkers resolves the configured worker count (0 = NumCPU) and caps
+// it at the number of jobs so no idle workers are started.
+func effectiveWorkers(configured, jobs int) int {
+	workers := configured
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > jobs {
+		workers = jobs
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// inRange reports whether a capture at t lies in [startDate, endDate), or in
+// [startDate, endDate] at whole seconds with InclusiveEnd. The capture is
+// read as the layout's day policy reads it, so a file is in the range
+// exactly when its date folder is.
+func (p *Planner) inRange(t time.Time, startDate, endDate *time.Time) bool {
+	days := p.Layout.Days
+	if startDate != nil && days.Compare(t, *startDate) < 0 {
+		return false
+	}
+	if endDate == nil {
+		return true
+	}
+	if p.InclusiveEnd {
+		return days.Compare(t.Truncate(time.Second), *endDate) <= 0
+	}
+	return days.Compare(t, *endDate) < 0
+}
+
+// deriveRange returns the range shown in the summary: the requested one, or
+// the span of the planned items, read as days decides. An exclusive end is
+// shown as the last instant before it, so the summary names the last
+// included day.
+func deriveRange(items []domain.CopyItem, startDate, endDate *time.Time, inclusiveEnd bool, days domain.DayPolicy) (*time.Time, *time.Time) {
 	if startDate != nil || endDate != nil {
+		if endDate != nil && !inclusiveEnd {
+			last := endDate.Add(-time.Nanosecond)
+			endDate = &last
+		}
 		return startDate, endDate
 	}
 	if len(items) == 0 {
@@ -321,5 +1163,6 @@ func deriveRange(items []domain.CopyItem, startDate, endDate *time.Time) (*time.
 			max = item.FileMeta.TakenAt
 		}
 	}
+	min, max = days.In(min), days.In(max)
 	return &min, &max
 }
@@ -1,145 +1,259 @@
 package app
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io/fs"
+	"math/rand"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	_ "time/tzdata"
 
 	"phopy/internal/domain"
+	"phopy/internal/journal"
+	"phopy/internal/logging"
+	"phopy/internal/trace"
+	"phopy/phopytest"
 )
 
-type mockFS struct {
-	entries []mockEntry
-	exists  map[string]bool
+// offset returns a pointer to d for domain.PhotoMeta.Offset.
+func offset(d time.Duration) *time.Duration {
+	return &d
 }
 
-type mockEntry struct {
-	path    string
-	isDir   bool
-	modTime time.Time
-}
+func TestPlannerSkipsJPEGWhenRAWExists(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	rawPath := filepath.Join(sourceDir, "DSC0001.ARW")
+	jpegPath := filepath.Join(sourceDir, "DSC0001.JPG")
 
-func (m mockFS) WalkDir(root string, fn fs.WalkDirFunc) error {
-	for _, entry := range m.entries {
-		dirEntry := mockDirEntry{name: filepath.Base(entry.path), isDir: entry.isDir}
-		if err := fn(entry.path, dirEntry, nil); err != nil {
-			return err
-		}
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	fsys := phopytest.NewFS().
+		AddFile(rawPath, phopytest.File{ModTime: now}).
+		AddFile(jpegPath, phopytest.File{ModTime: now})
+
+	planner := Planner{
+		FS:   fsys,
+		Exif: phopytest.NewExif().SetTakenAt(rawPath, now).SetTakenAt(jpegPath, now),
 	}
-	return nil
-}
 
-func (m mockFS) Stat(path string) (fs.FileInfo, error) {
-	for _, entry := range m.entries {
-		if entry.path == path {
-			return mockFileInfo{name: filepath.Base(path), modTime: entry.modTime}, nil
-		}
+	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.SkippedJPEGs != 1 {
+		t.Fatalf("expected 1 skipped JPEG, got %d", plan.SkippedJPEGs)
+	}
+	if plan.RawCount != 1 || plan.JpegCount != 0 {
+		t.Fatalf("unexpected counts: raw=%d jpeg=%d", plan.RawCount, plan.JpegCount)
 	}
-	return nil, fs.ErrNotExist
 }
 
-func (m mockFS) Exists(path string) (bool, error) {
-	return m.exists[path], nil
-}
+func TestPlannerSkipsJunkFiles(t *testing.T) {
+	sourceDir := "/card/DCIM"
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	fsys := phopytest.NewFS().AddTree(sourceDir, phopytest.Tree{
+		"100MSDCF/DSC0001.ARW":   {ModTime: now, Size: 24 << 20},
+		"100MSDCF/._DSC0001.ARW": {ModTime: now, Size: 4096},
+		"100MSDCF/.DS_Store":     {ModTime: now},
+		"100MSDCF/Thumbs.db":     {ModTime: now},
+	})
 
-func (m mockFS) MkdirAll(path string, perm fs.FileMode) error {
-	return nil
-}
+	planner := Planner{FS: fsys, Exif: phopytest.NewExif().SetTakenAt(filepath.Join(sourceDir, "100MSDCF", "DSC0001.ARW"), now)}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.RawCount != 1 || len(plan.Items) != 1 || plan.JunkFiles != 3 {
+		t.Fatalf("expected only DSC0001.ARW planned and 3 junk files, got %d RAWs, %v and %d junk files", plan.RawCount, plan.Items, plan.JunkFiles)
+	}
+	for _, leftover := range plan.Leftovers {
+		if leftover.Reason != skipJunk {
+			t.Fatalf("expected junk leftovers only, got %v", plan.Leftovers)
+		}
+	}
+	if plan.Scan.Files != 4 {
+		t.Fatalf("expected the scan to count the junk files it examined, got %+v", plan.Scan)
+	}
 
-func (m mockFS) CopyFile(src, dst string) error {
-	return nil
+	// Kept, the AppleDouble file counts as a RAW again
+	planner.KeepJunk = true
+	plan, err = planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.RawCount != 2 || plan.JunkFiles != 0 {
+		t.Fatalf("expected the junk kept, got %d RAWs and %d junk files", plan.RawCount, plan.JunkFiles)
+	}
 }
 
-type mockExif struct {
-	timestamps map[string]time.Time
-	err        error
-}
+func TestPlannerLeavesOutPhopyMetadata(t *testing.T) {
+	sourceDir := "/old-archive"
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	fsys := phopytest.NewFS().AddTree(sourceDir, phopytest.Tree{
+		"2024-10-02/DSC0001.ARW":                      {ModTime: now},
+		".phopy/journal.jsonl":                        {ModTime: now, Size: 512},
+		".phopy/manifests/manifest-1.json":            {ModTime: now, Size: 2048},
+		".phopy/manifests/DSC0001.ARW":                {ModTime: now},
+		"2024-10-02/.PHOPY/manifests/manifest-2.json": {ModTime: now, Size: 2048},
+	})
 
-func (m mockExif) DateTimeOriginal(ctx context.Context, path string) (time.Time, error) {
-	if m.err != nil {
-		return time.Time{}, m.err
+	planner := Planner{
+		FS:             fsys,
+		Exif:           phopytest.NewExif().SetTakenAt(filepath.Join(sourceDir, "2024-10-02", "DSC0001.ARW"), now),
+		Sniff:          true,
+		IncludeMisc:    true,
+		CompanionGlobs: []string{"*.json", "*.jsonl"},
+	}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 1 || plan.Items[0].FileMeta.SourcePath != filepath.Join(sourceDir, "2024-10-02", "DSC0001.ARW") {
+		t.Fatalf("expected only the photo planned, got %+v", plan.Items)
 	}
-	if ts, ok := m.timestamps[path]; ok {
-		return ts, nil
+	if len(plan.Leftovers) != 0 || plan.Scan.Files != 1 {
+		t.Fatalf("expected the metadata not to count anywhere, got leftovers %v and %d files scanned", plan.Leftovers, plan.Scan.Files)
 	}
-	return time.Time{}, errors.New("missing exif")
 }
 
-// trackingExif records which files had EXIF read
-type trackingExif struct {
-	timestamps map[string]time.Time
-	called     map[string]bool
-}
+func TestPlannerRecordsJPEGPairings(t *testing.T) {
+	sourceDir := "/card/DCIM"
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	fsys := phopytest.NewFS().AddTree(sourceDir, phopytest.Tree{
+		"100MSDCF/DSC0042.ARW": {ModTime: now},
+		"100MSDCF/DSC0042.JPG": {ModTime: now},
+		"101MSDCF/dsc0043.arw": {ModTime: now},
+		"101MSDCF/DSC0043.JPG": {ModTime: now},
+		"101MSDCF/DSC0044.JPG": {ModTime: now},
+	})
 
-func newTrackingExif(timestamps map[string]time.Time) *trackingExif {
-	return &trackingExif{
-		timestamps: timestamps,
-		called:     make(map[string]bool),
+	planner := Planner{FS: fsys, Exif: phopytest.NewExif()}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
-}
 
-func (m *trackingExif) DateTimeOriginal(ctx context.Context, path string) (time.Time, error) {
-	m.called[path] = true
-	if ts, ok := m.timestamps[path]; ok {
-		return ts, nil
+	want := []domain.JPEGPairing{
+		{JPEG: filepath.Join(sourceDir, "100MSDCF", "DSC0042.JPG"), RAW: filepath.Join(sourceDir, "100MSDCF", "DSC0042.ARW")},
+		{JPEG: filepath.Join(sourceDir, "101MSDCF", "DSC0043.JPG"), RAW: filepath.Join(sourceDir, "101MSDCF", "dsc0043.arw")},
+	}
+	if len(plan.Pairings) != len(want) {
+		t.Fatalf("expected %d pairings, got %+v", len(want), plan.Pairings)
+	}
+	for i := range want {
+		if plan.Pairings[i] != want[i] {
+			t.Fatalf("pairing %d: expected %+v, got %+v", i, want[i], plan.Pairings[i])
+		}
+	}
+	if plan.SkippedJPEGs != len(plan.Pairings) {
+		t.Fatalf("expected one pairing per skipped JPEG, got %d skipped", plan.SkippedJPEGs)
 	}
-	return time.Time{}, errors.New("missing exif")
 }
 
-type mockDirEntry struct {
-	name  string
-	isDir bool
-}
+func TestPlannerComparesWithLastImport(t *testing.T) {
+	sourceDir := "/card"
+	targetDir := "/archive"
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	imported := time.Date(2024, 3, 10, 9, 0, 0, 0, time.Local)
 
-func (m mockDirEntry) Name() string               { return m.name }
-func (m mockDirEntry) IsDir() bool                { return m.isDir }
-func (m mockDirEntry) Type() fs.FileMode          { return 0 }
-func (m mockDirEntry) Info() (fs.FileInfo, error) { return nil, nil }
+	fsys := phopytest.NewFS().AddTree(sourceDir, phopytest.Tree{
+		"DSC0001.ARW": {Size: 100, ModTime: now},
+		"DSC0002.JPG": {Size: 200, ModTime: now},
+		"DSC0003.JPG": {Size: 300, ModTime: now},
+	})
+	history := journal.NewHistory([]journal.Entry{{
+		Time: imported,
+		Files: []journal.File{
+			{SourcePath: filepath.Join(sourceDir, "DSC0001.ARW"), Size: 100, TakenAt: now},
+			{SourcePath: filepath.Join(sourceDir, "DSC0002.JPG"), Size: 150, TakenAt: now},
+		},
+	}})
 
-type mockFileInfo struct {
-	name    string
-	modTime time.Time
-}
+	exif := phopytest.NewExif()
+	for _, name := range []string{"DSC0001.ARW", "DSC0002.JPG", "DSC0003.JPG"} {
+		exif.SetTakenAt(filepath.Join(sourceDir, name), now)
+	}
+	planner := Planner{FS: fsys, Exif: exif, History: history}
+	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
-func (m mockFileInfo) Name() string       { return m.name }
-func (m mockFileInfo) Size() int64        { return 0 }
-func (m mockFileInfo) Mode() fs.FileMode  { return 0 }
-func (m mockFileInfo) ModTime() time.Time { return m.modTime }
-func (m mockFileInfo) IsDir() bool        { return false }
-func (m mockFileInfo) Sys() interface{}   { return nil }
+	diff := plan.SinceLastImport
+	if diff == nil {
+		t.Fatalf("expected a comparison with the last import")
+	}
+	if !diff.LastImport.Equal(imported) || diff.New != 1 || diff.Unchanged != 1 || diff.Modified != 1 {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	if len(plan.Warnings) != 1 || plan.Warnings[0].Code != domain.WarningChangedSinceImport || !strings.HasSuffix(plan.Warnings[0].Text, "DSC0002.JPG changed since it was imported on 2024-03-10") {
+		t.Fatalf("expected a warning about the modified JPEG, got %v", plan.Warnings)
+	}
 
-func TestPlannerSkipsJPEGWhenRAWExists(t *testing.T) {
-	sourceDir := "/source"
-	targetDir := "/target"
-	rawPath := filepath.Join(sourceDir, "DSC0001.ARW")
-	jpegPath := filepath.Join(sourceDir, "DSC0001.JPG")
+	planner.History = journal.NewHistory(nil)
+	if plan, _ = planner.Plan(context.Background(), sourceDir, targetDir, nil, nil); plan.SinceLastImport != nil {
+		t.Fatalf("did not expect a comparison without earlier imports")
+	}
+}
 
+func TestPlannerDedupesCaptures(t *testing.T) {
+	sourceDir := "/card"
 	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
-	mock := mockFS{
-		entries: []mockEntry{
-			{path: rawPath, modTime: now},
-			{path: jpegPath, modTime: now},
-		},
-		exists: map[string]bool{},
+	fsys := phopytest.NewFS().AddTree(sourceDir, phopytest.Tree{
+		// Same shot of a body with serial and image number, copied twice
+		"DCIM/100CANON/IMG_0001.CR3": {Data: []byte("raw one"), ModTime: now},
+		"copies/IMG_0001.CR3":        {Data: []byte("raw one, re-encoded"), ModTime: now},
+		// A body without those tags: compared by content
+		"DCIM/100PHONE/IMG_0002.JPG": {Data: []byte("same jpeg"), ModTime: now},
+		"copies/IMG_0002_1.JPG":      {Data: []byte("same jpeg"), ModTime: now},
+		"DCIM/100PHONE/IMG_0003.JPG": {Data: []byte("other jpeg"), ModTime: now},
+	})
+	canon := domain.PhotoMeta{TakenAt: now, Make: "Canon", Model: "Canon EOS R5", BodySerial: "0820", ImageNumber: 1}
+	exif := phopytest.NewExif().
+		SetMeta(filepath.Join(sourceDir, "DCIM", "100CANON", "IMG_0001.CR3"), canon).
+		SetMeta(filepath.Join(sourceDir, "copies", "IMG_0001.CR3"), canon)
+	for _, rel := range []string{"DCIM/100PHONE/IMG_0002.JPG", "copies/IMG_0002_1.JPG", "DCIM/100PHONE/IMG_0003.JPG"} {
+		exif.SetMeta(filepath.Join(sourceDir, filepath.FromSlash(rel)), domain.PhotoMeta{TakenAt: now, Make: "Apple"})
 	}
 
-	planner := Planner{
-		FS:   mock,
-		Exif: mockExif{timestamps: map[string]time.Time{rawPath: now, jpegPath: now}},
+	planner := Planner{FS: fsys, Exif: exif, Layout: domain.Layout{Flatten: true}}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 5 || plan.Duplicates != 0 {
+		t.Fatalf("expected every file without --dedupe, got %d items", len(plan.Items))
 	}
 
-	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	planner.Dedupe = true
+	plan, err = planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if plan.SkippedJPEGs != 1 {
-		t.Fatalf("expected 1 skipped JPEG, got %d", plan.SkippedJPEGs)
+	if plan.Duplicates != 2 || len(plan.Items) != 3 {
+		t.Fatalf("expected 2 duplicates and 3 items, got %d and %d", plan.Duplicates, len(plan.Items))
 	}
-	if plan.RawCount != 1 || plan.JpegCount != 0 {
-		t.Fatalf("unexpected counts: raw=%d jpeg=%d", plan.RawCount, plan.JpegCount)
+	for _, item := range plan.Items {
+		if strings.HasPrefix(item.FileMeta.RelativePath, "copies") {
+			t.Fatalf("expected the copies to be left out, got %s", item.FileMeta.RelativePath)
+		}
+	}
+	for _, want := range []string{
+		filepath.Join("copies", "IMG_0001.CR3") + " is a duplicate of " + filepath.Join("DCIM", "100CANON", "IMG_0001.CR3"),
+		filepath.Join("copies", "IMG_0002_1.JPG") + " is a duplicate of " + filepath.Join("DCIM", "100PHONE", "IMG_0002.JPG"),
+	} {
+		if !strings.Contains(fmt.Sprint(plan.Warnings), want) {
+			t.Fatalf("expected warning %q, got %v", want, plan.Warnings)
+		}
 	}
 }
 
@@ -150,18 +264,13 @@ func TestPlannerDetectsOverrides(t *testing.T) {
 	targetPath := filepath.Join(targetDir, "DSC0002.ARW")
 
 	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
-	mock := mockFS{
-		entries: []mockEntry{
-			{path: rawPath, modTime: now},
-		},
-		exists: map[string]bool{
-			targetPath: true,
-		},
-	}
+	fsys := phopytest.NewFS().
+		AddFile(rawPath, phopytest.File{ModTime: now}).
+		AddFile(targetPath, phopytest.File{ModTime: now})
 
 	planner := Planner{
-		FS:            mock,
-		Exif:          mockExif{timestamps: map[string]time.Time{rawPath: now}},
+		FS:            fsys,
+		Exif:          phopytest.NewExif().SetTakenAt(rawPath, now),
 		AllowOverride: true, // Enable override mode to detect existing files
 	}
 
@@ -175,6 +284,183 @@ func TestPlannerDetectsOverrides(t *testing.T) {
 	if plan.RawOverrides != 1 {
 		t.Fatalf("expected 1 raw override, got %d", plan.RawOverrides)
 	}
+	if !plan.OverrideItems[0].Exists || len(plan.Items) != 1 || !plan.Items[0].Exists {
+		t.Fatalf("expected the override to be marked as existing, got %+v", plan.Items)
+	}
+}
+
+func TestPlannerAutoApprovesStaleOverrides(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
+	targets := map[string]phopytest.File{
+		"DSC0001.ARW": {Size: 50, ModTime: now.Add(-time.Hour)},  // older and smaller: stale
+		"DSC0002.ARW": {Size: 50, ModTime: now.Add(time.Hour)},   // newer: maybe edited
+		"DSC0003.ARW": {Size: 100, ModTime: now.Add(-time.Hour)}, // same size: maybe the same file
+		"DSC0004.ARW": {Size: 50, ModTime: now},                  // same date
+	}
+	fsys := phopytest.NewFS()
+	exif := phopytest.NewExif()
+	for name, target := range targets {
+		source := filepath.Join(sourceDir, name)
+		fsys.AddFile(source, phopytest.File{Size: 100, ModTime: now}).AddFile(filepath.Join(targetDir, name), target)
+		exif.SetTakenAt(source, now)
+	}
+
+	for _, auto := range []bool{false, true} {
+		planner := Planner{FS: fsys, Exif: exif, AllowOverride: true, AutoOverrideStale: auto}
+		plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var asked, approved []string
+		for _, item := range plan.OverrideItems {
+			asked = append(asked, item.FileMeta.Name)
+		}
+		for _, item := range plan.AutoOverrideItems {
+			approved = append(approved, item.FileMeta.Name)
+		}
+		wantAsked, wantApproved := []string{"DSC0001.ARW", "DSC0002.ARW", "DSC0003.ARW", "DSC0004.ARW"}, []string(nil)
+		if auto {
+			wantAsked, wantApproved = wantAsked[1:], wantAsked[:1]
+		}
+		if !reflect.DeepEqual(asked, wantAsked) || !reflect.DeepEqual(approved, wantApproved) {
+			t.Fatalf("auto %t: expected to ask about %v and approve %v, got %v and %v", auto, wantAsked, wantApproved, asked, approved)
+		}
+		if plan.RawOverrides != len(wantAsked) || len(plan.Items) != 4 {
+			t.Fatalf("auto %t: expected %d RAW overrides of 4 items, got %d of %d", auto, len(wantAsked), plan.RawOverrides, len(plan.Items))
+		}
+		if got := plan.Items[0]; !got.Exists || got.AutoOverride != auto {
+			t.Fatalf("auto %t: expected the stale item to be marked, got %+v", auto, got)
+		}
+	}
+}
+
+func TestPlannerChecksOverridesConcurrently(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+
+	fsys := phopytest.NewFS()
+	exif := phopytest.NewExif()
+	for i := range 8 {
+		name := fmt.Sprintf("DSC%04d.ARW", i)
+		fsys.AddFile(filepath.Join(sourceDir, name), phopytest.File{ModTime: now})
+		exif.SetTakenAt(filepath.Join(sourceDir, name), now.Add(time.Duration(i)*time.Minute))
+		if i%2 == 0 {
+			fsys.AddFile(filepath.Join(targetDir, name), phopytest.File{ModTime: now})
+		}
+	}
+	fsys.Latency.Exists = 50 * time.Millisecond
+
+	planner := Planner{FS: fsys, Exif: exif, TargetWorkers: 8, AllowOverride: true}
+	start := time.Now()
+	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if serial := fsys.Elapsed(); elapsed >= serial {
+		t.Fatalf("expected the checks to overlap, took %v for %v of latency", elapsed, serial)
+	}
+	if len(plan.OverrideItems) != 4 {
+		t.Fatalf("expected 4 overrides, got %d", len(plan.OverrideItems))
+	}
+	for i, item := range plan.OverrideItems {
+		if want := fmt.Sprintf("DSC%04d.ARW", 2*i); item.FileMeta.Name != want {
+			t.Fatalf("expected override %d to be %s, got %s", i, want, item.FileMeta.Name)
+		}
+	}
+}
+
+func TestPlannerSizesTargetChecksApartFromExifWorkers(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+
+	plan := func(targetWorkers int) time.Duration {
+		fsys := phopytest.NewFS()
+		exif := phopytest.NewExif()
+		for i := range 8 {
+			name := fmt.Sprintf("DSC%04d.ARW", i)
+			fsys.AddFile(filepath.Join(sourceDir, name), phopytest.File{ModTime: now})
+			exif.SetTakenAt(filepath.Join(sourceDir, name), now.Add(time.Duration(i)*time.Minute))
+		}
+		// A slow network target: only the existence checks wait.
+		fsys.Latency.Exists = 25 * time.Millisecond
+
+		planner := Planner{FS: fsys, Exif: exif, ExifWorkers: 1, TargetWorkers: targetWorkers, AllowOverride: true}
+		start := time.Now()
+		if _, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return time.Since(start)
+	}
+
+	serial := plan(1)
+	if serial < 8*25*time.Millisecond {
+		t.Fatalf("expected one target worker to check one target at a time, took %v", serial)
+	}
+	if parallel := plan(8); parallel >= serial/2 {
+		t.Fatalf("expected 8 target workers to overlap with 1 EXIF worker, took %v against %v", parallel, serial)
+	}
+}
+
+func TestPlannerStopsTheScanEarlyWithAPartialPlan(t *testing.T) {
+	sourceDir := "/card/DCIM/100MSDCF"
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	fsys := phopytest.NewFS()
+	exif := phopytest.NewExif()
+	const files = 10
+	for i := range files {
+		path := filepath.Join(sourceDir, fmt.Sprintf("DSC%04d.ARW", i))
+		fsys.AddFile(path, phopytest.File{ModTime: now})
+		exif.SetTakenAt(path, now.Add(time.Duration(i)*time.Minute))
+	}
+
+	stop := make(chan struct{})
+	planner := Planner{FS: fsys, Exif: exif, ExifWorkers: 1, Stop: stop}
+	planner.OnProgress = func(current, total int) {
+		if current == 3 {
+			close(stop)
+		}
+	}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("expected a stopped scan to plan, got %v", err)
+	}
+
+	// The single worker may have taken the next file before the stop
+	if !plan.Partial || plan.Unscanned < files-4 || plan.Unscanned > files-3 {
+		t.Fatalf("expected a partial plan with 6 or 7 files not scanned, got partial %v and %d", plan.Partial, plan.Unscanned)
+	}
+	if len(plan.Items)+plan.Unscanned != files || plan.RawCount != len(plan.Items) {
+		t.Fatalf("expected every file planned or not scanned, got %d items, %d RAWs and %d not scanned", len(plan.Items), plan.RawCount, plan.Unscanned)
+	}
+	seen := make(map[string]bool)
+	for i, item := range plan.Items {
+		if want := fmt.Sprintf("DSC%04d.ARW", i); item.FileMeta.Name != want {
+			t.Fatalf("expected the scanned files to be the first in queue order, got %s at %d", item.FileMeta.Name, i)
+		}
+		seen[item.FileMeta.SourcePath] = true
+	}
+	for _, leftover := range plan.Leftovers {
+		if leftover.Reason != skipNotScanned || seen[leftover.SourcePath] {
+			t.Fatalf("expected only the files not scanned as leftovers, got %+v", leftover)
+		}
+	}
+	if len(plan.Leftovers) != plan.Unscanned {
+		t.Fatalf("expected %d leftovers, got %d", plan.Unscanned, len(plan.Leftovers))
+	}
+
+	// Until it is closed, Stop changes nothing
+	planner.Stop = make(chan struct{})
+	planner.OnProgress = nil
+	if plan, err = planner.Plan(context.Background(), sourceDir, "/target", nil, nil); err != nil || plan.Partial || len(plan.Items) != files {
+		t.Fatalf("expected a full plan without a stop, got partial %v with %d items (%v)", plan.Partial, len(plan.Items), err)
+	}
 }
 
 func TestPlannerSkipsExistingWhenOverrideFalse(t *testing.T) {
@@ -185,19 +471,14 @@ func TestPlannerSkipsExistingWhenOverrideFalse(t *testing.T) {
 	targetPath1 := filepath.Join(targetDir, "DSC0001.ARW")
 
 	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
-	mock := mockFS{
-		entries: []mockEntry{
-			{path: rawPath1, modTime: now},
-			{path: rawPath2, modTime: now},
-		},
-		exists: map[string]bool{
-			targetPath1: true, // DSC0001.ARW already exists in target
-		},
-	}
+	fsys := phopytest.NewFS().
+		AddFile(rawPath1, phopytest.File{ModTime: now}).
+		AddFile(rawPath2, phopytest.File{ModTime: now}).
+		AddFile(targetPath1, phopytest.File{ModTime: now}) // DSC0001.ARW already exists in target
 
 	planner := Planner{
-		FS:            mock,
-		Exif:          mockExif{timestamps: map[string]time.Time{rawPath1: now, rawPath2: now}},
+		FS:            fsys,
+		Exif:          phopytest.NewExif().SetTakenAt(rawPath1, now).SetTakenAt(rawPath2, now),
 		AllowOverride: false, // Default: skip existing files
 	}
 
@@ -228,18 +509,13 @@ func TestPlannerFiltersByDateRange(t *testing.T) {
 	rawPath := filepath.Join(sourceDir, "DSC0003.ARW")
 
 	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
-	mock := mockFS{
-		entries: []mockEntry{
-			{path: rawPath, modTime: now},
-		},
-		exists: map[string]bool{},
-	}
+	fsys := phopytest.NewFS().AddFile(rawPath, phopytest.File{ModTime: now})
 
 	start := now.Add(24 * time.Hour)
 	end := now.Add(48 * time.Hour)
 	planner := Planner{
-		FS:   mock,
-		Exif: mockExif{timestamps: map[string]time.Time{rawPath: now}},
+		FS:   fsys,
+		Exif: phopytest.NewExif().SetTakenAt(rawPath, now),
 	}
 
 	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, &start, &end)
@@ -249,10 +525,13 @@ func TestPlannerFiltersByDateRange(t *testing.T) {
 	if len(plan.Items) != 0 {
 		t.Fatalf("expected 0 items, got %d", len(plan.Items))
 	}
-	// Should track skipped RAW as date-filtered
+	// Should track skipped RAW as date-filtered, by its modification time
 	if plan.SkippedRAWsDate != 1 {
 		t.Fatalf("expected 1 skipped RAW date, got %d", plan.SkippedRAWsDate)
 	}
+	if plan.SkippedByMtimeShortcut != 1 || plan.SkippedByCaptureDate != 0 {
+		t.Fatalf("expected the mtime shortcut to skip it, got %d by mtime and %d by capture date", plan.SkippedByMtimeShortcut, plan.SkippedByCaptureDate)
+	}
 }
 
 func TestDeriveRangeFallsBackToItems(t *testing.T) {
@@ -263,7 +542,7 @@ func TestDeriveRangeFallsBackToItems(t *testing.T) {
 		{FileMeta: domain.FileMeta{TakenAt: later}},
 		{FileMeta: domain.FileMeta{TakenAt: now}},
 	}
-	start, end := deriveRange(items, nil, nil)
+	start, end := deriveRange(items, nil, nil, false, domain.DayPolicy{})
 	if start == nil || end == nil {
 		t.Fatalf("expected start and end to be set")
 	}
@@ -272,33 +551,48 @@ func TestDeriveRangeFallsBackToItems(t *testing.T) {
 	}
 }
 
+func TestPlannerCountsCaptureDateSkipsApart(t *testing.T) {
+	sourceDir := "/source"
+	oldPath := filepath.Join(sourceDir, "DSC0001.ARW")
+	copiedPath := filepath.Join(sourceDir, "DSC0002.ARW")
+	startDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+
+	// Copied onto the card after the start, but taken before it
+	modified := time.Date(2024, 6, 15, 12, 0, 0, 0, time.Local)
+	fsys := phopytest.NewFS().
+		AddFile(oldPath, phopytest.File{ModTime: time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)}).
+		AddFile(copiedPath, phopytest.File{ModTime: modified})
+	exif := phopytest.NewExif().SetTakenAt(copiedPath, time.Date(2024, 5, 20, 12, 0, 0, 0, time.Local))
+
+	plan, err := (&Planner{FS: fsys, Exif: exif}).Plan(context.Background(), sourceDir, "/target", &startDate, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.SkippedRAWsDate != 2 || plan.SkippedByMtimeShortcut != 1 || plan.SkippedByCaptureDate != 1 {
+		t.Fatalf("expected one skip by mtime and one by capture date, got %d (%d by mtime, %d by capture date)", plan.SkippedRAWsDate, plan.SkippedByMtimeShortcut, plan.SkippedByCaptureDate)
+	}
+}
+
 func TestPlannerSkipsExifReadWhenModTimeBeforeStartDate(t *testing.T) {
 	sourceDir := "/source"
 	targetDir := "/target"
-	oldPath := filepath.Join(sourceDir, "DSC0001.ARW")  // ModTime before startDate
-	newPath := filepath.Join(sourceDir, "DSC0002.ARW")  // ModTime after startDate
+	oldPath := filepath.Join(sourceDir, "DSC0001.ARW") // ModTime before startDate
+	newPath := filepath.Join(sourceDir, "DSC0002.ARW") // ModTime after startDate
 
 	oldTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
 	newTime := time.Date(2024, 6, 15, 12, 0, 0, 0, time.Local)
 	startDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
 
-	mock := mockFS{
-		entries: []mockEntry{
-			{path: oldPath, modTime: oldTime}, // ModTime is 2024-01-01, before startDate
-			{path: newPath, modTime: newTime}, // ModTime is 2024-06-15, after startDate
-		},
-		exists: map[string]bool{},
-	}
+	fsys := phopytest.NewFS().
+		AddFile(oldPath, phopytest.File{ModTime: oldTime}). // ModTime is 2024-01-01, before startDate
+		AddFile(newPath, phopytest.File{ModTime: newTime})  // ModTime is 2024-06-15, after startDate
 
-	// Use tracking mock to verify which files have EXIF read
-	exifMock := newTrackingExif(map[string]time.Time{
-		oldPath: oldTime,
-		newPath: newTime,
-	})
+	// The fake counts reads to verify which files have EXIF read
+	exif := phopytest.NewExif().SetTakenAt(oldPath, oldTime).SetTakenAt(newPath, newTime)
 
 	planner := Planner{
-		FS:   mock,
-		Exif: exifMock,
+		FS:   fsys,
+		Exif: exif,
 	}
 
 	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, &startDate, nil)
@@ -315,17 +609,1494 @@ func TestPlannerSkipsExifReadWhenModTimeBeforeStartDate(t *testing.T) {
 	}
 
 	// Verify EXIF was NOT read for the old file (optimization)
-	if exifMock.called[oldPath] {
+	if exif.Reads(oldPath) != 0 {
 		t.Fatalf("EXIF should NOT have been read for %s (ModTime before startDate)", oldPath)
 	}
 
 	// Verify EXIF WAS read for the new file
-	if !exifMock.called[newPath] {
+	if exif.Reads(newPath) == 0 {
 		t.Fatalf("EXIF should have been read for %s", newPath)
 	}
 
-	// Should track skipped RAW as date-filtered
+	// Should track skipped RAW as date-filtered, by its modification time
 	if plan.SkippedRAWsDate != 1 {
 		t.Fatalf("expected 1 skipped RAW date, got %d", plan.SkippedRAWsDate)
 	}
+	if plan.SkippedByMtimeShortcut != 1 || plan.SkippedByCaptureDate != 0 {
+		t.Fatalf("expected the mtime shortcut to skip it, got %d by mtime and %d by capture date", plan.SkippedByMtimeShortcut, plan.SkippedByCaptureDate)
+	}
+}
+
+func TestPlannerFastPlanSkipsExif(t *testing.T) {
+	sourceDir := "/source"
+	modTime := time.Date(2024, 10, 3, 9, 0, 0, 0, time.Local)
+	takenAt := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	fsys := phopytest.NewFS().AddTree(sourceDir, phopytest.Tree{
+		"DSC0001.ARW": {ModTime: modTime},
+		"DSC0001.JPG": {ModTime: modTime},
+		"DSC0002.JPG": {ModTime: modTime},
+	})
+	exif := phopytest.NewExif().SetTakenAt(filepath.Join(sourceDir, "DSC0001.ARW"), takenAt)
+
+	planner := Planner{FS: fsys, Exif: exif, Layout: domain.Layout{Dir: "{date}"}, Fast: true}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exif.TotalReads() != 0 {
+		t.Fatalf("expected no EXIF reads in a fast plan, got %d", exif.TotalReads())
+	}
+	if !plan.ApproximateDates || len(plan.Warnings) != 0 {
+		t.Fatalf("expected an approximate plan without EXIF warnings, got %+v", plan)
+	}
+	if len(plan.Items) != 2 || plan.SkippedJPEGs != 1 {
+		t.Fatalf("expected the RAW pairing to still apply, got %d items and %d skipped JPEGs", len(plan.Items), plan.SkippedJPEGs)
+	}
+	if want := filepath.Join("/target", "2024-10-03", "DSC0001.ARW"); plan.Items[0].TargetPath != want {
+		t.Fatalf("expected the modification date, got %s", plan.Items[0].TargetPath)
+	}
+
+	// Without EXIF the planner still works in fast mode
+	planner.Exif = nil
+	if _, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil); err != nil {
+		t.Fatalf("unexpected error without an EXIF reader: %v", err)
+	}
+}
+
+func TestPlannerDatesScansByDirectory(t *testing.T) {
+	sourceDir := "/scans"
+	scanned := time.Date(2023, 3, 1, 20, 0, 0, 0, time.Local)
+	fsys := phopytest.NewFS().AddTree(sourceDir, phopytest.Tree{
+		"1998-07 summer trip/roll 1/scan01.jpg":           {ModTime: scanned},
+		"1998-07 summer trip/1998-07-14 beach/scan02.jpg": {ModTime: scanned},
+		"1998-07 summer trip/1998-07-14 beach/scan03.jpg": {ModTime: scanned},
+		"misc/scan04.jpg": {ModTime: scanned},
+	})
+	// A scan with EXIF keeps its EXIF date
+	exif := phopytest.NewExif().SetTakenAt(filepath.Join(sourceDir, "1998-07 summer trip", "1998-07-14 beach", "scan03.jpg"), time.Date(1998, 7, 15, 10, 0, 0, 0, time.Local))
+	dirDates, err := domain.ParseDirDatePattern("auto")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	planner := Planner{FS: fsys, Exif: exif, Layout: domain.Layout{Dir: "{date}", Flatten: true}, DirDates: dirDates}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, item := range plan.Items {
+		rel, _ := filepath.Rel("/target", item.TargetPath)
+		got[filepath.ToSlash(rel)] = item.FileMeta.DateSource.String()
+	}
+	want := map[string]string{
+		"1998-07-01/scan01.jpg": "directory",
+		"1998-07-14/scan02.jpg": "directory",
+		"1998-07-15/scan03.jpg": "exif",
+		"2023-03-01/scan04.jpg": "file time",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected targets:\n%v", got)
+	}
+	if len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0].Text, "scan04.jpg") {
+		t.Fatalf("expected a warning only for the undated scan, got %v", plan.Warnings)
+	}
+}
+
+func TestPlannerFlattensSiblingCardFolders(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
+
+	paths := []string{
+		filepath.Join(sourceDir, "DCIM", "100MSDCF", "DSC0001.ARW"),
+		filepath.Join(sourceDir, "DCIM", "101MSDCF", "DSC0001.ARW"),
+		filepath.Join(sourceDir, "DCIM", "102MSDCF", "DSC0002.ARW"),
+	}
+	fsys, exif := phopytest.NewFS(), phopytest.NewExif()
+	for i, path := range paths {
+		takenAt := now.Add(time.Duration(i) * time.Minute)
+		fsys.AddFile(path, phopytest.File{ModTime: takenAt})
+		exif.SetTakenAt(path, takenAt)
+	}
+
+	planner := Planner{
+		FS:     fsys,
+		Exif:   exif,
+		Layout: domain.Layout{Dir: "{date}", Flatten: true},
+	}
+
+	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(targetDir, "2024-10-02", "DSC0001.ARW"),
+		filepath.Join(targetDir, "2024-10-02", "DSC0001-1.ARW"),
+		filepath.Join(targetDir, "2024-10-02", "DSC0002.ARW"),
+	}
+	if len(plan.Items) != len(want) {
+		t.Fatalf("expected %d items, got %d", len(want), len(plan.Items))
+	}
+	for i, item := range plan.Items {
+		if item.TargetPath != want[i] {
+			t.Fatalf("item %d: expected %s, got %s", i, want[i], item.TargetPath)
+		}
+		if item.FileMeta.SourcePath != paths[i] {
+			t.Fatalf("item %d: expected source %s, got %s", i, paths[i], item.FileMeta.SourcePath)
+		}
+	}
+}
+
+func TestPlannerSkipsExistingDatedTargets(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	rawPath := filepath.Join(sourceDir, "DSC0001.ARW")
+	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
+
+	planner := Planner{
+		FS: phopytest.NewFS().
+			AddFile(rawPath, phopytest.File{ModTime: now}).
+			AddFile(filepath.Join(targetDir, "2024-10-02", "DSC0001.ARW"), phopytest.File{ModTime: now}),
+		Exif:   phopytest.NewExif().SetTakenAt(rawPath, now),
+		Layout: domain.Layout{Dir: "{date}"},
+	}
+
+	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 0 || plan.SkippedRAWsDupl != 1 {
+		t.Fatalf("expected the existing dated target to be skipped, got %d items", len(plan.Items))
+	}
+}
+
+func TestPlannerMatchesExistingTargetsInAnyCaseWithExtCase(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
+
+	// The archive keeps lowercase names; DSC0002 is new
+	fsys, exif := phopytest.NewFS(), phopytest.NewExif()
+	for i, name := range []string{"DSC0001.ARW", "DSC0002.ARW"} {
+		path := filepath.Join(sourceDir, name)
+		fsys.AddFile(path, phopytest.File{ModTime: now})
+		exif.SetTakenAt(path, now.Add(time.Duration(i)*time.Minute))
+	}
+	existing := filepath.Join(targetDir, "2024-10-02", "dsc0001.arw")
+	fsys.AddFile(existing, phopytest.File{ModTime: now})
+
+	tests := []struct {
+		extCase domain.ExtCase
+		// targets planned without and with AllowOverride
+		skip, override []string
+	}{
+		{domain.ExtCaseKeep, []string{"DSC0001.ARW", "DSC0002.ARW"}, []string{"DSC0001.ARW", "DSC0002.ARW"}},
+		{domain.ExtCaseLower, []string{"DSC0002.arw"}, []string{"dsc0001.arw", "DSC0002.arw"}},
+		{domain.ExtCaseUpper, []string{"DSC0002.ARW"}, []string{"dsc0001.arw", "DSC0002.ARW"}},
+	}
+	for _, tt := range tests {
+		for _, allowOverride := range []bool{false, true} {
+			planner := Planner{
+				FS:            fsys,
+				Exif:          exif,
+				Layout:        domain.Layout{Dir: "{date}", Flatten: true, ExtCase: tt.extCase},
+				AllowOverride: allowOverride,
+			}
+			plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, item := range plan.Items {
+				got = append(got, filepath.Base(item.TargetPath))
+			}
+			want, overrides := tt.skip, 0
+			if allowOverride {
+				want = tt.override
+				if tt.extCase.Sets() {
+					overrides = 1
+				}
+			}
+			if !reflect.DeepEqual(got, want) || len(plan.OverrideItems) != overrides {
+				t.Fatalf("ext case %d, override %t: expected %v with %d overrides, got %v with %d", tt.extCase, allowOverride, want, overrides, got, len(plan.OverrideItems))
+			}
+		}
+	}
+}
+
+func TestPlannerAggregatesMixedCaseExtensions(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
+
+	paths := []string{
+		filepath.Join(sourceDir, "DSC0001.ARW"),
+		filepath.Join(sourceDir, "DSC0002.arw"),
+		filepath.Join(sourceDir, "IMG0003.JPG"),
+		filepath.Join(sourceDir, "img0004.jpg"),
+	}
+	fsys, exif := phopytest.NewFS(), phopytest.NewExif()
+	for i, path := range paths {
+		takenAt := now.Add(time.Duration(i) * time.Minute)
+		fsys.AddFile(path, phopytest.File{ModTime: takenAt})
+		exif.SetTakenAt(path, takenAt)
+	}
+
+	for _, tt := range []struct {
+		extCase domain.ExtCase
+		want    []string
+	}{
+		{domain.ExtCaseDefault, []string{"DSC0001.arw", "DSC0002.arw", "IMG0003.jpg", "img0004.jpg"}},
+		{domain.ExtCaseKeep, []string{"DSC0001.ARW", "DSC0002.arw", "IMG0003.JPG", "img0004.jpg"}},
+	} {
+		planner := Planner{
+			FS:     fsys,
+			Exif:   exif,
+			Layout: domain.Layout{Name: "{name}.{ext}", ExtCase: tt.extCase},
+		}
+
+		plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(plan.ExtensionCounts) != 2 || plan.ExtensionCounts[".arw"] != 2 || plan.ExtensionCounts[".jpg"] != 2 {
+			t.Fatalf("expected single .arw and .jpg buckets, got %v", plan.ExtensionCounts)
+		}
+		if plan.RawCount != 2 || plan.JpegCount != 2 {
+			t.Fatalf("unexpected counts: raw=%d jpeg=%d", plan.RawCount, plan.JpegCount)
+		}
+		for i, item := range plan.Items {
+			if got := filepath.Base(item.TargetPath); got != tt.want[i] {
+				t.Fatalf("extCase=%v item %d: expected %s, got %s", tt.extCase, i, tt.want[i], got)
+			}
+		}
+	}
+}
+
+func TestPlannerSniffsFilesWithUnknownExtensions(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	videoPath := filepath.Join(sourceDir, "GOPR0001")
+	jpegPath := filepath.Join(sourceDir, "IMG0002.insv")
+	textPath := filepath.Join(sourceDir, "notes.txt")
+	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
+
+	fsys := phopytest.NewFS().
+		AddFile(videoPath, phopytest.File{ModTime: now, Data: []byte("\x00\x00\x00\x20ftypmp42\x00\x00\x00\x00")}).
+		AddFile(jpegPath, phopytest.File{ModTime: now.Add(time.Minute), Data: []byte{0xFF, 0xD8, 0xFF, 0xE0}}).
+		AddFile(textPath, phopytest.File{ModTime: now, Data: []byte("remember the milk")})
+
+	planner := Planner{FS: fsys, Exif: phopytest.NewExif(), Layout: domain.Layout{FixSniffedExt: true}}
+	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 0 {
+		t.Fatalf("expected sniffing to be off by default, got %d items", len(plan.Items))
+	}
+
+	planner.Sniff = true
+	plan, err = planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 2 || plan.SniffedFiles != 2 {
+		t.Fatalf("expected 2 sniffed items, got %d (%d sniffed)", len(plan.Items), plan.SniffedFiles)
+	}
+	if plan.JpegCount != 1 {
+		t.Fatalf("expected the sniffed JPEG to count as JPEG, got %d", plan.JpegCount)
+	}
+	if got := plan.Items[0].TargetPath; got != filepath.Join(targetDir, "GOPR0001.mp4") {
+		t.Fatalf("unexpected target for sniffed video: %s", got)
+	}
+	if got := plan.Items[1].TargetPath; got != filepath.Join(targetDir, "IMG0002.jpg") {
+		t.Fatalf("unexpected target for sniffed JPEG: %s", got)
+	}
+}
+
+func TestPlannerSkipsVideoProxies(t *testing.T) {
+	sourceDir := "/card"
+	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
+	mp4 := phopytest.File{ModTime: now, Data: []byte("\x00\x00\x00\x20ftypmp42\x00\x00\x00\x00")}
+	fsys := phopytest.NewFS().AddTree(sourceDir, phopytest.Tree{
+		"PRIVATE/M4ROOT/CLIP/C0001.MP4":     mp4,
+		"PRIVATE/M4ROOT/SUB/C0001S03.MP4":   mp4,
+		"CONTENTS/PROXY/0001AB.MP4":         mp4,
+		"DCIM/100MSDCF/DSC0001.ARW":         {ModTime: now},
+		"DCIM/100MSDCF/DSC0001_proxy.mp4":   mp4,
+		"PRIVATE/M4ROOT/CLIP/C0001M01.XML":  {ModTime: now},
+		"PRIVATE/M4ROOT/SUB/C0001S03M1.XML": {ModTime: now},
+	})
+	exif := phopytest.NewExif().SetTakenAt(filepath.Join(sourceDir, "DCIM", "100MSDCF", "DSC0001.ARW"), now)
+
+	planner := Planner{FS: fsys, Exif: exif, Sniff: true}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 2 || plan.ProxyClips != 3 {
+		t.Fatalf("expected the clip and the RAW planned and 3 proxies skipped, got %v and %d proxies", plan.Items, plan.ProxyClips)
+	}
+	proxies := 0
+	for _, leftover := range plan.Leftovers {
+		if leftover.Reason == skipProxy {
+			proxies++
+		}
+	}
+	if proxies != 3 {
+		t.Fatalf("expected the proxies among the leftovers, got %v", plan.Leftovers)
+	}
+
+	planner.IncludeProxies = true
+	plan, err = planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 5 || plan.ProxyClips != 0 {
+		t.Fatalf("expected the proxies planned, got %d items and %d proxies", len(plan.Items), plan.ProxyClips)
+	}
+}
+
+func TestPlannerSelectsPathsWithIncludesAndExcludes(t *testing.T) {
+	sourceDir := "/card"
+	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
+	tree := phopytest.Tree{
+		"DCIM/100MSDCF/DSC0001.ARW":      {ModTime: now},
+		"DCIM/100MSDCF/DSC0002.JPG":      {ModTime: now},
+		"DCIM/100MSDCF/TEST/DSC0003.ARW": {ModTime: now},
+		"DCIM/101MSDCF/DSC0004.ARW":      {ModTime: now},
+		"DCIM/102MSDCF/DSC0005.ARW":      {ModTime: now},
+		"PRIVATE/AVCHD/DSC0006.JPG":      {ModTime: now},
+	}
+	exif := phopytest.NewExif()
+	for rel := range tree {
+		exif.SetTakenAt(filepath.Join(sourceDir, filepath.FromSlash(rel)), now)
+	}
+	fsys := phopytest.NewFS().AddTree(sourceDir, tree)
+
+	// The includes select two folders, the exclude removes a subfolder of
+	// one of them and *.JPG files anywhere
+	planner := Planner{FS: fsys, Exif: exif, Paths: domain.PathFilter{
+		Include: []string{"DCIM/100MSDCF", "DCIM/101MSDCF"},
+		Exclude: []string{"DCIM/100MSDCF/TEST", "*.JPG"},
+	}}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var planned []string
+	for _, item := range plan.Items {
+		planned = append(planned, item.FileMeta.Name)
+	}
+	if !slices.Equal(planned, []string{"DSC0001.ARW", "DSC0004.ARW"}) {
+		t.Fatalf("expected DSC0001 and DSC0004 planned, got %v", planned)
+	}
+
+	// Pruned folders are not walked, so only the JPEG next to an included
+	// file is a leftover
+	var filtered []string
+	for _, leftover := range plan.Leftovers {
+		if leftover.Reason == skipPathFilter {
+			filtered = append(filtered, filepath.Base(leftover.SourcePath))
+		}
+	}
+	if !slices.Equal(filtered, []string{"DSC0002.JPG"}) {
+		t.Fatalf("expected only DSC0002.JPG filtered during the walk, got %v", filtered)
+	}
+	for _, path := range []string{"DCIM/100MSDCF/TEST/DSC0003.ARW", "DCIM/102MSDCF/DSC0005.ARW"} {
+		if exif.Reads(filepath.Join(sourceDir, filepath.FromSlash(path))) != 0 {
+			t.Fatalf("expected %s not to be read", path)
+		}
+	}
+}
+
+func TestPlannerSumsUpFolders(t *testing.T) {
+	sourceDir := "/card"
+	day := time.Date(2024, 4, 1, 10, 0, 0, 0, time.Local)
+	fsys := phopytest.NewFS().AddTree(sourceDir, phopytest.Tree{
+		"DCIM/100MSDCF/DSC0001.ARW": {ModTime: day},
+		"DCIM/100MSDCF/DSC0001.JPG": {ModTime: day},
+		"DCIM/100MSDCF/.DS_Store":   {ModTime: day},
+		"DCIM/101MSDCF/DSC0002.ARW": {ModTime: day},
+		"DCIM/101MSDCF/DSC0003.ARW": {ModTime: day},
+	}).AddFile("/target/DCIM/101MSDCF/DSC0003.ARW", phopytest.File{ModTime: day})
+	exif := phopytest.NewExif().
+		SetTakenAt("/card/DCIM/100MSDCF/DSC0001.ARW", day).
+		SetTakenAt("/card/DCIM/101MSDCF/DSC0002.ARW", day.AddDate(0, 0, 1)).
+		SetTakenAt("/card/DCIM/101MSDCF/DSC0003.ARW", day.AddDate(0, 0, 2))
+
+	planner := Planner{FS: fsys, Exif: exif, AllowOverride: true}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []domain.FolderStats{
+		{Folder: "DCIM/100MSDCF", Planned: 1, Skipped: 2, First: day, Last: day},
+		{Folder: "DCIM/101MSDCF", Planned: 2, Conflicts: 1, First: day.AddDate(0, 0, 1), Last: day.AddDate(0, 0, 2)},
+	}
+	if !reflect.DeepEqual(plan.Folders, want) {
+		t.Fatalf("unexpected folders:\n got %+v\nwant %+v", plan.Folders, want)
+	}
+}
+
+func TestPlannerAggregatesRangeExclusions(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(2024, 3, 15, 23, 59, 59, 0, time.Local)
+
+	shortcut := filepath.Join(sourceDir, "DSC0001.ARW") // mtime before the range
+	early := filepath.Join(sourceDir, "DSC0002.ARW")    // EXIF before the range
+	inside := filepath.Join(sourceDir, "DSC0003.ARW")
+	late := filepath.Join(sourceDir, "IMG0004.JPG")
+
+	fsys := phopytest.NewFS().
+		AddFile(shortcut, phopytest.File{ModTime: time.Date(2024, 2, 2, 12, 0, 0, 0, time.Local)}).
+		AddFile(early, phopytest.File{ModTime: time.Date(2024, 3, 20, 12, 0, 0, 0, time.Local)}).
+		AddFile(inside, phopytest.File{ModTime: time.Date(2024, 3, 20, 12, 0, 0, 0, time.Local)}).
+		AddFile(late, phopytest.File{ModTime: time.Date(2024, 3, 30, 12, 0, 0, 0, time.Local)})
+	exif := phopytest.NewExif().
+		SetTakenAt(early, time.Date(2024, 2, 20, 12, 0, 0, 0, time.Local)).
+		SetTakenAt(inside, time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)).
+		SetTakenAt(late, time.Date(2024, 3, 30, 12, 0, 0, 0, time.Local))
+
+	planner := Planner{FS: fsys, Exif: exif}
+	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, &start, &end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	excluded := plan.OutsideRange
+	if excluded.Count != 3 {
+		t.Fatalf("expected 3 files outside the range, got %d", excluded.Count)
+	}
+	if got := excluded.Earliest.Format("2006-01-02"); got != "2024-02-02" {
+		t.Fatalf("unexpected earliest date %s", got)
+	}
+	if got := excluded.Latest.Format("2006-01-02"); got != "2024-03-30" {
+		t.Fatalf("unexpected latest date %s", got)
+	}
+	if len(plan.Items) != 1 {
+		t.Fatalf("expected 1 item inside the range, got %d", len(plan.Items))
+	}
+}
+
+func TestPlannerCapsExifWorkersAtFileCount(t *testing.T) {
+	sourceDir := "/source"
+	first := filepath.Join(sourceDir, "DSC0001.ARW")
+	second := filepath.Join(sourceDir, "DSC0002.ARW")
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+
+	fsys := phopytest.NewFS().
+		AddFile(first, phopytest.File{ModTime: now}).
+		AddFile(second, phopytest.File{ModTime: now})
+
+	var logs bytes.Buffer
+	planner := Planner{
+		FS:          fsys,
+		Exif:        phopytest.NewExif().SetTakenAt(first, now).SetTakenAt(second, now),
+		ExifWorkers: 16,
+		Logger:      logging.New(&logs, true),
+	}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(plan.Items))
+	}
+	if !strings.Contains(logs.String(), "Using 2 EXIF workers") {
+		t.Fatalf("expected effective worker count in log, got:\n%s", logs.String())
+	}
+}
+
+func TestPlannerReadsRAWsBeforeJPEGs(t *testing.T) {
+	sourceDir := "/source"
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	names := []string{"A0001.JPG", "DSC0001.ARW", "DSC0001.JPG", "DSC0002.ARW", "Z0001.JPG"}
+	fsys := phopytest.NewFS()
+	exif := phopytest.NewExif()
+	for _, name := range names {
+		path := filepath.Join(sourceDir, name)
+		fsys.AddFile(path, phopytest.File{ModTime: now})
+		exif.SetTakenAt(path, now)
+	}
+
+	var logs bytes.Buffer
+	planner := Planner{FS: fsys, Exif: exif, ExifWorkers: 1, Logger: logging.New(&logs, true)}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		filepath.Join(sourceDir, "DSC0001.ARW"),
+		filepath.Join(sourceDir, "DSC0002.ARW"),
+		filepath.Join(sourceDir, "A0001.JPG"),
+		filepath.Join(sourceDir, "Z0001.JPG"),
+	}
+	if got := exif.Order(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected RAWs read first and the paired JPEG not at all, got %v", got)
+	}
+	if len(plan.Items) != 4 || plan.Items[0].FileMeta.Name != "A0001.JPG" {
+		t.Fatalf("expected the plan in its usual order, got %v", plan.Items)
+	}
+	if !strings.Contains(logs.String(), "First RAW inspected after") {
+		t.Fatalf("expected the time to the first RAW in log, got:\n%s", logs.String())
+	}
+}
+
+func TestEffectiveWorkers(t *testing.T) {
+	tests := []struct {
+		configured, jobs, want int
+	}{
+		{configured: 16, jobs: 2, want: 2},
+		{configured: 4, jobs: 100, want: 4},
+		{configured: 4, jobs: 0, want: 1},
+	}
+	for _, tt := range tests {
+		if got := effectiveWorkers(tt.configured, tt.jobs); got != tt.want {
+			t.Fatalf("effectiveWorkers(%d, %d) = %d, want %d", tt.configured, tt.jobs, got, tt.want)
+		}
+	}
+}
+
+func TestPlannerWarnsAboutSpringForwardBoundary(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("load zone: %v", err)
+	}
+
+	sourceDir := "/source"
+	// The camera clock was still on winter time (+01:00) after the switch
+	lateNight := filepath.Join(sourceDir, "DSC0001.ARW")
+	noon := filepath.Join(sourceDir, "DSC0002.ARW")
+	// This camera followed the switch, so its date matches
+	adjusted := filepath.Join(sourceDir, "DSC0003.ARW")
+	// Without an offset there is nothing to compare
+	unknown := filepath.Join(sourceDir, "DSC0004.ARW")
+
+	modTime := time.Date(2024, 4, 2, 12, 0, 0, 0, time.Local)
+	fsys := phopytest.NewFS()
+	for _, path := range []string{lateNight, noon, adjusted, unknown} {
+		fsys.AddFile(path, phopytest.File{ModTime: modTime})
+	}
+	exif := phopytest.NewExif().
+		SetMeta(lateNight, domain.PhotoMeta{TakenAt: time.Date(2024, 3, 31, 23, 30, 0, 0, time.Local), Offset: offset(time.Hour)}).
+		SetMeta(noon, domain.PhotoMeta{TakenAt: time.Date(2024, 3, 31, 12, 0, 0, 0, time.Local), Offset: offset(time.Hour)}).
+		SetMeta(adjusted, domain.PhotoMeta{TakenAt: time.Date(2024, 3, 31, 23, 30, 0, 0, time.Local), Offset: offset(2 * time.Hour)}).
+		SetTakenAt(unknown, time.Date(2024, 3, 31, 23, 30, 0, 0, time.Local))
+
+	planner := Planner{FS: fsys, Exif: exif, CheckTimezone: true, TimeZone: berlin}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if plan.ZoneBoundary != 1 {
+		t.Fatalf("expected 1 zone boundary file, got %d", plan.ZoneBoundary)
+	}
+	if len(plan.Warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", plan.Warnings)
+	}
+	want := "DSC0001.ARW was taken on 2024-03-31 at UTC+01:00 but falls on 2024-04-01 in Europe/Berlin"
+	if !reflect.DeepEqual(plan.Warnings[0], domain.Warning{Code: domain.WarningZoneBoundary, Text: want}) {
+		t.Fatalf("unexpected warning %q", plan.Warnings[0])
+	}
+
+	// The file still lands in the camera's date folder
+	if got := plan.Items[len(plan.Items)-1].FileMeta.TakenAt.Format("2006-01-02"); got != "2024-03-31" {
+		t.Fatalf("expected the camera date to be kept, got %s", got)
+	}
+}
+
+func TestPlannerSkipsZoneCheckByDefault(t *testing.T) {
+	path := filepath.Join("/source", "DSC0001.ARW")
+	fsys := phopytest.NewFS().AddFile(path, phopytest.File{ModTime: testTime})
+	exif := phopytest.NewExif().SetMeta(path, domain.PhotoMeta{TakenAt: time.Date(2024, 3, 31, 23, 30, 0, 0, time.Local), Offset: offset(-10 * time.Hour)})
+
+	planner := Planner{FS: fsys, Exif: exif, TimeZone: time.UTC}
+	plan, err := planner.Plan(context.Background(), "/source", "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.ZoneBoundary != 0 || len(plan.Warnings) != 0 {
+		t.Fatalf("expected no zone check without CheckTimezone, got %d %v", plan.ZoneBoundary, plan.Warnings)
+	}
+}
+
+func TestPlannerNeverReadsExifOfExcludedFiles(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	startDate := time.Date(2024, 10, 1, 0, 0, 0, 0, time.Local)
+
+	included := filepath.Join(sourceDir, "DSC0001.ARW")
+	paired := filepath.Join(sourceDir, "DSC0001.JPG")
+	sidecar := filepath.Join(sourceDir, "DSC0001.XMP")
+	existing := filepath.Join(sourceDir, "DSC0002.ARW")
+	old := filepath.Join(sourceDir, "DSC0003.JPG")
+
+	fsys := phopytest.NewFS().
+		AddFile(included, phopytest.File{ModTime: now}).
+		AddFile(paired, phopytest.File{ModTime: now}).
+		AddFile(sidecar, phopytest.File{ModTime: now}).
+		AddFile(existing, phopytest.File{ModTime: now}).
+		AddFile(old, phopytest.File{ModTime: startDate.Add(-time.Hour)}).
+		AddFile(filepath.Join(targetDir, "DSC0002.ARW"), phopytest.File{ModTime: now})
+	exif := phopytest.NewExif().SetTakenAt(included, now)
+
+	var logs bytes.Buffer
+	planner := Planner{
+		FS:     fsys,
+		Exif:   exif,
+		Logger: logging.New(&logs, true),
+	}
+
+	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, &startDate, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(plan.Items))
+	}
+
+	for _, path := range []string{paired, sidecar, existing, old} {
+		if exif.Reads(path) != 0 {
+			t.Fatalf("EXIF should not have been read for excluded %s", path)
+		}
+	}
+
+	want := "Accounted for 5 discovered files: 1 included, 1 queued, 1 skipped (sidecar), 1 skipped (JPEG with RAW), 1 skipped (modified before start date), 1 skipped (target exists)"
+	if !strings.Contains(logs.String(), want) {
+		t.Fatalf("expected every file to be accounted for, got:\n%s", logs.String())
+	}
+}
+
+func TestPlannerAggregatesSidecarRatings(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+
+	keeper := filepath.Join(sourceDir, "DSC0001.ARW")
+	alsoKeeper := filepath.Join(sourceDir, "DSC0002.ARW")
+	unrated := filepath.Join(sourceDir, "DSC0003.ARW")
+	fsys := phopytest.NewFS().AddTree(sourceDir, phopytest.Tree{
+		"DSC0001.ARW":     {ModTime: now},
+		"DSC0001.xmp":     {ModTime: now, Data: []byte(`<rdf:Description xmp:Rating="4"/>`)},
+		"DSC0002.ARW":     {ModTime: now},
+		"DSC0002.ARW.xmp": {ModTime: now, Data: []byte(`<xmp:Rating>4</xmp:Rating>`)},
+		"DSC0003.ARW":     {ModTime: now},
+	})
+	planner := Planner{
+		FS:   fsys,
+		Exif: phopytest.NewExif().SetTakenAt(keeper, now).SetTakenAt(alsoKeeper, now).SetTakenAt(unrated, now),
+	}
+
+	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 3 {
+		t.Fatalf("expected the sidecars to stay out of the plan, got %d items", len(plan.Items))
+	}
+	if len(plan.Ratings) != 1 || plan.Ratings[4] != 2 {
+		t.Fatalf("expected two 4-star files, got %v", plan.Ratings)
+	}
+	for _, item := range plan.Items {
+		if item.FileMeta.Rated != (item.FileMeta.Name != "DSC0003.ARW") {
+			t.Fatalf("unexpected rating of %s: %+v", item.FileMeta.Name, item.FileMeta)
+		}
+	}
+}
+
+func TestPlannerTreatsImplausibleExifDatesAsMissing(t *testing.T) {
+	sourceDir := "/source"
+	targetDir := "/target"
+	mtime := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+
+	epoch := filepath.Join(sourceDir, "DSC0001.ARW")
+	zeros := filepath.Join(sourceDir, "DSC0002.ARW")
+	valid := filepath.Join(sourceDir, "DSC0003.ARW")
+	fsys := phopytest.NewFS().
+		AddFile(epoch, phopytest.File{ModTime: mtime}).
+		AddFile(zeros, phopytest.File{ModTime: mtime}).
+		AddFile(valid, phopytest.File{ModTime: mtime})
+	// The all-zeros date is reported the way exif.Reader does
+	exif := phopytest.NewExif().
+		SetTakenAt(epoch, time.Unix(0, 0)).
+		Fail(zeros, domain.ErrInvalidCaptureDate).
+		SetTakenAt(valid, time.Date(2024, 10, 1, 9, 0, 0, 0, time.Local))
+	planner := Planner{FS: fsys, Exif: exif, Layout: domain.Layout{Dir: "{date}", Flatten: true}}
+
+	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.InvalidDates != 2 {
+		t.Fatalf("expected 2 invalid dates, got %d", plan.InvalidDates)
+	}
+	for _, item := range plan.Items {
+		if strings.Contains(item.TargetPath, "1970") {
+			t.Fatalf("expected no 1970 folder, got %s", item.TargetPath)
+		}
+		if item.FileMeta.Name != "DSC0003.ARW" && !item.FileMeta.TakenAt.Equal(mtime) {
+			t.Fatalf("expected %s to fall back to its filesystem time, got %v", item.FileMeta.Name, item.FileMeta.TakenAt)
+		}
+	}
+	if len(plan.Warnings) != 2 || plan.Warnings[0].Code != domain.WarningExifDateInvalid || !strings.Contains(plan.Warnings[0].Text, "Invalid EXIF date") {
+		t.Fatalf("expected invalid date warnings, got %v", plan.Warnings)
+	}
+
+	// A lower floor accepts the epoch date
+	planner.DateFloor = time.Date(1960, 1, 1, 0, 0, 0, 0, time.Local)
+	plan, err = planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.InvalidDates != 1 {
+		t.Fatalf("expected only the zero date to be invalid, got %d", plan.InvalidDates)
+	}
+}
+
+func TestPlannerRangeBoundaries(t *testing.T) {
+	sourceDir := "/source"
+	day := func(d, h, m, s int, subSec time.Duration) domain.PhotoMeta {
+		return domain.PhotoMeta{TakenAt: time.Date(2024, 10, d, h, m, s, 0, time.Local), SubSec: subSec}
+	}
+	captures := map[string]domain.PhotoMeta{
+		"A_before_start.ARW":   day(0, 23, 59, 59, 999*time.Millisecond), // 2024-09-30
+		"B_at_start.ARW":       day(1, 0, 0, 0, 0),
+		"C_last_moment.ARW":    day(2, 23, 59, 59, 500*time.Millisecond),
+		"D_at_until.ARW":       day(3, 0, 0, 0, 0),
+		"E_until_day_last.ARW": day(3, 23, 59, 59, 750*time.Millisecond),
+	}
+	fsys, exif := phopytest.NewFS(), phopytest.NewExif()
+	for name, meta := range captures {
+		path := filepath.Join(sourceDir, name)
+		fsys.AddFile(path, phopytest.File{ModTime: time.Date(2024, 10, 5, 12, 0, 0, 0, time.Local)})
+		exif.SetMeta(path, meta)
+	}
+
+	from := time.Date(2024, 10, 1, 0, 0, 0, 0, time.Local)
+	for _, tt := range []struct {
+		name      string
+		inclusive bool
+		until     time.Time // as config.FromOptions derives it from --until 2024-10-03
+		want      []string
+		rangeEnd  string
+	}{
+		{
+			name:     "exclusive",
+			until:    time.Date(2024, 10, 3, 0, 0, 0, 0, time.Local),
+			want:     []string{"B_at_start.ARW", "C_last_moment.ARW"},
+			rangeEnd: "2024-10-02",
+		},
+		{
+			name:      "inclusive",
+			inclusive: true,
+			until:     time.Date(2024, 10, 3, 23, 59, 59, 0, time.Local),
+			want:      []string{"B_at_start.ARW", "C_last_moment.ARW", "D_at_until.ARW", "E_until_day_last.ARW"},
+			rangeEnd:  "2024-10-03",
+		},
+	} {
+		planner := Planner{FS: fsys, Exif: exif, InclusiveEnd: tt.inclusive}
+		plan, err := planner.Plan(context.Background(), sourceDir, "/target", &from, &tt.until)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		var got []string
+		for _, item := range plan.Items {
+			got = append(got, item.FileMeta.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Fatalf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+		if plan.OutsideRange.Count != len(captures)-len(tt.want) {
+			t.Fatalf("%s: expected the rest outside the range, got %d", tt.name, plan.OutsideRange.Count)
+		}
+		if got := plan.RangeEnd.Format("2006-01-02"); got != tt.rangeEnd {
+			t.Fatalf("%s: expected the summary to end on %s, got %s", tt.name, tt.rangeEnd, got)
+		}
+	}
+}
+
+func TestPlannerRangeZoneDecidesBoundaryFiles(t *testing.T) {
+	// 00:30 on the camera in Tokyo is still the 15th in UTC
+	tokyo := 9 * time.Hour
+	path := "/source/DSC0001.ARW"
+	fsys, exif := phopytest.NewFS(), phopytest.NewExif()
+	fsys.AddFile(path, phopytest.File{ModTime: time.Date(2024, 3, 16, 12, 0, 0, 0, time.UTC)})
+	exif.SetMeta(path, domain.PhotoMeta{TakenAt: time.Date(2024, 3, 16, 0, 30, 0, 0, time.Local), Offset: &tokyo})
+
+	for _, tt := range []struct {
+		name string
+		zone *time.Location
+		want int
+	}{
+		{name: "camera clock", want: 1},
+		{name: "--timezone UTC", zone: time.UTC, want: 0},
+	} {
+		// As config.FromOptions derives it from --from 2024-03-16
+		loc := time.Local
+		if tt.zone != nil {
+			loc = tt.zone
+		}
+		from := time.Date(2024, 3, 16, 0, 0, 0, 0, loc)
+		planner := Planner{FS: fsys, Exif: exif, Layout: domain.Layout{Days: domain.DayPolicy{Zone: tt.zone}}}
+		plan, err := planner.Plan(context.Background(), "/source", "/target", &from, nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if len(plan.Items) != tt.want || plan.OutsideRange.Count != 1-tt.want {
+			t.Fatalf("%s: expected %d items in the range, got %d", tt.name, tt.want, len(plan.Items))
+		}
+		if got := plan.RangeStart.Format("2006-01-02 15:04 MST"); got != from.Format("2006-01-02 15:04 MST") {
+			t.Fatalf("%s: expected the range to start at %s, got %s", tt.name, from.Format("2006-01-02 15:04 MST"), got)
+		}
+	}
+}
+
+// shuffledFS walks the files of FS in a random order, like a file system
+// that breaks the lexical order of the FileSystem contract.
+type shuffledFS struct {
+	*phopytest.FS
+	rand *rand.Rand
+}
+
+func (s shuffledFS) WalkDir(root string, fn fs.WalkDirFunc) error {
+	type entry struct {
+		path string
+		d    fs.DirEntry
+	}
+	var entries []entry
+	err := s.FS.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{path, d})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.rand.Shuffle(len(entries), func(i, j int) { entries[i], entries[j] = entries[j], entries[i] })
+	for _, e := range entries {
+		if err := fn(e.path, e.d, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestPlannerIsDeterministic(t *testing.T) {
+	sourceDir := "/card/DCIM"
+	taken := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	fsys := phopytest.NewFS()
+	exif := phopytest.NewExif()
+	for i := 0; i < 40; i++ {
+		dir := filepath.Join(sourceDir, fmt.Sprintf("%d00MSDCF", 1+i%3))
+		raw := filepath.Join(dir, fmt.Sprintf("DSC%04d.ARW", i%25))
+		jpeg := filepath.Join(dir, fmt.Sprintf("DSC%04d.JPG", i))
+		fsys.AddFile(raw, phopytest.File{ModTime: taken}).AddFile(jpeg, phopytest.File{ModTime: taken})
+		// Every fourth file lacks EXIF and warns; all share a capture time
+		// so only the tie-breaks order them
+		if i%4 != 0 {
+			exif.SetTakenAt(raw, taken).SetTakenAt(jpeg, taken)
+		}
+	}
+	fsys.AddFile(filepath.Join(sourceDir, "100MSDCF", "notes.txt"), phopytest.File{})
+	// Workers finish in a random order
+	exif.Latency = time.Nanosecond
+	jitter := rand.New(rand.NewSource(1))
+	var mu sync.Mutex
+	exif.Sleep = func(time.Duration) {
+		mu.Lock()
+		d := time.Duration(jitter.Intn(50)) * time.Microsecond
+		mu.Unlock()
+		time.Sleep(d)
+	}
+
+	var first domain.CopyPlan
+	for run := 0; run < 5; run++ {
+		var warned []string
+		planner := Planner{FS: shuffledFS{fsys, rand.New(rand.NewSource(int64(run)))}, Exif: exif, ExifWorkers: 8}
+		planner.onWarning = func(w domain.Warning) { warned = append(warned, w.Text) }
+		plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(warned) != len(plan.Warnings) {
+			t.Fatalf("expected every warning reported live, got %d of %d", len(warned), len(plan.Warnings))
+		}
+		// Only the time the scan took may differ
+		plan.Scan.Duration = 0
+		if run == 0 {
+			first = plan
+			continue
+		}
+		if !reflect.DeepEqual(plan, first) {
+			t.Fatalf("run %d planned differently:\n%+v\nvs\n%+v", run, plan, first)
+		}
+	}
+	if len(first.Warnings) == 0 || len(first.Pairings) == 0 || len(first.Leftovers) == 0 {
+		t.Fatalf("expected warnings, pairings and leftovers to compare, got %+v", first)
+	}
+}
+
+func TestPlannerOrdersFilesOfTheSameNameAndTimeBySourcePath(t *testing.T) {
+	sourceDir := "/card/DCIM"
+	taken := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	fsys := phopytest.NewFS()
+	exif := phopytest.NewExif()
+	var dirs []string
+	for i := 0; i < 6; i++ {
+		dir := fmt.Sprintf("%d00MSDCF", 1+i)
+		dirs = append(dirs, dir)
+		path := filepath.Join(sourceDir, dir, "DSC0001.ARW")
+		fsys.AddFile(path, phopytest.File{ModTime: taken})
+		exif.SetTakenAt(path, taken)
+	}
+	exif.Latency = time.Nanosecond
+	jitter := rand.New(rand.NewSource(1))
+	var mu sync.Mutex
+	exif.Sleep = func(time.Duration) {
+		mu.Lock()
+		d := time.Duration(jitter.Intn(50)) * time.Microsecond
+		mu.Unlock()
+		time.Sleep(d)
+	}
+
+	// Flattened, the files compete for one target name and the order
+	// decides which of them gets it
+	var first []byte
+	for run := 0; run < 5; run++ {
+		planner := Planner{FS: shuffledFS{fsys, rand.New(rand.NewSource(int64(run)))}, Exif: exif, ExifWorkers: 8, Layout: domain.Layout{Flatten: true}}
+		plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i, item := range plan.Items {
+			if want := filepath.Join(dirs[i], "DSC0001.ARW"); item.FileMeta.RelativePath != want {
+				t.Fatalf("run %d: expected %s at %d, got %s", run, want, i, item.FileMeta.RelativePath)
+			}
+		}
+		plan.Scan.Duration = 0
+		serialized, err := json.Marshal(plan)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if run == 0 {
+			first = serialized
+			continue
+		}
+		if !bytes.Equal(serialized, first) {
+			t.Fatalf("run %d serialized differently:\n%s\nvs\n%s", run, serialized, first)
+		}
+	}
+}
+
+// spillRecorder is a WarningSpill that keeps what it got.
+type spillRecorder struct{ spilled []domain.Warning }
+
+func (s *spillRecorder) Spill(warning domain.Warning) error {
+	s.spilled = append(s.spilled, warning)
+	return nil
+}
+
+func (s *spillRecorder) Name() string { return "/tmp/phopy-warnings.log" }
+
+func TestPlannerCapsWarnings(t *testing.T) {
+	const files = 3000
+	tree := phopytest.Tree{}
+	for i := 0; i < files; i++ {
+		tree[fmt.Sprintf("scan%04d.jpg", i)] = phopytest.File{ModTime: testTime}
+	}
+	fsys := phopytest.NewFS().AddTree("/scans", tree)
+
+	spill := &spillRecorder{}
+	planner := Planner{FS: fsys, Exif: phopytest.NewExif(), WarningCap: 100, WarningSpill: spill}
+	plan, err := planner.Plan(context.Background(), "/scans", "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != files {
+		t.Fatalf("expected every file to be planned, got %d", len(plan.Items))
+	}
+	if len(plan.Warnings) != 100 || plan.TruncatedWarnings != files-100 || len(spill.spilled) != files-100 {
+		t.Fatalf("expected 100 warnings in memory and %d spilled, got %d, %d truncated and %d spilled", files-100, len(plan.Warnings), plan.TruncatedWarnings, len(spill.spilled))
+	}
+	if plan.WarningLog != spill.Name() || !strings.Contains(spill.spilled[0].Text, "scan0100.jpg") {
+		t.Fatalf("expected the warnings after the cap in %s, got %q first", plan.WarningLog, spill.spilled[0])
+	}
+
+	// The review still reaches the files of the warnings after the cap
+	if len(plan.TruncatedReviewable) != files-100 || plan.TruncatedReviewable[0].Text != "" {
+		t.Fatalf("expected %d reviewable warnings without text after the cap, got %d", files-100, len(plan.TruncatedReviewable))
+	}
+	reviewed, err := plan.Review("/target", []domain.ReviewDecision{{Code: domain.WarningExifMissing, Action: domain.ActionExclude}}, fsys.Exists)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reviewed.Items) != 0 || reviewed.Reviewed[0].Files != files {
+		t.Fatalf("expected every file excluded, got %d left and %d excluded", len(reviewed.Items), reviewed.Reviewed[0].Files)
+	}
+}
+
+func TestPlannerCopiesCompanionsAlongsideTheirClip(t *testing.T) {
+	sourceDir := "/card"
+	targetDir := "/target"
+	clipDir := filepath.Join(sourceDir, "PRIVATE", "M4ROOT", "CLIP")
+	clip := filepath.Join(clipDir, "C0001.MP4")
+	clipXML := filepath.Join(clipDir, "C0001M01.XML")
+	loneXML := filepath.Join(clipDir, "C0002M01.XML")
+	mediaPro := filepath.Join(sourceDir, "PRIVATE", "M4ROOT", "MEDIAPRO.XML")
+	photo := filepath.Join(sourceDir, "DCIM", "100MSDCF", "DSC0001.ARW")
+	day := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
+
+	fsys := phopytest.NewFS().
+		AddFile(clip, phopytest.File{ModTime: day, Data: []byte("\x00\x00\x00\x20ftypXAVC\x00\x00\x00\x00")}).
+		AddFile(clipXML, phopytest.File{ModTime: day.Add(time.Hour), Size: 2}).
+		AddFile(loneXML, phopytest.File{ModTime: day, Size: 2}).
+		AddFile(mediaPro, phopytest.File{ModTime: day.AddDate(0, 0, 3), Size: 4}).
+		AddFile(photo, phopytest.File{ModTime: day.Add(time.Minute)})
+	exif := phopytest.NewExif().SetTakenAt(photo, day.Add(time.Minute))
+
+	planner := Planner{
+		FS:             fsys,
+		Exif:           exif,
+		Layout:         domain.Layout{Dir: "{date}", Flatten: true},
+		Sniff:          true,
+		CompanionGlobs: domain.DefaultCompanionGlobs,
+	}
+	plan, err := planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var targets []string
+	for _, item := range plan.Items {
+		targets = append(targets, item.TargetPath)
+	}
+	want := []string{
+		filepath.Join(targetDir, "2024-10-02", "C0001.MP4"),
+		filepath.Join(targetDir, "2024-10-02", "C0001M01.XML"),
+		filepath.Join(targetDir, "2024-10-02", "DSC0001.ARW"),
+	}
+	if !reflect.DeepEqual(targets, want) {
+		t.Fatalf("expected the clip XML next to its clip, got %v", targets)
+	}
+	if got := plan.Items[1].CompanionOf; got != clip {
+		t.Fatalf("expected the XML to be a companion of %s, got %q", clip, got)
+	}
+	if plan.ExtensionCounts[".xml"] != 1 {
+		t.Fatalf("expected the companion in the extension counts, got %v", plan.ExtensionCounts)
+	}
+	leftover := domain.Leftover{SourcePath: loneXML, Reason: skipLoneCompanion}
+	if !slices.Contains(plan.Leftovers, leftover) {
+		t.Fatalf("expected the XML without clip as a leftover, got %v", plan.Leftovers)
+	}
+
+	// Housekeeping files keep their card path below MISC and stay out of
+	// the derived range
+	planner.IncludeMisc = true
+	plan, err = planner.Plan(context.Background(), sourceDir, targetDir, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	last := plan.Items[len(plan.Items)-1]
+	if want := filepath.Join(targetDir, "MISC", "PRIVATE", "M4ROOT", "MEDIAPRO.XML"); last.TargetPath != want {
+		t.Fatalf("expected MEDIAPRO.XML at %s, got %s", want, last.TargetPath)
+	}
+	if plan.RangeEnd == nil || !plan.RangeEnd.Equal(day.Add(time.Minute)) {
+		t.Fatalf("expected the range to end at the photo, got %v", plan.RangeEnd)
+	}
+}
+
+func TestPlannerFlagsCameraDefaultDates(t *testing.T) {
+	sourceDir := "/source"
+	day := time.Date(2015, 1, 1, 0, 2, 0, 0, time.Local)
+	fsys := phopytest.NewFS()
+	exif := phopytest.NewExif()
+	var gopros []string
+	for i := 1; i <= 4; i++ {
+		path := filepath.Join(sourceDir, fmt.Sprintf("GOPR%04d.JPG", i))
+		gopros = append(gopros, path)
+		fsys.AddFile(path, phopytest.File{ModTime: time.Date(2024, 6, 1, 10, 0, 0, 0, time.Local)})
+		exif.SetTakenAt(path, day.Add(time.Duration(i)*time.Minute))
+	}
+	// A lowered floor lets the FAT epoch through, where it is flagged too
+	epoch := filepath.Join(sourceDir, "IMG0001.JPG")
+	fsys.AddFile(epoch, phopytest.File{})
+	exif.SetTakenAt(epoch, time.Date(1980, 1, 1, 0, 0, 5, 0, time.Local))
+
+	planner := Planner{FS: fsys, Exif: exif, DateFloor: time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []domain.Warning{
+		{Code: domain.WarningCameraDefaultDate, Text: "1 files are dated 1980-01-01, the date some cameras start from before their clock is set (IMG0001.JPG); if so, --date-floor 1980-01-02 dates them by modification time instead", Paths: []string{epoch}, Actions: domain.SuspectDateActions},
+		{Code: domain.WarningCameraDefaultDate, Text: "4 files are dated 2015-01-01, the date some cameras start from before their clock is set (GOPR0001.JPG, GOPR0002.JPG, GOPR0003.JPG and 1 more); if so, --date-floor 2015-01-02 dates them by modification time instead", Paths: gopros, Actions: domain.SuspectDateActions},
+	}
+	if !reflect.DeepEqual(plan.Warnings, want) {
+		t.Fatalf("expected a warning per default date, got %q", plan.Warnings)
+	}
+}
+
+func TestPlannerFlagsTooManyFilesInTheSameSecond(t *testing.T) {
+	sourceDir := "/source"
+	stuck := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	fsys := phopytest.NewFS()
+	exif := phopytest.NewExif()
+	for i := 1; i <= 6; i++ {
+		path := filepath.Join(sourceDir, fmt.Sprintf("DSC%04d.ARW", i))
+		fsys.AddFile(path, phopytest.File{})
+		// Sub-second fractions of a burst still share the second
+		exif.SetTakenAt(path, stuck.Add(time.Duration(i)*100*time.Millisecond))
+	}
+	burst := filepath.Join(sourceDir, "DSC0100.ARW")
+	fsys.AddFile(burst, phopytest.File{})
+	exif.SetTakenAt(burst, stuck.Add(time.Hour))
+
+	planner := Planner{FS: fsys, Exif: exif, SameSecondLimit: 6}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Warnings) != 0 {
+		t.Fatalf("expected no warning at the limit, got %q", plan.Warnings)
+	}
+
+	planner.SameSecondLimit = 5
+	plan, err = planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "6 files share the capture time 2024-10-02 15:01:00, more than a burst takes (DSC0001.ARW, DSC0002.ARW, DSC0003.ARW and 3 more); the camera clock was likely not running, so check their dates before relying on the folders"
+	if len(plan.Warnings) != 1 || plan.Warnings[0].Code != domain.WarningSameSecond || plan.Warnings[0].Text != want || len(plan.Warnings[0].Paths) != 6 {
+		t.Fatalf("expected the stuck second to be flagged, got %q", plan.Warnings)
+	}
+}
+
+func TestPlannerFailsTargetsBeyondThePathLimits(t *testing.T) {
+	sourceDir := "/source"
+	takenAt := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	fsys := phopytest.NewFS()
+	exif := phopytest.NewExif()
+	longName := strings.Repeat("n", 260) + ".ARW"
+	fits := strings.Repeat("f", 251) + ".ARW"
+	deep := filepath.Join(strings.Repeat(strings.Repeat("d", 200)+string(filepath.Separator), 21), "DSC0001.ARW")
+	for _, rel := range []string{longName, fits, deep} {
+		path := filepath.Join(sourceDir, rel)
+		fsys.AddFile(path, phopytest.File{})
+		exif.SetTakenAt(path, takenAt)
+	}
+
+	limits := domain.PathLimits{FileSystem: "ext4", MaxName: 255, NameUnit: domain.LengthBytes, MaxPath: 4095, PathUnit: domain.LengthBytes}
+	planner := Planner{FS: fsys, Exif: exif, PathLimits: limits}
+	_, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	var limitErr *domain.PathLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected a path limit error, got %v", err)
+	}
+	deepTarget := filepath.Join("/target", deep)
+	for _, want := range []string{
+		"2 target paths are too long for the ext4 file system of the target:",
+		fmt.Sprintf("%s: path is %d bytes, %d over the limit of 4095", deepTarget, len(deepTarget), len(deepTarget)-4095),
+		fmt.Sprintf("%s: name is 264 bytes, 9 over the limit of 255", filepath.Join("/target", longName)),
+		"use --flatten or a shorter --layout or --rename",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in the error:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), fits) {
+		t.Fatalf("expected the name within the limit to pass:\n%v", err)
+	}
+
+	// Flattening fixes the deep structure, but not the long name
+	planner.Layout = domain.Layout{Flatten: true}
+	_, err = planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if !errors.As(err, &limitErr) || len(limitErr.Violations) != 1 || limitErr.Violations[0].Excess() != 9 {
+		t.Fatalf("expected only the long name to fail, got %v", err)
+	}
+
+	planner.PathLimits = domain.PathLimits{}
+	if _, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil); err != nil {
+		t.Fatalf("expected no checks without limits, got %v", err)
+	}
+}
+
+func TestPlanAndCopyTraceNestedPhases(t *testing.T) {
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	tree := phopytest.Tree{}
+	exif := phopytest.NewExif()
+	for i := range 60 {
+		rel := fmt.Sprintf("100MSDCF/DSC%04d.ARW", i)
+		tree[rel] = phopytest.File{ModTime: now, Size: 1000}
+		exif.SetTakenAt(filepath.Join("/card", filepath.FromSlash(rel)), now)
+	}
+	fsys := phopytest.NewFS().AddTree("/card", tree)
+
+	recorder := trace.New()
+	logger := logging.Logger{Trace: recorder}
+	planner := Planner{FS: fsys, Exif: exif, ExifWorkers: 2, AllowOverride: true, Logger: logger}
+	plan, err := planner.Plan(context.Background(), "/card", "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	executor := Executor{FS: fsys, Logger: logger}
+	if _, err := executor.Execute(context.Background(), plan, plan.Decide(false)); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := recorder.Write(&buf); err != nil {
+		t.Fatalf("write trace: %v", err)
+	}
+	var file struct {
+		TraceEvents []struct {
+			Name     string         `json:"name"`
+			Phase    string         `json:"ph"`
+			TS       int64          `json:"ts"`
+			Duration int64          `json:"dur"`
+			TID      int            `json:"tid"`
+			Args     map[string]any `json:"args"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &file); err != nil {
+		t.Fatalf("expected the trace to be JSON: %v", err)
+	}
+
+	type span struct {
+		start, end int64
+		track      int
+		args       map[string]any
+	}
+	spans := make(map[string][]span)
+	for _, e := range file.TraceEvents {
+		if e.Phase == "X" {
+			spans[e.Name] = append(spans[e.Name], span{e.TS, e.TS + e.Duration, e.TID, e.Args})
+		}
+	}
+	within := func(child, parent string) {
+		t.Helper()
+		if len(spans[parent]) != 1 || len(spans[child]) == 0 {
+			t.Fatalf("expected one %s span and %s spans, got %d and %d", parent, child, len(spans[parent]), len(spans[child]))
+		}
+		p := spans[parent][0]
+		for _, c := range spans[child] {
+			if c.start < p.start || c.end > p.end {
+				t.Errorf("expected %s [%d, %d] inside %s [%d, %d]", child, c.start, c.end, parent, p.start, p.end)
+			}
+		}
+	}
+	within("scan", "plan")
+	within("walk", "scan")
+	within("exif-scan", "scan")
+	within("exif-worker", "exif-scan")
+	within("exif-file", "exif-scan")
+	within("override-check", "plan")
+
+	if plan := spans["plan"][0]; plan.end > spans["copy"][0].start {
+		t.Errorf("expected the copy to start after planning")
+	}
+	if got := spans["walk"][0].args["files"]; got != float64(60) {
+		t.Errorf("expected the walk to count 60 files, got %v", got)
+	}
+	if got := spans["exif-scan"][0].args["bytes"]; got != float64(60000) {
+		t.Errorf("expected the EXIF scan to count 60000 bytes, got %v", got)
+	}
+	files := 0.0
+	for _, worker := range spans["exif-worker"] {
+		if worker.track == 0 {
+			t.Errorf("expected workers on tracks of their own")
+		}
+		files += worker.args["files"].(float64)
+	}
+	if files != 60 || len(spans["exif-file"]) >= 60 {
+		t.Errorf("expected the workers to inspect 60 files and sample few, got %v and %d samples", files, len(spans["exif-file"]))
+	}
+	if got := spans["copy"][0].args; got["copied"] != float64(60) || got["bytes_copied"] != float64(60000) {
+		t.Errorf("unexpected copy attributes %v", got)
+	}
+}
+
+func TestEveryFeatureFilesANearMidnightPhotoUnderTheSameDay(t *testing.T) {
+	// 23:30 on the camera, nine hours ahead of UTC: 14:30 UTC, which is
+	// 00:30 of the next day ten hours ahead
+	path := "/card/DCIM/100MSDCF/DSC0001.ARW"
+	wall := time.Date(2024, 3, 15, 23, 30, 0, 0, time.Local)
+	exif := phopytest.NewExif().SetMeta(path, domain.PhotoMeta{TakenAt: wall, Offset: offset(9 * time.Hour)})
+	fsys := phopytest.NewFS().AddFile(path, phopytest.File{ModTime: wall, Size: 1000})
+	plus10 := time.FixedZone("UTC+10", 10*60*60)
+	day := func(d int) *time.Time {
+		t := time.Date(2024, 3, d, 0, 0, 0, 0, time.Local)
+		return &t
+	}
+
+	tests := []struct {
+		name string
+		days domain.DayPolicy
+		date string
+		// zone is the zone of --check-timezone, warning whether it
+		// files the photo elsewhere
+		zone    *time.Location
+		warning bool
+	}{
+		{"camera clock", domain.DayPolicy{}, "2024-03-15", plus10, true},
+		{"zone", domain.DayPolicy{Zone: plus10}, "2024-03-16", plus10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planner := Planner{
+				FS:            fsys,
+				Exif:          exif,
+				Layout:        domain.Layout{Dir: "{date}", Flatten: true, Days: tt.days},
+				CheckTimezone: true,
+				TimeZone:      tt.zone,
+			}
+			plan, err := planner.Plan(context.Background(), "/card", "/target", nil, nil)
+			if err != nil {
+				t.Fatalf("plan: %v", err)
+			}
+			if len(plan.Items) != 1 {
+				t.Fatalf("expected the photo to be planned, got %v", plan.Items)
+			}
+
+			// The date folder, the folder breakdown and the range agree
+			got := map[string]string{
+				"date folder":  filepath.Base(filepath.Dir(plan.Items[0].TargetPath)),
+				"listed time":  plan.Items[0].FileMeta.TakenAt.Format("2006-01-02"),
+				"first day":    plan.Folders[0].First.Format("2006-01-02"),
+				"last day":     plan.Folders[0].Last.Format("2006-01-02"),
+				"range start":  plan.RangeStart.Format("2006-01-02"),
+				"range end":    plan.RangeEnd.Format("2006-01-02"),
+				"template day": domain.DayOf(plan.Items[0].FileMeta.TakenAt, tt.days).Format("2006-01-02"),
+			}
+			for output, date := range got {
+				if date != tt.date {
+					t.Errorf("expected the %s to be %s, got %s", output, tt.date, date)
+				}
+			}
+
+			// The date range takes the photo exactly on that day
+			for _, r := range []struct {
+				from, until *time.Time
+				planned     bool
+			}{
+				{day(15), day(16), tt.date == "2024-03-15"},
+				{day(16), day(17), tt.date == "2024-03-16"},
+				{nil, day(16), tt.date == "2024-03-15"},
+				{day(16), nil, tt.date == "2024-03-16"},
+			} {
+				ranged, err := planner.Plan(context.Background(), "/card", "/target", r.from, r.until)
+				if err != nil {
+					t.Fatalf("plan: %v", err)
+				}
+				if planned := len(ranged.Items) == 1; planned != r.planned {
+					t.Errorf("range %v to %v: expected planned %v, got %v", r.from, r.until, r.planned, planned)
+				}
+			}
+
+			if warned := plan.ZoneBoundary > 0; warned != tt.warning {
+				t.Errorf("expected a zone boundary warning: %v, got %v", tt.warning, plan.Warnings)
+			}
+		})
+	}
+}
+
+func TestAuditReportsEveryDeviationWithoutWriting(t *testing.T) {
+	archive := "/archive"
+	march15 := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+	march16 := march15.AddDate(0, 0, 1)
+	fsys := phopytest.NewFS().AddTree(archive, phopytest.Tree{
+		"2024/2024-03-15/DSC0001.ARW": {Data: []byte("raw 1"), ModTime: march15},
+		"2024/2024-03-15/DSC0001.JPG": {Data: []byte("jpeg 1"), ModTime: march15},
+		// Taken a day later than its folder says
+		"2024/2024-03-15/DSC0002.ARW": {Data: []byte("raw 2"), ModTime: march16},
+		// A JPEG left behind when its RAW was moved
+		"2024/2024-03-16/DSC0003.ARW": {Data: []byte("raw 3"), ModTime: march16},
+		"2024/2024-03-15/DSC0003.JPG": {Data: []byte("jpeg 3"), ModTime: march16},
+		// The same photo twice
+		"2024/2024-03-16/DSC0004.JPG": {Data: []byte("jpeg 4"), ModTime: march16},
+		"2024/2024-03-16/DSC0005.JPG": {Data: []byte("jpeg 4"), ModTime: march16},
+		"2024/2024-03-15/.DS_Store":   {Data: []byte("junk")},
+		"2024/._DSC0001.ARW":          {Data: []byte("junk")},
+	})
+	exif := phopytest.NewExif()
+	for _, rel := range []string{"2024-03-15/DSC0001.ARW", "2024-03-15/DSC0002.ARW", "2024-03-16/DSC0003.ARW", "2024-03-16/DSC0004.JPG", "2024-03-16/DSC0005.JPG"} {
+		path := filepath.Join(archive, "2024", filepath.FromSlash(rel))
+		info, _ := fsys.File(path)
+		exif.SetTakenAt(path, info.ModTime)
+	}
+
+	planner := Planner{FS: fsys, Exif: exif, Layout: domain.Layout{Dir: "{yyyy}/{date}"}}
+	report, err := planner.Audit(context.Background(), archive)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+
+	rel := filepath.FromSlash
+	want := domain.NewAuditReport(archive, 9, map[string][]domain.AuditFinding{
+		domain.AuditMisfiled:  {{Path: rel("2024/2024-03-15/DSC0002.ARW"), Folder: rel("2024/2024-03-16"), Detail: "dated 2024-03-16 by exif"}},
+		domain.AuditSplitPair: {{Path: rel("2024/2024-03-15/DSC0003.JPG"), Folder: rel("2024/2024-03-16"), Of: rel("2024/2024-03-16/DSC0003.ARW")}},
+		domain.AuditDuplicate: {{Path: rel("2024/2024-03-16/DSC0005.JPG"), Of: rel("2024/2024-03-16/DSC0004.JPG")}},
+		domain.AuditJunk:      {{Path: rel("2024/._DSC0001.ARW")}, {Path: rel("2024/2024-03-15/.DS_Store")}},
+	})
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("unexpected report:\n%+v\nwant:\n%+v", report, want)
+	}
+	if report.Findings() != 5 {
+		t.Fatalf("expected 5 findings, got %d", report.Findings())
+	}
+	if len(fsys.Copies()) != 0 || len(fsys.Links()) != 0 || len(fsys.Syncs()) != 0 {
+		t.Fatalf("expected the audit not to write, got copies %v, links %v and syncs %v", fsys.Copies(), fsys.Links(), fsys.Syncs())
+	}
+}
+
+func TestPlannerWarnsAboutMissingExifOnlyWhereItIsExpected(t *testing.T) {
+	sourceDir := "/card"
+	now := time.Date(2024, 10, 2, 15, 2, 0, 0, time.Local)
+	fsys := phopytest.NewFS().AddTree(sourceDir, phopytest.Tree{
+		"DCIM/100MSDCF/DSC0001.ARW": {ModTime: now},
+		"DCIM/100MSDCF/DSC0002.JPG": {ModTime: now, Data: []byte{0xFF, 0xD8, 0xFF, 0xE0}},
+		"DCIM/100GOPRO/GOPR0003":    {ModTime: now, Data: []byte("\x00\x00\x00\x20ftypmp42\x00\x00\x00\x00")},
+		"DCIM/100APPLE/IMG_0004":    {ModTime: now, Data: []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00")},
+	})
+	exif := phopytest.NewExif()
+
+	planner := Planner{FS: fsys, Exif: exif, Sniff: true}
+	plan, err := planner.Plan(context.Background(), sourceDir, "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Items) != 4 {
+		t.Fatalf("expected every file planned, got %d items", len(plan.Items))
+	}
+
+	// The video is dated by its modification time without reading EXIF
+	var warned []string
+	for _, warning := range plan.Warnings {
+		warned = append(warned, warning.Paths...)
+	}
+	want := []string{
+		filepath.Join(sourceDir, "DCIM", "100APPLE", "IMG_0004"),
+		filepath.Join(sourceDir, "DCIM", "100MSDCF", "DSC0001.ARW"),
+		filepath.Join(sourceDir, "DCIM", "100MSDCF", "DSC0002.JPG"),
+	}
+	slices.Sort(warned)
+	if !reflect.DeepEqual(warned, want) {
+		t.Fatalf("expected EXIF warnings for %v, got %v", want, warned)
+	}
+	if exif.TotalReads() != 3 {
+		t.Fatalf("expected 3 EXIF reads, got %d", exif.TotalReads())
+	}
+	for _, item := range plan.Items {
+		if item.FileMeta.DateSource != domain.DateSourceFileTime {
+			t.Fatalf("expected %s dated by file time, got %s", item.FileMeta.Name, item.FileMeta.DateSource)
+		}
+	}
 }
@@ -4,16 +4,101 @@ import (
 	"context"
 	"io/fs"
 	"time"
+
+	"phopy/internal/domain"
+	"phopy/internal/hash"
 )
 
 type FileSystem interface {
+	// WalkDir walks the tree below root depth-first, visiting the entries
+	// of each directory in lexical order like filepath.WalkDir, so progress,
+	// warnings and plans do not depend on the order a file system lists
+	// them in. phopytest.CheckWalkOrder verifies an implementation.
 	WalkDir(root string, fn fs.WalkDirFunc) error
 	Stat(path string) (fs.FileInfo, error)
 	Exists(path string) (bool, error)
 	MkdirAll(path string, perm fs.FileMode) error
 	CopyFile(src, dst string) error
+	// ReadHeader returns up to n leading bytes of path.
+	ReadHeader(path string, n int) ([]byte, error)
+	// SyncDir flushes the entries of the directory at path to disk, so the
+	// files created in it survive a power cut.
+	SyncDir(path string) error
+	// Link creates path as a hard link to the existing file, creating its
+	// parent directories. It fails when both are not on the same volume.
+	Link(existing, path string) error
+}
+
+// ProgressCopier is implemented by file systems that can report the bytes
+// written while a file is copied. onProgress receives the running total.
+type ProgressCopier interface {
+	CopyFileProgress(src, dst string, onProgress func(written int64)) error
+}
+
+// HashingCopier is implemented by file systems that can hash a file while
+// copying it, so a copy can be verified without reading its source twice.
+// It copies like ProgressCopier, onProgress may be nil, and returns the hex
+// digest with algorithm of the bytes read from src.
+type HashingCopier interface {
+	CopyFileHash(src, dst string, algorithm hash.Algorithm, onProgress func(written int64)) (string, error)
+}
+
+// ContentHasher is implemented by file systems that can hash the content of
+// a file, e.g. to tell duplicates apart. Implementations should hash with
+// hash.Algorithm.Sum, so every file system yields the same digests.
+type ContentHasher interface {
+	HashFile(path string, algorithm hash.Algorithm) (string, error)
+}
+
+// Stamper is implemented by file systems that can attach metadata to a
+// file, like extended attributes. attrs maps attribute names to values.
+type Stamper interface {
+	Stamp(path string, attrs map[string]string) error
+}
+
+// LockProber is implemented by file systems that can tell whether another
+// process holds a file open in a way that makes copying it fail, like a
+// writer without read sharing on Windows.
+type LockProber interface {
+	Locked(path string) (bool, error)
 }
 
+// Remover is implemented by file systems that can delete a file, which
+// the executor uses to remove what a copy left behind when the target
+// filled up.
+type Remover interface {
+	Remove(path string) error
+}
+
+// SpaceReporter is implemented by file systems that can tell how many bytes
+// the volume holding path still has free for the user.
+type SpaceReporter interface {
+	FreeSpace(path string) (int64, error)
+}
+
+// WarningSpill takes the plan warnings beyond the planner's WarningCap, so
+// they need not be kept in memory, e.g. by writing them to a log file.
+type WarningSpill interface {
+	Spill(warning domain.Warning) error
+	// Name is where the spilled warnings can be read, like a file path.
+	Name() string
+}
+
+// ExifReader extracts photo metadata. Implementations should decode each
+// file at most once per call.
 type ExifReader interface {
-	DateTimeOriginal(ctx context.Context, path string) (time.Time, error)
+	ReadMeta(ctx context.Context, path string) (domain.PhotoMeta, error)
+}
+
+// Journal records executions so later runs can tell what was already
+// imported.
+type Journal interface {
+	Append(result domain.ExecutionResult) error
+}
+
+// History looks up what earlier runs imported into the target.
+type History interface {
+	// LastImport is the time of the latest run, zero without runs.
+	LastImport() time.Time
+	Lookup(sourcePath string) (domain.ImportedFile, bool)
 }
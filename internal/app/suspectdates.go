@@ -0,0 +1,85 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+// suspectNames is how many file names a suspect date warning lists.
+const suspectNames = 3
+
+// suspectDateWarnings flags EXIF dates that parsed but are likely garbage:
+// days cameras count from before their clock is set, and more files in the
+// same second than SameSecondLimit allows. metas must be sorted by capture
+// time. It returns one warning per suspect day or second.
+func (p *Planner) suspectDateWarnings(metas []domain.FileMeta) []domain.Warning {
+	limit := p.SameSecondLimit
+	if limit <= 0 {
+		limit = domain.DefaultSameSecondLimit
+	}
+
+	byDay := make(map[time.Time][]domain.FileMeta)
+	var days []time.Time
+	bySecond := make(map[time.Time][]domain.FileMeta)
+	var seconds []time.Time
+	for _, meta := range metas {
+		if meta.DateSource != domain.DateSourceExif || p.Fast {
+			continue
+		}
+		if day, ok := domain.CameraDefaultDate(meta.TakenAt); ok {
+			if byDay[day] == nil {
+				days = append(days, day)
+			}
+			byDay[day] = append(byDay[day], meta)
+			continue
+		}
+		second := meta.TakenAt.Truncate(time.Second)
+		if bySecond[second] == nil {
+			seconds = append(seconds, second)
+		}
+		bySecond[second] = append(bySecond[second], meta)
+	}
+
+	var warnings []domain.Warning
+	for _, day := range days {
+		metas := byDay[day]
+		warnings = append(warnings, domain.Warningf(domain.WarningCameraDefaultDate,
+			"%d files are dated %s, the date some cameras start from before their clock is set (%s); if so, --date-floor %s dates them by modification time instead",
+			len(metas), day.Format("2006-01-02"), listNames(metas), day.AddDate(0, 0, 1).Format("2006-01-02")).About(domain.SuspectDateActions, sourcePaths(metas)...))
+	}
+	for _, second := range seconds {
+		metas := bySecond[second]
+		if len(metas) <= limit {
+			continue
+		}
+		warnings = append(warnings, domain.Warningf(domain.WarningSameSecond,
+			"%d files share the capture time %s, more than a burst takes (%s); the camera clock was likely not running, so check their dates before relying on the folders",
+			len(metas), second.Format("2006-01-02 15:04:05"), listNames(metas)).About(domain.SuspectDateActions, sourcePaths(metas)...))
+	}
+	return warnings
+}
+
+// listNames joins the names of the first suspectNames metas and counts
+// the rest.
+func listNames(metas []domain.FileMeta) string {
+	var names []string
+	for _, meta := range metas[:min(len(metas), suspectNames)] {
+		names = append(names, meta.Name)
+	}
+	if len(metas) <= suspectNames {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names, ", "), len(metas)-suspectNames)
+}
+
+// sourcePaths returns the source paths of metas.
+func sourcePaths(metas []domain.FileMeta) []string {
+	paths := make([]string, len(metas))
+	for i, meta := range metas {
+		paths[i] = meta.SourcePath
+	}
+	return paths
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// targetNames finds existing targets regardless of the case of their
+// names, so a source DSC0001.ARW planned as DSC0001.arw matches the
+// dsc0001.arw of an archive kept in lowercase. It lists every target
+// directory once and is safe for concurrent use.
+type targetNames struct {
+	fs   FileSystem
+	mu   sync.Mutex
+	dirs map[string]map[string]string // lowercase name to name, by directory
+}
+
+func newTargetNames(fsys FileSystem) *targetNames {
+	return &targetNames{fs: fsys, dirs: make(map[string]map[string]string)}
+}
+
+// find returns the path of the file whose name matches the base of path
+// in any case, and whether there is one.
+func (t *targetNames) find(path string) (string, bool, error) {
+	dir := filepath.Dir(path)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names, ok := t.dirs[dir]
+	if !ok {
+		names = make(map[string]string)
+		err := t.fs.WalkDir(dir, func(entry string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// A directory yet to be created holds nothing
+				if entry == dir && errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if entry == dir {
+				return nil
+			}
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			names[strings.ToLower(d.Name())] = d.Name()
+			return nil
+		})
+		if err != nil {
+			return "", false, err
+		}
+		t.dirs[dir] = names
+	}
+	name, ok := names[strings.ToLower(filepath.Base(path))]
+	if !ok {
+		return "", false, nil
+	}
+	return filepath.Join(dir, name), true, nil
+}
+
+// targetExists reports whether the target path exists and the path it
+// exists at. Layouts that set the extension case also match files whose
+// names differ only in case, which is the same file on case-insensitive
+// file systems.
+func (p *Planner) targetExists(path string) (string, bool, error) {
+	exists, err := p.FS.Exists(path)
+	if err != nil || exists || !p.Layout.ExtCase.Sets() || p.targets == nil {
+		return path, exists, err
+	}
+	return p.targets.find(path)
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+
+	"phopy/internal/trace"
+)
+
+// exifSampleEvery is how often an EXIF worker traces the inspection of a
+// single file, which keeps the traces of large cards small.
+const exifSampleEvery = 50
+
+// workerSpan traces an EXIF worker on a track of its own: one span for its
+// whole run, with the files and bytes it inspected, and one for every
+// exifSampleEvery-th file. A nil workerSpan traces nothing.
+type workerSpan struct {
+	trace *trace.Recorder
+	track int
+	span  *trace.Span
+	files int
+	bytes int64
+}
+
+func newWorkerSpan(r *trace.Recorder, worker int) *workerSpan {
+	if r == nil {
+		return nil
+	}
+	track := worker + 1
+	r.NameTrack(track, fmt.Sprintf("exif worker %d", track))
+	return &workerSpan{trace: r, track: track, span: r.Start("exif-worker", track)}
+}
+
+// inspect counts file and returns the span of its inspection if it is
+// sampled, nil otherwise.
+func (w *workerSpan) inspect(file candidate) *trace.Span {
+	if w == nil {
+		return nil
+	}
+	w.files++
+	if file.info != nil {
+		w.bytes += file.info.Size()
+	}
+	if (w.files-1)%exifSampleEvery != 0 {
+		return nil
+	}
+	span := w.trace.Start("exif-file", w.track)
+	span.Set("path", file.path)
+	return span
+}
+
+func (w *workerSpan) end() {
+	if w == nil {
+		return
+	}
+	w.span.Set("files", w.files)
+	w.span.Set("bytes", w.bytes)
+	w.span.End()
+}
+
+// candidateBytes sums the sizes of files the walk knows.
+func candidateBytes(files []candidate) int64 {
+	var total int64
+	for _, file := range files {
+		if file.info != nil {
+			total += file.info.Size()
+		}
+	}
+	return total
+}
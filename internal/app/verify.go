@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+
+	"phopy/internal/domain"
+	"phopy/internal/hash"
+	"phopy/internal/logging"
+)
+
+// ImportedCopy is a file an earlier run copied, with the hash of its source
+// its manifest recorded; Hash is empty for manifests without hashes.
+type ImportedCopy struct {
+	SourcePath string
+	TargetPath string
+	Hash       string
+	Algorithm  hash.Algorithm
+}
+
+// Verifier checks the files earlier runs imported for bit rot. Its file
+// system must implement ContentHasher.
+type Verifier struct {
+	FS         FileSystem
+	Logger     logging.Logger
+	OnProgress ProgressFunc
+	// Listed are the sources the manifests list without a copy, such as
+	// skipped duplicates; the walk of the source does not report them.
+	Listed map[string]bool
+}
+
+// Verify hashes the target and, while it is still there, the source of
+// every copy and classifies them against the recorded hash, see
+// domain.IntegrityCheck. With sourceDir, the files below it that no copy
+// came from and that are not Listed are reported as never imported; junk, camera housekeeping
+// files and phopy's own folders are left out.
+func (v Verifier) Verify(ctx context.Context, copies []ImportedCopy, sourceDir string) (domain.IntegrityReport, error) {
+	report := domain.IntegrityReport{Summary: make(domain.IntegritySummary)}
+	hasher, ok := v.FS.(ContentHasher)
+	if !ok {
+		return report, errors.New("verify requires a file system that can hash files")
+	}
+
+	imported := make(map[string]bool, len(copies))
+	for i, c := range copies {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		imported[c.SourcePath] = true
+		check := domain.IntegrityCheck{Imported: true, Recorded: c.Hash}
+		var err error
+		if check.Target, err = v.hash(hasher, c.TargetPath, c.Algorithm); err != nil {
+			return report, err
+		}
+		if check.Source, err = v.hash(hasher, c.SourcePath, c.Algorithm); err != nil {
+			return report, err
+		}
+		if class := report.Summary.Add(check); class != domain.IntegrityOK {
+			v.Logger.Verbosef("%s: %s", c.TargetPath, class)
+			report.Findings = append(report.Findings, domain.IntegrityFinding{SourcePath: c.SourcePath, TargetPath: c.TargetPath, Class: class})
+		}
+		if v.OnProgress != nil {
+			v.OnProgress(i+1, len(copies))
+		}
+	}
+	if sourceDir == "" {
+		return report, nil
+	}
+
+	err := v.FS.WalkDir(sourceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != sourceDir && domain.IsMetaDir(d.Name()) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if imported[path] || v.Listed[path] || domain.IsJunkFile(d.Name()) || domain.IsMiscFile(d.Name()) {
+			return nil
+		}
+		report.Summary.Add(domain.IntegrityCheck{})
+		report.Findings = append(report.Findings, domain.IntegrityFinding{SourcePath: path, Class: domain.IntegrityNeverImported})
+		return nil
+	})
+	return report, err
+}
+
+// hash returns the hash of path, or "" for a file that is gone.
+func (v Verifier) hash(hasher ContentHasher, path string, algorithm hash.Algorithm) (string, error) {
+	exists, err := v.FS.Exists(path)
+	if err != nil || !exists {
+		return "", err
+	}
+	sum, err := hasher.HashFile(path, algorithm)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return sum, err
+}
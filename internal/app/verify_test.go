@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"phopy/internal/domain"
+	"phopy/internal/hash"
+	"phopy/internal/logging"
+	"phopy/phopytest"
+)
+
+func TestVerifierClassifiesEveryImportedFile(t *testing.T) {
+	sum := func(data string) string {
+		s, _ := hash.SHA256.Sum(strings.NewReader(data))
+		return s
+	}
+	copied := func(name, recorded string) ImportedCopy {
+		return ImportedCopy{SourcePath: "/card/DCIM/" + name, TargetPath: "/archive/" + name, Hash: recorded, Algorithm: hash.SHA256}
+	}
+	fsys := phopytest.NewFS()
+	add := func(path, data string) { fsys.AddFile(path, phopytest.File{Data: []byte(data)}) }
+	copies := []ImportedCopy{
+		copied("OK.ARW", sum("ok")),
+		copied("ROT.ARW", sum("rot")),
+		copied("EDITED.JPG", sum("edited")),
+		copied("GONE.ARW", sum("gone")),
+		copied("MISSING.ARW", sum("missing")),
+		copied("OLD.ARW", ""),
+	}
+	add("/card/DCIM/OK.ARW", "ok")
+	add("/archive/OK.ARW", "ok")
+	add("/card/DCIM/ROT.ARW", "rot")
+	add("/archive/ROT.ARW", "bit rot")
+	add("/card/DCIM/EDITED.JPG", "edited again")
+	add("/archive/EDITED.JPG", "edited")
+	add("/archive/GONE.ARW", "gone") // formatted card
+	add("/card/DCIM/MISSING.ARW", "missing")
+	add("/card/DCIM/OLD.ARW", "old")
+	add("/archive/OLD.ARW", "older")
+	add("/card/DCIM/NEW.ARW", "new")
+	add("/card/DCIM/DUPE.ARW", "ok")
+	add("/card/DCIM/.DS_Store", "junk")
+
+	var progress int
+	verifier := Verifier{FS: fsys, Logger: logging.New(io.Discard, false), OnProgress: func(current, total int) { progress = current }, Listed: map[string]bool{"/card/DCIM/DUPE.ARW": true}}
+	report, err := verifier.Verify(context.Background(), copies, "/card")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := domain.IntegritySummary{
+		domain.IntegrityOK:              2,
+		domain.IntegrityTargetCorrupted: 2,
+		domain.IntegritySourceModified:  1,
+		domain.IntegrityDiffers:         1,
+		domain.IntegrityNeverImported:   1,
+	}
+	if !reflect.DeepEqual(report.Summary, want) {
+		t.Fatalf("expected %v, got %v", want, report.Summary)
+	}
+	if len(report.Findings) != 5 || report.Findings[4] != (domain.IntegrityFinding{SourcePath: "/card/DCIM/NEW.ARW", Class: domain.IntegrityNeverImported}) {
+		t.Fatalf("unexpected findings %+v", report.Findings)
+	}
+	if progress != len(copies) {
+		t.Fatalf("expected progress over every copy, got %d", progress)
+	}
+}
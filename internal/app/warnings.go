@@ -0,0 +1,56 @@
+package app
+
+import "phopy/internal/domain"
+
+// warningList collects plan warnings up to a cap. The warnings beyond it are
+// only counted and handed to the spill, so a source with hundreds of
+// thousands of files without EXIF does not keep as many strings in memory.
+type warningList struct {
+	max       int
+	spill     WarningSpill
+	kept      []domain.Warning
+	truncated int
+	spillErr  error
+	// reviewable keeps the truncated warnings the review can act on,
+	// without their text.
+	reviewable []domain.Warning
+}
+
+func newWarningList(max int, spill WarningSpill) *warningList {
+	if max <= 0 {
+		max = domain.DefaultWarningCap
+	}
+	return &warningList{max: max, spill: spill}
+}
+
+func (l *warningList) add(warnings ...domain.Warning) {
+	for _, warning := range warnings {
+		if len(l.kept) < l.max {
+			l.kept = append(l.kept, warning)
+			continue
+		}
+		l.truncated++
+		if len(warning.Actions) > 0 {
+			reviewable := warning
+			reviewable.Text = ""
+			l.reviewable = append(l.reviewable, reviewable)
+		}
+		if l.spill != nil && l.spillErr == nil {
+			l.spillErr = l.spill.Spill(warning)
+		}
+	}
+}
+
+// len is the number of warnings, kept or not.
+func (l *warningList) len() int {
+	return len(l.kept) + l.truncated
+}
+
+// log is where the truncated warnings can be read, or empty when none were
+// truncated or they could not all be spilled.
+func (l *warningList) log() string {
+	if l.truncated == 0 || l.spill == nil || l.spillErr != nil {
+		return ""
+	}
+	return l.spill.Name()
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWarningsHaveCodes checks every site in this package that builds a
+// warning: it must use domain.Warningf or a domain.Warning literal with one
+// of the code constants, never a bare string or no code.
+func TestWarningsHaveCodes(t *testing.T) {
+	paths, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	fset := token.NewFileSet()
+	sites := 0
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatalf("parse %s: %v", path, err)
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			var code ast.Expr
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				if !isDomain(n.Fun, "Warningf") {
+					return true
+				}
+				code = n.Args[0]
+			case *ast.CompositeLit:
+				if !isDomain(n.Type, "Warning") || len(n.Elts) == 0 {
+					return true
+				}
+				for _, elt := range n.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok && kv.Key.(*ast.Ident).Name == "Code" {
+						code = kv.Value
+					}
+				}
+			default:
+				return true
+			}
+			sites++
+			if !isCodeConstant(code) {
+				t.Errorf("%s: warning without a domain.Warning* code constant", fset.Position(n.Pos()))
+			}
+			return true
+		})
+	}
+	if sites == 0 {
+		t.Fatal("found no warnings, is the check still looking at the right calls?")
+	}
+}
+
+// isDomain reports whether expr is domain.name.
+func isDomain(expr ast.Expr, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "domain"
+}
+
+// isCodeConstant reports whether expr names a warning code, like
+// domain.WarningExifMissing.
+func isCodeConstant(expr ast.Expr) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || !strings.HasPrefix(sel.Sel.Name, "Warning") {
+		return false
+	}
+	return isDomain(sel, sel.Sel.Name)
+}
@@ -1,40 +1,268 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"time"
+
+	"phopy/internal/domain"
+	"phopy/internal/hash"
 )
 
 type Config struct {
-	SourceDir string
-	TargetDir string
-	DryRun    bool
-	Verbose   bool
-	Override  bool
+	SourceDir      string
+	TargetDir      string
+	DryRun         bool
+	Verbose        bool
+	Override       bool
+	ConfirmDefault string
+	Layout         domain.Layout
+	Manifest       bool
+	Sniff          bool
+	CheckTimezone  bool
+	LatestLink     string
+	NoLock         bool
+	BarMaxWidth    int
+	BarSolid       bool
+	BarPercent     bool
+	// AskLabel prompts for the label before scanning (--label ask).
+	AskLabel bool
+	// OverrideCap is how many override items are listed; 0 uses the
+	// default.
+	OverrideCap int
+	// PreviewItems is how many planned items the TUI receives
+	// (--preview-items); the rest are only counted.
+	PreviewItems int
+	// PreviewCount is how many planned files the preview lists
+	// (--preview-count); 0 uses the default.
+	PreviewCount int
+	// ExifWorkers and TargetWorkers are how many source files are
+	// inspected and how many targets are checked for existence at once
+	// (--exif-workers, --target-workers); 0 picks a default.
+	ExifWorkers   int
+	TargetWorkers int
+	// DateFloor is the earliest plausible EXIF capture date.
+	DateFloor time.Time
 	StartDate *time.Time
 	EndDate   *time.Time
+	// InclusiveEnd includes the whole --until day (--boundary inclusive);
+	// by default EndDate is the first excluded instant.
+	InclusiveEnd bool
+	// PreserveBirthTime gives copies the creation time of their source
+	// where the platform can set it (--preserve-btime).
+	PreserveBirthTime bool
+	// Dedupe leaves out source files that are the same capture as another
+	// one (--dedupe).
+	Dedupe bool
+	// OnConflict answers targets that appear after planning in plain
+	// mode (--on-conflict); the TUI asks instead.
+	OnConflict domain.ConflictAnswer
+	// OnSourceChange decides about sources that changed after planning
+	// (--on-source-change), in the TUI and plain mode alike.
+	OnSourceChange domain.SourceChange
+	// FastPlan skips EXIF reads and dates files by their modification
+	// time (--fast-plan). Only dry runs can be fast.
+	FastPlan bool
+	// Leftovers is the file that lists the discovered files left out of
+	// the plan (--leftovers); empty writes none.
+	Leftovers string
+	// DirDates dates files without EXIF by their folder names
+	// (--dir-date-pattern); the zero value is off.
+	DirDates domain.DirDatePattern
+	// AnswerOverrides answers the override prompt of plain mode without
+	// asking: "yes" for --yes, "no" for --no and empty to ask.
+	AnswerOverrides string
+	// Unattended is the answer to the override prompt when there is no
+	// terminal to ask on: AnswerOverrides or an explicit --confirm-default
+	// yes or no. Empty refuses to copy.
+	Unattended string
+	// DateTags is the order of the EXIF tags the capture date is read
+	// from (--date-tag-order); nil is the reader's default.
+	DateTags []domain.DateTag
+	// StampXattr stamps every copy with its source path and run id in
+	// extended attributes (--stamp-xattr).
+	StampXattr bool
+	// NoBenchmark skips the write test in the target that estimates how
+	// long the copy takes (--no-benchmark).
+	NoBenchmark bool
+	// StatsOnly scans and benchmarks the target and reports how long the
+	// copy would take instead of copying (--stats-only); StatsJSON prints
+	// that report as JSON (--stats-json).
+	StatsOnly bool
+	StatsJSON bool
+	// SkipLocked defers sources another program holds open, retries them
+	// once at the end and skips those still locked (--skip-locked).
+	SkipLocked bool
+	// CompanionGlobs match files copied next to the file they belong to by
+	// name, like Sony clip XML (--companion-globs). The default is
+	// domain.DefaultCompanionGlobs with --sniff and none without.
+	CompanionGlobs []string
+	// IncludeMisc copies known camera housekeeping files (--include-misc).
+	IncludeMisc bool
+	// KeepJunk plans operating system metadata files like .DS_Store
+	// instead of skipping them (--keep-junk).
+	KeepJunk bool
+	// IncludeProxies plans the low-resolution proxies cameras record
+	// alongside their clips instead of skipping them (--include-proxies).
+	IncludeProxies bool
+	// Thumbnails shows the EXIF thumbnail of the file highlighted in the
+	// TUI preview in terminals that can draw images (--thumbnails).
+	Thumbnails bool
+	// Paths selects the files of the source by their relative path
+	// (--include and --exclude).
+	Paths domain.PathFilter
+	// AutoOverrideStale overwrites stale targets, older than their source
+	// and of another size, without asking (--auto-override-stale).
+	AutoOverrideStale bool
+	// DedupeHash compares source files by content for --dedupe, and
+	// ManifestHash is recorded in manifests and matched by --link-dupes.
+	// --hash sets both; by default dedupe uses a fast hash and manifests a
+	// cryptographic one.
+	DedupeHash   hash.Algorithm
+	ManifestHash hash.Algorithm
+	// Fsync flushes every copy, its directory, the manifest and the journal
+	// to disk before the run counts as successful (--fsync).
+	Fsync bool
+	// Verify re-reads every copy and compares it with its source as read
+	// while copying (--verify).
+	Verify bool
+	// LinkDupes hard links copies to identical files that earlier runs
+	// recorded in their manifests (--link-dupes). It implies Manifest, so
+	// the copies of this run can be linked to later.
+	LinkDupes bool
+	// ExportScript is the file the plan is written to as a script of
+	// mkdir and cp commands (--export-script); only dry runs export.
+	ExportScript string
+	// ScriptPowerShell exports a PowerShell script instead of a POSIX
+	// shell one (--script-format powershell).
+	ScriptPowerShell bool
+	// Progress is how plain mode reports progress (--progress): full,
+	// line or none.
+	Progress string
+	// Hazards explain why the copy needs explicit confirmation, like
+	// running as root or a system directory as target. Dry runs and
+	// --i-know-what-im-doing have none.
+	Hazards []string
 }
 
 type Options struct {
-	SourceDir string
-	TargetDir string
-	DryRun    bool
-	Verbose   bool
-	Override  bool
-	FromDate  string
-	UntilDate string
+	SourceDir      string
+	TargetDir      string
+	DryRun         bool
+	Verbose        bool
+	Override       bool
+	ConfirmDefault string
+	Layout         string
+	Rename         string
+	Flatten        bool
+	KeepExtCase    bool
+	Sniff          bool
+	SniffFixExt    bool
+	CheckTimezone  bool
+	LatestLink     string
+	NoLock         bool
+	BarStyle       string
+	BarMaxWidth    int
+	BarPercent     bool
+	Manifest       bool
+	Label          string
+	DateFloor      string
+	OverrideCap    int
+	PreviewItems   int
+	PreviewCount   int
+	Boundary       string
+	FromDate       string
+	UntilDate      string
+	TimeZone       string
+
+	PreserveBirthTime bool
+	IgnoreHazards     bool
+	Dedupe            bool
+	OnConflict        string
+	OnSourceChange    string
+	FastPlan          bool
+	Leftovers         string
+	DirDatePattern    string
+	DateTagOrder      string
+	Yes               bool
+	NoBenchmark       bool
+	StatsOnly         bool
+	StatsJSON         bool
+	SkipLocked        bool
+	No                bool
+	CompanionGlobs    string
+	IncludeMisc       bool
+	KeepJunk          bool
+	IncludeProxies    bool
+	Thumbnails        bool
+	Include           []string
+	Exclude           []string
+	AutoOverrideStale bool
+	Hash              string
+	Fsync             bool
+	Verify            bool
+	LinkDupes         bool
+	StampXattr        bool
+	ExportScript      string
+	ScriptFormat      string
+	Progress          string
+	ExtCase           string
+	ExifWorkers       int
+	TargetWorkers     int
+	// Since is an inferred start of the range, like the newest capture
+	// imported from the card before (--auto); --from takes precedence.
+	Since time.Time
 }
 
 func FromOptions(opts Options) (Config, error) {
 	cfg := Config{
-		SourceDir: opts.SourceDir,
-		TargetDir: opts.TargetDir,
-		DryRun:    opts.DryRun,
-		Verbose:   opts.Verbose,
-		Override:  opts.Override,
+		SourceDir:     opts.SourceDir,
+		TargetDir:     opts.TargetDir,
+		DryRun:        opts.DryRun,
+		Verbose:       opts.Verbose,
+		Override:      opts.Override,
+		Manifest:      opts.Manifest || opts.LinkDupes,
+		Sniff:         opts.Sniff,
+		CheckTimezone: opts.CheckTimezone,
+		LatestLink:    strings.TrimSpace(opts.LatestLink),
+		NoLock:        opts.NoLock,
+		BarMaxWidth:   opts.BarMaxWidth,
+		BarPercent:    opts.BarPercent,
+		OverrideCap:   opts.OverrideCap,
+		PreviewItems:  opts.PreviewItems,
+		PreviewCount:  opts.PreviewCount,
+		Layout: domain.Layout{
+			Dir:           strings.TrimSpace(opts.Layout),
+			Name:          strings.TrimSpace(opts.Rename),
+			Flatten:       opts.Flatten,
+			FixSniffedExt: opts.SniffFixExt,
+		},
+		PreserveBirthTime: opts.PreserveBirthTime,
+		Dedupe:            opts.Dedupe,
+		FastPlan:          opts.FastPlan,
+		Leftovers:         strings.TrimSpace(opts.Leftovers),
+		StampXattr:        opts.StampXattr,
+		NoBenchmark:       opts.NoBenchmark,
+		StatsOnly:         opts.StatsOnly,
+		StatsJSON:         opts.StatsJSON,
+		SkipLocked:        opts.SkipLocked,
+		IncludeMisc:       opts.IncludeMisc,
+		KeepJunk:          opts.KeepJunk,
+		IncludeProxies:    opts.IncludeProxies,
+		Thumbnails:        opts.Thumbnails,
+		AutoOverrideStale: opts.AutoOverrideStale,
+		Fsync:             opts.Fsync,
+		Verify:            opts.Verify,
+		LinkDupes:         opts.LinkDupes,
+		ExportScript:      strings.TrimSpace(opts.ExportScript),
+		ExifWorkers:       opts.ExifWorkers,
+		TargetWorkers:     opts.TargetWorkers,
 	}
+	confirmDefault := strings.ToLower(strings.TrimSpace(opts.ConfirmDefault))
 	fromDate := strings.TrimSpace(opts.FromDate)
 	untilDate := strings.TrimSpace(opts.UntilDate)
 
@@ -64,21 +292,256 @@ func FromOptions(opts Options) (Config, error) {
 		return Config{}, errors.New("source and target are required")
 	}
 
+	switch confirmDefault {
+	case "":
+		cfg.ConfirmDefault = "no"
+	case "yes", "no", "none":
+		cfg.ConfirmDefault = confirmDefault
+	default:
+		return Config{}, errors.New("invalid confirm default, use yes, no or none")
+	}
+
+	switch {
+	case opts.Yes && opts.No:
+		return Config{}, errors.New("--yes and --no contradict each other, pass one of them")
+	case opts.Yes:
+		cfg.AnswerOverrides = "yes"
+	case opts.No:
+		cfg.AnswerOverrides = "no"
+	}
+	cfg.Unattended = cfg.AnswerOverrides
+	if cfg.Unattended == "" && confirmDefault != "none" {
+		cfg.Unattended = confirmDefault
+	}
+
+	if cfg.OverrideCap < 0 {
+		return Config{}, errors.New("override preview must not be negative")
+	}
+	if cfg.PreviewCount < 0 {
+		return Config{}, errors.New("preview count must not be negative")
+	}
+	if cfg.PreviewItems == 0 {
+		cfg.PreviewItems = domain.DefaultPreviewItems
+	} else if cfg.PreviewItems < 0 {
+		return Config{}, errors.New("preview items must not be negative")
+	}
+	if cfg.ExifWorkers < 0 || cfg.TargetWorkers < 0 {
+		return Config{}, errors.New("worker counts must not be negative, use 0 for the default")
+	}
+
+	if err := domain.ValidateTemplate(cfg.Layout.Dir); err != nil {
+		return Config{}, fmt.Errorf("invalid layout: %w", err)
+	}
+	if err := domain.ValidateTemplate(cfg.Layout.Name); err != nil {
+		return Config{}, fmt.Errorf("invalid rename template: %w", err)
+	}
+
+	if label := strings.TrimSpace(opts.Label); label == "ask" {
+		cfg.AskLabel = true
+	} else if err := domain.ValidateLabel(label); err != nil {
+		return Config{}, fmt.Errorf("invalid label: %w", err)
+	} else {
+		cfg.Layout.Label = label
+	}
+
+	if cfg.LatestLink != "" && (strings.ContainsAny(cfg.LatestLink, `/\`) || cfg.LatestLink == "." || cfg.LatestLink == "..") {
+		return Config{}, errors.New("invalid latest link, use a plain file name")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(opts.BarStyle)) {
+	case "", "gradient":
+	case "solid":
+		cfg.BarSolid = true
+	default:
+		return Config{}, errors.New("invalid bar style, use gradient or solid")
+	}
+	if cfg.BarMaxWidth < 0 {
+		return Config{}, errors.New("invalid bar width, use a positive number of columns")
+	}
+
+	if floor := strings.TrimSpace(opts.DateFloor); floor != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", floor, time.Local)
+		if err != nil {
+			return Config{}, errors.New("invalid date floor, use YYYY-MM-DD")
+		}
+		cfg.DateFloor = parsed
+	}
+
+	switch strings.ToLower(strings.TrimSpace(opts.Boundary)) {
+	case "", "exclusive":
+	case "inclusive":
+		cfg.InclusiveEnd = true
+	default:
+		return Config{}, errors.New("invalid boundary, use inclusive or exclusive")
+	}
+
+	// --timezone decides the days of the layout as well as the range, so
+	// a file is in the range exactly when its date folder is. Without it
+	// photos compare by the camera's clock, which carries no offset a date
+	// could name
+	zone, err := parseTimeZone(strings.TrimSpace(opts.TimeZone))
+	if err != nil {
+		return Config{}, errors.New("invalid timezone, use a name like Europe/Berlin or an offset like +09:00")
+	}
+	cfg.Layout.Days.Zone = zone
+	if zone == nil && (hasOffset(fromDate) || hasOffset(untilDate)) {
+		return Config{}, errors.New("a date with a UTC offset needs --timezone, which compares photos at the moment they were taken")
+	}
+
 	if fromDate != "" {
-		parsed, err := time.ParseInLocation("2006-01-02", fromDate, time.Local)
+		parsed, _, err := parseRangeDate(fromDate, zone)
 		if err != nil {
-			return Config{}, errors.New("invalid from date, use YYYY-MM-DD")
+			return Config{}, errors.New("invalid from date, use YYYY-MM-DD or YYYY-MM-DDThh:mm:ss+hh:mm")
 		}
 		cfg.StartDate = &parsed
+	} else if !opts.Since.IsZero() {
+		since := opts.Since
+		if zone != nil {
+			since = since.In(zone)
+		}
+		cfg.StartDate = &since
 	}
 	if untilDate != "" {
-		parsed, err := time.ParseInLocation("2006-01-02", untilDate, time.Local)
+		parsed, bare, err := parseRangeDate(untilDate, zone)
 		if err != nil {
-			return Config{}, errors.New("invalid until date, use YYYY-MM-DD")
+			return Config{}, errors.New("invalid until date, use YYYY-MM-DD or YYYY-MM-DDThh:mm:ss+hh:mm")
+		}
+		if cfg.InclusiveEnd && bare {
+			parsed = parsed.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
 		}
-		parsed = parsed.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
 		cfg.EndDate = &parsed
 	}
+	if cfg.StartDate != nil && cfg.EndDate != nil && !cfg.EndDate.After(*cfg.StartDate) {
+		return Config{}, errors.New("empty date range, --until is exclusive unless --boundary inclusive")
+	}
+
+	if pattern := strings.TrimSpace(opts.DirDatePattern); pattern != "" {
+		parsed, err := domain.ParseDirDatePattern(pattern)
+		if err != nil {
+			return Config{}, errors.New("invalid dir date pattern, use auto or a regular expression with a (?P<year>...) group")
+		}
+		cfg.DirDates = parsed
+	}
+
+	if order := strings.TrimSpace(opts.DateTagOrder); order != "" {
+		parsed, err := domain.ParseDateTagOrder(order)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid date tag order: %v, use a comma separated list of DateTimeOriginal, CreateDate and ModifyDate", err)
+		}
+		cfg.DateTags = parsed
+	}
+
+	// The default pairs with video clips, which are planned only when
+	// sniffing
+	if opts.Sniff {
+		cfg.CompanionGlobs = domain.DefaultCompanionGlobs
+	}
+	if globs := strings.TrimSpace(opts.CompanionGlobs); globs != "" {
+		parsed, err := domain.ParseCompanionGlobs(globs)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid companion globs: %v, use a comma separated list of file name globs like C*.XML, or none", err)
+		}
+		cfg.CompanionGlobs = parsed
+	}
+
+	for _, globs := range []struct {
+		flag   string
+		values []string
+		parsed *[]string
+	}{
+		{"--include", opts.Include, &cfg.Paths.Include},
+		{"--exclude", opts.Exclude, &cfg.Paths.Exclude},
+	} {
+		parsed, err := domain.ParsePathGlobs(globs.values)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid %s: %v, use a glob of the path below the source like DCIM/100MSDCF or *.MP4", globs.flag, err)
+		}
+		*globs.parsed = parsed
+	}
+
+	cfg.DedupeHash, cfg.ManifestHash = hash.DefaultDedupe, hash.DefaultManifest
+	if name := strings.TrimSpace(opts.Hash); name != "" {
+		algorithm, err := hash.Parse(name)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.DedupeHash, cfg.ManifestHash = algorithm, algorithm
+	}
+
+	if cfg.AutoOverrideStale && !cfg.Override {
+		return Config{}, errors.New("--auto-override-stale approves overrides, use it with --override")
+	}
+
+	if cfg.FastPlan && !cfg.DryRun {
+		return Config{}, errors.New("--fast-plan only previews, use it with --dry-run or phopy plan")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(opts.ScriptFormat)) {
+	case "", "sh", "posix":
+	case "powershell", "ps1":
+		cfg.ScriptPowerShell = true
+	default:
+		return Config{}, errors.New("invalid script format, use sh or powershell")
+	}
+	switch progress := strings.ToLower(strings.TrimSpace(opts.Progress)); progress {
+	case "":
+		cfg.Progress = "full"
+	case "full", "line", "none":
+		cfg.Progress = progress
+	default:
+		return Config{}, errors.New("invalid progress, use full, line or none")
+	}
+
+	// --keep-ext-case is the deprecated spelling of --ext-case keep
+	extCase := strings.ToLower(strings.TrimSpace(opts.ExtCase))
+	if extCase == "" && opts.KeepExtCase {
+		extCase = "keep"
+	}
+	switch extCase {
+	case "":
+	case "keep":
+		cfg.Layout.ExtCase = domain.ExtCaseKeep
+	case "lower":
+		cfg.Layout.ExtCase = domain.ExtCaseLower
+	case "upper":
+		cfg.Layout.ExtCase = domain.ExtCaseUpper
+	default:
+		return Config{}, errors.New("invalid ext-case, use keep, lower or upper")
+	}
+
+	if cfg.StatsJSON && !cfg.StatsOnly {
+		return Config{}, errors.New("--stats-json prints the report of --stats-only, use them together")
+	}
+
+	if cfg.ExportScript != "" && !cfg.DryRun {
+		return Config{}, errors.New("--export-script only writes the commands, use it with --dry-run or phopy plan so the files are not copied twice")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(opts.OnConflict)) {
+	case "", "fail":
+		cfg.OnConflict = domain.ConflictFail
+	case "skip":
+		cfg.OnConflict = domain.ConflictSkip
+	case "overwrite":
+		cfg.OnConflict = domain.ConflictOverwrite
+	default:
+		return Config{}, errors.New("invalid on-conflict, use fail, skip or overwrite")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(opts.OnSourceChange)) {
+	case "", "copy":
+		cfg.OnSourceChange = domain.SourceChangeCopy
+	case "skip":
+		cfg.OnSourceChange = domain.SourceChangeSkip
+	case "fail":
+		cfg.OnSourceChange = domain.SourceChangeFail
+	default:
+		return Config{}, errors.New("invalid on-source-change, use copy, skip or fail")
+	}
+
+	if !cfg.DryRun && !cfg.StatsOnly && !opts.IgnoreHazards {
+		cfg.Hazards = hazards(resolveTarget(cfg.TargetDir), currentHost())
+	}
 
 	return cfg, nil
 }
@@ -91,3 +554,63 @@ func envTruthy(key string) bool {
 	val := strings.TrimSpace(strings.ToLower(os.Getenv(key)))
 	return val == "1" || val == "true" || val == "yes" || val == "y"
 }
+
+// Digest identifies the settings that decide which files are copied where,
+// so journal entries of runs with the same setup can be told apart from
+// others.
+func (c Config) Digest() string {
+	setup := fmt.Sprintf("%s|%s|%t|%+v|%t|%v|%v", c.SourceDir, c.TargetDir, c.Override, c.Layout, c.Sniff, formatDate(c.StartDate), formatDate(c.EndDate))
+	sum := sha256.Sum256([]byte(setup))
+	return hex.EncodeToString(sum[:6])
+}
+
+// parseTimeZone parses --timezone: a name of the time zone database, Local
+// or a UTC offset like +09:00. It returns nil for "".
+func parseTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if offset, err := time.Parse("-07:00", name); err == nil {
+		return fixedZone(offset), nil
+	}
+	return time.LoadLocation(name)
+}
+
+// hasOffset reports whether a --from or --until value names a moment with
+// a UTC offset rather than a bare date.
+func hasOffset(value string) bool {
+	_, err := time.Parse(time.RFC3339, value)
+	return err == nil
+}
+
+// fixedZone returns the zone of the offset of t, named like UTC+09:00.
+func fixedZone(t time.Time) *time.Location {
+	_, offset := t.Zone()
+	return time.FixedZone("UTC"+t.Format("-07:00"), offset)
+}
+
+// parseRangeDate parses a --from or --until value. A bare date
+// (YYYY-MM-DD) is midnight in zone, or in the local zone without one; a
+// time with an offset (RFC 3339) is that instant, read in zone. bare
+// reports a bare date.
+func parseRangeDate(value string, zone *time.Location) (t time.Time, bare bool, err error) {
+	loc := time.Local
+	if zone != nil {
+		loc = zone
+	}
+	if midnight, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return midnight, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t.In(loc), false, nil
+}
+
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(time.RFC3339)
+}
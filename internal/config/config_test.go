@@ -0,0 +1,182 @@
+package config
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+func TestFromOptionsReadsTheDateRangeInItsZone(t *testing.T) {
+	plus9 := time.FixedZone("UTC+09:00", 9*60*60)
+
+	tests := []struct {
+		name      string
+		from      string
+		until     string
+		zone      string
+		inclusive bool
+		wantStart time.Time
+		wantEnd   time.Time
+		wantZone  *time.Location
+	}{
+		{
+			name:      "bare dates are local midnight",
+			from:      "2024-03-16",
+			until:     "2024-03-17",
+			wantStart: time.Date(2024, 3, 16, 0, 0, 0, 0, time.Local),
+			wantEnd:   time.Date(2024, 3, 17, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:      "timezone applies to bare dates",
+			from:      "2024-03-16",
+			until:     "2024-03-17",
+			zone:      "+09:00",
+			wantStart: time.Date(2024, 3, 16, 0, 0, 0, 0, plus9),
+			wantEnd:   time.Date(2024, 3, 17, 0, 0, 0, 0, plus9),
+			wantZone:  plus9,
+		},
+		{
+			name:      "timezone wins over an offset",
+			from:      "2024-03-16T00:00:00+09:00",
+			zone:      "UTC",
+			wantStart: time.Date(2024, 3, 15, 15, 0, 0, 0, time.UTC),
+			wantZone:  time.UTC,
+		},
+		{
+			name:      "an offset is read in the timezone",
+			from:      "2024-03-15T16:00:00Z",
+			zone:      "+09:00",
+			wantStart: time.Date(2024, 3, 16, 1, 0, 0, 0, plus9),
+			wantZone:  plus9,
+		},
+		{
+			name:      "an inclusive moment stays a moment",
+			until:     "2024-03-17T12:00:00Z",
+			zone:      "UTC",
+			inclusive: true,
+			wantEnd:   time.Date(2024, 3, 17, 12, 0, 0, 0, time.UTC),
+			wantZone:  time.UTC,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{SourceDir: "/card", TargetDir: "/archive", FromDate: tt.from, UntilDate: tt.until, TimeZone: tt.zone}
+			if tt.inclusive {
+				opts.Boundary = "inclusive"
+			}
+			cfg, err := FromOptions(opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkDate(t, "start", cfg.StartDate, tt.wantStart)
+			checkDate(t, "end", cfg.EndDate, tt.wantEnd)
+
+			zone := cfg.Layout.Days.Zone
+			if (zone == nil) != (tt.wantZone == nil) {
+				t.Fatalf("expected zone %v, got %v", tt.wantZone, zone)
+			}
+			if zone != nil && cfg.StartDate != nil && cfg.StartDate.Location() != zone {
+				t.Fatalf("expected the start in the zone of the layout, got %v", cfg.StartDate.Location())
+			}
+		})
+	}
+}
+
+func checkDate(t *testing.T, name string, got *time.Time, want time.Time) {
+	t.Helper()
+	if want.IsZero() {
+		if got != nil {
+			t.Fatalf("expected no %s date, got %v", name, got)
+		}
+		return
+	}
+	if got == nil || !got.Equal(want) || got.Format(time.RFC3339) != want.Format(time.RFC3339) {
+		t.Fatalf("expected %s %v, got %v", name, want, got)
+	}
+}
+
+func TestFromOptionsRejectsUnknownZones(t *testing.T) {
+	for _, opts := range []Options{
+		{SourceDir: "/card", TargetDir: "/archive", TimeZone: "Mars/Olympus_Mons"},
+		{SourceDir: "/card", TargetDir: "/archive", FromDate: "2024-03-16T00:00:00"},
+		// Without --timezone photos carry no offset to compare with
+		{SourceDir: "/card", TargetDir: "/archive", FromDate: "2024-03-16T00:00:00+09:00"},
+		{SourceDir: "/card", TargetDir: "/archive", UntilDate: "2024-03-17T12:00:00Z"},
+	} {
+		if _, err := FromOptions(opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestFromOptionsPairsClipXMLOnlyWhenSniffing(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		globs []string
+	}{
+		{"sniff off", Options{}, nil},
+		{"sniff on", Options{Sniff: true}, domain.DefaultCompanionGlobs},
+		{"explicit globs without sniff", Options{CompanionGlobs: "*.THM"}, []string{"*.THM"}},
+		{"none with sniff", Options{Sniff: true, CompanionGlobs: "none"}, nil},
+	}
+	for _, tt := range tests {
+		tt.opts.SourceDir, tt.opts.TargetDir = "/card", "/archive"
+		cfg, err := FromOptions(tt.opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !slices.Equal(cfg.CompanionGlobs, tt.globs) {
+			t.Errorf("%s: expected companion globs %v, got %v", tt.name, tt.globs, cfg.CompanionGlobs)
+		}
+	}
+}
+
+func TestFromOptionsDecidesTheExtensionCase(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want domain.ExtCase
+	}{
+		{"default", Options{}, domain.ExtCaseDefault},
+		{"keep alone", Options{ExtCase: "keep"}, domain.ExtCaseKeep},
+		{"deprecated keep flag", Options{KeepExtCase: true}, domain.ExtCaseKeep},
+		{"ext case wins over the deprecated flag", Options{ExtCase: "lower", KeepExtCase: true}, domain.ExtCaseLower},
+		{"upper", Options{ExtCase: "UPPER"}, domain.ExtCaseUpper},
+	}
+	for _, tt := range tests {
+		tt.opts.SourceDir, tt.opts.TargetDir = "/card", "/archive"
+		cfg, err := FromOptions(tt.opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if cfg.Layout.ExtCase != tt.want {
+			t.Errorf("%s: expected ext case %d, got %d", tt.name, tt.want, cfg.Layout.ExtCase)
+		}
+	}
+
+	// keep alone keeps the case of {ext} too
+	cfg, err := FromOptions(Options{SourceDir: "/card", TargetDir: "/archive", Rename: "{name}.{ext}", Flatten: true, ExtCase: "keep"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	meta := domain.NewFileMeta("/card/DSC0001.ARW", "DSC0001.ARW", time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local))
+	if got := cfg.Layout.TargetRel(meta); got != "DSC0001.ARW" {
+		t.Errorf("expected DSC0001.ARW with --ext-case keep, got %s", got)
+	}
+}
+
+func TestFromOptionsNeedsStatsOnlyForJSON(t *testing.T) {
+	if _, err := FromOptions(Options{SourceDir: "/card", TargetDir: "/archive", StatsJSON: true}); err == nil {
+		t.Errorf("expected --json without --stats-only to be rejected")
+	}
+	cfg, err := FromOptions(Options{SourceDir: "/card", TargetDir: "/", StatsOnly: true, StatsJSON: true})
+	if err != nil {
+		t.Fatalf("FromOptions: %v", err)
+	}
+	if len(cfg.Hazards) != 0 {
+		t.Errorf("expected no hazards for a run that does not copy, got %v", cfg.Hazards)
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Profile holds the defaults saved by the first-run setup. Flags and
+// environment variables take precedence over it.
+type Profile struct {
+	SourceDir string `json:"source_dir"`
+	TargetDir string `json:"target_dir"`
+	Layout    string `json:"layout,omitempty"`
+	Flatten   bool   `json:"flatten,omitempty"`
+}
+
+// ProfilePath returns where the default profile is stored, usually
+// ~/.config/phopy/profile.json.
+func ProfilePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "phopy", "profile.json"), nil
+}
+
+// LoadProfile reads the default profile. The boolean is false when no
+// profile has been saved yet.
+func LoadProfile() (Profile, bool, error) {
+	path, err := ProfilePath()
+	if err != nil {
+		return Profile{}, false, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Profile{}, false, nil
+	}
+	if err != nil {
+		return Profile{}, false, err
+	}
+	var profile Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return Profile{}, false, fmt.Errorf("invalid profile %s: %w", path, err)
+	}
+	return profile, true, nil
+}
+
+// SaveProfile writes profile as the default for later runs.
+func SaveProfile(profile Profile) error {
+	path, err := ProfilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
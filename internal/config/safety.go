@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// host is what the guard rails know about the machine phopy runs on.
+type host struct {
+	goos string
+	root bool
+	home string
+}
+
+// currentHost describes the running process.
+var currentHost = func() host {
+	home, _ := os.UserHomeDir()
+	return host{goos: runtime.GOOS, root: os.Geteuid() == 0, home: home}
+}
+
+// The system trees are directories that, with everything below them, belong
+// to the operating system. The system roots only guard the directory itself,
+// since user data lives below them.
+var (
+	unixSystemTrees = []string{"/bin", "/boot", "/dev", "/etc", "/lib", "/lib64", "/proc", "/sbin", "/sys", "/usr"}
+	unixSystemRoots = []string{"/", "/home", "/opt", "/root", "/tmp", "/var"}
+
+	darwinSystemTrees = []string{"/System", "/Library", "/Applications"}
+	darwinSystemRoots = []string{"/Users", "/Volumes"}
+
+	windowsSystemTrees = []string{`C:\Windows`, `C:\Program Files`, `C:\Program Files (x86)`, `C:\ProgramData`}
+	windowsSystemRoots = []string{`C:\`, `C:\Users`}
+)
+
+func systemDirs(goos string) (trees, roots []string) {
+	switch goos {
+	case "windows":
+		return windowsSystemTrees, windowsSystemRoots
+	case "darwin":
+		return append(unixSystemTrees, darwinSystemTrees...), append(unixSystemRoots, darwinSystemRoots...)
+	default:
+		return unixSystemTrees, unixSystemRoots
+	}
+}
+
+// hazards explains why copying into target on h needs confirmation: running
+// as root, a target that is a system directory and a target that is the
+// home directory itself.
+func hazards(target string, h host) []string {
+	var found []string
+	if h.root {
+		found = append(found, "phopy runs as root, so the copies will be owned by root")
+	}
+
+	sep, fold := "/", h.goos == "darwin"
+	if h.goos == "windows" {
+		sep, fold = `\`, true
+	}
+	norm := func(path string) string {
+		if sep == `\` {
+			path = strings.ReplaceAll(path, "/", `\`)
+		}
+		if len(path) > 1 && !strings.HasSuffix(path, `:\`) {
+			path = strings.TrimSuffix(path, sep)
+		}
+		if fold {
+			path = strings.ToLower(path)
+		}
+		return path
+	}
+	path := norm(target)
+
+	trees, roots := systemDirs(h.goos)
+	for _, dir := range roots {
+		if path == norm(dir) {
+			found = append(found, fmt.Sprintf("the target %s is a system directory", target))
+			return found
+		}
+	}
+	for _, dir := range trees {
+		if tree := norm(dir); path == tree || strings.HasPrefix(path, tree+sep) {
+			found = append(found, fmt.Sprintf("the target %s is inside the system directory %s", target, dir))
+			return found
+		}
+	}
+	if h.home != "" && path == norm(h.home) {
+		found = append(found, fmt.Sprintf("the target %s is your entire home directory", target))
+	}
+	return found
+}
+
+// resolveTarget returns the absolute target with symlinks of its existing
+// part resolved, so a link cannot hide a system directory.
+func resolveTarget(target string) string {
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return target
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHazards(t *testing.T) {
+	linux := host{goos: "linux", home: "/home/sven"}
+	darwin := host{goos: "darwin", home: "/Users/sven"}
+	windows := host{goos: "windows", home: `C:\Users\sven`}
+
+	tests := []struct {
+		name   string
+		target string
+		host   host
+		want   string
+	}{
+		{"archive on linux", "/home/sven/Pictures", linux, ""},
+		{"removable disk on linux", "/media/sven/disk/photos", linux, ""},
+		{"running as root", "/srv/photos", host{goos: "linux", root: true}, "runs as root"},
+		{"filesystem root", "/", linux, "is a system directory"},
+		{"usr local", "/usr/local", linux, "inside the system directory /usr"},
+		{"below etc", "/etc/phopy/", linux, "inside the system directory /etc"},
+		{"home parent", "/home", linux, "is a system directory"},
+		{"home root", "/home/sven/", linux, "entire home directory"},
+		{"archive on macOS", "/Users/sven/Pictures", darwin, ""},
+		{"macOS library", "/library/Photos", darwin, "inside the system directory /Library"},
+		{"macOS users", "/Users", darwin, "is a system directory"},
+		{"macOS home root", "/Users/Sven", darwin, "entire home directory"},
+		{"archive on Windows", `D:\Photos`, windows, ""},
+		{"drive root", `C:\`, windows, "is a system directory"},
+		{"Windows directory", `c:\windows\Temp`, windows, `inside the system directory C:\Windows`},
+		{"Windows home root", `C:/Users/sven`, windows, "entire home directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(hazards(tt.target, tt.host), "; ")
+			if tt.want == "" && got != "" {
+				t.Fatalf("expected no hazard, got %q", got)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFromOptionsReportsHazards(t *testing.T) {
+	saved := currentHost
+	currentHost = func() host { return host{goos: "linux", root: true} }
+	defer func() { currentHost = saved }()
+
+	opts := Options{SourceDir: "/card", TargetDir: "/srv/photos"}
+	cfg, err := FromOptions(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Hazards) != 1 {
+		t.Fatalf("expected the root hazard, got %v", cfg.Hazards)
+	}
+
+	opts.IgnoreHazards = true
+	if cfg, _ = FromOptions(opts); len(cfg.Hazards) != 0 {
+		t.Fatalf("expected --i-know-what-im-doing to skip the check, got %v", cfg.Hazards)
+	}
+
+	opts.IgnoreHazards = false
+	opts.DryRun = true
+	if cfg, _ = FromOptions(opts); len(cfg.Hazards) != 0 {
+		t.Fatalf("expected dry runs to skip the check, got %v", cfg.Hazards)
+	}
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// datedDirPattern matches folder names an organized archive uses: a year, a
+// month or a day (2024, 2024-10, 2024_10_02, 20241002, 10-02), optionally
+// followed by a description like "2024-10-02 Iceland".
+var datedDirPattern = regexp.MustCompile(`^(?:(?:19|20)\d{2}(?:[-_. ]?(?:0[1-9]|1[0-2])(?:[-_. ]?(?:0[1-9]|[12]\d|3[01]))?)?|(?:0[1-9]|1[0-2])[-_.](?:0[1-9]|[12]\d|3[01]))(?:$|[^0-9])`)
+
+// MetaDir is the directory below a target that holds phopy's own files,
+// like the journal and manifests. An archive used as a source has them too;
+// they are never planned.
+const MetaDir = ".phopy"
+
+// IsMetaDir reports whether a directory is named MetaDir, in any case since
+// card and archive file systems often ignore it.
+func IsMetaDir(name string) bool {
+	return strings.EqualFold(name, MetaDir)
+}
+
+// SourceLayout summarizes the first-level directories of a source.
+type SourceLayout struct {
+	Dirs    int
+	Dated   []string
+	HasDCIM bool
+}
+
+// InspectSourceLayout classifies the first-level directory names of a
+// source. Hidden directories are ignored.
+func InspectSourceLayout(dirNames []string) SourceLayout {
+	var layout SourceLayout
+	for _, name := range dirNames {
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		layout.Dirs++
+		if strings.EqualFold(name, "DCIM") {
+			layout.HasDCIM = true
+		}
+		if datedDirPattern.MatchString(name) {
+			layout.Dated = append(layout.Dated, name)
+		}
+	}
+	return layout
+}
+
+// LooksOrganized reports whether the source looks like an archive phopy
+// already sorted into dated folders rather than a camera card: there is no
+// DCIM folder and at least two and three in five folders are named like
+// dates.
+func (l SourceLayout) LooksOrganized() bool {
+	return !l.HasDCIM && len(l.Dated) >= 2 && len(l.Dated)*5 >= l.Dirs*3
+}
+
+// Summary explains why the source looks organized, e.g. "5 of 6 folders are
+// named like dates (2020, 2021, 2022, ...) and there is no DCIM folder".
+func (l SourceLayout) Summary() string {
+	examples := l.Dated
+	if len(examples) > 3 {
+		examples = append(examples[:3:3], "...")
+	}
+	return fmt.Sprintf("%d of %d folders are named like dates (%s) and there is no DCIM folder", len(l.Dated), l.Dirs, strings.Join(examples, ", "))
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "testing"
+
+func TestInspectSourceLayout(t *testing.T) {
+	tests := []struct {
+		name string
+		dirs []string
+		want bool
+	}{
+		{"camera card", []string{"DCIM", "MISC", "PRIVATE"}, false},
+		{"card with dated extras", []string{"DCIM", "2023", "2024"}, false},
+		{"year archive", []string{"2019", "2020", "2021", "2022", "2023"}, true},
+		{"year and month archive", []string{"2024-01", "2024-02", "2024_03", "Exports"}, true},
+		{"day archive with descriptions", []string{"2024-10-02 Iceland", "2024-10-05", "20241007", "Edits"}, true},
+		{"month-day folders", []string{"10-02", "10-03", "10-04"}, true},
+		{"hidden folders ignored", []string{".phopy", ".Trashes", "2023", "2024"}, true},
+		{"mostly undated", []string{"2024", "Family", "Work", "Scans", "Phone"}, false},
+		{"single dated folder", []string{"2024"}, false},
+		{"numbers that are not dates", []string{"100MSDCF", "101MSDCF", "1234"}, false},
+		{"empty source", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InspectSourceLayout(tt.dirs).LooksOrganized(); got != tt.want {
+				t.Fatalf("expected %v for %v", tt.want, tt.dirs)
+			}
+		})
+	}
+}
+
+func TestSourceLayoutSummary(t *testing.T) {
+	got := InspectSourceLayout([]string{"2020", "2021", "2022", "2023", "2024", "Misc"}).Summary()
+	want := "5 of 6 folders are named like dates (2020, 2021, 2022, ...) and there is no DCIM folder"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Audit issues, the kinds of findings of `phopy audit`. They are part of
+// its JSON report and must not change once released.
+const (
+	AuditMisfiled  = "misfiled"
+	AuditSplitPair = "split_pair"
+	AuditDuplicate = "duplicate"
+	AuditJunk      = "junk"
+)
+
+// AuditIssue documents an audit issue; Title heads its group in the text
+// report.
+type AuditIssue struct {
+	Issue string
+	Title string
+}
+
+// AuditIssues lists every audit issue in the order of the report.
+var AuditIssues = []AuditIssue{
+	{AuditMisfiled, "Files in another folder than the layout gives their date"},
+	{AuditSplitPair, "JPEGs in another folder than their RAW"},
+	{AuditDuplicate, "Duplicate captures"},
+	{AuditJunk, "Junk files"},
+}
+
+// AuditReport is what an audit found in an existing archive. Paths are
+// relative to Dir.
+type AuditReport struct {
+	Dir string `json:"dir"`
+	// Files is every file the audit walk discovered.
+	Files int `json:"files"`
+	// Groups holds one group per issue of AuditIssues, in that order,
+	// empty ones included.
+	Groups []AuditGroup `json:"groups"`
+}
+
+// AuditGroup holds the findings of one issue, by path.
+type AuditGroup struct {
+	Issue    string         `json:"issue"`
+	Findings []AuditFinding `json:"findings"`
+}
+
+// AuditFinding is a file that deviates from the rules. Folder is where a
+// misfiled file or a split JPEG belongs; Of is the RAW of a split JPEG or
+// the file a duplicate repeats.
+type AuditFinding struct {
+	Path   string `json:"path"`
+	Folder string `json:"folder,omitempty"`
+	Of     string `json:"of,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// NewAuditReport groups findings, keyed by issue, into a report.
+func NewAuditReport(dir string, files int, findings map[string][]AuditFinding) AuditReport {
+	report := AuditReport{Dir: dir, Files: files, Groups: make([]AuditGroup, 0, len(AuditIssues))}
+	for _, issue := range AuditIssues {
+		group := AuditGroup{Issue: issue.Issue, Findings: slices.Clone(findings[issue.Issue])}
+		if group.Findings == nil {
+			group.Findings = []AuditFinding{}
+		}
+		slices.SortFunc(group.Findings, func(a, b AuditFinding) int { return cmp.Compare(a.Path, b.Path) })
+		report.Groups = append(report.Groups, group)
+	}
+	return report
+}
+
+// Findings counts the findings of every group.
+func (r AuditReport) Findings() int {
+	n := 0
+	for _, group := range r.Groups {
+		n += len(group.Findings)
+	}
+	return n
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewAuditReportGroupsFindingsByIssue(t *testing.T) {
+	report := NewAuditReport("/archive", 4, map[string][]AuditFinding{
+		AuditJunk:     {{Path: "b/.DS_Store"}, {Path: "a/Thumbs.db"}},
+		AuditMisfiled: {{Path: "2024-03-15/DSC0002.ARW", Folder: "2024-03-16"}},
+	})
+
+	if len(report.Groups) != len(AuditIssues) {
+		t.Fatalf("expected a group per issue, got %+v", report.Groups)
+	}
+	for i, issue := range AuditIssues {
+		if report.Groups[i].Issue != issue.Issue {
+			t.Fatalf("expected group %d to be %s, got %s", i, issue.Issue, report.Groups[i].Issue)
+		}
+	}
+	if junk := report.Groups[3].Findings; junk[0].Path != "a/Thumbs.db" || junk[1].Path != "b/.DS_Store" {
+		t.Fatalf("expected the findings by path, got %+v", junk)
+	}
+	if report.Findings() != 3 {
+		t.Fatalf("expected 3 findings, got %d", report.Findings())
+	}
+
+	// Issues without findings are empty lists, not null, for scripts
+	data, err := json.Marshal(report.Groups[1])
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if got := string(data); got != `{"issue":"split_pair","findings":[]}` {
+		t.Fatalf("unexpected JSON %s", got)
+	}
+}
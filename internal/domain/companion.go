@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultCompanionGlobs match the per-clip metadata Sony bodies write next
+// to their videos, like C0001M01.XML for C0001.MP4.
+var DefaultCompanionGlobs = []string{"C*.XML"}
+
+// miscFiles are housekeeping files cameras write that are harmless to copy
+// and that some editors expect, by upper-case name.
+var miscFiles = map[string]bool{
+	"MEDIAPRO.XML": true, // Sony clip index
+	"CUEUP.XML":    true, // Sony clip cue points
+	"STATUS.BIN":   true,
+	"AVIN0001.BNP": true, // Sony AVF_INFO database
+	"AVIN0001.INP": true,
+	"AVIN0001.INT": true,
+	"PRV00001.BIN": true,
+}
+
+// ParseCompanionGlobs parses a comma-separated list of file name globs, like
+// "C*.XML,*.THM". "none" or an empty list matches nothing.
+func ParseCompanionGlobs(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil, nil
+	}
+	var globs []string
+	for _, glob := range strings.Split(value, ",") {
+		glob = strings.TrimSpace(glob)
+		if glob == "" {
+			continue
+		}
+		if strings.ContainsAny(glob, `/\`) {
+			return nil, fmt.Errorf("%q is not a file name glob", glob)
+		}
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("%q is not a valid glob", glob)
+		}
+		globs = append(globs, glob)
+	}
+	return globs, nil
+}
+
+// MatchesCompanion reports whether the file name matches one of globs,
+// ignoring case.
+func MatchesCompanion(globs []string, name string) bool {
+	name = strings.ToUpper(name)
+	for _, glob := range globs {
+		if ok, _ := filepath.Match(strings.ToUpper(glob), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCompanionOf reports whether the file name belongs to the file with the
+// base name primaryBase by the camera's naming convention: it starts with
+// the clip name, followed by anything but a digit, so C0001M01.XML belongs
+// to C0001.MP4 but not to C00010.MP4.
+func IsCompanionOf(name, primaryBase string) bool {
+	base := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+	primaryBase = strings.ToLower(primaryBase)
+	if primaryBase == "" || !strings.HasPrefix(base, primaryBase) {
+		return false
+	}
+	rest := base[len(primaryBase):]
+	return rest == "" || rest[0] < '0' || rest[0] > '9'
+}
+
+// IsMiscFile reports whether the file name is camera housekeeping that is
+// harmless to copy, see --include-misc.
+func IsMiscFile(name string) bool {
+	return miscFiles[strings.ToUpper(name)]
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCompanionGlobs(t *testing.T) {
+	globs, err := ParseCompanionGlobs(" C*.XML, *.THM ,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"C*.XML", "*.THM"}; !reflect.DeepEqual(globs, want) {
+		t.Fatalf("expected %v, got %v", want, globs)
+	}
+	for _, value := range []string{"", "none", "NONE"} {
+		if globs, err := ParseCompanionGlobs(value); err != nil || globs != nil {
+			t.Fatalf("expected no globs for %q, got %v, %v", value, globs, err)
+		}
+	}
+	for _, value := range []string{"CLIP/C*.XML", "C[*.XML"} {
+		if _, err := ParseCompanionGlobs(value); err == nil {
+			t.Fatalf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestCompanionMatching(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		primary string
+		match   bool
+		belongs bool
+	}{
+		{"C0001M01.XML", "C0001", true, true},
+		{"c0001m01.xml", "C0001", true, true},
+		{"C0001.XML", "C0001", true, true},
+		{"C00010M01.XML", "C0001", true, false},
+		{"C0002M01.XML", "C0001", true, false},
+		{"MEDIAPRO.XML", "C0001", false, false},
+	} {
+		if got := MatchesCompanion(DefaultCompanionGlobs, tt.name); got != tt.match {
+			t.Errorf("MatchesCompanion(%q) = %v, want %v", tt.name, got, tt.match)
+		}
+		if got := IsCompanionOf(tt.name, tt.primary); got != tt.belongs {
+			t.Errorf("IsCompanionOf(%q, %q) = %v, want %v", tt.name, tt.primary, got, tt.belongs)
+		}
+	}
+	if !IsMiscFile("mediapro.xml") || IsMiscFile(".DS_Store") {
+		t.Fatal("expected only known housekeeping files to be misc files")
+	}
+}
@@ -0,0 +1,50 @@
+package domain
+
+// ConflictAnswer resolves a late conflict: a target file that appeared
+// after planning, so the plan did not list it as an override.
+type ConflictAnswer int
+
+const (
+	ConflictFail ConflictAnswer = iota
+	ConflictSkip
+	ConflictOverwrite
+	// ConflictSkipAll and ConflictOverwriteAll answer this and every
+	// later conflict of the run.
+	ConflictSkipAll
+	ConflictOverwriteAll
+)
+
+func (a ConflictAnswer) String() string {
+	switch a {
+	case ConflictFail:
+		return "fail"
+	case ConflictSkip:
+		return "skip"
+	case ConflictOverwrite:
+		return "overwrite"
+	case ConflictSkipAll:
+		return "skip all"
+	case ConflictOverwriteAll:
+		return "overwrite all"
+	default:
+		return "unknown"
+	}
+}
+
+// Once returns the answer for a single conflict, turning the "all" answers
+// into their one-off counterpart.
+func (a ConflictAnswer) Once() ConflictAnswer {
+	switch a {
+	case ConflictSkipAll:
+		return ConflictSkip
+	case ConflictOverwriteAll:
+		return ConflictOverwrite
+	default:
+		return a
+	}
+}
+
+// Sticky reports whether a applies to all later conflicts too.
+func (a ConflictAnswer) Sticky() bool {
+	return a == ConflictSkipAll || a == ConflictOverwriteAll
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DateTag is an EXIF tag that can hold the capture date. Names follow
+// exiftool.
+type DateTag int
+
+const (
+	// DateTagOriginal is DateTimeOriginal, when the shutter fired.
+	DateTagOriginal DateTag = iota
+	// DateTagCreate is CreateDate (EXIF DateTimeDigitized), when the image
+	// was digitized, like the time of a film scan.
+	DateTagCreate
+	// DateTagModify is ModifyDate (EXIF DateTime), when the file was last
+	// changed.
+	DateTagModify
+)
+
+// DefaultDateTagOrder is the order capture dates are looked up in without
+// --date-tag-order.
+var DefaultDateTagOrder = []DateTag{DateTagOriginal, DateTagModify}
+
+func (t DateTag) String() string {
+	switch t {
+	case DateTagOriginal:
+		return "DateTimeOriginal"
+	case DateTagCreate:
+		return "CreateDate"
+	case DateTagModify:
+		return "ModifyDate"
+	default:
+		return "unknown"
+	}
+}
+
+// dateTagNames maps lowercase tag names, including the EXIF names of
+// CreateDate and ModifyDate, to their tag.
+var dateTagNames = map[string]DateTag{
+	"datetimeoriginal":  DateTagOriginal,
+	"createdate":        DateTagCreate,
+	"datetimedigitized": DateTagCreate,
+	"modifydate":        DateTagModify,
+	"datetime":          DateTagModify,
+}
+
+// ParseDateTagOrder parses a comma separated list of tag names, like
+// "DateTimeOriginal,CreateDate,ModifyDate". Names are case insensitive.
+func ParseDateTagOrder(order string) ([]DateTag, error) {
+	var tags []DateTag
+	seen := map[DateTag]bool{}
+	for _, name := range strings.Split(order, ",") {
+		name = strings.TrimSpace(name)
+		tag, ok := dateTagNames[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown date tag %q", name)
+		}
+		if seen[tag] {
+			return nil, fmt.Errorf("date tag %s listed twice", tag)
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDateTagOrder(t *testing.T) {
+	got, err := ParseDateTagOrder("CreateDate, datetimeoriginal,DateTime")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []DateTag{DateTagCreate, DateTagOriginal, DateTagModify}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for _, order := range []string{"GPSDateStamp", "CreateDate,DateTimeDigitized", ""} {
+		if _, err := ParseDateTagOrder(order); err == nil {
+			t.Fatalf("%q: expected an error", order)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// DayPolicy decides which calendar day a capture falls on. The date
+// folders of the layout, the first and last days of the folder breakdown,
+// the range of the plan and the date range filter all ask the same policy,
+// so none of them can file a photo under another day than the others show.
+//
+// The zero policy reads every time on the clock it carries, which for EXIF
+// dates is the camera's wall clock at capture. A policy with a Zone reads
+// times in that zone instead; captures with a recorded UTC offset are
+// converted to it by the planner.
+type DayPolicy struct {
+	Zone *time.Location
+}
+
+// In returns t as the policy reads it.
+func (p DayPolicy) In(t time.Time) time.Time {
+	if p.Zone == nil || t.IsZero() {
+		return t
+	}
+	return t.In(p.Zone)
+}
+
+// String names the clock of the policy, e.g. "Asia/Tokyo", or "camera
+// clock" for the zero policy. It keeps printed layouts free of pointers.
+func (p DayPolicy) String() string {
+	if p.Zone == nil {
+		return "camera clock"
+	}
+	return p.Zone.String()
+}
+
+// DayOf returns midnight of the day t falls on under policy, in the zone
+// the policy reads t in.
+func DayOf(t time.Time, policy DayPolicy) time.Time {
+	t = policy.In(t)
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// Compare compares the clock reading of t under the policy with the one of
+// bound on its own clock, like the dates of --from and --until, which name
+// days of the calendar the date folders use. A photo taken at 00:30 on the
+// camera compares after midnight of its day, whatever the zones of the two
+// times. It returns -1, 0 or +1 like time.Time.Compare.
+func (p DayPolicy) Compare(t, bound time.Time) int {
+	return clockReading(p.In(t)).Compare(clockReading(bound))
+}
+
+// clockReading returns the date and time of day t shows, as the same
+// reading in UTC, so readings of different zones compare.
+func clockReading(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDayOf(t *testing.T) {
+	plus9 := time.FixedZone("UTC+9", 9*60*60)
+	plus10 := time.FixedZone("UTC+10", 10*60*60)
+	taken := time.Date(2024, 3, 15, 23, 30, 0, 0, plus9)
+
+	tests := []struct {
+		name   string
+		policy DayPolicy
+		want   string
+	}{
+		{"camera clock", DayPolicy{}, "2024-03-15"},
+		{"zone ahead", DayPolicy{Zone: plus10}, "2024-03-16"},
+		{"zone behind", DayPolicy{Zone: time.UTC}, "2024-03-15"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := DayOf(taken, tt.policy)
+			if got := day.Format("2006-01-02 15:04"); got != tt.want+" 00:00" {
+				t.Errorf("expected %s 00:00, got %s", tt.want, got)
+			}
+		})
+	}
+
+	if !DayOf(time.Time{}, DayPolicy{Zone: plus10}).IsZero() {
+		t.Error("expected the zero time to stay zero")
+	}
+}
+
+func TestDayPolicyComparesClockReadings(t *testing.T) {
+	plus10 := time.FixedZone("UTC+10", 10*60*60)
+	// 00:30 on the 16th ten hours ahead of UTC
+	taken := time.Date(2024, 3, 15, 14, 30, 0, 0, time.UTC)
+	midnight := time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)
+
+	if got := (DayPolicy{}).Compare(taken, midnight); got != -1 {
+		t.Errorf("expected the camera clock to be before midnight, got %d", got)
+	}
+	if got := (DayPolicy{Zone: plus10}).Compare(taken, midnight); got != 1 {
+		t.Errorf("expected the zone clock to be after midnight, got %d", got)
+	}
+}
+
+func TestDayPolicyString(t *testing.T) {
+	if got := (DayPolicy{}).String(); got != "camera clock" {
+		t.Errorf("expected the camera clock, got %q", got)
+	}
+	if got := (DayPolicy{Zone: time.FixedZone("UTC+09:00", 9*60*60)}).String(); got != "UTC+09:00" {
+		t.Errorf("expected the zone name, got %q", got)
+	}
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"maps"
+	"time"
+)
+
+// CopyDecision is what the executor does with a single plan item.
+type CopyDecision int
+
+const (
+	// DecisionCopy copies an item planned as new. Its target is checked
+	// for late conflicts right before copying.
+	DecisionCopy CopyDecision = iota
+	// DecisionOverwrite copies an item over the target that existed when
+	// planning.
+	DecisionOverwrite
+	// DecisionSkip leaves the existing target of an item alone.
+	DecisionSkip
+)
+
+func (d CopyDecision) String() string {
+	switch d {
+	case DecisionCopy:
+		return "copy"
+	case DecisionOverwrite:
+		return "overwrite"
+	case DecisionSkip:
+		return "skip"
+	default:
+		return "unknown"
+	}
+}
+
+// Decide returns the decision for every item of the plan, in order. Items
+// planned as new are copied; items whose target exists are overwritten
+// when overwrite is set or they are auto-approved, and skipped otherwise.
+func (p CopyPlan) Decide(overwrite bool) []CopyDecision {
+	decisions := make([]CopyDecision, len(p.Items))
+	for i, item := range p.Items {
+		switch {
+		case !item.Exists:
+			decisions[i] = DecisionCopy
+		case overwrite, item.AutoOverride:
+			decisions[i] = DecisionOverwrite
+		default:
+			decisions[i] = DecisionSkip
+		}
+	}
+	return decisions
+}
+
+// IsStaleTarget reports whether the existing target of source is a stale
+// copy, like the remains of an interrupted import: older than the source
+// and of another size. --auto-override-stale overwrites those without
+// asking. A newer target may have been edited, and one of the same size may
+// be the same file, so those are left to the user. Sources planned without
+// their modification time are never stale.
+func IsStaleTarget(source FileMeta, targetSize int64, targetModTime time.Time) bool {
+	if source.ModTime.IsZero() {
+		return false
+	}
+	return targetModTime.Before(source.ModTime) && targetSize != source.Size
+}
+
+// LeftoverOverrideDeclined is the leftover reason of files whose existing
+// target the user chose to keep.
+const LeftoverOverrideDeclined = "override declined"
+
+// Effective returns the plan as it is executed once the overrides are
+// answered. With overwrite that is p; otherwise the items whose target
+// exists and is not auto-approved move from Items and the counts to the
+// leftovers, while OverrideItems and the override counts keep describing
+// what was declined. Previews that cut OverrideItems return their Declined
+// plan. p itself is not changed.
+func (p CopyPlan) Effective(overwrite bool) CopyPlan {
+	if overwrite || len(p.OverrideItems) == 0 {
+		return p
+	}
+	if p.Declined != nil {
+		return *p.Declined
+	}
+	effective := p
+	effective.Items = make([]CopyItem, 0, len(p.Items))
+	for _, item := range p.Items {
+		if !item.Exists || item.AutoOverride {
+			effective.Items = append(effective.Items, item)
+		}
+	}
+	// Overrides cut off a preview were counted in TruncatedItems
+	effective.TruncatedItems -= len(p.OverrideItems) - (len(p.Items) - len(effective.Items))
+	effective.TruncatedItems = max(effective.TruncatedItems, 0)
+
+	effective.ExtensionCounts = maps.Clone(p.ExtensionCounts)
+	effective.Ratings = maps.Clone(p.Ratings)
+	effective.Leftovers = append([]Leftover(nil), p.Leftovers...)
+	for _, item := range p.OverrideItems {
+		effective.Leftovers = append(effective.Leftovers, Leftover{SourcePath: item.FileMeta.SourcePath, Reason: LeftoverOverrideDeclined})
+		uncount(effective.ExtensionCounts, item.FileMeta.Ext)
+		if item.CompanionOf != "" {
+			continue
+		}
+		if item.FileMeta.IsRAW {
+			effective.RawCount--
+		} else if item.FileMeta.IsJPEG {
+			effective.JpegCount--
+		}
+		if item.FileMeta.Rated {
+			uncount(effective.Ratings, item.FileMeta.Rating)
+		}
+	}
+	return effective
+}
+
+// uncount takes one off counts[key], dropping keys that reach zero.
+func uncount[K comparable](counts map[K]int, key K) {
+	if counts[key] <= 1 {
+		delete(counts, key)
+		return
+	}
+	counts[key]--
+}
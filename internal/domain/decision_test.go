@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecideFollowsExistsNotTargetPath(t *testing.T) {
+	// Two items share a target; only the one planned over an existing file
+	// is an override
+	plan := CopyPlan{Items: []CopyItem{
+		{TargetPath: "/target/DSC0001.ARW"},
+		{TargetPath: "/target/DSC0001.ARW", Exists: true},
+		{TargetPath: "/target/DSC0002.ARW"},
+	}}
+	plan.OverrideItems = []CopyItem{plan.Items[1]}
+
+	if got, want := plan.Decide(false), []CopyDecision{DecisionCopy, DecisionSkip, DecisionCopy}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got, want := plan.Decide(true), []CopyDecision{DecisionCopy, DecisionOverwrite, DecisionCopy}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestEffectiveLeavesDeclinedOverridesOut(t *testing.T) {
+	item := func(name string, exists bool) CopyItem {
+		meta := NewFileMeta("/card/"+name, name, time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC))
+		return CopyItem{FileMeta: meta, TargetPath: "/target/" + name, Exists: exists}
+	}
+	plan := CopyPlan{
+		Items:           []CopyItem{item("DSC0001.ARW", true), item("DSC0002.ARW", false), item("DSC0003.JPG", true), item("DSC0004.JPG", false)},
+		RawCount:        2,
+		JpegCount:       2,
+		RawOverrides:    1,
+		JpegOverrides:   1,
+		ExtensionCounts: map[string]int{".arw": 2, ".jpg": 2},
+	}
+	plan.OverrideItems = []CopyItem{plan.Items[0], plan.Items[2]}
+
+	if got := plan.Effective(true); !reflect.DeepEqual(got, plan) {
+		t.Fatalf("expected confirmed overrides to keep the plan, got %+v", got)
+	}
+
+	got := plan.Effective(false)
+	if len(got.Items) != 2 || got.Items[0].FileMeta.Name != "DSC0002.ARW" || got.Items[1].FileMeta.Name != "DSC0004.JPG" {
+		t.Fatalf("expected only new items, got %v", got.Items)
+	}
+	if got.RawCount != 1 || got.JpegCount != 1 || !reflect.DeepEqual(got.ExtensionCounts, map[string]int{".arw": 1, ".jpg": 1}) {
+		t.Fatalf("expected recomputed counts, got %d RAW, %d JPEG, %v", got.RawCount, got.JpegCount, got.ExtensionCounts)
+	}
+	if got.RawOverrides != 1 || got.JpegOverrides != 1 || len(got.OverrideItems) != 2 {
+		t.Fatalf("expected the declined overrides to stay described, got %+v", got)
+	}
+	want := []Leftover{{SourcePath: "/card/DSC0001.ARW", Reason: LeftoverOverrideDeclined}, {SourcePath: "/card/DSC0003.JPG", Reason: LeftoverOverrideDeclined}}
+	if !reflect.DeepEqual(got.Leftovers, want) {
+		t.Fatalf("expected leftovers %v, got %v", want, got.Leftovers)
+	}
+	if plan.RawCount != 2 || len(plan.Items) != 4 || plan.ExtensionCounts[".arw"] != 2 {
+		t.Fatalf("expected the plan itself unchanged, got %+v", plan)
+	}
+
+	// A preview cut off before the second override
+	preview := plan.Preview(2, nil).Effective(false)
+	if len(preview.Items) != 1 || preview.TruncatedItems != 1 || preview.ItemCount() != 2 {
+		t.Fatalf("expected 2 items in the preview, got %d and %d truncated", len(preview.Items), preview.TruncatedItems)
+	}
+}
+
+func TestIsStaleTarget(t *testing.T) {
+	source := NewFileMeta("/card/DSC0001.ARW", "DSC0001.ARW", time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC))
+	source.Size = 100
+	source.ModTime = time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		source  FileMeta
+		size    int64
+		modTime time.Time
+		want    bool
+	}{
+		{"older and smaller", source, 40, source.ModTime.Add(-time.Hour), true},
+		{"older and larger", source, 400, source.ModTime.Add(-time.Hour), true},
+		{"older and same size", source, 100, source.ModTime.Add(-time.Hour), false},
+		{"newer", source, 40, source.ModTime.Add(time.Hour), false},
+		{"same time", source, 40, source.ModTime, false},
+		{"source without modification time", FileMeta{Size: 100}, 40, source.ModTime.Add(-time.Hour), false},
+	}
+	for _, tt := range tests {
+		if got := IsStaleTarget(tt.source, tt.size, tt.modTime); got != tt.want {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestEffectiveKeepsAutoApprovedOverrides(t *testing.T) {
+	item := func(name string, exists, auto bool) CopyItem {
+		meta := NewFileMeta("/card/"+name, name, time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC))
+		return CopyItem{FileMeta: meta, TargetPath: "/target/" + name, Exists: exists, AutoOverride: auto}
+	}
+	plan := CopyPlan{
+		Items:           []CopyItem{item("DSC0001.ARW", true, true), item("DSC0002.ARW", true, false), item("DSC0003.ARW", false, false)},
+		RawCount:        3,
+		RawOverrides:    1,
+		ExtensionCounts: map[string]int{".arw": 3},
+	}
+	plan.OverrideItems = []CopyItem{plan.Items[1]}
+	plan.AutoOverrideItems = []CopyItem{plan.Items[0]}
+
+	if got, want := plan.Decide(false), []CopyDecision{DecisionOverwrite, DecisionSkip, DecisionCopy}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	got := plan.Effective(false)
+	if len(got.Items) != 2 || got.Items[0].FileMeta.Name != "DSC0001.ARW" || got.RawCount != 2 {
+		t.Fatalf("expected the auto-approved override to stay planned, got %d RAW in %v", got.RawCount, got.Items)
+	}
+	if len(got.AutoOverrideItems) != 1 || len(got.Leftovers) != 1 || got.Leftovers[0].SourcePath != "/card/DSC0002.ARW" {
+		t.Fatalf("expected only the declined override as a leftover, got %v", got.Leftovers)
+	}
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// cameraDefaultDates are the days cameras count from until their clock is
+// set, by year, month and day.
+var cameraDefaultDates = []time.Time{
+	time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), // Unix epoch
+	time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), // FAT epoch
+	time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), // action cams
+	time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// DefaultSameSecondLimit is how many files may share a capture second before
+// their dates are suspect; bursts of fast bodies stay below it.
+const DefaultSameSecondLimit = 40
+
+// CameraDefaultDate returns the day t falls on when that day is a known
+// default date of camera clocks, like 2015-01-01.
+func CameraDefaultDate(t time.Time) (time.Time, bool) {
+	year, month, day := t.Date()
+	for _, date := range cameraDefaultDates {
+		if date.Year() == year && date.Month() == month && date.Day() == day {
+			return time.Date(year, month, day, 0, 0, 0, 0, t.Location()), true
+		}
+	}
+	return time.Time{}, false
+}
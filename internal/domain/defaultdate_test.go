@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCameraDefaultDate(t *testing.T) {
+	for _, tt := range []struct {
+		taken time.Time
+		want  bool
+	}{
+		{time.Date(2015, 1, 1, 0, 3, 12, 0, time.Local), true},
+		{time.Date(1980, 1, 1, 23, 59, 59, 0, time.Local), true},
+		{time.Date(2015, 1, 2, 0, 0, 0, 0, time.Local), false},
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), false},
+	} {
+		day, ok := CameraDefaultDate(tt.taken)
+		if ok != tt.want {
+			t.Errorf("CameraDefaultDate(%v) = %v, want %v", tt.taken, ok, tt.want)
+		}
+		if ok && day.Format("2006-01-02 15:04:05") != tt.taken.Format("2006-01-02")+" 00:00:00" {
+			t.Errorf("expected the start of the day of %v, got %v", tt.taken, day)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateSource tells where the capture date of a file came from.
+type DateSource int
+
+const (
+	DateSourceExif DateSource = iota
+	// DateSourceFileTime is the modification time, used without a better
+	// source.
+	DateSourceFileTime
+	// DateSourceDirectory is a date parsed from the name of a folder the
+	// file is in, see DirDatePattern.
+	DateSourceDirectory
+)
+
+func (s DateSource) String() string {
+	switch s {
+	case DateSourceExif:
+		return "exif"
+	case DateSourceFileTime:
+		return "file time"
+	case DateSourceDirectory:
+		return "directory"
+	default:
+		return "unknown"
+	}
+}
+
+// AutoDirDatePattern matches folder names starting with a year, optionally
+// followed by month and day: "1998", "1998-07 summer trip", "19980714".
+const AutoDirDatePattern = `^(?P<year>(?:19|20)\d{2})(?:[-_.]?(?P<month>0[1-9]|1[0-2])(?:[-_.]?(?P<day>0[1-9]|[12]\d|3[01]))?)?(?:\D|$)`
+
+// ErrDirDatePattern is returned for patterns without a year group.
+var ErrDirDatePattern = errors.New("pattern needs a (?P<year>...) group")
+
+// DirDatePattern dates files by the names of the folders they are in, for
+// archives like scanned negatives that carry no EXIF. The zero value dates
+// nothing.
+type DirDatePattern struct {
+	re *regexp.Regexp
+}
+
+// ParseDirDatePattern compiles pattern, "auto" for AutoDirDatePattern. The
+// regular expression needs a named group year and may have month and day
+// groups; missing ones default to the first.
+func ParseDirDatePattern(pattern string) (DirDatePattern, error) {
+	if strings.EqualFold(pattern, "auto") {
+		pattern = AutoDirDatePattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return DirDatePattern{}, err
+	}
+	if re.SubexpIndex("year") < 0 {
+		return DirDatePattern{}, ErrDirDatePattern
+	}
+	return DirDatePattern{re: re}, nil
+}
+
+// Enabled reports whether p dates anything.
+func (p DirDatePattern) Enabled() bool {
+	return p.re != nil
+}
+
+// String returns the regular expression, empty for the zero value.
+func (p DirDatePattern) String() string {
+	if p.re == nil {
+		return ""
+	}
+	return p.re.String()
+}
+
+// DateOf returns the date of the deepest folder in relDir, a directory
+// relative to the source, whose name matches. Deeper folders win since
+// they are usually more specific: "1998/1998-07-14 beach" is the 14th.
+func (p DirDatePattern) DateOf(relDir string) (time.Time, bool) {
+	if p.re == nil {
+		return time.Time{}, false
+	}
+	for dir := filepath.Clean(relDir); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if date, ok := p.parse(filepath.Base(dir)); ok {
+			return date, true
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	return time.Time{}, false
+}
+
+func (p DirDatePattern) parse(name string) (time.Time, bool) {
+	match := p.re.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}, false
+	}
+	group := func(name string, fallback int) (int, bool) {
+		i := p.re.SubexpIndex(name)
+		if i < 0 || match[i] == "" {
+			return fallback, true
+		}
+		n, err := strconv.Atoi(match[i])
+		return n, err == nil
+	}
+	year, okYear := group("year", 0)
+	month, okMonth := group("month", 1)
+	day, okDay := group("day", 1)
+	if !okYear || !okMonth || !okDay || year == 0 || month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+	// Reject dates time.Date normalized, like February 30
+	if date.Day() != day {
+		return time.Time{}, false
+	}
+	return date, true
+}
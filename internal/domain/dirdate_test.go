@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirDatePatternDateOf(t *testing.T) {
+	auto, err := ParseDirDatePattern("auto")
+	if err != nil {
+		t.Fatalf("parse auto: %v", err)
+	}
+	custom, err := ParseDirDatePattern(`(?P<day>\d{2})\.(?P<month>\d{2})\.(?P<year>\d{4})`)
+	if err != nil {
+		t.Fatalf("parse custom: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pattern DirDatePattern
+		dir     string
+		want    string
+	}{
+		{"year and month", auto, "1998-07 summer trip", "1998-07-01"},
+		{"deeper folder wins", auto, "1998/1998-07 summer trip/1998-07-14 beach", "1998-07-14"},
+		{"undated folder inherits", auto, "1998-07 summer trip/roll 3", "1998-07-01"},
+		{"compact date", auto, "19980714", "1998-07-14"},
+		{"year only", auto, "2003 negatives", "2003-01-01"},
+		{"not a date", auto, "scans/roll 3", ""},
+		{"invalid day falls back to parent", auto, "1999-02/1999-02-30", "1999-02-01"},
+		{"longer number", auto, "199807141", ""},
+		{"custom layout", custom, "Urlaub 14.07.1998", "1998-07-14"},
+		{"disabled", DirDatePattern{}, "1998-07", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, ok := tt.pattern.DateOf(filepath.FromSlash(tt.dir))
+			got := ""
+			if ok {
+				got = date.Format("2006-01-02")
+				if date.Location() != time.Local {
+					t.Fatalf("expected a local date, got %v", date.Location())
+				}
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseDirDatePatternNeedsYear(t *testing.T) {
+	if _, err := ParseDirDatePattern(`(?P<month>\d{2})`); err != ErrDirDatePattern {
+		t.Fatalf("expected ErrDirDatePattern, got %v", err)
+	}
+	if _, err := ParseDirDatePattern(`(?P<year>\d{4}`); err == nil {
+		t.Fatalf("expected an invalid regular expression to fail")
+	}
+}
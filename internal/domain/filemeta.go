@@ -2,6 +2,7 @@ package domain
 
 import (
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 )
@@ -13,8 +14,22 @@ type FileMeta struct {
 	BaseName     string
 	Ext          string
 	TakenAt      time.Time
+	Size         int64
 	IsRAW        bool
 	IsJPEG       bool
+	Sniffed      bool // Ext was detected from the file content
+	Rating       int  // star rating from an XMP sidecar, valid when Rated
+	Rated        bool
+	// CaptureKey is the EXIF capture key, see PhotoMeta.CaptureKey; empty
+	// when unknown.
+	CaptureKey string
+	// DateSource tells where TakenAt came from, and DateTag which EXIF tag
+	// when it came from EXIF.
+	DateSource DateSource
+	DateTag    DateTag
+	// ModTime is the modification time of the source when it was planned;
+	// zero in plans saved before it was recorded.
+	ModTime time.Time
 }
 
 func NewFileMeta(sourcePath, relativePath string, takenAt time.Time) FileMeta {
@@ -36,20 +51,62 @@ func NewFileMeta(sourcePath, relativePath string, takenAt time.Time) FileMeta {
 	}
 }
 
+// WithSniffedExt returns a copy of m classified by an extension detected from
+// the file content instead of the file name.
+func (m FileMeta) WithSniffedExt(ext string) FileMeta {
+	m.Ext = ext
+	m.IsRAW = IsRawExtension(ext)
+	m.IsJPEG = IsJpegExtension(ext)
+	m.Sniffed = true
+	return m
+}
+
+// RawExtensions and JpegExtensions are the lower-case extensions of the
+// photos phopy copies.
+var (
+	RawExtensions  = []string{".arw", ".cr2", ".cr3", ".nef", ".raf", ".rw2", ".orf", ".dng"}
+	JpegExtensions = []string{".jpg", ".jpeg"}
+)
+
 func IsRawExtension(ext string) bool {
-	switch strings.ToLower(ext) {
-	case ".arw", ".cr2", ".cr3", ".nef", ".raf", ".rw2", ".orf", ".dng":
-		return true
-	default:
-		return false
-	}
+	return slices.Contains(RawExtensions, strings.ToLower(ext))
 }
 
 func IsJpegExtension(ext string) bool {
-	switch strings.ToLower(ext) {
-	case ".jpg", ".jpeg":
+	return slices.Contains(JpegExtensions, strings.ToLower(ext))
+}
+
+// exifTypes records, by lower-case extension, whether files of the types
+// besides RAW and JPEG carry EXIF. Screenshots and graphics, videos, which
+// keep their dates in their own metadata, and audio memos never do.
+var exifTypes = map[string]bool{
+	".heic": true,
+	".heif": true,
+	".tif":  true,
+	".tiff": true,
+	".png":  false,
+	".gif":  false,
+	".bmp":  false,
+	".mp4":  false,
+	".mov":  false,
+	".avi":  false,
+	".mts":  false,
+	".wav":  false,
+	".mp3":  false,
+	".m4a":  false,
+}
+
+// HasExif reports whether files with ext are expected to carry EXIF, so
+// that one without it is worth a warning. Types that never carry it are
+// dated by their folder or modification time without one. Unknown types
+// are expected to carry it.
+func HasExif(ext string) bool {
+	ext = strings.ToLower(ext)
+	if IsRawExtension(ext) || IsJpegExtension(ext) {
 		return true
-	default:
-		return false
 	}
+	if has, ok := exifTypes[ext]; ok {
+		return has
+	}
+	return true
 }
@@ -0,0 +1,34 @@
+package domain
+
+import "testing"
+
+func TestHasExif(t *testing.T) {
+	tests := []struct {
+		ext  string
+		want bool
+	}{
+		{".arw", true},
+		{".CR3", true},
+		{".dng", true},
+		{".jpg", true},
+		{".JPEG", true},
+		{".heic", true},
+		{".HEIF", true},
+		{".tif", true},
+		{".png", false},
+		{".PNG", false},
+		{".gif", false},
+		{".mp4", false},
+		{".MOV", false},
+		{".wav", false},
+		{".m4a", false},
+		// Unknown types may be photos, so a missing date is reported
+		{".insv", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if got := HasExif(tt.ext); got != tt.want {
+			t.Errorf("HasExif(%q) = %v, want %v", tt.ext, got, tt.want)
+		}
+	}
+}
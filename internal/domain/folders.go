@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FolderStats sums up the files of a plan by the folder of the source they
+// came from, so oddities can be traced to one of the folders a card rolled
+// over into, see FolderOf.
+type FolderStats struct {
+	Folder string `json:"folder"`
+	// Planned counts the planned files, Conflicts those of them whose
+	// target exists already.
+	Planned   int `json:"planned"`
+	Conflicts int `json:"conflicts"`
+	// Skipped counts the discovered files left out of the plan.
+	Skipped int `json:"skipped"`
+	// Warnings counts the kept warnings about files in the folder.
+	Warnings int `json:"warnings"`
+	// First and Last are the earliest and latest capture times planned;
+	// zero without planned files.
+	First time.Time `json:"first,omitzero"`
+	Last  time.Time `json:"last,omitzero"`
+}
+
+// FolderOf returns the folder of the source relPath is counted under: its
+// top-level folder, or below DCIM the numbered folder it is in, like
+// DCIM/100MSDCF. Files directly in the source count under ".".
+func FolderOf(relPath string) string {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	if len(parts) < 2 {
+		return "."
+	}
+	if strings.EqualFold(parts[0], "DCIM") && len(parts) > 2 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+// FolderStatsOf sums up plan by the folders of sourceDir, ordered by
+// folder, with the capture times read as days decides. Warnings beyond the
+// plan's cap are not counted.
+func FolderStatsOf(plan CopyPlan, sourceDir string, days DayPolicy) []FolderStats {
+	byFolder := make(map[string]*FolderStats)
+	folder := func(relPath string) *FolderStats {
+		name := FolderOf(relPath)
+		stats, ok := byFolder[name]
+		if !ok {
+			stats = &FolderStats{Folder: name}
+			byFolder[name] = stats
+		}
+		return stats
+	}
+	relative := func(path string) string {
+		rel, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return path
+		}
+		return rel
+	}
+
+	for _, item := range plan.Items {
+		stats := folder(item.FileMeta.RelativePath)
+		stats.Planned++
+		taken := days.In(item.FileMeta.TakenAt)
+		if taken.IsZero() {
+			continue
+		}
+		if stats.First.IsZero() || taken.Before(stats.First) {
+			stats.First = taken
+		}
+		if taken.After(stats.Last) {
+			stats.Last = taken
+		}
+	}
+	for _, item := range plan.OverrideItems {
+		folder(item.FileMeta.RelativePath).Conflicts++
+	}
+	for _, leftover := range plan.Leftovers {
+		folder(relative(leftover.SourcePath)).Skipped++
+	}
+	for _, warning := range plan.Warnings {
+		// A warning about several files of a folder counts once for it
+		counted := make(map[*FolderStats]bool)
+		for _, path := range warning.Paths {
+			stats := folder(relative(path))
+			if !counted[stats] {
+				stats.Warnings++
+				counted[stats] = true
+			}
+		}
+	}
+
+	folders := make([]FolderStats, 0, len(byFolder))
+	for _, stats := range byFolder {
+		folders = append(folders, *stats)
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].Folder < folders[j].Folder })
+	return folders
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFolderOf(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"DCIM/100MSDCF/DSC0001.ARW", "DCIM/100MSDCF"},
+		{"dcim/101MSDCF/sub/DSC0001.ARW", "dcim/101MSDCF"},
+		{"DCIM/DSC0001.ARW", "DCIM"},
+		{"PRIVATE/M4ROOT/CLIP/C0001.MP4", "PRIVATE"},
+		{"DSC0001.ARW", "."},
+	}
+	for _, tt := range tests {
+		if got := FolderOf(filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("FolderOf(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFolderStatsOf(t *testing.T) {
+	day := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	item := func(rel string, taken time.Time) CopyItem {
+		return CopyItem{FileMeta: NewFileMeta(filepath.Join("/card", rel), rel, taken)}
+	}
+	first := item("DCIM/100MSDCF/DSC0001.ARW", day)
+	conflict := item("DCIM/100MSDCF/DSC0002.ARW", day.AddDate(0, 0, 2))
+	other := item("DCIM/101MSDCF/DSC0003.ARW", day.AddDate(0, 0, 1))
+	plan := CopyPlan{
+		Items:         []CopyItem{first, other, conflict},
+		OverrideItems: []CopyItem{conflict},
+		Leftovers: []Leftover{
+			{SourcePath: "/card/DCIM/101MSDCF/DSC0003.JPG", Reason: "JPEG with RAW"},
+			{SourcePath: "/card/DCIM/102MSDCF/DSC0004.ARW", Reason: "outside date range"},
+		},
+		Warnings: []Warning{
+			{Code: WarningDuplicateCapture, Paths: []string{"/card/DCIM/100MSDCF/DSC0001.ARW", "/card/DCIM/100MSDCF/DSC0002.ARW"}},
+			{Code: WarningDuplicateCapture},
+		},
+	}
+
+	got := FolderStatsOf(plan, "/card", DayPolicy{})
+	want := []FolderStats{
+		{Folder: "DCIM/100MSDCF", Planned: 2, Conflicts: 1, Warnings: 1, First: day, Last: day.AddDate(0, 0, 2)},
+		{Folder: "DCIM/101MSDCF", Planned: 1, Skipped: 1, First: day.AddDate(0, 0, 1), Last: day.AddDate(0, 0, 1)},
+		{Folder: "DCIM/102MSDCF", Skipped: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d folders, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("folder %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package domain
+
+import "crypto/subtle"
+
+// Integrity is the outcome of checking an imported file against the hash
+// recorded when it was imported.
+type Integrity int
+
+const (
+	IntegrityOK Integrity = iota
+	IntegrityTargetCorrupted
+	IntegritySourceModified
+	IntegrityNeverImported
+	// IntegrityDiffers is a source and target that differ without a
+	// recorded hash to tell which side changed.
+	IntegrityDiffers
+)
+
+func (i Integrity) String() string {
+	switch i {
+	case IntegrityOK:
+		return "ok"
+	case IntegrityTargetCorrupted:
+		return "target corrupted"
+	case IntegritySourceModified:
+		return "source modified since import"
+	case IntegrityNeverImported:
+		return "never imported"
+	case IntegrityDiffers:
+		return "differs"
+	default:
+		return "unknown"
+	}
+}
+
+// IntegrityCheck holds the three hashes of a file. An empty hash is a file
+// that is gone, or for Recorded an import that recorded none.
+type IntegrityCheck struct {
+	// Imported is whether a manifest or the journal lists the file as
+	// copied.
+	Imported bool
+	Recorded string
+	Source   string
+	Target   string
+}
+
+// Classify compares the three hashes. The recorded hash is the ground truth
+// when present, so a changed target is told apart from a changed source;
+// a target that changed wins over a source that changed too. Without it
+// only a difference between source and target can be reported.
+func (c IntegrityCheck) Classify() Integrity {
+	if !c.Imported {
+		return IntegrityNeverImported
+	}
+	if c.Recorded != "" {
+		if !SameHash(c.Target, c.Recorded) {
+			return IntegrityTargetCorrupted
+		}
+		if c.Source != "" && !SameHash(c.Source, c.Recorded) {
+			return IntegritySourceModified
+		}
+		return IntegrityOK
+	}
+	if c.Target == "" {
+		return IntegrityTargetCorrupted
+	}
+	if c.Source != "" && !SameHash(c.Source, c.Target) {
+		return IntegrityDiffers
+	}
+	return IntegrityOK
+}
+
+// SameHash compares two hashes in constant time, so comparisons take as
+// long for near misses as for hashes that differ early.
+func SameHash(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// IntegritySummary counts the checked files per class.
+type IntegritySummary map[Integrity]int
+
+// Add classifies c and counts it.
+func (s IntegritySummary) Add(c IntegrityCheck) Integrity {
+	class := c.Classify()
+	s[class]++
+	return class
+}
+
+// Mismatches counts the checked files that are not IntegrityOK.
+func (s IntegritySummary) Mismatches() int {
+	n := 0
+	for class, count := range s {
+		if class != IntegrityOK {
+			n += count
+		}
+	}
+	return n
+}
+
+// IntegrityFinding is a checked file that is not IntegrityOK. TargetPath
+// is empty for files that were never imported.
+type IntegrityFinding struct {
+	SourcePath string
+	TargetPath string
+	Class      Integrity
+}
+
+// IntegrityReport is the outcome of checking the files earlier runs
+// imported, see `phopy verify`.
+type IntegrityReport struct {
+	Summary  IntegritySummary
+	Findings []IntegrityFinding
+}
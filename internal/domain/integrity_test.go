@@ -0,0 +1,42 @@
+package domain
+
+import "testing"
+
+func TestIntegrityCheckClassify(t *testing.T) {
+	tests := []struct {
+		name  string
+		check IntegrityCheck
+		want  Integrity
+	}{
+		{"intact", IntegrityCheck{Imported: true, Recorded: "aa", Source: "aa", Target: "aa"}, IntegrityOK},
+		{"source gone", IntegrityCheck{Imported: true, Recorded: "aa", Target: "aa"}, IntegrityOK},
+		{"target bit rot", IntegrityCheck{Imported: true, Recorded: "aa", Source: "aa", Target: "ab"}, IntegrityTargetCorrupted},
+		{"target gone", IntegrityCheck{Imported: true, Recorded: "aa", Source: "aa"}, IntegrityTargetCorrupted},
+		{"both changed", IntegrityCheck{Imported: true, Recorded: "aa", Source: "bb", Target: "cc"}, IntegrityTargetCorrupted},
+		{"source edited", IntegrityCheck{Imported: true, Recorded: "aa", Source: "bb", Target: "aa"}, IntegritySourceModified},
+		{"never imported", IntegrityCheck{Source: "aa", Target: "aa"}, IntegrityNeverImported},
+		{"no recorded hash, same", IntegrityCheck{Imported: true, Source: "aa", Target: "aa"}, IntegrityOK},
+		{"no recorded hash, different", IntegrityCheck{Imported: true, Source: "aa", Target: "bb"}, IntegrityDiffers},
+		{"no recorded hash, target gone", IntegrityCheck{Imported: true, Source: "aa"}, IntegrityTargetCorrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.check.Classify(); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIntegritySummaryCountsPerClass(t *testing.T) {
+	summary := IntegritySummary{}
+	summary.Add(IntegrityCheck{Imported: true, Recorded: "aa", Target: "aa"})
+	summary.Add(IntegrityCheck{Imported: true, Recorded: "aa", Target: "ab"})
+	summary.Add(IntegrityCheck{Imported: true, Recorded: "aa", Target: "ac"})
+	if class := summary.Add(IntegrityCheck{Source: "aa"}); class != IntegrityNeverImported {
+		t.Fatalf("expected Add to return the class, got %v", class)
+	}
+	if summary[IntegrityOK] != 1 || summary[IntegrityTargetCorrupted] != 2 || summary[IntegrityNeverImported] != 1 {
+		t.Fatalf("unexpected summary: %v", summary)
+	}
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "strings"
+
+// junkFiles are the folder metadata files macOS and Windows leave on cards,
+// by upper-case name.
+var junkFiles = map[string]bool{
+	".DS_STORE":   true,
+	"THUMBS.DB":   true,
+	"DESKTOP.INI": true,
+}
+
+// IsJunkFile reports whether the file name is metadata an operating system
+// wrote next to the photos: .DS_Store, Thumbs.db, desktop.ini and the ._
+// AppleDouble files macOS writes for every file on FAT cards, which carry
+// the photo's extension, like ._DSC0001.ARW, but only a few KB of
+// attributes.
+func IsJunkFile(name string) bool {
+	return strings.HasPrefix(name, "._") || junkFiles[strings.ToUpper(name)]
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "testing"
+
+func TestIsJunkFile(t *testing.T) {
+	for _, name := range []string{".DS_Store", "._DSC0001.ARW", "._.Trashes", "Thumbs.db", "THUMBS.DB", "desktop.ini"} {
+		if !IsJunkFile(name) {
+			t.Errorf("expected %s to be junk", name)
+		}
+	}
+	for _, name := range []string{"DSC0001.ARW", "_DSC0001.ARW", ".hidden.ARW", "Thumbs.jpg"} {
+		if IsJunkFile(name) {
+			t.Errorf("did not expect %s to be junk", name)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Template tokens understood by layout and rename templates.
+var templateTokens = map[string]bool{
+	"yyyy":       true,
+	"mm":         true,
+	"dd":         true,
+	"date":       true,
+	"source_dir": true,
+	"name":       true,
+	"ext":        true,
+	"label":      true,
+}
+
+var dateTokens = map[string]bool{"yyyy": true, "mm": true, "dd": true, "date": true}
+
+var tokenPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// labelForbidden are characters that are unsafe in a file name on any of
+// the supported platforms.
+const labelForbidden = `/\:*?"<>|`
+
+// ValidateLabel reports labels that cannot be used as part of a file name.
+func ValidateLabel(label string) error {
+	if label == "" {
+		return nil
+	}
+	if label == "." || label == ".." || strings.TrimSpace(label) != label || strings.HasSuffix(label, ".") {
+		return fmt.Errorf("label %q cannot start or end with spaces or end with a dot", label)
+	}
+	if len(label) > 64 {
+		return fmt.Errorf("label %q is longer than 64 characters", label)
+	}
+	for _, r := range label {
+		if r < ' ' || strings.ContainsRune(labelForbidden, r) {
+			return fmt.Errorf("label %q contains %q, which is not allowed in file names", label, r)
+		}
+	}
+	return nil
+}
+
+// ValidateTemplate reports unknown tokens in a layout or rename template.
+func ValidateTemplate(tmpl string) error {
+	for _, match := range tokenPattern.FindAllStringSubmatch(tmpl, -1) {
+		if !templateTokens[match[1]] {
+			return fmt.Errorf("unknown template token {%s}", match[1])
+		}
+	}
+	return nil
+}
+
+// Layout decides where a file lands below the target directory.
+//
+// The target path is Dir/<source-relative dir>/Name. An empty Dir adds no
+// prefix, an empty Name keeps the source file name and Flatten drops the
+// source-relative directory. ExtCase decides the case of the target file's
+// extension and of the {ext} token. FixSniffedExt
+// replaces the extension of files whose type was detected from their
+// content. Label is rendered by the {label} token.
+type Layout struct {
+	Dir           string
+	Name          string
+	Flatten       bool
+	ExtCase       ExtCase
+	FixSniffedExt bool
+	Label         string
+	// Days decides the day of the date tokens, see DayPolicy.
+	Days DayPolicy
+}
+
+// ExtCase is the case of the extensions of target file names.
+type ExtCase int
+
+const (
+	// ExtCaseDefault keeps the extension of the source file name and
+	// renders {ext} as the canonical lowercase extension.
+	ExtCaseDefault ExtCase = iota
+	ExtCaseLower
+	ExtCaseUpper
+	// ExtCaseKeep keeps the extension of the source file name, {ext}
+	// included.
+	ExtCaseKeep
+)
+
+// Sets reports whether c sets the case of the extensions rather than
+// keeping that of the source.
+func (c ExtCase) Sets() bool {
+	return c == ExtCaseLower || c == ExtCaseUpper
+}
+
+// apply returns ext in case c.
+func (c ExtCase) apply(ext string) string {
+	switch c {
+	case ExtCaseLower:
+		return strings.ToLower(ext)
+	case ExtCaseUpper:
+		return strings.ToUpper(ext)
+	default:
+		return ext
+	}
+}
+
+// NeedsDate reports whether the target path depends on the capture date.
+func (l Layout) NeedsDate() bool {
+	for _, tmpl := range []string{l.Dir, l.Name} {
+		for _, match := range tokenPattern.FindAllStringSubmatch(tmpl, -1) {
+			if dateTokens[match[1]] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TargetRel returns the target path of meta relative to the target directory.
+func (l Layout) TargetRel(meta FileMeta) string {
+	values := templateValues(meta, l.ExtCase, l.Days)
+	values["label"] = l.Label
+
+	var parts []string
+	if l.Dir != "" {
+		parts = append(parts, renderTemplate(l.Dir, values))
+	}
+	if !l.Flatten {
+		parts = append(parts, filepath.Dir(meta.RelativePath))
+	}
+
+	name := meta.Name
+	if meta.Sniffed && l.FixSniffedExt {
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + meta.Ext
+	}
+	if l.Name != "" {
+		name = renderTemplate(l.Name, values)
+	}
+	ext := filepath.Ext(name)
+	parts = append(parts, strings.TrimSuffix(name, ext)+l.ExtCase.apply(ext))
+
+	return filepath.Join(parts...)
+}
+
+func templateValues(meta FileMeta, extCase ExtCase, days DayPolicy) map[string]string {
+	ext := meta.Ext
+	if extCase == ExtCaseKeep && !meta.Sniffed {
+		ext = filepath.Ext(meta.Name)
+	}
+	ext = extCase.apply(ext)
+	sourceDir := filepath.Base(filepath.Dir(meta.RelativePath))
+	if sourceDir == "." || sourceDir == string(filepath.Separator) {
+		sourceDir = ""
+	}
+	day := DayOf(meta.TakenAt, days)
+	return map[string]string{
+		"yyyy":       day.Format("2006"),
+		"mm":         day.Format("01"),
+		"dd":         day.Format("02"),
+		"date":       day.Format("2006-01-02"),
+		"source_dir": sourceDir,
+		"name":       strings.TrimSuffix(meta.Name, filepath.Ext(meta.Name)),
+		"ext":        strings.TrimPrefix(ext, "."),
+	}
+}
+
+// templateSeparators are dropped next to a token that renders empty, so an
+// unset {label} in "{date}_{label}" leaves no dangling "_".
+const templateSeparators = "_-. /"
+
+// renderTemplate replaces the tokens of tmpl. A token that renders empty
+// takes the separator before it along, or the one after it when it starts
+// a path segment.
+func renderTemplate(tmpl string, values map[string]string) string {
+	var b strings.Builder
+	skipNext := false
+	literal := func(text string) {
+		if skipNext && text != "" && strings.ContainsRune(templateSeparators, rune(text[0])) {
+			text = text[1:]
+		}
+		skipNext = false
+		b.WriteString(text)
+	}
+
+	last := 0
+	for _, loc := range tokenPattern.FindAllStringSubmatchIndex(tmpl, -1) {
+		literal(tmpl[last:loc[0]])
+		last = loc[1]
+
+		value := values[tmpl[loc[2]:loc[3]]]
+		if value != "" {
+			b.WriteString(value)
+			continue
+		}
+		out := b.String()
+		switch {
+		case out == "" || strings.HasSuffix(out, "/"):
+			skipNext = true
+		case strings.ContainsRune(templateSeparators, rune(out[len(out)-1])):
+			b.Reset()
+			b.WriteString(out[:len(out)-1])
+		}
+	}
+	literal(tmpl[last:])
+	return b.String()
+}
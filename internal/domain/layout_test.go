@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLayoutTargetRel(t *testing.T) {
+	takenAt := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	meta := NewFileMeta("/card/DCIM/100MSDCF/DSC0001.ARW", filepath.Join("DCIM", "100MSDCF", "DSC0001.ARW"), takenAt)
+
+	tests := []struct {
+		name   string
+		layout Layout
+		want   string
+	}{
+		{"default keeps source structure", Layout{}, filepath.Join("DCIM", "100MSDCF", "DSC0001.ARW")},
+		{"flatten drops structure", Layout{Flatten: true}, "DSC0001.ARW"},
+		{"dated and flattened", Layout{Dir: "{yyyy}/{date}", Flatten: true}, filepath.Join("2024", "2024-10-02", "DSC0001.ARW")},
+		{"source dir token", Layout{Dir: "{date}/{source_dir}", Flatten: true}, filepath.Join("2024-10-02", "100MSDCF", "DSC0001.ARW")},
+		{"rename template", Layout{Name: "{date}_{source_dir}_{name}.{ext}", Flatten: true}, "2024-10-02_100MSDCF_DSC0001.arw"},
+		{"rename keeping extension case", Layout{Name: "{name}.{ext}", Flatten: true, ExtCase: ExtCaseKeep}, "DSC0001.ARW"},
+		{"label in layout", Layout{Dir: "{date}_{label}", Flatten: true, Label: "iceland-day3"}, filepath.Join("2024-10-02_iceland-day3", "DSC0001.ARW")},
+		{"label in rename", Layout{Name: "{label}_{name}.{ext}", Flatten: true, Label: "smith-wedding"}, "smith-wedding_DSC0001.arw"},
+		{"empty label after separator", Layout{Dir: "{date}_{label}", Flatten: true}, filepath.Join("2024-10-02", "DSC0001.ARW")},
+		{"empty label starting a segment", Layout{Dir: "{yyyy}/{label}-{date}", Flatten: true}, filepath.Join("2024", "2024-10-02", "DSC0001.ARW")},
+		{"empty label as a segment", Layout{Dir: "{yyyy}/{label}/{date}", Flatten: true}, filepath.Join("2024", "2024-10-02", "DSC0001.ARW")},
+		{"empty label in rename", Layout{Name: "{date}_{label}_{name}.{ext}", Flatten: true}, "2024-10-02_DSC0001.arw"},
+		{"lowercase extension", Layout{Flatten: true, ExtCase: ExtCaseLower}, "DSC0001.arw"},
+		{"uppercase extension", Layout{Flatten: true, ExtCase: ExtCaseUpper}, "DSC0001.ARW"},
+		{"kept extension", Layout{Flatten: true, ExtCase: ExtCaseKeep}, "DSC0001.ARW"},
+		{"uppercase extension in rename", Layout{Name: "{name}.{ext}", Flatten: true, ExtCase: ExtCaseUpper}, "DSC0001.ARW"},
+		{"extension case leaves the name", Layout{Dir: "{date}", Name: "{source_dir}_{name}.{ext}", Flatten: true, ExtCase: ExtCaseLower}, filepath.Join("2024-10-02", "100MSDCF_DSC0001.arw")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.layout.TargetRel(meta); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLayoutNeedsDate(t *testing.T) {
+	if (Layout{Dir: "{source_dir}"}).NeedsDate() {
+		t.Fatalf("source_dir layout should not need a date")
+	}
+	if !(Layout{Name: "{yyyy}_{name}.{ext}"}).NeedsDate() {
+		t.Fatalf("rename with {yyyy} should need a date")
+	}
+}
+
+func TestValidateTemplateRejectsUnknownTokens(t *testing.T) {
+	if err := ValidateTemplate("{date}/{camera}"); err == nil {
+		t.Fatalf("expected an error for {camera}")
+	}
+	if err := ValidateTemplate("{yyyy}/{mm}-{dd}/{source_dir}"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateLabel(t *testing.T) {
+	for _, label := range []string{"", "smith-wedding", "iceland day 3", "2024.10"} {
+		if err := ValidateLabel(label); err != nil {
+			t.Fatalf("unexpected error for %q: %v", label, err)
+		}
+	}
+	for _, label := range []string{"a/b", `a\b`, "what?", "..", " padded", "trailing.", "tab\there", strings.Repeat("x", 65)} {
+		if err := ValidateLabel(label); err == nil {
+			t.Fatalf("expected an error for %q", label)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PathFilter selects the files of the source by their path relative to it
+// (--include and --exclude). A file is planned when it matches no exclude
+// and, if there are includes, at least one include; excludes win, so an
+// include can select a folder and an exclude remove a subfolder of it.
+//
+// A glob without a slash, like *.MP4 or 100MSDCF, matches the name of the
+// file or of any folder it is in. A glob with slashes, like DCIM/10?MSDCF,
+// matches the leading folders of the path, or the whole path. Either way
+// a glob that matches a folder matches everything below it. Matching
+// ignores case, like the file systems of memory cards.
+type PathFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// ParsePathGlobs checks and normalizes the globs given to --include or
+// --exclude, which take slashes as separators on every platform.
+func ParsePathGlobs(values []string) ([]string, error) {
+	var globs []string
+	for _, value := range values {
+		glob := strings.Trim(filepath.ToSlash(strings.TrimSpace(value)), "/")
+		if glob == "" {
+			continue
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("%q is not a valid glob", value)
+		}
+		globs = append(globs, strings.ToUpper(glob))
+	}
+	return globs, nil
+}
+
+// Selects reports whether the file at relPath, relative to the source, is
+// planned.
+func (f PathFilter) Selects(relPath string) bool {
+	parts := pathParts(relPath)
+	if matchesAny(f.Exclude, parts) {
+		return false
+	}
+	return len(f.Include) == 0 || matchesAny(f.Include, parts)
+}
+
+// SkipsDir reports whether no file below the folder at relPath, relative
+// to the source, can be planned, so the walk does not need to enter it:
+// an exclude matches the folder, or there are includes and none of them
+// can match anything below it.
+func (f PathFilter) SkipsDir(relPath string) bool {
+	parts := pathParts(relPath)
+	if len(parts) == 0 {
+		return false
+	}
+	if matchesAny(f.Exclude, parts) {
+		return true
+	}
+	if len(f.Include) == 0 {
+		return false
+	}
+	for _, glob := range f.Include {
+		if mayMatchBelow(glob, parts) {
+			return false
+		}
+	}
+	return true
+}
+
+func pathParts(relPath string) []string {
+	relPath = strings.Trim(filepath.ToSlash(relPath), "/")
+	if relPath == "" || relPath == "." {
+		return nil
+	}
+	return strings.Split(strings.ToUpper(relPath), "/")
+}
+
+// matchesAny reports whether one of globs matches the path of parts or a
+// folder it is in.
+func matchesAny(globs []string, parts []string) bool {
+	for _, glob := range globs {
+		if !strings.Contains(glob, "/") {
+			for _, part := range parts {
+				if ok, _ := path.Match(glob, part); ok {
+					return true
+				}
+			}
+			continue
+		}
+		segments := strings.Count(glob, "/") + 1
+		if segments <= len(parts) {
+			if ok, _ := path.Match(glob, strings.Join(parts[:segments], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mayMatchBelow reports whether glob matches the folder of parts or may
+// match something below it. A glob without a slash may match any name
+// further down.
+func mayMatchBelow(glob string, parts []string) bool {
+	if !strings.Contains(glob, "/") {
+		return true
+	}
+	segments := strings.Split(glob, "/")
+	for i, part := range parts {
+		if i == len(segments) {
+			// The glob matched the leading folders already
+			return true
+		}
+		if ok, _ := path.Match(segments[i], part); !ok {
+			return false
+		}
+	}
+	return true
+}
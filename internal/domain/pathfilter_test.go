@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePathGlobs(t *testing.T) {
+	globs, err := ParsePathGlobs([]string{" /DCIM/100msdcf/ ", "", "*.mp4"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(globs) != 2 || globs[0] != "DCIM/100MSDCF" || globs[1] != "*.MP4" {
+		t.Fatalf("unexpected globs %q", globs)
+	}
+	if _, err := ParsePathGlobs([]string{"DCIM/[100"}); err == nil {
+		t.Fatalf("expected an error for a malformed glob")
+	}
+}
+
+func TestPathFilterSelects(t *testing.T) {
+	filter := PathFilter{
+		Include: []string{"DCIM/100MSDCF", "DCIM/101MSDCF"},
+		Exclude: []string{"DCIM/100MSDCF/TEST", "*.MP4"},
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"DCIM/100MSDCF/DSC0001.ARW", true},
+		{"dcim/101msdcf/dsc0002.arw", true},
+		{"DCIM/102MSDCF/DSC0003.ARW", false},
+		{"DCIM/100MSDCFX/DSC0004.ARW", false},
+		{"MISC/DSC0005.ARW", false},
+		// Excludes win over includes, also for a subfolder of an include
+		{"DCIM/100MSDCF/TEST/DSC0006.ARW", false},
+		{"DCIM/101MSDCF/C0001.MP4", false},
+	}
+	for _, tt := range tests {
+		if got := filter.Selects(filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("Selects(%q) = %t, want %t", tt.path, got, tt.want)
+		}
+	}
+
+	if !(PathFilter{}).Selects("DCIM/100MSDCF/DSC0001.ARW") {
+		t.Fatalf("expected an empty filter to select every file")
+	}
+	if (PathFilter{Exclude: []string{"100MSDCF"}}).Selects("DCIM/100MSDCF/DSC0001.ARW") {
+		t.Fatalf("expected a glob without a slash to match a folder at any depth")
+	}
+}
+
+func TestPathFilterSkipsDir(t *testing.T) {
+	tests := []struct {
+		filter PathFilter
+		dir    string
+		want   bool
+	}{
+		// Includes with slashes prune the folders they cannot lead to
+		{PathFilter{Include: []string{"DCIM/10?MSDCF"}}, "DCIM", false},
+		{PathFilter{Include: []string{"DCIM/10?MSDCF"}}, "DCIM/100MSDCF", false},
+		{PathFilter{Include: []string{"DCIM/10?MSDCF"}}, "DCIM/100MSDCF/SUB", false},
+		{PathFilter{Include: []string{"DCIM/10?MSDCF"}}, "DCIM/200MSDCF", true},
+		{PathFilter{Include: []string{"DCIM/10?MSDCF"}}, "PRIVATE", true},
+		// A name may come further down, so it prunes nothing
+		{PathFilter{Include: []string{"*.ARW"}}, "PRIVATE", false},
+		// Excludes prune the folders they match
+		{PathFilter{Exclude: []string{"PRIVATE"}}, "PRIVATE", true},
+		{PathFilter{Exclude: []string{"PRIVATE"}}, "DCIM", false},
+		{PathFilter{Include: []string{"DCIM"}, Exclude: []string{"DCIM/100MSDCF/TEST"}}, "DCIM/100MSDCF/TEST", true},
+		// The source itself is always walked
+		{PathFilter{Include: []string{"DCIM"}}, ".", false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.SkipsDir(filepath.FromSlash(tt.dir)); got != tt.want {
+			t.Errorf("%+v SkipsDir(%q) = %t, want %t", tt.filter, tt.dir, got, tt.want)
+		}
+	}
+}
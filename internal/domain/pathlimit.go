@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// LengthUnit is what a file system counts the length of names and paths in.
+type LengthUnit int
+
+const (
+	LengthBytes LengthUnit = iota
+	LengthUTF16
+)
+
+func (u LengthUnit) String() string {
+	if u == LengthUTF16 {
+		return "UTF-16 units"
+	}
+	return "bytes"
+}
+
+// Len is the length of s in u.
+func (u LengthUnit) Len(s string) int {
+	if u == LengthUTF16 {
+		return len(utf16.Encode([]rune(s)))
+	}
+	return len(s)
+}
+
+// PathLimits are the longest file name and path a target takes, like
+// 255-byte names on ext4 or 255 UTF-16 units on NTFS. Names are limited by
+// the file system, paths by the operating system. Zero limits are not
+// checked, so the zero value accepts every path.
+type PathLimits struct {
+	// FileSystem names the file system the limits are of, like "ext4";
+	// empty when it was not recognized.
+	FileSystem string
+	MaxName    int
+	NameUnit   LengthUnit
+	MaxPath    int
+	PathUnit   LengthUnit
+}
+
+// PathViolation is a target path beyond PathLimits. Name is set when one
+// of its elements is too long rather than the whole path.
+type PathViolation struct {
+	Path   string
+	Name   string
+	Length int
+	Limit  int
+	Unit   LengthUnit
+}
+
+// Excess is by how much the violation exceeds its limit.
+func (v PathViolation) Excess() int {
+	return v.Length - v.Limit
+}
+
+func (v PathViolation) String() string {
+	what := "path"
+	if v.Name == filepath.Base(v.Path) {
+		what = "name"
+	} else if v.Name != "" {
+		what = fmt.Sprintf("folder %q", v.Name)
+	}
+	return fmt.Sprintf("%s: %s is %d %s, %d over the limit of %d", v.Path, what, v.Length, v.Unit, v.Excess(), v.Limit)
+}
+
+// Check returns the violation of path, a target below targetDir, if any.
+// Only the elements below targetDir are checked against MaxName, since the
+// target itself already exists; a too long name is reported before a too
+// long path.
+func (l PathLimits) Check(targetDir, path string) (PathViolation, bool) {
+	if l.MaxName > 0 {
+		rel, err := filepath.Rel(targetDir, path)
+		if err != nil {
+			rel = filepath.Base(path)
+		}
+		for _, name := range strings.Split(rel, string(filepath.Separator)) {
+			if n := l.NameUnit.Len(name); n > l.MaxName {
+				return PathViolation{Path: path, Name: name, Length: n, Limit: l.MaxName, Unit: l.NameUnit}, true
+			}
+		}
+	}
+	if l.MaxPath > 0 {
+		if n := l.PathUnit.Len(path); n > l.MaxPath {
+			return PathViolation{Path: path, Length: n, Limit: l.MaxPath, Unit: l.PathUnit}, true
+		}
+	}
+	return PathViolation{}, false
+}
+
+// maxListedViolations is how many violations PathLimitError lists.
+const maxListedViolations = 20
+
+// PathLimitError fails a plan whose target paths do not fit the target
+// file system, which would truncate them or refuse to create them.
+type PathLimitError struct {
+	Limits     PathLimits
+	Violations []PathViolation
+}
+
+func (e *PathLimitError) Error() string {
+	var b strings.Builder
+	fileSystem := "the target file system"
+	if e.Limits.FileSystem != "" {
+		fileSystem = "the " + e.Limits.FileSystem + " file system of the target"
+	}
+	fmt.Fprintf(&b, "%d target paths are too long for %s:", len(e.Violations), fileSystem)
+	for i, violation := range e.Violations {
+		if i == maxListedViolations {
+			fmt.Fprintf(&b, "\n  and %d more", len(e.Violations)-i)
+			break
+		}
+		b.WriteString("\n  " + violation.String())
+	}
+	b.WriteString("\nuse --flatten or a shorter --layout or --rename")
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathLimitsCountNamesInTheirUnit(t *testing.T) {
+	target := filepath.FromSlash("/target")
+	// 100 characters of 3 UTF-8 bytes but one UTF-16 unit each
+	name := strings.Repeat("写", 100) + ".jpg"
+	path := filepath.Join(target, "2024-10-02", name)
+
+	ntfs := PathLimits{MaxName: 255, NameUnit: LengthUTF16}
+	if v, ok := ntfs.Check(target, path); ok {
+		t.Fatalf("expected 104 UTF-16 units to fit NTFS, got %v", v)
+	}
+	ext4 := PathLimits{MaxName: 255, NameUnit: LengthBytes}
+	v, ok := ext4.Check(target, path)
+	if !ok || v.Length != 304 || v.Excess() != 49 {
+		t.Fatalf("expected 304 bytes to exceed ext4 by 49, got %+v", v)
+	}
+	if want := path + ": name is 304 bytes, 49 over the limit of 255"; v.String() != want {
+		t.Fatalf("expected %q, got %q", want, v.String())
+	}
+
+	folder := filepath.Join(target, strings.Repeat("d", 300), "DSC0001.ARW")
+	if v, _ := ext4.Check(target, folder); !strings.Contains(v.String(), `folder "ddd`) {
+		t.Fatalf("expected the folder to be named, got %q", v.String())
+	}
+	// Only the part below the target is checked for names
+	long := filepath.Join(string(filepath.Separator)+strings.Repeat("t", 300), "DSC0001.ARW")
+	if v, ok := ext4.Check(filepath.Dir(long), long); ok {
+		t.Fatalf("expected the target itself not to be checked, got %v", v)
+	}
+	if v, ok := (PathLimits{}).Check(target, folder); ok {
+		t.Fatalf("expected the zero limits to accept every path, got %v", v)
+	}
+}
+
+func TestPathLimitErrorListsTheFirstViolations(t *testing.T) {
+	err := &PathLimitError{}
+	for range maxListedViolations + 3 {
+		err.Violations = append(err.Violations, PathViolation{Path: "/target/x", Length: 300, Limit: 255})
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "23 target paths are too long for the target file system:") {
+		t.Fatalf("unexpected summary:\n%s", msg)
+	}
+	if strings.Count(msg, "/target/x") != maxListedViolations || !strings.Contains(msg, "\n  and 3 more\n") {
+		t.Fatalf("expected %d listed violations and the rest counted:\n%s", maxListedViolations, msg)
+	}
+}
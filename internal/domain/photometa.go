@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidCaptureDate marks an EXIF capture date that is present but
+// obviously wrong, like "0000:00:00 00:00:00" written by broken firmware.
+var ErrInvalidCaptureDate = errors.New("invalid exif capture date")
+
+// PhotoMeta is the metadata extracted from a photo in a single EXIF decode.
+// Only TakenAt is guaranteed to be set; all other fields are optional and
+// left at their zero value when the file does not carry them.
+type PhotoMeta struct {
+	TakenAt time.Time
+	// DateTag is the tag TakenAt was read from.
+	DateTag DateTag
+	SubSec  time.Duration
+	Make    string
+	Model   string
+	GPS     *GPS
+	// Offset is the camera's UTC offset at capture (EXIF OffsetTimeOriginal),
+	// nil when the camera did not record one.
+	Offset *time.Duration
+	// BodySerial (EXIF BodySerialNumber) and ImageNumber identify a capture
+	// of cameras that record them; see CaptureKey.
+	BodySerial  string
+	ImageNumber uint32
+}
+
+// GPS holds a decimal-degree coordinate.
+type GPS struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// CaptureKey identifies the capture cheaply: two files of the same kind
+// with the same key are the same shot of the same camera body. It reports
+// false when the camera did not record its serial or an image number.
+func (m PhotoMeta) CaptureKey() (string, bool) {
+	if m.BodySerial == "" || m.ImageNumber == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("%s|%s|%s|%d", m.Make, m.Model, m.BodySerial, m.ImageNumber), true
+}
+
+// CaptureInstant returns the moment of capture by reading TakenAt as the
+// camera's wall clock at Offset. The boolean is false without an offset.
+func (m PhotoMeta) CaptureInstant() (time.Time, bool) {
+	if m.Offset == nil {
+		return time.Time{}, false
+	}
+	t := m.TakenAt
+	zone := time.FixedZone("", int(m.Offset.Seconds()))
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), zone), true
+}
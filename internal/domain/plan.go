@@ -1,23 +1,267 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
 
 type CopyItem struct {
 	FileMeta   FileMeta
 	TargetPath string
+	// Exists marks an item whose target existed when planning. These
+	// items are also listed in OverrideItems, or in AutoOverrideItems when
+	// AutoOverride is set.
+	Exists bool
+	// CompanionOf is the source path of the item a companion file, like a
+	// clip's XML metadata, is copied alongside; empty for other items.
+	CompanionOf string
+	// AutoOverride marks an item whose existing target is overwritten
+	// without asking, because it is stale (--auto-override-stale, see
+	// IsStaleTarget).
+	AutoOverride bool
 }
 
 type CopyPlan struct {
-	Items               []CopyItem
-	OverrideItems       []CopyItem
-	SkippedJPEGs        int
-	SkippedRAWsDate     int
-	SkippedRAWsDupl     int
-	RangeStart          *time.Time
-	RangeEnd            *time.Time
-	RawCount            int
-	JpegCount           int
-	RawOverrides        int
-	JpegOverrides       int
-	Warnings            []string
+	// Items holds the dated files ordered by capture time, then file name,
+	// relative path and source path, each directly followed by its
+	// companions, and then the misc files by source path. OverrideItems
+	// keeps that order. Planning the same files always yields the same
+	// order, whatever order they were scanned in, so plan files, manifests
+	// and numbered target names are reproducible.
+	Items           []CopyItem
+	OverrideItems   []CopyItem
+	SkippedJPEGs    int
+	SkippedRAWsDate int
+	SkippedRAWsDupl int
+	RangeStart      *time.Time
+	RangeEnd        *time.Time
+	RawCount        int
+	JpegCount       int
+	RawOverrides    int
+	JpegOverrides   int
+	SniffedFiles    int // files classified by content rather than extension
+	ZoneBoundary    int // files whose date differs in the camera's and the local zone
+	InvalidDates    int // files whose EXIF date was implausible, dated by the filesystem instead
+	OutsideRange    RangeExclusions
+	ExtensionCounts map[string]int // keyed by canonical lowercase extension
+	Ratings         map[int]int    // planned files per sidecar star rating
+	Pairings        []JPEGPairing  // JPEGs skipped for their RAW, by path
+	Duplicates      int            // files skipped as the same capture as another source file
+	JunkFiles       int            // operating system metadata files skipped, see IsJunkFile
+	ProxyClips      int            // low-resolution video proxies skipped, see IsVideoProxy
+	Leftovers       []Leftover     // discovered files left out of the plan, by source path
+	Warnings        []Warning
+	// SinceLastImport compares the plan to the target's journal; nil
+	// without earlier imports.
+	SinceLastImport *ImportDiff
+	// ApproximateDates marks a plan made without reading EXIF
+	// (--fast-plan): files are dated by their modification time.
+	ApproximateDates bool
+	// TruncatedWarnings counts the warnings beyond the planner's cap that
+	// were left out of Warnings; they are in WarningLog when it is set.
+	TruncatedWarnings int
+	WarningLog        string
+	// TruncatedReviewable holds the truncated warnings the review can act
+	// on, without their text, so its actions reach their files too.
+	TruncatedReviewable []Warning `json:",omitempty"`
+	// TruncatedItems and TruncatedOverrides count the items and override
+	// items left out of a preview of the plan, see Preview.
+	TruncatedItems     int
+	TruncatedOverrides int
+	// Declined is what Effective returns for a preview whose override
+	// items were cut, decided from all of them.
+	Declined *CopyPlan `json:"-"`
+	// Sample holds the items at the positions of the full plan a preview
+	// was asked to keep, in order, so a truncated preview still lists
+	// files from across the plan.
+	Sample []CopyItem
+	// SkippedByMtimeShortcut and SkippedByCaptureDate split SkippedRAWsDate
+	// by how the date filter decided: by a modification time before the
+	// range, without reading EXIF, or by the capture date itself.
+	SkippedByMtimeShortcut int
+	SkippedByCaptureDate   int
+	// Reviewed records the actions chosen in the warnings review, see
+	// Review.
+	Reviewed []ReviewDecision
+	// SourceVolume identifies the volume of the source at plan time; zero
+	// when it could not be probed.
+	SourceVolume Volume
+	// Scan tells what the planner examined to make the plan.
+	Scan ScanStats
+	// Partial marks a plan whose scan was stopped early: Unscanned files
+	// were never inspected and are left out, whatever their date.
+	Partial   bool
+	Unscanned int
+	// Folders sums up the plan by the folders of the source, see
+	// FolderStatsOf.
+	Folders []FolderStats
+	// AutoOverrideItems lists the items whose stale target is overwritten
+	// without asking, in the order of Items. They are not part of
+	// OverrideItems and the override counts, which describe what the user
+	// is asked about.
+	AutoOverrideItems []CopyItem
+}
+
+// ScanStats describes the scan a plan was made from; zero for plans saved
+// before it was recorded.
+type ScanStats struct {
+	// Files is every file the walk discovered, skipped ones included.
+	Files int `json:"files"`
+	// Duration is the time from the start of the walk to the finished plan.
+	Duration time.Duration `json:"duration_ns"`
+	// Warnings counts the plan warnings, those beyond the cap included.
+	Warnings int `json:"warnings"`
+}
+
+// UniqueTargetPath keeps planned items from sharing a target path (e.g. when
+// flattening several card folders) by appending a counter to the file name.
+// The first item in plan order keeps the name, see CopyPlan.Items.
+func UniqueTargetPath(path string, used map[string]bool) string {
+	candidate := path
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// DefaultWarningCap is how many warnings a plan keeps in memory.
+const DefaultWarningCap = 1000
+
+// DefaultPreviewItems is how many planned items the TUI receives.
+const DefaultPreviewItems = 1000
+
+// Preview returns the plan with at most max items and override items for
+// display, counting the rest in TruncatedItems and TruncatedOverrides, and
+// the items at the positions sample in Sample. Previews are not for
+// copying.
+func (p CopyPlan) Preview(max int, sample []int) CopyPlan {
+	if max <= 0 || (len(p.Items) <= max && len(p.OverrideItems) <= max) {
+		return p
+	}
+	preview := p.truncate(max, sample)
+	if len(p.OverrideItems) > max {
+		// Effective needs every override item
+		declined := p.Effective(false).truncate(max, nil)
+		preview.Declined = &declined
+	}
+	return preview
+}
+
+// truncate cuts Items and OverrideItems to max for Preview.
+func (p CopyPlan) truncate(max int, sample []int) CopyPlan {
+	if len(p.Items) > max {
+		p.Sample = make([]CopyItem, 0, len(sample))
+		for _, i := range sample {
+			if i >= 0 && i < len(p.Items) {
+				p.Sample = append(p.Sample, p.Items[i])
+			}
+		}
+		p.TruncatedItems += len(p.Items) - max
+		p.Items = head(p.Items, max)
+	}
+	if len(p.OverrideItems) > max {
+		p.TruncatedOverrides += len(p.OverrideItems) - max
+		p.OverrideItems = head(p.OverrideItems, max)
+	}
+	return p
+}
+
+// head returns a copy of the first n items, so a preview does not keep
+// the full list alive.
+func head(items []CopyItem, n int) []CopyItem {
+	kept := make([]CopyItem, n)
+	copy(kept, items)
+	return kept
+}
+
+// ItemCount is the number of planned items, including those left out of a
+// preview.
+func (p CopyPlan) ItemCount() int {
+	return len(p.Items) + p.TruncatedItems
+}
+
+// OverrideCount is the number of items whose target exists, including
+// those left out of a preview.
+func (p CopyPlan) OverrideCount() int {
+	return len(p.OverrideItems) + p.TruncatedOverrides
+}
+
+// JPEGPairing records a JPEG left out of the plan because a RAW with the
+// same base name was found.
+type JPEGPairing struct {
+	JPEG string // source path of the skipped JPEG
+	RAW  string // source path of the RAW it deferred to
+}
+
+// Leftover is a discovered source file that is not part of the plan.
+type Leftover struct {
+	SourcePath string
+	Reason     string // e.g. "JPEG with RAW" or "outside date range"
+	// Of is the source path of the file a duplicate capture repeats;
+	// empty for other reasons.
+	Of string `json:",omitempty"`
+}
+
+// ImportedFile is a source file an earlier run copied into the target.
+type ImportedFile struct {
+	SourcePath string
+	Size       int64
+	TakenAt    time.Time
+	ImportedAt time.Time
+}
+
+// Changed reports whether the source file differs from when it was
+// imported, e.g. a JPEG edited on the card since.
+func (f ImportedFile) Changed(meta FileMeta) bool {
+	return f.Size != meta.Size || !f.TakenAt.Equal(meta.TakenAt)
+}
+
+// ImportDiff is what changed on the source since the last import into the
+// target.
+type ImportDiff struct {
+	LastImport time.Time
+	New        int // planned files no earlier run imported
+	Unchanged  int // planned files imported before, unchanged since
+	Modified   int // planned files imported before that changed since
+}
+
+// RangeExclusions aggregates the files skipped only because their date fell
+// outside --from/--until, so users can tell whether the window clipped
+// anything interesting.
+type RangeExclusions struct {
+	Count    int
+	Earliest time.Time
+	Latest   time.Time
+}
+
+// Add records one excluded file dated at t.
+func (r *RangeExclusions) Add(t time.Time) {
+	if r.Count == 0 || t.Before(r.Earliest) {
+		r.Earliest = t
+	}
+	if r.Count == 0 || t.After(r.Latest) {
+		r.Latest = t
+	}
+	r.Count++
+}
+
+// TotalBytes is the size of all planned items.
+func (p CopyPlan) TotalBytes() int64 {
+	var total int64
+	for _, item := range p.Items {
+		total += item.FileMeta.Size
+	}
+	return total
+}
+
+// Skipped is the number of files left out of the plan, as itemized by the
+// summary: JPEGs with a RAW, RAWs skipped by the date filter or as
+// duplicates, and duplicate captures.
+func (p CopyPlan) Skipped() int {
+	return p.SkippedJPEGs + p.SkippedRAWsDate + p.SkippedRAWsDupl + p.Duplicates
 }
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPreviewBoundsItems(t *testing.T) {
+	const files = 300_000
+	plan := CopyPlan{Items: make([]CopyItem, files)}
+	for i := range plan.Items {
+		plan.Items[i].TargetPath = "/target/file"
+	}
+
+	preview := plan.Preview(DefaultPreviewItems, nil)
+	if len(preview.Items) != DefaultPreviewItems || cap(preview.Items) != DefaultPreviewItems {
+		t.Fatalf("expected %d items in memory, got %d (capacity %d)", DefaultPreviewItems, len(preview.Items), cap(preview.Items))
+	}
+	if preview.TruncatedItems != files-DefaultPreviewItems || preview.ItemCount() != files {
+		t.Fatalf("expected %d truncated of %d, got %d of %d", files-DefaultPreviewItems, files, preview.TruncatedItems, preview.ItemCount())
+	}
+	if len(plan.Items) != files {
+		t.Fatalf("expected the plan itself to keep every item")
+	}
+	if small := (CopyPlan{Items: make([]CopyItem, 3)}).Preview(DefaultPreviewItems, nil); len(small.Items) != 3 || small.TruncatedItems != 0 {
+		t.Fatalf("expected a small plan to stay whole, got %+v", small)
+	}
+
+	plan.Items[files-1].TargetPath = "/target/last"
+	if sampled := plan.Preview(DefaultPreviewItems, []int{0, files - 1}); len(sampled.Sample) != 2 || sampled.Sample[1].TargetPath != "/target/last" {
+		t.Fatalf("expected the sample to keep the last item, got %+v", sampled.Sample)
+	}
+}
+
+func TestPreviewBoundsOverridesAndKeepsTheDeclinedPlan(t *testing.T) {
+	const files = 5
+	plan := CopyPlan{Items: make([]CopyItem, files), RawCount: files}
+	for i := range plan.Items {
+		plan.Items[i].FileMeta = FileMeta{Name: fmt.Sprintf("DSC%04d.ARW", i), IsRAW: true}
+		plan.Items[i].Exists = i > 0
+	}
+	plan.OverrideItems = plan.Items[1:]
+
+	preview := plan.Preview(2, nil)
+	if len(preview.OverrideItems) != 2 || preview.TruncatedOverrides != 2 || preview.OverrideCount() != 4 {
+		t.Fatalf("expected 2 of 4 override items, got %d of %d", len(preview.OverrideItems), preview.OverrideCount())
+	}
+	declined := preview.Effective(false)
+	if declined.ItemCount() != 1 || declined.RawCount != 1 || len(declined.Leftovers) != 4 || declined.OverrideCount() != 4 {
+		t.Fatalf("expected the declined plan of every override, got %d items, %d RAW, %d leftovers, %d overrides",
+			declined.ItemCount(), declined.RawCount, len(declined.Leftovers), declined.OverrideCount())
+	}
+	if confirmed := preview.Effective(true); confirmed.ItemCount() != files {
+		t.Fatalf("expected confirmed overrides to keep the preview, got %d items", confirmed.ItemCount())
+	}
+}
@@ -0,0 +1,82 @@
+package domain
+
+// PlanDiff is how a plan differs from an earlier one of the same source,
+// e.g. after changing the layout. Files are matched by source path.
+type PlanDiff struct {
+	// Moved lists the files planned by both whose target changed, in the
+	// order of the new plan.
+	Moved []MovedItem `json:"moved"`
+	// OnlyOld and OnlyNew are the source paths of the files only one of
+	// the plans copies, in the order of that plan.
+	OnlyOld []string `json:"only_old"`
+	OnlyNew []string `json:"only_new"`
+	// Counts lists the summary counts that changed.
+	Counts []CountDelta `json:"counts"`
+}
+
+// MovedItem is a file whose target differs between two plans.
+type MovedItem struct {
+	SourcePath string `json:"source_path"`
+	OldTarget  string `json:"old_target"`
+	NewTarget  string `json:"new_target"`
+}
+
+// CountDelta is a summary count of two plans, named like in the summary.
+type CountDelta struct {
+	Name string `json:"name"`
+	Old  int    `json:"old"`
+	New  int    `json:"new"`
+}
+
+// DiffPlans compares plan to old. It only looks at what plan files
+// record, so it works for plans read back from them; those must have the
+// plan file version of this build, see planfile.Read.
+func DiffPlans(old, plan CopyPlan) PlanDiff {
+	diff := PlanDiff{Moved: []MovedItem{}, OnlyOld: []string{}, OnlyNew: []string{}, Counts: []CountDelta{}}
+
+	oldTargets := make(map[string]string, len(old.Items))
+	for _, item := range old.Items {
+		oldTargets[item.FileMeta.SourcePath] = item.TargetPath
+	}
+	planned := make(map[string]bool, len(plan.Items))
+	for _, item := range plan.Items {
+		source := item.FileMeta.SourcePath
+		planned[source] = true
+		target, ok := oldTargets[source]
+		if !ok {
+			diff.OnlyNew = append(diff.OnlyNew, source)
+		} else if target != item.TargetPath {
+			diff.Moved = append(diff.Moved, MovedItem{SourcePath: source, OldTarget: target, NewTarget: item.TargetPath})
+		}
+	}
+	for _, item := range old.Items {
+		if !planned[item.FileMeta.SourcePath] {
+			diff.OnlyOld = append(diff.OnlyOld, item.FileMeta.SourcePath)
+		}
+	}
+
+	for _, count := range []CountDelta{
+		{"files", len(old.Items), len(plan.Items)},
+		{"RAW files", old.RawCount, plan.RawCount},
+		{"JPEG files", old.JpegCount, plan.JpegCount},
+		{"overrides", len(old.OverrideItems), len(plan.OverrideItems)},
+		{"skipped JPEGs", old.SkippedJPEGs, plan.SkippedJPEGs},
+		{"skipped RAWs (date)", old.SkippedRAWsDate, plan.SkippedRAWsDate},
+		{"skipped RAWs (duplicate)", old.SkippedRAWsDupl, plan.SkippedRAWsDupl},
+		{"duplicate captures", old.Duplicates, plan.Duplicates},
+		{"junk files", old.JunkFiles, plan.JunkFiles},
+		{"proxy clips", old.ProxyClips, plan.ProxyClips},
+		{"warnings", len(old.Warnings) + old.TruncatedWarnings, len(plan.Warnings) + plan.TruncatedWarnings},
+	} {
+		if count.Old != count.New {
+			diff.Counts = append(diff.Counts, count)
+		}
+	}
+	return diff
+}
+
+// Empty reports whether the plans copy the same files to the same targets
+// with the same counts.
+func (d PlanDiff) Empty() bool {
+	return len(d.Moved) == 0 && len(d.OnlyOld) == 0 && len(d.OnlyNew) == 0 && len(d.Counts) == 0
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// proxyExtensions are the containers cameras record proxies in, by
+// upper-case extension.
+var proxyExtensions = map[string]bool{
+	".MP4": true,
+	".MOV": true,
+	".MXF": true,
+}
+
+// proxyDirs are the folders cameras keep the proxies of their clips in, by
+// upper-case name: Sony's PRIVATE/M4ROOT/SUB and XDROOT/Sub, and the PROXY
+// folders of Panasonic P2 and AVCCAM and Canon XF cards.
+var proxyDirs = map[string]bool{
+	"SUB":   true,
+	"PROXY": true,
+}
+
+// IsVideoProxy reports whether the video at relPath, relative to the
+// source, is a low-resolution proxy a camera recorded alongside a clip: one
+// in a SUB or PROXY folder, like PRIVATE/M4ROOT/SUB/C0001S03.MP4, or one
+// named with a _proxy suffix, like A001_proxy.mov. Other files are never
+// proxies.
+func IsVideoProxy(relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	ext := filepath.Ext(relPath)
+	if !proxyExtensions[strings.ToUpper(ext)] {
+		return false
+	}
+	dirs := strings.Split(relPath, "/")
+	name := dirs[len(dirs)-1]
+	if strings.HasSuffix(strings.ToUpper(strings.TrimSuffix(name, ext)), "_PROXY") {
+		return true
+	}
+	for _, dir := range dirs[:len(dirs)-1] {
+		if proxyDirs[strings.ToUpper(dir)] {
+			return true
+		}
+	}
+	return false
+}
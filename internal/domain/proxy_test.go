@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsVideoProxy(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		// Sony XAVC S: clips in CLIP, their proxies in SUB
+		{"PRIVATE/M4ROOT/CLIP/C0001.MP4", false},
+		{"PRIVATE/M4ROOT/CLIP/C0001M01.XML", false},
+		{"PRIVATE/M4ROOT/SUB/C0001S03.MP4", true},
+		{"PRIVATE/M4ROOT/THMBNL/C0001T01.JPG", false},
+		// Sony XDCAM and XAVC on professional cards
+		{"XDROOT/Clip/C0001.MXF", false},
+		{"XDROOT/Sub/C0001S01.MXF", true},
+		// Panasonic P2 and AVCCAM
+		{"CONTENTS/VIDEO/0001AB.MXF", false},
+		{"CONTENTS/PROXY/0001AB.MP4", true},
+		{"PRIVATE/PANA_GRP/001RAQAM/P1000001.MP4", false},
+		// Proxies named after their clip
+		{"DCIM/100GOPRO/A001_proxy.mov", true},
+		{"DCIM/100GOPRO/A001_PROXY.MP4", true},
+		{"DCIM/100GOPRO/proxy.MP4", false},
+		// Only videos are proxies
+		{"PRIVATE/M4ROOT/SUB/C0001S03.XML", false},
+		{"DCIM/SUB/DSC0001.ARW", false},
+		{"DCIM/100MSDCF/DSC0001_proxy.JPG", false},
+		// A folder only counts when it holds the file
+		{"SUBURBS/C0001.MP4", false},
+	}
+	for _, tt := range tests {
+		if got := IsVideoProxy(filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("IsVideoProxy(%q) = %t, want %t", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package domain
+
+import "path/filepath"
+
+// ItemStatus is the outcome of a single plan item during execution.
+type ItemStatus int
+
+const (
+	ItemCopied ItemStatus = iota
+	ItemSkippedOverride
+	ItemFailed
+	ItemCancelled
+	// ItemSkippedLocked is a source another process still held open
+	// after the retry at the end of the run.
+	ItemSkippedLocked
+	// ItemSkippedChanged is a source that changed after planning, skipped
+	// with SourceChangeSkip.
+	ItemSkippedChanged
+)
+
+func (s ItemStatus) String() string {
+	switch s {
+	case ItemCopied:
+		return "copied"
+	case ItemSkippedOverride:
+		return "skipped-override"
+	case ItemFailed:
+		return "failed"
+	case ItemCancelled:
+		return "cancelled"
+	case ItemSkippedLocked:
+		return "skipped-locked"
+	case ItemSkippedChanged:
+		return "skipped-changed"
+	default:
+		return "unknown"
+	}
+}
+
+type ItemResult struct {
+	Item   CopyItem
+	Status ItemStatus
+	Err    error
+	// Linked marks a copied item that was hard linked to an identical file
+	// already in the target instead of written again.
+	Linked bool
+	// SourceChanged marks a copied item whose source changed after
+	// planning. Item.FileMeta holds the size and modification time of the
+	// version that was copied.
+	SourceChanged bool
+	// Hash is the content hash of the source as it was read for the copy,
+	// made with the executor's Hash algorithm; empty without one.
+	Hash string
+}
+
+// ExecutionResult records what actually happened to every plan item. Final
+// reporting should be rendered from it rather than from the plan counts.
+type ExecutionResult struct {
+	Items            []ItemResult
+	Copied           int
+	RawCopied        int
+	JpegCopied       int
+	SkippedOverrides int
+	Failed           int
+	Cancelled        int
+	// BytesCopied counts the bytes written, which linked items are not.
+	BytesCopied   int64
+	BytesPlanned  int64
+	SkippedLocked int
+	// Linked counts the copied items that were hard linked, see
+	// RecordLinked.
+	Linked int
+	// SourceChanged counts the copied items whose source changed after
+	// planning, see MarkSourceChanged, and SkippedChanged those skipped
+	// for it.
+	SourceChanged  int
+	SkippedChanged int
+	// Integrity counts the verified copies per class, nil without
+	// verification (see Executor.Verify in internal/app). Copies that are not IntegrityOK failed.
+	Integrity IntegritySummary
+	// RunID identifies the run that produced the result, see
+	// internal/runid; empty for results built outside a run.
+	RunID string
+}
+
+// Record appends the outcome of item and updates the aggregate counters.
+func (r *ExecutionResult) Record(item CopyItem, status ItemStatus, err error) {
+	r.Items = append(r.Items, ItemResult{Item: item, Status: status, Err: err})
+	r.BytesPlanned += item.FileMeta.Size
+
+	switch status {
+	case ItemCopied:
+		r.Copied++
+		r.BytesCopied += item.FileMeta.Size
+		if item.FileMeta.IsRAW {
+			r.RawCopied++
+		} else if item.FileMeta.IsJPEG {
+			r.JpegCopied++
+		}
+	case ItemSkippedOverride:
+		r.SkippedOverrides++
+	case ItemFailed:
+		r.Failed++
+	case ItemCancelled:
+		r.Cancelled++
+	case ItemSkippedLocked:
+		r.SkippedLocked++
+	case ItemSkippedChanged:
+		r.SkippedChanged++
+	}
+}
+
+// MarkSourceChanged flags the item recorded last as copied in the version
+// its source had when copied rather than the planned one.
+func (r *ExecutionResult) MarkSourceChanged() {
+	r.Items[len(r.Items)-1].SourceChanged = true
+	r.SourceChanged++
+}
+
+// RecordLinked records item as copied by hard linking it to an identical
+// file already in the target. Its bytes are not counted as copied.
+func (r *ExecutionResult) RecordLinked(item CopyItem) {
+	r.Record(item, ItemCopied, nil)
+	r.BytesCopied -= item.FileMeta.Size
+	r.Items[len(r.Items)-1].Linked = true
+	r.Linked++
+}
+
+// FailedItems returns the results of all items that failed to copy.
+func (r ExecutionResult) FailedItems() []ItemResult {
+	var failed []ItemResult
+	for _, item := range r.Items {
+		if item.Status == ItemFailed {
+			failed = append(failed, item)
+		}
+	}
+	return failed
+}
+
+// LockedItems returns the results of all items skipped because their
+// source was still locked by another process.
+func (r ExecutionResult) LockedItems() []ItemResult {
+	var locked []ItemResult
+	for _, item := range r.Items {
+		if item.Status == ItemSkippedLocked {
+			locked = append(locked, item)
+		}
+	}
+	return locked
+}
+
+// LatestTargetDir returns the directory of the copied item with the newest
+// capture date, or "" when nothing was copied.
+func (r ExecutionResult) LatestTargetDir() string {
+	var latest *CopyItem
+	for i := range r.Items {
+		item := &r.Items[i].Item
+		if r.Items[i].Status != ItemCopied {
+			continue
+		}
+		if latest == nil || item.FileMeta.TakenAt.After(latest.FileMeta.TakenAt) {
+			latest = item
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return filepath.Dir(latest.TargetPath)
+}
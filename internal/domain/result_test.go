@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLatestTargetDirPicksNewestCopiedItem(t *testing.T) {
+	item := func(target string, day int) CopyItem {
+		return CopyItem{
+			FileMeta:   FileMeta{TakenAt: time.Date(2024, 10, day, 12, 0, 0, 0, time.Local)},
+			TargetPath: target,
+		}
+	}
+
+	var result ExecutionResult
+	result.Record(item("/target/2024-10-02/a.arw", 2), ItemCopied, nil)
+	result.Record(item("/target/2024-10-05/b.arw", 5), ItemCopied, nil)
+	result.Record(item("/target/2024-10-03/c.arw", 3), ItemCopied, nil)
+	// Files that were not written must not be linked
+	result.Record(item("/target/2024-10-09/d.arw", 9), ItemFailed, errors.New("boom"))
+	result.Record(item("/target/2024-10-08/e.arw", 8), ItemSkippedOverride, nil)
+
+	if got := result.LatestTargetDir(); got != "/target/2024-10-05" {
+		t.Fatalf("expected newest copied folder, got %q", got)
+	}
+	if got := (ExecutionResult{}).LatestTargetDir(); got != "" {
+		t.Fatalf("expected no folder for an empty result, got %q", got)
+	}
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// WarningAction is what the warnings review does with the files of a
+// warning.
+type WarningAction string
+
+const (
+	// ActionAccept keeps the files as planned.
+	ActionAccept WarningAction = "accept"
+	// ActionExclude leaves the files out of this run.
+	ActionExclude WarningAction = "exclude"
+	// ActionQuarantine copies the files into QuarantineDir below the
+	// target instead of the folders their doubtful dates put them in.
+	ActionQuarantine WarningAction = "quarantine"
+)
+
+// QuarantineDir is the folder below the target ActionQuarantine copies
+// files into.
+const QuarantineDir = "_unsorted"
+
+// ExiflessActions and SuspectDateActions are the actions offered for files
+// without a usable EXIF date and for files whose EXIF date is doubtful.
+var (
+	ExiflessActions    = []WarningAction{ActionQuarantine, ActionExclude}
+	SuspectDateActions = []WarningAction{ActionExclude, ActionQuarantine}
+)
+
+// LeftoverExcludedInReview is the leftover reason of files excluded by the
+// warnings review.
+const LeftoverExcludedInReview = "excluded in review"
+
+// ReviewDecision is the action chosen in the warnings review for the files
+// of every warning with Code. Files is how many planned files it changed.
+type ReviewDecision struct {
+	Code   string        `json:"code"`
+	Action WarningAction `json:"action"`
+	Files  int           `json:"files"`
+}
+
+func (d ReviewDecision) String() string {
+	return fmt.Sprintf("%s: %s %d files", d.Code, d.Action, d.Files)
+}
+
+// ReviewableWarnings returns the warnings of the plan followed by the
+// truncated ones the review can act on.
+func (p CopyPlan) ReviewableWarnings() []Warning {
+	if len(p.TruncatedReviewable) == 0 {
+		return p.Warnings
+	}
+	return append(append([]Warning(nil), p.Warnings...), p.TruncatedReviewable...)
+}
+
+// Review returns the plan with decisions applied to the files of its
+// warnings, truncated ones included, and recorded in Reviewed, with the
+// number of files each changed. Excluded files become leftovers;
+// quarantined ones keep their name below QuarantineDir in targetDir, made
+// unique like planned targets, and override what exists at their new
+// target, as told by exists; with a nil exists nothing does. Companion
+// files follow the file they belong to. Actions a warning does not allow
+// change nothing, and the last decision for a file wins. p itself is not
+// changed.
+func (p CopyPlan) Review(targetDir string, decisions []ReviewDecision, exists func(path string) (bool, error)) (CopyPlan, error) {
+	decisions = append([]ReviewDecision(nil), decisions...)
+	decided := make(map[string]int)
+	for i := range decisions {
+		decisions[i].Files = 0
+		for _, warning := range p.ReviewableWarnings() {
+			if warning.Code != decisions[i].Code || !warning.Allows(decisions[i].Action) {
+				continue
+			}
+			for _, path := range warning.Paths {
+				decided[path] = i
+			}
+		}
+	}
+
+	used := make(map[string]bool, len(p.Items))
+	for _, item := range p.Items {
+		used[item.TargetPath] = true
+	}
+	reviewed := p
+	reviewed.Items = nil
+	reviewed.OverrideItems, reviewed.AutoOverrideItems = nil, nil
+	reviewed.RawCount, reviewed.JpegCount, reviewed.RawOverrides, reviewed.JpegOverrides = 0, 0, 0, 0
+	reviewed.ExtensionCounts = make(map[string]int)
+	reviewed.Ratings = make(map[int]int)
+	reviewed.Leftovers = append([]Leftover(nil), p.Leftovers...)
+	for _, item := range p.Items {
+		path := item.FileMeta.SourcePath
+		if item.CompanionOf != "" {
+			path = item.CompanionOf
+		}
+		if i, ok := decided[path]; ok {
+			if item.CompanionOf == "" {
+				decisions[i].Files++
+			}
+			switch decisions[i].Action {
+			case ActionExclude:
+				reviewed.Leftovers = append(reviewed.Leftovers, Leftover{SourcePath: item.FileMeta.SourcePath, Reason: LeftoverExcludedInReview})
+				continue
+			case ActionQuarantine:
+				item.TargetPath = UniqueTargetPath(filepath.Join(targetDir, QuarantineDir, filepath.Base(item.TargetPath)), used)
+				item.Exists, item.AutoOverride = false, false
+				if exists != nil {
+					var err error
+					if item.Exists, err = exists(item.TargetPath); err != nil {
+						return CopyPlan{}, fmt.Errorf("check %s: %w", item.TargetPath, err)
+					}
+				}
+			}
+		}
+		reviewed.Items = append(reviewed.Items, item)
+		reviewed.count(item)
+	}
+
+	reviewed.Reviewed = append(append([]ReviewDecision(nil), p.Reviewed...), decisions...)
+	return reviewed, nil
+}
+
+// count adds item to the counts of the plan.
+func (p *CopyPlan) count(item CopyItem) {
+	if item.CompanionOf == "" {
+		if item.FileMeta.IsRAW {
+			p.RawCount++
+		} else if item.FileMeta.IsJPEG {
+			p.JpegCount++
+		}
+		if item.FileMeta.Rated {
+			p.Ratings[item.FileMeta.Rating]++
+		}
+	}
+	p.ExtensionCounts[item.FileMeta.Ext]++
+	if !item.Exists {
+		return
+	}
+	if item.AutoOverride {
+		p.AutoOverrideItems = append(p.AutoOverrideItems, item)
+		return
+	}
+	p.OverrideItems = append(p.OverrideItems, item)
+	if item.FileMeta.IsRAW {
+		p.RawOverrides++
+	} else if item.FileMeta.IsJPEG {
+		p.JpegOverrides++
+	}
+}
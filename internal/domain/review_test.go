@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func reviewPlan() CopyPlan {
+	taken := time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC)
+	item := func(rel string, exists bool) CopyItem {
+		meta := NewFileMeta(filepath.Join("/card", rel), rel, taken)
+		return CopyItem{FileMeta: meta, TargetPath: filepath.Join("/target", "2024-10-02", filepath.Base(rel)), Exists: exists}
+	}
+	noExif := item("DSC0001.ARW", false)
+	clip := item("C0001.MP4", false)
+	clipXML := item("C0001M01.XML", false)
+	clipXML.CompanionOf = clip.FileMeta.SourcePath
+	stuck := item("DSC0002.JPG", false)
+	existing := item("101/DSC0001.ARW", true)
+	existing.TargetPath = filepath.Join("/target", "2024-10-02", "DSC0001-1.ARW")
+	fine := item("DSC0003.ARW", false)
+
+	plan := CopyPlan{
+		Items:         []CopyItem{noExif, clip, clipXML, stuck, existing, fine},
+		OverrideItems: []CopyItem{existing},
+		RawCount:      3,
+		JpegCount:     1,
+		RawOverrides:  1,
+		Warnings: []Warning{
+			Warningf(WarningExifMissing, "EXIF not found for DSC0001.ARW").About(ExiflessActions, noExif.FileMeta.SourcePath),
+			Warningf(WarningExifMissing, "EXIF not found for C0001.MP4").About(ExiflessActions, clip.FileMeta.SourcePath),
+			Warningf(WarningExifMissing, "EXIF not found for DSC0001.ARW").About(ExiflessActions, existing.FileMeta.SourcePath),
+			Warningf(WarningSameSecond, "1 files share the capture time").About(SuspectDateActions, stuck.FileMeta.SourcePath),
+			Warningf(WarningZoneBoundary, "DSC0003.ARW falls on another day"),
+		},
+	}
+	return plan
+}
+
+func targets(items []CopyItem) []string {
+	var paths []string
+	for _, item := range items {
+		paths = append(paths, item.TargetPath)
+	}
+	return paths
+}
+
+func TestReviewQuarantinesExiflessFiles(t *testing.T) {
+	plan := reviewPlan()
+	reviewed, err := plan.Review("/target", []ReviewDecision{{Code: WarningExifMissing, Action: ActionQuarantine}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"/target/_unsorted/DSC0001.ARW",
+		"/target/_unsorted/C0001.MP4",
+		"/target/_unsorted/C0001M01.XML", // the companion follows its clip
+		"/target/2024-10-02/DSC0002.JPG",
+		"/target/_unsorted/DSC0001-1.ARW", // the second DSC0001.ARW of the card
+		"/target/2024-10-02/DSC0003.ARW",
+	}
+	for i := range want {
+		want[i] = filepath.FromSlash(want[i])
+	}
+	if got := targets(reviewed.Items); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected targets %v, got %v", want, got)
+	}
+	if len(reviewed.OverrideItems) != 0 || reviewed.RawOverrides != 0 || reviewed.Items[4].Exists {
+		t.Fatalf("expected the quarantined file to override nothing, got %+v", reviewed.OverrideItems)
+	}
+	if reviewed.RawCount != 3 || reviewed.JpegCount != 1 {
+		t.Fatalf("expected unchanged counts, got %d RAW and %d JPEG", reviewed.RawCount, reviewed.JpegCount)
+	}
+	if want := []ReviewDecision{{Code: WarningExifMissing, Action: ActionQuarantine, Files: 3}}; !reflect.DeepEqual(reviewed.Reviewed, want) {
+		t.Fatalf("expected the recorded decision %v, got %v", want, reviewed.Reviewed)
+	}
+	if plan.Items[0].TargetPath != filepath.FromSlash("/target/2024-10-02/DSC0001.ARW") || len(plan.OverrideItems) != 1 || plan.Reviewed != nil {
+		t.Fatal("expected the reviewed plan to leave the original alone")
+	}
+}
+
+func TestReviewExcludesSuspectDates(t *testing.T) {
+	reviewed, err := reviewPlan().Review("/target", []ReviewDecision{
+		{Code: WarningSameSecond, Action: ActionExclude},
+		{Code: WarningExifMissing, Action: ActionAccept},
+		// Zone boundary warnings cannot be acted on
+		{Code: WarningZoneBoundary, Action: ActionExclude},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(reviewed.Items) != 5 || reviewed.JpegCount != 0 || reviewed.RawCount != 3 || reviewed.RawOverrides != 1 {
+		t.Fatalf("expected only the JPEG to be left out, got %v", targets(reviewed.Items))
+	}
+	if want := []Leftover{{SourcePath: filepath.FromSlash("/card/DSC0002.JPG"), Reason: LeftoverExcludedInReview}}; !reflect.DeepEqual(reviewed.Leftovers, want) {
+		t.Fatalf("expected the excluded file as leftover, got %v", reviewed.Leftovers)
+	}
+	want := []ReviewDecision{
+		{Code: WarningSameSecond, Action: ActionExclude, Files: 1},
+		{Code: WarningExifMissing, Action: ActionAccept, Files: 3},
+		{Code: WarningZoneBoundary, Action: ActionExclude},
+	}
+	if !reflect.DeepEqual(reviewed.Reviewed, want) {
+		t.Fatalf("expected decisions %v, got %v", want, reviewed.Reviewed)
+	}
+}
+
+func TestReviewChecksTheQuarantineTargets(t *testing.T) {
+	plan := reviewPlan()
+	// The warning of the existing file went past the cap
+	plan.TruncatedReviewable = plan.Warnings[2:3]
+	plan.Warnings = append(plan.Warnings[:2:2], plan.Warnings[3:]...)
+	taken := filepath.FromSlash("/target/_unsorted/DSC0001-1.ARW")
+	exists := func(path string) (bool, error) {
+		return path == taken, nil
+	}
+
+	reviewed, err := plan.Review("/target", []ReviewDecision{{Code: WarningExifMissing, Action: ActionQuarantine}}, exists)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := reviewed.Items[4].TargetPath; got != taken {
+		t.Fatalf("expected the file of the truncated warning quarantined, got %s", got)
+	}
+	if len(reviewed.OverrideItems) != 1 || reviewed.OverrideItems[0].TargetPath != taken || reviewed.RawOverrides != 1 {
+		t.Fatalf("expected the existing quarantine target to be overridden, got %v", targets(reviewed.OverrideItems))
+	}
+	if reviewed.Reviewed[0].Files != 3 {
+		t.Fatalf("expected 3 files quarantined, got %d", reviewed.Reviewed[0].Files)
+	}
+
+	failing := func(string) (bool, error) { return false, errors.New("permission denied") }
+	if _, err := plan.Review("/target", []ReviewDecision{{Code: WarningExifMissing, Action: ActionQuarantine}}, failing); err == nil {
+		t.Fatal("expected the failed check to be reported")
+	}
+}
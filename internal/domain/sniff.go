@@ -0,0 +1,34 @@
+package domain
+
+import "bytes"
+
+// SniffHeaderSize is how many leading bytes SniffExtension needs to see.
+const SniffHeaderSize = 512
+
+var heifBrands = map[string]bool{
+	"heic": true, "heix": true, "hevc": true, "hevx": true,
+	"heim": true, "heis": true, "mif1": true, "msf1": true,
+}
+
+// SniffExtension detects the media type of a file from its leading bytes
+// and returns the canonical lowercase extension for it, or "" when the
+// content is not recognized.
+func SniffExtension(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return ".jpg"
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")):
+		return ".tif"
+	case len(header) >= 12 && string(header[4:8]) == "ftyp":
+		brand := string(header[8:12])
+		if heifBrands[brand] {
+			return ".heic"
+		}
+		if brand == "qt  " {
+			return ".mov"
+		}
+		return ".mp4"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "testing"
+
+func TestSniffExtension(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x10}, ".jpg"},
+		{"tiff little endian", []byte("II*\x00\x08\x00\x00\x00"), ".tif"},
+		{"tiff big endian", []byte("MM\x00*\x00\x00\x00\x08"), ".tif"},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), ".heic"},
+		{"mp4", []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00"), ".mp4"},
+		{"quicktime", []byte("\x00\x00\x00\x14ftypqt  \x00\x00\x00\x00"), ".mov"},
+		{"text", []byte("hello world"), ""},
+		{"truncated ftyp", []byte("\x00\x00\x00\x18ftyp"), ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SniffExtension(tt.header); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package domain
+
+// SourceChange decides what happens to a source file whose size or
+// modification time changed between planning and copying it, e.g. because
+// a sync client rewrote it.
+type SourceChange int
+
+const (
+	// SourceChangeCopy copies the version the file has when it is copied.
+	SourceChangeCopy SourceChange = iota
+	SourceChangeSkip
+	SourceChangeFail
+)
+
+func (c SourceChange) String() string {
+	switch c {
+	case SourceChangeCopy:
+		return "copy"
+	case SourceChangeSkip:
+		return "skip"
+	case SourceChangeFail:
+		return "fail"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,26 @@
+package domain
+
+// Volume identifies the file system a source is on, like a memory card,
+// independently of where it is mounted.
+type Volume struct {
+	// Label is the name given to the volume, like "EOS_DIGITAL".
+	Label string `json:"label,omitempty"`
+	// Serial is the UUID or serial number of the file system, empty when
+	// the platform does not report one.
+	Serial string `json:"serial,omitempty"`
+}
+
+// IsZero reports whether nothing is known about the volume.
+func (v Volume) IsZero() bool {
+	return v == Volume{}
+}
+
+// Same reports whether v and other are the same volume by their serials.
+// known is false when either serial is missing, which leaves the question
+// open.
+func (v Volume) Same(other Volume) (same, known bool) {
+	if v.Serial == "" || other.Serial == "" {
+		return false, false
+	}
+	return v.Serial == other.Serial, true
+}
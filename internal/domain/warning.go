@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Warning is a non-fatal problem found while planning or copying. Code is
+// a stable snake_case identifier scripts can branch on, see WarningCodes;
+// Text is for people and may change.
+type Warning struct {
+	Code string `json:"code"`
+	Text string `json:"text"`
+	// Paths are the source paths of the files the warning is about and
+	// Actions what the warnings review may do with them besides accepting
+	// them, see CopyPlan.Review. Both are empty for warnings that cannot
+	// be acted on.
+	Paths   []string        `json:"paths,omitempty"`
+	Actions []WarningAction `json:"actions,omitempty"`
+}
+
+// Warningf returns a warning with code and the formatted text.
+func Warningf(code, format string, args ...any) Warning {
+	return Warning{Code: code, Text: fmt.Sprintf(format, args...)}
+}
+
+func (w Warning) String() string {
+	return w.Text
+}
+
+// About returns w acting on the files at paths with actions.
+func (w Warning) About(actions []WarningAction, paths ...string) Warning {
+	w.Actions = actions
+	w.Paths = paths
+	return w
+}
+
+// Allows reports whether action may be taken on the files of w. Accepting
+// them is always allowed.
+func (w Warning) Allows(action WarningAction) bool {
+	return action == ActionAccept || slices.Contains(w.Actions, action)
+}
+
+// Warning codes. They are part of the plan file and must not change once
+// released; add new ones to WarningCodes.
+const (
+	WarningExifMissing        = "exif_missing"
+	WarningExifDateInvalid    = "exif_date_invalid"
+	WarningZoneBoundary       = "zone_boundary"
+	WarningDuplicateCapture   = "duplicate_capture"
+	WarningChangedSinceImport = "changed_since_import"
+	WarningCameraDefaultDate  = "camera_default_date"
+	WarningSameSecond         = "same_second"
+	WarningCopyFailed         = "copy_failed"
+	WarningSourceChanged      = "source_changed"
+)
+
+// WarningCode documents a warning code for `phopy warnings`.
+type WarningCode struct {
+	Code        string
+	Description string
+}
+
+// WarningCodes lists every warning code, in the order they can occur.
+var WarningCodes = []WarningCode{
+	{WarningExifMissing, "A file of a type that carries EXIF, like a RAW or JPEG, has no capture date and is dated by its modification time."},
+	{WarningExifDateInvalid, "A file's EXIF date is implausible and it is dated by its folder or modification time."},
+	{WarningZoneBoundary, "A capture falls on another day in the compared time zone than in the camera's."},
+	{WarningDuplicateCapture, "A file is the same capture as another source file and is left out (--dedupe)."},
+	{WarningChangedSinceImport, "A source file changed since an earlier run imported it."},
+	{WarningCameraDefaultDate, "Files are dated on a day cameras start from before their clock is set."},
+	{WarningSameSecond, "More files share a capture second than a burst takes."},
+	{WarningCopyFailed, "A file failed to copy while --keep-going continued with the others."},
+	{WarningSourceChanged, "A source file changed between planning and copying it, see --on-source-change."},
+}
+
+// IsWarningCode reports whether code is listed in WarningCodes.
+func IsWarningCode(code string) bool {
+	for _, known := range WarningCodes {
+		if known.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWarningCodesAreStableIdentifiers(t *testing.T) {
+	snakeCase := regexp.MustCompile(`^[a-z]+(_[a-z]+)*$`)
+	seen := make(map[string]bool)
+	for _, code := range WarningCodes {
+		if !snakeCase.MatchString(code.Code) {
+			t.Errorf("code %q is not snake_case", code.Code)
+		}
+		if seen[code.Code] {
+			t.Errorf("code %q is listed twice", code.Code)
+		}
+		seen[code.Code] = true
+		if code.Description == "" {
+			t.Errorf("code %q has no description", code.Code)
+		}
+	}
+
+	// Every code constant must be listed, or `phopy warnings` misses it
+	file, err := parser.ParseFile(token.NewFileSet(), "warning.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || !strings.HasPrefix(spec.Names[0].Name, "Warning") || len(spec.Values) == 0 {
+			return true
+		}
+		lit, ok := spec.Values[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		if code, _ := strconv.Unquote(lit.Value); !IsWarningCode(code) {
+			t.Errorf("%s = %q is missing from WarningCodes", spec.Names[0].Name, code)
+		}
+		return true
+	})
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SidecarMaxSize is how many bytes of an XMP sidecar are read; ratings sit
+// near the top and sidecars are rarely larger.
+const SidecarMaxSize = 256 << 10
+
+var xmpRatingPattern = regexp.MustCompile(`xmp:Rating\s*=\s*["'](-?\d+)["']|<xmp:Rating>\s*(-?\d+)\s*</xmp:Rating>`)
+
+func IsSidecarExtension(ext string) bool {
+	return strings.EqualFold(ext, ".xmp")
+}
+
+// SidecarKey is the key a sidecar is stored under: its lowercase path
+// without the .xmp extension. It matches both DSC0001.xmp and
+// DSC0001.ARW.xmp naming, see SidecarKeys.
+func SidecarKey(sidecarPath string) string {
+	return strings.ToLower(strings.TrimSuffix(sidecarPath, filepath.Ext(sidecarPath)))
+}
+
+// SidecarKeys returns the keys a sidecar of path may be stored under, the
+// more specific one first.
+func SidecarKeys(path string) []string {
+	lower := strings.ToLower(path)
+	return []string{lower, strings.TrimSuffix(lower, filepath.Ext(lower))}
+}
+
+// ParseXMPRating extracts the xmp:Rating star rating (0 to 5) from XMP data,
+// written either as an attribute or as an element. Rejected (-1) and
+// missing ratings report false.
+func ParseXMPRating(data []byte) (int, bool) {
+	match := xmpRatingPattern.FindSubmatch(data)
+	if match == nil {
+		return 0, false
+	}
+	value := match[1]
+	if value == nil {
+		value = match[2]
+	}
+	rating, err := strconv.Atoi(string(value))
+	if err != nil || rating < 0 || rating > 5 {
+		return 0, false
+	}
+	return rating, true
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseXMPRating(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    int
+		ok      bool
+	}{
+		{"rating-attribute.xmp", 4, true},
+		{"rating-element.xmp", 2, true},
+		{"rating-rejected.xmp", 0, false},
+		{"no-rating.xmp", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatalf("read fixture: %v", err)
+			}
+			got, ok := ParseXMPRating(data)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("expected %d/%v, got %d/%v", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestSidecarKeysMatchBothNamings(t *testing.T) {
+	keys := SidecarKeys("/card/DSC0001.ARW")
+	for _, sidecar := range []string{"/card/DSC0001.xmp", "/card/DSC0001.ARW.xmp"} {
+		key := SidecarKey(sidecar)
+		if key != keys[0] && key != keys[1] {
+			t.Fatalf("expected %s to match one of %v", key, keys)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package errors
 
-import "fmt"
+import (
+	stderrors "errors"
+	"fmt"
+)
 
 type Kind string
 
@@ -10,12 +13,15 @@ const (
 	ExifFailure   Kind = "exif_failure"
 	IOFailure     Kind = "io_failure"
 	Internal      Kind = "internal"
+	// Aborted is a run the user stopped before it copied anything.
+	Aborted Kind = "aborted"
 )
 
 type AppError struct {
 	Kind Kind
 	Op   string
 	Path string
+	Hint string
 	Err  error
 }
 
@@ -42,11 +48,60 @@ func Wrap(kind Kind, op, path string, err error) error {
 	}
 }
 
+// WithHint wraps err like Wrap and attaches a hint telling the user how to
+// resolve the problem.
+func WithHint(kind Kind, op, path, hint string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &AppError{
+		Kind: kind,
+		Op:   op,
+		Path: path,
+		Hint: hint,
+		Err:  err,
+	}
+}
+
+// Exit codes of phopy. Runs refused for their configuration exit with
+// ExitInvalidConfig and runs the user aborted with ExitAborted, so scripts
+// can tell them from failed copies.
+const (
+	ExitFailure       = 1
+	ExitInvalidConfig = 2
+	ExitAborted       = 3
+)
+
+// ExitCode returns the exit code phopy ends with after err, which may wrap
+// an *AppError.
+func ExitCode(err error) int {
+	var appErr *AppError
+	if stderrors.As(err, &appErr) {
+		switch appErr.Kind {
+		case InvalidConfig:
+			return ExitInvalidConfig
+		case Aborted:
+			return ExitAborted
+		}
+	}
+	return ExitFailure
+}
+
+// UserMessage returns the message shown for err, that of the *AppError it
+// wraps if any.
 func UserMessage(err error) string {
-	appErr, ok := err.(*AppError)
-	if !ok {
+	var appErr *AppError
+	if !stderrors.As(err, &appErr) {
 		return err.Error()
 	}
+	msg := kindMessage(appErr)
+	if appErr.Hint != "" {
+		msg += "\nHint: " + appErr.Hint
+	}
+	return msg
+}
+
+func kindMessage(appErr *AppError) string {
 	switch appErr.Kind {
 	case InvalidConfig:
 		return fmt.Sprintf("Invalid configuration: %v", appErr.Err)
@@ -55,7 +110,12 @@ func UserMessage(err error) string {
 	case ExifFailure:
 		return fmt.Sprintf("EXIF read failed: %s", appErr.Path)
 	case IOFailure:
+		if appErr.Err != nil {
+			return fmt.Sprintf("I/O error: %s: %v", appErr.Path, appErr.Err)
+		}
 		return fmt.Sprintf("I/O error: %s", appErr.Path)
+	case Aborted:
+		return fmt.Sprintf("Aborted at %s: %v", appErr.Op, appErr.Err)
 	default:
 		return fmt.Sprintf("Unexpected error: %v", appErr.Err)
 	}
@@ -0,0 +1,20 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+)
+
+func TestWrappedAppErrorsKeepTheirExitCodeAndMessage(t *testing.T) {
+	err := fmt.Errorf("run: %w", WithHint(Aborted, "confirm", "", "run phopy again", stderrors.New("quit")))
+	if code := ExitCode(err); code != ExitAborted {
+		t.Fatalf("expected exit code %d, got %d", ExitAborted, code)
+	}
+	if msg := UserMessage(err); msg != "Aborted at confirm: quit\nHint: run phopy again" {
+		t.Fatalf("unexpected message %q", msg)
+	}
+	if code := ExitCode(stderrors.New("boom")); code != ExitFailure {
+		t.Fatalf("expected exit code %d, got %d", ExitFailure, code)
+	}
+}
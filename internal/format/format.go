@@ -0,0 +1,84 @@
+// Package format renders sizes, durations and rates for people, so the TUI,
+// the plain printer and reports agree on how they look.
+package format
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bytes renders n with IEC units and one decimal, e.g. "12.4 GiB". Sizes
+// below 1 KiB are whole bytes, e.g. "512 B".
+func Bytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for value := n / unit; value >= unit; value /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Duration renders d rounded to seconds, e.g. "4m 12s" or "2h 0m 5s".
+// Durations below a second are "<1s".
+func Duration(d time.Duration) string {
+	if d < time.Second {
+		return "<1s"
+	}
+
+	d = d.Round(time.Second)
+
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm %ds", hours, minutes, seconds)
+	}
+	if minutes > 0 {
+		return fmt.Sprintf("%dm %ds", minutes, seconds)
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
+
+// Estimate renders a rough duration in whole minutes, e.g. "~14 min" or
+// "~2 h 5 min".
+func Estimate(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	switch {
+	case minutes < 1:
+		return "<1 min"
+	case minutes < 60:
+		return fmt.Sprintf("~%d min", minutes)
+	default:
+		return fmt.Sprintf("~%d h %d min", minutes/60, minutes%60)
+	}
+}
+
+// Count renders n with thousands separators, e.g. "299,000".
+func Count(n int) string {
+	digits := fmt.Sprint(n)
+	if n < 0 {
+		return "-" + Count(-n)
+	}
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String()
+}
+
+// Rate renders a throughput in bytes per second, e.g. "85.3 MiB/s".
+func Rate(bytesPerSec float64) string {
+	if bytesPerSec <= 0 {
+		return "0 B/s"
+	}
+	return Bytes(int64(bytesPerSec)) + "/s"
+}
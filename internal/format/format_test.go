@@ -0,0 +1,92 @@
+package format
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBytes(t *testing.T) {
+	for _, tt := range []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{1024 * 1024, "1.0 MiB"},
+		{12*1024*1024*1024 + 400*1024*1024, "12.4 GiB"},
+		{3 << 40, "3.0 TiB"},
+	} {
+		if got := Bytes(tt.n); got != tt.want {
+			t.Errorf("Bytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	for _, tt := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "<1s"},
+		{999 * time.Millisecond, "<1s"},
+		{time.Second, "1s"},
+		{1500 * time.Millisecond, "2s"},
+		{4*time.Minute + 12*time.Second, "4m 12s"},
+		{time.Hour, "1h 0m 0s"},
+		{26*time.Hour + 3*time.Minute + 5*time.Second, "26h 3m 5s"},
+	} {
+		if got := Duration(tt.d); got != tt.want {
+			t.Errorf("Duration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestRate(t *testing.T) {
+	for _, tt := range []struct {
+		bytesPerSec float64
+		want        string
+	}{
+		{0, "0 B/s"},
+		{-1, "0 B/s"},
+		{512, "512 B/s"},
+		{1024, "1.0 KiB/s"},
+		{85.3 * 1024 * 1024, "85.3 MiB/s"},
+	} {
+		if got := Rate(tt.bytesPerSec); got != tt.want {
+			t.Errorf("Rate(%v) = %q, want %q", tt.bytesPerSec, got, tt.want)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	for _, tt := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{29 * time.Second, "<1 min"},
+		{14 * time.Minute, "~14 min"},
+		{2*time.Hour + 22*time.Minute + 10*time.Second, "~2 h 22 min"},
+	} {
+		if got := Estimate(tt.d); got != tt.want {
+			t.Errorf("Estimate(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	for _, tt := range []struct {
+		n    int
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1234567, "1,234,567"},
+		{-299000, "-299,000"},
+	} {
+		if got := Count(tt.n); got != tt.want {
+			t.Errorf("Count(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package hash
+
+import (
+	"runtime"
+
+	"github.com/klauspost/cpuid/v2"
+)
+
+// Acceleration names the vector or hash instructions the implementation of
+// a uses on this CPU, like "AVX2", or "" when it runs generic Go code.
+func (a Algorithm) Acceleration() string {
+	cpu := cpuid.CPU
+	switch runtime.GOARCH {
+	case "amd64":
+		switch a {
+		case XXH3:
+			return firstSupported(cpu, cpuid.AVX512F, cpuid.AVX2, cpuid.SSE2)
+		case SHA256:
+			return firstSupported(cpu, cpuid.SHA)
+		case BLAKE3:
+			return firstSupported(cpu, cpuid.AVX2, cpuid.SSE4)
+		}
+	case "arm64":
+		switch a {
+		case XXH3:
+			return firstSupported(cpu, cpuid.ASIMD)
+		case SHA256:
+			return firstSupported(cpu, cpuid.SHA2)
+		}
+	}
+	return ""
+}
+
+func firstSupported(cpu cpuid.CPUInfo, features ...cpuid.FeatureID) string {
+	for _, feature := range features {
+		if cpu.Supports(feature) {
+			return feature.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+// Package hash hashes file content with the algorithm picked by --hash, so
+// dedupe, manifests and linking duplicates agree on what identical content
+// is. Digests are lowercase hex.
+package hash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	stdhash "hash"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeebo/blake3"
+	"github.com/zeebo/xxh3"
+)
+
+// Algorithm names a hash function as --hash and manifests spell it.
+type Algorithm string
+
+const (
+	// XXH3 is the 128-bit xxh3, a fast non-cryptographic hash.
+	XXH3 Algorithm = "xxh3"
+	// SHA256 is the cryptographic hash older manifests recorded.
+	SHA256 Algorithm = "sha256"
+	// BLAKE3 is a cryptographic hash, several times faster than SHA-256
+	// on CPUs with vector instructions.
+	BLAKE3 Algorithm = "blake3"
+)
+
+// Algorithms lists every algorithm, in the order --hash documents them.
+var Algorithms = []Algorithm{XXH3, SHA256, BLAKE3}
+
+const (
+	// DefaultDedupe tells duplicate source files apart, which only needs
+	// a fast hash since the hashes never leave the run.
+	DefaultDedupe = XXH3
+	// DefaultManifest is recorded in manifests, where some archives
+	// require a cryptographic hash.
+	DefaultManifest = SHA256
+)
+
+// Parse returns the algorithm named name, in any case.
+func Parse(name string) (Algorithm, error) {
+	a := Algorithm(strings.ToLower(strings.TrimSpace(name)))
+	for _, known := range Algorithms {
+		if a == known {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("unknown hash %q, use xxh3, sha256 or blake3", name)
+}
+
+// New returns a hash.Hash of a. An unknown algorithm is a programming
+// error and panics.
+func (a Algorithm) New() stdhash.Hash {
+	switch a {
+	case XXH3:
+		return xxh3.New128()
+	case SHA256:
+		return sha256.New()
+	case BLAKE3:
+		return blake3.New()
+	default:
+		panic(fmt.Sprintf("hash: unknown algorithm %q", string(a)))
+	}
+}
+
+// Sum hashes everything read from r and returns the hex digest. The bytes
+// and time count towards the Stats of a.
+func (a Algorithm) Sum(r io.Reader) (string, error) {
+	h := a.New()
+	start := time.Now()
+	n, err := io.Copy(h, r)
+	meters[a].add(n, time.Since(start))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Stats is what was hashed with an algorithm since the process started.
+type Stats struct {
+	Algorithm Algorithm
+	Bytes     int64
+	// Elapsed includes the time spent reading, so Rate is what hashing
+	// files achieved rather than the speed of the hash alone.
+	Elapsed time.Duration
+}
+
+// Rate is the throughput in bytes per second, 0 without any time spent.
+func (s Stats) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Elapsed.Seconds()
+}
+
+// Report returns the Stats of the algorithms that hashed anything, in the
+// order of Algorithms.
+func Report() []Stats {
+	var stats []Stats
+	for _, a := range Algorithms {
+		m := meters[a]
+		if bytes := m.bytes.Load(); bytes > 0 {
+			stats = append(stats, Stats{Algorithm: a, Bytes: bytes, Elapsed: time.Duration(m.nanos.Load())})
+		}
+	}
+	return stats
+}
+
+// meter sums up the work of one algorithm across goroutines.
+type meter struct {
+	bytes atomic.Int64
+	nanos atomic.Int64
+}
+
+func (m *meter) add(n int64, elapsed time.Duration) {
+	m.bytes.Add(n)
+	m.nanos.Add(int64(elapsed))
+}
+
+var meters = map[Algorithm]*meter{XXH3: {}, SHA256: {}, BLAKE3: {}}
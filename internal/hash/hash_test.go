@@ -0,0 +1,112 @@
+package hash
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// The digests of the reference implementations of each algorithm.
+var vectors = []struct {
+	algorithm Algorithm
+	input     string
+	want      string
+}{
+	{XXH3, "", "99aa06d3014798d86001c324468d497f"},
+	{XXH3, "abc", "06b05ab6733a618578af5f94892f3950"},
+	{SHA256, "", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	{SHA256, "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	{BLAKE3, "", "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+	{BLAKE3, "abc", "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85"},
+}
+
+func TestSumMatchesReferenceDigests(t *testing.T) {
+	for _, v := range vectors {
+		got, err := v.algorithm.Sum(strings.NewReader(v.input))
+		if err != nil {
+			t.Fatalf("%s(%q): unexpected error: %v", v.algorithm, v.input, err)
+		}
+		if got != v.want {
+			t.Errorf("%s(%q): expected %s, got %s", v.algorithm, v.input, v.want, got)
+		}
+	}
+}
+
+func TestSumDoesNotDependOnHowContentIsRead(t *testing.T) {
+	// Long enough for the vectorized code paths, with a tail that is not
+	// a multiple of any block size
+	content := bytes.Repeat([]byte("DSC0001.ARW\x00\xff"), 40_000)
+	seen := make(map[string]Algorithm)
+	for _, a := range Algorithms {
+		whole, err := a.Sum(bytes.NewReader(content))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", a, err)
+		}
+		halves, err := a.Sum(iotest.HalfReader(bytes.NewReader(content)))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", a, err)
+		}
+		if whole != halves {
+			t.Fatalf("%s: expected the same digest for short reads, got %s and %s", a, whole, halves)
+		}
+		if other, ok := seen[whole]; ok {
+			t.Fatalf("%s and %s yield the same digest", a, other)
+		}
+		seen[whole] = a
+	}
+}
+
+func TestSumReportsReadErrors(t *testing.T) {
+	if _, err := SHA256.Sum(iotest.ErrReader(iotest.ErrTimeout)); err != iotest.ErrTimeout {
+		t.Fatalf("expected the read error, got %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for name, want := range map[string]Algorithm{"xxh3": XXH3, " SHA256 ": SHA256, "Blake3": BLAKE3} {
+		if got, err := Parse(name); err != nil || got != want {
+			t.Errorf("Parse(%q): expected %s, got %q, %v", name, want, got, err)
+		}
+	}
+	if _, err := Parse("md5"); err == nil {
+		t.Fatalf("expected an error for an unknown hash")
+	}
+}
+
+func TestReportSumsUpHashedBytes(t *testing.T) {
+	before := statsOf(BLAKE3)
+	if _, err := BLAKE3.Sum(strings.NewReader("0123456789")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := statsOf(BLAKE3).Bytes - before.Bytes; got != 10 {
+		t.Fatalf("expected 10 more bytes, got %d", got)
+	}
+	if (Stats{Bytes: 10}).Rate() != 0 {
+		t.Fatalf("expected no rate without elapsed time")
+	}
+}
+
+func statsOf(a Algorithm) Stats {
+	for _, stats := range Report() {
+		if stats.Algorithm == a {
+			return stats
+		}
+	}
+	return Stats{Algorithm: a}
+}
+
+func BenchmarkSum(b *testing.B) {
+	// 4 MiB, a small RAW file, read from memory so only the hash counts
+	content := bytes.Repeat([]byte{0x5a, 0xa5, 0x3c, 0xc3}, 1<<20)
+	for _, a := range Algorithms {
+		b.Run(string(a), func(b *testing.B) {
+			b.SetBytes(int64(len(content)))
+			for b.Loop() {
+				if _, err := a.Sum(bytes.NewReader(content)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
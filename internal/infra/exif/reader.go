@@ -1,46 +1,244 @@
 package exif
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"phopy/internal/domain"
+
 	goexif "github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
 )
 
-type Reader struct{}
+var errDateTimeNotFound = errors.New("exif datetime not found")
+
+// Exif sub-IFD tags goexif does not know about.
+const (
+	tagOffsetTime         = 0x9010
+	tagOffsetTimeOriginal = 0x9011
+	tagImageNumber        = 0x9211
+	tagBodySerialNumber   = 0xa431
+)
+
+// Reader reads photo metadata with goexif.
+type Reader struct {
+	// DateTags is the order the capture date is looked up in; nil uses
+	// domain.DefaultDateTagOrder.
+	DateTags []domain.DateTag
+}
 
-func (Reader) DateTimeOriginal(ctx context.Context, path string) (time.Time, error) {
+// ReadMeta decodes the EXIF block of path once and extracts everything
+// phopy knows how to use from it.
+func (r Reader) ReadMeta(ctx context.Context, path string) (domain.PhotoMeta, error) {
 	select {
 	case <-ctx.Done():
-		return time.Time{}, ctx.Err()
+		return domain.PhotoMeta{}, ctx.Err()
 	default:
 	}
 
 	file, err := os.Open(path)
 	if err != nil {
-		return time.Time{}, err
+		return domain.PhotoMeta{}, err
 	}
 	defer file.Close()
 
 	x, err := goexif.Decode(file)
-	if err != nil {
-		return time.Time{}, err
+	if err != nil && (x == nil || goexif.IsCriticalError(err)) {
+		return domain.PhotoMeta{}, err
+	}
+
+	meta := domain.PhotoMeta{
+		Make:  stringTag(x, goexif.Make),
+		Model: stringTag(x, goexif.Model),
+	}
+	if lat, long, err := x.LatLong(); err == nil {
+		meta.GPS = &domain.GPS{Latitude: lat, Longitude: long}
+	}
+	extra := exifIFDTags(x)
+	meta.BodySerial = extra.str(tagBodySerialNumber)
+	meta.ImageNumber = extra.uint32(tagImageNumber)
+	offset, ok := parseOffset(extra.str(tagOffsetTimeOriginal))
+	if !ok {
+		offset, ok = parseOffset(extra.str(tagOffsetTime))
+	}
+	if ok {
+		meta.Offset = &offset
 	}
 
-	if tag, err := x.Get(goexif.DateTimeOriginal); err == nil {
-		if str, err := tag.StringVal(); err == nil {
-			parsed, err := time.ParseInLocation("2006:01:02 15:04:05", str, time.Local)
-			if err == nil {
-				return parsed, nil
-			}
+	// A zero date in one tag does not rule out a fine date in the next,
+	// but is reported when no tag has one
+	tags := r.DateTags
+	if tags == nil {
+		tags = domain.DefaultDateTagOrder
+	}
+	var zeroErr error
+	for _, tag := range tags {
+		fields := dateFields[tag]
+		str := stringTag(x, fields.date)
+		if isZeroDate(str) && zeroErr == nil {
+			zeroErr = fmt.Errorf("%w: %s is %q", domain.ErrInvalidCaptureDate, tag, str)
 		}
+		if parsed, err := parseDate(str); err == nil {
+			meta.TakenAt = parsed
+			meta.DateTag = tag
+			meta.SubSec = parseSubSec(stringTag(x, fields.subSec))
+			return meta, nil
+		}
+	}
+	if zeroErr != nil {
+		return meta, zeroErr
+	}
+	return meta, errDateTimeNotFound
+}
+
+// Thumbnail returns the JPEG preview camera makers embed in the EXIF block
+// of path. It fails when there is none.
+func (r Reader) Thumbnail(ctx context.Context, path string) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	x, err := goexif.Decode(file)
+	if err != nil && (x == nil || goexif.IsCriticalError(err)) {
+		return nil, err
+	}
+	return x.JpegThumbnail()
+}
+
+// dateFields are the goexif fields of a date tag and its fraction of a
+// second.
+var dateFields = map[domain.DateTag]struct{ date, subSec goexif.FieldName }{
+	domain.DateTagOriginal: {goexif.DateTimeOriginal, goexif.SubSecTimeOriginal},
+	domain.DateTagCreate:   {goexif.DateTimeDigitized, goexif.SubSecTimeDigitized},
+	domain.DateTagModify:   {goexif.DateTime, goexif.SubSecTime},
+}
+
+// isZeroDate reports an EXIF date that is present but all zeros or blanks,
+// e.g. "0000:00:00 00:00:00" or "    :  :     :  :  ".
+func isZeroDate(value string) bool {
+	return value != "" && strings.Trim(value, "0: ") == ""
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" || isZeroDate(value) {
+		return time.Time{}, errDateTimeNotFound
 	}
+	return time.ParseInLocation("2006:01:02 15:04:05", value, time.Local)
+}
+
+func stringTag(x *goexif.Exif, name goexif.FieldName) string {
+	tag, err := x.Get(name)
+	if err != nil {
+		return ""
+	}
+	str, err := tag.StringVal()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(str, "\x00"))
+}
+
+// ifdTags are the tags of an IFD by id.
+type ifdTags map[uint16]*tiff.Tag
+
+func (t ifdTags) str(id uint16) string {
+	tag, ok := t[id]
+	if !ok || tag.Type != tiff.DTAscii {
+		return ""
+	}
+	str, err := tag.StringVal()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(str, "\x00"))
+}
 
-	if parsed, err := x.DateTime(); err == nil {
-		return parsed.In(time.Local), nil
+func (t ifdTags) uint32(id uint16) uint32 {
+	tag, ok := t[id]
+	if !ok || (tag.Type != tiff.DTLong && tag.Type != tiff.DTShort) {
+		return 0
+	}
+	n, err := tag.Int64(0)
+	if err != nil || n < 0 {
+		return 0
 	}
+	return uint32(n)
+}
 
-	return time.Time{}, errors.New("exif datetime not found")
+// exifIFDTags decodes the tags of the Exif sub-IFD, including the ones
+// goexif drops because it does not know them.
+func exifIFDTags(x *goexif.Exif) ifdTags {
+	tags := ifdTags{}
+	ptr, err := x.Get(goexif.ExifIFDPointer)
+	if err != nil {
+		return tags
+	}
+	offset, err := ptr.Int64(0)
+	if err != nil {
+		return tags
+	}
+	r := bytes.NewReader(x.Raw)
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return tags
+	}
+	dir, _, err := tiff.DecodeDir(r, x.Tiff.Order)
+	if err != nil {
+		return tags
+	}
+	for _, tag := range dir.Tags {
+		tags[tag.Id] = tag
+	}
+	return tags
+}
+
+// parseOffset converts an EXIF offset value ("+02:00") into a duration.
+func parseOffset(value string) (time.Duration, bool) {
+	if len(value) != 6 || (value[0] != '+' && value[0] != '-') || value[3] != ':' {
+		return 0, false
+	}
+	hours, err := strconv.Atoi(value[1:3])
+	if err != nil {
+		return 0, false
+	}
+	minutes, err := strconv.Atoi(value[4:6])
+	if err != nil || hours > 14 || minutes > 59 {
+		return 0, false
+	}
+	offset := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	if value[0] == '-' {
+		offset = -offset
+	}
+	return offset, true
+}
+
+// parseSubSec converts an EXIF SubSecTime value ("123" meaning .123s) into
+// a duration. Malformed values yield zero.
+func parseSubSec(value string) time.Duration {
+	if value == "" || len(value) > 9 {
+		return 0
+	}
+	digits, err := strconv.Atoi(value)
+	if err != nil || digits < 0 {
+		return 0
+	}
+	scale := time.Duration(1)
+	for i := len(value); i < 9; i++ {
+		scale *= 10
+	}
+	return time.Duration(digits) * scale
 }
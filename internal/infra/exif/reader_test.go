@@ -0,0 +1,384 @@
+package exif
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+// tiffEntry is a single IFD entry for building fixture JPEGs.
+type tiffEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func asciiEntry(tag uint16, value string) tiffEntry {
+	data := append([]byte(value), 0)
+	return tiffEntry{tag: tag, typ: 2, count: uint32(len(data)), data: data}
+}
+
+func longEntry(tag uint16, value uint32) tiffEntry {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, value)
+	return tiffEntry{tag: tag, typ: 4, count: 1, data: data}
+}
+
+func rationalEntry(tag uint16, values ...[2]uint32) tiffEntry {
+	data := make([]byte, 0, len(values)*8)
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, v[0])
+		data = binary.LittleEndian.AppendUint32(data, v[1])
+	}
+	return tiffEntry{tag: tag, typ: 5, count: uint32(len(values)), data: data}
+}
+
+// fixtureIFDs describes the directories written into a fixture JPEG.
+type fixtureIFDs struct {
+	ifd0 []tiffEntry
+	exif []tiffEntry
+	gps  []tiffEntry
+}
+
+const (
+	tagExifPointer = 0x8769
+	tagGPSPointer  = 0x8825
+)
+
+func ifdSize(entries []tiffEntry) int {
+	size := 2 + 12*len(entries) + 4
+	for _, e := range entries {
+		if len(e.data) > 4 {
+			size += len(e.data) + len(e.data)%2
+		}
+	}
+	return size
+}
+
+func encodeIFD(entries []tiffEntry, offset int) []byte {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].tag < entries[j].tag })
+
+	buf := binary.LittleEndian.AppendUint16(nil, uint16(len(entries)))
+	dataOffset := offset + 2 + 12*len(entries) + 4
+	var data []byte
+	for _, e := range entries {
+		buf = binary.LittleEndian.AppendUint16(buf, e.tag)
+		buf = binary.LittleEndian.AppendUint16(buf, e.typ)
+		buf = binary.LittleEndian.AppendUint32(buf, e.count)
+		if len(e.data) <= 4 {
+			value := make([]byte, 4)
+			copy(value, e.data)
+			buf = append(buf, value...)
+			continue
+		}
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(dataOffset+len(data)))
+		data = append(data, e.data...)
+		if len(e.data)%2 == 1 {
+			data = append(data, 0)
+		}
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, 0)
+	return append(buf, data...)
+}
+
+// buildJPEG returns a minimal JPEG whose APP1 segment carries the given IFDs.
+func buildJPEG(ifds fixtureIFDs) []byte {
+	ifd0 := append([]tiffEntry{}, ifds.ifd0...)
+	if len(ifds.exif) > 0 {
+		ifd0 = append(ifd0, longEntry(tagExifPointer, 0))
+	}
+	if len(ifds.gps) > 0 {
+		ifd0 = append(ifd0, longEntry(tagGPSPointer, 0))
+	}
+
+	exifOffset := 8 + ifdSize(ifd0)
+	gpsOffset := exifOffset + ifdSize(ifds.exif)
+	for i := range ifd0 {
+		switch ifd0[i].tag {
+		case tagExifPointer:
+			ifd0[i] = longEntry(tagExifPointer, uint32(exifOffset))
+		case tagGPSPointer:
+			ifd0[i] = longEntry(tagGPSPointer, uint32(gpsOffset))
+		}
+	}
+
+	tiff := []byte("II*\x00")
+	tiff = binary.LittleEndian.AppendUint32(tiff, 8)
+	tiff = append(tiff, encodeIFD(ifd0, 8)...)
+	if len(ifds.exif) > 0 {
+		tiff = append(tiff, encodeIFD(ifds.exif, exifOffset)...)
+	}
+	if len(ifds.gps) > 0 {
+		tiff = append(tiff, encodeIFD(ifds.gps, gpsOffset)...)
+	}
+
+	var b bytes.Buffer
+	b.Write([]byte{0xFF, 0xD8, 0xFF, 0xE1})
+	_ = binary.Write(&b, binary.BigEndian, uint16(2+6+len(tiff)))
+	b.WriteString("Exif\x00\x00")
+	b.Write(tiff)
+	b.Write([]byte{0xFF, 0xD9})
+	return b.Bytes()
+}
+
+func writeFixture(t *testing.T, ifds fixtureIFDs) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixture.jpg")
+	if err := os.WriteFile(path, buildJPEG(ifds), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	return path
+}
+
+func TestReadMetaDecodesAllFields(t *testing.T) {
+	path := writeFixture(t, fixtureIFDs{
+		ifd0: []tiffEntry{
+			asciiEntry(0x010F, "SONY"),
+			asciiEntry(0x0110, "ILCE-7M3"),
+		},
+		exif: []tiffEntry{
+			asciiEntry(0x9003, "2024:10:02 15:01:30"),
+			asciiEntry(0x9291, "250"),
+		},
+		gps: []tiffEntry{
+			asciiEntry(0x1, "N"),
+			rationalEntry(0x2, [2]uint32{52, 1}, [2]uint32{30, 1}, [2]uint32{0, 1}),
+			asciiEntry(0x3, "E"),
+			rationalEntry(0x4, [2]uint32{13, 1}, [2]uint32{24, 1}, [2]uint32{0, 1}),
+		},
+	})
+
+	meta, err := Reader{}.ReadMeta(context.Background(), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := time.Date(2024, 10, 2, 15, 1, 30, 0, time.Local)
+	if !meta.TakenAt.Equal(want) {
+		t.Fatalf("expected TakenAt %v, got %v", want, meta.TakenAt)
+	}
+	if meta.SubSec != 250*time.Millisecond {
+		t.Fatalf("expected SubSec 250ms, got %v", meta.SubSec)
+	}
+	if meta.Make != "SONY" || meta.Model != "ILCE-7M3" {
+		t.Fatalf("unexpected make/model: %q %q", meta.Make, meta.Model)
+	}
+	if meta.GPS == nil {
+		t.Fatalf("expected GPS to be set")
+	}
+	if math.Abs(meta.GPS.Latitude-52.5) > 1e-9 || math.Abs(meta.GPS.Longitude-13.4) > 1e-9 {
+		t.Fatalf("unexpected GPS: %+v", *meta.GPS)
+	}
+}
+
+func TestReadMetaLeavesOptionalFieldsEmpty(t *testing.T) {
+	path := writeFixture(t, fixtureIFDs{
+		exif: []tiffEntry{asciiEntry(0x9003, "2024:10:02 15:01:30")},
+	})
+
+	meta, err := Reader{}.ReadMeta(context.Background(), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.Make != "" || meta.Model != "" || meta.GPS != nil || meta.SubSec != 0 {
+		t.Fatalf("expected optional fields to be empty, got %+v", meta)
+	}
+}
+
+func TestReadMetaDecodesCaptureKey(t *testing.T) {
+	path := writeFixture(t, fixtureIFDs{
+		ifd0: []tiffEntry{
+			asciiEntry(0x010F, "Canon"),
+			asciiEntry(0x0110, "Canon EOS R5"),
+		},
+		exif: []tiffEntry{
+			asciiEntry(0x9003, "2024:10:02 15:01:30"),
+			longEntry(0x9211, 12345),
+			asciiEntry(0xA431, "082021000123"),
+		},
+	})
+
+	meta, err := Reader{}.ReadMeta(context.Background(), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.BodySerial != "082021000123" || meta.ImageNumber != 12345 {
+		t.Fatalf("unexpected serial and image number: %q %d", meta.BodySerial, meta.ImageNumber)
+	}
+	if key, ok := meta.CaptureKey(); !ok || key != "Canon|Canon EOS R5|082021000123|12345" {
+		t.Fatalf("unexpected capture key %q, %v", key, ok)
+	}
+}
+
+func TestReadMetaWithoutSerialHasNoCaptureKey(t *testing.T) {
+	// Like most phones and many bodies: a serial but no image number
+	path := writeFixture(t, fixtureIFDs{
+		ifd0: []tiffEntry{asciiEntry(0x010F, "SONY")},
+		exif: []tiffEntry{
+			asciiEntry(0x9003, "2024:10:02 15:01:30"),
+			asciiEntry(0xA431, "5123456"),
+		},
+	})
+
+	meta, err := Reader{}.ReadMeta(context.Background(), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := meta.CaptureKey(); ok {
+		t.Fatalf("did not expect a capture key without an image number")
+	}
+}
+
+func TestReadMetaReadsDateTimeOriginalOnTheLocalClock(t *testing.T) {
+	path := writeFixture(t, fixtureIFDs{
+		exif: []tiffEntry{asciiEntry(0x9003, "2024:10:02 15:01:30")},
+	})
+
+	meta, err := Reader{}.ReadMeta(context.Background(), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !meta.TakenAt.Equal(time.Date(2024, 10, 2, 15, 1, 30, 0, time.Local)) {
+		t.Fatalf("unexpected time: %v", meta.TakenAt)
+	}
+}
+
+func TestReadMetaDecodesOffsetTimeOriginal(t *testing.T) {
+	path := writeFixture(t, fixtureIFDs{
+		exif: []tiffEntry{
+			asciiEntry(0x9003, "2024:03:31 23:30:00"),
+			asciiEntry(0x9010, "+02:00"),
+			asciiEntry(0x9011, "-03:30"),
+		},
+	})
+
+	meta, err := Reader{}.ReadMeta(context.Background(), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.Offset == nil || *meta.Offset != -(3*time.Hour+30*time.Minute) {
+		t.Fatalf("expected OffsetTimeOriginal -03:30, got %v", meta.Offset)
+	}
+
+	instant, ok := meta.CaptureInstant()
+	if !ok || !instant.Equal(time.Date(2024, 4, 1, 3, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected capture instant %v", instant)
+	}
+}
+
+func TestReadMetaIgnoresMalformedOffset(t *testing.T) {
+	path := writeFixture(t, fixtureIFDs{
+		exif: []tiffEntry{
+			asciiEntry(0x9003, "2024:03:31 23:30:00"),
+			asciiEntry(0x9011, "   :  "),
+		},
+	})
+
+	meta, err := Reader{}.ReadMeta(context.Background(), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.Offset != nil {
+		t.Fatalf("expected no offset, got %v", *meta.Offset)
+	}
+}
+
+func TestReadMetaRejectsZeroDate(t *testing.T) {
+	for _, value := range []string{"0000:00:00 00:00:00", "    :  :     :  :  "} {
+		path := writeFixture(t, fixtureIFDs{
+			exif: []tiffEntry{asciiEntry(0x9003, value)},
+		})
+
+		_, err := Reader{}.ReadMeta(context.Background(), path)
+		if !errors.Is(err, domain.ErrInvalidCaptureDate) {
+			t.Fatalf("%q: expected ErrInvalidCaptureDate, got %v", value, err)
+		}
+	}
+}
+
+func TestReadMetaFallsBackToDateTimeAfterZeroDate(t *testing.T) {
+	path := writeFixture(t, fixtureIFDs{
+		ifd0: []tiffEntry{asciiEntry(0x0132, "2024:10:02 15:01:30")},
+		exif: []tiffEntry{asciiEntry(0x9003, "0000:00:00 00:00:00")},
+	})
+
+	meta, err := Reader{}.ReadMeta(context.Background(), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !meta.TakenAt.Equal(time.Date(2024, 10, 2, 15, 1, 30, 0, time.Local)) {
+		t.Fatalf("unexpected time: %v", meta.TakenAt)
+	}
+}
+
+func TestReadMetaFollowsDateTagOrder(t *testing.T) {
+	// A scan: shot in 1998, digitized in 2023, edited in 2024
+	path := writeFixture(t, fixtureIFDs{
+		ifd0: []tiffEntry{asciiEntry(0x0132, "2024:03:05 09:00:00")},
+		exif: []tiffEntry{
+			asciiEntry(0x9003, "1998:07:14 12:00:00"),
+			asciiEntry(0x9004, "2023:11:20 18:30:00"),
+		},
+	})
+
+	tests := []struct {
+		name string
+		tags []domain.DateTag
+		want time.Time
+		tag  domain.DateTag
+	}{
+		{"default", nil, time.Date(1998, 7, 14, 12, 0, 0, 0, time.Local), domain.DateTagOriginal},
+		{"create first", []domain.DateTag{domain.DateTagCreate, domain.DateTagOriginal}, time.Date(2023, 11, 20, 18, 30, 0, 0, time.Local), domain.DateTagCreate},
+		{"modify only", []domain.DateTag{domain.DateTagModify}, time.Date(2024, 3, 5, 9, 0, 0, 0, time.Local), domain.DateTagModify},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := Reader{DateTags: tt.tags}.ReadMeta(context.Background(), path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !meta.TakenAt.Equal(tt.want) || meta.DateTag != tt.tag {
+				t.Fatalf("expected %v from %s, got %v from %s", tt.want, tt.tag, meta.TakenAt, meta.DateTag)
+			}
+		})
+	}
+}
+
+func TestReadMetaSkipsMissingDateTags(t *testing.T) {
+	path := writeFixture(t, fixtureIFDs{
+		exif: []tiffEntry{asciiEntry(0x9003, "2024:10:02 15:01:30")},
+	})
+
+	tags := []domain.DateTag{domain.DateTagCreate, domain.DateTagModify}
+	if _, err := (Reader{DateTags: tags}).ReadMeta(context.Background(), path); err == nil {
+		t.Fatalf("expected no date without DateTimeOriginal in the order")
+	}
+	tags = append(tags, domain.DateTagOriginal)
+	meta, err := Reader{DateTags: tags}.ReadMeta(context.Background(), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.DateTag != domain.DateTagOriginal {
+		t.Fatalf("expected DateTimeOriginal, got %s", meta.DateTag)
+	}
+}
+
+func TestThumbnailFailsWithoutEmbeddedPreview(t *testing.T) {
+	path := writeFixture(t, fixtureIFDs{
+		exif: []tiffEntry{asciiEntry(0x9003, "2024:10:02 15:01:30")},
+	})
+
+	if thumb, err := (Reader{}).Thumbnail(context.Background(), path); err == nil {
+		t.Fatalf("expected an error, got %d bytes", len(thumb))
+	}
+}
@@ -0,0 +1,74 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// ErrBirthTimeUnsupported is returned where file creation times cannot be
+// read or set, like on Linux.
+var ErrBirthTimeUnsupported = errors.New("file creation time is not supported on this platform")
+
+// probeBirthTime is the creation time ProbeBirthTime sets; it is earlier than
+// any file the probe can create.
+var probeBirthTime = time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
+// BirthTime returns the creation time of the file at path.
+func BirthTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return birthTime(info)
+}
+
+// SetBirthTime sets the creation time of the file at path to t. Its other
+// times stay unchanged.
+func SetBirthTime(path string, t time.Time) error {
+	return setBirthTime(path, t)
+}
+
+// ProbeBirthTime checks that creation times can be set on files in dir by
+// setting and reading back the creation time of a hidden probe file. Like
+// ProbeWritable it probes the nearest existing ancestor of dir.
+func ProbeBirthTime(dir string) error {
+	if !birthTimeSupported {
+		return ErrBirthTimeUnsupported
+	}
+	probeDir, err := nearestExistingDir(dir)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(probeDir, ".phopy-probe-*")
+	if err != nil {
+		return err
+	}
+	name := file.Name()
+	defer os.Remove(name)
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := setBirthTime(name, probeBirthTime); err != nil {
+		return err
+	}
+	got, err := BirthTime(name)
+	if err != nil {
+		return err
+	}
+	if !got.Truncate(time.Second).Equal(probeBirthTime) {
+		return ErrBirthTimeUnsupported
+	}
+	return nil
+}
+
+// copyBirthTime gives dst the creation time of src.
+func copyBirthTime(src os.FileInfo, dst string) error {
+	t, err := birthTime(src)
+	if err != nil {
+		return err
+	}
+	return setBirthTime(dst, t)
+}
@@ -0,0 +1,39 @@
+//go:build darwin
+
+package fs
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+const birthTimeSupported = true
+
+func birthTime(info os.FileInfo) (time.Time, error) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, ErrBirthTimeUnsupported
+	}
+	return time.Unix(stat.Birthtimespec.Unix()), nil
+}
+
+// setBirthTime relies on APFS and HFS+ moving the creation time back when
+// the modification time is set before it: the modification time is set to
+// t and then restored. Creation times later than the current one cannot be
+// set this way.
+func setBirthTime(path string, t time.Time) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ErrBirthTimeUnsupported
+	}
+	atime := time.Unix(stat.Atimespec.Unix())
+	if err := os.Chtimes(path, atime, t); err != nil {
+		return err
+	}
+	return os.Chtimes(path, atime, info.ModTime())
+}
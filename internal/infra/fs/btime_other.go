@@ -0,0 +1,19 @@
+//go:build !darwin && !windows
+
+package fs
+
+import (
+	"os"
+	"time"
+)
+
+// Linux file systems may record creation times, but none of them can be set.
+const birthTimeSupported = false
+
+func birthTime(os.FileInfo) (time.Time, error) {
+	return time.Time{}, ErrBirthTimeUnsupported
+}
+
+func setBirthTime(string, time.Time) error {
+	return ErrBirthTimeUnsupported
+}
@@ -0,0 +1,25 @@
+//go:build !darwin && !windows
+
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBirthTimeUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	if err := ProbeBirthTime(dir); !errors.Is(err, ErrBirthTimeUnsupported) {
+		t.Fatalf("expected the probe to report no support, got %v", err)
+	}
+
+	src := filepath.Join(dir, "DSC0001.ARW")
+	if err := os.WriteFile(src, []byte("raw"), 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	if err := (OSFS{PreserveBirthTime: true}).CopyFile(src, filepath.Join(dir, "copy.ARW")); !errors.Is(err, ErrBirthTimeUnsupported) {
+		t.Fatalf("expected copies to fail without a successful probe, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build darwin || windows
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopyFilePreservesBirthTime(t *testing.T) {
+	dir := t.TempDir()
+	if err := ProbeBirthTime(dir); err != nil {
+		t.Skipf("file system of %s cannot set creation times: %v", dir, err)
+	}
+
+	src := filepath.Join(dir, "DSC0001.ARW")
+	if err := os.WriteFile(src, []byte("raw"), 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	created := time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC)
+	if err := SetBirthTime(src, created); err != nil {
+		t.Fatalf("set source creation time: %v", err)
+	}
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		t.Fatalf("stat source: %v", err)
+	}
+
+	dst := filepath.Join(dir, "out", "DSC0001.ARW")
+	if err := (OSFS{PreserveBirthTime: true}).CopyFile(src, dst); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	got, err := BirthTime(dst)
+	if err != nil {
+		t.Fatalf("read creation time: %v", err)
+	}
+	if !got.Equal(created) {
+		t.Fatalf("expected creation time %v, got %v", created, got)
+	}
+	dstInfo, err := os.Stat(dst)
+	if err != nil {
+		t.Fatalf("stat copy: %v", err)
+	}
+	if dstInfo.ModTime().Before(srcInfo.ModTime()) {
+		t.Fatalf("expected the modification time of the copy to stay, got %v", dstInfo.ModTime())
+	}
+}
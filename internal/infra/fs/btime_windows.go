@@ -0,0 +1,39 @@
+//go:build windows
+
+package fs
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+const birthTimeSupported = true
+
+func birthTime(info os.FileInfo) (time.Time, error) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, ErrBirthTimeUnsupported
+	}
+	return time.Unix(0, data.CreationTime.Nanoseconds()), nil
+}
+
+func setBirthTime(path string, t time.Time) error {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	handle, err := windows.CreateFile(name, windows.FILE_WRITE_ATTRIBUTES, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE, nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	defer windows.CloseHandle(handle)
+
+	created := windows.NsecToFiletime(t.UnixNano())
+	if err := windows.SetFileTime(handle, &created, nil, nil); err != nil {
+		return &os.PathError{Op: "setfiletime", Path: path, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package fs
+
+import (
+	"strings"
+
+	"phopy/internal/domain"
+)
+
+// nameLimits are the longest file names of the file system families phopy
+// recognizes. Families are lowercase, like "ext4" or "ntfs".
+var nameLimits = map[string]domain.PathLimits{
+	"ext4":  {MaxName: 255, NameUnit: domain.LengthBytes},
+	"btrfs": {MaxName: 255, NameUnit: domain.LengthBytes},
+	"xfs":   {MaxName: 255, NameUnit: domain.LengthBytes},
+	"zfs":   {MaxName: 255, NameUnit: domain.LengthBytes},
+	"f2fs":  {MaxName: 255, NameUnit: domain.LengthBytes},
+	"tmpfs": {MaxName: 255, NameUnit: domain.LengthBytes},
+	"nfs":   {MaxName: 255, NameUnit: domain.LengthBytes},
+	"apfs":  {MaxName: 255, NameUnit: domain.LengthUTF16},
+	"hfs":   {MaxName: 255, NameUnit: domain.LengthUTF16},
+	"ntfs":  {MaxName: 255, NameUnit: domain.LengthUTF16},
+	"refs":  {MaxName: 255, NameUnit: domain.LengthUTF16},
+	"exfat": {MaxName: 255, NameUnit: domain.LengthUTF16},
+	"fat":   {MaxName: 255, NameUnit: domain.LengthUTF16},
+	"smb":   {MaxName: 255, NameUnit: domain.LengthUTF16},
+}
+
+// unknownNameLimit applies to file systems not in nameLimits. 255 bytes
+// is the common denominator of all of them.
+var unknownNameLimit = domain.PathLimits{MaxName: 255, NameUnit: domain.LengthBytes}
+
+// PathLimitsOf returns the limits of targets on the file system family,
+// with the path limit of this operating system.
+func PathLimitsOf(family string) domain.PathLimits {
+	limits, ok := nameLimits[family]
+	if !ok {
+		limits = unknownNameLimit
+		family = ""
+	}
+	limits.FileSystem = family
+	limits.MaxPath, limits.PathUnit = maxPath, maxPathUnit
+	return limits
+}
+
+// ProbePathLimits detects the file system family of dir, or of its nearest
+// existing ancestor when it does not exist yet, and returns its limits.
+func ProbePathLimits(dir string) (domain.PathLimits, error) {
+	existing, err := nearestExistingDir(dir)
+	if err != nil {
+		return domain.PathLimits{}, err
+	}
+	family, err := fileSystemFamily(existing)
+	if err != nil {
+		return domain.PathLimits{}, err
+	}
+	return PathLimitsOf(family), nil
+}
+
+// networkFamilies are the families of file systems whose every access is
+// a round trip to another machine.
+var networkFamilies = map[string]bool{"nfs": true, "smb": true}
+
+// IsNetworkFamily reports whether the file system family, as in
+// domain.PathLimits, is network storage.
+func IsNetworkFamily(family string) bool {
+	return networkFamilies[family]
+}
+
+// normalizeFamily maps the names operating systems report for a file
+// system to its family in nameLimits.
+func normalizeFamily(name string) string {
+	name = strings.ToLower(name)
+	switch name {
+	case "fat12", "fat16", "fat32", "msdos", "vfat":
+		return "fat"
+	case "smbfs", "cifs":
+		return "smb"
+	}
+	return name
+}
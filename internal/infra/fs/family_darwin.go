@@ -0,0 +1,25 @@
+//go:build darwin
+
+package fs
+
+import (
+	"golang.org/x/sys/unix"
+
+	"phopy/internal/domain"
+)
+
+// maxPath is PATH_MAX without its terminating NUL.
+const (
+	maxPath     = 1023
+	maxPathUnit = domain.LengthBytes
+)
+
+// fileSystemFamily returns the family of the file system at path by its
+// type name, like "apfs" or "msdos".
+func fileSystemFamily(path string) (string, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return "", err
+	}
+	return normalizeFamily(unix.ByteSliceToString(stat.Fstypename[:])), nil
+}
@@ -0,0 +1,43 @@
+//go:build linux
+
+package fs
+
+import (
+	"golang.org/x/sys/unix"
+
+	"phopy/internal/domain"
+)
+
+// maxPath is PATH_MAX without its terminating NUL.
+const (
+	maxPath     = 4095
+	maxPathUnit = domain.LengthBytes
+)
+
+// familyMagic maps the statfs magic numbers of file systems to their
+// family. ext2, ext3 and ext4 share one.
+var familyMagic = map[int64]string{
+	unix.EXT4_SUPER_MAGIC:  "ext4",
+	unix.BTRFS_SUPER_MAGIC: "btrfs",
+	unix.XFS_SUPER_MAGIC:   "xfs",
+	0x2fc12fc1:             "zfs", // not in x/sys
+	unix.F2FS_SUPER_MAGIC:  "f2fs",
+	unix.TMPFS_MAGIC:       "tmpfs",
+	unix.NFS_SUPER_MAGIC:   "nfs",
+	unix.EXFAT_SUPER_MAGIC: "exfat",
+	unix.MSDOS_SUPER_MAGIC: "fat",
+	0x5346544e:             "ntfs", // the read-only ntfs driver
+	0x7366746e:             "ntfs", // ntfs3, not in x/sys
+	unix.CIFS_SUPER_MAGIC:  "smb",
+	unix.SMB2_SUPER_MAGIC:  "smb",
+}
+
+// fileSystemFamily returns the family of the file system at path, empty
+// for those without an entry in familyMagic, like FUSE mounts.
+func fileSystemFamily(path string) (string, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return "", err
+	}
+	return familyMagic[int64(stat.Type)], nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !windows
+
+package fs
+
+import "phopy/internal/domain"
+
+// maxPath is not checked where the limit is not known.
+const (
+	maxPath     = 0
+	maxPathUnit = domain.LengthBytes
+)
+
+func fileSystemFamily(string) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,33 @@
+package fs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"phopy/internal/domain"
+)
+
+func TestPathLimitsOfFamilies(t *testing.T) {
+	if limits := PathLimitsOf("ext4"); limits.FileSystem != "ext4" || limits.MaxName != 255 || limits.NameUnit != domain.LengthBytes {
+		t.Fatalf("unexpected ext4 limits: %+v", limits)
+	}
+	if limits := PathLimitsOf(normalizeFamily("NTFS")); limits.FileSystem != "ntfs" || limits.NameUnit != domain.LengthUTF16 {
+		t.Fatalf("unexpected NTFS limits: %+v", limits)
+	}
+	if limits := PathLimitsOf(normalizeFamily("FAT32")); limits.FileSystem != "fat" {
+		t.Fatalf("expected FAT32 to be FAT, got %+v", limits)
+	}
+	if limits := PathLimitsOf("fuseblk"); limits.FileSystem != "" || limits.MaxName != 255 {
+		t.Fatalf("expected the common limit for unknown file systems, got %+v", limits)
+	}
+}
+
+func TestProbePathLimitsOfAMissingTarget(t *testing.T) {
+	limits, err := ProbePathLimits(filepath.Join(t.TempDir(), "archive", "2024"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limits.MaxName == 0 {
+		t.Fatalf("expected a name limit, got %+v", limits)
+	}
+}
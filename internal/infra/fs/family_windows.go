@@ -0,0 +1,34 @@
+//go:build windows
+
+package fs
+
+import (
+	"golang.org/x/sys/windows"
+
+	"phopy/internal/domain"
+)
+
+// maxPath is the limit of extended-length paths, which Go uses for long
+// paths on its own.
+const (
+	maxPath     = 32767
+	maxPathUnit = domain.LengthUTF16
+)
+
+// fileSystemFamily returns the family of the volume path is on by the file
+// system name Windows reports, like "NTFS" or "exFAT".
+func fileSystemFamily(path string) (string, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return "", err
+	}
+	root := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumePathName(name, &root[0], uint32(len(root))); err != nil {
+		return "", err
+	}
+	fsName := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumeInformation(&root[0], nil, 0, nil, nil, nil, &fsName[0], uint32(len(fsName))); err != nil {
+		return "", err
+	}
+	return normalizeFamily(windows.UTF16ToString(fsName)), nil
+}
@@ -0,0 +1,60 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// UpdateLatestLink points targetDir/name at dir, replacing an earlier link
+// atomically so readers never see it missing. On Windows, where symlinks
+// need extra privileges, name.txt receives the path instead.
+func UpdateLatestLink(targetDir, name, dir string) error {
+	rel, err := filepath.Rel(targetDir, dir)
+	if err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		return replaceFile(filepath.Join(targetDir, name+".txt"), []byte(dir+"\n"))
+	}
+
+	link := filepath.Join(targetDir, name)
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s exists and is not a symlink", link)
+	}
+
+	tmp := filepath.Join(targetDir, fmt.Sprintf(".%s.tmp-%d", name, os.Getpid()))
+	_ = os.Remove(tmp)
+	if err := os.Symlink(rel, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// replaceFile writes data to path through a temporary file and a rename.
+func replaceFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestUpdateLatestLinkReplacesEarlierLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks are replaced by latest.txt on Windows")
+	}
+	target := t.TempDir()
+	first := filepath.Join(target, "2024", "2024-10-01")
+	second := filepath.Join(target, "2024", "2024-10-02")
+	for _, dir := range []string{first, second} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+
+	if err := UpdateLatestLink(target, "latest", first); err != nil {
+		t.Fatalf("first link: %v", err)
+	}
+	if err := UpdateLatestLink(target, "latest", second); err != nil {
+		t.Fatalf("replace link: %v", err)
+	}
+
+	dest, err := os.Readlink(filepath.Join(target, "latest"))
+	if err != nil {
+		t.Fatalf("readlink: %v", err)
+	}
+	if dest != filepath.Join("2024", "2024-10-02") {
+		t.Fatalf("expected a relative link to the second folder, got %q", dest)
+	}
+
+	entries, err := os.ReadDir(target)
+	if err != nil {
+		t.Fatalf("read target: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected only the year folder and the link, got %d entries", len(entries))
+	}
+}
+
+func TestUpdateLatestLinkKeepsRegularFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks are replaced by latest.txt on Windows")
+	}
+	target := t.TempDir()
+	existing := filepath.Join(target, "latest")
+	if err := os.Mkdir(existing, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	if err := UpdateLatestLink(target, "latest", filepath.Join(target, "2024-10-02")); err == nil {
+		t.Fatalf("expected an error instead of replacing a real directory")
+	}
+	if info, err := os.Lstat(existing); err != nil || !info.IsDir() {
+		t.Fatalf("expected the directory to be untouched")
+	}
+}
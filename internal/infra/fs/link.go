@@ -0,0 +1,33 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrCrossDevice is returned by Link when the existing file and the new
+// path are on different volumes, which hard links cannot span.
+var ErrCrossDevice = errors.New("hard links cannot cross volumes")
+
+// Link creates path as a hard link to existing. It checks that the nearest
+// existing directory of path is on the volume of existing before creating
+// any directories, so a failed link leaves nothing behind.
+func (OSFS) Link(existing, path string) error {
+	dir, err := nearestExistingDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	same, err := sameVolume(existing, dir)
+	if err != nil {
+		return err
+	}
+	if !same {
+		return fmt.Errorf("link %s to %s: %w", path, existing, ErrCrossDevice)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.Link(existing, path)
+}
@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOSFSLinkSharesTheFile(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, "2024-10-01", "DSC0001.ARW")
+	if err := os.MkdirAll(filepath.Dir(existing), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(existing, []byte("raw"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	path := filepath.Join(root, "2024-10-02", "DSC0001.ARW")
+	if err := (OSFS{}).Link(existing, path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a, err := os.Stat(existing)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	b, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if !os.SameFile(a, b) {
+		t.Fatal("expected the link to share the file of the existing copy")
+	}
+	if err := (OSFS{}).Link(existing, path); err == nil {
+		t.Fatal("expected an error for an existing target")
+	}
+}
+
+func TestOSFSLinkRefusesToCrossVolumes(t *testing.T) {
+	// /dev/shm is a tmpfs on Linux and usually not the volume of TempDir
+	other, err := os.MkdirTemp("/dev/shm", "phopy-link-*")
+	if err != nil {
+		t.Skipf("no second volume: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(other) })
+	root := t.TempDir()
+	if same, err := sameVolume(root, other); err != nil || same {
+		t.Skipf("%s is on the volume of %s", other, root)
+	}
+
+	existing := filepath.Join(other, "DSC0001.ARW")
+	if err := os.WriteFile(existing, []byte("raw"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	path := filepath.Join(root, "2024-10-02", "DSC0001.ARW")
+	err = (OSFS{}).Link(existing, path)
+	if !errors.Is(err, ErrCrossDevice) {
+		t.Fatalf("expected ErrCrossDevice, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Fatalf("expected no directories left behind, got %v", err)
+	}
+
+	// The copy the executor falls back to works across volumes
+	if err := (OSFS{}).CopyFile(existing, path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sameVolume compares the volume names of both paths, like C: or a UNC
+// share. Mounted folders are not resolved; os.Link reports those.
+func sameVolume(a, b string) (bool, error) {
+	if _, err := os.Stat(a); err != nil {
+		return false, err
+	}
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false, err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(filepath.VolumeName(absA), filepath.VolumeName(absB)), nil
+}
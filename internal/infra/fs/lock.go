@@ -0,0 +1,94 @@
+package fs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LockFile is where the execution lock of a target lives, relative to it.
+const LockFile = ".phopy/lock"
+
+// errWouldBlock is returned by tryLock when another process holds the lock.
+var errWouldBlock = errors.New("lock is held")
+
+// LockInfo identifies the process holding a target lock.
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// LockedError is returned by AcquireLock when another run holds the lock.
+type LockedError struct {
+	Path string
+	Info LockInfo
+}
+
+func (e *LockedError) Error() string {
+	if e.Info.PID == 0 {
+		return fmt.Sprintf("target is locked by another phopy run (%s)", e.Path)
+	}
+	return fmt.Sprintf("target is locked by phopy (pid %d, started %s)", e.Info.PID, e.Info.StartedAt.Format("2006-01-02 15:04:05"))
+}
+
+// Lock is an advisory lock that keeps two runs from writing into the same
+// target at once.
+type Lock struct {
+	file *os.File
+}
+
+// AcquireLock takes the execution lock of targetDir without waiting. The OS
+// lock (flock, LockFileEx) is released when the holder exits, even after a
+// crash. On file systems without OS locks the recorded PID decides: a lock
+// of a process that no longer runs is taken over.
+func AcquireLock(targetDir string) (*Lock, error) {
+	path := filepath.Join(targetDir, LockFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tryLock(file); err != nil {
+		info := readLockInfo(file)
+		if errors.Is(err, errWouldBlock) || (info.PID != 0 && info.PID != os.Getpid() && processAlive(info.PID)) {
+			file.Close()
+			return nil, &LockedError{Path: path, Info: info}
+		}
+		// No OS lock support and the recorded holder is gone: take over
+	}
+
+	info := LockInfo{PID: os.Getpid(), StartedAt: time.Now()}
+	data, err := json.Marshal(info)
+	if err == nil {
+		err = file.Truncate(0)
+	}
+	if err == nil {
+		_, err = file.WriteAt(append(data, '\n'), 0)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &Lock{file: file}, nil
+}
+
+// Release clears and unlocks the lock.
+func (l *Lock) Release() error {
+	_ = l.file.Truncate(0)
+	unlock(l.file)
+	return l.file.Close()
+}
+
+func readLockInfo(file *os.File) LockInfo {
+	var info LockInfo
+	data := make([]byte, 256)
+	n, _ := file.ReadAt(data, 0)
+	_ = json.Unmarshal(data[:n], &info)
+	return info
+}
@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireLockFailsFastWhileHeld(t *testing.T) {
+	target := t.TempDir()
+
+	acquired := make(chan *Lock)
+	errs := make(chan error)
+	go func() {
+		lock, err := AcquireLock(target)
+		if err != nil {
+			errs <- err
+			return
+		}
+		acquired <- lock
+	}()
+
+	var lock *Lock
+	select {
+	case lock = <-acquired:
+	case err := <-errs:
+		t.Fatalf("first acquisition: %v", err)
+	}
+
+	start := time.Now()
+	_, err := AcquireLock(target)
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("expected a LockedError, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("expected the second acquisition to fail fast")
+	}
+	if locked.Info.PID != os.Getpid() || locked.Info.StartedAt.IsZero() {
+		t.Fatalf("expected the holder to be named, got %+v", locked.Info)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	again, err := AcquireLock(target)
+	if err != nil {
+		t.Fatalf("expected the lock to be free after release: %v", err)
+	}
+	_ = again.Release()
+}
+
+func TestAcquireLockTakesOverStaleLockFile(t *testing.T) {
+	target := t.TempDir()
+	path := filepath.Join(target, LockFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	// Left behind by a crashed run; nothing holds the OS lock anymore
+	if err := os.WriteFile(path, []byte(`{"pid":999999,"started_at":"2024-10-02T15:00:00Z"}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	lock, err := AcquireLock(target)
+	if err != nil {
+		t.Fatalf("expected a stale lock to be taken over: %v", err)
+	}
+	defer lock.Release()
+
+	if info := readLockInfo(lock.file); info.PID != os.Getpid() {
+		t.Fatalf("expected the lock to record this process, got %+v", info)
+	}
+}
@@ -1,14 +1,29 @@
 package fs
 
 import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	stdhash "hash"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"syscall"
+
+	"phopy/internal/hash"
 )
 
-type OSFS struct{}
+type OSFS struct {
+	// PreserveBirthTime gives copies the creation time of their source.
+	// Check with ProbeBirthTime first; where it is unsupported copies fail.
+	PreserveBirthTime bool
+	// Fsync flushes every copy to disk before it counts as written.
+	Fsync bool
+}
 
+// WalkDir walks root with filepath.WalkDir, which reads every directory in
+// full and sorts it by name.
 func (OSFS) WalkDir(root string, fn fs.WalkDirFunc) error {
 	return filepath.WalkDir(root, fn)
 }
@@ -32,31 +47,199 @@ func (OSFS) MkdirAll(path string, perm fs.FileMode) error {
 	return os.MkdirAll(path, perm)
 }
 
-func (OSFS) CopyFile(src, dst string) error {
+func (fsys OSFS) CopyFile(src, dst string) error {
+	return fsys.CopyFileProgress(src, dst, nil)
+}
+
+// CopyError is a copy that failed at Op, one of "open source", "stat
+// source", "create folder", "create target", "read source", "write", "sync
+// target", "close target" or "copy birth time". It unwraps to the error of
+// that step, so errors.Is still finds e.g. syscall.ENOSPC.
+type CopyError struct {
+	Op  string
+	Src string
+	Dst string
+	Err error
+}
+
+func (e *CopyError) Error() string {
+	// Both paths are named already; the path of a PathError would repeat
+	// one of them
+	cause := e.Err
+	var pathErr *fs.PathError
+	if errors.As(cause, &pathErr) {
+		cause = pathErr.Err
+	}
+	return fmt.Sprintf("copy %s to %s: %s: %v", e.Src, e.Dst, e.Op, cause)
+}
+
+func (e *CopyError) Unwrap() error {
+	return e.Err
+}
+
+// CopyFileProgress copies like CopyFile and reports the bytes written so far
+// after every chunk. Its errors are *CopyError.
+func (fsys OSFS) CopyFileProgress(src, dst string, onProgress func(written int64)) error {
+	return fsys.copyFile(src, dst, nil, onProgress)
+}
+
+// CopyFileHash copies like CopyFileProgress, onProgress may be nil, and
+// returns the hex digest with algorithm of the bytes read from src.
+func (fsys OSFS) CopyFileHash(src, dst string, algorithm hash.Algorithm, onProgress func(written int64)) (string, error) {
+	h := algorithm.New()
+	if err := fsys.copyFile(src, dst, h, onProgress); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyFile copies src to dst, writing what it reads to h as well, if set.
+func (fsys OSFS) copyFile(src, dst string, h stdhash.Hash, onProgress func(written int64)) error {
+	fail := func(op string, err error) error {
+		return &CopyError{Op: op, Src: src, Dst: dst, Err: err}
+	}
+
 	srcFile, err := os.Open(src)
 	if err != nil {
-		return err
+		return fail("open source", err)
 	}
 	defer srcFile.Close()
 
 	info, err := srcFile.Stat()
 	if err != nil {
-		return err
+		return fail("stat source", err)
+	}
+	// The kernel copy below reports reading a folder as a failed write
+	if info.IsDir() {
+		return fail("read source", errors.New("is a directory"))
 	}
 
 	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
-		return err
+		return fail("create folder", err)
 	}
 
 	dstFile, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
 	if err != nil {
-		return err
+		return fail("create target", err)
 	}
 	defer dstFile.Close()
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
+	// The files are handed to io.Copy unwrapped where possible, so the
+	// kernel can copy without reading the data into memory
+	var w io.Writer = dstFile
+	if onProgress != nil {
+		w = &progressWriter{w: dstFile, onProgress: onProgress}
+	}
+	var r io.Reader = srcFile
+	if h != nil {
+		r = io.TeeReader(srcFile, h)
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		// io.Copy does not tell a failed read from a failed write, the
+		// operation of the file error does
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) && pathErr.Op == "read" {
+			return fail("read source", err)
+		}
+		return fail("write", err)
+	}
+	if fsys.Fsync {
+		if err := dstFile.Sync(); err != nil {
+			return fail("sync target", err)
+		}
+	}
+	// Some file systems, like network shares, report a failed write only
+	// when the file is closed
+	if err := dstFile.Close(); err != nil {
+		return fail("close target", err)
+	}
+	if !fsys.PreserveBirthTime {
+		return nil
+	}
+	if err := copyBirthTime(info, dst); err != nil {
+		return fail("copy birth time", err)
+	}
+	return nil
+}
+
+// progressWriter reports the running byte count of writes to w.
+type progressWriter struct {
+	w          io.Writer
+	written    int64
+	onProgress func(written int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.onProgress(p.written)
+	return n, err
+}
+
+func (OSFS) ReadHeader(path string, n int) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, n)
+	read, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+// HashFile returns the hex digest of the content of the file at path.
+func (OSFS) HashFile(path string, algorithm hash.Algorithm) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return algorithm.Sum(file)
+}
+
+// ProbeWritable checks that files can be created in dir by creating and
+// removing a hidden probe file. When dir does not exist yet, the nearest
+// existing ancestor is probed instead, since that is where MkdirAll will
+// have to write.
+func ProbeWritable(dir string) error {
+	probeDir, err := nearestExistingDir(dir)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	file, err := os.CreateTemp(probeDir, ".phopy-probe-*")
+	if err != nil {
+		return err
+	}
+	name := file.Name()
+	closeErr := file.Close()
+	if err := os.Remove(name); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func nearestExistingDir(path string) (string, error) {
+	current := filepath.Clean(path)
+	for {
+		info, err := os.Stat(current)
+		if err == nil {
+			if !info.IsDir() {
+				return "", &fs.PathError{Op: "probe", Path: current, Err: syscall.ENOTDIR}
+			}
+			return current, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", err
+		}
+		current = parent
+	}
 }
@@ -0,0 +1,132 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+
+	"phopy/internal/hash"
+	"phopy/phopytest"
+)
+
+func TestOSFSWalksInLexicalOrder(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{"DCIM/101MSDCF/DSC0003.ARW", "DCIM/100MSDCF/DSC0002.JPG", "DCIM/100MSDCF/DSC0001.ARW", "MISC/notes.txt", "a.txt"} {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := phopytest.CheckWalkOrder(OSFS{}, root); err != nil {
+		t.Fatalf("unexpected walk order: %v", err)
+	}
+}
+
+func TestOSFSSyncsCopiesAndDirectories(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "DSC0001.ARW")
+	if err := os.WriteFile(src, []byte("raw"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	dst := filepath.Join(root, "archive", "2024-10-02", "DSC0001.ARW")
+	fsys := OSFS{Fsync: true}
+	if err := fsys.CopyFile(src, dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := fsys.SyncDir(filepath.Dir(dst)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := fsys.SyncDir(filepath.Join(root, "missing")); err == nil && runtime.GOOS != "windows" {
+		t.Fatal("expected an error for a missing directory")
+	}
+}
+
+func TestOSFSCopyFileNamesTheFailedStep(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "DSC0001.ARW")
+	if err := os.WriteFile(src, []byte("raw"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	notADir := filepath.Join(root, "archive")
+	if err := os.WriteFile(notADir, nil, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	aDir := filepath.Join(root, "taken")
+	if err := os.Mkdir(aDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		src, dst string
+		op       string
+		is       error
+	}{
+		{"missing source", filepath.Join(root, "missing.ARW"), filepath.Join(root, "out", "a.ARW"), "open source", os.ErrNotExist},
+		{"file in the way of the folder", src, filepath.Join(notADir, "2024", "DSC0001.ARW"), "create folder", nil},
+		{"folder in the way of the target", src, aDir, "create target", nil},
+		{"source is a folder", aDir, filepath.Join(root, "out", "b.ARW"), "read source", nil},
+	}
+	if runtime.GOOS == "linux" {
+		// Every write to /dev/full fails with ENOSPC
+		tests = append(tests, struct {
+			name     string
+			src, dst string
+			op       string
+			is       error
+		}{"full disk", src, "/dev/full", "write", syscall.ENOSPC})
+	}
+	for _, tt := range tests {
+		// With progress the files are wrapped, without it io.Copy gets
+		// them as they are
+		for _, err := range []error{
+			OSFS{}.CopyFile(tt.src, tt.dst),
+			OSFS{}.CopyFileProgress(tt.src, tt.dst, func(int64) {}),
+		} {
+			var copyErr *CopyError
+			if !errors.As(err, &copyErr) {
+				t.Fatalf("%s: expected a CopyError, got %v", tt.name, err)
+			}
+			want := "copy " + tt.src + " to " + tt.dst + ": " + tt.op + ": "
+			if copyErr.Op != tt.op || !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("%s: expected %q, got %q", tt.name, want, err.Error())
+			}
+			if tt.is != nil && !errors.Is(err, tt.is) {
+				t.Errorf("%s: expected the error to be %v, got %v", tt.name, tt.is, err)
+			}
+		}
+	}
+}
+
+func TestOSFSCopyFileHashHashesTheSourceRead(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "DSC0001.ARW")
+	if err := os.WriteFile(src, []byte("raw"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	sum, err := OSFS{}.CopyFileHash(src, filepath.Join(dir, "copy", "DSC0001.ARW"), hash.SHA256, nil)
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	want, err := OSFS{}.HashFile(src, hash.SHA256)
+	if err != nil || sum != want {
+		t.Fatalf("expected %s, got %s (%v)", want, sum, err)
+	}
+}
+
+func TestOSFSReportsFreeSpace(t *testing.T) {
+	free, err := OSFS{}.FreeSpace(t.TempDir())
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("free space is not reported on " + runtime.GOOS)
+	}
+	if err != nil || free <= 0 {
+		t.Fatalf("expected free space in the temp dir, got %d, %v", free, err)
+	}
+}
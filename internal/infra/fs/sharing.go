@@ -0,0 +1,8 @@
+package fs
+
+// Locked reports whether another process holds the file at path open in a
+// way that makes copying it fail. Only Windows enforces this; elsewhere
+// files are never locked.
+func (OSFS) Locked(path string) (bool, error) {
+	return sharingLocked(path)
+}
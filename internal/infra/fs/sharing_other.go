@@ -0,0 +1,7 @@
+//go:build !windows
+
+package fs
+
+func sharingLocked(string) (bool, error) {
+	return false, nil
+}
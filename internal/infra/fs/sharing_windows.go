@@ -0,0 +1,26 @@
+//go:build windows
+
+package fs
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// sharingLocked opens path for reading while denying writers, which fails
+// with a sharing violation as long as another process writes the file.
+func sharingLocked(path string) (bool, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return false, err
+	}
+	handle, err := windows.CreateFile(name, windows.GENERIC_READ, windows.FILE_SHARE_READ, nil, windows.OPEN_EXISTING, windows.FILE_ATTRIBUTE_NORMAL, 0)
+	if errors.Is(err, windows.ERROR_SHARING_VIOLATION) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, windows.CloseHandle(handle)
+}
@@ -0,0 +1,14 @@
+package fs
+
+import "os"
+
+// Remove deletes the file at path.
+func (OSFS) Remove(path string) error {
+	return os.Remove(path)
+}
+
+// FreeSpace returns the bytes the volume holding path has free for the
+// user, leaving out blocks reserved for the system.
+func (OSFS) FreeSpace(path string) (int64, error) {
+	return freeSpace(path)
+}
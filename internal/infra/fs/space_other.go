@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package fs
+
+import "errors"
+
+func freeSpace(string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
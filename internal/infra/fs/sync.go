@@ -0,0 +1,19 @@
+package fs
+
+import "os"
+
+// SyncDir flushes the entries of the directory at path to disk. Windows
+// cannot sync directories and commits their entries with the files.
+func (OSFS) SyncDir(path string) error {
+	return syncDir(path)
+}
+
+// SyncFile flushes the content of the file at path to disk.
+func SyncFile(path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package fs
+
+import "os"
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
@@ -0,0 +1,7 @@
+//go:build windows
+
+package fs
+
+func syncDir(string) error {
+	return nil
+}
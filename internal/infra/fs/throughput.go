@@ -0,0 +1,48 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// benchmarkSize is how much MeasureThroughput writes, in chunks of
+// benchmarkChunk.
+const (
+	benchmarkSize  = 4 << 20
+	benchmarkChunk = 1 << 20
+)
+
+// MeasureThroughput estimates how fast files can be written to dir, in bytes
+// per second, by writing, syncing and removing a hidden file of a few MB.
+// Like ProbeWritable it measures in the nearest existing ancestor of dir.
+func MeasureThroughput(dir string) (float64, error) {
+	probeDir, err := nearestExistingDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := os.CreateTemp(probeDir, ".phopy-benchmark-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	chunk := make([]byte, benchmarkChunk)
+	start := time.Now()
+	for written := 0; written < benchmarkSize; written += len(chunk) {
+		if _, err := file.Write(chunk); err != nil {
+			return 0, err
+		}
+	}
+	// Without the sync only the page cache would be measured
+	if err := file.Sync(); err != nil {
+		return 0, err
+	}
+	elapsed := time.Since(start)
+	if elapsed <= 0 {
+		return 0, errors.New("benchmark finished too fast to measure")
+	}
+	return benchmarkSize / elapsed.Seconds(), nil
+}
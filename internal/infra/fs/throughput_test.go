@@ -0,0 +1,25 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMeasureThroughputLeavesNothingBehind(t *testing.T) {
+	root := t.TempDir()
+	throughput, err := MeasureThroughput(filepath.Join(root, "not", "yet", "created"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if throughput <= 0 {
+		t.Fatalf("expected a positive throughput, got %v", throughput)
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected the benchmark file to be removed, got %v", entries)
+	}
+}
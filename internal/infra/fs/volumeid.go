@@ -0,0 +1,10 @@
+package fs
+
+import "phopy/internal/domain"
+
+// ProbeVolume returns the label and serial of the volume dir is on, so a
+// memory card can be recognized under any mount path. Either is empty when
+// the platform does not report it.
+func ProbeVolume(dir string) (domain.Volume, error) {
+	return volumeIdentity(dir)
+}
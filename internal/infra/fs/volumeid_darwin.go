@@ -0,0 +1,47 @@
+//go:build darwin
+
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"phopy/internal/domain"
+)
+
+// volumeIdentity asks diskutil for the volume UUID of dir, which FAT and
+// exFAT cards derive from their serial number. The label is the name the
+// volume is mounted under when diskutil has none.
+func volumeIdentity(dir string) (domain.Volume, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return domain.Volume{}, err
+	}
+	volume := domain.Volume{Label: filepath.Base(unix.ByteSliceToString(stat.Mntonname[:]))}
+	out, err := exec.Command("diskutil", "info", unix.ByteSliceToString(stat.Mntonname[:])).Output()
+	if err != nil {
+		return volume, nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Volume Name":
+			if value != "" {
+				volume.Label = value
+			}
+		case "Volume UUID":
+			volume.Serial = value
+		}
+	}
+	return volume, nil
+}
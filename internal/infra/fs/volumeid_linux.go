@@ -0,0 +1,60 @@
+//go:build linux
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"phopy/internal/domain"
+)
+
+// volumeIdentity finds the device of dir among the links udev keeps below
+// /dev/disk, named by file system UUID and label.
+func volumeIdentity(dir string) (domain.Volume, error) {
+	var stat unix.Stat_t
+	if err := unix.Stat(dir, &stat); err != nil {
+		return domain.Volume{}, err
+	}
+	return domain.Volume{
+		Label:  unescapeUdev(diskLink("/dev/disk/by-label", uint64(stat.Dev))),
+		Serial: diskLink("/dev/disk/by-uuid", uint64(stat.Dev)),
+	}, nil
+}
+
+// diskLink returns the name of the link in dir to the device dev, empty
+// when there is none.
+func diskLink(dir string, dev uint64) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		var stat unix.Stat_t
+		if unix.Stat(filepath.Join(dir, entry.Name()), &stat) == nil && uint64(stat.Rdev) == dev {
+			return entry.Name()
+		}
+	}
+	return ""
+}
+
+// unescapeUdev decodes the \xHH escapes udev writes for characters like
+// spaces in link names.
+func unescapeUdev(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+3 < len(name) && name[i+1] == 'x' {
+			if c, err := strconv.ParseUint(name[i+2:i+4], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,18 @@
+package fs
+
+import "testing"
+
+func TestUnescapeUdev(t *testing.T) {
+	tests := map[string]string{
+		"EOS_DIGITAL":        "EOS_DIGITAL",
+		`NO\x20NAME`:         "NO NAME",
+		`Sommer\x20\xc3\xbc`: "Sommer ü",
+		`broken\x2`:          `broken\x2`,
+		`odd\xzz`:            `odd\xzz`,
+	}
+	for in, want := range tests {
+		if got := unescapeUdev(in); got != want {
+			t.Errorf("unescapeUdev(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !windows
+
+package fs
+
+import (
+	"os"
+
+	"phopy/internal/domain"
+)
+
+// volumeIdentity knows nothing about the volumes of other platforms.
+func volumeIdentity(dir string) (domain.Volume, error) {
+	_, err := os.Stat(dir)
+	return domain.Volume{}, err
+}
@@ -0,0 +1,14 @@
+package fs
+
+import "testing"
+
+func TestProbeVolumeOfATemporaryDirectory(t *testing.T) {
+	// Build machines rarely have a labelled volume; probing must still
+	// work and only leave out what it cannot find
+	if _, err := ProbeVolume(t.TempDir()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ProbeVolume("/does/not/exist"); err == nil {
+		t.Fatalf("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package fs
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+
+	"phopy/internal/domain"
+)
+
+// volumeIdentity returns the label and serial number Windows reports for
+// the volume of dir, the serial formatted like vol shows it.
+func volumeIdentity(dir string) (domain.Volume, error) {
+	name, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return domain.Volume{}, err
+	}
+	root := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumePathName(name, &root[0], uint32(len(root))); err != nil {
+		return domain.Volume{}, err
+	}
+	label := make([]uint16, windows.MAX_PATH+1)
+	var serial uint32
+	if err := windows.GetVolumeInformation(&root[0], &label[0], uint32(len(label)), &serial, nil, nil, nil, 0); err != nil {
+		return domain.Volume{}, err
+	}
+	return domain.Volume{
+		Label:  windows.UTF16ToString(label),
+		Serial: fmt.Sprintf("%04X-%04X", serial>>16, serial&0xffff),
+	}, nil
+}
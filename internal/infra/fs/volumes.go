@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// VolumeRoots returns the directories removable media is mounted below on
+// macOS and common Linux desktops.
+func VolumeRoots() []string {
+	roots := []string{"/Volumes", "/media"}
+	if user := os.Getenv("USER"); user != "" {
+		roots = append(roots, filepath.Join("/media", user), filepath.Join("/run/media", user))
+	}
+	return roots
+}
+
+// CardVolumes returns the volumes below roots that contain a DCIM folder,
+// most recently modified first.
+func CardVolumes(roots []string) []string {
+	type volume struct {
+		path    string
+		modTime time.Time
+	}
+
+	var volumes []volume
+	for _, root := range roots {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			path := filepath.Join(root, entry.Name())
+			info, err := os.Stat(filepath.Join(path, "DCIM"))
+			if err != nil || !info.IsDir() {
+				continue
+			}
+			volumes = append(volumes, volume{path: path, modTime: info.ModTime()})
+		}
+	}
+
+	sort.SliceStable(volumes, func(i, j int) bool {
+		return volumes[i].modTime.After(volumes[j].modTime)
+	})
+
+	paths := make([]string, len(volumes))
+	for i, v := range volumes {
+		paths[i] = v.path
+	}
+	return paths
+}
+
+// DirNames returns the names of the directories directly below dir.
+func DirNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,30 @@
+package fs
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrXattrUnsupported is returned where extended attributes cannot be
+// stored, like on Windows or file systems such as FAT.
+var ErrXattrUnsupported = errors.New("extended attributes are not supported on this file system")
+
+// Stamp sets the extended attributes attrs, by name, on the file at path.
+func (OSFS) Stamp(path string, attrs map[string]string) error {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := setXattr(path, name, attrs[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Xattr returns the extended attribute name of the file at path.
+func Xattr(path, name string) (string, error) {
+	return getXattr(path, name)
+}
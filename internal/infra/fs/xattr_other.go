@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package fs
+
+func setXattr(string, string, string) error {
+	return ErrXattrUnsupported
+}
+
+func getXattr(string, string) (string, error) {
+	return "", ErrXattrUnsupported
+}
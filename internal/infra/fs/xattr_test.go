@@ -0,0 +1,31 @@
+//go:build linux || darwin
+
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStampSetsExtendedAttributes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "DSC0001.ARW")
+	if err := os.WriteFile(path, []byte("raw"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	err := OSFS{}.Stamp(path, map[string]string{"user.phopy.src": "/card/DSC0001.ARW", "user.phopy.run": "20241002T150100-abcd"})
+	if errors.Is(err, ErrXattrUnsupported) {
+		t.Skipf("file system of %s has no extended attributes", path)
+	}
+	if err != nil {
+		t.Fatalf("stamp: %v", err)
+	}
+	for name, want := range map[string]string{"user.phopy.src": "/card/DSC0001.ARW", "user.phopy.run": "20241002T150100-abcd"} {
+		got, err := Xattr(path, name)
+		if err != nil || got != want {
+			t.Fatalf("expected %s=%q, got %q (%v)", name, want, got, err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+//go:build linux || darwin
+
+package fs
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+func setXattr(path, name, value string) error {
+	return xattrErr(unix.Setxattr(path, name, []byte(value), 0))
+}
+
+func getXattr(path, name string) (string, error) {
+	buf := make([]byte, 1024)
+	for {
+		n, err := unix.Getxattr(path, name, buf)
+		if errors.Is(err, unix.ERANGE) {
+			buf = make([]byte, 2*len(buf))
+			continue
+		}
+		if err != nil {
+			return "", xattrErr(err)
+		}
+		return string(buf[:n]), nil
+	}
+}
+
+func xattrErr(err error) error {
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		return ErrXattrUnsupported
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+// Package termimage draws small images inline in terminals that implement
+// the kitty graphics protocol or the inline images of iTerm2.
+package termimage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// Protocol is how a terminal takes inline images.
+type Protocol int
+
+const (
+	// None is a terminal without inline images; nothing is drawn.
+	None Protocol = iota
+	// Kitty is the graphics protocol of kitty, also spoken by Ghostty.
+	Kitty
+	// ITerm2 is the inline image escape of iTerm2, also spoken by WezTerm.
+	ITerm2
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case Kitty:
+		return "kitty"
+	case ITerm2:
+		return "iterm2"
+	default:
+		return "none"
+	}
+}
+
+// Detect tells the protocol of the terminal phopy runs in from its
+// environment. Inside tmux or screen, which do not pass the escapes on by
+// default, and in unknown terminals it returns None, so nothing is drawn
+// rather than garbage.
+func Detect(getenv func(string) string) Protocol {
+	if getenv("TMUX") != "" || strings.HasPrefix(getenv("TERM"), "screen") {
+		return None
+	}
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", getenv("TERM") == "xterm-kitty", getenv("TERM_PROGRAM") == "ghostty":
+		return Kitty
+	case getenv("TERM_PROGRAM") == "iTerm.app", getenv("LC_TERMINAL") == "iTerm2", getenv("TERM_PROGRAM") == "WezTerm":
+		return ITerm2
+	}
+	return None
+}
+
+// Fit scales img down to fit into maxWidth by maxHeight pixels, keeping
+// its aspect ratio, by averaging the pixels each new pixel covers. Images
+// that fit already are returned as they are.
+func Fit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxWidth && h <= maxHeight || w == 0 || h == 0 {
+		return img
+	}
+	scale := min(float64(maxWidth)/float64(w), float64(maxHeight)/float64(h))
+	nw, nh := max(int(float64(w)*scale), 1), max(int(float64(h)*scale), 1)
+
+	out := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		y0, y1 := y*h/nh, max((y+1)*h/nh, y*h/nh+1)
+		for x := 0; x < nw; x++ {
+			x0, x1 := x*w/nw, max((x+1)*w/nw, x*w/nw+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa), n+1
+				}
+			}
+			out.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return out
+}
+
+// Columns is how many terminal cells wide img is drawn over rows lines,
+// taking cells to be twice as high as wide.
+func Columns(img image.Image, rows int) int {
+	bounds := img.Bounds()
+	if bounds.Dy() == 0 {
+		return 0
+	}
+	return max(rows*2*bounds.Dx()/bounds.Dy(), 1)
+}
+
+// kittyChunk is the most base64 data a kitty graphics escape may carry.
+const kittyChunk = 4096
+
+// kittyImageID is the id of the image phopy draws; drawing a new one
+// replaces the last, so there is only ever one on screen.
+const kittyImageID = 1
+
+// Encode returns the escape sequence that draws img with p over rows lines
+// of the terminal at the cursor, without moving the cursor: the caller
+// leaves the rows free. None encodes to "".
+func Encode(p Protocol, img image.Image, rows int) (string, error) {
+	if p == None {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+	cols := Columns(img, rows)
+
+	if p == ITerm2 {
+		return fmt.Sprintf("\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=1;doNotMoveCursor=1:%s\a", buf.Len(), cols, rows, data), nil
+	}
+
+	var b strings.Builder
+	for first := true; first || data != ""; first = false {
+		chunk := data[:min(len(data), kittyChunk)]
+		data = data[len(chunk):]
+		more := 0
+		if data != "" {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(&b, "\x1b_Ga=T,f=100,i=%d,c=%d,r=%d,C=1,q=2,m=%d;%s\x1b\\", kittyImageID, cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return b.String(), nil
+}
+
+// Clear returns the escape sequence that removes what Encode drew with p.
+// iTerm2 images are part of the text and go with it, so only kitty needs
+// one.
+func Clear(p Protocol) string {
+	if p != Kitty {
+		return ""
+	}
+	return fmt.Sprintf("\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", kittyImageID)
+}
@@ -0,0 +1,187 @@
+package termimage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// checker returns a w by h image of red and blue pixels in a checkerboard.
+func checker(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if (x+y)%2 == 0 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+// decodePNG decodes the base64 PNG data of an escape sequence.
+func decodePNG(t *testing.T, data string) image.Image {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatalf("decode base64: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	return img
+}
+
+func sameImage(t *testing.T, got, want image.Image) {
+	t.Helper()
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("expected bounds %v, got %v", want.Bounds(), got.Bounds())
+	}
+	for y := want.Bounds().Min.Y; y < want.Bounds().Max.Y; y++ {
+		for x := want.Bounds().Min.X; x < want.Bounds().Max.X; x++ {
+			gr, gg, gb, ga := got.At(x, y).RGBA()
+			wr, wg, wb, wa := want.At(x, y).RGBA()
+			if gr != wr || gg != wg || gb != wb || ga != wa {
+				t.Fatalf("pixel %d,%d differs: %v vs %v", x, y, got.At(x, y), want.At(x, y))
+			}
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want Protocol
+	}{
+		{map[string]string{"TERM": "xterm-kitty"}, Kitty},
+		{map[string]string{"KITTY_WINDOW_ID": "1", "TERM": "xterm-256color"}, Kitty},
+		{map[string]string{"TERM_PROGRAM": "ghostty"}, Kitty},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, ITerm2},
+		{map[string]string{"LC_TERMINAL": "iTerm2"}, ITerm2},
+		{map[string]string{"TERM_PROGRAM": "WezTerm"}, ITerm2},
+		{map[string]string{"TERM_PROGRAM": "Apple_Terminal"}, None},
+		{map[string]string{"TERM": "xterm-kitty", "TMUX": "/tmp/tmux-1000/default,1,0"}, None},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app", "TERM": "screen-256color"}, None},
+		{map[string]string{}, None},
+	}
+	for _, tt := range tests {
+		if got := Detect(func(key string) string { return tt.env[key] }); got != tt.want {
+			t.Errorf("Detect(%v) = %s, want %s", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestFitAveragesDownKeepingTheAspectRatio(t *testing.T) {
+	// Every 2x2 block of the checkerboard holds two red and two blue
+	// pixels, which average to purple
+	fitted := Fit(checker(8, 4), 4, 4)
+	if fitted.Bounds() != image.Rect(0, 0, 4, 2) {
+		t.Fatalf("expected 4x2, got %v", fitted.Bounds())
+	}
+	r, g, b, a := fitted.At(1, 1).RGBA()
+	if r>>8 != 127 || g != 0 || b>>8 != 127 || a>>8 != 255 {
+		t.Fatalf("expected purple, got %d %d %d %d", r>>8, g>>8, b>>8, a>>8)
+	}
+
+	small := checker(2, 2)
+	if Fit(small, 4, 4) != image.Image(small) {
+		t.Fatalf("expected an image that fits to be kept")
+	}
+}
+
+func TestColumnsAssumesCellsTwiceAsHighAsWide(t *testing.T) {
+	if got := Columns(checker(160, 120), 6); got != 16 {
+		t.Fatalf("expected 16 columns for 4:3 over 6 rows, got %d", got)
+	}
+	if got := Columns(checker(1, 100), 6); got != 1 {
+		t.Fatalf("expected at least 1 column, got %d", got)
+	}
+}
+
+func TestEncodeITerm2(t *testing.T) {
+	img := checker(4, 2)
+	seq, err := Encode(ITerm2, img, 2)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	match := regexp.MustCompile(`^\x1b\]1337;File=inline=1;size=(\d+);width=8;height=2;preserveAspectRatio=1;doNotMoveCursor=1:([A-Za-z0-9+/=]+)\a$`).FindStringSubmatch(seq)
+	if match == nil {
+		t.Fatalf("unexpected escape %q", seq)
+	}
+	sameImage(t, decodePNG(t, match[2]), img)
+}
+
+func TestEncodeKittyChunksLargeImages(t *testing.T) {
+	// Noise does not compress, so the PNG needs several chunks
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	seed := uint32(1)
+	for i := range img.Pix {
+		seed = seed*1664525 + 1013904223
+		img.Pix[i] = byte(seed >> 24)
+		if i%4 == 3 {
+			img.Pix[i] = 255
+		}
+	}
+	seq, err := Encode(Kitty, img, 6)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	escapes := regexp.MustCompile(`\x1b_G([^;]*);([A-Za-z0-9+/=]*)\x1b\\`).FindAllStringSubmatch(seq, -1)
+	if len(escapes) < 3 || strings.Join(regexp.MustCompile(`\x1b_G[^\x1b]*\x1b\\`).FindAllString(seq, -1), "") != seq {
+		t.Fatalf("expected several chunks and nothing else, got %d in %d bytes", len(escapes), len(seq))
+	}
+	if escapes[0][1] != "a=T,f=100,i=1,c=16,r=6,C=1,q=2,m=1" {
+		t.Fatalf("unexpected first control data %q", escapes[0][1])
+	}
+	var data strings.Builder
+	for i, escape := range escapes {
+		if i > 0 {
+			want := "m=1"
+			if i == len(escapes)-1 {
+				want = "m=0"
+			}
+			if escape[1] != want {
+				t.Fatalf("chunk %d: expected %q, got %q", i, want, escape[1])
+			}
+		}
+		if len(escape[2]) > kittyChunk {
+			t.Fatalf("chunk %d carries %d bytes", i, len(escape[2]))
+		}
+		data.WriteString(escape[2])
+	}
+	sameImage(t, decodePNG(t, data.String()), img)
+}
+
+func TestEncodeKittyOfASmallImageIsOneEscape(t *testing.T) {
+	img := checker(2, 2)
+	seq, err := Encode(Kitty, img, 1)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	prefix := "\x1b_Ga=T,f=100,i=1,c=2,r=1,C=1,q=2,m=0;"
+	if !strings.HasPrefix(seq, prefix) || !strings.HasSuffix(seq, "\x1b\\") {
+		t.Fatalf("unexpected escape %q", seq)
+	}
+	sameImage(t, decodePNG(t, strings.TrimSuffix(strings.TrimPrefix(seq, prefix), "\x1b\\")), img)
+}
+
+func TestEncodeAndClearWithoutProtocolDrawNothing(t *testing.T) {
+	if seq, err := Encode(None, checker(2, 2), 1); seq != "" || err != nil {
+		t.Fatalf("expected nothing, got %q (%v)", seq, err)
+	}
+	if Clear(None) != "" || Clear(ITerm2) != "" {
+		t.Fatalf("expected only kitty to need clearing")
+	}
+	if Clear(Kitty) != "\x1b_Ga=d,d=I,i=1,q=2\x1b\\" {
+		t.Fatalf("unexpected kitty clear %q", Clear(Kitty))
+	}
+}
@@ -0,0 +1,358 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"phopy/internal/domain"
+	"phopy/internal/format"
+	"phopy/internal/manifest"
+)
+
+// FileName is the journal below the target's meta directory.
+const FileName = "journal.jsonl"
+
+// Counts summarizes the outcome of a run.
+type Counts struct {
+	Copied           int   `json:"copied"`
+	SkippedOverrides int   `json:"skipped_overrides"`
+	Failed           int   `json:"failed"`
+	Cancelled        int   `json:"cancelled"`
+	BytesCopied      int64 `json:"bytes_copied"`
+	// BytesCopiedText is BytesCopied for people reading the journal,
+	// e.g. "12.4 GiB".
+	BytesCopiedText string `json:"bytes_copied_text,omitempty"`
+}
+
+// File is a file copied by a run.
+type File struct {
+	SourcePath string    `json:"source_path"`
+	TargetPath string    `json:"target_path"`
+	TakenAt    time.Time `json:"taken_at"`
+	Size       int64     `json:"size"`
+}
+
+// Entry is one line of the journal and describes one execution.
+type Entry struct {
+	RunID        string    `json:"run_id"`
+	Time         time.Time `json:"time"`
+	ConfigDigest string    `json:"config_digest"`
+	SourceDir    string    `json:"source_dir"`
+	TargetDir    string    `json:"target_dir"`
+	Label        string    `json:"label,omitempty"`
+	Counts       Counts    `json:"counts"`
+	Files        []File    `json:"files"`
+	// SourceVolume identifies the card the run copied from, when known.
+	SourceVolume *domain.Volume `json:"source_volume,omitempty"`
+	// Partial marks a run that copied only part of the card, like one
+	// limited by --include or --until or with a stopped scan, so newer
+	// files may be left on it. Watermark leaves it out.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Path returns the journal of targetDir.
+func Path(targetDir string) string {
+	return filepath.Join(targetDir, manifest.MetaDir, FileName)
+}
+
+// FromResult builds the journal entry of an execution. Only copied files
+// are listed.
+func FromResult(runID, configDigest, sourceDir, targetDir string, result domain.ExecutionResult, at time.Time) Entry {
+	entry := Entry{
+		RunID:        runID,
+		Time:         at,
+		ConfigDigest: configDigest,
+		SourceDir:    sourceDir,
+		TargetDir:    targetDir,
+		Counts: Counts{
+			Copied:           result.Copied,
+			SkippedOverrides: result.SkippedOverrides,
+			Failed:           result.Failed,
+			Cancelled:        result.Cancelled,
+			BytesCopied:      result.BytesCopied,
+			BytesCopiedText:  format.Bytes(result.BytesCopied),
+		},
+		Files: []File{},
+	}
+	for _, item := range result.Items {
+		if item.Status != domain.ItemCopied {
+			continue
+		}
+		meta := item.Item.FileMeta
+		entry.Files = append(entry.Files, File{
+			SourcePath: meta.SourcePath,
+			TargetPath: item.Item.TargetPath,
+			TakenAt:    meta.TakenAt,
+			Size:       meta.Size,
+		})
+	}
+	return entry
+}
+
+// Append adds entry to the journal of targetDir with a single write and
+// syncs it to disk. A partial line left by an earlier crash is terminated
+// first so it cannot corrupt the new entry.
+func Append(targetDir string, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	path := Path(targetDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	terminated, err := endsWithNewline(file)
+	if err != nil {
+		return err
+	}
+	if !terminated {
+		line = append([]byte{'\n'}, line...)
+	}
+	if _, err := file.Write(line); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// endsWithNewline reports whether file is empty or ends with a newline.
+func endsWithNewline(file *os.File) (bool, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() == 0 {
+		return true, nil
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] == '\n', nil
+}
+
+// Read returns the entries of the journal of targetDir, oldest first. A
+// missing journal has no entries. Lines that cannot be decoded, usually a
+// partial line from a crash during Append, are skipped.
+func Read(targetDir string) ([]Entry, error) {
+	file, err := os.Open(Path(targetDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return decode(file)
+}
+
+func decode(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var entry Entry
+			if json.Unmarshal(line, &entry) == nil {
+				entries = append(entries, entry)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Compact rewrites the journal of targetDir without undecodable lines and
+// lists every target path only in the latest run that copied it. Runs left
+// without files are dropped. The journal is replaced atomically.
+func Compact(targetDir string) error {
+	entries, err := Read(targetDir)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for i := len(entries) - 1; i >= 0; i-- {
+		files := entries[i].Files[:0]
+		for _, file := range entries[i].Files {
+			if seen[file.TargetPath] {
+				continue
+			}
+			seen[file.TargetPath] = true
+			files = append(files, file)
+		}
+		entries[i].Files = files
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		if len(entry.Files) == 0 {
+			continue
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return replace(Path(targetDir), buf.Bytes())
+}
+
+// replace writes data to path through a synced temporary file and a rename.
+func replace(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+FileName+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace journal: %w", err)
+	}
+	return nil
+}
+
+// History is what the runs recorded in a journal imported. It implements
+// app.History.
+type History struct {
+	last  time.Time
+	files map[string]domain.ImportedFile
+}
+
+// LoadHistory reads the journal of targetDir. A missing journal has no
+// imports.
+func LoadHistory(targetDir string) (History, error) {
+	entries, err := Read(targetDir)
+	if err != nil {
+		return History{}, err
+	}
+	return NewHistory(entries), nil
+}
+
+// NewHistory indexes entries by source path; later runs win.
+func NewHistory(entries []Entry) History {
+	h := History{files: make(map[string]domain.ImportedFile)}
+	for _, entry := range entries {
+		if entry.Time.After(h.last) {
+			h.last = entry.Time
+		}
+		for _, file := range entry.Files {
+			h.files[file.SourcePath] = domain.ImportedFile{
+				SourcePath: file.SourcePath,
+				Size:       file.Size,
+				TakenAt:    file.TakenAt,
+				ImportedAt: entry.Time,
+			}
+		}
+	}
+	return h
+}
+
+// LastImport is the time of the latest run, zero without runs.
+func (h History) LastImport() time.Time {
+	return h.last
+}
+
+// Lookup returns how sourcePath was last imported.
+func (h History) Lookup(sourcePath string) (domain.ImportedFile, bool) {
+	file, ok := h.files[sourcePath]
+	return file, ok
+}
+
+// Watermark returns the newest capture time of the files copied from the
+// card volume named card, zero when no run imported from it. Cards are told
+// apart by the serial of their volume where both the run and volume have
+// one, so a card keeps its watermark when it mounts as "UNTITLED 1" instead
+// of "UNTITLED" and two cards of the same name keep theirs apart. Otherwise
+// they are told apart by their volume name, the last element of the source
+// directory. Partial runs are left out.
+func Watermark(entries []Entry, card string, volume domain.Volume) time.Time {
+	var newest time.Time
+	for _, entry := range entries {
+		if entry.Partial {
+			continue
+		}
+		var recorded domain.Volume
+		if entry.SourceVolume != nil {
+			recorded = *entry.SourceVolume
+		}
+		same, known := recorded.Same(volume)
+		if !known {
+			same = filepath.Base(filepath.Clean(entry.SourceDir)) == card
+		}
+		if !same {
+			continue
+		}
+		for _, file := range entry.Files {
+			if file.TakenAt.After(newest) {
+				newest = file.TakenAt
+			}
+		}
+	}
+	return newest
+}
+
+// Writer appends the executions of one run to the journal of TargetDir.
+// It implements app.Journal.
+type Writer struct {
+	RunID        string
+	ConfigDigest string
+	SourceDir    string
+	TargetDir    string
+	Label        string
+	SourceVolume domain.Volume
+	// Partial marks the entries as partial, see Entry.Partial.
+	Partial bool
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+// Append records result as an entry of the run.
+func (w Writer) Append(result domain.ExecutionResult) error {
+	now := time.Now
+	if w.Now != nil {
+		now = w.Now
+	}
+	entry := FromResult(w.RunID, w.ConfigDigest, w.SourceDir, w.TargetDir, result, now())
+	entry.Label = w.Label
+	entry.Partial = w.Partial
+	if !w.SourceVolume.IsZero() {
+		volume := w.SourceVolume
+		entry.SourceVolume = &volume
+	}
+	return Append(w.TargetDir, entry)
+}
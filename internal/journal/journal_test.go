@@ -0,0 +1,244 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+func copiedResult(targetDir string, names ...string) domain.ExecutionResult {
+	takenAt := time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC)
+	var result domain.ExecutionResult
+	for _, name := range names {
+		meta := domain.NewFileMeta(filepath.Join("/card", name), name, takenAt)
+		meta.Size = 1024
+		result.Record(domain.CopyItem{FileMeta: meta, TargetPath: filepath.Join(targetDir, name)}, domain.ItemCopied, nil)
+	}
+	return result
+}
+
+func TestAppendAndRead(t *testing.T) {
+	targetDir := t.TempDir()
+	at := time.Date(2024, 10, 2, 16, 0, 0, 0, time.UTC)
+
+	result := copiedResult(targetDir, "DSC0001.ARW", "DSC0002.ARW")
+	result.Record(domain.CopyItem{FileMeta: domain.FileMeta{Name: "DSC0003.ARW"}}, domain.ItemFailed, errors.New("boom"))
+	if err := Append(targetDir, FromResult("run-1", "abc", "/card", targetDir, result, at)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := Append(targetDir, FromResult("run-2", "abc", "/card", targetDir, copiedResult(targetDir, "DSC0004.ARW"), at.Add(time.Hour))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := Read(targetDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].RunID != "run-1" || entries[1].RunID != "run-2" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	first := entries[0]
+	if first.Counts.Copied != 2 || first.Counts.Failed != 1 || first.Counts.BytesCopied != 2048 {
+		t.Fatalf("unexpected counts %+v", first.Counts)
+	}
+	if len(first.Files) != 2 || first.Files[0].SourcePath != "/card/DSC0001.ARW" {
+		t.Fatalf("expected only the copied files, got %+v", first.Files)
+	}
+	if !first.Time.Equal(at) || first.ConfigDigest != "abc" {
+		t.Fatalf("unexpected entry %+v", first)
+	}
+}
+
+func TestReadMissingJournal(t *testing.T) {
+	entries, err := Read(t.TempDir())
+	if err != nil || entries != nil {
+		t.Fatalf("expected no entries, got %v, %v", entries, err)
+	}
+}
+
+func TestReadToleratesPartialLine(t *testing.T) {
+	targetDir := t.TempDir()
+	if err := Append(targetDir, FromResult("run-1", "", "/card", targetDir, copiedResult(targetDir, "DSC0001.ARW"), time.Now())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A crash in the middle of an append leaves a truncated line
+	file, err := os.OpenFile(Path(targetDir), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _ = file.WriteString(`{"run_id":"run-2","files":[{"source_pa`)
+	file.Close()
+
+	entries, err := Read(targetDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].RunID != "run-1" {
+		t.Fatalf("expected the partial line to be skipped, got %+v", entries)
+	}
+
+	// The next append must not be glued to the partial line
+	if err := Append(targetDir, FromResult("run-3", "", "/card", targetDir, copiedResult(targetDir, "DSC0002.ARW"), time.Now())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, err = Read(targetDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[1].RunID != "run-3" {
+		t.Fatalf("expected the new entry after the partial line, got %+v", entries)
+	}
+}
+
+func TestCompactKeepsLatestCopyOfEachFile(t *testing.T) {
+	targetDir := t.TempDir()
+	at := time.Date(2024, 10, 2, 16, 0, 0, 0, time.UTC)
+	runs := []struct {
+		id    string
+		names []string
+	}{
+		{"run-1", []string{"DSC0001.ARW", "DSC0002.ARW"}},
+		{"run-2", []string{"DSC0001.ARW"}},
+		{"run-3", []string{"DSC0002.ARW", "DSC0003.ARW"}},
+	}
+	for i, run := range runs {
+		entry := FromResult(run.id, "", "/card", targetDir, copiedResult(targetDir, run.names...), at.Add(time.Duration(i)*time.Hour))
+		if err := Append(targetDir, entry); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	file, _ := os.OpenFile(Path(targetDir), os.O_WRONLY|os.O_APPEND, 0)
+	_, _ = file.WriteString("{not json\n")
+	file.Close()
+
+	if err := Compact(targetDir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := Read(targetDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// run-1 lost both files to later runs and is dropped
+	if len(entries) != 2 || entries[0].RunID != "run-2" || entries[1].RunID != "run-3" {
+		t.Fatalf("unexpected entries after compaction %+v", entries)
+	}
+	if len(entries[0].Files) != 1 || len(entries[1].Files) != 2 {
+		t.Fatalf("unexpected files after compaction %+v", entries)
+	}
+
+	data, _ := os.ReadFile(Path(targetDir))
+	if n := len(data); n == 0 || data[n-1] != '\n' {
+		t.Fatalf("expected a newline-terminated journal")
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(Path(targetDir)), ".*"))
+	if len(leftovers) > 0 {
+		t.Fatalf("expected no temporary files, got %v", leftovers)
+	}
+}
+
+func TestLoadHistoryKeepsLatestImportOfEachFile(t *testing.T) {
+	target := t.TempDir()
+	first := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	second := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	if err := Append(target, FromResult("a", "", "/card", target, copiedResult(target, "DSC0001.ARW", "DSC0002.JPG"), first)); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := Append(target, FromResult("b", "", "/card", target, copiedResult(target, "DSC0002.JPG"), second)); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	history, err := LoadHistory(target)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !history.LastImport().Equal(second) {
+		t.Fatalf("expected the last import at %v, got %v", second, history.LastImport())
+	}
+	if file, ok := history.Lookup("/card/DSC0001.ARW"); !ok || !file.ImportedAt.Equal(first) || file.Size != 1024 {
+		t.Fatalf("unexpected lookup of DSC0001.ARW: %+v, %v", file, ok)
+	}
+	if file, ok := history.Lookup("/card/DSC0002.JPG"); !ok || !file.ImportedAt.Equal(second) {
+		t.Fatalf("expected the later import of DSC0002.JPG, got %+v", file)
+	}
+	if _, ok := history.Lookup("/card/DSC0003.ARW"); ok {
+		t.Fatalf("did not expect a file that was never imported")
+	}
+
+	empty, err := LoadHistory(t.TempDir())
+	if err != nil || !empty.LastImport().IsZero() {
+		t.Fatalf("expected no imports without a journal, got %v, %v", empty.LastImport(), err)
+	}
+}
+
+func TestWatermarkIsNewestCaptureFromCard(t *testing.T) {
+	first := time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC)
+	entries := []Entry{
+		{SourceDir: "/Volumes/EOS_DIGITAL", Files: []File{{TakenAt: first}, {TakenAt: first.Add(time.Hour)}}},
+		{SourceDir: "/media/sven/EOS_DIGITAL/", Files: []File{{TakenAt: first.Add(30 * time.Minute)}}},
+		{SourceDir: "/Volumes/UNTITLED", Files: []File{{TakenAt: first.Add(48 * time.Hour)}}},
+	}
+	if got := Watermark(entries, "EOS_DIGITAL", domain.Volume{}); !got.Equal(first.Add(time.Hour)) {
+		t.Fatalf("expected the newest capture from the card, got %v", got)
+	}
+	if got := Watermark(entries, "NIKON", domain.Volume{}); !got.IsZero() {
+		t.Fatalf("expected no watermark for an unknown card, got %v", got)
+	}
+}
+
+func TestPartialRunsDoNotMoveTheWatermark(t *testing.T) {
+	target := t.TempDir()
+	first := time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC)
+	full := Writer{RunID: "a", SourceDir: "/Volumes/EOS_DIGITAL", TargetDir: target}
+	if err := full.Append(domain.ExecutionResult{Items: []domain.ItemResult{{Item: takenItem(first)}}}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	partial := Writer{RunID: "b", SourceDir: "/Volumes/EOS_DIGITAL", TargetDir: target, Partial: true}
+	if err := partial.Append(domain.ExecutionResult{Items: []domain.ItemResult{{Item: takenItem(first.Add(48 * time.Hour))}}}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	entries, err := Read(target)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Partial || !entries[1].Partial {
+		t.Fatalf("expected the second entry marked partial, got %+v", entries)
+	}
+	if got := Watermark(entries, "EOS_DIGITAL", domain.Volume{}); !got.Equal(first) {
+		t.Fatalf("expected the watermark of the full run, got %v", got)
+	}
+	if _, ok := NewHistory(entries).Lookup("/Volumes/EOS_DIGITAL/DSC0001.ARW"); !ok {
+		t.Fatalf("expected the files of a partial run in the history")
+	}
+}
+
+func takenItem(taken time.Time) domain.CopyItem {
+	return domain.CopyItem{FileMeta: domain.FileMeta{SourcePath: "/Volumes/EOS_DIGITAL/DSC0001.ARW", TakenAt: taken}}
+}
+
+func TestWatermarkFollowsTheCardAcrossMountPaths(t *testing.T) {
+	first := time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC)
+	card := domain.Volume{Label: "UNTITLED", Serial: "1234-ABCD"}
+	other := domain.Volume{Label: "UNTITLED", Serial: "9876-FEDC"}
+	entries := []Entry{
+		{SourceDir: "/Volumes/UNTITLED", SourceVolume: &card, Files: []File{{TakenAt: first}}},
+		{SourceDir: "/Volumes/UNTITLED", SourceVolume: &other, Files: []File{{TakenAt: first.Add(48 * time.Hour)}}},
+		// Recorded before volumes were
+		{SourceDir: "/Volumes/UNTITLED 1", Files: []File{{TakenAt: first.Add(time.Hour)}}},
+	}
+	if got := Watermark(entries, "UNTITLED 1", card); !got.Equal(first.Add(time.Hour)) {
+		t.Fatalf("expected the card's imports under both names, got %v", got)
+	}
+	if got := Watermark(entries, "UNTITLED", card); !got.Equal(first) {
+		t.Fatalf("expected another card of the same name to be left out, got %v", got)
+	}
+	if got := Watermark(entries, "UNTITLED", domain.Volume{Label: "UNTITLED"}); !got.Equal(first.Add(48 * time.Hour)) {
+		t.Fatalf("expected the name to decide without a serial, got %v", got)
+	}
+}
@@ -0,0 +1,78 @@
+// Package lifecycle ties the background goroutines of a run to it, so the
+// run can stop them all and wait for them before it returns.
+package lifecycle
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs the background tasks of a run. Every task gets the context of
+// the group, which Stop cancels; Stop then waits until every task has
+// returned. The zero value is not usable; create one with New.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// New returns a group whose tasks are cancelled with parent as well.
+func New(parent context.Context) *Group {
+	ctx, cancel := context.WithCancel(parent)
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Context is cancelled once the group stops. Work that belongs to the run
+// but is not started with Go or Do, like a copy, should use it.
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// Go runs task in a new goroutine. Once the group has stopped, task is not
+// run at all.
+func (g *Group) Go(task func(ctx context.Context)) {
+	if !g.add() {
+		return
+	}
+	go func() {
+		defer g.wg.Done()
+		task(g.ctx)
+	}()
+}
+
+// Do runs task in the calling goroutine, which Stop then waits for like
+// one started with Go. It reports false without running task once the
+// group has stopped. It suits work run on a goroutine the group does not
+// own, like a Bubble Tea command.
+func (g *Group) Do(task func(ctx context.Context)) bool {
+	if !g.add() {
+		return false
+	}
+	defer g.wg.Done()
+	task(g.ctx)
+	return true
+}
+
+// Stop cancels the context of the group and waits for its tasks to return.
+// It may be called more than once.
+func (g *Group) Stop() {
+	g.mu.Lock()
+	g.stopped = true
+	g.mu.Unlock()
+	g.cancel()
+	g.wg.Wait()
+}
+
+// add counts a task unless the group has stopped.
+func (g *Group) add() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		return false
+	}
+	g.wg.Add(1)
+	return true
+}
@@ -0,0 +1,147 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"phopy/internal/app"
+	"phopy/internal/domain"
+	"phopy/phopytest"
+)
+
+// checkNoLeak fails when more goroutines run than before, giving those
+// that just returned from their tasks a moment to exit.
+func checkNoLeak(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("expected %d goroutines, got %d:\n%s", before, runtime.NumGoroutine(), buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStopCancelsAndWaitsForTasks(t *testing.T) {
+	g := New(context.Background())
+	returned := make(chan struct{})
+	g.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		close(returned)
+	})
+	g.Stop()
+	select {
+	case <-returned:
+	default:
+		t.Fatalf("expected Stop to wait for the task")
+	}
+	g.Stop()
+}
+
+func TestTasksAfterStopDoNotRun(t *testing.T) {
+	g := New(context.Background())
+	g.Stop()
+	ran := false
+	g.Go(func(context.Context) { ran = true })
+	if g.Do(func(context.Context) { ran = true }) || ran {
+		t.Fatalf("expected no task to run after Stop")
+	}
+	if g.Context().Err() == nil {
+		t.Fatalf("expected the context to be cancelled")
+	}
+}
+
+func TestParentCancellationReachesTasks(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	g := New(parent)
+	defer g.Stop()
+	cancel()
+	select {
+	case <-g.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected the group to be cancelled with its parent")
+	}
+}
+
+// cardFS holds n RAWs of which reading and copying takes a while.
+func cardFS(n int) (*phopytest.FS, *phopytest.Exif) {
+	taken := time.Date(2024, 10, 2, 15, 0, 0, 0, time.Local)
+	fsys := phopytest.NewFS()
+	exif := phopytest.NewExif()
+	for i := range n {
+		path := fmt.Sprintf("/card/DCIM/DSC%04d.ARW", i+1)
+		fsys.AddFile(path, phopytest.File{Size: 1 << 20, ModTime: taken})
+		exif.SetTakenAt(path, taken.Add(time.Duration(i)*time.Second))
+	}
+	fsys.Latency.Copy = time.Millisecond
+	exif.Latency = time.Millisecond
+	return fsys, exif
+}
+
+// TestRunCancelledEarlyLeavesNoGoroutines wires planning, copying and the
+// event bridge like the TUI does and cancels the run while both scan and
+// copy are underway.
+func TestRunCancelledEarlyLeavesNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	fsys, exif := cardFS(200)
+	plan, err := (&app.Planner{FS: fsys, Exif: exif}).Plan(context.Background(), "/card", "/target", nil, nil)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g := New(ctx)
+	events := make(chan app.Event, 4)
+	copying := make(chan struct{})
+	// The bridge cancels the run once the copy is underway and never
+	// drains the planner's final event, which must not block it
+	g.Go(func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev := <-events:
+				if _, ok := ev.(app.CopyProgressEvent); ok {
+					select {
+					case <-copying:
+					default:
+						close(copying)
+						cancel()
+					}
+				}
+			}
+		}
+	})
+	g.Go(func(ctx context.Context) {
+		_, _ = (&app.Planner{FS: fsys, Exif: exif}).PlanWithEvents(ctx, "/card", "/target", nil, nil, events)
+	})
+
+	// Like a Bubble Tea command, the copy runs on a goroutine the group
+	// does not own
+	copied := make(chan domain.ExecutionResult, 1)
+	go func() {
+		g.Do(func(ctx context.Context) {
+			executor := app.Executor{FS: fsys, OnConflict: app.AskConflicts(events)}
+			result, _ := executor.ExecuteWithEvents(ctx, plan, plan.Decide(false), events)
+			copied <- result
+		})
+	}()
+
+	select {
+	case <-copying:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the copy to start")
+	}
+	g.Stop()
+	result := <-copied
+	if result.Copied == len(plan.Items) {
+		t.Fatalf("expected the cancellation to stop the copy early")
+	}
+	checkNoLeak(t, before)
+}
@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"io"
 	"time"
+
+	"phopy/internal/trace"
 )
 
 // Logger provides optional verbose logging and lightweight timing helpers.
 type Logger struct {
 	Writer  io.Writer
 	Verbose bool
+	// Trace, when set, records every measured phase as a span.
+	Trace *trace.Recorder
 }
 
 func New(writer io.Writer, verbose bool) Logger {
@@ -30,14 +34,29 @@ func (l Logger) Verbosef(format string, args ...any) {
 	l.Infof("Verbose: "+format, args...)
 }
 
-// Measure returns a stop function that logs the elapsed time when called.
-func (l Logger) Measure(label string) func() {
-	if !l.Verbose {
-		return func() {}
-	}
-	start := time.Now()
-	return func() {
-		elapsed := time.Since(start).Round(time.Millisecond)
-		l.Verbosef("%s took %s", label, elapsed)
-	}
+// Measure starts timing the phase name. Its End logs the elapsed time
+// under label and records the phase in the Trace, if any, so the verbose
+// log and the trace agree.
+func (l Logger) Measure(name, label string) *Measurement {
+	return &Measurement{logger: l, label: label, start: time.Now(), span: l.Trace.Start(name, 0)}
+}
+
+// Measurement is a phase timed by Measure.
+type Measurement struct {
+	logger Logger
+	label  string
+	start  time.Time
+	span   *trace.Span
+}
+
+// Set attaches an attribute, like a file count, to the span of the phase.
+func (m *Measurement) Set(key string, value any) {
+	m.span.Set(key, value)
+}
+
+// End stops timing the phase.
+func (m *Measurement) End() {
+	m.span.End()
+	elapsed := time.Since(m.start).Round(time.Millisecond)
+	m.logger.Verbosef("%s took %s", m.label, elapsed)
 }
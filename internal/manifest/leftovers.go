@@ -0,0 +1,32 @@
+package manifest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"phopy/internal/domain"
+)
+
+// WriteLeftovers writes the files a plan left out to path, one per line as
+// the source path and the reason separated by a tab, so the report can be
+// read before formatting a card or fed to cut and xargs.
+func WriteLeftovers(path string, leftovers []domain.Leftover) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	for _, leftover := range leftovers {
+		fmt.Fprintf(w, "%s\t%s\n", leftover.SourcePath, leftover.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,218 @@
+package manifest
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"phopy/internal/domain"
+	"phopy/internal/hash"
+)
+
+// MetaDir is the directory below the target that holds phopy's own files.
+const MetaDir = domain.MetaDir
+
+// Entry records the outcome of a single plan item. SourcePath is always the
+// original location so provenance survives flattening and renaming.
+type Entry struct {
+	SourcePath   string    `json:"source_path"`
+	RelativePath string    `json:"relative_path"`
+	TargetPath   string    `json:"target_path"`
+	TakenAt      time.Time `json:"taken_at"`
+	Size         int64     `json:"size"`
+	Rating       *int      `json:"rating,omitempty"`
+	Status       string    `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	// DateSource is where TakenAt came from when it is not EXIF, like
+	// "directory" for --dir-date-pattern.
+	DateSource string `json:"date_source,omitempty"`
+	// DateTag is the EXIF tag TakenAt was read from when it is not
+	// DateTimeOriginal, like "CreateDate" for --date-tag-order.
+	DateTag string `json:"date_tag,omitempty"`
+	// Hash is the hex content hash with the manifest's Algorithm of the
+	// source as it was read for the copy, which the copy was verified
+	// against. Older manifests have none.
+	Hash string `json:"hash,omitempty"`
+	// SHA256 is where manifest files keep Hash when it is a SHA-256, as
+	// they did before other algorithms; Write and Read move it.
+	SHA256 string `json:"sha256,omitempty"`
+	// Linked marks a copy that is a hard link to an identical file that
+	// was already in the target, see --link-dupes.
+	Linked bool `json:"linked,omitempty"`
+	// SourceChanged marks a copy of a source that changed after planning;
+	// Size is that of the version copied.
+	SourceChanged bool `json:"source_changed,omitempty"`
+}
+
+type Manifest struct {
+	CreatedAt time.Time `json:"created_at"`
+	SourceDir string    `json:"source_dir"`
+	TargetDir string    `json:"target_dir"`
+	Label     string    `json:"label,omitempty"`
+	Entries   []Entry   `json:"entries"`
+
+	// Review holds the actions chosen in the warnings review of the run.
+	Review []domain.ReviewDecision `json:"review,omitempty"`
+	// SourceVolume identifies the card the run copied from, when known.
+	SourceVolume *domain.Volume `json:"source_volume,omitempty"`
+	// Scan describes the scan the copied plan was made from, when known.
+	Scan *domain.ScanStats `json:"scan,omitempty"`
+	// RunID identifies the run, like the journal entry it belongs to.
+	// Older manifests have none.
+	RunID string `json:"run_id,omitempty"`
+	// HashAlgorithm is the algorithm of the entries' hashes. Manifest
+	// files record it for algorithms other than SHA-256 only. See
+	// Algorithm.
+	HashAlgorithm hash.Algorithm `json:"hash_algorithm,omitempty"`
+}
+
+// Algorithm is the algorithm of the entries' hashes. Manifests written
+// before it was recorded used SHA-256.
+func (m Manifest) Algorithm() hash.Algorithm {
+	return cmp.Or(m.HashAlgorithm, hash.SHA256)
+}
+
+// FromResult builds a manifest from the outcome of an execution. Entries
+// follow the plan order, see domain.CopyPlan.Items, except for locked
+// files retried at the end.
+func FromResult(sourceDir, targetDir string, result domain.ExecutionResult, createdAt time.Time) Manifest {
+	entries := make([]Entry, 0, len(result.Items))
+	for _, item := range result.Items {
+		meta := item.Item.FileMeta
+		entry := Entry{
+			SourcePath:   meta.SourcePath,
+			RelativePath: meta.RelativePath,
+			TargetPath:   item.Item.TargetPath,
+			TakenAt:      meta.TakenAt,
+			Size:         meta.Size,
+			Status:       item.Status.String(),
+			Linked:       item.Linked,
+
+			SourceChanged: item.SourceChanged,
+			Hash:          item.Hash,
+		}
+		if meta.DateSource != domain.DateSourceExif {
+			entry.DateSource = meta.DateSource.String()
+		} else if meta.DateTag != domain.DateTagOriginal {
+			entry.DateTag = meta.DateTag.String()
+		}
+		if meta.Rated {
+			rating := meta.Rating
+			entry.Rating = &rating
+		}
+		if item.Err != nil {
+			entry.Error = item.Err.Error()
+		}
+		entries = append(entries, entry)
+	}
+
+	return Manifest{
+		CreatedAt: createdAt,
+		SourceDir: sourceDir,
+		TargetDir: targetDir,
+		Entries:   entries,
+		RunID:     result.RunID,
+	}
+}
+
+// HashIndex maps the content hashes recorded with algorithm by the
+// manifests below targetDir to the target path of one copied file with that
+// content, so new copies of the same content can be linked to it. Later
+// manifests win; those recorded with another algorithm are left out. A
+// target without manifests has an empty index.
+func HashIndex(targetDir string, algorithm hash.Algorithm) (map[string]string, error) {
+	manifests, err := ReadAll(targetDir)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]string)
+	copied := domain.ItemCopied.String()
+	for _, m := range manifests {
+		if m.Algorithm() != algorithm {
+			continue
+		}
+		for _, entry := range m.Entries {
+			if entry.Status == copied && entry.Hash != "" {
+				index[entry.Hash] = entry.TargetPath
+			}
+		}
+	}
+	return index, nil
+}
+
+// ReadAll loads the manifests below targetDir in the order of their file
+// names, which is the order of their runs.
+func ReadAll(targetDir string) ([]Manifest, error) {
+	paths, err := filepath.Glob(filepath.Join(targetDir, MetaDir, "manifests", "manifest-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var manifests []Manifest
+	for _, path := range paths {
+		m, err := Read(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+// Write stores m below targetDir/.phopy/manifests and returns the file path.
+// The file is named after the run id, or the creation time without one.
+func Write(targetDir string, m Manifest) (string, error) {
+	dir := filepath.Join(targetDir, MetaDir, "manifests")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	// SHA-256 manifests keep the fields readers of older ones know
+	if m.Algorithm() == hash.SHA256 {
+		entries := make([]Entry, len(m.Entries))
+		for i, entry := range m.Entries {
+			entry.SHA256, entry.Hash = entry.Hash, ""
+			entries[i] = entry
+		}
+		m.Entries, m.HashAlgorithm = entries, ""
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	name := m.RunID
+	if name == "" {
+		name = m.CreatedAt.Format("20060102-150405")
+	}
+	path := filepath.Join(dir, fmt.Sprintf("manifest-%s.json", name))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Read loads a manifest written by Write, with SHA-256 hashes moved into
+// Hash.
+func Read(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, err
+	}
+	for i := range m.Entries {
+		entry := &m.Entries[i]
+		entry.Hash, entry.SHA256 = cmp.Or(entry.Hash, entry.SHA256), ""
+	}
+	return m, nil
+}
@@ -0,0 +1,195 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+	"phopy/internal/hash"
+)
+
+func TestWriteRecordsOriginalSourcePaths(t *testing.T) {
+	targetDir := t.TempDir()
+	createdAt := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+
+	copied := domain.NewFileMeta("/card/DCIM/100MSDCF/DSC0001.ARW", "DCIM/100MSDCF/DSC0001.ARW", createdAt)
+	copied.Rating, copied.Rated = 4, true
+	copied.DateTag = domain.DateTagCreate
+	failed := domain.NewFileMeta("/card/DCIM/101MSDCF/DSC0001.ARW", "DCIM/101MSDCF/DSC0001.ARW", createdAt)
+	failed.DateSource = domain.DateSourceDirectory
+
+	result := domain.ExecutionResult{RunID: "20241002T130100Z-1a2b3c4d"}
+	result.Record(domain.CopyItem{FileMeta: copied, TargetPath: filepath.Join(targetDir, "2024-10-02", "DSC0001.ARW")}, domain.ItemCopied, nil)
+	result.MarkSourceChanged()
+	result.Record(domain.CopyItem{FileMeta: failed, TargetPath: filepath.Join(targetDir, "2024-10-02", "DSC0001-1.ARW")}, domain.ItemFailed, errors.New("boom"))
+
+	written := FromResult("/card", targetDir, result, createdAt)
+	written.Review = []domain.ReviewDecision{{Code: domain.WarningExifMissing, Action: domain.ActionQuarantine, Files: 1}}
+	path, err := Write(targetDir, written)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != filepath.Join(targetDir, MetaDir, "manifests", "manifest-20241002T130100Z-1a2b3c4d.json") {
+		t.Fatalf("expected the manifest to be named after the run, got %s", path)
+	}
+
+	m, err := Read(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(m.Entries))
+	}
+	if m.Entries[0].SourcePath != copied.SourcePath || m.Entries[1].SourcePath != failed.SourcePath {
+		t.Fatalf("expected original source paths, got %+v", m.Entries)
+	}
+	if m.Entries[0].Rating == nil || *m.Entries[0].Rating != 4 || m.Entries[1].Rating != nil {
+		t.Fatalf("expected the sidecar rating of rated files only, got %+v", m.Entries)
+	}
+	if m.Entries[0].DateSource != "" || m.Entries[1].DateSource != "directory" {
+		t.Fatalf("expected the date source of files without EXIF dates only, got %+v", m.Entries)
+	}
+	if m.Entries[0].DateTag != "CreateDate" || m.Entries[1].DateTag != "" {
+		t.Fatalf("expected the date tag of EXIF dates only, got %+v", m.Entries)
+	}
+	if !m.Entries[0].SourceChanged || m.Entries[1].SourceChanged {
+		t.Fatalf("expected the copy of a changed source to be marked, got %+v", m.Entries)
+	}
+	if m.Entries[1].Status != "failed" || m.Entries[1].Error != "boom" {
+		t.Fatalf("unexpected failed entry: %+v", m.Entries[1])
+	}
+	if m.RunID != result.RunID {
+		t.Fatalf("expected run %s, got %q", result.RunID, m.RunID)
+	}
+	if len(m.Review) != 1 || m.Review[0] != written.Review[0] {
+		t.Fatalf("expected the review decisions, got %v", m.Review)
+	}
+}
+
+func TestFromResultRecordsTheVerifiedHashes(t *testing.T) {
+	createdAt := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	copied := domain.NewFileMeta("/card/DSC0001.ARW", "DSC0001.ARW", createdAt)
+	skipped := domain.NewFileMeta("/card/DSC0002.ARW", "DSC0002.ARW", createdAt)
+
+	var result domain.ExecutionResult
+	result.Record(domain.CopyItem{FileMeta: copied, TargetPath: "/target/DSC0001.ARW"}, domain.ItemCopied, nil)
+	result.Items[0].Hash = "aa"
+	result.Record(domain.CopyItem{FileMeta: skipped, TargetPath: "/target/DSC0002.ARW"}, domain.ItemSkippedOverride, nil)
+
+	m := FromResult("/card", "/target", result, createdAt)
+	if m.Entries[0].Hash != "aa" || m.Entries[1].Hash != "" {
+		t.Fatalf("expected only the copied file to have a hash, got %+v", m.Entries)
+	}
+}
+
+func TestHashIndexMapsRecordedHashesToTargets(t *testing.T) {
+	targetDir := t.TempDir()
+	if index, err := HashIndex(targetDir, hash.SHA256); err != nil || len(index) != 0 {
+		t.Fatalf("expected an empty index without manifests, got %v, %v", index, err)
+	}
+
+	createdAt := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	first := domain.NewFileMeta("/card/DSC0001.ARW", "DSC0001.ARW", createdAt)
+	second := domain.NewFileMeta("/card/DSC0002.ARW", "DSC0002.ARW", createdAt)
+	failed := domain.NewFileMeta("/card/DSC0003.ARW", "DSC0003.ARW", createdAt)
+
+	var result domain.ExecutionResult
+	result.Record(domain.CopyItem{FileMeta: first, TargetPath: "/target/DSC0001.ARW"}, domain.ItemCopied, nil)
+	result.RecordLinked(domain.CopyItem{FileMeta: second, TargetPath: "/target/DSC0002.ARW"})
+	result.Record(domain.CopyItem{FileMeta: failed, TargetPath: "/target/DSC0003.ARW"}, domain.ItemFailed, errors.New("boom"))
+	result.Items[0].Hash, result.Items[1].Hash = "aa", "bb"
+	m := FromResult("/card", targetDir, result, createdAt)
+	m.HashAlgorithm = hash.SHA256
+	path, err := Write(targetDir, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	read, err := Read(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if read.Entries[0].Linked || !read.Entries[1].Linked {
+		t.Fatalf("expected only the second entry to be linked, got %+v", read.Entries)
+	}
+	index, err := HashIndex(targetDir, hash.SHA256)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(index) != 2 || index["aa"] != "/target/DSC0001.ARW" || index["bb"] != "/target/DSC0002.ARW" {
+		t.Fatalf("expected the copied targets by hash, got %v", index)
+	}
+	if index, err := HashIndex(targetDir, hash.XXH3); err != nil || len(index) != 0 {
+		t.Fatalf("expected no matches for hashes of another algorithm, got %v, %v", index, err)
+	}
+}
+
+func TestWriteKeepsTheSHA256KeyForSHA256Hashes(t *testing.T) {
+	tests := []struct {
+		algorithm hash.Algorithm
+		want      []string
+		wantNot   []string
+	}{
+		{hash.SHA256, []string{`"sha256": "aa"`}, []string{`"hash"`, `"hash_algorithm"`}},
+		{hash.XXH3, []string{`"hash": "aa"`, `"hash_algorithm": "xxh3"`}, []string{`"sha256"`}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.algorithm), func(t *testing.T) {
+			meta := domain.NewFileMeta("/card/DSC0001.ARW", "DSC0001.ARW", time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC))
+			var result domain.ExecutionResult
+			result.Record(domain.CopyItem{FileMeta: meta, TargetPath: "/target/DSC0001.ARW"}, domain.ItemCopied, nil)
+			result.Items[0].Hash = "aa"
+			m := FromResult("/card", "/target", result, meta.TakenAt)
+			m.HashAlgorithm = tt.algorithm
+
+			path, err := Write(t.TempDir(), m)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("expected %s in:\n%s", want, data)
+				}
+			}
+			for _, key := range tt.wantNot {
+				if strings.Contains(string(data), key) {
+					t.Errorf("did not expect %s in:\n%s", key, data)
+				}
+			}
+			read, err := Read(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if read.Algorithm() != tt.algorithm || read.Entries[0].Hash != "aa" || read.Entries[0].SHA256 != "" {
+				t.Fatalf("expected the hash back, got %q of %+v", read.Algorithm(), read.Entries[0])
+			}
+			if m.Entries[0].Hash != "aa" {
+				t.Fatalf("expected Write to leave the manifest alone, got %+v", m.Entries[0])
+			}
+		})
+	}
+}
+
+func TestReadTakesTheSHA256OfOlderManifests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest-20241002-150100.json")
+	data := `{"entries": [{"source_path": "/card/DSC0001.ARW", "target_path": "/target/DSC0001.ARW", "status": "copied", "sha256": "aa"}]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Read(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Algorithm() != hash.SHA256 || m.Entries[0].Hash != "aa" {
+		t.Fatalf("expected the SHA-256 of the entry, got %q of %+v", m.Algorithm(), m.Entries[0])
+	}
+}
@@ -0,0 +1,66 @@
+package planfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+// Version is the format of the plan files written by this build.
+const Version = 3
+
+// File is a plan saved by `phopy plan --plan-out` for `phopy copy
+// --plan-in`. Saving the plan of the same files twice yields the same
+// items in the same order, see domain.CopyPlan.Items.
+type File struct {
+	Version      int             `json:"version"`
+	CreatedAt    time.Time       `json:"created_at"`
+	SourceDir    string          `json:"source_dir"`
+	TargetDir    string          `json:"target_dir"`
+	ConfigDigest string          `json:"config_digest"`
+	Plan         domain.CopyPlan `json:"plan"`
+}
+
+// Write stores f at path, creating its directory if needed.
+func Write(path string, f File) error {
+	f.Version = Version
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// VersionError is a plan file of another format version than Version.
+type VersionError struct {
+	Path    string
+	Version int
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("plan file %s has version %d, but this build of phopy reads version %d only; save the plan again with this build", e.Path, e.Version, Version)
+}
+
+// Read loads the plan file at path. Plan files of other versions fail with
+// a *VersionError, since their fields may mean something else.
+func Read(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return File{}, fmt.Errorf("invalid plan file %s: %w", path, err)
+	}
+	if f.Version != Version {
+		return File{}, &VersionError{Path: path, Version: f.Version}
+	}
+	return f, nil
+}
@@ -0,0 +1,68 @@
+package planfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	taken := time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC)
+	item := domain.CopyItem{
+		FileMeta:   domain.NewFileMeta("/card/DCIM/100MSDCF/DSC0001.ARW", "DCIM/100MSDCF/DSC0001.ARW", taken),
+		TargetPath: "/archive/2024-10-02/DSC0001.ARW",
+	}
+	want := File{
+		CreatedAt:    taken,
+		SourceDir:    "/card",
+		TargetDir:    "/archive",
+		ConfigDigest: "0a1b2c3d4e5f",
+		Plan:         domain.CopyPlan{Items: []domain.CopyItem{item}, RawCount: 1, RangeStart: &taken, RangeEnd: &taken},
+	}
+	want.Plan.Warnings = []domain.Warning{domain.Warningf(domain.WarningExifMissing, "EXIF not found for DSC0001.ARW, using filesystem time").About(domain.ExiflessActions, item.FileMeta.SourcePath)}
+
+	path := filepath.Join(t.TempDir(), "plans", "card.json")
+	if err := Write(path, want); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got.Version != Version || got.SourceDir != want.SourceDir || got.ConfigDigest != want.ConfigDigest {
+		t.Fatalf("unexpected header: %+v", got)
+	}
+	if len(got.Plan.Items) != 1 || got.Plan.Items[0].TargetPath != item.TargetPath || !got.Plan.Items[0].FileMeta.TakenAt.Equal(taken) {
+		t.Fatalf("unexpected items: %+v", got.Plan.Items)
+	}
+	if got.Plan.RawCount != 1 || got.Plan.RangeStart == nil || !got.Plan.RangeStart.Equal(taken) {
+		t.Fatalf("unexpected plan: %+v", got.Plan)
+	}
+	if len(got.Plan.Warnings) != 1 || !reflect.DeepEqual(got.Plan.Warnings[0], want.Plan.Warnings[0]) {
+		t.Fatalf("expected the warning with its code and actions, got %+v", got.Plan.Warnings)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.Contains(string(data), `"code": "exif_missing"`) {
+		t.Fatalf("expected the warning code in the JSON:\n%s", data)
+	}
+}
+
+func TestReadRejectsOtherVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "card.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var versionErr *VersionError
+	if _, err := Read(path); !errors.As(err, &versionErr) || versionErr.Version != 99 || !strings.Contains(err.Error(), "save the plan again") {
+		t.Fatalf("expected a version error, got %v", err)
+	}
+}
@@ -1,59 +1,119 @@
 package presentation
 
 import (
+	"bufio"
 	"fmt"
 	"io"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"phopy/internal/domain"
+	"phopy/internal/format"
 )
 
+// DefaultOverrideCap is how many override items the TUI and the printer
+// list before summarizing the rest.
+const DefaultOverrideCap = 4
+
 type Printer struct {
 	Writer  io.Writer
 	Verbose bool
+	// ShowAll prints every planned item instead of a sample.
+	ShowAll bool
+	// PageSize prints the file lists in pages of that many lines; implies
+	// ShowAll. Between pages an Interactive printer waits for Enter on Input
+	// (q skips the rest of the list), otherwise output is continuous.
+	PageSize    int
+	Interactive bool
+	Input       io.Reader
+	// Quiet prints only the dry-run verdict line.
+	Quiet bool
+	// OverrideCap caps the override list unless ShowAll or PageSize is
+	// set; 0 uses DefaultOverrideCap.
+	OverrideCap int
+	// PreviewCount is how many planned items are sampled unless ShowAll or
+	// PageSize is set; 0 uses DefaultPreviewCount.
+	PreviewCount int
 }
 
 func (p Printer) PrintDryRun(plan domain.CopyPlan) {
-	fmt.Fprintln(p.Writer, "Copying:")
-	fmt.Fprintln(p.Writer)
+	if p.Quiet {
+		fmt.Fprintln(p.Writer, DryRunVerdict(plan))
+		return
+	}
 
-	for _, line := range formatCopyLines(plan.Items) {
-		fmt.Fprintln(p.Writer, line)
+	if plan.ApproximateDates {
+		fmt.Fprintln(p.Writer, "Copying (approximate dates):")
+	} else {
+		fmt.Fprintln(p.Writer, "Copying:")
 	}
+	fmt.Fprintln(p.Writer)
+	p.printLines(p.copyLines(plan.Items))
 
 	fmt.Fprintln(p.Writer)
 	fmt.Fprintln(p.Writer, "Override Required:")
-	for _, item := range plan.OverrideItems {
-		fmt.Fprintln(p.Writer, item.FileMeta.Name)
-	}
+	p.printLines(p.overrideLines(plan.OverrideItems))
 
 	fmt.Fprintln(p.Writer)
 	p.printSummary(plan, true, 0)
 
+	if p.Verbose && len(plan.ExtensionCounts) > 0 {
+		fmt.Fprintln(p.Writer, "Extensions: "+formatExtensionCounts(plan.ExtensionCounts))
+	}
+
+	if p.Verbose && len(plan.Folders) > 1 {
+		fmt.Fprintln(p.Writer)
+		fmt.Fprintln(p.Writer, "Folders:")
+		for _, folder := range plan.Folders {
+			fmt.Fprintln(p.Writer, "- "+FolderLine(folder))
+		}
+	}
+
 	if p.Verbose && len(plan.Warnings) > 0 {
 		fmt.Fprintln(p.Writer)
 		fmt.Fprintln(p.Writer, "Warnings:")
 		for _, warning := range plan.Warnings {
-			fmt.Fprintln(p.Writer, "- "+warning)
+			fmt.Fprintln(p.Writer, "- "+warning.Text)
+		}
+		if plan.TruncatedWarnings > 0 {
+			fmt.Fprintln(p.Writer, "- "+TruncatedLine(plan.TruncatedWarnings, plan.WarningLog))
 		}
 	}
+
+	if p.Verbose && len(plan.Pairings) > 0 {
+		fmt.Fprintln(p.Writer)
+		fmt.Fprintln(p.Writer, "JPEGs skipped for their RAW:")
+		for _, pairing := range plan.Pairings {
+			fmt.Fprintf(p.Writer, "- %s → %s\n", pairing.JPEG, pairing.RAW)
+		}
+	}
+
+	fmt.Fprintln(p.Writer)
+	fmt.Fprintln(p.Writer, DryRunVerdict(plan))
+}
+
+// DryRunVerdict is the single line that ends a dry run, meant for scripts:
+//
+//	DRY-RUN: would copy 117 files (5 RAW, 112 JPEG, 1.8 GiB), 3 conflicts, 14 skipped
+//
+// The format is stable; conflicts are existing target files and skipped
+// counts the files the summary itemizes as skipped.
+func DryRunVerdict(plan domain.CopyPlan) string {
+	return fmt.Sprintf("DRY-RUN: would copy %d files (%d RAW, %d JPEG, %s), %d conflicts, %d skipped",
+		len(plan.Items), plan.RawCount, plan.JpegCount, format.Bytes(plan.TotalBytes()), len(plan.OverrideItems), plan.Skipped())
 }
 
 func (p Printer) PrintExecution(plan domain.CopyPlan, overridesConfirmed int) {
 	fmt.Fprintln(p.Writer, "Copying:")
 	fmt.Fprintln(p.Writer)
-
-	for _, line := range formatCopyLines(plan.Items) {
-		fmt.Fprintln(p.Writer, line)
-	}
+	p.printLines(p.copyLines(plan.Items))
 
 	if len(plan.OverrideItems) > 0 {
 		fmt.Fprintln(p.Writer)
 		fmt.Fprintln(p.Writer, "Override Required:")
-		for _, item := range plan.OverrideItems {
-			fmt.Fprintln(p.Writer, item.FileMeta.Name)
-		}
+		p.printLines(p.overrideLines(plan.OverrideItems))
 	}
 
 	fmt.Fprintln(p.Writer)
@@ -64,15 +124,53 @@ func (p Printer) printSummary(plan domain.CopyPlan, dryRun bool, overridesConfir
 	rangeStart := formatDate(plan.RangeStart)
 	rangeEnd := formatDate(plan.RangeEnd)
 
+	if line := ScanLine(plan.Scan); line != "" {
+		fmt.Fprintln(p.Writer, line+".")
+	}
 	if rangeStart == "" || rangeEnd == "" {
 		fmt.Fprintf(p.Writer, "Copied %d RAW and %d JPEG files.\n", plan.RawCount, plan.JpegCount)
 	} else {
 		fmt.Fprintf(p.Writer, "Copied %d RAW and %d JPEG files from %s until %s.\n", plan.RawCount, plan.JpegCount, rangeStart, rangeEnd)
 	}
+	if plan.ApproximateDates {
+		fmt.Fprintln(p.Writer, ApproximateDatesLine+".")
+	}
 
 	fmt.Fprintf(p.Writer, "Skipped %d JPEGs because their RAW files existed.\n", plan.SkippedJPEGs)
-	fmt.Fprintf(p.Writer, "Skipped %d RAWs (date filter).\n", plan.SkippedRAWsDate)
+	if p.Verbose && plan.SkippedRAWsDate > 0 {
+		fmt.Fprintf(p.Writer, "Skipped %d RAWs (date filter: %s).\n", plan.SkippedRAWsDate, DateSkipsLine(plan))
+	} else {
+		fmt.Fprintf(p.Writer, "Skipped %d RAWs (date filter).\n", plan.SkippedRAWsDate)
+	}
 	fmt.Fprintf(p.Writer, "Skipped %d RAWs (duplicate).\n", plan.SkippedRAWsDupl)
+	if plan.Duplicates > 0 {
+		fmt.Fprintf(p.Writer, "Skipped %d duplicate captures.\n", plan.Duplicates)
+	}
+	if plan.JunkFiles > 0 {
+		fmt.Fprintf(p.Writer, "Skipped %d junk files (.DS_Store, ._ AppleDouble files, Thumbs.db).\n", plan.JunkFiles)
+	}
+	if plan.ProxyClips > 0 {
+		fmt.Fprintf(p.Writer, "Skipped %d proxy clips (low-resolution copies of videos, see --include-proxies).\n", plan.ProxyClips)
+	}
+	if line := OutsideRangeLine(plan.OutsideRange); line != "" {
+		fmt.Fprintln(p.Writer, line+".")
+	}
+	if plan.InvalidDates > 0 {
+		fmt.Fprintf(p.Writer, "Invalid EXIF date: %d files, dated by their filesystem time.\n", plan.InvalidDates)
+	}
+	if line := RatingsLine(plan.Ratings); line != "" {
+		fmt.Fprintln(p.Writer, line+".")
+	}
+	if plan.ZoneBoundary > 0 {
+		fmt.Fprintf(p.Writer, "%d files fall on another date in the checked zone, see the warnings.\n", plan.ZoneBoundary)
+	}
+	if line := SinceLastImportLine(plan.SinceLastImport); line != "" {
+		fmt.Fprintln(p.Writer, line+".")
+	}
+
+	if line := autoOverrideLine(len(plan.AutoOverrideItems), dryRun); line != "" {
+		fmt.Fprintln(p.Writer, line)
+	}
 
 	overrideCount := plan.RawOverrides + plan.JpegOverrides
 	if dryRun {
@@ -95,19 +193,336 @@ func (p Printer) printSummary(plan domain.CopyPlan, dryRun bool, overridesConfir
 	}
 }
 
-func formatCopyLines(items []domain.CopyItem) []string {
+// PrintResult prints what actually happened during an execution.
+func (p Printer) PrintResult(result domain.ExecutionResult) {
+	fmt.Fprintf(p.Writer, "Copied %d RAW and %d JPEG files (%s).\n", result.RawCopied, result.JpegCopied, format.Bytes(result.BytesCopied))
+
+	if result.Linked > 0 {
+		fmt.Fprintf(p.Writer, "Linked %d of them to identical files already in the target instead of copying them.\n", result.Linked)
+	}
+	if result.SkippedOverrides > 0 {
+		fmt.Fprintf(p.Writer, "Skipped %d files that already existed in the target.\n", result.SkippedOverrides)
+	}
+	if result.SourceChanged > 0 {
+		fmt.Fprintf(p.Writer, "Copied the current version of %d files that changed after planning.\n", result.SourceChanged)
+	}
+	if result.SkippedChanged > 0 {
+		fmt.Fprintf(p.Writer, "Skipped %d files that changed after planning.\n", result.SkippedChanged)
+	}
+	if result.Cancelled > 0 {
+		fmt.Fprintf(p.Writer, "Cancelled %d files before they were copied.\n", result.Cancelled)
+	}
+	if result.SkippedLocked > 0 {
+		fmt.Fprintf(p.Writer, "Still locked, not copied (%d files open in another program):\n", result.SkippedLocked)
+		for _, locked := range result.LockedItems() {
+			fmt.Fprintf(p.Writer, "- %s\n", locked.Item.FileMeta.SourcePath)
+		}
+	}
+	if result.Failed > 0 {
+		fmt.Fprintf(p.Writer, "Failed to copy %d files:\n", result.Failed)
+		for _, failed := range result.FailedItems() {
+			fmt.Fprintf(p.Writer, "- %s: %v\n", failed.Item.FileMeta.Name, failed.Err)
+		}
+	}
+	if result.Integrity.Mismatches() > 0 {
+		fmt.Fprintf(p.Writer, "Verified the copies against their sources: %s.\n", IntegrityLine(result.Integrity))
+	}
+	if result.RunID != "" {
+		fmt.Fprintf(p.Writer, "Run %s.\n", result.RunID)
+	}
+}
+
+// PrintPlanDiff prints how a plan differs from an earlier one, see
+// domain.DiffPlans.
+func (p Printer) PrintPlanDiff(diff domain.PlanDiff) {
+	if diff.Empty() {
+		fmt.Fprintln(p.Writer, "The plans copy the same files to the same targets.")
+		return
+	}
+	if len(diff.Moved) > 0 {
+		fmt.Fprintf(p.Writer, "Moved %d files:\n", len(diff.Moved))
+		for _, moved := range diff.Moved {
+			fmt.Fprintf(p.Writer, "~ %s: %s -> %s\n", moved.SourcePath, moved.OldTarget, moved.NewTarget)
+		}
+	}
+	if len(diff.OnlyOld) > 0 {
+		fmt.Fprintf(p.Writer, "Only in the old plan (%d files):\n", len(diff.OnlyOld))
+		for _, source := range diff.OnlyOld {
+			fmt.Fprintf(p.Writer, "- %s\n", source)
+		}
+	}
+	if len(diff.OnlyNew) > 0 {
+		fmt.Fprintf(p.Writer, "Only in the new plan (%d files):\n", len(diff.OnlyNew))
+		for _, source := range diff.OnlyNew {
+			fmt.Fprintf(p.Writer, "+ %s\n", source)
+		}
+	}
+	if len(diff.Counts) > 0 {
+		fmt.Fprintln(p.Writer, "Changed counts:")
+		for _, count := range diff.Counts {
+			fmt.Fprintf(p.Writer, "  %s: %d -> %d (%+d)\n", count.Name, count.Old, count.New, count.New-count.Old)
+		}
+	}
+}
+
+// PrintAudit prints the findings of an audit grouped by issue, see
+// app.Planner.Audit.
+func (p Printer) PrintAudit(report domain.AuditReport) {
+	if report.Findings() == 0 {
+		fmt.Fprintf(p.Writer, "Audited %d files in %s: nothing to report.\n", report.Files, report.Dir)
+		return
+	}
+	fmt.Fprintf(p.Writer, "Audited %d files in %s: %d findings.\n", report.Files, report.Dir, report.Findings())
+	for i, group := range report.Groups {
+		if len(group.Findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(p.Writer, "%s (%d):\n", domain.AuditIssues[i].Title, len(group.Findings))
+		for _, finding := range group.Findings {
+			fmt.Fprintf(p.Writer, "- %s%s\n", finding.Path, auditNote(group.Issue, finding))
+		}
+	}
+}
+
+// auditNote tells where a finding belongs, or what it repeats.
+func auditNote(issue string, finding domain.AuditFinding) string {
+	switch issue {
+	case domain.AuditMisfiled:
+		return fmt.Sprintf(": %s, belongs in %s", finding.Detail, finding.Folder)
+	case domain.AuditSplitPair:
+		return fmt.Sprintf(": its RAW is %s", finding.Of)
+	case domain.AuditDuplicate:
+		return fmt.Sprintf(": duplicate of %s", finding.Of)
+	}
+	return ""
+}
+
+// copyLines returns the copy list, sampled unless ShowAll or PageSize is set.
+func (p Printer) copyLines(items []domain.CopyItem) []string {
+	if p.ShowAll || p.PageSize > 0 {
+		return allCopyLines(items)
+	}
+	return formatCopyLines(items, EffectivePreviewCount(p.PreviewCount))
+}
+
+// overrideLines returns the override list, capped at OverrideCap unless
+// ShowAll or PageSize is set.
+func (p Printer) overrideLines(items []domain.CopyItem) []string {
+	limit := len(items)
+	if !p.ShowAll && p.PageSize <= 0 {
+		limit = min(limit, EffectiveOverrideCap(p.OverrideCap))
+	}
+	lines := make([]string, 0, limit+1)
+	for _, item := range items[:limit] {
+		lines = append(lines, item.FileMeta.Name)
+	}
+	if limit < len(items) {
+		lines = append(lines, MoreLine(len(items)-limit))
+	}
+	return lines
+}
+
+// EffectiveOverrideCap resolves a configured override cap, where 0
+// means DefaultOverrideCap.
+func EffectiveOverrideCap(configured int) int {
+	if configured <= 0 {
+		return DefaultOverrideCap
+	}
+	return configured
+}
+
+// MoreLine summarizes n list items that are not shown.
+func MoreLine(n int) string {
+	return fmt.Sprintf("... and %d more", n)
+}
+
+// printLines writes lines, in pages of PageSize when it is set.
+func (p Printer) printLines(lines []string) {
+	if p.PageSize <= 0 {
+		for _, line := range lines {
+			fmt.Fprintln(p.Writer, line)
+		}
+		return
+	}
+
+	var input *bufio.Reader
+	if p.Interactive && p.Input != nil {
+		input = bufio.NewReader(p.Input)
+	}
+	for start := 0; start < len(lines); start += p.PageSize {
+		end := start + p.PageSize
+		if end > len(lines) {
+			end = len(lines)
+		}
+		for _, line := range lines[start:end] {
+			fmt.Fprintln(p.Writer, line)
+		}
+		if input == nil || end == len(lines) {
+			continue
+		}
+		fmt.Fprint(p.Writer, "-- more --")
+		answer, err := input.ReadString('\n')
+		if err != nil {
+			// Input is gone, print the rest without waiting
+			fmt.Fprintln(p.Writer)
+			input = nil
+		}
+		if strings.TrimSpace(strings.ToLower(answer)) == "q" {
+			fmt.Fprintf(p.Writer, "... %d more\n", len(lines)-end)
+			return
+		}
+	}
+}
+
+func allCopyLines(items []domain.CopyItem) []string {
 	lines := make([]string, 0, len(items))
 	for _, item := range items {
 		date := item.FileMeta.TakenAt.Format("2006-01-02 15:04")
 		lines = append(lines, fmt.Sprintf("Copy %s  %s", item.FileMeta.Name, date))
 	}
+	return lines
+}
+
+// formatCopyLines lists a sample of count items, see SampleIndexes.
+func formatCopyLines(items []domain.CopyItem, count int) []string {
+	picks := SampleIndexes(len(items), count)
+	sample := make([]domain.CopyItem, 0, len(picks))
+	for _, i := range picks {
+		sample = append(sample, items[i])
+	}
+	lines := allCopyLines(sample)
+	if len(sample) < len(items) {
+		lines = append(lines, InBetweenLine(len(items)-len(sample)))
+	}
+	return lines
+}
+
+// ApproximateDatesLine labels plans made with --fast-plan.
+const ApproximateDatesLine = "Approximate dates: EXIF was not read, files are dated by their modification time"
+
+// OutsideRangeLine describes the files excluded by the date range, or returns
+// "" when nothing was excluded.
+func OutsideRangeLine(r domain.RangeExclusions) string {
+	if r.Count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d files outside the range (earliest %s, latest %s)", r.Count, r.Earliest.Format("2006-01-02"), r.Latest.Format("2006-01-02"))
+}
+
+// SinceLastImportLine compares the plan to the last import, e.g. "Since
+// your last import on 2024-03-10: 212 new files, 0 previously imported files
+// modified", or returns "" without earlier imports.
+func SinceLastImportLine(diff *domain.ImportDiff) string {
+	if diff == nil {
+		return ""
+	}
+	line := fmt.Sprintf("Since your last import on %s: %d new files, %d previously imported files modified",
+		diff.LastImport.Format("2006-01-02"), diff.New, diff.Modified)
+	if diff.Unchanged > 0 {
+		line += fmt.Sprintf(", %d unchanged", diff.Unchanged)
+	}
+	return line
+}
+
+// PrintIntegrity prints every file of the report that is not intact and
+// a summary line.
+func (p Printer) PrintIntegrity(report domain.IntegrityReport) {
+	for _, finding := range report.Findings {
+		if finding.TargetPath == "" {
+			fmt.Fprintf(p.Writer, "%s: %s\n", finding.SourcePath, finding.Class)
+			continue
+		}
+		fmt.Fprintf(p.Writer, "%s (from %s): %s\n", finding.TargetPath, finding.SourcePath, finding.Class)
+	}
+	total := 0
+	for _, count := range report.Summary {
+		total += count
+	}
+	fmt.Fprintf(p.Writer, "Checked %d files: %s\n", total, IntegrityLine(report.Summary))
+}
 
-	if len(lines) <= 4 {
-		return lines
+// IntegrityLine summarizes checked files per class, e.g. "40 ok, 2 target
+// corrupted, 1 source modified since import".
+func IntegrityLine(summary domain.IntegritySummary) string {
+	var parts []string
+	for _, class := range []domain.Integrity{domain.IntegrityOK, domain.IntegrityTargetCorrupted, domain.IntegritySourceModified, domain.IntegrityDiffers, domain.IntegrityNeverImported} {
+		if count := summary[class]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, class))
+		}
+	}
+	if len(parts) == 0 {
+		return "No files checked"
 	}
-	head := lines[:2]
-	tail := lines[len(lines)-2:]
-	return append(append(head, "..."), tail...)
+	return strings.Join(parts, ", ")
+}
+
+// PairingLine explains why a JPEG was skipped like the summary does, e.g.
+// "Skipped DSC0042.JPG because its RAW DSC0042.ARW existed".
+func PairingLine(pairing domain.JPEGPairing) string {
+	return fmt.Sprintf("Skipped %s because its RAW %s existed", filepath.Base(pairing.JPEG), filepath.Base(pairing.RAW))
+}
+
+// RatingsLine describes the star ratings of the planned files, e.g. "42
+// files rated ≥3 stars (5★ 3, 4★ 10, 3★ 29, 1★ 2)", or returns "" when no
+// file has a rating.
+func RatingsLine(ratings map[int]int) string {
+	if len(ratings) == 0 {
+		return ""
+	}
+	keepers := 0
+	var parts []string
+	for rating := 5; rating >= 0; rating-- {
+		count := ratings[rating]
+		if count == 0 {
+			continue
+		}
+		if rating >= 3 {
+			keepers += count
+		}
+		parts = append(parts, fmt.Sprintf("%d★ %d", rating, count))
+	}
+	return fmt.Sprintf("%d files rated ≥3 stars (%s)", keepers, strings.Join(parts, ", "))
+}
+
+// FolderLine sums up the files of a plan from one folder of the source,
+// e.g. "DCIM/100MSDCF: 120 planned, 3 conflicts, 5 skipped, 2 warnings,
+// 2024-04-01 to 2024-04-03".
+func FolderLine(folder domain.FolderStats) string {
+	parts := []string{fmt.Sprintf("%s planned", format.Count(folder.Planned))}
+	for _, count := range []struct {
+		n    int
+		noun string
+	}{
+		{folder.Conflicts, "conflicts"},
+		{folder.Skipped, "skipped"},
+		{folder.Warnings, "warnings"},
+	} {
+		if count.n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %s", format.Count(count.n), count.noun))
+		}
+	}
+	switch first, last := folder.First.Format("2006-01-02"), folder.Last.Format("2006-01-02"); {
+	case folder.First.IsZero():
+	case first == last:
+		parts = append(parts, "on "+first)
+	default:
+		parts = append(parts, first+" to "+last)
+	}
+	return folder.Folder + ": " + strings.Join(parts, ", ")
+}
+
+func formatExtensionCounts(counts map[string]int) string {
+	exts := make([]string, 0, len(counts))
+	for ext := range counts {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+
+	parts := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		parts = append(parts, fmt.Sprintf("%s %d", ext, counts[ext]))
+	}
+	return strings.Join(parts, ", ")
 }
 
 func formatDate(value *time.Time) string {
@@ -117,6 +532,48 @@ func formatDate(value *time.Time) string {
 	return value.Format("2006-01-02")
 }
 
+// DateSkipsLine splits the RAWs skipped by the date filter by how it
+// decided, e.g. "3 by modification time, 1 by capture date".
+func DateSkipsLine(plan domain.CopyPlan) string {
+	return fmt.Sprintf("%d by modification time, %d by capture date", plan.SkippedByMtimeShortcut, plan.SkippedByCaptureDate)
+}
+
+// ScanLine sums up the scan a plan was made from, e.g. "Scanned 4,112
+// files in 38s · 12 warnings", and is empty when it is unknown.
+func ScanLine(scan domain.ScanStats) string {
+	if scan.Files == 0 {
+		return ""
+	}
+	line := fmt.Sprintf("Scanned %s files in %s", format.Count(scan.Files), format.Duration(scan.Duration))
+	if scan.Warnings > 0 {
+		line += fmt.Sprintf(" · %s warnings", format.Count(scan.Warnings))
+	}
+	return line
+}
+
+// TruncatedLine tells how many entries a list leaves out and where they
+// are, e.g. "and 299,000 more (see /tmp/phopy-warnings-1.log)".
+func TruncatedLine(n int, log string) string {
+	if log == "" {
+		return fmt.Sprintf("and %s more", format.Count(n))
+	}
+	return fmt.Sprintf("and %s more (see %s)", format.Count(n), log)
+}
+
+// EstimateCopyTime is how long writing n bytes takes at throughput bytes
+// per second, or 0 without a throughput.
+func EstimateCopyTime(n int64, throughput float64) time.Duration {
+	if throughput <= 0 {
+		return 0
+	}
+	return time.Duration(float64(n) / throughput * float64(time.Second))
+}
+
+// EstimateLine labels the estimated duration of a copy as an estimate.
+func EstimateLine(d time.Duration) string {
+	return fmt.Sprintf("Estimated time: %s (rough, from a short write test of the target)", format.Estimate(d))
+}
+
 func JoinLines(lines []string) string {
 	return strings.Join(lines, "\n")
 }
@@ -131,6 +588,19 @@ func dryRunOverrideLine(plan domain.CopyPlan) string {
 	return fmt.Sprintf("Would ask for override confirmation for %d RAW and %d JPEG files when not in dry run.", plan.RawOverrides, plan.JpegOverrides)
 }
 
+// autoOverrideLine reports the stale targets overwritten without asking
+// (--auto-override-stale), apart from the overrides the user confirms.
+func autoOverrideLine(count int, dryRun bool) string {
+	switch {
+	case count == 0:
+		return ""
+	case dryRun:
+		return fmt.Sprintf("Would overwrite %d stale targets without asking (older than the source, another size).", count)
+	default:
+		return fmt.Sprintf("Overwrote %d stale targets without asking (older than the source, another size).", count)
+	}
+}
+
 func runtimeOverrideLine(plan domain.CopyPlan, confirmed bool) string {
 	verb := "declined"
 	if confirmed {
@@ -2,15 +2,42 @@ package presentation
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"phopy/internal/domain"
+	"phopy/internal/planfile"
 )
 
-func TestFormatCopyLinesTruncates(t *testing.T) {
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// checkGolden compares got to testdata/name, rewriting it with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if got != string(want) {
+		t.Fatalf("output differs from %s:\n%s", path, got)
+	}
+}
+
+func TestFormatCopyLinesSamples(t *testing.T) {
 	items := make([]domain.CopyItem, 0, 6)
 	for i := 0; i < 6; i++ {
 		items = append(items, domain.CopyItem{
@@ -21,12 +48,20 @@ func TestFormatCopyLinesTruncates(t *testing.T) {
 		})
 	}
 
-	lines := formatCopyLines(items)
-	if len(lines) != 5 {
-		t.Fatalf("expected 5 lines, got %d", len(lines))
+	if lines := formatCopyLines(items, DefaultPreviewCount); len(lines) != 6 {
+		t.Fatalf("expected every item, got %d lines", len(lines))
 	}
-	if lines[2] != "..." {
-		t.Fatalf("expected ellipsis, got %q", lines[2])
+
+	lines := formatCopyLines(items, 4)
+	want := []string{
+		"Copy DSC0000.ARW  2024-10-02 10:00",
+		"Copy DSC0001.ARW  2024-10-02 11:00",
+		"Copy DSC0003.ARW  2024-10-02 13:00",
+		"Copy DSC0005.ARW  2024-10-02 15:00",
+		"... 2 more files in between",
+	}
+	if !slices.Equal(lines, want) {
+		t.Fatalf("expected a sample across the plan, got %q", lines)
 	}
 }
 
@@ -61,3 +96,427 @@ func TestPrintDryRunOutputIncludesSections(t *testing.T) {
 		t.Fatalf("expected copy line")
 	}
 }
+
+func TestPrintResultReportsFailuresAndSkips(t *testing.T) {
+	var buf bytes.Buffer
+	printer := Printer{Writer: &buf}
+
+	result := domain.ExecutionResult{RunID: "20241002T130100Z-1a2b3c4d"}
+	result.Record(domain.CopyItem{FileMeta: domain.FileMeta{Name: "DSC0001.ARW", IsRAW: true, Size: 2048}}, domain.ItemCopied, nil)
+	result.Record(domain.CopyItem{FileMeta: domain.FileMeta{Name: "DSC0002.ARW", IsRAW: true}}, domain.ItemFailed, errors.New("no space left"))
+	result.Record(domain.CopyItem{FileMeta: domain.FileMeta{Name: "DSC0003.JPG", IsJPEG: true}}, domain.ItemFailed, errors.New("permission denied"))
+	result.Record(domain.CopyItem{FileMeta: domain.FileMeta{Name: "DSC0004.ARW", IsRAW: true}}, domain.ItemSkippedOverride, nil)
+	result.Record(domain.CopyItem{FileMeta: domain.FileMeta{Name: "C0001.MP4", SourcePath: "/card/PRIVATE/M4ROOT/CLIP/C0001.MP4"}}, domain.ItemSkippedLocked, nil)
+	result.RecordLinked(domain.CopyItem{FileMeta: domain.FileMeta{Name: "DSC0005.ARW", IsRAW: true, Size: 1024}})
+
+	printer.PrintResult(result)
+	output := buf.String()
+
+	for _, want := range []string{
+		"Copied 2 RAW and 0 JPEG files (2.0 KiB).",
+		"Linked 1 of them to identical files already in the target instead of copying them.",
+		"Skipped 1 files that already existed in the target.",
+		"Failed to copy 2 files:",
+		"- DSC0002.ARW: no space left",
+		"- DSC0003.JPG: permission denied",
+		"Still locked, not copied (1 files open in another program):",
+		"- /card/PRIVATE/M4ROOT/CLIP/C0001.MP4",
+		"Run 20241002T130100Z-1a2b3c4d.",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestPrintDryRunLabelsApproximateDates(t *testing.T) {
+	var buf bytes.Buffer
+	printer := Printer{Writer: &buf}
+	printer.PrintDryRun(domain.CopyPlan{ApproximateDates: true})
+	for _, want := range []string{"Copying (approximate dates):", ApproximateDatesLine + "."} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestPrintDryRunReportsRangeExclusions(t *testing.T) {
+	var buf bytes.Buffer
+	printer := Printer{Writer: &buf}
+
+	var plan domain.CopyPlan
+	plan.OutsideRange.Add(time.Date(2024, 3, 30, 9, 0, 0, 0, time.Local))
+	plan.OutsideRange.Add(time.Date(2024, 2, 2, 9, 0, 0, 0, time.Local))
+
+	printer.PrintDryRun(plan)
+	want := "2 files outside the range (earliest 2024-02-02, latest 2024-03-30)."
+	if !strings.Contains(buf.String(), want) {
+		t.Fatalf("expected %q in output:\n%s", want, buf.String())
+	}
+}
+
+func pagedPlan(n int) domain.CopyPlan {
+	var plan domain.CopyPlan
+	for i := 0; i < n; i++ {
+		plan.Items = append(plan.Items, domain.CopyItem{
+			FileMeta: domain.FileMeta{
+				Name:    fmt.Sprintf("DSC000%d.ARW", i),
+				TakenAt: time.Date(2024, 10, 2, 10+i, 0, 0, 0, time.Local),
+			},
+		})
+	}
+	return plan
+}
+
+func TestPrintDryRunShowAllPrintsEveryItem(t *testing.T) {
+	var buf bytes.Buffer
+	Printer{Writer: &buf, ShowAll: true}.PrintDryRun(pagedPlan(6))
+
+	output := buf.String()
+	if strings.Contains(output, "...") {
+		t.Fatalf("did not expect truncation")
+	}
+	if strings.Count(output, "Copy DSC") != 6 {
+		t.Fatalf("expected 6 copy lines, got:\n%s", output)
+	}
+}
+
+func TestPrintDryRunPagesOnTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	printer := Printer{Writer: &buf, PageSize: 2, Interactive: true, Input: strings.NewReader("\n\n")}
+	printer.PrintDryRun(pagedPlan(5))
+
+	output := buf.String()
+	if strings.Count(output, "-- more --") != 2 {
+		t.Fatalf("expected a prompt between each of the 3 pages, got:\n%s", output)
+	}
+	if strings.Count(output, "Copy DSC") != 5 {
+		t.Fatalf("expected all 5 copy lines, got:\n%s", output)
+	}
+}
+
+func TestPrintDryRunPagingStopsOnQuit(t *testing.T) {
+	var buf bytes.Buffer
+	printer := Printer{Writer: &buf, PageSize: 2, Interactive: true, Input: strings.NewReader("q\n")}
+	printer.PrintDryRun(pagedPlan(5))
+
+	output := buf.String()
+	if strings.Count(output, "Copy DSC") != 2 {
+		t.Fatalf("expected only the first page, got:\n%s", output)
+	}
+	if !strings.Contains(output, "... 3 more") {
+		t.Fatalf("expected the skipped count, got:\n%s", output)
+	}
+}
+
+func TestPrintDryRunPagesContinuouslyWhenPiped(t *testing.T) {
+	var buf bytes.Buffer
+	printer := Printer{Writer: &buf, PageSize: 2, Interactive: false, Input: strings.NewReader("")}
+	plan := pagedPlan(5)
+	plan.OverrideItems = plan.Items[:3]
+	printer.PrintDryRun(plan)
+
+	output := buf.String()
+	if strings.Contains(output, "-- more --") {
+		t.Fatalf("did not expect prompts when piped")
+	}
+	if strings.Count(output, "Copy DSC") != 5 || strings.Count(output, "\nDSC000") != 3 {
+		t.Fatalf("expected full copy and override lists, got:\n%s", output)
+	}
+}
+
+func TestPrintDryRunCapsOverrideList(t *testing.T) {
+	plan := pagedPlan(7)
+	plan.OverrideItems = plan.Items[:7]
+
+	var buf bytes.Buffer
+	Printer{Writer: &buf}.PrintDryRun(plan)
+	output := buf.String()
+	if strings.Count(output, "\nDSC000") != 4 || !strings.Contains(output, "\n... and 3 more\n") {
+		t.Fatalf("expected 4 override items and the rest summarized, got:\n%s", output)
+	}
+
+	buf.Reset()
+	Printer{Writer: &buf, OverrideCap: 6}.PrintDryRun(plan)
+	output = buf.String()
+	if strings.Count(output, "\nDSC000") != 6 || !strings.Contains(output, "\n... and 1 more\n") {
+		t.Fatalf("expected the configured cap, got:\n%s", output)
+	}
+
+	buf.Reset()
+	Printer{Writer: &buf, ShowAll: true}.PrintDryRun(plan)
+	if output = buf.String(); strings.Count(output, "\nDSC000") != 7 || strings.Contains(output, "more") {
+		t.Fatalf("expected every override item with --show-all, got:\n%s", output)
+	}
+}
+
+func verdictPlan() domain.CopyPlan {
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	plan := domain.CopyPlan{
+		RawCount:        1,
+		JpegCount:       2,
+		SkippedJPEGs:    3,
+		SkippedRAWsDate: 1,
+		SkippedRAWsDupl: 2,
+		RangeStart:      &now,
+		RangeEnd:        &now,
+	}
+	for i, name := range []string{"DSC0001.ARW", "DSC0002.JPG", "DSC0003.JPG"} {
+		item := domain.CopyItem{FileMeta: domain.FileMeta{Name: name, TakenAt: now.Add(time.Duration(i) * time.Minute), Size: 600 << 20}}
+		plan.Items = append(plan.Items, item)
+	}
+	plan.OverrideItems = plan.Items[2:]
+	plan.JpegOverrides = 1
+	return plan
+}
+
+func TestPrintDryRunEndsWithVerdict(t *testing.T) {
+	var buf bytes.Buffer
+	Printer{Writer: &buf}.PrintDryRun(verdictPlan())
+	checkGolden(t, "dry-run.golden", buf.String())
+}
+
+func TestPrintPlanDiffOfTwoSavedPlans(t *testing.T) {
+	old, err := planfile.Read(filepath.Join("testdata", "plan-old.json"))
+	if err != nil {
+		t.Fatalf("read old plan: %v", err)
+	}
+	plan, err := planfile.Read(filepath.Join("testdata", "plan-new.json"))
+	if err != nil {
+		t.Fatalf("read new plan: %v", err)
+	}
+	diff := domain.DiffPlans(old.Plan, plan.Plan)
+
+	var buf bytes.Buffer
+	Printer{Writer: &buf}.PrintPlanDiff(diff)
+	checkGolden(t, "plan-diff.golden", buf.String())
+
+	data, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	checkGolden(t, "plan-diff.json.golden", string(data)+"\n")
+
+	buf.Reset()
+	Printer{Writer: &buf}.PrintPlanDiff(domain.DiffPlans(plan.Plan, plan.Plan))
+	if got := buf.String(); got != "The plans copy the same files to the same targets.\n" {
+		t.Fatalf("expected a plan to equal itself, got:\n%s", got)
+	}
+}
+
+func TestPrintDryRunQuietPrintsOnlyVerdict(t *testing.T) {
+	var buf bytes.Buffer
+	Printer{Writer: &buf, Quiet: true}.PrintDryRun(verdictPlan())
+	want := "DRY-RUN: would copy 3 files (1 RAW, 2 JPEG, 1.8 GiB), 1 conflicts, 6 skipped\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRatingsLine(t *testing.T) {
+	if line := RatingsLine(nil); line != "" {
+		t.Fatalf("expected no line without ratings, got %q", line)
+	}
+	got := RatingsLine(map[int]int{5: 3, 4: 10, 3: 29, 1: 2})
+	want := "42 files rated ≥3 stars (5★ 3, 4★ 10, 3★ 29, 1★ 2)"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestPrintDryRunVerboseListsPairings(t *testing.T) {
+	plan := pagedPlan(1)
+	plan.SkippedJPEGs = 1
+	plan.Pairings = []domain.JPEGPairing{{JPEG: "/card/DCIM/100MSDCF/DSC0042.JPG", RAW: "/card/DCIM/100MSDCF/DSC0042.ARW"}}
+
+	var buf bytes.Buffer
+	Printer{Writer: &buf}.PrintDryRun(plan)
+	if strings.Contains(buf.String(), "DSC0042") {
+		t.Fatalf("expected pairings only in verbose output, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	Printer{Writer: &buf, Verbose: true}.PrintDryRun(plan)
+	want := "JPEGs skipped for their RAW:\n- /card/DCIM/100MSDCF/DSC0042.JPG → /card/DCIM/100MSDCF/DSC0042.ARW\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Fatalf("expected %q in output:\n%s", want, buf.String())
+	}
+}
+
+func TestPrintDryRunVerboseListsFolders(t *testing.T) {
+	day := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	plan := pagedPlan(1)
+	plan.Folders = []domain.FolderStats{
+		{Folder: "DCIM/100MSDCF", Planned: 1200, Conflicts: 3, First: day, Last: day.AddDate(0, 0, 2)},
+		{Folder: "DCIM/101MSDCF", Skipped: 4, Warnings: 2},
+	}
+
+	var buf bytes.Buffer
+	Printer{Writer: &buf}.PrintDryRun(plan)
+	if strings.Contains(buf.String(), "Folders:") {
+		t.Fatalf("expected folders only in verbose output, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	Printer{Writer: &buf, Verbose: true}.PrintDryRun(plan)
+	want := "Folders:\n- DCIM/100MSDCF: 1,200 planned, 3 conflicts, 2024-04-01 to 2024-04-03\n- DCIM/101MSDCF: 0 planned, 4 skipped, 2 warnings\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Fatalf("expected %q in output:\n%s", want, buf.String())
+	}
+
+	// A single folder says nothing the summary does not
+	plan.Folders = plan.Folders[:1]
+	buf.Reset()
+	Printer{Writer: &buf, Verbose: true}.PrintDryRun(plan)
+	if strings.Contains(buf.String(), "Folders:") {
+		t.Fatalf("expected no folders for a single one, got:\n%s", buf.String())
+	}
+}
+
+func TestPairingLine(t *testing.T) {
+	got := PairingLine(domain.JPEGPairing{JPEG: "/card/DCIM/100MSDCF/DSC0042.JPG", RAW: "/card/DCIM/100MSDCF/DSC0042.ARW"})
+	want := "Skipped DSC0042.JPG because its RAW DSC0042.ARW existed"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSinceLastImportLine(t *testing.T) {
+	if line := SinceLastImportLine(nil); line != "" {
+		t.Fatalf("expected no line without earlier imports, got %q", line)
+	}
+	diff := &domain.ImportDiff{LastImport: time.Date(2024, 3, 10, 9, 0, 0, 0, time.Local), New: 212}
+	want := "Since your last import on 2024-03-10: 212 new files, 0 previously imported files modified"
+	if got := SinceLastImportLine(diff); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	diff.Modified, diff.Unchanged = 2, 5
+	if got := SinceLastImportLine(diff); !strings.HasSuffix(got, "2 previously imported files modified, 5 unchanged") {
+		t.Fatalf("unexpected line %q", got)
+	}
+}
+
+func TestIntegrityLine(t *testing.T) {
+	summary := domain.IntegritySummary{domain.IntegrityOK: 40, domain.IntegrityNeverImported: 3, domain.IntegrityTargetCorrupted: 2}
+	want := "40 ok, 2 target corrupted, 3 never imported"
+	if got := IntegrityLine(summary); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestEstimateCopyTime(t *testing.T) {
+	tests := []struct {
+		n          int64
+		throughput float64
+		want       time.Duration
+	}{
+		{n: 150 << 30, throughput: 75 << 20, want: 2048 * time.Second},
+		{n: 10 << 20, throughput: 100 << 20, want: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := EstimateCopyTime(tt.n, tt.throughput); got != tt.want {
+			t.Fatalf("%d bytes at %.0f B/s: expected %v, got %v", tt.n, tt.throughput, tt.want, got)
+		}
+	}
+	if EstimateCopyTime(1<<30, 0) != 0 {
+		t.Fatalf("expected no estimate without a throughput")
+	}
+}
+
+func TestTruncatedLine(t *testing.T) {
+	if got := TruncatedLine(299000, "/tmp/phopy-warnings-1.log"); got != "and 299,000 more (see /tmp/phopy-warnings-1.log)" {
+		t.Fatalf("unexpected line %q", got)
+	}
+	if got := TruncatedLine(999, ""); got != "and 999 more" {
+		t.Fatalf("unexpected line %q", got)
+	}
+}
+
+func TestPrintDryRunSumsUpTheScan(t *testing.T) {
+	plan := verdictPlan()
+	plan.Scan = domain.ScanStats{Files: 4112, Duration: 38 * time.Second, Warnings: 12}
+
+	var buf bytes.Buffer
+	Printer{Writer: &buf}.PrintDryRun(plan)
+	if want := "Scanned 4,112 files in 38s · 12 warnings.\n"; !strings.Contains(buf.String(), want) {
+		t.Fatalf("expected %q in output:\n%s", want, buf.String())
+	}
+
+	if got := ScanLine(domain.ScanStats{Files: 3, Duration: time.Second}); got != "Scanned 3 files in 1s" {
+		t.Fatalf("expected no warnings without any, got %q", got)
+	}
+	if got := ScanLine(domain.ScanStats{}); got != "" {
+		t.Fatalf("expected nothing for a plan without scan stats, got %q", got)
+	}
+}
+
+func TestPrintDryRunVerboseSplitsDateSkips(t *testing.T) {
+	plan := verdictPlan()
+	plan.SkippedByMtimeShortcut = 1
+
+	var buf bytes.Buffer
+	Printer{Writer: &buf}.PrintDryRun(plan)
+	if !strings.Contains(buf.String(), "Skipped 1 RAWs (date filter).\n") {
+		t.Fatalf("expected the combined count without --verbose, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	Printer{Writer: &buf, Verbose: true}.PrintDryRun(plan)
+	if want := "Skipped 1 RAWs (date filter: 1 by modification time, 0 by capture date).\n"; !strings.Contains(buf.String(), want) {
+		t.Fatalf("expected %q in output:\n%s", want, buf.String())
+	}
+}
+
+func TestPrintExecutionSeparatesStaleAndConfirmedOverrides(t *testing.T) {
+	stale := domain.CopyItem{FileMeta: domain.FileMeta{Name: "DSC0001.ARW", IsRAW: true}, Exists: true, AutoOverride: true}
+	asked := domain.CopyItem{FileMeta: domain.FileMeta{Name: "DSC0002.ARW", IsRAW: true}, Exists: true}
+	plan := domain.CopyPlan{
+		Items:             []domain.CopyItem{stale, asked},
+		OverrideItems:     []domain.CopyItem{asked},
+		AutoOverrideItems: []domain.CopyItem{stale},
+		RawCount:          2,
+		RawOverrides:      1,
+	}
+
+	var buf bytes.Buffer
+	Printer{Writer: &buf}.PrintExecution(plan, 1)
+	for _, want := range []string{
+		"Overwrote 1 stale targets without asking (older than the source, another size).\n",
+		"Override confirmation granted for 1 RAW files.\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	Printer{Writer: &buf}.PrintDryRun(plan)
+	if want := "Would overwrite 1 stale targets without asking (older than the source, another size).\n"; !strings.Contains(buf.String(), want) {
+		t.Fatalf("expected %q in output:\n%s", want, buf.String())
+	}
+}
+
+func TestPrintAuditGroupsFindings(t *testing.T) {
+	report := domain.NewAuditReport("/archive", 9, map[string][]domain.AuditFinding{
+		domain.AuditMisfiled:  {{Path: "2024/2024-03-15/DSC0002.ARW", Folder: "2024/2024-03-16", Detail: "dated 2024-03-16 by exif"}},
+		domain.AuditSplitPair: {{Path: "2024/2024-03-15/DSC0003.JPG", Folder: "2024/2024-03-16", Of: "2024/2024-03-16/DSC0003.ARW"}},
+		domain.AuditDuplicate: {{Path: "2024/2024-03-16/DSC0005.JPG", Of: "2024/2024-03-16/DSC0004.JPG"}},
+		domain.AuditJunk:      {{Path: "2024/._DSC0001.ARW"}, {Path: "2024/2024-03-15/.DS_Store"}},
+	})
+
+	var buf bytes.Buffer
+	Printer{Writer: &buf}.PrintAudit(report)
+	checkGolden(t, "audit.golden", buf.String())
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	checkGolden(t, "audit.json.golden", string(data)+"\n")
+
+	buf.Reset()
+	Printer{Writer: &buf}.PrintAudit(domain.NewAuditReport("/archive", 2, nil))
+	if got := buf.String(); got != "Audited 2 files in /archive: nothing to report.\n" {
+		t.Fatalf("unexpected clean report %q", got)
+	}
+}
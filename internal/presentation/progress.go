@@ -0,0 +1,114 @@
+package presentation
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ProgressMode is how plain mode reports progress (--progress).
+type ProgressMode string
+
+const (
+	// ProgressFull prints the plan and the summary and nothing while
+	// scanning and copying.
+	ProgressFull ProgressMode = "full"
+	// ProgressLine keeps a status line of the current phase.
+	ProgressLine ProgressMode = "line"
+	// ProgressNone prints a line per phase and the summary, without the
+	// list of copied files.
+	ProgressNone ProgressMode = "none"
+)
+
+// DefaultSnapshotInterval is how often Progress prints a snapshot of the
+// status line when it cannot rewrite it.
+const DefaultSnapshotInterval = 30 * time.Second
+
+// Progress reports the phases of a plain run and, in ProgressLine mode,
+// how far the current one is. On a terminal the status line is rewritten
+// in place; elsewhere, like in CI logs, it is printed when a phase starts
+// and ends and every Interval in between. It is safe for concurrent use.
+type Progress struct {
+	Writer   io.Writer
+	Mode     ProgressMode
+	Terminal bool
+	// Interval defaults to DefaultSnapshotInterval.
+	Interval time.Duration
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	mu    sync.Mutex
+	phase string
+	// written is the width of the status line on the terminal, 0 when
+	// there is none
+	written  int
+	snapshot time.Time
+}
+
+// Phase starts the phase described by text, like "Scanning /Volumes/CARD".
+func (p *Progress) Phase(text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.endLine()
+	p.phase = text
+	p.snapshot = time.Time{}
+	if p.Mode == ProgressNone {
+		fmt.Fprintln(p.Writer, text+"...")
+	}
+}
+
+// Update reports that current of total steps of the phase are done.
+func (p *Progress) Update(current, total int) {
+	if p.Mode != ProgressLine {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	line := fmt.Sprintf("%s: %d/%d", p.phase, current, total)
+	if total > 0 {
+		line += fmt.Sprintf(" (%d%%)", current*100/total)
+	}
+	if p.Terminal {
+		width := utf8.RuneCountInString(line)
+		fmt.Fprint(p.Writer, "\r"+line+strings.Repeat(" ", max(p.written-width, 0)))
+		p.written = width
+		return
+	}
+	now := p.now()
+	if current < total && !p.snapshot.IsZero() && now.Sub(p.snapshot) < p.interval() {
+		return
+	}
+	p.snapshot = now
+	fmt.Fprintln(p.Writer, line)
+}
+
+// Done ends the status line, so the summary starts on a line of its own.
+func (p *Progress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.endLine()
+}
+
+func (p *Progress) endLine() {
+	if p.written > 0 {
+		fmt.Fprintln(p.Writer)
+		p.written = 0
+	}
+}
+
+func (p *Progress) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+func (p *Progress) interval() time.Duration {
+	if p.Interval > 0 {
+		return p.Interval
+	}
+	return DefaultSnapshotInterval
+}
@@ -0,0 +1,88 @@
+package presentation
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressLineRewritesTheLineOnATerminal(t *testing.T) {
+	var buf bytes.Buffer
+	progress := Progress{Writer: &buf, Mode: ProgressLine, Terminal: true}
+	progress.Phase("Scanning /card")
+	for i := 1; i <= 3; i++ {
+		progress.Update(i*5, 15)
+	}
+	progress.Phase("Copying 2 files to /archive")
+	progress.Update(1, 2)
+	progress.Update(2, 2)
+	progress.Done()
+
+	want := "\rScanning /card: 5/15 (33%)" +
+		"\rScanning /card: 10/15 (66%)" +
+		"\rScanning /card: 15/15 (100%)\n" +
+		"\rCopying 2 files to /archive: 1/2 (50%)" +
+		"\rCopying 2 files to /archive: 2/2 (100%)\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected output:\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestProgressLineClearsLongerLines(t *testing.T) {
+	var buf bytes.Buffer
+	progress := Progress{Writer: &buf, Mode: ProgressLine, Terminal: true}
+	progress.Phase("Scanning")
+	progress.Update(10, 10)
+	progress.Update(0, 0)
+	if got, want := buf.String(), "\rScanning: 10/10 (100%)\rScanning: 0/0"+strings.Repeat(" ", 9); got != want {
+		t.Fatalf("unexpected output: %q, want %q", got, want)
+	}
+}
+
+func TestProgressLineSnapshotsWithoutATerminal(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC)
+	progress := Progress{Writer: &buf, Mode: ProgressLine, Now: func() time.Time { return now }}
+	progress.Phase("Copying 100 files to /archive")
+	for i := 1; i <= 100; i++ {
+		progress.Update(i, 100)
+		now = now.Add(time.Second)
+	}
+	progress.Done()
+
+	want := "Copying 100 files to /archive: 1/100 (1%)\n" +
+		"Copying 100 files to /archive: 31/100 (31%)\n" +
+		"Copying 100 files to /archive: 61/100 (61%)\n" +
+		"Copying 100 files to /archive: 91/100 (91%)\n" +
+		"Copying 100 files to /archive: 100/100 (100%)\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected output:\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestProgressNonePrintsOnlyPhases(t *testing.T) {
+	for _, terminal := range []bool{true, false} {
+		var buf bytes.Buffer
+		progress := Progress{Writer: &buf, Mode: ProgressNone, Terminal: terminal}
+		progress.Phase("Scanning /card")
+		progress.Update(1, 2)
+		progress.Phase("Copying 2 files to /archive")
+		progress.Update(2, 2)
+		progress.Done()
+		if got, want := buf.String(), "Scanning /card...\nCopying 2 files to /archive...\n"; got != want {
+			t.Fatalf("unexpected output with terminal %t: %q, want %q", terminal, got, want)
+		}
+	}
+}
+
+func TestProgressFullPrintsNothing(t *testing.T) {
+	var buf bytes.Buffer
+	progress := Progress{Writer: &buf, Mode: ProgressFull, Terminal: true}
+	progress.Phase("Scanning /card")
+	progress.Update(1, 2)
+	progress.Done()
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output, got %q", buf.String())
+	}
+}
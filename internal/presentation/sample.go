@@ -0,0 +1,48 @@
+package presentation
+
+import "phopy/internal/format"
+
+// DefaultPreviewCount is how many planned files the TUI and the printer
+// list before summarizing the rest.
+const DefaultPreviewCount = 8
+
+// EffectivePreviewCount resolves a configured preview count, where 0 means
+// DefaultPreviewCount.
+func EffectivePreviewCount(configured int) int {
+	if configured <= 0 {
+		return DefaultPreviewCount
+	}
+	return configured
+}
+
+// SampleIndexes picks count of total list positions to preview: the first,
+// the last and evenly spaced ones between them, in order. Plans are sorted
+// by capture time, so the sample spans the whole date range and a wrong
+// range shows in the middle too. Every position is picked when total is
+// not more than count.
+func SampleIndexes(total, count int) []int {
+	if total <= 0 || count <= 0 {
+		return nil
+	}
+	if total <= count {
+		picks := make([]int, total)
+		for i := range picks {
+			picks[i] = i
+		}
+		return picks
+	}
+	if count == 1 {
+		return []int{0}
+	}
+	// Steps of at least one position keep the picks distinct
+	picks := make([]int, count)
+	for i := range picks {
+		picks[i] = i * (total - 1) / (count - 1)
+	}
+	return picks
+}
+
+// InBetweenLine summarizes the n list items between the sampled ones.
+func InBetweenLine(n int) string {
+	return "... " + format.Count(n) + " more files in between"
+}
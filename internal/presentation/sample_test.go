@@ -0,0 +1,37 @@
+package presentation
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSampleIndexes(t *testing.T) {
+	tests := []struct {
+		total, count int
+		want         []int
+	}{
+		{total: 0, count: 8, want: nil},
+		{total: 5, count: 0, want: nil},
+		{total: 3, count: 8, want: []int{0, 1, 2}},
+		{total: 8, count: 8, want: []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{total: 9, count: 8, want: []int{0, 1, 2, 3, 4, 5, 6, 8}},
+		{total: 100, count: 8, want: []int{0, 14, 28, 42, 56, 70, 84, 99}},
+		{total: 1000, count: 4, want: []int{0, 333, 666, 999}},
+		{total: 10, count: 2, want: []int{0, 9}},
+		{total: 10, count: 1, want: []int{0}},
+	}
+	for _, tt := range tests {
+		if got := SampleIndexes(tt.total, tt.count); !slices.Equal(got, tt.want) {
+			t.Errorf("SampleIndexes(%d, %d) = %v, want %v", tt.total, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestEffectivePreviewCount(t *testing.T) {
+	if got := EffectivePreviewCount(0); got != DefaultPreviewCount {
+		t.Fatalf("expected the default for 0, got %d", got)
+	}
+	if got := EffectivePreviewCount(3); got != 3 {
+		t.Fatalf("expected the configured count, got %d", got)
+	}
+}
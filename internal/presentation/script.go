@@ -0,0 +1,114 @@
+package presentation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+// ScriptFormat is the shell a plan is exported for.
+type ScriptFormat int
+
+const (
+	ScriptPOSIX ScriptFormat = iota
+	ScriptPowerShell
+)
+
+// script is the syntax of one ScriptFormat.
+type script struct {
+	header []string
+	quote  func(string) string
+	mkdir  string
+	copy   string
+	// overwrite copies over an existing target
+	overwrite string
+}
+
+var scripts = map[ScriptFormat]script{
+	ScriptPOSIX: {
+		header:    []string{"#!/bin/sh", "set -eu"},
+		quote:     QuotePOSIX,
+		mkdir:     "mkdir -p -- %s",
+		copy:      "cp -p -- %s %s",
+		overwrite: "cp -p -f -- %s %s",
+	},
+	ScriptPowerShell: {
+		header:    []string{"$ErrorActionPreference = 'Stop'"},
+		quote:     QuotePowerShell,
+		mkdir:     "New-Item -ItemType Directory -Force -Path %s | Out-Null",
+		copy:      "Copy-Item -LiteralPath %s -Destination %s",
+		overwrite: "Copy-Item -LiteralPath %s -Destination %s -Force",
+	},
+}
+
+// WriteScript writes the copies of plan as a script of format: a folder
+// command for every target folder and a copy command for every planned
+// item. Items whose target exists are only listed, commented out, at the
+// end, so running the script never overwrites a file unless they are
+// uncommented; that includes the stale targets a run would overwrite
+// without asking.
+func WriteScript(w io.Writer, plan domain.CopyPlan, format ScriptFormat, createdAt time.Time) error {
+	s := scripts[format]
+	existing := len(plan.OverrideItems) + len(plan.AutoOverrideItems)
+	bw := bufio.NewWriter(w)
+	for _, line := range s.header {
+		fmt.Fprintln(bw, line)
+	}
+	fmt.Fprintf(bw, "# Written by phopy on %s: copies %d files; %d more would overwrite existing ones.\n", createdAt.Format("2006-01-02 15:04"), len(plan.Items)-existing, existing)
+
+	made := make(map[string]bool)
+	for _, item := range plan.Items {
+		if item.Exists {
+			continue
+		}
+		if dir := filepath.Dir(item.TargetPath); !made[dir] {
+			made[dir] = true
+			fmt.Fprintln(bw)
+			fmt.Fprintf(bw, s.mkdir+"\n", s.quote(dir))
+		}
+		fmt.Fprintf(bw, s.copy+"\n", s.quote(item.FileMeta.SourcePath), s.quote(item.TargetPath))
+	}
+
+	if existing > 0 {
+		fmt.Fprintln(bw)
+		fmt.Fprintln(bw, "# These targets already exist. Uncomment the lines to overwrite them.")
+		for _, item := range plan.Items {
+			if item.Exists {
+				// A quoted line break must not end the comment
+				line := fmt.Sprintf(s.overwrite, s.quote(item.FileMeta.SourcePath), s.quote(item.TargetPath))
+				fmt.Fprintln(bw, "# "+strings.ReplaceAll(line, "\n", "\n# "))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// QuotePOSIX quotes s as one word of a POSIX shell: in single quotes, which
+// keep everything literal but themselves.
+func QuotePOSIX(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// powerShellQuotes are the characters PowerShell takes for a single quote,
+// typographic ones included.
+const powerShellQuotes = "'‘’‚‛"
+
+// QuotePowerShell quotes s as a verbatim PowerShell string, doubling every
+// single quote in it.
+func QuotePowerShell(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		if strings.ContainsRune(powerShellQuotes, r) {
+			b.WriteRune(r)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
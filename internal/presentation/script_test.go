@@ -0,0 +1,96 @@
+package presentation
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+func TestQuotePOSIX(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"/card/DSC0001.ARW", `'/card/DSC0001.ARW'`},
+		{"/card/Summer trip/DSC 0001.ARW", `'/card/Summer trip/DSC 0001.ARW'`},
+		{"/card/Anna's/DSC0001.ARW", `'/card/Anna'\''s/DSC0001.ARW'`},
+		{`/card/"best"/$HOME/*.ARW`, `'/card/"best"/$HOME/*.ARW'`},
+		{"/card/Übersee/ßü 📷.JPG", `'/card/Übersee/ßü 📷.JPG'`},
+		{"-rf", `'-rf'`},
+	}
+	for _, tt := range tests {
+		if got := QuotePOSIX(tt.in); got != tt.want {
+			t.Errorf("QuotePOSIX(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQuotePowerShell(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`C:\card\DSC0001.ARW`, `'C:\card\DSC0001.ARW'`},
+		{`C:\card\Summer trip\DSC 0001.ARW`, `'C:\card\Summer trip\DSC 0001.ARW'`},
+		{`C:\card\Anna's\DSC0001.ARW`, `'C:\card\Anna''s\DSC0001.ARW'`},
+		{`C:\card\Anna’s\DSC0001.ARW`, `'C:\card\Anna’’s\DSC0001.ARW'`},
+		{`C:\card\"best"\$env:HOME\[1].ARW`, `'C:\card\"best"\$env:HOME\[1].ARW'`},
+		{`C:\card\Übersee\ßü 📷.JPG`, `'C:\card\Übersee\ßü 📷.JPG'`},
+	}
+	for _, tt := range tests {
+		if got := QuotePowerShell(tt.in); got != tt.want {
+			t.Errorf("QuotePowerShell(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func scriptPlan() domain.CopyPlan {
+	taken := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	item := func(source, target string, exists bool) domain.CopyItem {
+		meta := domain.NewFileMeta(source, filepath.Base(source), taken)
+		return domain.CopyItem{FileMeta: meta, TargetPath: target, Exists: exists}
+	}
+	existing := item("/card/DCIM/100MSDCF/DSC0003.ARW", "/archive/2024-10-02/DSC0003.ARW", true)
+	return domain.CopyPlan{
+		Items: []domain.CopyItem{
+			item("/card/DCIM/100MSDCF/DSC0001.ARW", "/archive/2024-10-02/DSC0001.ARW", false),
+			item("/card/DCIM/100MSDCF/Anna's DSC0002.JPG", "/archive/2024-10-02/Anna's DSC0002.JPG", false),
+			existing,
+			item("/card/DCIM/101MSDCF/Übersee 0004.ARW", "/archive/2024-10-03/Übersee 0004.ARW", false),
+		},
+		OverrideItems: []domain.CopyItem{existing},
+	}
+}
+
+func TestWriteScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("golden paths use forward slashes")
+	}
+	createdAt := time.Date(2024, 10, 2, 18, 30, 0, 0, time.Local)
+	for name, format := range map[string]ScriptFormat{"script.sh.golden": ScriptPOSIX, "script.ps1.golden": ScriptPowerShell} {
+		var buf bytes.Buffer
+		if err := WriteScript(&buf, scriptPlan(), format, createdAt); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkGolden(t, name, buf.String())
+	}
+}
+
+func TestWriteScriptKeepsLineBreaksInComments(t *testing.T) {
+	taken := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	existing := domain.CopyItem{FileMeta: domain.NewFileMeta("/card/a\nrm -rf x.ARW", "a\nrm -rf x.ARW", taken), TargetPath: "/archive/a\nrm -rf x.ARW", Exists: true}
+	plan := domain.CopyPlan{Items: []domain.CopyItem{existing}, OverrideItems: []domain.CopyItem{existing}}
+	var buf bytes.Buffer
+	if err := WriteScript(&buf, plan, ScriptPOSIX, taken); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))[2:] {
+		if len(line) > 0 && line[0] != '#' {
+			t.Fatalf("expected every override line commented out, got %q in:\n%s", line, buf.String())
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package presentation
+
+import (
+	"fmt"
+	"time"
+
+	"phopy/internal/domain"
+	"phopy/internal/format"
+)
+
+// RunStats is what --stats-only reports instead of copying: the scan, what
+// the plan copies and how long copying it would take. Throughput and
+// Estimate are 0 without a write test of the target.
+type RunStats struct {
+	Scan  domain.ScanStats `json:"scan"`
+	Files int              `json:"files"`
+	Bytes int64            `json:"bytes"`
+	// Throughput is how fast the target wrote, in bytes per second.
+	Throughput float64       `json:"throughput_bytes_per_second"`
+	Estimate   time.Duration `json:"estimate_ns"`
+}
+
+// NewRunStats sums up plan, estimating the copy at throughput bytes per
+// second.
+func NewRunStats(plan domain.CopyPlan, throughput float64) RunStats {
+	return RunStats{
+		Scan:       plan.Scan,
+		Files:      plan.ItemCount(),
+		Bytes:      plan.TotalBytes(),
+		Throughput: throughput,
+		Estimate:   EstimateCopyTime(plan.TotalBytes(), throughput),
+	}
+}
+
+// StatsLine renders stats on one line, e.g. "scan: 2m 10s, plan: 8,214
+// files / 96.0 GiB, estimated copy: ~54 min at 30.0 MiB/s".
+func StatsLine(stats RunStats) string {
+	estimate := "unknown, no write test of the target"
+	if stats.Throughput > 0 {
+		estimate = fmt.Sprintf("%s at %s", format.Estimate(stats.Estimate), format.Rate(stats.Throughput))
+	}
+	return fmt.Sprintf("scan: %s, plan: %s files / %s, estimated copy: %s",
+		format.Duration(stats.Scan.Duration), format.Count(stats.Files), format.Bytes(stats.Bytes), estimate)
+}
@@ -0,0 +1,29 @@
+package presentation
+
+import (
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+func TestStatsLine(t *testing.T) {
+	plan := domain.CopyPlan{
+		Items:          []domain.CopyItem{{FileMeta: domain.FileMeta{Size: 64 << 30}}, {FileMeta: domain.FileMeta{Size: 32 << 30}}},
+		TruncatedItems: 8212,
+		Scan:           domain.ScanStats{Files: 9000, Duration: 2*time.Minute + 10*time.Second},
+	}
+
+	// 96 GiB at 32 MiB/s take 3,072s
+	stats := NewRunStats(plan, 32<<20)
+	if stats.Files != 8214 || stats.Bytes != 96<<30 || stats.Estimate != 3072*time.Second {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if got, want := StatsLine(stats), "scan: 2m 10s, plan: 8,214 files / 96.0 GiB, estimated copy: ~51 min at 32.0 MiB/s"; got != want {
+		t.Errorf("StatsLine() = %q, want %q", got, want)
+	}
+
+	if got, want := StatsLine(NewRunStats(plan, 0)), "scan: 2m 10s, plan: 8,214 files / 96.0 GiB, estimated copy: unknown, no write test of the target"; got != want {
+		t.Errorf("StatsLine() without a write test = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,18 @@
+// Package runid identifies a single phopy invocation, so its journal
+// entry, manifest, verbose log and completion summary can be matched up
+// afterwards.
+package runid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// New returns a sortable, unique id for a run started at t, e.g.
+// "20241003T091500Z-1a2b3c4d".
+func New(t time.Time) string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return t.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
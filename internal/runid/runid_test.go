@@ -0,0 +1,22 @@
+package runid
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestNewSortsByStartTimeAndIsUnique(t *testing.T) {
+	start := time.Date(2024, 10, 3, 11, 15, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	first, second := New(start), New(start)
+	if !regexp.MustCompile(`^20241003T091500Z-[0-9a-f]{8}$`).MatchString(first) {
+		t.Fatalf("expected a UTC timestamp and a random suffix, got %q", first)
+	}
+	if first == second {
+		t.Fatalf("expected two runs started at once to get different ids, got %q twice", first)
+	}
+	if later := New(start.Add(time.Second)); later <= first || later <= second {
+		t.Fatalf("expected %q to sort after %q and %q", later, first, second)
+	}
+}
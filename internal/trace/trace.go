@@ -0,0 +1,152 @@
+// Package trace records the phases of a run as spans in the Chrome trace
+// event format, which chrome://tracing, Perfetto and most tracing UIs load
+// without a collector.
+package trace
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// pid is the process id of every event; a trace holds one run.
+const pid = 1
+
+// Recorder collects spans. It is safe for concurrent use; a nil Recorder
+// records nothing, so callers need not check whether tracing is on.
+type Recorder struct {
+	mu     sync.Mutex
+	start  time.Time
+	now    func() time.Time
+	events []event
+	tracks map[int]string
+}
+
+// New returns a Recorder whose timestamps count from now.
+func New() *Recorder {
+	return &Recorder{start: time.Now(), now: time.Now, tracks: make(map[int]string)}
+}
+
+// event is a complete ("X") or metadata ("M") trace event. Timestamps and
+// durations are in microseconds.
+type event struct {
+	Name     string         `json:"name"`
+	Category string         `json:"cat,omitempty"`
+	Phase    string         `json:"ph"`
+	TS       int64          `json:"ts"`
+	Duration int64          `json:"dur"`
+	PID      int            `json:"pid"`
+	TID      int            `json:"tid"`
+	Args     map[string]any `json:"args,omitempty"`
+}
+
+// Span is a phase being timed. A span nests below every span on the same
+// track that started before and ends after it; spans of workers go on
+// tracks of their own.
+type Span struct {
+	r     *Recorder
+	name  string
+	track int
+	start time.Time
+	args  map[string]any
+}
+
+// Start starts the span name on track, 0 being the main one.
+func (r *Recorder) Start(name string, track int) *Span {
+	if r == nil {
+		return nil
+	}
+	return &Span{r: r, name: name, track: track, start: r.now()}
+}
+
+// NameTrack names track in the trace, like "exif worker 1".
+func (r *Recorder) NameTrack(track int, name string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tracks[track] = name
+}
+
+// Set attaches an attribute like a file count or byte total to s.
+func (s *Span) Set(key string, value any) {
+	if s == nil {
+		return
+	}
+	if s.args == nil {
+		s.args = make(map[string]any)
+	}
+	s.args[key] = value
+}
+
+// End records s. Ending a span twice records it once.
+func (s *Span) End() {
+	if s == nil || s.r == nil {
+		return
+	}
+	r := s.r
+	s.r = nil
+	end := r.now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event{
+		Name:     s.name,
+		Category: "phopy",
+		Phase:    "X",
+		TS:       s.start.Sub(r.start).Microseconds(),
+		Duration: end.Sub(s.start).Microseconds(),
+		PID:      pid,
+		TID:      s.track,
+		Args:     s.args,
+	})
+}
+
+// Write writes the recorded spans as a JSON trace object, ordered by
+// track and start, parents before their children.
+func (r *Recorder) Write(w io.Writer) error {
+	r.mu.Lock()
+	events := append([]event(nil), r.events...)
+	tracks := make([]int, 0, len(r.tracks))
+	for track := range r.tracks {
+		tracks = append(tracks, track)
+	}
+	sort.Ints(tracks)
+	for _, track := range tracks {
+		events = append(events, event{Name: "thread_name", Phase: "M", PID: pid, TID: track, Args: map[string]any{"name": r.tracks[track]}})
+	}
+	r.mu.Unlock()
+
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if a.TID != b.TID {
+			return a.TID < b.TID
+		}
+		if a.TS != b.TS {
+			return a.TS < b.TS
+		}
+		return a.Duration > b.Duration
+	})
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		TraceEvents     []event `json:"traceEvents"`
+		DisplayTimeUnit string  `json:"displayTimeUnit"`
+	}{events, "ms"})
+}
+
+// WriteFile writes the trace to the file at path, replacing it.
+func (r *Recorder) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,95 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// fakeClock advances by step on every reading.
+func fakeClock(step time.Duration) func() time.Time {
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+type traceFile struct {
+	TraceEvents []struct {
+		Name     string         `json:"name"`
+		Phase    string         `json:"ph"`
+		TS       int64          `json:"ts"`
+		Duration int64          `json:"dur"`
+		TID      int            `json:"tid"`
+		Args     map[string]any `json:"args"`
+	} `json:"traceEvents"`
+	DisplayTimeUnit string `json:"displayTimeUnit"`
+}
+
+func TestRecorderWritesNestedSpans(t *testing.T) {
+	clock := fakeClock(time.Millisecond)
+	r := &Recorder{start: clock(), now: clock, tracks: make(map[int]string)}
+	parent := r.Start("scan", 0)
+	child := r.Start("walk", 0)
+	child.Set("files", 12)
+	child.End()
+	child.End()
+	r.NameTrack(1, "exif worker 1")
+	worker := r.Start("exif-worker", 1)
+	worker.End()
+	parent.Set("bytes", int64(4096))
+	parent.End()
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var file traceFile
+	if err := json.Unmarshal(buf.Bytes(), &file); err != nil {
+		t.Fatalf("expected valid JSON, got %v:\n%s", err, buf.String())
+	}
+
+	var names []string
+	for _, e := range file.TraceEvents {
+		names = append(names, e.Phase+":"+e.Name)
+	}
+	want := []string{"X:scan", "X:walk", "M:thread_name", "X:exif-worker"}
+	if len(names) != len(want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, names)
+		}
+	}
+
+	scan, walk, worker1 := file.TraceEvents[0], file.TraceEvents[1], file.TraceEvents[3]
+	for _, e := range []struct {
+		name string
+		ts   int64
+		dur  int64
+	}{{"walk", walk.TS, walk.Duration}, {"exif-worker", worker1.TS, worker1.Duration}} {
+		if e.ts < scan.TS || e.ts+e.dur > scan.TS+scan.Duration {
+			t.Errorf("expected %s [%d, +%d] inside scan [%d, +%d]", e.name, e.ts, e.dur, scan.TS, scan.Duration)
+		}
+	}
+	if walk.Args["files"] != float64(12) || scan.Args["bytes"] != float64(4096) {
+		t.Errorf("unexpected attributes: walk %v, scan %v", walk.Args, scan.Args)
+	}
+	if file.TraceEvents[2].TID != 1 || file.TraceEvents[2].Args["name"] != "exif worker 1" {
+		t.Errorf("expected the worker track to be named, got %+v", file.TraceEvents[2])
+	}
+	if file.DisplayTimeUnit != "ms" {
+		t.Errorf("unexpected display unit %q", file.DisplayTimeUnit)
+	}
+}
+
+func TestNilRecorderRecordsNothing(t *testing.T) {
+	var r *Recorder
+	span := r.Start("copy", 0)
+	span.Set("files", 1)
+	span.End()
+	r.NameTrack(0, "phopy")
+}
@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func (m Model) updateAuto(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y", "enter":
+		if m.config.SourceWarning != "" {
+			m.Phase = PhaseSourceWarning
+			return m, nil
+		}
+		m.Phase = PhaseScanning
+		cmds := []tea.Cmd{m.copyView.Init()}
+		if m.config.Continue != nil {
+			cmds = append(cmds, m.config.Continue())
+		}
+		return m, tea.Batch(cmds...)
+	case "n", "N", "q", "esc", "ctrl+c":
+		m.Quitting = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m Model) renderAuto() string {
+	var b strings.Builder
+	b.WriteString(sectionStyle.Render("Copy everything new?"))
+	b.WriteString("\n\n")
+	dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
+	for _, line := range m.config.AutoSummary {
+		b.WriteString(dimStyle.Render("  " + line))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("  Flags override each of these, e.g. --source or --from."))
+	b.WriteString("\n")
+	return b.String()
+}
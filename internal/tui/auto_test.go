@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAutoSummaryWaitsForConfirmation(t *testing.T) {
+	started := false
+	m := NewModel(Config{
+		SourceDir:   "/Volumes/EOS_DIGITAL",
+		TargetDir:   "/photos",
+		AutoSummary: []string{"Source: /Volumes/EOS_DIGITAL (newest memory card)", "Target: /photos (profile)"},
+		Continue: func() tea.Cmd {
+			started = true
+			return nil
+		},
+	})
+	if m.Phase != PhaseAuto {
+		t.Fatalf("expected auto phase, got %v", m.Phase)
+	}
+	if view := m.View(); !strings.Contains(view, "Copy everything new?") || !strings.Contains(view, "(newest memory card)") {
+		t.Fatalf("expected the summary in view, got:\n%s", view)
+	}
+
+	m, _ = update(t, m, keyMsg("enter"))
+	if m.Phase != PhaseScanning || !started {
+		t.Fatalf("expected scanning to start after Enter, got %v", m.Phase)
+	}
+}
+
+func TestAutoSummaryPrecedesSourceWarning(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/archive", TargetDir: "/target", AskLabel: true, AutoSummary: []string{"Target: /target (profile)"}, SourceWarning: "2 of 2 folders are named like dates"})
+	m, _ = update(t, m, keyMsg("enter"))
+	if m.Phase != PhaseAuto {
+		t.Fatalf("expected the summary after the label, got %v", m.Phase)
+	}
+	m, _ = update(t, m, keyMsg("y"))
+	if m.Phase != PhaseSourceWarning {
+		t.Fatalf("expected the source warning after the summary, got %v", m.Phase)
+	}
+	m, _ = update(t, m, keyMsg("q"))
+	if !m.Quitting {
+		t.Fatalf("expected q to quit")
+	}
+}
@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"phopy/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ConflictMsg pauses the copy: the target of Item appeared after planning.
+// The executor waits until Reply receives the answer.
+type ConflictMsg struct {
+	Item  domain.CopyItem
+	Reply chan<- domain.ConflictAnswer
+}
+
+func (m Model) updateConflict(msg tea.KeyMsg) (Model, tea.Cmd) {
+	answer := domain.ConflictFail
+	switch msg.String() {
+	case "o":
+		answer = domain.ConflictOverwrite
+	case "s":
+		answer = domain.ConflictSkip
+	case "O":
+		answer = domain.ConflictOverwriteAll
+	case "S":
+		answer = domain.ConflictSkipAll
+	case "q", "ctrl+c":
+		// Fail the file so the executor does not wait for an answer that
+		// never comes
+		m.answerConflict(domain.ConflictFail)
+		m.Quitting = true
+		return m, tea.Quit
+	default:
+		return m, nil
+	}
+	m.answerConflict(answer)
+	m.Phase = PhaseExecuting
+	return m, m.copyView.Init()
+}
+
+func (m *Model) answerConflict(answer domain.ConflictAnswer) {
+	if m.conflict.Reply != nil {
+		m.conflict.Reply <- answer
+	}
+	m.conflict = ConflictMsg{}
+}
+
+func (m Model) renderConflict() string {
+	var b strings.Builder
+	name := filepath.Base(m.conflict.Item.TargetPath)
+	b.WriteString(warningStyle.Render(fmt.Sprintf("%s %s now exists in target — overwrite / skip / skip all / overwrite all?", iconOverride, name)))
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(dimTextColor).Render("  " + shortenPath(m.conflict.Item.TargetPath) + " appeared after planning."))
+	b.WriteString("\n")
+	return b.String()
+}
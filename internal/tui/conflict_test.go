@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"phopy/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestConflictPausesExecutionUntilAnswered(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target"})
+	m, _ = update(t, m, PlanReadyMsg{Plan: domain.CopyPlan{}})
+	if m.Phase != PhaseExecuting {
+		t.Fatalf("expected executing phase, got %v", m.Phase)
+	}
+
+	reply := make(chan domain.ConflictAnswer, 1)
+	item := domain.CopyItem{TargetPath: "/target/2024-10-02/DSC0123.ARW"}
+	m, _ = update(t, m, ConflictMsg{Item: item, Reply: reply})
+	if m.Phase != PhaseConflict {
+		t.Fatalf("expected conflict phase, got %v", m.Phase)
+	}
+	if view := m.View(); !strings.Contains(view, "DSC0123.ARW now exists in target — overwrite / skip / skip all / overwrite all?") {
+		t.Fatalf("expected the conflict question in view, got:\n%s", view)
+	}
+
+	m, _ = update(t, m, keyMsg("x"))
+	if m.Phase != PhaseConflict || len(reply) != 0 {
+		t.Fatalf("expected other keys to leave the question open")
+	}
+	m, cmd := update(t, m, keyMsg("S"))
+	if m.Phase != PhaseExecuting || cmd == nil {
+		t.Fatalf("expected the copy to resume, got %v", m.Phase)
+	}
+	if answer := <-reply; answer != domain.ConflictSkipAll {
+		t.Fatalf("expected skip all, got %v", answer)
+	}
+}
+
+func TestConflictQuitFailsTheFile(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target"})
+	reply := make(chan domain.ConflictAnswer, 1)
+	m, _ = update(t, m, ConflictMsg{Item: domain.CopyItem{TargetPath: "/target/a.arw"}, Reply: reply})
+	m, cmd := update(t, m, keyMsg("q"))
+	if !m.Quitting || cmd == nil {
+		t.Fatalf("expected q to quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatalf("expected a quit command")
+	}
+	if answer := <-reply; answer != domain.ConflictFail {
+		t.Fatalf("expected the file to fail, got %v", answer)
+	}
+}
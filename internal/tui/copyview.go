@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"phopy/internal/domain"
+	"phopy/internal/presentation"
+	"phopy/tui/copyview"
+)
+
+// viewPlan is what the copy view shows of plan.
+func viewPlan(plan domain.CopyPlan) copyview.Plan {
+	return copyview.Plan{
+		Files:           plan.ItemCount(),
+		RAW:             plan.RawCount,
+		JPEG:            plan.JpegCount,
+		SkippedJPEGs:    plan.SkippedJPEGs,
+		Overrides:       plan.OverrideCount(),
+		StaleOverwrites: len(plan.AutoOverrideItems),
+	}
+}
+
+// viewResult is what the copy view shows of result.
+func viewResult(result domain.ExecutionResult) copyview.Result {
+	view := copyview.Result{
+		Copied:         result.Copied,
+		RAW:            result.RawCopied,
+		JPEG:           result.JpegCopied,
+		Linked:         result.Linked,
+		SourceChanged:  result.SourceChanged,
+		SkippedChanged: result.SkippedChanged,
+		Cancelled:      result.Cancelled,
+		RunID:          result.RunID,
+	}
+	for _, failed := range result.FailedItems() {
+		view.Failed = append(view.Failed, copyview.Failure{File: failed.Item.FileMeta.Name, Err: failed.Err})
+	}
+	for _, locked := range result.LockedItems() {
+		view.Locked = append(view.Locked, locked.Item.FileMeta.SourcePath)
+	}
+	if result.Integrity.Mismatches() > 0 {
+		view.Integrity = presentation.IntegrityLine(result.Integrity)
+	}
+	return view
+}
@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"phopy/internal/domain"
+	"phopy/internal/format"
+)
+
+// DiskFullMsg ends a copy that stopped because the target filled up. The
+// file being copied was removed again, so running the same command once
+// there is room copies exactly the Remaining files. Missing is the space
+// they lack, -1 when the target cannot tell its free space. Err is what
+// the run exits with.
+type DiskFullMsg struct {
+	Result         domain.ExecutionResult
+	Remaining      int
+	RemainingBytes int64
+	Missing        int64
+	Err            error
+}
+
+// updateDiskFull leaves the disk full report on any of the exit keys.
+func (m Model) updateDiskFull(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m Model) renderDiskFull() string {
+	var b strings.Builder
+	b.WriteString(sectionStyle.Render("Target Full"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("  %s %s\n\n", errorStyle.Render(iconError), errorStyle.Render("The target ran out of space, the copy was stopped")))
+
+	missing := "unknown"
+	if m.diskFull.Missing >= 0 {
+		missing = format.Bytes(m.diskFull.Missing)
+	}
+	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Copied:"), statValueStyle.Render(fmt.Sprintf("%d files, %s", m.Result.Copied, format.Bytes(m.Result.BytesCopied)))))
+	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Remaining:"), warningStyle.Render(fmt.Sprintf("%d files, %s", m.diskFull.Remaining, format.Bytes(m.diskFull.RemainingBytes)))))
+	b.WriteString(fmt.Sprintf("  %s  %s\n\n", statLabelStyle.Render("Space missing:"), warningStyle.Render(missing)))
+
+	dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
+	b.WriteString(dimStyle.Render("  The partly copied file was removed and the copied files are recorded."))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("  Free up space and run the same command again to copy the remaining files."))
+	b.WriteString("\n")
+	return b.String()
+}
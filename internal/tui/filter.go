@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"phopy/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// filterListSize is how many matching files the filtered preview lists.
+const filterListSize = 10
+
+// previewFilter narrows the files listed in the preview to those whose name
+// or capture date contains the query. It only changes the view: confirming
+// and copying always use the whole plan.
+type previewFilter struct {
+	query   string
+	editing bool
+}
+
+// canFilter reports whether the preview is shown and can be filtered.
+func (m Model) canFilter() bool {
+	return m.Phase == PhasePreview || m.Phase == PhaseConfirm || m.Phase == PhaseDone
+}
+
+func (m Model) updateFilter(msg tea.KeyMsg) (Model, tea.Cmd) {
+	f := &m.filter
+	switch msg.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		f.query += string(msg.Runes)
+	case tea.KeyBackspace:
+		if len(f.query) > 0 {
+			runes := []rune(f.query)
+			f.query = string(runes[:len(runes)-1])
+		}
+	case tea.KeyEnter:
+		// Keep the filter but hand the keys back to the preview
+		f.editing = false
+	case tea.KeyEsc:
+		*f = previewFilter{}
+	}
+	return m, nil
+}
+
+// matches reports whether item contains the query in its name, its relative
+// path or its capture date, written as 2024-04-23, 20240423 or 0423.
+func (f previewFilter) matches(item domain.CopyItem) bool {
+	query := strings.ToLower(f.query)
+	meta := item.FileMeta
+	for _, field := range []string{meta.Name, meta.RelativePath, meta.TakenAt.Format("2006-01-02 15:04"), meta.TakenAt.Format("20060102")} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f previewFilter) apply(items []domain.CopyItem) []domain.CopyItem {
+	var matched []domain.CopyItem
+	for _, item := range items {
+		if f.matches(item) {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+// renderFilteredItems lists the files matching the filter with their count
+// and, while typing, the filter input.
+func (m Model) renderFilteredItems() string {
+	var b strings.Builder
+	dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
+	matched := m.filter.apply(m.Plan.Items)
+	if len(matched) == 0 {
+		b.WriteString(dimStyle.Render("  No files match"))
+		b.WriteString("\n")
+	}
+	for _, line := range formatFileList(domain.CopyPlan{Items: matched}, filterListSize) {
+		b.WriteString("  ")
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	if m.filter.editing {
+		b.WriteString(renderInput("Filter", m.filter.query))
+	} else {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Filter:"), fileNameStyle.Render(m.filter.query)))
+	}
+	count := fmt.Sprintf("  %d of %d match", len(matched), len(m.Plan.Items))
+	if m.Plan.TruncatedItems > 0 {
+		// Only the preview can be searched
+		count = fmt.Sprintf("  %d of the first %d files match", len(matched), len(m.Plan.Items))
+	}
+	b.WriteString(dimStyle.Render(count))
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (m Model) filterHelp() string {
+	if m.filter.query != "" {
+		return " • / to edit filter • Esc to clear filter"
+	}
+	return " • / to filter"
+}
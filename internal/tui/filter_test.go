@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cardPlan plans n JPEGs, one per day from April 1st.
+func cardPlan(n int) domain.CopyPlan {
+	start := time.Date(2024, 4, 1, 10, 0, 0, 0, time.Local)
+	var plan domain.CopyPlan
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("DSC%04d.JPG", i+1)
+		plan.Items = append(plan.Items, domain.CopyItem{FileMeta: domain.FileMeta{Name: name, RelativePath: "DCIM/" + name, TakenAt: start.AddDate(0, 0, i), IsJPEG: true}})
+	}
+	plan.JpegCount = n
+	return plan
+}
+
+func typeKeys(t *testing.T, m Model, keys string) Model {
+	t.Helper()
+	for _, r := range keys {
+		m, _ = update(t, m, keyMsg(string(r)))
+	}
+	return m
+}
+
+func TestPreviewFilterNarrowsTheList(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", DryRun: true})
+	m, _ = update(t, m, PlanReadyMsg{Plan: cardPlan(40)})
+
+	// q is typed into the filter instead of quitting
+	m = typeKeys(t, m, "/q")
+	if m.Quitting || m.filter.query != "q" {
+		t.Fatalf("expected q in the filter, got %q", m.filter.query)
+	}
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyBackspace})
+
+	// 0423 is April 23rd, the date of DSC0023
+	m = typeKeys(t, m, "0423")
+	view := m.View()
+	if !strings.Contains(view, "DSC0023.JPG") || !strings.Contains(view, "1 of 40 match") {
+		t.Fatalf("expected the matching frame, got:\n%s", view)
+	}
+	if strings.Contains(view, "DSC0001.JPG") {
+		t.Fatalf("expected other files to be hidden, got:\n%s", view)
+	}
+
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	m = typeKeys(t, m, "/2024-04-2")
+	if view := m.View(); !strings.Contains(view, "10 of 40 match") {
+		t.Fatalf("expected the days from the 20th, got:\n%s", view)
+	}
+
+	// Esc clears the filter and shows the whole plan again
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if view := m.View(); strings.Contains(view, "match") || !strings.Contains(view, "32 more files in between") {
+		t.Fatalf("expected the unfiltered preview, got:\n%s", view)
+	}
+	if len(m.Plan.Items) != 40 {
+		t.Fatalf("expected the plan to stay whole, got %d items", len(m.Plan.Items))
+	}
+}
+
+func TestPreviewFilterKeepsConfirmingTheWholePlan(t *testing.T) {
+	plan := cardPlan(12)
+	plan.OverrideItems = plan.Items[:1]
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", ConfirmDefault: ConfirmDefaultNo})
+	m, _ = update(t, m, PlanReadyMsg{Plan: plan})
+
+	// y and Enter belong to the filter while typing
+	m = typeKeys(t, m, "/y")
+	m, cmd := update(t, m, keyMsg("enter"))
+	if cmd != nil || m.confirmChosen || m.filter.editing {
+		t.Fatalf("expected Enter to only close the filter input")
+	}
+	if view := m.View(); !strings.Contains(view, "0 of 12 match") {
+		t.Fatalf("expected the kept filter, got:\n%s", view)
+	}
+
+	m, _ = update(t, m, keyMsg("y"))
+	msg, ok := pressEnter(t, m)
+	if !ok || !msg.Confirmed {
+		t.Fatalf("expected the confirm prompt to work with a filter, got %+v", msg)
+	}
+	if len(m.Plan.Items) != 12 || len(m.Plan.OverrideItems) != 1 {
+		t.Fatalf("expected the plan to stay whole")
+	}
+}
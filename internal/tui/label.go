@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"strings"
+
+	"phopy/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// LabelFunc is called once the label prompt is answered. It should apply
+// the label and start planning unless the first-run setup or the source
+// warning still follows.
+type LabelFunc func(label string) tea.Cmd
+
+// labelPrompt is the state of the --label ask prompt.
+type labelPrompt struct {
+	input string
+	err   error
+}
+
+func (m Model) updateLabel(msg tea.KeyMsg) (Model, tea.Cmd) {
+	l := &m.label
+	switch msg.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		l.input += string(msg.Runes)
+		l.err = nil
+	case tea.KeyBackspace:
+		if len(l.input) > 0 {
+			runes := []rune(l.input)
+			l.input = string(runes[:len(runes)-1])
+		}
+		l.err = nil
+	case tea.KeyEnter:
+		label := strings.TrimSpace(l.input)
+		if err := domain.ValidateLabel(label); err != nil {
+			l.err = err
+			return m, nil
+		}
+		m.config.Label = label
+		m.Phase = PhaseScanning
+		if m.config.Onboarding {
+			m.Phase = PhaseOnboarding
+		} else if len(m.config.AutoSummary) > 0 {
+			m.Phase = PhaseAuto
+		} else if m.config.SourceWarning != "" {
+			m.Phase = PhaseSourceWarning
+		}
+		cmds := []tea.Cmd{m.copyView.Init()}
+		if m.config.SetLabel != nil {
+			cmds = append(cmds, m.config.SetLabel(label))
+		}
+		return m, tea.Batch(cmds...)
+	}
+	return m, nil
+}
+
+func (m Model) renderLabel() string {
+	var b strings.Builder
+	b.WriteString(sectionStyle.Render("Label this import"))
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(dimTextColor).Render("  Used by the {label} token and recorded in the manifest. Leave empty for none."))
+	b.WriteString("\n\n")
+	b.WriteString(renderInput("Label", m.label.input))
+	if m.label.err != nil {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render("  " + m.label.err.Error()))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLabelPromptStartsScanWithLabel(t *testing.T) {
+	var got *string
+	m := NewModel(Config{
+		SourceDir: "/source",
+		TargetDir: "/target",
+		AskLabel:  true,
+		SetLabel: func(label string) tea.Cmd {
+			got = &label
+			return nil
+		},
+	})
+	if m.Phase != PhaseLabel {
+		t.Fatalf("expected label phase, got %v", m.Phase)
+	}
+
+	// Invalid labels are rejected until fixed
+	m = typeText(t, m, "smith/wedding")
+	m, _ = update(t, m, keyMsg("enter"))
+	if m.Phase != PhaseLabel || got != nil {
+		t.Fatalf("expected the invalid label to be rejected")
+	}
+	if !strings.Contains(m.View(), "not allowed in file names") {
+		t.Fatalf("expected the validation error in view, got:\n%s", m.View())
+	}
+	for range "/wedding" {
+		m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	m = typeText(t, m, "-wedding")
+	m, _ = update(t, m, keyMsg("enter"))
+
+	if m.Phase != PhaseScanning {
+		t.Fatalf("expected scanning after the label, got %v", m.Phase)
+	}
+	if got == nil || *got != "smith-wedding" {
+		t.Fatalf("expected SetLabel to receive the label, got %v", got)
+	}
+	if !strings.Contains(m.View(), "Label:  smith-wedding") {
+		t.Fatalf("expected the label in the header, got:\n%s", m.View())
+	}
+}
+
+func TestLabelPromptAcceptsEmptyLabel(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", AskLabel: true, Onboarding: true})
+	m, _ = update(t, m, keyMsg("enter"))
+	if m.Phase != PhaseOnboarding {
+		t.Fatalf("expected the first-run setup after the label, got %v", m.Phase)
+	}
+	m.Phase = PhaseScanning
+	if strings.Contains(m.View(), "Label:") {
+		t.Fatalf("did not expect an empty label in the header")
+	}
+}
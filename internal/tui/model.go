@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"phopy/internal/domain"
+	"phopy/internal/format"
+	"phopy/internal/presentation"
+	"phopy/tui/copyview"
 
-	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -24,42 +26,127 @@ const (
 	PhaseExecuting
 	PhaseDone
 	PhaseError
+	PhaseOnboarding
+	PhaseLabel
+	PhaseSourceWarning
+	// PhaseAuto confirms the configuration inferred by --auto.
+	PhaseAuto
+	// PhaseConflict pauses PhaseExecuting until a late conflict is
+	// answered.
+	PhaseConflict
+	// PhaseReview lists the warnings of the plan by code and lets the
+	// user act on their files before the copy.
+	PhaseReview
+	// PhasePartial asks before a plan whose scan was stopped early is
+	// copied.
+	PhasePartial
+	// PhaseDiskFull reports a copy stopped by a full target and how to
+	// resume it.
+	PhaseDiskFull
 )
 
-// Messages for the TUI
+// Messages for the TUI. The progress of the scan and the copy is reported
+// with the messages of the copy view.
 type (
+	// PlanReadyMsg ends the scan with the plan to copy.
 	PlanReadyMsg struct {
 		Plan domain.CopyPlan
-	}
-	ScanProgressMsg struct {
-		Current int
-		Total   int
-	}
-	CopyProgressMsg struct {
-		Current int
-		Total   int
-		File    string
-	}
+		// Estimate is how long the copy is expected to take; 0 when
+		// unknown.
+		Estimate time.Duration
+	}
+	ScanProgressMsg = copyview.ScanProgressMsg
+	CopyProgressMsg = copyview.CopyProgressMsg
+	CopyBytesMsg    = copyview.CopyBytesMsg
+	// CopyDoneMsg ends the copy with its result.
 	CopyDoneMsg struct {
 		OverridesConfirmed int
+		Result             domain.ExecutionResult
 	}
 	ErrorMsg struct {
 		Err error
 	}
-	tickMsg time.Time
+)
+
+// BarStyle configures the scanning and copying progress bars.
+type BarStyle = copyview.BarStyle
+
+// DefaultBarMaxWidth caps the progress bars on wide terminals unless
+// BarStyle.MaxWidth says otherwise.
+const DefaultBarMaxWidth = copyview.DefaultBarMaxWidth
+
+// ConfirmDefault selects which answer the override prompt starts on
+type ConfirmDefault string
+
+const (
+	ConfirmDefaultNo   ConfirmDefault = "no"
+	ConfirmDefaultYes  ConfirmDefault = "yes"
+	ConfirmDefaultNone ConfirmDefault = "none" // Enter is ignored until y/n is pressed
 )
 
 // ExecuteCopyFunc is called to start the copy operation
 // It should run the copy in a goroutine and send progress/done messages
 type ExecuteCopyFunc func(plan domain.CopyPlan, includeOverrides bool) tea.Cmd
 
+// ReplanFunc is called when a plan with approximate dates should be made
+// again with EXIF. It should start planning; the plan arrives as a
+// PlanReadyMsg.
+type ReplanFunc func() tea.Cmd
+
 // Config for the TUI
 type Config struct {
-	SourceDir   string
-	TargetDir   string
-	DryRun      bool
-	Verbose     bool
-	ExecuteCopy ExecuteCopyFunc
+	SourceDir      string
+	TargetDir      string
+	DryRun         bool
+	Verbose        bool
+	ConfirmDefault ConfirmDefault
+	ExecuteCopy    ExecuteCopyFunc
+	Replan         ReplanFunc
+	StopScan       StopScanFunc
+	Bar            BarStyle
+	// OverrideCap is how many override items are listed at once; 0
+	// uses presentation.DefaultOverrideCap. The rest is paged through
+	// while confirming.
+	OverrideCap int
+	// PreviewCount is how many planned files the preview samples; 0 uses
+	// presentation.DefaultPreviewCount.
+	PreviewCount int
+	// Label is shown in the header. AskLabel prompts for it before
+	// anything else and passes the answer to SetLabel.
+	Label    string
+	AskLabel bool
+	SetLabel LabelFunc
+
+	// SourceWarning explains why the source looks like an organized
+	// archive. When set, scanning waits until the user accepts it and
+	// Continue starts planning.
+	SourceWarning string
+	Continue      ContinueFunc
+
+	// AutoSummary lists what --auto inferred, e.g. "Source:
+	// /Volumes/EOS_DIGITAL (newest memory card)". When set, scanning waits
+	// until the user confirms it; Continue then starts planning unless the
+	// source warning comes next.
+	AutoSummary []string
+
+	// Onboarding starts with the first-run setup instead of scanning.
+	// Volumes are the detected memory cards offered as source and
+	// DefaultTarget prefills the target.
+	Onboarding    bool
+	Volumes       []string
+	DefaultTarget string
+	StartScan     StartScanFunc
+
+	// Thumbnail loads the thumbnail of the file highlighted with the
+	// arrow keys in the preview; nil leaves thumbnails off.
+	// ThumbnailClear is the escape sequence that removes a drawn
+	// thumbnail once the preview goes, for terminals where it stays.
+	Thumbnail      ThumbnailFunc
+	ThumbnailClear string
+
+	// TargetExists tells whether a file exists at a path of the target,
+	// for files the warnings review moves; nil takes none to exist.
+	TargetExists func(path string) (bool, error)
 }
 
 // Model is the main TUI model
@@ -67,17 +154,25 @@ type Model struct {
 	config             Config
 	Phase              Phase
 	Plan               domain.CopyPlan
-	spinner            spinner.Model
-	progress           progress.Model
-	scanCurrent        int
-	scanTotal          int
-	scanStartTime      time.Time
-	copyProgress       int
-	copyTotal          int
-	copyStartTime      time.Time
-	currentFile        string
+	Estimate           time.Duration
+	Result             domain.ExecutionResult
+	copyView           copyview.Model
+	scanStopping       bool // the user stopped the scan, its plan is due
+	partialAccepted    bool
 	confirmSelection   bool // true = yes, false = no
+	confirmChosen      bool // true once the user picked an answer explicitly
+	confirmUsedDefault bool
+	overrideOffset     int // first override item shown
+	showPairings       bool
+	showFolders        bool
+	filter             previewFilter
+	thumbs             thumbnails
+	conflict           ConflictMsg
+	diskFull           DiskFullMsg
+	review             warningReview
 	OverridesConfirmed int
+	onboarding         onboarding
+	label              labelPrompt
 	Err                error
 	Quitting           bool
 	width              int
@@ -86,64 +181,133 @@ type Model struct {
 
 // NewModel creates a new TUI model
 func NewModel(cfg Config) Model {
-	s := spinner.New()
-	s.Spinner = spinner.Dot
-	s.Style = spinnerStyle
-
-	p := progress.New(
-		progress.WithDefaultGradient(),
-		progress.WithWidth(50),
-		progress.WithoutPercentage(),
-	)
-
-	return Model{
+	m := Model{
 		config:           cfg,
 		Phase:            PhaseScanning,
-		spinner:          s,
-		progress:         p,
-		confirmSelection: false, // default to No
-		width:            80,
-		height:           24,
+		copyView:         copyview.New(cfg.Bar),
+		confirmSelection: cfg.ConfirmDefault == ConfirmDefaultYes,
+		width:            copyview.DefaultWindowWidth,
+		height:           copyview.DefaultWindowHeight,
+	}
+	if cfg.Onboarding {
+		m.Phase = PhaseOnboarding
+		m.onboarding = newOnboarding(cfg.Volumes)
+	}
+	if cfg.SourceWarning != "" {
+		m.Phase = PhaseSourceWarning
 	}
+	if len(cfg.AutoSummary) > 0 {
+		m.Phase = PhaseAuto
+	}
+	if cfg.AskLabel {
+		m.Phase = PhaseLabel
+	}
+	return m
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick)
+	return m.copyView.Init()
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
+		msg = copyview.FitWindow(msg)
 		m.width = msg.Width
 		m.height = msg.Height
-		m.progress.Width = min(msg.Width-20, 60)
+		m.copyView, _ = m.copyView.Update(msg)
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.Phase == PhaseOnboarding && msg.String() != "ctrl+c" {
+			return m.updateOnboarding(msg)
+		}
+		if m.Phase == PhaseLabel && msg.String() != "ctrl+c" {
+			return m.updateLabel(msg)
+		}
+		if m.Phase == PhaseSourceWarning {
+			return m.updateSourceWarning(msg)
+		}
+		if m.Phase == PhaseAuto {
+			return m.updateAuto(msg)
+		}
+		if m.Phase == PhaseConflict {
+			return m.updateConflict(msg)
+		}
+		if m.Phase == PhaseReview {
+			return m.updateReview(msg)
+		}
+		if m.Phase == PhasePartial {
+			return m.updatePartial(msg)
+		}
+		if m.Phase == PhaseDiskFull {
+			return m.updateDiskFull(msg)
+		}
+		if m.filter.editing && m.canFilter() && msg.String() != "ctrl+c" {
+			return m.updateFilter(msg)
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
 			m.Quitting = true
 			return m, tea.Quit
-		case "left", "h":
+		case "left", "h", "y", "Y":
 			if m.Phase == PhaseConfirm {
 				m.confirmSelection = true
+				m.confirmChosen = true
 			}
-		case "right", "l":
+		case "right", "l", "n", "N":
 			if m.Phase == PhaseConfirm {
 				m.confirmSelection = false
+				m.confirmChosen = true
 			}
-		case "y", "Y":
-			if m.Phase == PhaseConfirm {
-				m.confirmSelection = true
+		case "/":
+			if m.canFilter() {
+				m.filter.editing = true
 			}
-		case "n", "N":
+		case "esc":
+			m.filter = previewFilter{}
+		case "down", "j":
+			return m.moveThumbnail(1)
+		case "up", "k":
+			return m.moveThumbnail(-1)
+		case "s":
+			if m.Phase == PhaseScanning {
+				return m.stopScan()
+			}
+			if m.Phase == PhasePreview || m.Phase == PhaseConfirm || m.Phase == PhaseDone {
+				m.showPairings = !m.showPairings
+			}
+		case "d":
+			if m.canFilter() && len(m.Plan.Folders) > 1 {
+				m.showFolders = !m.showFolders
+			}
+		case "w":
+			if (m.Phase == PhasePreview || m.Phase == PhaseConfirm || m.Phase == PhaseDone) && m.warningsHelp() != "" {
+				return m.openReview(m.Phase), nil
+			}
+		case "F", "f":
+			if m.Phase == PhaseDone && m.Plan.ApproximateDates && m.config.Replan != nil {
+				m.Phase = PhaseScanning
+				m.copyView = m.copyView.Rescan()
+				return m, tea.Batch(m.copyView.Init(), m.config.Replan())
+			}
+		case "pgdown", "]":
+			if m.Phase == PhaseConfirm && m.overrideOffset+m.overridePage() < len(m.Plan.OverrideItems) {
+				m.overrideOffset += m.overridePage()
+			}
+		case "pgup", "[":
 			if m.Phase == PhaseConfirm {
-				m.confirmSelection = false
+				m.overrideOffset = max(m.overrideOffset-m.overridePage(), 0)
 			}
 		case "enter":
 			if m.Phase == PhaseConfirm {
+				if m.config.ConfirmDefault == ConfirmDefaultNone && !m.confirmChosen {
+					return m, nil
+				}
+				confirmed := m.confirmSelection
+				usedDefault := !m.confirmChosen
 				return m, func() tea.Msg {
-					return ConfirmMsg{Confirmed: m.confirmSelection}
+					return ConfirmMsg{Confirmed: confirmed, UsedDefault: usedDefault}
 				}
 			}
 			if m.Phase == PhaseDone || m.Phase == PhaseError {
@@ -151,54 +315,62 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-	case ScanProgressMsg:
-		// Track start time on first progress update
-		if m.scanStartTime.IsZero() && msg.Total > 0 {
-			m.scanStartTime = time.Now()
+	case OnboardingDoneMsg:
+		m.config.SourceDir = msg.Result.SourceDir
+		m.config.TargetDir = msg.Result.TargetDir
+		m.Phase = PhaseScanning
+		cmds := []tea.Cmd{m.copyView.Init()}
+		if m.config.StartScan != nil {
+			cmds = append(cmds, m.config.StartScan(msg.Result))
 		}
-		m.scanCurrent = msg.Current
-		m.scanTotal = msg.Total
-		return m, nil
+		return m, tea.Batch(cmds...)
+
+	case ScanProgressMsg, CopyProgressMsg, CopyBytesMsg:
+		var cmd tea.Cmd
+		m.copyView, cmd = m.copyView.Update(msg)
+		return m, cmd
 
 	case PlanReadyMsg:
+		m.copyView, _ = m.copyView.Update(copyview.PlanReadyMsg{Plan: viewPlan(msg.Plan), Estimate: msg.Estimate})
 		m.Plan = msg.Plan
+		m.Estimate = msg.Estimate
+		m.review = warningReview{}
+		m.scanStopping, m.partialAccepted = false, false
 		if m.config.DryRun {
 			m.Phase = PhaseDone
-		} else if len(m.Plan.OverrideItems) > 0 {
-			m.Phase = PhaseConfirm
-		} else {
-			// No overrides needed, start copy immediately
-			m.Phase = PhaseExecuting
-			if m.config.ExecuteCopy != nil {
-				return m, tea.Batch(tickCmd(), m.config.ExecuteCopy(m.Plan, false))
-			}
+			return m, nil
 		}
-		return m, nil
+		if hasActionableWarnings(m.Plan.ReviewableWarnings()) {
+			return m.openReview(PhaseConfirm), nil
+		}
+		return m.proceed()
 
 	case ConfirmMsg:
 		includeOverrides := msg.Confirmed
+		m.confirmUsedDefault = msg.UsedDefault
 		if includeOverrides {
-			m.OverridesConfirmed = len(m.Plan.OverrideItems)
+			m.OverridesConfirmed = m.Plan.OverrideCount()
 		}
+		m.Plan = m.Plan.Effective(includeOverrides)
 		// Start copy
 		m.Phase = PhaseExecuting
 		if m.config.ExecuteCopy != nil {
-			return m, tea.Batch(tickCmd(), m.config.ExecuteCopy(m.Plan, includeOverrides))
+			return m, m.config.ExecuteCopy(m.Plan, includeOverrides)
 		}
 		return m, nil
 
-	case CopyProgressMsg:
-		// Track start time on first progress update
-		if m.copyStartTime.IsZero() && msg.Total > 0 {
-			m.copyStartTime = time.Now()
-		}
-		m.copyProgress = msg.Current
-		m.copyTotal = msg.Total
-		m.currentFile = msg.File
+	case ThumbnailMsg:
+		return m.storeThumbnail(msg), nil
+
+	case ConflictMsg:
+		m.conflict = msg
+		m.Phase = PhaseConflict
 		return m, nil
 
 	case CopyDoneMsg:
+		m.copyView, _ = m.copyView.Update(copyview.CopyDoneMsg{Result: viewResult(msg.Result), OverridesConfirmed: msg.OverridesConfirmed})
 		m.Phase = PhaseDone
+		m.Result = msg.Result
 		if msg.OverridesConfirmed > 0 {
 			m.OverridesConfirmed = msg.OverridesConfirmed
 		}
@@ -209,27 +381,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.Err = msg.Err
 		return m, nil
 
+	case DiskFullMsg:
+		m.Phase = PhaseDiskFull
+		m.diskFull = msg
+		m.Result = msg.Result
+		m.Err = msg.Err
+		return m, nil
+
 	case spinner.TickMsg:
+		// The spinner stops while another phase hides it; Init of the
+		// copy view restarts it
 		if m.Phase == PhaseScanning || m.Phase == PhaseExecuting {
 			var cmd tea.Cmd
-			m.spinner, cmd = m.spinner.Update(msg)
+			m.copyView, cmd = m.copyView.Update(msg)
 			return m, cmd
 		}
 
-	case progress.FrameMsg:
-		progressModel, cmd := m.progress.Update(msg)
-		m.progress = progressModel.(progress.Model)
+	default:
+		// The bar animation and the ticks of the copy view
+		var cmd tea.Cmd
+		m.copyView, cmd = m.copyView.Update(msg)
 		return m, cmd
-
-	case tickMsg:
-		if m.Phase == PhaseExecuting {
-			var cmds []tea.Cmd
-			if m.copyTotal > 0 {
-				cmds = append(cmds, m.progress.SetPercent(float64(m.copyProgress)/float64(m.copyTotal)))
-			}
-			cmds = append(cmds, tickCmd(), m.spinner.Tick)
-			return m, tea.Batch(cmds...)
-		}
 	}
 
 	return m, nil
@@ -237,27 +409,44 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // Additional message types
 type (
-	ConfirmMsg struct{ Confirmed bool }
+	ConfirmMsg struct {
+		Confirmed   bool
+		UsedDefault bool
+	}
 )
 
-func tickCmd() tea.Cmd {
-	return tea.Tick(time.Millisecond*100, func(t time.Time) tea.Msg {
-		return tickMsg(t)
-	})
+// AbortedAtConfirm reports whether the user quit at the override
+// confirmation, which copies nothing; declining the overrides with n and
+// Enter copies the other files instead.
+func (m Model) AbortedAtConfirm() bool {
+	return m.Quitting && m.Phase == PhaseConfirm
 }
 
 func (m Model) View() string {
+	var b strings.Builder
+
+	// A thumbnail drawn by kitty is not part of the text and stays on
+	// screen until removed
+	if m.thumbs.active && (m.Quitting || !m.showsThumbnail()) {
+		b.WriteString(m.config.ThumbnailClear)
+	}
 	if m.Quitting {
-		return ""
+		return b.String()
 	}
 
-	var b strings.Builder
-
 	// Header
 	b.WriteString(m.renderHeader())
 	b.WriteString("\n\n")
 
 	switch m.Phase {
+	case PhaseOnboarding:
+		b.WriteString(m.renderOnboarding())
+	case PhaseLabel:
+		b.WriteString(m.renderLabel())
+	case PhaseSourceWarning:
+		b.WriteString(m.renderSourceWarning())
+	case PhaseAuto:
+		b.WriteString(m.renderAuto())
 	case PhaseScanning:
 		b.WriteString(m.renderScanning())
 	case PhasePreview:
@@ -266,7 +455,7 @@ func (m Model) View() string {
 		b.WriteString(m.renderPreview())
 		if !m.config.DryRun {
 			b.WriteString("\n")
-			b.WriteString(m.renderCopyCompletion())
+			b.WriteString(copyview.Completion(viewPlan(m.Plan), viewResult(m.Result), copyview.Overrides{Confirmed: m.OverridesConfirmed, Default: m.confirmUsedDefault}))
 		}
 	case PhaseConfirm:
 		b.WriteString(m.renderPreview())
@@ -275,9 +464,21 @@ func (m Model) View() string {
 	case PhaseExecuting:
 		b.WriteString(m.renderPreview())
 		b.WriteString("\n")
-		b.WriteString(m.renderExecution())
+		b.WriteString(m.copyView.CopyView())
+	case PhaseConflict:
+		b.WriteString(m.renderPreview())
+		b.WriteString("\n")
+		b.WriteString(m.renderConflict())
+	case PhaseReview:
+		b.WriteString(m.renderReview())
+	case PhasePartial:
+		b.WriteString(m.renderPreview())
+		b.WriteString("\n")
+		b.WriteString(m.renderPartialPrompt())
 	case PhaseError:
 		b.WriteString(m.renderError())
+	case PhaseDiskFull:
+		b.WriteString(m.renderDiskFull())
 	}
 
 	// Help
@@ -293,77 +494,76 @@ func (m Model) renderHeader() string {
 
 	dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
 
-	return lipgloss.JoinVertical(lipgloss.Left,
+	if m.Phase == PhaseOnboarding || m.Phase == PhaseLabel {
+		return lipgloss.JoinVertical(lipgloss.Left, title, subtitle)
+	}
+
+	lines := []string{
 		title,
 		subtitle,
 		"",
 		dimStyle.Render(fmt.Sprintf("%s Source: %s", iconFolder, shortenPath(m.config.SourceDir))),
 		dimStyle.Render(fmt.Sprintf("%s Target: %s", iconFolder, shortenPath(m.config.TargetDir))),
-	)
+	}
+	if m.config.Label != "" {
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("%s Label:  %s", iconLabel, m.config.Label)))
+	}
+	// The scan stays in view once planned, until a new scan replaces it
+	if line := presentation.ScanLine(m.Plan.Scan); line != "" && m.Phase != PhaseScanning {
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("%s %s", iconScan, line)))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
 }
 
 func (m Model) renderScanning() string {
-	if m.scanTotal > 0 {
-		percent := float64(m.scanCurrent) / float64(m.scanTotal)
-		progressBar := m.progress.ViewAs(percent)
-
-		countStyle := lipgloss.NewStyle().Foreground(primaryColor).Bold(true)
-		percentStyle := lipgloss.NewStyle().Foreground(dimTextColor)
-		etaStyle := lipgloss.NewStyle().Foreground(dimTextColor)
-
-		eta := estimateRemainingTime(m.scanStartTime, m.scanCurrent, m.scanTotal)
-		etaText := ""
-		if eta != "" {
-			etaText = etaStyle.Render(fmt.Sprintf(" • ~%s remaining", eta))
-		}
-
-		return fmt.Sprintf("%s Scanning photos...\n\n  %s\n  %s %s%s",
-			m.spinner.View(),
-			progressBar,
-			countStyle.Render(fmt.Sprintf("%d/%d", m.scanCurrent, m.scanTotal)),
-			percentStyle.Render(fmt.Sprintf("(%.0f%%)", percent*100)),
-			etaText,
-		)
+	if m.scanStopping {
+		return fmt.Sprintf("%s Stopping the scan, planning the %d files scanned so far...", m.copyView.Spinner(), m.copyView.Scanned())
 	}
-	return fmt.Sprintf("%s Scanning photos...", m.spinner.View())
+	return m.copyView.ScanView()
 }
 
 func (m Model) renderPreview() string {
 	var b strings.Builder
 
+	if m.Plan.Partial {
+		b.WriteString(m.renderPartialBanner())
+		b.WriteString("\n\n")
+	}
+
 	// Files to copy section
-	b.WriteString(sectionStyle.Render("Files to Copy"))
+	if m.Plan.ApproximateDates {
+		b.WriteString(sectionStyle.Render("Files to Copy (approximate dates)"))
+	} else {
+		b.WriteString(sectionStyle.Render("Files to Copy"))
+	}
 	b.WriteString("\n\n")
 
 	if len(m.Plan.Items) == 0 {
 		dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
 		b.WriteString(dimStyle.Render("  No files to copy"))
 		b.WriteString("\n")
+	} else if m.filter.editing || m.filter.query != "" {
+		b.WriteString(m.renderFilteredItems())
 	} else {
-		lines := formatFileList(m.Plan.Items, 4)
+		lines := formatFileList(m.Plan, presentation.EffectivePreviewCount(m.config.PreviewCount))
 		for _, line := range lines {
 			b.WriteString("  ")
 			b.WriteString(line)
 			b.WriteString("\n")
 		}
 	}
+	if m.showsThumbnail() {
+		b.WriteString("\n")
+		b.WriteString(m.renderThumbnail())
+	}
 
 	// Override section if any
 	if len(m.Plan.OverrideItems) > 0 {
 		b.WriteString("\n")
-		b.WriteString(warningStyle.Render(fmt.Sprintf("%s Override Required (%d files)", iconOverride, len(m.Plan.OverrideItems))))
+		b.WriteString(warningStyle.Render(fmt.Sprintf("%s Override Required (%d files)", iconOverride, m.Plan.OverrideCount())))
 		b.WriteString("\n\n")
 
-		for i, item := range m.Plan.OverrideItems {
-			if i >= 4 {
-				b.WriteString(fmt.Sprintf("  ... and %d more\n", len(m.Plan.OverrideItems)-4))
-				break
-			}
-			b.WriteString(fmt.Sprintf("  %s %s\n",
-				overrideStyle.Render(iconOverride),
-				fileNameStyle.Render(item.FileMeta.Name),
-			))
-		}
+		b.WriteString(m.renderOverrideItems())
 	}
 
 	// Summary
@@ -376,13 +576,41 @@ func (m Model) renderPreview() string {
 		b.WriteString(warningStyle.Render("Warnings:"))
 		b.WriteString("\n")
 		for _, w := range m.Plan.Warnings {
-			b.WriteString(fmt.Sprintf("  %s %s\n", iconOverride, w))
+			b.WriteString(fmt.Sprintf("  %s %s\n", iconOverride, w.Text))
+		}
+		if m.Plan.TruncatedWarnings > 0 {
+			b.WriteString(fmt.Sprintf("  %s %s\n", iconOverride, presentation.TruncatedLine(m.Plan.TruncatedWarnings, m.Plan.WarningLog)))
 		}
 	}
 
 	return b.String()
 }
 
+// overridePage is how many override items are listed at once.
+func (m Model) overridePage() int {
+	return presentation.EffectiveOverrideCap(m.config.OverrideCap)
+}
+
+// renderOverrideItems lists the current page of override items.
+func (m Model) renderOverrideItems() string {
+	var b strings.Builder
+	items := m.Plan.OverrideItems
+	end := min(m.overrideOffset+m.overridePage(), len(items))
+	if m.overrideOffset > 0 {
+		b.WriteString(fmt.Sprintf("  ... %d above\n", m.overrideOffset))
+	}
+	for _, item := range items[m.overrideOffset:end] {
+		b.WriteString(fmt.Sprintf("  %s %s\n",
+			overrideStyle.Render(iconOverride),
+			fileNameStyle.Render(item.FileMeta.Name),
+		))
+	}
+	if more := len(items) - end + m.Plan.TruncatedOverrides; more > 0 {
+		b.WriteString("  " + presentation.MoreLine(more) + "\n")
+	}
+	return b.String()
+}
+
 func (m Model) renderSummary() string {
 	var b strings.Builder
 
@@ -398,6 +626,9 @@ func (m Model) renderSummary() string {
 		)
 		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Date Range:"), dateStyle.Render(dateRange)))
 	}
+	if m.Plan.ApproximateDates {
+		b.WriteString("  " + warningStyle.Render(iconOverride+" "+presentation.ApproximateDatesLine) + "\n")
+	}
 
 	// File counts
 	rawStat := fmt.Sprintf("%s %d", iconRAW, m.Plan.RawCount)
@@ -407,13 +638,84 @@ func (m Model) renderSummary() string {
 	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("JPEG files:"), jpegFileStyle.Render(jpegStat)))
 	dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
 	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Skipped JPEGs:"), dimStyle.Render(fmt.Sprintf("%s %d", iconSkipped, m.Plan.SkippedJPEGs))))
-	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Skipped RAWs (date):"), dimStyle.Render(fmt.Sprintf("%s %d", iconSkipped, m.Plan.SkippedRAWsDate))))
+	if m.showPairings {
+		b.WriteString(m.renderPairings())
+	}
+	dateSkips := fmt.Sprintf("%s %d", iconSkipped, m.Plan.SkippedRAWsDate)
+	if m.config.Verbose && m.Plan.SkippedRAWsDate > 0 {
+		dateSkips += " (" + presentation.DateSkipsLine(m.Plan) + ")"
+	}
+	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Skipped RAWs (date):"), dimStyle.Render(dateSkips)))
 	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Skipped RAWs (dupl):"), dimStyle.Render(fmt.Sprintf("%s %d", iconSkipped, m.Plan.SkippedRAWsDupl))))
+	if m.Plan.Duplicates > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Duplicate captures:"), dimStyle.Render(fmt.Sprintf("%s %d", iconSkipped, m.Plan.Duplicates))))
+	}
+	if m.Plan.JunkFiles > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Junk files:"), dimStyle.Render(fmt.Sprintf("%s %d", iconSkipped, m.Plan.JunkFiles))))
+	}
+	if m.Plan.ProxyClips > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Proxy clips:"), dimStyle.Render(fmt.Sprintf("%s %d", iconSkipped, m.Plan.ProxyClips))))
+	}
+
+	if excluded := m.Plan.OutsideRange; excluded.Count > 0 {
+		// Highlight when the range clipped at least as much as it kept
+		style := dimStyle
+		if excluded.Count >= m.Plan.ItemCount() {
+			style = warningStyle
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Outside range:"), style.Render(fmt.Sprintf("%s %s", iconSkipped, presentation.OutsideRangeLine(excluded)))))
+	}
+
+	if m.Plan.InvalidDates > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Invalid EXIF date:"), warningStyle.Render(fmt.Sprintf("%s %d files (filesystem time used)", iconOverride, m.Plan.InvalidDates))))
+	}
+
+	if line := presentation.RatingsLine(m.Plan.Ratings); line != "" {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Ratings:"), dimStyle.Render(line)))
+	}
+
+	if diff := m.Plan.SinceLastImport; diff != nil {
+		stat := fmt.Sprintf("%d new since %s", diff.New, diff.LastImport.Format("2006-01-02"))
+		if diff.Unchanged > 0 {
+			stat += fmt.Sprintf(", %d imported before", diff.Unchanged)
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Last import:"), dimStyle.Render(stat)))
+		if diff.Modified > 0 {
+			hint := "see warnings"
+			if !m.config.Verbose {
+				hint = "run with -v to list"
+			}
+			b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Modified since:"), warningStyle.Render(fmt.Sprintf("%s %d previously imported files (%s)", iconOverride, diff.Modified, hint))))
+		}
+	}
+
+	if m.Plan.ZoneBoundary > 0 {
+		hint := "see warnings"
+		if !m.config.Verbose {
+			hint = "run with -v to list"
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Zone boundary:"), warningStyle.Render(fmt.Sprintf("%s %d (%s)", iconOverride, m.Plan.ZoneBoundary, hint))))
+	}
 
 	if m.Plan.RawOverrides+m.Plan.JpegOverrides > 0 {
 		overrideCount := m.Plan.RawOverrides + m.Plan.JpegOverrides
 		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Overrides:"), warningStyle.Render(fmt.Sprintf("%s %d", iconOverride, overrideCount))))
 	}
+	if len(m.Plan.AutoOverrideItems) > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Stale targets:"), warningStyle.Render(fmt.Sprintf("%s %d overwritten without asking", iconOverride, len(m.Plan.AutoOverrideItems)))))
+	}
+
+	if m.showFolders {
+		b.WriteString(fmt.Sprintf("  %s\n", statLabelStyle.Render("Folders:")))
+		for _, folder := range m.Plan.Folders {
+			b.WriteString(dimStyle.Render("    " + presentation.FolderLine(folder)))
+			b.WriteString("\n")
+		}
+	}
+
+	if m.Estimate > 0 && !m.config.DryRun {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Estimated time:"), dimStyle.Render(format.Estimate(m.Estimate)+" (estimate)")))
+	}
 
 	if m.config.DryRun {
 		b.WriteString("\n")
@@ -424,19 +726,32 @@ func (m Model) renderSummary() string {
 }
 
 func (m Model) renderConfirmPrompt() string {
-	prompt := confirmPromptStyle.Render(fmt.Sprintf("Override %d existing files?", len(m.Plan.OverrideItems)))
+	prompt := confirmPromptStyle.Render(fmt.Sprintf("Override %d existing files?", m.Plan.OverrideCount()))
+
+	yesLabel, noLabel := " Yes ", " No "
+	switch m.config.ConfirmDefault {
+	case ConfirmDefaultYes:
+		yesLabel = " Yes (default) "
+	case ConfirmDefaultNone:
+	default:
+		noLabel = " No (default) "
+	}
 
 	var yesBtn, noBtn string
-	if m.confirmSelection {
+	switch {
+	case m.config.ConfirmDefault == ConfirmDefaultNone && !m.confirmChosen:
+		yesBtn = boxStyle.Render(yesLabel)
+		noBtn = boxStyle.Render(noLabel)
+	case m.confirmSelection:
 		yesBtn = highlightBoxStyle.Copy().
 			Background(lipgloss.Color("#2D5A27")).
-			Render(" Yes ")
-		noBtn = boxStyle.Render(" No ")
-	} else {
-		yesBtn = boxStyle.Render(" Yes ")
+			Render(yesLabel)
+		noBtn = boxStyle.Render(noLabel)
+	default:
+		yesBtn = boxStyle.Render(yesLabel)
 		noBtn = highlightBoxStyle.Copy().
 			Background(lipgloss.Color("#5A2727")).
-			Render(" No ")
+			Render(noLabel)
 	}
 
 	buttons := lipgloss.JoinHorizontal(lipgloss.Center, yesBtn, "  ", noBtn)
@@ -444,77 +759,6 @@ func (m Model) renderConfirmPrompt() string {
 	return lipgloss.JoinVertical(lipgloss.Left, prompt, "", buttons)
 }
 
-func (m Model) renderExecution() string {
-	var b strings.Builder
-
-	b.WriteString(sectionStyle.Render("Copying Files"))
-	b.WriteString("\n\n")
-
-	// Progress bar
-	percent := 0.0
-	if m.copyTotal > 0 {
-		percent = float64(m.copyProgress) / float64(m.copyTotal)
-	}
-
-	// Spinner and progress
-	b.WriteString(fmt.Sprintf("  %s Copying...\n\n", m.spinner.View()))
-	b.WriteString(fmt.Sprintf("  %s\n", m.progress.ViewAs(percent)))
-
-	countStyle := lipgloss.NewStyle().Foreground(primaryColor).Bold(true)
-	percentStyle := lipgloss.NewStyle().Foreground(dimTextColor)
-	etaStyle := lipgloss.NewStyle().Foreground(dimTextColor)
-
-	eta := estimateRemainingTime(m.copyStartTime, m.copyProgress, m.copyTotal)
-	etaText := ""
-	if eta != "" {
-		etaText = etaStyle.Render(fmt.Sprintf(" • ~%s remaining", eta))
-	}
-
-	b.WriteString(fmt.Sprintf("  %s %s%s\n",
-		countStyle.Render(fmt.Sprintf("%d/%d files", m.copyProgress, m.copyTotal)),
-		percentStyle.Render(fmt.Sprintf("(%.0f%%)", percent*100)),
-		etaText,
-	))
-
-	if m.currentFile != "" {
-		b.WriteString(fmt.Sprintf("\n  %s %s\n",
-			iconArrow,
-			fileNameStyle.Render(m.currentFile),
-		))
-	}
-
-	return b.String()
-}
-
-func (m Model) renderCopyCompletion() string {
-	var b strings.Builder
-
-	b.WriteString(sectionStyle.Render("Copy Complete"))
-	b.WriteString("\n\n")
-
-	// Success message
-	icon := successStyle.Render(iconSuccess)
-	msg := successStyle.Render("Copy completed successfully!")
-	b.WriteString(fmt.Sprintf("  %s %s\n\n", icon, msg))
-
-	// Statistics
-	totalCopied := m.Plan.RawCount + m.Plan.JpegCount
-	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("RAW files copied:"), rawFileStyle.Render(fmt.Sprintf("%s %d", iconRAW, m.Plan.RawCount))))
-	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("JPEG files copied:"), jpegFileStyle.Render(fmt.Sprintf("%s %d", iconJPEG, m.Plan.JpegCount))))
-	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Total copied:"), statValueStyle.Render(fmt.Sprintf("%d files", totalCopied))))
-
-	if m.Plan.SkippedJPEGs > 0 {
-		dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
-		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Skipped JPEGs:"), dimStyle.Render(fmt.Sprintf("%s %d (RAW exists)", iconSkipped, m.Plan.SkippedJPEGs))))
-	}
-
-	if m.OverridesConfirmed > 0 {
-		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Files overwritten:"), warningStyle.Render(fmt.Sprintf("%s %d", iconOverride, m.OverridesConfirmed))))
-	}
-
-	return b.String()
-}
-
 func (m Model) renderError() string {
 	icon := errorStyle.Render(iconError)
 	msg := errorStyle.Render(fmt.Sprintf("Error: %s", m.Err.Error()))
@@ -524,51 +768,116 @@ func (m Model) renderError() string {
 		Render(fmt.Sprintf("%s %s", icon, msg))
 }
 
+// proceed asks about the overrides of the plan if it has any and
+// otherwise starts the copy.
+func (m Model) proceed() (Model, tea.Cmd) {
+	if m.Plan.Partial && !m.partialAccepted {
+		m.Phase = PhasePartial
+		return m, nil
+	}
+	if len(m.Plan.OverrideItems) > 0 {
+		m.Phase = PhaseConfirm
+		return m, nil
+	}
+	m.Phase = PhaseExecuting
+	if m.config.ExecuteCopy != nil {
+		return m, m.config.ExecuteCopy(m.Plan, false)
+	}
+	return m, nil
+}
+
 func (m Model) renderHelp() string {
+	if m.filter.editing && m.canFilter() {
+		return helpStyle.Render("Type to filter by name or date • Enter to keep the filter • Esc to clear • Ctrl+C to quit")
+	}
 	var help string
 	switch m.Phase {
+	case PhaseOnboarding:
+		help = "↑ ↓ to select • Enter to continue • Esc to go back • Ctrl+C to quit"
+		if m.onboarding.editing {
+			help = "Type a path • Enter to continue • Esc to go back • Ctrl+C to quit"
+		} else if m.onboarding.step == stepSave {
+			help = "← → or y/n to select • Enter to confirm • Esc to go back • Ctrl+C to quit"
+		}
+	case PhaseLabel:
+		help = "Type a label • Enter to continue • Ctrl+C to quit"
+	case PhaseSourceWarning:
+		help = "y to scan anyway • n or q to quit"
+	case PhaseAuto:
+		help = "Enter or y to scan • n or q to quit"
 	case PhaseScanning:
 		help = "Press q to quit"
+		if m.config.StopScan != nil && !m.scanStopping {
+			help = "s to stop and plan the files scanned so far • q to quit"
+		}
+	case PhasePartial:
+		help = "y to copy the scanned files • n or q to quit"
 	case PhasePreview:
-		help = "Press q to quit"
+		help = "Press q to quit" + m.pairingsHelp() + m.warningsHelp()
 	case PhaseConfirm:
-		help = "← → or y/n to select • Enter to confirm • q to quit"
+		help = "← → or y/n to select • Enter to confirm, n copies the files without conflicts • q to abort, copying nothing"
+		if m.config.ConfirmDefault == ConfirmDefaultNone && !m.confirmChosen {
+			help = "Press y or n to choose • q to abort, copying nothing"
+		}
+		if len(m.Plan.OverrideItems) > m.overridePage() {
+			help += " • PgUp/PgDn to page overrides"
+		}
+		help += m.pairingsHelp() + m.warningsHelp()
 	case PhaseExecuting:
 		help = "Copying files... Please wait"
+	case PhaseReview:
+		help = m.reviewHelp()
+	case PhaseConflict:
+		help = "o to overwrite • s to skip • O to overwrite all • S to skip all • q to quit"
 	case PhaseDone:
-		help = "Press Enter to exit"
-	case PhaseError:
+		help = "Press Enter to exit" + m.pairingsHelp() + m.warningsHelp()
+		if m.Plan.ApproximateDates && m.config.Replan != nil {
+			help += " • F for full EXIF scan"
+		}
+	case PhaseError, PhaseDiskFull:
 		help = "Press Enter or q to exit"
 	}
+	if m.canFilter() && len(m.Plan.Items) > 0 {
+		help += m.thumbnailHelp() + m.filterHelp()
+	}
+	if m.canFilter() {
+		help += m.foldersHelp()
+	}
 	return helpStyle.Render(help)
 }
 
-// formatFileList formats a list of copy items for display
-func formatFileList(items []domain.CopyItem, maxItems int) []string {
+// formatFileList lists a sample of maxItems items of plan, see
+// presentation.SampleIndexes. A truncated preview lists the sample it kept
+// of the full plan; without one only its first items are known.
+func formatFileList(plan domain.CopyPlan, maxItems int) []string {
+	items := plan.Items
 	if len(items) == 0 {
 		return []string{}
 	}
-
-	lines := make([]string, 0, min(len(items), maxItems+1))
-
-	showCount := min(len(items), maxItems)
-	if len(items) > maxItems {
-		// Show first half and last half
-		half := maxItems / 2
-		for i := 0; i < half; i++ {
-			lines = append(lines, formatFileItem(items[i]))
-		}
-		dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
-		lines = append(lines, dimStyle.Render(fmt.Sprintf("... %d more files ...", len(items)-maxItems)))
-		for i := len(items) - half; i < len(items); i++ {
-			lines = append(lines, formatFileItem(items[i]))
-		}
-	} else {
-		for i := 0; i < showCount; i++ {
-			lines = append(lines, formatFileItem(items[i]))
+	dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
+	if plan.TruncatedItems > 0 && len(plan.Sample) == 0 {
+		lines := make([]string, 0, maxItems+1)
+		for _, item := range items[:min(len(items), maxItems)] {
+			lines = append(lines, formatFileItem(item))
 		}
+		more := len(items) - min(len(items), maxItems) + plan.TruncatedItems
+		return append(lines, dimStyle.Render(fmt.Sprintf("... %s more files ...", format.Count(more))))
 	}
 
+	sample := plan.Sample
+	if plan.TruncatedItems == 0 {
+		sample = nil
+		for _, i := range presentation.SampleIndexes(len(items), maxItems) {
+			sample = append(sample, items[i])
+		}
+	}
+	lines := make([]string, 0, len(sample)+1)
+	for _, item := range sample {
+		lines = append(lines, formatFileItem(item))
+	}
+	if total := plan.ItemCount(); len(sample) < total {
+		lines = append(lines, dimStyle.Render(presentation.InBetweenLine(total-len(sample))))
+	}
 	return lines
 }
 
@@ -586,7 +895,6 @@ func formatFileItem(item domain.CopyItem) string {
 	return fmt.Sprintf("%s %s  %s", icon, name, date)
 }
 
-
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -594,59 +902,56 @@ func min(a, b int) int {
 	return b
 }
 
-// estimateRemainingTime calculates the estimated remaining time based on progress
-func estimateRemainingTime(startTime time.Time, current, total int) string {
-	if current <= 0 || total <= 0 || startTime.IsZero() {
-		return ""
-	}
-
-	elapsed := time.Since(startTime)
-	if elapsed < time.Millisecond*100 {
-		// Not enough data yet
-		return ""
+// shortenPath replaces the home directory prefix with ~ for display
+func shortenPath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
 	}
-
-	// Calculate rate and remaining time
-	rate := float64(current) / elapsed.Seconds()
-	if rate <= 0 {
-		return ""
+	if strings.HasPrefix(path, home) {
+		return "~" + path[len(home):]
 	}
-
-	remaining := float64(total-current) / rate
-	remainingDuration := time.Duration(remaining * float64(time.Second))
-
-	return formatDuration(remainingDuration)
+	return path
 }
 
-// formatDuration formats a duration into a human-readable string
-func formatDuration(d time.Duration) string {
-	if d < time.Second {
-		return "<1s"
-	}
-
-	d = d.Round(time.Second)
+// maxPairings is how many JPEGs skipped for their RAW the s toggle lists.
+const maxPairings = 8
 
-	hours := int(d.Hours())
-	minutes := int(d.Minutes()) % 60
-	seconds := int(d.Seconds()) % 60
+// renderPairings lists the JPEGs skipped for their RAW below the count.
+func (m Model) renderPairings() string {
+	dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
+	var b strings.Builder
+	pairings := m.Plan.Pairings
+	if len(pairings) > maxPairings {
+		pairings = pairings[:maxPairings]
+	}
+	for _, pairing := range pairings {
+		b.WriteString(dimStyle.Render("    " + presentation.PairingLine(pairing)))
+		b.WriteString("\n")
+	}
+	if rest := len(m.Plan.Pairings) - len(pairings); rest > 0 {
+		b.WriteString(dimStyle.Render("    " + presentation.MoreLine(rest)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
 
-	if hours > 0 {
-		return fmt.Sprintf("%dh %dm %ds", hours, minutes, seconds)
+func (m Model) foldersHelp() string {
+	if len(m.Plan.Folders) < 2 {
+		return ""
 	}
-	if minutes > 0 {
-		return fmt.Sprintf("%dm %ds", minutes, seconds)
+	if m.showFolders {
+		return " • d to hide folders"
 	}
-	return fmt.Sprintf("%ds", seconds)
+	return " • d for folders"
 }
 
-// shortenPath replaces the home directory prefix with ~ for display
-func shortenPath(path string) string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return path
+func (m Model) pairingsHelp() string {
+	if len(m.Plan.Pairings) == 0 {
+		return ""
 	}
-	if strings.HasPrefix(path, home) {
-		return "~" + path[len(home):]
+	if m.showPairings {
+		return " • s to hide skipped JPEGs"
 	}
-	return path
+	return " • s to show skipped JPEGs"
 }
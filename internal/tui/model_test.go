@@ -0,0 +1,545 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+	"phopy/internal/presentation"
+	"phopy/tui/copyview"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func overridePlan() domain.CopyPlan {
+	now := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	item := domain.CopyItem{FileMeta: domain.FileMeta{Name: "DSC0001.ARW", TakenAt: now, IsRAW: true}, Exists: true}
+	return domain.CopyPlan{
+		Items:         []domain.CopyItem{item},
+		OverrideItems: []domain.CopyItem{item},
+		RawCount:      1,
+		RawOverrides:  1,
+	}
+}
+
+func keyMsg(key string) tea.KeyMsg {
+	if key == "enter" {
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func update(t *testing.T, m Model, msg tea.Msg) (Model, tea.Cmd) {
+	t.Helper()
+	next, cmd := m.Update(msg)
+	return next.(Model), cmd
+}
+
+func confirmModel(t *testing.T, def ConfirmDefault) Model {
+	t.Helper()
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", ConfirmDefault: def})
+	m, _ = update(t, m, PlanReadyMsg{Plan: overridePlan()})
+	if m.Phase != PhaseConfirm {
+		t.Fatalf("expected confirm phase, got %v", m.Phase)
+	}
+	return m
+}
+
+func pressEnter(t *testing.T, m Model) (ConfirmMsg, bool) {
+	t.Helper()
+	_, cmd := update(t, m, keyMsg("enter"))
+	if cmd == nil {
+		return ConfirmMsg{}, false
+	}
+	msg, ok := cmd().(ConfirmMsg)
+	if !ok {
+		t.Fatalf("expected ConfirmMsg")
+	}
+	return msg, true
+}
+
+func TestConfirmDefaultNoDeclinesOnEnter(t *testing.T) {
+	m := confirmModel(t, ConfirmDefaultNo)
+	if !strings.Contains(m.View(), "No (default)") {
+		t.Fatalf("expected No button to be marked as default")
+	}
+
+	msg, ok := pressEnter(t, m)
+	if !ok || msg.Confirmed || !msg.UsedDefault {
+		t.Fatalf("expected default decline, got %+v", msg)
+	}
+
+	m, _ = update(t, m, msg)
+	if len(m.Plan.Items) != 0 || m.Plan.RawCount != 0 {
+		t.Fatalf("expected the declined override out of the plan, got %d items and %d RAWs", len(m.Plan.Items), m.Plan.RawCount)
+	}
+	m, _ = update(t, m, CopyDoneMsg{})
+	if !strings.Contains(m.View(), "overrides declined (default)") {
+		t.Fatalf("expected completion to mention the default decline")
+	}
+}
+
+func TestConfirmDefaultYesConfirmsOnEnter(t *testing.T) {
+	m := confirmModel(t, ConfirmDefaultYes)
+	if !strings.Contains(m.View(), "Yes (default)") {
+		t.Fatalf("expected Yes button to be marked as default")
+	}
+
+	msg, ok := pressEnter(t, m)
+	if !ok || !msg.Confirmed || !msg.UsedDefault {
+		t.Fatalf("expected default confirm, got %+v", msg)
+	}
+}
+
+func TestConfirmDefaultNoneRequiresExplicitChoice(t *testing.T) {
+	m := confirmModel(t, ConfirmDefaultNone)
+	if strings.Contains(m.View(), "(default)") {
+		t.Fatalf("expected no button to be marked as default")
+	}
+
+	if _, ok := pressEnter(t, m); ok {
+		t.Fatalf("expected Enter to be ignored before a choice is made")
+	}
+
+	m, _ = update(t, m, keyMsg("n"))
+	msg, ok := pressEnter(t, m)
+	if !ok || msg.Confirmed || msg.UsedDefault {
+		t.Fatalf("expected explicit decline, got %+v", msg)
+	}
+
+	m, _ = update(t, m, msg)
+	m, _ = update(t, m, CopyDoneMsg{})
+	view := m.View()
+	if !strings.Contains(view, "overrides declined") || strings.Contains(view, "(default)") {
+		t.Fatalf("expected explicit decline in completion summary")
+	}
+}
+
+func TestConfirmQuitAbortsWhileNoCopiesTheRest(t *testing.T) {
+	plan := overridePlan()
+	fresh := domain.CopyItem{FileMeta: domain.FileMeta{Name: "DSC0002.ARW", TakenAt: plan.Items[0].FileMeta.TakenAt, IsRAW: true}}
+	plan.Items = append(plan.Items, fresh)
+	plan.RawCount++
+
+	var copied []domain.CopyPlan
+	confirming := func() Model {
+		m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", ExecuteCopy: func(plan domain.CopyPlan, _ bool) tea.Cmd {
+			copied = append(copied, plan)
+			return nil
+		}})
+		m, _ = update(t, m, PlanReadyMsg{Plan: plan})
+		if m.Phase != PhaseConfirm {
+			t.Fatalf("expected confirm phase, got %v", m.Phase)
+		}
+		return m
+	}
+	if help := confirming().View(); !strings.Contains(help, "n copies the files without conflicts") || !strings.Contains(help, "q to abort, copying nothing") {
+		t.Fatalf("expected the help to tell declining from aborting:\n%s", help)
+	}
+
+	for _, key := range []tea.KeyMsg{keyMsg("q"), {Type: tea.KeyCtrlC}} {
+		m, _ := update(t, confirming(), key)
+		if !m.AbortedAtConfirm() || len(copied) != 0 {
+			t.Fatalf("%s: expected an abort without copying, got phase %v and %d copies", key, m.Phase, len(copied))
+		}
+	}
+
+	m, _ := update(t, confirming(), keyMsg("n"))
+	msg, ok := pressEnter(t, m)
+	if !ok {
+		t.Fatalf("expected n and Enter to answer the confirmation")
+	}
+	m, _ = update(t, m, msg)
+	if m.AbortedAtConfirm() || len(copied) != 1 || len(copied[0].Items) != 1 || copied[0].Items[0].FileMeta.Name != "DSC0002.ARW" {
+		t.Fatalf("expected only the file without conflict copied, got %+v", copied)
+	}
+}
+
+func TestConfirmExplicitChoiceOverridesDefault(t *testing.T) {
+	m := confirmModel(t, ConfirmDefaultNo)
+	m, _ = update(t, m, keyMsg("y"))
+
+	msg, ok := pressEnter(t, m)
+	if !ok || !msg.Confirmed || msg.UsedDefault {
+		t.Fatalf("expected explicit confirm, got %+v", msg)
+	}
+}
+
+func TestStaleOverridesSkipTheConfirmation(t *testing.T) {
+	plan := overridePlan()
+	plan.Items[0].AutoOverride = true
+	plan.AutoOverrideItems, plan.OverrideItems, plan.RawOverrides = plan.Items, nil, 0
+
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target"})
+	m, _ = update(t, m, PlanReadyMsg{Plan: plan})
+	if m.Phase == PhaseConfirm {
+		t.Fatalf("expected no confirmation for stale overrides only")
+	}
+	if view := m.View(); !strings.Contains(view, "1 overwritten without asking") {
+		t.Fatalf("expected the stale targets in the summary:\n%s", view)
+	}
+
+	m, _ = update(t, m, CopyDoneMsg{})
+	if view := m.View(); !strings.Contains(view, "Stale overwritten:") || strings.Contains(view, "Files overwritten:") {
+		t.Fatalf("expected the stale targets apart from confirmed overrides:\n%s", view)
+	}
+}
+
+func TestCompletionRendersExecutionResult(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target"})
+	plan := overridePlan()
+	plan.OverrideItems = nil
+	m, _ = update(t, m, PlanReadyMsg{Plan: plan})
+
+	result := domain.ExecutionResult{RunID: "20241002T130100Z-1a2b3c4d"}
+	result.Record(plan.Items[0], domain.ItemFailed, errors.New("boom"))
+	m, _ = update(t, m, CopyDoneMsg{Result: result})
+
+	view := m.View()
+	if !strings.Contains(view, "20241002T130100Z-1a2b3c4d") {
+		t.Fatalf("expected the run id in the completion view")
+	}
+	if !strings.Contains(view, "Copy completed with 1 failed files") {
+		t.Fatalf("expected failure headline in view")
+	}
+	if strings.Contains(view, "Copy completed successfully") {
+		t.Fatalf("did not expect success headline")
+	}
+}
+
+func executingModel(t *testing.T) Model {
+	t.Helper()
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target"})
+	plan := overridePlan()
+	plan.OverrideItems = nil
+	m, _ = update(t, m, PlanReadyMsg{Plan: plan})
+	if m.Phase != PhaseExecuting {
+		t.Fatalf("expected executing phase, got %v", m.Phase)
+	}
+	return m
+}
+
+func TestExecutionShowsBytesOfLongCopy(t *testing.T) {
+	m := executingModel(t)
+	const gib = 1 << 30
+	m, _ = update(t, m, CopyProgressMsg{Current: 0, Total: 2, File: "DSC0123.MP4"})
+
+	// A slow copy reports its bytes while the file count stays put
+	for _, written := range []int64{2 * gib, 6 * gib, 12*gib + 400*(1<<20)} {
+		m, _ = update(t, m, CopyBytesMsg{File: "DSC0123.MP4", Written: written, Size: 40 * gib, BytesDone: written, BytesTotal: 41 * gib})
+	}
+
+	view := m.View()
+	if !strings.Contains(view, "DSC0123.MP4 — 12.4 GiB / 40.0 GiB") {
+		t.Fatalf("expected byte progress of the current file, got:\n%s", view)
+	}
+	if !strings.Contains(view, "0/2 files") {
+		t.Fatalf("expected the file count to be unchanged")
+	}
+
+	// The next file starts without stale bytes from the previous one
+	m, _ = update(t, m, CopyProgressMsg{Current: 1, Total: 2, File: "DSC0124.ARW"})
+	if strings.Contains(m.View(), "GiB /") {
+		t.Fatalf("did not expect byte progress for the new file yet")
+	}
+}
+
+func TestConfirmPagesThroughOverrideItems(t *testing.T) {
+	plan := overridePlan()
+	var items []domain.CopyItem
+	for i := 1; i <= 10; i++ {
+		items = append(items, domain.CopyItem{FileMeta: domain.FileMeta{Name: fmt.Sprintf("DSC%04d.ARW", i), IsRAW: true}})
+	}
+	plan.OverrideItems = items
+
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", OverrideCap: 4})
+	m, _ = update(t, m, PlanReadyMsg{Plan: plan})
+	view := m.View()
+	if !strings.Contains(view, "DSC0004.ARW") || strings.Contains(view, "DSC0005.ARW") || !strings.Contains(view, "... and 6 more") {
+		t.Fatalf("expected the first 4 override items, got:\n%s", view)
+	}
+	if !strings.Contains(view, "PgUp/PgDn") {
+		t.Fatalf("expected the paging hint")
+	}
+
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyPgDown})
+	view = m.View()
+	if !strings.Contains(view, "... 4 above") || !strings.Contains(view, "DSC0008.ARW") || strings.Contains(view, "DSC0004.ARW") || !strings.Contains(view, "... and 2 more") {
+		t.Fatalf("expected the second page, got:\n%s", view)
+	}
+
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyPgDown})
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyPgDown})
+	view = m.View()
+	if !strings.Contains(view, "... 8 above") || !strings.Contains(view, "DSC0010.ARW") || strings.Contains(view, "more") {
+		t.Fatalf("expected paging to stop at the last page, got:\n%s", view)
+	}
+
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyPgUp})
+	if !strings.Contains(m.View(), "... 4 above") {
+		t.Fatalf("expected PgUp to go back a page")
+	}
+}
+
+func TestSkippedJPEGsToggleListsPairings(t *testing.T) {
+	m := confirmModel(t, ConfirmDefaultNo)
+	m.Plan.SkippedJPEGs = 1
+	m.Plan.Pairings = []domain.JPEGPairing{{JPEG: "/card/DCIM/100MSDCF/DSC0042.JPG", RAW: "/card/DCIM/100MSDCF/DSC0042.ARW"}}
+
+	line := "Skipped DSC0042.JPG because its RAW DSC0042.ARW existed"
+	if view := m.View(); strings.Contains(view, line) || !strings.Contains(view, "s to show skipped JPEGs") {
+		t.Fatalf("expected the pairings hidden behind the toggle, got:\n%s", view)
+	}
+	m, _ = update(t, m, keyMsg("s"))
+	if view := m.View(); !strings.Contains(view, line) {
+		t.Fatalf("expected the pairing after s, got:\n%s", view)
+	}
+	m, _ = update(t, m, keyMsg("s"))
+	if strings.Contains(m.View(), line) {
+		t.Fatalf("expected s to hide the pairings again")
+	}
+}
+
+func TestFoldersToggleListsFolderStats(t *testing.T) {
+	m := confirmModel(t, ConfirmDefaultNo)
+	day := time.Date(2024, 4, 1, 10, 0, 0, 0, time.Local)
+	m.Plan.Folders = []domain.FolderStats{
+		{Folder: "DCIM/100MSDCF", Planned: 120, First: day, Last: day},
+		{Folder: "DCIM/101MSDCF", Planned: 3, Skipped: 2, Warnings: 1, First: day, Last: day.AddDate(0, 0, 1)},
+	}
+
+	line := "DCIM/101MSDCF: 3 planned, 2 skipped, 1 warnings, 2024-04-01 to 2024-04-02"
+	if view := m.View(); strings.Contains(view, line) || !strings.Contains(view, "d for folders") {
+		t.Fatalf("expected the folders hidden behind the toggle, got:\n%s", view)
+	}
+	m, _ = update(t, m, keyMsg("d"))
+	if view := m.View(); !strings.Contains(view, line) || !strings.Contains(view, "d to hide folders") {
+		t.Fatalf("expected the folders after d, got:\n%s", view)
+	}
+}
+
+func TestFastPlanOffersFullScan(t *testing.T) {
+	replanned := false
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", DryRun: true, Replan: func() tea.Cmd {
+		replanned = true
+		return nil
+	}})
+	m, _ = update(t, m, PlanReadyMsg{Plan: domain.CopyPlan{ApproximateDates: true}})
+	view := m.View()
+	if !strings.Contains(view, "Files to Copy (approximate dates)") || !strings.Contains(view, "F for full EXIF scan") {
+		t.Fatalf("expected the approximate preview to offer a full scan, got:\n%s", view)
+	}
+
+	m, _ = update(t, m, keyMsg("F"))
+	if m.Phase != PhaseScanning || !replanned {
+		t.Fatalf("expected F to plan again, got %v", m.Phase)
+	}
+	m, _ = update(t, m, PlanReadyMsg{Plan: domain.CopyPlan{}})
+	if view := m.View(); strings.Contains(view, "approximate dates") || strings.Contains(view, "F for full EXIF scan") {
+		t.Fatalf("expected the full plan without the approximate label, got:\n%s", view)
+	}
+}
+
+func TestConfirmShowsCopyEstimate(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target"})
+	m, _ = update(t, m, PlanReadyMsg{Plan: overridePlan(), Estimate: 14 * time.Minute})
+	if view := m.View(); !strings.Contains(view, "Estimated time:") || !strings.Contains(view, "~14 min (estimate)") {
+		t.Fatalf("expected the estimate on the confirm screen, got:\n%s", view)
+	}
+
+	m = NewModel(Config{SourceDir: "/source", TargetDir: "/target"})
+	m, _ = update(t, m, PlanReadyMsg{Plan: overridePlan()})
+	if view := m.View(); strings.Contains(view, "Estimated time:") {
+		t.Fatalf("expected no estimate without a benchmark, got:\n%s", view)
+	}
+}
+
+func TestPreviewCountsTruncatedItemsAndWarnings(t *testing.T) {
+	plan := cardPlan(20)
+	plan.Warnings = []domain.Warning{domain.Warningf(domain.WarningExifMissing, "EXIF not found for DSC0001.JPG, using filesystem time")}
+	plan.TruncatedWarnings, plan.WarningLog = 299000, "/tmp/phopy-warnings-1.log"
+	preview := plan.Preview(10, presentation.SampleIndexes(len(plan.Items), presentation.DefaultPreviewCount))
+
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", DryRun: true, Verbose: true})
+	m, _ = update(t, m, PlanReadyMsg{Plan: preview})
+	view := m.View()
+	if !strings.Contains(view, "... 12 more files in between") {
+		t.Fatalf("expected the truncated items to be counted, got:\n%s", view)
+	}
+	if !strings.Contains(view, "DSC0020.JPG") {
+		t.Fatalf("expected the last file of the full plan in the sample, got:\n%s", view)
+	}
+
+	// A preview without a sample only knows its first items
+	m, _ = update(t, NewModel(Config{SourceDir: "/source", TargetDir: "/target", DryRun: true}), PlanReadyMsg{Plan: plan.Preview(10, nil)})
+	if view := m.View(); !strings.Contains(view, "... 12 more files ...") {
+		t.Fatalf("expected the truncated items to be counted, got:\n%s", view)
+	}
+	if !strings.Contains(view, "and 299,000 more (see /tmp/phopy-warnings-1.log)") {
+		t.Fatalf("expected the truncated warnings to be counted, got:\n%s", view)
+	}
+}
+
+func TestHeaderKeepsScanStatsThroughEveryPhase(t *testing.T) {
+	plan := overridePlan()
+	plan.Scan = domain.ScanStats{Files: 4112, Duration: 38 * time.Second, Warnings: 12}
+	const line = "Scanned 4,112 files in 38s · 12 warnings"
+
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target"})
+	if strings.Contains(m.View(), "Scanned") {
+		t.Fatalf("expected no scan stats while scanning")
+	}
+	m, _ = update(t, m, PlanReadyMsg{Plan: plan})
+	if m.Phase != PhaseConfirm || !strings.Contains(m.View(), line) {
+		t.Fatalf("expected the scan stats on the confirm screen, got:\n%s", m.View())
+	}
+	m, _ = update(t, m, ConfirmMsg{Confirmed: true})
+	if m.Phase != PhaseExecuting || !strings.Contains(m.View(), line) {
+		t.Fatalf("expected the scan stats while copying, got:\n%s", m.View())
+	}
+	m, _ = update(t, m, CopyDoneMsg{})
+	if m.Phase != PhaseDone || !strings.Contains(m.View(), line) {
+		t.Fatalf("expected the scan stats once done, got:\n%s", m.View())
+	}
+
+	m = NewModel(Config{SourceDir: "/source", TargetDir: "/target", DryRun: true})
+	m, _ = update(t, m, PlanReadyMsg{Plan: plan})
+	if !strings.Contains(m.View(), line) {
+		t.Fatalf("expected the scan stats in the preview, got:\n%s", m.View())
+	}
+}
+
+func TestStoppedScanNeedsExplicitConfirmation(t *testing.T) {
+	stops := 0
+	var copied domain.CopyPlan
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target",
+		StopScan: func() tea.Cmd {
+			stops++
+			return nil
+		},
+		ExecuteCopy: func(plan domain.CopyPlan, includeOverrides bool) tea.Cmd {
+			copied = plan
+			return nil
+		},
+	})
+	m, _ = update(t, m, ScanProgressMsg{Current: 3, Total: 10})
+	if !strings.Contains(m.View(), "s to stop") {
+		t.Fatalf("expected the scan to offer a stop, got:\n%s", m.View())
+	}
+	m, _ = update(t, m, keyMsg("s"))
+	m, _ = update(t, m, keyMsg("s"))
+	if stops != 1 || !strings.Contains(m.View(), "planning the 3 files scanned so far") {
+		t.Fatalf("expected one stop while the plan is made, got %d:\n%s", stops, m.View())
+	}
+
+	plan := cardPlan(3)
+	plan.Partial, plan.Unscanned = true, 7
+	m, _ = update(t, m, PlanReadyMsg{Plan: plan})
+	if m.Phase != PhasePartial || !strings.Contains(m.View(), "Partial plan: the scan was stopped, 7 files were not scanned") {
+		t.Fatalf("expected the partial plan to be marked and confirmed, got %v:\n%s", m.Phase, m.View())
+	}
+	m, _ = update(t, m, keyMsg("enter"))
+	if m.Phase != PhasePartial {
+		t.Fatalf("expected Enter alone not to accept a partial plan, got %v", m.Phase)
+	}
+	m, _ = update(t, m, keyMsg("y"))
+	if m.Phase != PhaseExecuting || !copied.Partial || len(copied.Items) != 3 {
+		t.Fatalf("expected y to copy the partial plan, got %v with %+v", m.Phase, copied)
+	}
+
+	// A dry run only marks it
+	m = NewModel(Config{SourceDir: "/source", TargetDir: "/target", DryRun: true})
+	m, _ = update(t, m, PlanReadyMsg{Plan: plan})
+	if m.Phase != PhaseDone || !strings.Contains(m.View(), "Partial plan") {
+		t.Fatalf("expected the dry run to show the partial plan, got %v:\n%s", m.Phase, m.View())
+	}
+}
+
+func TestDiskFullStopsTheCopyWithAResumeHint(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target"})
+	m, _ = update(t, m, PlanReadyMsg{Plan: cardPlan(4)})
+	m.Phase = PhaseExecuting
+
+	full := errors.New("target is full")
+	result := domain.ExecutionResult{Copied: 2, BytesCopied: 2048}
+	m, _ = update(t, m, DiskFullMsg{Result: result, Remaining: 2, RemainingBytes: 3072, Missing: 1024, Err: full})
+	view := m.View()
+	for _, want := range []string{"Target Full", "2 files, 2.0 KiB", "2 files, 3.0 KiB", "1.0 KiB", "run the same command again"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the disk full report:\n%s", want, view)
+		}
+	}
+	if m.Phase != PhaseDiskFull || m.Err != full {
+		t.Fatalf("expected the disk full phase to keep the error, got %v, %v", m.Phase, m.Err)
+	}
+
+	if _, cmd := update(t, m, keyMsg("s")); cmd != nil {
+		t.Fatalf("expected other keys to keep the report")
+	}
+	if _, cmd := update(t, m, keyMsg("enter")); cmd == nil {
+		t.Fatalf("expected Enter to exit")
+	}
+
+	// A target that cannot tell its free space
+	m, _ = update(t, m, DiskFullMsg{Result: result, Remaining: 2, RemainingBytes: 3072, Missing: -1, Err: full})
+	if !strings.Contains(m.View(), "unknown") {
+		t.Fatalf("expected the missing space to be unknown:\n%s", m.View())
+	}
+}
+
+func TestViewFitsAbsurdWindowSizes(t *testing.T) {
+	for _, width := range []int{0, 10, 5000} {
+		t.Run(fmt.Sprint(width), func(t *testing.T) {
+			size := tea.WindowSizeMsg{Width: width, Height: width}
+			views := map[string]Model{}
+
+			m := NewModel(Config{SourceDir: "/source", TargetDir: "/target"})
+			m, _ = update(t, m, size)
+			m, _ = update(t, m, ScanProgressMsg{Current: 1, Total: 3})
+			views["scanning"] = m
+
+			m = confirmModel(t, ConfirmDefaultNo)
+			m, _ = update(t, m, size)
+			views["confirm"] = m
+
+			m = executingModel(t)
+			m, _ = update(t, m, size)
+			m, _ = update(t, m, CopyProgressMsg{Current: 0, Total: 1, File: "DSC0001.ARW"})
+			views["executing"] = m
+
+			m, _ = update(t, m, CopyDoneMsg{Result: domain.ExecutionResult{Copied: 1, RawCopied: 1}})
+			views["done"] = m
+
+			for phase, m := range views {
+				if m.width < copyview.MinWindowWidth || m.width > copyview.MaxWindowWidth || m.height < copyview.MinWindowHeight || m.height > copyview.MaxWindowHeight {
+					t.Fatalf("%s: expected the size to be clamped, got %dx%d", phase, m.width, m.height)
+				}
+				// Long help lines wrap in the terminal; nothing is padded
+				// to the reported width
+				for _, line := range strings.Split(m.View(), "\n") {
+					if w := lipgloss.Width(line); w > copyview.MaxWindowWidth {
+						t.Fatalf("%s: line of %d columns:\n%s", phase, w, line)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPreviewSamplesAcrossThePlan(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", DryRun: true, PreviewCount: 3})
+	m, _ = update(t, m, PlanReadyMsg{Plan: cardPlan(21)})
+	view := m.View()
+	for _, name := range []string{"DSC0001.JPG", "DSC0011.JPG", "DSC0021.JPG", "... 18 more files in between"} {
+		if !strings.Contains(view, name) {
+			t.Fatalf("expected %q in the sample, got:\n%s", name, view)
+		}
+	}
+	if strings.Contains(view, "DSC0002.JPG") || strings.Contains(view, "DSC0020.JPG") {
+		t.Fatalf("expected only the first, the middle and the last file, got:\n%s", view)
+	}
+}
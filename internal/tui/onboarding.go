@@ -0,0 +1,279 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"phopy/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// LayoutPreset is a layout offered by the first-run setup.
+type LayoutPreset struct {
+	Name    string
+	Dir     string
+	Flatten bool
+}
+
+// LayoutPresets are the layouts offered by the first-run setup.
+var LayoutPresets = []LayoutPreset{
+	{Name: "Keep source folders", Dir: ""},
+	{Name: "By date", Dir: "{date}", Flatten: true},
+	{Name: "By year and date", Dir: "{yyyy}/{date}", Flatten: true},
+	{Name: "By year and month", Dir: "{yyyy}/{mm}", Flatten: true},
+}
+
+// OnboardingResult is what the user picked during the first-run setup.
+type OnboardingResult struct {
+	SourceDir   string
+	TargetDir   string
+	Layout      string
+	Flatten     bool
+	SaveProfile bool
+}
+
+// StartScanFunc is called once the first-run setup is complete. It should
+// start planning for the picked paths.
+type StartScanFunc func(result OnboardingResult) tea.Cmd
+
+// OnboardingDoneMsg ends the first-run setup.
+type OnboardingDoneMsg struct {
+	Result OnboardingResult
+}
+
+type onboardingStep int
+
+const (
+	stepSource onboardingStep = iota
+	stepTarget
+	stepLayout
+	stepSave
+)
+
+// onboarding is the state of the first-run setup.
+type onboarding struct {
+	step    onboardingStep
+	volumes []string
+	cursor  int
+	// editing is true while a path is typed into input
+	editing bool
+	input   string
+	result  OnboardingResult
+}
+
+func newOnboarding(volumes []string) onboarding {
+	return onboarding{
+		volumes: volumes,
+		editing: len(volumes) == 0,
+		result:  OnboardingResult{SaveProfile: true},
+	}
+}
+
+func (m Model) updateOnboarding(msg tea.KeyMsg) (Model, tea.Cmd) {
+	o := &m.onboarding
+	key := msg.String()
+
+	if o.editing {
+		switch msg.Type {
+		case tea.KeyRunes, tea.KeySpace:
+			o.input += string(msg.Runes)
+			return m, nil
+		case tea.KeyBackspace:
+			if len(o.input) > 0 {
+				runes := []rune(o.input)
+				o.input = string(runes[:len(runes)-1])
+			}
+			return m, nil
+		}
+	}
+
+	switch key {
+	case "esc":
+		o.back()
+	case "up", "k":
+		if o.cursor > 0 {
+			o.cursor--
+		}
+	case "down", "j":
+		if o.cursor < o.choices()-1 {
+			o.cursor++
+		}
+	case "left", "h", "y", "Y":
+		if o.step == stepSave {
+			o.result.SaveProfile = true
+		}
+	case "right", "l", "n", "N":
+		if o.step == stepSave {
+			o.result.SaveProfile = false
+		}
+	case "enter":
+		return m, o.next(m.config.DefaultTarget)
+	}
+	return m, nil
+}
+
+// choices is the number of entries selectable with up/down in this step.
+func (o *onboarding) choices() int {
+	switch {
+	case o.editing:
+		return 0
+	case o.step == stepSource:
+		return len(o.volumes) + 1 // the last entry asks for a path
+	case o.step == stepLayout:
+		return len(LayoutPresets)
+	default:
+		return 0
+	}
+}
+
+// next accepts the current step and returns the done command after the last.
+func (o *onboarding) next(defaultTarget string) tea.Cmd {
+	switch o.step {
+	case stepSource:
+		if !o.editing && o.cursor == len(o.volumes) {
+			o.editing = true
+			o.input = ""
+			return nil
+		}
+		source := o.input
+		if !o.editing {
+			source = o.volumes[o.cursor]
+		}
+		if strings.TrimSpace(source) == "" {
+			return nil
+		}
+		o.result.SourceDir = expandHome(strings.TrimSpace(source))
+		o.step = stepTarget
+		o.editing = true
+		o.input = defaultTarget
+	case stepTarget:
+		if strings.TrimSpace(o.input) == "" {
+			return nil
+		}
+		o.result.TargetDir = expandHome(strings.TrimSpace(o.input))
+		o.step = stepLayout
+		o.editing = false
+		o.cursor = 0
+	case stepLayout:
+		o.result.Layout = LayoutPresets[o.cursor].Dir
+		o.result.Flatten = LayoutPresets[o.cursor].Flatten
+		o.step = stepSave
+	case stepSave:
+		result := o.result
+		return func() tea.Msg {
+			return OnboardingDoneMsg{Result: result}
+		}
+	}
+	return nil
+}
+
+// back returns to the previous step, keeping what was picked there.
+func (o *onboarding) back() {
+	switch o.step {
+	case stepSource:
+		if o.editing && len(o.volumes) > 0 {
+			o.editing = false
+		}
+	case stepTarget:
+		o.step = stepSource
+		o.editing = len(o.volumes) == 0
+		o.input = o.result.SourceDir
+		o.cursor = 0
+	case stepLayout:
+		o.step = stepTarget
+		o.editing = true
+		o.input = o.result.TargetDir
+	case stepSave:
+		o.step = stepLayout
+	}
+}
+
+func (m Model) renderOnboarding() string {
+	o := m.onboarding
+	var b strings.Builder
+	dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
+
+	switch o.step {
+	case stepSource:
+		b.WriteString(sectionStyle.Render("Welcome! Where are your photos?"))
+		b.WriteString("\n\n")
+		if !o.editing {
+			for i, volume := range o.volumes {
+				b.WriteString(renderChoice(i == o.cursor, fmt.Sprintf("%s %s", iconFolder, shortenPath(volume))))
+			}
+			b.WriteString(renderChoice(o.cursor == len(o.volumes), "Enter a path..."))
+		} else {
+			if len(o.volumes) == 0 {
+				b.WriteString(dimStyle.Render("  No memory card detected."))
+				b.WriteString("\n\n")
+			}
+			b.WriteString(renderInput("Source", o.input))
+		}
+	case stepTarget:
+		b.WriteString(sectionStyle.Render("Where should they be copied to?"))
+		b.WriteString("\n\n")
+		b.WriteString(renderInput("Target", o.input))
+		if _, err := os.Stat(o.input); o.input != "" && err != nil {
+			b.WriteString(dimStyle.Render("  The directory will be created."))
+			b.WriteString("\n")
+		}
+	case stepLayout:
+		b.WriteString(sectionStyle.Render("How should the target be organized?"))
+		b.WriteString("\n\n")
+		for i, preset := range LayoutPresets {
+			b.WriteString(renderChoice(i == o.cursor, preset.Name))
+		}
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  %s %s", iconArrow, layoutPreview(o.result.TargetDir, LayoutPresets[o.cursor]))))
+		b.WriteString("\n")
+	case stepSave:
+		b.WriteString(sectionStyle.Render("Save as your default?"))
+		b.WriteString("\n\n")
+		b.WriteString(dimStyle.Render("  Later runs without --source and --target will start scanning right away."))
+		b.WriteString("\n\n")
+		yesBtn, noBtn := boxStyle.Render(" Yes "), boxStyle.Render(" No ")
+		if o.result.SaveProfile {
+			yesBtn = highlightBoxStyle.Copy().Background(lipgloss.Color("#2D5A27")).Render(" Yes ")
+		} else {
+			noBtn = highlightBoxStyle.Copy().Background(lipgloss.Color("#5A2727")).Render(" No ")
+		}
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, yesBtn, "  ", noBtn))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func renderChoice(selected bool, label string) string {
+	if selected {
+		return fmt.Sprintf("  %s %s\n", statValueStyle.Render(iconArrow), statValueStyle.Render(label))
+	}
+	return fmt.Sprintf("    %s\n", label)
+}
+
+func renderInput(label, value string) string {
+	return fmt.Sprintf("  %s  %s%s\n", statLabelStyle.Render(label+":"), fileNameStyle.Render(value), statValueStyle.Render("█"))
+}
+
+// layoutPreview shows where a typical card file would land with preset.
+func layoutPreview(targetDir string, preset LayoutPreset) string {
+	sample := domain.NewFileMeta("DCIM/100MSDCF/DSC01234.ARW", filepath.Join("DCIM", "100MSDCF", "DSC01234.ARW"), time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local))
+	return shortenPath(filepath.Join(targetDir, domain.Layout{Dir: preset.Dir, Flatten: preset.Flatten}.TargetRel(sample)))
+}
+
+// expandHome resolves a leading ~ to the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
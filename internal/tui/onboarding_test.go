@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func typeText(t *testing.T, m Model, text string) Model {
+	t.Helper()
+	for _, r := range text {
+		m, _ = update(t, m, keyMsg(string(r)))
+	}
+	return m
+}
+
+func finishOnboarding(t *testing.T, m Model) OnboardingDoneMsg {
+	t.Helper()
+	_, cmd := update(t, m, keyMsg("enter"))
+	if cmd == nil {
+		t.Fatalf("expected the last step to finish the setup")
+	}
+	msg, ok := cmd().(OnboardingDoneMsg)
+	if !ok {
+		t.Fatalf("expected OnboardingDoneMsg")
+	}
+	return msg
+}
+
+func TestOnboardingHappyPathWithDetectedVolume(t *testing.T) {
+	m := NewModel(Config{
+		Onboarding:    true,
+		Volumes:       []string{"/Volumes/SD_CARD", "/Volumes/BACKUP"},
+		DefaultTarget: "/photos",
+	})
+	if m.Phase != PhaseOnboarding {
+		t.Fatalf("expected onboarding phase, got %v", m.Phase)
+	}
+	if !strings.Contains(m.View(), "SD_CARD") {
+		t.Fatalf("expected detected volumes in view")
+	}
+
+	// Pick the second volume, keep the default target
+	m, _ = update(t, m, keyMsg("j"))
+	m, _ = update(t, m, keyMsg("enter"))
+	m, _ = update(t, m, keyMsg("enter"))
+
+	// Pick the year and date layout; the preview shows where files land
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	if !strings.Contains(m.View(), "/photos/2024/2024-10-02/DSC01234.ARW") {
+		t.Fatalf("expected layout preview in view, got:\n%s", m.View())
+	}
+	m, _ = update(t, m, keyMsg("enter"))
+
+	// Decline saving the profile
+	m, _ = update(t, m, keyMsg("n"))
+	msg := finishOnboarding(t, m)
+
+	want := OnboardingResult{SourceDir: "/Volumes/BACKUP", TargetDir: "/photos", Layout: "{yyyy}/{date}", Flatten: true}
+	if msg.Result != want {
+		t.Fatalf("unexpected result %+v, want %+v", msg.Result, want)
+	}
+}
+
+func TestOnboardingTypesPathsWithoutVolumes(t *testing.T) {
+	var started OnboardingResult
+	m := NewModel(Config{
+		Onboarding: true,
+		StartScan: func(result OnboardingResult) tea.Cmd {
+			started = result
+			return nil
+		},
+	})
+
+	// Without volumes the source is typed right away; q must not quit here
+	m = typeText(t, m, "/mnt/quick")
+	m, _ = update(t, m, keyMsg("enter"))
+	m = typeText(t, m, "/archive")
+	m, _ = update(t, m, keyMsg("enter"))
+	m, _ = update(t, m, keyMsg("enter"))
+	msg := finishOnboarding(t, m)
+
+	if msg.Result.SourceDir != "/mnt/quick" || msg.Result.TargetDir != "/archive" {
+		t.Fatalf("unexpected paths %+v", msg.Result)
+	}
+	if !msg.Result.SaveProfile {
+		t.Fatalf("expected saving the profile to be the default")
+	}
+
+	m, _ = update(t, m, msg)
+	if m.Phase != PhaseScanning {
+		t.Fatalf("expected scanning after the setup, got %v", m.Phase)
+	}
+	if started.SourceDir != "/mnt/quick" {
+		t.Fatalf("expected StartScan to receive the picked paths, got %+v", started)
+	}
+	if !strings.Contains(m.View(), "/archive") {
+		t.Fatalf("expected the picked target in the header")
+	}
+}
+
+func TestOnboardingEscGoesBack(t *testing.T) {
+	m := NewModel(Config{Onboarding: true, Volumes: []string{"/Volumes/SD_CARD"}, DefaultTarget: "/photos"})
+	m, _ = update(t, m, keyMsg("enter"))
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.onboarding.step != stepSource {
+		t.Fatalf("expected to be back at the source step, got %v", m.onboarding.step)
+	}
+}
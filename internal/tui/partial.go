@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"phopy/internal/format"
+)
+
+// StopScanFunc is called when the user stops the scan early. It should
+// stop the EXIF workers; the plan of the files inspected so far arrives as
+// a PlanReadyMsg marked Partial.
+type StopScanFunc func() tea.Cmd
+
+// stopScan asks the planner to stop, once.
+func (m Model) stopScan() (Model, tea.Cmd) {
+	if m.config.StopScan == nil || m.scanStopping {
+		return m, nil
+	}
+	m.scanStopping = true
+	return m, m.config.StopScan()
+}
+
+// updatePartial waits for an explicit answer before a partial plan is
+// copied; Enter alone does not accept it.
+func (m Model) updatePartial(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.partialAccepted = true
+		return m.proceed()
+	case "n", "N", "q", "esc", "ctrl+c":
+		m.Quitting = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// renderPartialBanner marks the preview of a partial plan.
+func (m Model) renderPartialBanner() string {
+	text := fmt.Sprintf("%s Partial plan: the scan was stopped, %s files were not scanned and are left out", iconOverride, format.Count(m.Plan.Unscanned))
+	return highlightBoxStyle.Copy().BorderForeground(warningColor).Render(warningStyle.Render(text))
+}
+
+func (m Model) renderPartialPrompt() string {
+	var b strings.Builder
+	b.WriteString(warningStyle.Render(iconOverride + " Copy only the files scanned so far?"))
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(dimTextColor).Render("  Files the scan did not reach are not copied, even when they are in the date range."))
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PanicError is a panic of the model that Recovering caught, with the stack
+// of where it happened.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("the interface crashed: %v", e.Value)
+}
+
+// Recovering wraps a model so a panic in its Update or View ends the
+// program instead of the process. Bubble Tea catches panics too, but loses
+// the model; Recovering keeps the last one that did not panic, so the run
+// can continue without the TUI.
+type Recovering struct {
+	Model tea.Model
+	// Panic is the first panic caught, nil while the model works.
+	Panic *PanicError
+	// Quit ends the program after a panic in View, which cannot return a
+	// command. It must not block, e.g. go p.Quit().
+	Quit func()
+}
+
+// Recover wraps model, see Recovering.
+func Recover(model tea.Model) *Recovering {
+	return &Recovering{Model: model}
+}
+
+func (r *Recovering) Init() tea.Cmd {
+	return r.Model.Init()
+}
+
+func (r *Recovering) Update(msg tea.Msg) (next tea.Model, cmd tea.Cmd) {
+	if r.Panic != nil {
+		return r, tea.Quit
+	}
+	defer func() {
+		if value := recover(); value != nil {
+			r.caught(value)
+			next, cmd = r, tea.Quit
+		}
+	}()
+	model, cmd := r.Model.Update(msg)
+	r.Model = model
+	return r, cmd
+}
+
+func (r *Recovering) View() (view string) {
+	if r.Panic != nil {
+		return ""
+	}
+	defer func() {
+		if value := recover(); value != nil {
+			r.caught(value)
+			if r.Quit != nil {
+				r.Quit()
+			}
+			view = ""
+		}
+	}()
+	return r.Model.View()
+}
+
+func (r *Recovering) caught(value any) {
+	r.Panic = &PanicError{Value: value, Stack: debug.Stack()}
+}
@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"phopy/tui/copyview"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// panickingModel panics in View once it is as narrow as it gets, like a
+// layout bug that only exotic window sizes trigger, and in Update on
+// explodeMsg.
+type panickingModel struct {
+	Model
+}
+
+type explodeMsg struct{}
+
+func (m panickingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(explodeMsg); ok {
+		panic("index out of range")
+	}
+	next, cmd := m.Model.Update(msg)
+	return panickingModel{next.(Model)}, cmd
+}
+
+func (m panickingModel) View() string {
+	if m.width == copyview.MinWindowWidth {
+		panic("negative repeat count")
+	}
+	return m.Model.View()
+}
+
+func TestRecoveringEndsTheProgramOnAPanicInView(t *testing.T) {
+	model := NewModel(Config{SourceDir: "/source", TargetDir: "/target", DryRun: true})
+	model, _ = update(t, model, PlanReadyMsg{Plan: overridePlan()})
+	guard := Recover(panickingModel{model})
+
+	var out bytes.Buffer
+	p := tea.NewProgram(guard, tea.WithInput(nil), tea.WithOutput(&out), tea.WithoutSignalHandler())
+	guard.Quit = func() { go p.Quit() }
+	go p.Send(tea.WindowSizeMsg{Width: 1, Height: 24})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Run()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected the program to quit cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the program to end after the panic")
+	}
+
+	if guard.Panic == nil || !strings.Contains(guard.Panic.Error(), "negative repeat count") || len(guard.Panic.Stack) == 0 {
+		t.Fatalf("expected the panic with its stack, got %+v", guard.Panic)
+	}
+	last := guard.Model.(panickingModel)
+	if last.Phase != PhaseDone || len(last.Plan.Items) != 1 {
+		t.Fatalf("expected the model to keep the plan, got phase %v", last.Phase)
+	}
+}
+
+func TestRecoveringEndsTheProgramOnAPanicInUpdate(t *testing.T) {
+	guard := Recover(panickingModel{NewModel(Config{SourceDir: "/source", TargetDir: "/target"})})
+	next, cmd := guard.Update(explodeMsg{})
+	if next != guard || cmd == nil {
+		t.Fatalf("expected the guard to quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok || guard.Panic == nil {
+		t.Fatalf("expected a quit after the panic, got %+v", guard.Panic)
+	}
+	if guard.View() != "" {
+		t.Fatalf("expected no view after a panic")
+	}
+}
@@ -0,0 +1,267 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"phopy/internal/domain"
+	"phopy/internal/presentation"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// reviewSamples is how many warning texts the review shows per category.
+const reviewSamples = 2
+
+// warningReview is the state of the warnings review. The chosen actions
+// are applied to base, the plan before any review, whenever the review is
+// left, so they can be changed until the copy starts.
+type warningReview struct {
+	base   domain.CopyPlan
+	chosen map[string]domain.WarningAction
+	cursor int
+	// back is the phase the review was opened from. Leaving a review
+	// opened after the copy or in a dry run returns there; otherwise the
+	// copy goes ahead.
+	back Phase
+}
+
+// reviewCategory is the warnings of one code.
+type reviewCategory struct {
+	code     string
+	warnings []domain.Warning
+	files    int
+	actions  []domain.WarningAction
+}
+
+// reviewCategories groups warnings by code in the order the codes first
+// occur.
+func reviewCategories(warnings []domain.Warning) []reviewCategory {
+	var categories []reviewCategory
+	index := make(map[string]int)
+	files := make(map[string]map[string]bool)
+	for _, warning := range warnings {
+		i, ok := index[warning.Code]
+		if !ok {
+			i = len(categories)
+			index[warning.Code] = i
+			categories = append(categories, reviewCategory{code: warning.Code})
+			files[warning.Code] = make(map[string]bool)
+		}
+		c := &categories[i]
+		c.warnings = append(c.warnings, warning)
+		for _, path := range warning.Paths {
+			files[warning.Code][path] = true
+		}
+		for _, action := range warning.Actions {
+			if !c.allows(action) {
+				c.actions = append(c.actions, action)
+			}
+		}
+	}
+	for i := range categories {
+		categories[i].files = len(files[categories[i].code])
+	}
+	return categories
+}
+
+func (c reviewCategory) allows(action domain.WarningAction) bool {
+	for _, allowed := range c.actions {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+// hasActionableWarnings reports whether the review can act on any of
+// warnings.
+func hasActionableWarnings(warnings []domain.Warning) bool {
+	for _, warning := range warnings {
+		if len(warning.Actions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// openReview shows the warnings review, which returns to back when left.
+func (m Model) openReview(back Phase) Model {
+	if m.review.chosen == nil {
+		m.review = warningReview{base: m.Plan, chosen: make(map[string]domain.WarningAction)}
+	}
+	m.review.back = back
+	m.Phase = PhaseReview
+	return m
+}
+
+// canAct reports whether actions chosen in the review still change what
+// is copied.
+func (r warningReview) canAct(dryRun bool) bool {
+	return !dryRun && r.back != PhaseDone
+}
+
+// decisions returns the chosen action of every actionable category,
+// accepting those without a choice.
+func (r warningReview) decisions() []domain.ReviewDecision {
+	var decisions []domain.ReviewDecision
+	for _, c := range reviewCategories(r.base.ReviewableWarnings()) {
+		if len(c.actions) == 0 {
+			continue
+		}
+		action, ok := r.chosen[c.code]
+		if !ok {
+			action = domain.ActionAccept
+		}
+		decisions = append(decisions, domain.ReviewDecision{Code: c.code, Action: action})
+	}
+	return decisions
+}
+
+func (m Model) updateReview(msg tea.KeyMsg) (Model, tea.Cmd) {
+	categories := reviewCategories(m.review.base.ReviewableWarnings())
+	r := &m.review
+	action := domain.WarningAction("")
+	switch msg.String() {
+	case "ctrl+c", "q":
+		m.Quitting = true
+		return m, tea.Quit
+	case "up", "k":
+		r.cursor = max(r.cursor-1, 0)
+	case "down", "j":
+		r.cursor = min(r.cursor+1, len(categories)-1)
+	case "a":
+		action = domain.ActionAccept
+	case "x":
+		action = domain.ActionExclude
+	case "u":
+		action = domain.ActionQuarantine
+	case "enter", "w":
+		return m.closeReview()
+	}
+	if action != "" && r.canAct(m.config.DryRun) && r.cursor < len(categories) {
+		c := categories[r.cursor]
+		if action == domain.ActionAccept || c.allows(action) {
+			r.chosen[c.code] = action
+		}
+	}
+	return m, nil
+}
+
+// closeReview leaves the review. Unless it was only for reading, the
+// plan becomes base with the chosen actions applied and the copy goes
+// ahead.
+func (m Model) closeReview() (Model, tea.Cmd) {
+	if !m.review.canAct(m.config.DryRun) {
+		m.Phase = m.review.back
+		return m, nil
+	}
+	plan, err := m.review.base.Review(m.config.TargetDir, m.review.decisions(), m.config.TargetExists)
+	if err != nil {
+		m.Phase, m.Err = PhaseError, err
+		return m, nil
+	}
+	m.Plan = plan
+	return m.proceed()
+}
+
+// actionLabel describes what happens to the files of a category.
+func actionLabel(action domain.WarningAction) string {
+	switch action {
+	case domain.ActionExclude:
+		return "exclude from this run"
+	case domain.ActionQuarantine:
+		return "copy to " + domain.QuarantineDir
+	default:
+		return "accept and copy as planned"
+	}
+}
+
+// actionKeys lists the keys of the actions c allows besides accepting.
+func actionKeys(c reviewCategory) string {
+	keys := []string{"a accept"}
+	for _, action := range c.actions {
+		switch action {
+		case domain.ActionExclude:
+			keys = append(keys, "x exclude")
+		case domain.ActionQuarantine:
+			keys = append(keys, "u "+domain.QuarantineDir)
+		}
+	}
+	return strings.Join(keys, " • ")
+}
+
+func describeWarningCode(code string) string {
+	for _, known := range domain.WarningCodes {
+		if known.Code == code {
+			return known.Description
+		}
+	}
+	return ""
+}
+
+func (m Model) renderReview() string {
+	var b strings.Builder
+	dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
+	canAct := m.review.canAct(m.config.DryRun)
+
+	b.WriteString(sectionStyle.Render("Review Warnings"))
+	b.WriteString("\n\n")
+	categories := reviewCategories(m.review.base.ReviewableWarnings())
+	if len(categories) == 0 {
+		b.WriteString(dimStyle.Render("  No warnings"))
+		b.WriteString("\n")
+	}
+	for i, c := range categories {
+		cursor := "  "
+		if i == m.review.cursor {
+			cursor = "› "
+		}
+		count := fmt.Sprintf("%d warnings", len(c.warnings))
+		if c.files > 0 {
+			count += fmt.Sprintf(", %d files", c.files)
+		}
+		b.WriteString(fmt.Sprintf("%s%s %s  %s\n", cursor, warningStyle.Render(iconOverride), fileNameStyle.Render(c.code), dimStyle.Render(count)))
+		if description := describeWarningCode(c.code); description != "" {
+			b.WriteString("    " + dimStyle.Render(description) + "\n")
+		}
+		for j, warning := range c.warnings {
+			if j == reviewSamples {
+				b.WriteString("    " + dimStyle.Render(presentation.MoreLine(len(c.warnings)-j)) + "\n")
+				break
+			}
+			b.WriteString("    " + warning.Text + "\n")
+		}
+		if len(c.actions) > 0 && canAct {
+			action, ok := m.review.chosen[c.code]
+			if !ok {
+				action = domain.ActionAccept
+			}
+			b.WriteString(fmt.Sprintf("    %s %s", iconArrow, statValueStyle.Render(actionLabel(action))))
+			if i == m.review.cursor {
+				b.WriteString("  " + dimStyle.Render("("+actionKeys(c)+")"))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	if m.review.base.TruncatedWarnings > 0 {
+		b.WriteString(dimStyle.Render("  "+presentation.TruncatedLine(m.review.base.TruncatedWarnings, m.review.base.WarningLog)+"; actions only apply to the listed ones") + "\n")
+	}
+	return b.String()
+}
+
+func (m Model) reviewHelp() string {
+	if !m.review.canAct(m.config.DryRun) {
+		return "↑ ↓ to select • w or Enter to go back • q to quit"
+	}
+	return "↑ ↓ to select • a/x/u to choose • Enter to continue • q to quit"
+}
+
+func (m Model) warningsHelp() string {
+	if len(m.Plan.Warnings) == 0 && len(m.review.base.Warnings) == 0 {
+		return ""
+	}
+	return " • w to review warnings"
+}
@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func warnedPlan() domain.CopyPlan {
+	taken := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	item := func(name string) domain.CopyItem {
+		meta := domain.NewFileMeta(filepath.Join("/source", name), name, taken)
+		return domain.CopyItem{FileMeta: meta, TargetPath: filepath.Join("/target", "2024-10-02", name)}
+	}
+	noExif, stuck, fine := item("DSC0001.ARW"), item("DSC0002.ARW"), item("DSC0003.ARW")
+	return domain.CopyPlan{
+		Items:    []domain.CopyItem{noExif, stuck, fine},
+		RawCount: 3,
+		Warnings: []domain.Warning{
+			domain.Warningf(domain.WarningExifMissing, "EXIF not found for DSC0001.ARW").About(domain.ExiflessActions, noExif.FileMeta.SourcePath),
+			domain.Warningf(domain.WarningSameSecond, "1 files share the capture time").About(domain.SuspectDateActions, stuck.FileMeta.SourcePath),
+		},
+	}
+}
+
+// reviewModel returns a model in the review of warnedPlan and the plans
+// its copy is started with.
+func reviewModel(t *testing.T) (Model, *[]domain.CopyPlan) {
+	t.Helper()
+	var executed []domain.CopyPlan
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", ExecuteCopy: func(plan domain.CopyPlan, _ bool) tea.Cmd {
+		executed = append(executed, plan)
+		return nil
+	}})
+	m, _ = update(t, m, PlanReadyMsg{Plan: warnedPlan()})
+	if m.Phase != PhaseReview {
+		t.Fatalf("expected review phase, got %v", m.Phase)
+	}
+	view := m.View()
+	for _, want := range []string{domain.WarningExifMissing, domain.WarningSameSecond, "EXIF not found for DSC0001.ARW", "accept and copy as planned"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in review, got:\n%s", want, view)
+		}
+	}
+	return m, &executed
+}
+
+func TestReviewAcceptCopiesAsPlanned(t *testing.T) {
+	m, executed := reviewModel(t)
+	m, _ = update(t, m, keyMsg("a"))
+	m, _ = update(t, m, keyMsg("enter"))
+	if m.Phase != PhaseExecuting || len(*executed) != 1 {
+		t.Fatalf("expected the copy to start, got %v", m.Phase)
+	}
+	plan := (*executed)[0]
+	if len(plan.Items) != 3 || len(plan.Leftovers) != 0 {
+		t.Fatalf("expected all items copied, got %d items and %d leftovers", len(plan.Items), len(plan.Leftovers))
+	}
+	want := []domain.ReviewDecision{
+		{Code: domain.WarningExifMissing, Action: domain.ActionAccept, Files: 1},
+		{Code: domain.WarningSameSecond, Action: domain.ActionAccept, Files: 1},
+	}
+	if len(plan.Reviewed) != len(want) || plan.Reviewed[0] != want[0] || plan.Reviewed[1] != want[1] {
+		t.Fatalf("expected decisions %v, got %v", want, plan.Reviewed)
+	}
+}
+
+func TestReviewExcludeLeavesSuspectDatesOut(t *testing.T) {
+	m, executed := reviewModel(t)
+	m, _ = update(t, m, keyMsg("j"))
+	m, _ = update(t, m, keyMsg("x"))
+	if view := m.View(); !strings.Contains(view, "exclude from this run") {
+		t.Fatalf("expected the chosen action in review, got:\n%s", view)
+	}
+	m, _ = update(t, m, keyMsg("enter"))
+	if m.Phase != PhaseExecuting || len(*executed) != 1 {
+		t.Fatalf("expected the copy to start, got %v", m.Phase)
+	}
+	plan := (*executed)[0]
+	if len(plan.Items) != 2 || plan.Items[1].FileMeta.Name != "DSC0003.ARW" {
+		t.Fatalf("expected DSC0002.ARW excluded, got %v", plan.Items)
+	}
+	if len(plan.Leftovers) != 1 || plan.Leftovers[0].Reason != domain.LeftoverExcludedInReview {
+		t.Fatalf("expected DSC0002.ARW as leftover, got %v", plan.Leftovers)
+	}
+}
+
+func TestReviewQuarantineMovesExiflessFilesToUnsorted(t *testing.T) {
+	m, executed := reviewModel(t)
+	m, _ = update(t, m, keyMsg("u"))
+	m, _ = update(t, m, keyMsg("w"))
+	if m.Phase != PhaseExecuting || len(*executed) != 1 {
+		t.Fatalf("expected the copy to start, got %v", m.Phase)
+	}
+	plan := (*executed)[0]
+	want := filepath.Join("/target", domain.QuarantineDir, "DSC0001.ARW")
+	if got := plan.Items[0].TargetPath; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if got := plan.Items[1].TargetPath; got != filepath.Join("/target", "2024-10-02", "DSC0002.ARW") {
+		t.Fatalf("expected DSC0002.ARW untouched, got %s", got)
+	}
+}
+
+func TestReviewAfterDryRunOnlyReads(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", DryRun: true})
+	m, _ = update(t, m, PlanReadyMsg{Plan: warnedPlan()})
+	if m.Phase != PhaseDone {
+		t.Fatalf("expected done phase, got %v", m.Phase)
+	}
+	m, _ = update(t, m, keyMsg("w"))
+	if m.Phase != PhaseReview {
+		t.Fatalf("expected w to open the review, got %v", m.Phase)
+	}
+	m, _ = update(t, m, keyMsg("x"))
+	m, _ = update(t, m, keyMsg("w"))
+	if m.Phase != PhaseDone || len(m.Plan.Items) != 3 || len(m.Plan.Reviewed) != 0 {
+		t.Fatalf("expected the plan unchanged, got %v with %d items", m.Phase, len(m.Plan.Items))
+	}
+}
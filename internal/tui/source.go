@@ -0,0 +1,39 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ContinueFunc is called once the source warning is accepted. It should
+// start planning.
+type ContinueFunc func() tea.Cmd
+
+func (m Model) updateSourceWarning(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.Phase = PhaseScanning
+		cmds := []tea.Cmd{m.copyView.Init()}
+		if m.config.Continue != nil {
+			cmds = append(cmds, m.config.Continue())
+		}
+		return m, tea.Batch(cmds...)
+	case "n", "N", "q", "esc", "ctrl+c":
+		m.Quitting = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m Model) renderSourceWarning() string {
+	var b strings.Builder
+	b.WriteString(warningStyle.Render(iconOverride + " Source looks like an organized archive — continue?"))
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(dimTextColor).Render("  " + m.config.SourceWarning + "."))
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(dimTextColor).Render("  phopy is meant to import from camera cards; scanning an archive copies it again."))
+	b.WriteString("\n")
+	return b.String()
+}
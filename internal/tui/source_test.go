@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSourceWarningWaitsForConfirmation(t *testing.T) {
+	started := false
+	m := NewModel(Config{
+		SourceDir:     "/archive",
+		TargetDir:     "/target",
+		SourceWarning: "5 of 5 folders are named like dates (2020, 2021, 2022, ...) and there is no DCIM folder",
+		Continue: func() tea.Cmd {
+			started = true
+			return nil
+		},
+	})
+	if m.Phase != PhaseSourceWarning {
+		t.Fatalf("expected source warning phase, got %v", m.Phase)
+	}
+	if view := m.View(); !strings.Contains(view, "Source looks like an organized archive — continue?") || !strings.Contains(view, "no DCIM folder") {
+		t.Fatalf("expected the warning in view, got:\n%s", view)
+	}
+
+	m, _ = update(t, m, keyMsg("enter"))
+	if m.Phase != PhaseSourceWarning || started {
+		t.Fatalf("expected Enter to leave the warning open")
+	}
+	m, _ = update(t, m, keyMsg("y"))
+	if m.Phase != PhaseScanning || !started {
+		t.Fatalf("expected scanning to start after y, got %v", m.Phase)
+	}
+}
+
+func TestSourceWarningDeclineQuits(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/archive", TargetDir: "/target", SourceWarning: "2 of 2 folders are named like dates"})
+	m, cmd := update(t, m, keyMsg("n"))
+	if !m.Quitting || cmd == nil {
+		t.Fatalf("expected n to quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatalf("expected a quit command")
+	}
+}
+
+func TestSourceWarningFollowsLabelPrompt(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/archive", TargetDir: "/target", AskLabel: true, SourceWarning: "2 of 2 folders are named like dates"})
+	if m.Phase != PhaseLabel {
+		t.Fatalf("expected the label prompt first, got %v", m.Phase)
+	}
+	m, _ = update(t, m, keyMsg("enter"))
+	if m.Phase != PhaseSourceWarning {
+		t.Fatalf("expected the source warning after the label, got %v", m.Phase)
+	}
+}
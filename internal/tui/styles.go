@@ -1,17 +1,31 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"phopy/internal/tui/theme"
+)
 
 var (
-	// Color palette - warm, photography-inspired
-	primaryColor   = lipgloss.Color("#E8A87C") // warm orange
-	secondaryColor = lipgloss.Color("#85DCB0") // mint green
-	accentColor    = lipgloss.Color("#C38D9E") // dusty rose
-	warningColor   = lipgloss.Color("#F6AE2D") // amber warning
-	errorColor     = lipgloss.Color("#E85D75") // soft red
-	mutedColor     = lipgloss.Color("#6B7280") // gray
-	textColor      = lipgloss.Color("#F3F4F6") // light text
-	dimTextColor   = lipgloss.Color("#9CA3AF") // dim text
+	// The palette and the styles shared with the copy view
+	primaryColor   = theme.PrimaryColor
+	secondaryColor = theme.SecondaryColor
+	accentColor    = theme.AccentColor
+	warningColor   = theme.WarningColor
+	errorColor     = theme.ErrorColor
+	mutedColor     = theme.MutedColor
+	textColor      = theme.TextColor
+	dimTextColor   = theme.DimTextColor
+
+	sectionStyle   = theme.SectionStyle
+	fileNameStyle  = theme.FileNameStyle
+	rawFileStyle   = theme.RawFileStyle
+	jpegFileStyle  = theme.JpegFileStyle
+	dateStyle      = theme.DateStyle
+	warningStyle   = theme.WarningStyle
+	errorStyle     = theme.ErrorStyle
+	statLabelStyle = theme.StatLabelStyle
+	statValueStyle = theme.StatValueStyle
 
 	// Base styles
 	baseStyle = lipgloss.NewStyle()
@@ -26,47 +40,10 @@ var (
 			Foreground(dimTextColor).
 			Italic(true)
 
-	// Section header
-	sectionStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(secondaryColor).
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderBottom(true).
-			BorderForeground(mutedColor).
-			MarginTop(1).
-			MarginBottom(1).
-			PaddingBottom(0)
-
-	// File display styles
-	fileNameStyle = lipgloss.NewStyle().
-			Foreground(textColor)
-
-	rawFileStyle = lipgloss.NewStyle().
-			Foreground(primaryColor).
-			Bold(true)
-
-	jpegFileStyle = lipgloss.NewStyle().
-			Foreground(secondaryColor)
-
-	dateStyle = lipgloss.NewStyle().
-			Foreground(dimTextColor)
-
 	pathStyle = lipgloss.NewStyle().
 			Foreground(mutedColor).
 			Italic(true)
 
-	// Status indicators
-	successStyle = lipgloss.NewStyle().
-			Foreground(secondaryColor).
-			Bold(true)
-
-	warningStyle = lipgloss.NewStyle().
-			Foreground(warningColor)
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(errorColor).
-			Bold(true)
-
 	// Box styles for sections
 	boxStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
@@ -80,15 +57,6 @@ var (
 				Padding(1, 2).
 				MarginTop(1)
 
-	// Summary stat styles
-	statLabelStyle = lipgloss.NewStyle().
-			Foreground(dimTextColor).
-			Width(20)
-
-	statValueStyle = lipgloss.NewStyle().
-			Foreground(textColor).
-			Bold(true)
-
 	// Progress bar styling
 	progressStyle = lipgloss.NewStyle().
 			Foreground(primaryColor)
@@ -111,10 +79,6 @@ var (
 	overrideStyle = lipgloss.NewStyle().
 			Foreground(warningColor)
 
-	// Spinner style
-	spinnerStyle = lipgloss.NewStyle().
-			Foreground(primaryColor)
-
 	// Help text
 	helpStyle = lipgloss.NewStyle().
 			Foreground(mutedColor).
@@ -123,12 +87,14 @@ var (
 
 	// Icon characters
 	iconCopy     = "📷"
-	iconRAW      = "◆"
-	iconJPEG     = "◇"
-	iconSkipped  = "○"
-	iconOverride = "⚠"
-	iconSuccess  = "✓"
-	iconError    = "✗"
-	iconArrow    = "→"
+	iconRAW      = theme.IconRAW
+	iconJPEG     = theme.IconJPEG
+	iconSkipped  = theme.IconSkipped
+	iconOverride = theme.IconOverride
+	iconSuccess  = theme.IconSuccess
+	iconError    = theme.IconError
+	iconArrow    = theme.IconArrow
 	iconFolder   = "📁"
+	iconLabel    = "🏷"
+	iconScan     = "🔍"
 )
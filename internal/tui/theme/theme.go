@@ -0,0 +1,76 @@
+// Package theme holds the palette, styles and icons that the phopy TUI
+// shares with the copy view of tui/copyview.
+package theme
+
+import "github.com/charmbracelet/lipgloss"
+
+var (
+	// Color palette - warm, photography-inspired
+	PrimaryColor   = lipgloss.Color("#E8A87C") // warm orange
+	SecondaryColor = lipgloss.Color("#85DCB0") // mint green
+	AccentColor    = lipgloss.Color("#C38D9E") // dusty rose
+	WarningColor   = lipgloss.Color("#F6AE2D") // amber warning
+	ErrorColor     = lipgloss.Color("#E85D75") // soft red
+	MutedColor     = lipgloss.Color("#6B7280") // gray
+	TextColor      = lipgloss.Color("#F3F4F6") // light text
+	DimTextColor   = lipgloss.Color("#9CA3AF") // dim text
+
+	// Section header
+	SectionStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(SecondaryColor).
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderBottom(true).
+			BorderForeground(MutedColor).
+			MarginTop(1).
+			MarginBottom(1).
+			PaddingBottom(0)
+
+	// File display styles
+	FileNameStyle = lipgloss.NewStyle().
+			Foreground(TextColor)
+
+	RawFileStyle = lipgloss.NewStyle().
+			Foreground(PrimaryColor).
+			Bold(true)
+
+	JpegFileStyle = lipgloss.NewStyle().
+			Foreground(SecondaryColor)
+
+	DateStyle = lipgloss.NewStyle().
+			Foreground(DimTextColor)
+
+	// Status indicators
+	SuccessStyle = lipgloss.NewStyle().
+			Foreground(SecondaryColor).
+			Bold(true)
+
+	WarningStyle = lipgloss.NewStyle().
+			Foreground(WarningColor)
+
+	ErrorStyle = lipgloss.NewStyle().
+			Foreground(ErrorColor).
+			Bold(true)
+
+	// Summary stat styles
+	StatLabelStyle = lipgloss.NewStyle().
+			Foreground(DimTextColor).
+			Width(20)
+
+	StatValueStyle = lipgloss.NewStyle().
+			Foreground(TextColor).
+			Bold(true)
+
+	// Spinner style
+	SpinnerStyle = lipgloss.NewStyle().
+			Foreground(PrimaryColor)
+
+	// Icon characters
+	IconRAW      = "◆"
+	IconJPEG     = "◇"
+	IconSkipped  = "○"
+	IconOverride = "⚠"
+	IconSuccess  = "✓"
+	IconError    = "✗"
+	IconArrow    = "→"
+)
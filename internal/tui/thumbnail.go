@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"phopy/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ThumbnailFunc is called to load the thumbnail of the file at path. It
+// should answer with a ThumbnailMsg; loading stays on demand, one
+// highlighted file at a time, so scanning is not slowed down.
+type ThumbnailFunc func(path string) tea.Cmd
+
+// ThumbnailMsg carries the escape sequence that draws the thumbnail of
+// Path over Rows lines of the terminal. An empty Image is a file without a
+// thumbnail, which is shown as such.
+type ThumbnailMsg struct {
+	Path  string
+	Image string
+	Rows  int
+}
+
+// thumbnails highlights one of the listed files to show its thumbnail.
+// Loaded thumbnails are kept, so moving back and forth loads each once.
+type thumbnails struct {
+	active bool
+	index  int
+	loaded map[string]ThumbnailMsg
+}
+
+// listedItems are the files the thumbnail cursor moves over: those
+// matching the filter, or all of the preview.
+func (m Model) listedItems() []domain.CopyItem {
+	if m.filter.query != "" {
+		return m.filter.apply(m.Plan.Items)
+	}
+	return m.Plan.Items
+}
+
+// showsThumbnail reports whether the thumbnail panel is part of the view.
+func (m Model) showsThumbnail() bool {
+	return m.thumbs.active && m.canFilter() && !m.filter.editing && len(m.listedItems()) > 0
+}
+
+// highlighted is the file whose thumbnail is shown and its position among
+// the listed files.
+func (m Model) highlighted() (domain.CopyItem, int, int) {
+	items := m.listedItems()
+	index := min(m.thumbs.index, len(items)-1)
+	return items[index], index, len(items)
+}
+
+// moveThumbnail moves the highlight by delta, starting at the first file,
+// and loads the thumbnail of the new one unless it is loaded already.
+func (m Model) moveThumbnail(delta int) (Model, tea.Cmd) {
+	items := m.listedItems()
+	if m.config.Thumbnail == nil || !m.canFilter() || len(items) == 0 {
+		return m, nil
+	}
+	if !m.thumbs.active {
+		m.thumbs.active, m.thumbs.index = true, 0
+	} else {
+		m.thumbs.index = max(min(m.thumbs.index+delta, len(items)-1), 0)
+	}
+	item, _, _ := m.highlighted()
+	if _, ok := m.thumbs.loaded[item.FileMeta.SourcePath]; ok {
+		return m, nil
+	}
+	return m, m.config.Thumbnail(item.FileMeta.SourcePath)
+}
+
+func (m Model) storeThumbnail(msg ThumbnailMsg) Model {
+	if m.thumbs.loaded == nil {
+		m.thumbs.loaded = make(map[string]ThumbnailMsg)
+	}
+	m.thumbs.loaded[msg.Path] = msg
+	return m
+}
+
+// renderThumbnail shows the highlighted file and its thumbnail, leaving
+// the rows the image is drawn over empty.
+func (m Model) renderThumbnail() string {
+	var b strings.Builder
+	dimStyle := lipgloss.NewStyle().Foreground(dimTextColor)
+	item, index, total := m.highlighted()
+	b.WriteString(fmt.Sprintf("  ▸ %s  %s\n", formatFileItem(item), dimStyle.Render(fmt.Sprintf("(%d of %d)", index+1, total))))
+
+	thumb, ok := m.thumbs.loaded[item.FileMeta.SourcePath]
+	switch {
+	case !ok:
+		b.WriteString(dimStyle.Render("    Loading thumbnail..."))
+		b.WriteString("\n")
+	case thumb.Image == "":
+		b.WriteString(dimStyle.Render("    No thumbnail"))
+		b.WriteString("\n")
+	default:
+		b.WriteString("    ")
+		b.WriteString(thumb.Image)
+		b.WriteString(strings.Repeat("\n", max(thumb.Rows, 1)))
+	}
+	return b.String()
+}
+
+func (m Model) thumbnailHelp() string {
+	if m.config.Thumbnail == nil {
+		return ""
+	}
+	return " • ↑ ↓ for thumbnails"
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestThumbnailsLoadOnDemandAndAreKept(t *testing.T) {
+	var requested []string
+	m := NewModel(Config{
+		SourceDir: "/source", TargetDir: "/target", DryRun: true,
+		Thumbnail: func(path string) tea.Cmd {
+			requested = append(requested, path)
+			return func() tea.Msg { return ThumbnailMsg{Path: path, Image: "<" + path + ">", Rows: 3} }
+		},
+		ThumbnailClear: "<clear>",
+	})
+	plan := cardPlan(3)
+	for i := range plan.Items {
+		plan.Items[i].FileMeta.SourcePath = "/source/" + plan.Items[i].FileMeta.RelativePath
+	}
+	m, _ = update(t, m, PlanReadyMsg{Plan: plan})
+	if len(requested) != 0 || strings.Contains(m.View(), "▸") {
+		t.Fatalf("expected nothing to load before a file is highlighted")
+	}
+
+	m, cmd := update(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	if view := m.View(); !strings.Contains(view, "DSC0001.JPG") || !strings.Contains(view, "(1 of 3)") || !strings.Contains(view, "Loading thumbnail") {
+		t.Fatalf("expected the first file to be loading, got:\n%s", view)
+	}
+	m, _ = update(t, m, cmd())
+	if view := m.View(); !strings.Contains(view, "</source/DCIM/DSC0001.JPG>\n\n\n") {
+		t.Fatalf("expected the thumbnail over 3 rows, got:\n%s", view)
+	}
+
+	m, cmd = update(t, m, keyMsg("j"))
+	m, _ = update(t, m, cmd())
+	m, cmd = update(t, m, keyMsg("k"))
+	if cmd != nil {
+		t.Fatalf("expected the first thumbnail to be kept")
+	}
+	if len(requested) != 2 || !strings.Contains(m.View(), "(1 of 3)") {
+		t.Fatalf("expected two loads and the first file again, got %v", requested)
+	}
+
+	// Filtering hides the panel, which clears the drawn thumbnail
+	m, _ = update(t, m, keyMsg("/"))
+	if view := m.View(); !strings.HasPrefix(view, "<clear>") || strings.Contains(view, "▸") {
+		t.Fatalf("expected the thumbnail to be cleared, got:\n%s", view)
+	}
+}
+
+func TestThumbnailsAreOffWithoutLoader(t *testing.T) {
+	m := NewModel(Config{SourceDir: "/source", TargetDir: "/target", DryRun: true})
+	m, _ = update(t, m, PlanReadyMsg{Plan: cardPlan(3)})
+	m, cmd := update(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	if cmd != nil || strings.Contains(m.View(), "▸") || strings.Contains(m.View(), "thumbnails") {
+		t.Fatalf("expected no thumbnails, got:\n%s", m.View())
+	}
+}
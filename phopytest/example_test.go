@@ -0,0 +1,40 @@
+package phopytest_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"phopy/internal/app"
+	"phopy/internal/domain"
+	"phopy/phopytest"
+)
+
+// The fakes stand in for the disk and the EXIF decoder of a planner.
+func Example() {
+	taken := time.Date(2024, 10, 2, 15, 1, 0, 0, time.Local)
+	fsys := phopytest.NewFS().AddTree("/card", phopytest.Tree{
+		"DCIM/100MSDCF/DSC0001.ARW": {ModTime: taken},
+		"DCIM/100MSDCF/DSC0001.JPG": {ModTime: taken},
+		"DCIM/100MSDCF/DSC0002.ARW": {ModTime: taken},
+	})
+	exif := phopytest.NewExif().
+		SetTakenAt("/card/DCIM/100MSDCF/DSC0001.ARW", taken).
+		SetTakenAt("/card/DCIM/100MSDCF/DSC0002.ARW", taken.Add(time.Minute))
+
+	planner := app.Planner{FS: fsys, Exif: exif, Layout: domain.Layout{Dir: "{date}", Flatten: true}}
+	plan, err := planner.Plan(context.Background(), "/card", "/archive", nil, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, item := range plan.Items {
+		fmt.Println(item.TargetPath)
+	}
+	fmt.Println("EXIF reads:", exif.TotalReads())
+
+	// Output:
+	// /archive/2024-10-02/DSC0001.ARW
+	// /archive/2024-10-02/DSC0002.ARW
+	// EXIF reads: 2
+}
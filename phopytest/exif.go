@@ -0,0 +1,126 @@
+package phopytest
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"phopy/internal/domain"
+)
+
+// ErrNoMeta is returned by Exif for files without metadata.
+var ErrNoMeta = errors.New("phopytest: no EXIF metadata")
+
+// Exif is an in-memory EXIF reader implementing app.ExifReader. It is safe
+// for concurrent use and counts the reads of every path. The zero value is
+// not usable; create one with NewExif.
+type Exif struct {
+	// Latency is waited for with Sleep on every read.
+	Latency time.Duration
+	// Sleep defaults to time.Sleep.
+	Sleep func(time.Duration)
+
+	mu    sync.Mutex
+	meta  map[string]domain.PhotoMeta
+	errs  map[string]error
+	err   error
+	reads map[string]int
+	order []string
+}
+
+// NewExif returns a reader without metadata for any file.
+func NewExif() *Exif {
+	return &Exif{
+		meta:  make(map[string]domain.PhotoMeta),
+		errs:  make(map[string]error),
+		reads: make(map[string]int),
+	}
+}
+
+// SetTakenAt gives path the capture time t.
+func (e *Exif) SetTakenAt(path string, t time.Time) *Exif {
+	return e.SetMeta(path, domain.PhotoMeta{TakenAt: t})
+}
+
+// SetMeta gives path the metadata meta.
+func (e *Exif) SetMeta(path string, meta domain.PhotoMeta) *Exif {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.meta[filepath.Clean(path)] = meta
+	return e
+}
+
+// Fail makes reading path fail with err.
+func (e *Exif) Fail(path string, err error) *Exif {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errs[filepath.Clean(path)] = err
+	return e
+}
+
+// FailAll makes every read fail with err.
+func (e *Exif) FailAll(err error) *Exif {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.err = err
+	return e
+}
+
+// Reads returns how often path was read.
+func (e *Exif) Reads(path string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.reads[filepath.Clean(path)]
+}
+
+// TotalReads returns the number of reads of all paths.
+func (e *Exif) TotalReads() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	total := 0
+	for _, n := range e.reads {
+		total += n
+	}
+	return total
+}
+
+// Order returns the paths read so far, in the order they were read.
+func (e *Exif) Order() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.order...)
+}
+
+// ReadMeta returns the metadata set for path, the error set for it, or
+// ErrNoMeta.
+func (e *Exif) ReadMeta(ctx context.Context, path string) (domain.PhotoMeta, error) {
+	if e.Latency > 0 {
+		sleep := e.Sleep
+		if sleep == nil {
+			sleep = time.Sleep
+		}
+		sleep(e.Latency)
+	}
+	if err := ctx.Err(); err != nil {
+		return domain.PhotoMeta{}, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	path = filepath.Clean(path)
+	e.reads[path]++
+	e.order = append(e.order, path)
+	if e.err != nil {
+		return domain.PhotoMeta{}, e.err
+	}
+	if err := e.errs[path]; err != nil {
+		return domain.PhotoMeta{}, err
+	}
+	meta, ok := e.meta[path]
+	if !ok {
+		return domain.PhotoMeta{}, ErrNoMeta
+	}
+	return meta, nil
+}
@@ -0,0 +1,59 @@
+package phopytest_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"phopy/internal/domain"
+	"phopy/phopytest"
+)
+
+func TestExifReturnsMetaAndCountsReads(t *testing.T) {
+	offset := time.Hour
+	exif := phopytest.NewExif().
+		SetTakenAt("/card/DSC0001.ARW", modTime).
+		SetMeta("/card/DSC0002.ARW", domain.PhotoMeta{TakenAt: modTime, Offset: &offset}).
+		Fail("/card/DSC0003.ARW", domain.ErrInvalidCaptureDate)
+	ctx := context.Background()
+
+	if meta, err := exif.ReadMeta(ctx, "/card/DSC0001.ARW"); err != nil || !meta.TakenAt.Equal(modTime) {
+		t.Fatalf("unexpected meta %+v, %v", meta, err)
+	}
+	if meta, err := exif.ReadMeta(ctx, "/card/DSC0002.ARW"); err != nil || meta.Offset == nil || *meta.Offset != time.Hour {
+		t.Fatalf("unexpected meta %+v, %v", meta, err)
+	}
+	if _, err := exif.ReadMeta(ctx, "/card/DSC0003.ARW"); !errors.Is(err, domain.ErrInvalidCaptureDate) {
+		t.Fatalf("expected the injected error, got %v", err)
+	}
+	if _, err := exif.ReadMeta(ctx, "/card/DSC0004.ARW"); !errors.Is(err, phopytest.ErrNoMeta) {
+		t.Fatalf("expected ErrNoMeta, got %v", err)
+	}
+	_, _ = exif.ReadMeta(ctx, "/card/DSC0001.ARW")
+
+	if exif.Reads("/card/DSC0001.ARW") != 2 || exif.Reads("/card/DSC0005.ARW") != 0 || exif.TotalReads() != 5 {
+		t.Fatalf("unexpected read counts")
+	}
+	if order := exif.Order(); len(order) != 5 || order[4] != filepath.Clean("/card/DSC0001.ARW") {
+		t.Fatalf("unexpected read order: %v", order)
+	}
+
+	exif.FailAll(errors.New("decoder gone"))
+	if _, err := exif.ReadMeta(ctx, "/card/DSC0001.ARW"); err == nil || err.Error() != "decoder gone" {
+		t.Fatalf("expected every read to fail, got %v", err)
+	}
+}
+
+func TestExifHonorsCancellation(t *testing.T) {
+	exif := phopytest.NewExif().SetTakenAt("/card/DSC0001.ARW", modTime)
+	exif.Latency = time.Second
+	exif.Sleep = func(time.Duration) {}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := exif.ReadMeta(ctx, "/card/DSC0001.ARW"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,603 @@
+// Package phopytest provides deterministic in-memory fakes of the ports of
+// phopy's planner and executor: a file system and an EXIF reader. Both
+// inject errors per path and can simulate latency, so tests never touch the
+// disk or decode real photos.
+package phopytest
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"phopy/internal/hash"
+)
+
+// Op names a file system operation for error injection.
+type Op string
+
+const (
+	OpWalk       Op = "walk"
+	OpStat       Op = "stat"
+	OpExists     Op = "exists"
+	OpMkdir      Op = "mkdir"
+	OpCopy       Op = "copy"
+	OpReadHeader Op = "readheader"
+	OpHash       Op = "hash"
+	OpStamp      Op = "stamp"
+	OpLocked     Op = "locked"
+	OpSyncDir    Op = "syncdir"
+	OpLink       Op = "link"
+	OpRemove     Op = "remove"
+)
+
+// File is a file of FS. Without Data its content is Size zero bytes, which
+// keeps large files cheap.
+type File struct {
+	Data    []byte
+	Size    int64
+	ModTime time.Time
+}
+
+func (f File) size() int64 {
+	if f.Data != nil {
+		return int64(len(f.Data))
+	}
+	return f.Size
+}
+
+// Tree lays out files below a root for AddTree. Keys are slash separated
+// relative paths; a key ending in a slash adds an empty directory.
+type Tree map[string]File
+
+// Latency is the simulated duration of each FS operation. Copy is charged
+// once per copied file and Chunk once per chunk reported by CopyFileProgress.
+type Latency struct {
+	Walk       time.Duration
+	Stat       time.Duration
+	Exists     time.Duration
+	Mkdir      time.Duration
+	ReadHeader time.Duration
+	Hash       time.Duration
+	Copy       time.Duration
+	Chunk      time.Duration
+}
+
+// Copy records a successful CopyFile.
+type Copy struct {
+	Src string
+	Dst string
+}
+
+// FS is an in-memory file system implementing app.FileSystem. It is safe
+// for concurrent use. The zero value is not usable; create one with NewFS.
+type FS struct {
+	// Latency is waited for with Sleep on every operation.
+	Latency Latency
+	// Sleep defaults to time.Sleep. Replace it with a no-op or a fake
+	// clock to simulate latency without waiting; Elapsed adds it up
+	// either way.
+	Sleep func(time.Duration)
+	// Locks, when set, reports for Locked whether another process holds
+	// path open. It is asked on every probe, so a lock can be released
+	// between the first attempt and the retry.
+	Locks func(path string) bool
+
+	mu      sync.Mutex
+	files   map[string]File
+	dirs    map[string]bool
+	errs    map[Op]map[string]error
+	ro      []string
+	copies  []Copy
+	syncs   []string
+	links   []Copy
+	stamps  map[string]map[string]string
+	elapsed time.Duration
+	free    *int64
+}
+
+// NewFS returns an empty file system.
+func NewFS() *FS {
+	return &FS{
+		files:  make(map[string]File),
+		dirs:   make(map[string]bool),
+		errs:   make(map[Op]map[string]error),
+		stamps: make(map[string]map[string]string),
+	}
+}
+
+// AddFile adds file at path, creating its parent directories.
+func (f *FS) AddFile(path string, file File) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path = filepath.Clean(path)
+	f.files[path] = file
+	f.addDirs(filepath.Dir(path))
+	return f
+}
+
+// AddDir adds the directory path and its parents.
+func (f *FS) AddDir(path string) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.addDirs(filepath.Clean(path))
+	return f
+}
+
+// AddTree adds every entry of tree below root.
+func (f *FS) AddTree(root string, tree Tree) *FS {
+	for rel, file := range tree {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if strings.HasSuffix(rel, "/") {
+			f.AddDir(path)
+		} else {
+			f.AddFile(path, file)
+		}
+	}
+	return f
+}
+
+// Fail makes op fail with err for path. For OpCopy path may be the source or
+// the destination; a failing destination is left with the first half of
+// the file, like a write that broke off. For OpWalk the walk function
+// receives err for path.
+func (f *FS) Fail(op Op, path string, err error) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errs[op] == nil {
+		f.errs[op] = make(map[string]error)
+	}
+	f.errs[op][filepath.Clean(path)] = err
+	return f
+}
+
+// ReadOnly mounts root read-only, like a locked card: creating
+// directories, copying, linking and stamping below it fail with a
+// permission error, while reading it still works.
+func (f *FS) ReadOnly(root string) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ro = append(f.ro, filepath.Clean(root))
+	return f
+}
+
+// Free sets the bytes FreeSpace reports for every path. Without it
+// FreeSpace fails with errors.ErrUnsupported.
+func (f *FS) Free(bytes int64) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.free = &bytes
+	return f
+}
+
+// File returns the file at path.
+func (f *FS) File(path string) (File, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, ok := f.files[filepath.Clean(path)]
+	return file, ok
+}
+
+// Copies returns the successful copies in the order they finished.
+func (f *FS) Copies() []Copy {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Copy(nil), f.copies...)
+}
+
+// Links returns the successful links in call order, Src being the
+// existing file.
+func (f *FS) Links() []Copy {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Copy(nil), f.links...)
+}
+
+// Syncs returns the directories passed to SyncDir, in call order.
+func (f *FS) Syncs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.syncs...)
+}
+
+// Stamps returns the attributes Stamp set on path.
+func (f *FS) Stamps(path string) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stamps[filepath.Clean(path)]
+}
+
+// Elapsed is the latency simulated so far, summed over all operations.
+func (f *FS) Elapsed() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.elapsed
+}
+
+// WalkDir walks the tree below root in lexical order like fs.WalkDir,
+// including fs.SkipDir and fs.SkipAll.
+func (f *FS) WalkDir(root string, fn fs.WalkDirFunc) error {
+	f.wait(f.Latency.Walk)
+	root = filepath.Clean(root)
+
+	f.mu.Lock()
+	children := make(map[string][]string)
+	for path := range f.files {
+		f.addChild(children, root, path)
+	}
+	for path := range f.dirs {
+		f.addChild(children, root, path)
+	}
+	rootEntry, ok := f.entry(root)
+	walkErrs := f.errs[OpWalk]
+	f.mu.Unlock()
+
+	if !ok {
+		return fn(root, nil, &fs.PathError{Op: "lstat", Path: root, Err: fs.ErrNotExist})
+	}
+	for _, names := range children {
+		sort.Strings(names)
+	}
+
+	var walk func(path string, d fs.DirEntry) error
+	walk = func(path string, d fs.DirEntry) error {
+		if err := walkErrs[path]; err != nil {
+			return fn(path, d, err)
+		}
+		if err := fn(path, d, nil); err != nil || !d.IsDir() {
+			if err == fs.SkipDir && d.IsDir() {
+				return nil
+			}
+			return err
+		}
+		for _, name := range children[path] {
+			child := filepath.Join(path, name)
+			f.mu.Lock()
+			entry, _ := f.entry(child)
+			f.mu.Unlock()
+			if err := walk(child, entry); err != nil {
+				if err == fs.SkipDir {
+					// SkipDir on a file skips its remaining siblings
+					return nil
+				}
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := walk(root, rootEntry)
+	if err == fs.SkipDir || err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// Stat returns the info of the file or directory at path.
+func (f *FS) Stat(path string) (fs.FileInfo, error) {
+	f.wait(f.Latency.Stat)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path = filepath.Clean(path)
+	if err := f.errs[OpStat][path]; err != nil {
+		return nil, err
+	}
+	entry, ok := f.entry(path)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist}
+	}
+	return entry.info, nil
+}
+
+// Exists reports whether a file or directory exists at path.
+func (f *FS) Exists(path string) (bool, error) {
+	f.wait(f.Latency.Exists)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path = filepath.Clean(path)
+	if err := f.errs[OpExists][path]; err != nil {
+		return false, err
+	}
+	_, ok := f.entry(path)
+	return ok, nil
+}
+
+// MkdirAll adds the directory path and its parents.
+func (f *FS) MkdirAll(path string, perm fs.FileMode) error {
+	f.wait(f.Latency.Mkdir)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path = filepath.Clean(path)
+	if err := f.errs[OpMkdir][path]; err != nil {
+		return err
+	}
+	if f.dirs[path] {
+		return nil
+	}
+	if err := f.readOnly("mkdir", path); err != nil {
+		return err
+	}
+	f.addDirs(path)
+	return nil
+}
+
+// SyncDir records path, see Syncs.
+func (f *FS) SyncDir(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path = filepath.Clean(path)
+	if err := f.errs[OpSyncDir][path]; err != nil {
+		return err
+	}
+	if !f.dirs[path] {
+		return &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	f.syncs = append(f.syncs, path)
+	return nil
+}
+
+// Link makes path share the file at existing, see Links. For OpLink the
+// failing path may be either of them.
+func (f *FS) Link(existing, path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, path = filepath.Clean(existing), filepath.Clean(path)
+	err := f.errs[OpLink][existing]
+	if err == nil {
+		err = f.errs[OpLink][path]
+	}
+	if err == nil {
+		err = f.readOnly("link", path)
+	}
+	if err != nil {
+		return err
+	}
+	file, ok := f.files[existing]
+	if !ok {
+		return &fs.PathError{Op: "link", Path: existing, Err: fs.ErrNotExist}
+	}
+	if _, ok := f.files[path]; ok {
+		return &fs.PathError{Op: "link", Path: path, Err: fs.ErrExist}
+	}
+	f.files[path] = file
+	f.addDirs(filepath.Dir(path))
+	f.links = append(f.links, Copy{Src: existing, Dst: path})
+	return nil
+}
+
+// CopyFile copies the file at src to dst, replacing dst.
+func (f *FS) CopyFile(src, dst string) error {
+	return f.CopyFileProgress(src, dst, nil)
+}
+
+// CopyFileProgress copies like CopyFile. When onProgress is set it reports
+// the running total in chunks of a quarter of the file, waiting for
+// Latency.Chunk before each.
+func (f *FS) CopyFileProgress(src, dst string, onProgress func(written int64)) error {
+	f.wait(f.Latency.Copy)
+	src, dst = filepath.Clean(src), filepath.Clean(dst)
+
+	f.mu.Lock()
+	file, ok := f.files[src]
+	err := f.errs[OpCopy][src]
+	if err == nil {
+		if err = f.errs[OpCopy][dst]; err != nil && ok {
+			f.files[dst] = File{Size: file.size() / 2, ModTime: file.ModTime}
+			f.addDirs(filepath.Dir(dst))
+		}
+	}
+	if err == nil {
+		err = f.readOnly("open", dst)
+	}
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &fs.PathError{Op: "open", Path: src, Err: fs.ErrNotExist}
+	}
+
+	if onProgress != nil {
+		size := file.size()
+		for quarter := int64(1); quarter <= 4; quarter++ {
+			f.wait(f.Latency.Chunk)
+			onProgress(size * quarter / 4)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[dst] = file
+	f.addDirs(filepath.Dir(dst))
+	f.copies = append(f.copies, Copy{Src: src, Dst: dst})
+	return nil
+}
+
+// ReadHeader returns up to n leading bytes of the file at path.
+func (f *FS) ReadHeader(path string, n int) ([]byte, error) {
+	f.wait(f.Latency.ReadHeader)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path = filepath.Clean(path)
+	if err := f.errs[OpReadHeader][path]; err != nil {
+		return nil, err
+	}
+	file, ok := f.files[path]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	data := file.Data
+	if len(data) > n {
+		data = data[:n]
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// HashFile returns the hex digest of the content of the file at path.
+func (f *FS) HashFile(path string, algorithm hash.Algorithm) (string, error) {
+	f.wait(f.Latency.Hash)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path = filepath.Clean(path)
+	if err := f.errs[OpHash][path]; err != nil {
+		return "", err
+	}
+	file, ok := f.files[path]
+	if !ok {
+		return "", &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	if file.Data != nil {
+		return algorithm.Sum(bytes.NewReader(file.Data))
+	}
+	return algorithm.Sum(bytes.NewReader(make([]byte, file.Size)))
+}
+
+// Stamp sets attrs on the file at path, see Stamps.
+func (f *FS) Stamp(path string, attrs map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path = filepath.Clean(path)
+	if err := f.errs[OpStamp][path]; err != nil {
+		return err
+	}
+	if err := f.readOnly("setxattr", path); err != nil {
+		return err
+	}
+	if _, ok := f.files[path]; !ok {
+		return &fs.PathError{Op: "setxattr", Path: path, Err: fs.ErrNotExist}
+	}
+	if f.stamps[path] == nil {
+		f.stamps[path] = make(map[string]string)
+	}
+	for name, value := range attrs {
+		f.stamps[path][name] = value
+	}
+	return nil
+}
+
+// Remove deletes the file at path.
+func (f *FS) Remove(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path = filepath.Clean(path)
+	if err := f.errs[OpRemove][path]; err != nil {
+		return err
+	}
+	if err := f.readOnly("remove", path); err != nil {
+		return err
+	}
+	if _, ok := f.files[path]; !ok {
+		return &fs.PathError{Op: "remove", Path: path, Err: fs.ErrNotExist}
+	}
+	delete(f.files, path)
+	return nil
+}
+
+// FreeSpace reports the bytes set with Free.
+func (f *FS) FreeSpace(path string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.free == nil {
+		return 0, &fs.PathError{Op: "statfs", Path: path, Err: errors.ErrUnsupported}
+	}
+	return *f.free, nil
+}
+
+// Locked reports what Locks reports for path, and false without Locks.
+func (f *FS) Locked(path string) (bool, error) {
+	f.mu.Lock()
+	path = filepath.Clean(path)
+	err := f.errs[OpLocked][path]
+	locks := f.Locks
+	f.mu.Unlock()
+	if err != nil {
+		return false, err
+	}
+	return locks != nil && locks(path), nil
+}
+
+// readOnly returns the error of writing path when it is below a root
+// mounted with ReadOnly. The caller holds f.mu.
+func (f *FS) readOnly(op, path string) error {
+	for _, root := range f.ro {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return &fs.PathError{Op: op, Path: path, Err: fs.ErrPermission}
+		}
+	}
+	return nil
+}
+
+// wait simulates d of latency.
+func (f *FS) wait(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	f.mu.Lock()
+	f.elapsed += d
+	sleep := f.Sleep
+	f.mu.Unlock()
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	sleep(d)
+}
+
+// addDirs adds path and its parents. The caller holds mu.
+func (f *FS) addDirs(path string) {
+	for {
+		f.dirs[path] = true
+		parent := filepath.Dir(path)
+		if parent == path {
+			return
+		}
+		path = parent
+	}
+}
+
+// addChild records path below its parent if it is inside root. The caller
+// holds mu.
+func (f *FS) addChild(children map[string][]string, root, path string) {
+	if path == root || !strings.HasPrefix(path, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator)) {
+		return
+	}
+	parent := filepath.Dir(path)
+	children[parent] = append(children[parent], filepath.Base(path))
+}
+
+// entry returns the dir entry of path. The caller holds mu.
+func (f *FS) entry(path string) (dirEntry, bool) {
+	if file, ok := f.files[path]; ok {
+		return dirEntry{info: fileInfo{name: filepath.Base(path), size: file.size(), mode: 0o644, modTime: file.ModTime}}, true
+	}
+	if f.dirs[path] {
+		return dirEntry{info: fileInfo{name: filepath.Base(path), mode: fs.ModeDir | 0o755}}, true
+	}
+	return dirEntry{}, false
+}
+
+type dirEntry struct {
+	info fileInfo
+}
+
+func (d dirEntry) Name() string               { return d.info.name }
+func (d dirEntry) IsDir() bool                { return d.info.IsDir() }
+func (d dirEntry) Type() fs.FileMode          { return d.info.mode.Type() }
+func (d dirEntry) Info() (fs.FileInfo, error) { return d.info, nil }
+
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) Mode() fs.FileMode  { return i.mode }
+func (i fileInfo) ModTime() time.Time { return i.modTime }
+func (i fileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i fileInfo) Sys() any           { return nil }
@@ -0,0 +1,256 @@
+package phopytest_test
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"phopy/internal/app"
+	"phopy/phopytest"
+)
+
+var (
+	_ app.FileSystem     = (*phopytest.FS)(nil)
+	_ app.ProgressCopier = (*phopytest.FS)(nil)
+	_ app.ContentHasher  = (*phopytest.FS)(nil)
+	_ app.Stamper        = (*phopytest.FS)(nil)
+	_ app.Remover        = (*phopytest.FS)(nil)
+	_ app.SpaceReporter  = (*phopytest.FS)(nil)
+	_ app.ExifReader     = (*phopytest.Exif)(nil)
+)
+
+var modTime = time.Date(2024, 10, 2, 15, 1, 0, 0, time.UTC)
+
+func walk(t *testing.T, fsys *phopytest.FS, root string, fn func(path string, d fs.DirEntry) error) []string {
+	t.Helper()
+	var visited []string
+	err := fsys.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		visited = append(visited, filepath.ToSlash(path))
+		if fn != nil {
+			return fn(path, d)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	return visited
+}
+
+func TestFSWalkDirVisitsTreeInLexicalOrder(t *testing.T) {
+	fsys := phopytest.NewFS().AddTree("/card", phopytest.Tree{
+		"DCIM/101MSDCF/DSC0002.ARW": {ModTime: modTime},
+		"DCIM/100MSDCF/DSC0001.JPG": {ModTime: modTime},
+		"DCIM/100MSDCF/DSC0001.ARW": {ModTime: modTime},
+		"MISC/":                     {},
+	}).AddFile("/archive/DSC0001.ARW", phopytest.File{})
+
+	want := []string{
+		"/card",
+		"/card/DCIM",
+		"/card/DCIM/100MSDCF",
+		"/card/DCIM/100MSDCF/DSC0001.ARW",
+		"/card/DCIM/100MSDCF/DSC0001.JPG",
+		"/card/DCIM/101MSDCF",
+		"/card/DCIM/101MSDCF/DSC0002.ARW",
+		"/card/MISC",
+	}
+	if got := walk(t, fsys, "/card", nil); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected walk order:\n%v", got)
+	}
+
+	// SkipDir on a directory skips it, on a file the rest of its directory
+	got := walk(t, fsys, "/card", func(path string, d fs.DirEntry) error {
+		if d.Name() == "101MSDCF" || d.Name() == "DSC0001.ARW" {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	want = []string{"/card", "/card/DCIM", "/card/DCIM/100MSDCF", "/card/DCIM/100MSDCF/DSC0001.ARW", "/card/DCIM/101MSDCF", "/card/MISC"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected walk with SkipDir:\n%v", got)
+	}
+}
+
+func TestFSWalkDirReportsFileInfo(t *testing.T) {
+	fsys := phopytest.NewFS().AddFile("/card/DSC0001.ARW", phopytest.File{Size: 40 << 30, ModTime: modTime})
+	walk(t, fsys, "/card", func(path string, d fs.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			t.Fatalf("info: %v", err)
+		}
+		if d.IsDir() != info.IsDir() || d.Type().IsRegular() == d.IsDir() {
+			t.Fatalf("inconsistent entry %s", path)
+		}
+		if !d.IsDir() && (info.Size() != 40<<30 || !info.ModTime().Equal(modTime)) {
+			t.Fatalf("unexpected info of %s: %d %v", path, info.Size(), info.ModTime())
+		}
+		return nil
+	})
+
+	err := fsys.WalkDir("/missing", func(path string, d fs.DirEntry, err error) error { return err })
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected a missing root to be reported, got %v", err)
+	}
+}
+
+func TestFSInjectsErrorsPerPath(t *testing.T) {
+	boom := errors.New("boom")
+	fsys := phopytest.NewFS().
+		AddFile("/card/a.ARW", phopytest.File{}).
+		AddFile("/card/b.ARW", phopytest.File{}).
+		Fail(phopytest.OpStat, "/card/a.ARW", boom).
+		Fail(phopytest.OpReadHeader, "/card/a.ARW", boom).
+		Fail(phopytest.OpExists, "/archive/a.ARW", boom).
+		Fail(phopytest.OpMkdir, "/archive/2024", boom).
+		Fail(phopytest.OpCopy, "/archive/b.ARW", boom).
+		Fail(phopytest.OpWalk, "/card/b.ARW", boom)
+
+	if _, err := fsys.Stat("/card/a.ARW"); err != boom {
+		t.Fatalf("stat: %v", err)
+	}
+	if _, err := fsys.Stat("/card/b.ARW"); err != nil {
+		t.Fatalf("stat of another path: %v", err)
+	}
+	if _, err := fsys.ReadHeader("/card/a.ARW", 4); err != boom {
+		t.Fatalf("read header: %v", err)
+	}
+	if _, err := fsys.Exists("/archive/a.ARW"); err != boom {
+		t.Fatalf("exists: %v", err)
+	}
+	if err := fsys.MkdirAll("/archive/2024", 0o755); err != boom {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := fsys.CopyFile("/card/b.ARW", "/archive/b.ARW"); err != boom {
+		t.Fatalf("copy to a failing destination: %v", err)
+	}
+	if err := fsys.WalkDir("/card", func(path string, d fs.DirEntry, err error) error { return err }); err != boom {
+		t.Fatalf("walk: %v", err)
+	}
+}
+
+func TestFSCopiesFiles(t *testing.T) {
+	fsys := phopytest.NewFS().AddFile("/card/DSC0001.ARW", phopytest.File{Data: []byte("raw data"), ModTime: modTime})
+
+	if err := fsys.CopyFile("/card/DSC0001.ARW", "/archive/2024/DSC0001.ARW"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if file, ok := fsys.File("/archive/2024/DSC0001.ARW"); !ok || string(file.Data) != "raw data" {
+		t.Fatalf("expected the copied content, got %+v", file)
+	}
+	if exists, _ := fsys.Exists("/archive/2024"); !exists {
+		t.Fatalf("expected the target directory to exist")
+	}
+	if header, _ := fsys.ReadHeader("/archive/2024/DSC0001.ARW", 3); string(header) != "raw" {
+		t.Fatalf("unexpected header %q", header)
+	}
+	want := []phopytest.Copy{{Src: "/card/DSC0001.ARW", Dst: "/archive/2024/DSC0001.ARW"}}
+	if got := fsys.Copies(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected copies %v", got)
+	}
+
+	if err := fsys.CopyFile("/card/missing.ARW", "/archive/missing.ARW"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected a missing source to fail, got %v", err)
+	}
+}
+
+func TestFSReportsCopyProgressInQuarters(t *testing.T) {
+	fsys := phopytest.NewFS().AddFile("/card/C0001.MP4", phopytest.File{Size: 1000})
+
+	var written []int64
+	if err := fsys.CopyFileProgress("/card/C0001.MP4", "/archive/C0001.MP4", func(n int64) { written = append(written, n) }); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if want := []int64{250, 500, 750, 1000}; !reflect.DeepEqual(written, want) {
+		t.Fatalf("unexpected progress %v", written)
+	}
+}
+
+func TestFSSimulatesLatencyWithoutWaiting(t *testing.T) {
+	var slept []time.Duration
+	fsys := phopytest.NewFS().AddFile("/card/C0001.MP4", phopytest.File{Size: 1000})
+	fsys.Latency = phopytest.Latency{Stat: time.Millisecond, Copy: time.Second, Chunk: 100 * time.Millisecond}
+	fsys.Sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	if _, err := fsys.Stat("/card/C0001.MP4"); err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if err := fsys.CopyFileProgress("/card/C0001.MP4", "/archive/C0001.MP4", func(int64) {}); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if len(slept) != 6 {
+		t.Fatalf("expected a wait for stat, copy and every chunk, got %v", slept)
+	}
+	if got := fsys.Elapsed(); got != 1401*time.Millisecond {
+		t.Fatalf("unexpected elapsed %v", got)
+	}
+}
+
+func TestFSRejectsWritesBelowReadOnlyRoot(t *testing.T) {
+	fsys := phopytest.NewFS().
+		AddFile("/card/DCIM/DSC0001.ARW", phopytest.File{Data: []byte("raw")}).
+		AddFile("/card2/DSC0002.ARW", phopytest.File{}).
+		ReadOnly("/card")
+
+	if err := fsys.CopyFile("/card/DCIM/DSC0001.ARW", "/archive/DSC0001.ARW"); err != nil {
+		t.Fatalf("expected reading a read-only root to work, got %v", err)
+	}
+	if err := fsys.MkdirAll("/card/DCIM", 0o755); err != nil {
+		t.Fatalf("expected an existing directory to need no write, got %v", err)
+	}
+	if err := fsys.MkdirAll("/card/DCIM/101MSDCF", 0o755); !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := fsys.CopyFile("/archive/DSC0001.ARW", "/card/DSC0001.ARW"); !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("copy: %v", err)
+	}
+	if err := fsys.Link("/card/DCIM/DSC0001.ARW", "/card/DCIM/DSC0001 copy.ARW"); !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("link: %v", err)
+	}
+	if err := fsys.Stamp("/card/DCIM/DSC0001.ARW", map[string]string{"user.test": "1"}); !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("stamp: %v", err)
+	}
+	if err := fsys.CopyFile("/card/DCIM/DSC0001.ARW", "/card2/DSC0001.ARW"); err != nil {
+		t.Fatalf("expected a sibling with a common prefix to stay writable, got %v", err)
+	}
+}
+
+func TestFSLeavesPartialFileOnFailingDestination(t *testing.T) {
+	full := errors.New("no space left on device")
+	fsys := phopytest.NewFS().
+		AddFile("/card/DSC0001.ARW", phopytest.File{Size: 1000}).
+		Fail(phopytest.OpCopy, "/archive/DSC0001.ARW", full)
+
+	if err := fsys.CopyFile("/card/DSC0001.ARW", "/archive/DSC0001.ARW"); err != full {
+		t.Fatalf("copy: %v", err)
+	}
+	partial, ok := fsys.File("/archive/DSC0001.ARW")
+	if !ok || partial.Size != 500 {
+		t.Fatalf("expected half of the file to be left, got %+v (%v)", partial, ok)
+	}
+	if err := fsys.Remove("/archive/DSC0001.ARW"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, ok := fsys.File("/archive/DSC0001.ARW"); ok {
+		t.Fatalf("expected the partial file to be removed")
+	}
+	if err := fsys.Remove("/archive/DSC0001.ARW"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected removing a missing file to fail, got %v", err)
+	}
+}
+
+func TestFSReportsFreeSpaceOnlyWhenSet(t *testing.T) {
+	fsys := phopytest.NewFS()
+	if _, err := fsys.FreeSpace("/archive"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("expected free space to be unknown, got %v", err)
+	}
+	if free, err := fsys.Free(4096).FreeSpace("/archive"); err != nil || free != 4096 {
+		t.Fatalf("free space: %d, %v", free, err)
+	}
+}
@@ -0,0 +1,58 @@
+package phopytest
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// Walker is the walking part of app.FileSystem.
+type Walker interface {
+	WalkDir(root string, fn fs.WalkDirFunc) error
+}
+
+// CheckWalkOrder walks root with w and reports the first entry that breaks
+// the order app.FileSystem promises: depth-first, with the entries of each
+// directory in lexical order and every directory left for good once a later
+// sibling is visited. Use it to test alternative file systems against a
+// tree with a few nested directories.
+func CheckWalkOrder(w Walker, root string) error {
+	root = filepath.Clean(root)
+	type open struct {
+		dir  string
+		last string
+	}
+	var stack []open
+	err := w.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		path = filepath.Clean(path)
+		if path == root {
+			if len(stack) > 0 {
+				return fmt.Errorf("visited the root %s twice", root)
+			}
+			if d.IsDir() {
+				stack = append(stack, open{dir: root})
+			}
+			return nil
+		}
+		parent, name := filepath.Dir(path), filepath.Base(path)
+		for len(stack) > 0 && stack[len(stack)-1].dir != parent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			return fmt.Errorf("visited %s after its directory %s was left", path, parent)
+		}
+		top := &stack[len(stack)-1]
+		if top.last != "" && name <= top.last {
+			return fmt.Errorf("visited %s after %s in %s, want lexical order", name, top.last, parent)
+		}
+		top.last = name
+		if d.IsDir() {
+			stack = append(stack, open{dir: path})
+		}
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,69 @@
+package phopytest_test
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"phopy/phopytest"
+)
+
+// walkFunc replays a fixed list of entries as a walk.
+type walkFunc func(root string, fn fs.WalkDirFunc) error
+
+func (w walkFunc) WalkDir(root string, fn fs.WalkDirFunc) error { return w(root, fn) }
+
+// replay walks the entries of fsys below root in the given order.
+func replay(t *testing.T, fsys *phopytest.FS, paths ...string) phopytest.Walker {
+	t.Helper()
+	return walkFunc(func(root string, fn fs.WalkDirFunc) error {
+		for _, path := range paths {
+			var entry fs.DirEntry
+			walk(t, fsys, path, func(visited string, d fs.DirEntry) error {
+				entry = d
+				return fs.SkipAll
+			})
+			if err := fn(path, entry, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func TestCheckWalkOrder(t *testing.T) {
+	fsys := phopytest.NewFS().AddTree("/card", phopytest.Tree{
+		"DCIM/100MSDCF/DSC0001.ARW": {ModTime: modTime},
+		"DCIM/100MSDCF/DSC0002.ARW": {ModTime: modTime},
+		"DCIM/101MSDCF/DSC0003.ARW": {ModTime: modTime},
+		"MISC/":                     {},
+	})
+	if err := phopytest.CheckWalkOrder(fsys, "/card"); err != nil {
+		t.Fatalf("expected the FS to walk in order: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{
+			"unsorted siblings",
+			[]string{"/card", "/card/DCIM", "/card/DCIM/100MSDCF", "/card/DCIM/100MSDCF/DSC0002.ARW", "/card/DCIM/100MSDCF/DSC0001.ARW"},
+			"visited DSC0001.ARW after DSC0002.ARW",
+		},
+		{
+			"breadth-first",
+			[]string{"/card", "/card/DCIM", "/card/MISC", "/card/DCIM/100MSDCF"},
+			"after its directory /card/DCIM was left",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := phopytest.CheckWalkOrder(replay(t, fsys, tt.paths...), "/card")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package copyview
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/progress"
+
+	"phopy/internal/tui/theme"
+)
+
+// DefaultBarMaxWidth caps the progress bars on wide terminals unless
+// BarStyle.MaxWidth says otherwise.
+const DefaultBarMaxWidth = 60
+
+// BarStyle configures the scanning and copying progress bars.
+type BarStyle struct {
+	// MaxWidth caps the width the bar grows to with the window; 0 uses
+	// DefaultBarMaxWidth.
+	MaxWidth int
+	// Solid fills the bar with the primary color instead of a gradient,
+	// which stays visible in monochrome themes.
+	Solid bool
+	// ShowPercent renders the percentage inside the bar instead of next to
+	// the counter.
+	ShowPercent bool
+}
+
+func (s BarStyle) maxWidth() int {
+	if s.MaxWidth > 0 {
+		return s.MaxWidth
+	}
+	return DefaultBarMaxWidth
+}
+
+// minBarWidth is the narrowest bar, however narrow the window.
+const minBarWidth = 10
+
+// width fits the bar into a window of windowWidth columns.
+func (s BarStyle) width(windowWidth int) int {
+	return max(min(windowWidth-20, s.maxWidth()), minBarWidth)
+}
+
+func newProgressBar(style BarStyle) progress.Model {
+	opts := []progress.Option{progress.WithWidth(min(50, style.maxWidth()))}
+	if style.Solid {
+		opts = append(opts, progress.WithSolidFill(string(theme.PrimaryColor)))
+	} else {
+		opts = append(opts, progress.WithDefaultGradient())
+	}
+	if !style.ShowPercent {
+		opts = append(opts, progress.WithoutPercentage())
+	}
+	return progress.New(opts...)
+}
+
+// percentLabel is the percentage shown next to the counter, empty when the
+// bar renders it itself.
+func (s BarStyle) percentLabel(percent float64) string {
+	if s.ShowPercent {
+		return ""
+	}
+	return fmt.Sprintf("(%.0f%%)", percent*100)
+}
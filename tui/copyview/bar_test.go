@@ -0,0 +1,83 @@
+package copyview
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/muesli/termenv"
+)
+
+func TestProgressBarSnapshots(t *testing.T) {
+	tests := []struct {
+		name        string
+		style       BarStyle
+		windowWidth int
+		want        string
+	}{
+		{
+			name:        "capped on a wide window",
+			style:       BarStyle{MaxWidth: 20},
+			windowWidth: 200,
+			want:        "██████████░░░░░░░░░░",
+		},
+		{
+			name:        "narrow window wins over the cap",
+			style:       BarStyle{MaxWidth: 60},
+			windowWidth: 32,
+			want:        "██████░░░░░░",
+		},
+		{
+			name:        "floor on a window without columns",
+			style:       BarStyle{},
+			windowWidth: 0,
+			want:        "█████░░░░░",
+		},
+		{
+			name:        "percentage inside the bar",
+			style:       BarStyle{MaxWidth: 20, Solid: true, ShowPercent: true},
+			windowWidth: 200,
+			want:        "████████░░░░░░░  50%",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bar := newProgressBar(tt.style)
+			bar.Width = tt.style.width(tt.windowWidth)
+			if got := bar.ViewAs(0.5); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressBarSolidFillUsesOneColor(t *testing.T) {
+	colors := regexp.MustCompile(`38;2;\d+;\d+;\d+`)
+	distinct := func(style BarStyle) int {
+		bar := newProgressBar(style)
+		progress.WithColorProfile(termenv.TrueColor)(&bar)
+		bar.Width = 20
+		seen := map[string]bool{}
+		for _, c := range colors.FindAllString(bar.ViewAs(1), -1) {
+			seen[c] = true
+		}
+		return len(seen)
+	}
+
+	if n := distinct(BarStyle{Solid: true}); n != 1 {
+		t.Fatalf("expected a single fill color, got %d", n)
+	}
+	if n := distinct(BarStyle{}); n < 2 {
+		t.Fatalf("expected a gradient, got %d colors", n)
+	}
+}
+
+func TestPercentLabelMovesIntoBar(t *testing.T) {
+	if got := (BarStyle{}).percentLabel(0.5); got != "(50%)" {
+		t.Fatalf("unexpected label %q", got)
+	}
+	if got := (BarStyle{ShowPercent: true}).percentLabel(0.5); got != "" {
+		t.Fatalf("expected no label next to the counter, got %q", got)
+	}
+}
@@ -0,0 +1,294 @@
+// Package copyview is the progress view of a phopy copy: the scan bar, the
+// copy bar with the file being copied, and the summary of the finished
+// copy. It is a Bubble Tea model of its own, so a program other than the
+// phopy TUI can host it; it is driven by the messages of this package,
+// which a host makes from the progress of its scan and copy. They only hold
+// types of this package, so programs outside the module can send them.
+package copyview
+
+import (
+	"time"
+
+	"phopy/internal/tui/theme"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Plan is what the view shows of a planned copy.
+type Plan struct {
+	// Files counts the files to copy, RAW and JPEG those of each kind.
+	Files int
+	RAW   int
+	JPEG  int
+	// SkippedJPEGs counts the JPEGs left out because their RAW is copied.
+	SkippedJPEGs int
+	// Overrides counts the existing targets the user was asked about, and
+	// StaleOverwrites those overwritten without asking.
+	Overrides       int
+	StaleOverwrites int
+}
+
+// Result is the outcome of a copy the summary shows.
+type Result struct {
+	// Copied counts the files copied, RAW and JPEG those of each kind.
+	Copied int
+	RAW    int
+	JPEG   int
+	// Linked counts the copies hard linked to identical files already in
+	// the target.
+	Linked int
+	// SourceChanged counts the files copied in the version they had when
+	// copied, since they changed after planning, and SkippedChanged those
+	// left out for it.
+	SourceChanged  int
+	SkippedChanged int
+	Cancelled      int
+	// Failed lists the files that failed to copy.
+	Failed []Failure
+	// Locked lists the paths of the sources still open in another
+	// program, which were not copied.
+	Locked []string
+	// Integrity summarizes the verification of the copies when any did
+	// not match its source, e.g. "40 ok, 2 target corrupted"; empty
+	// otherwise.
+	Integrity string
+	// RunID names the run, if any.
+	RunID string
+}
+
+// Failure is a file that failed to copy.
+type Failure struct {
+	File string
+	Err  error
+}
+
+// Messages that drive the view
+type (
+	// PlanReadyMsg ends the scan with the plan to copy.
+	PlanReadyMsg struct {
+		Plan Plan
+		// Estimate is how long the copy is expected to take; 0 when
+		// unknown.
+		Estimate time.Duration
+	}
+	// ScanProgressMsg reports how many of the scanned files were
+	// inspected.
+	ScanProgressMsg struct {
+		Current int
+		Total   int
+	}
+	// CopyProgressMsg reports the file about to be copied.
+	CopyProgressMsg struct {
+		Current int
+		Total   int
+		File    string
+	}
+	// CopyBytesMsg is the byte progress of the file being copied
+	CopyBytesMsg struct {
+		File       string
+		Written    int64
+		Size       int64
+		BytesDone  int64
+		BytesTotal int64
+	}
+	// CopyDoneMsg ends the copy with its result.
+	CopyDoneMsg struct {
+		OverridesConfirmed int
+		Result             Result
+	}
+	tickMsg time.Time
+)
+
+// stage is how far the copy got, which View follows.
+type stage int
+
+const (
+	stageScanning stage = iota
+	stagePlanned
+	stageCopying
+	stageDone
+)
+
+// Model is the progress view. Its zero value is not usable; create it with
+// New.
+type Model struct {
+	bar      BarStyle
+	spinner  spinner.Model
+	progress progress.Model
+	stage    stage
+	ticking  bool
+
+	scanCurrent   int
+	scanTotal     int
+	scanStartTime time.Time
+
+	copyProgress  int
+	copyTotal     int
+	copyStartTime time.Time
+	currentFile   string
+	fileStartTime time.Time
+	fileBytes     CopyBytesMsg
+	now           func() time.Time
+
+	plan               Plan
+	estimate           time.Duration
+	result             Result
+	overridesConfirmed int
+}
+
+// New creates a view in the scanning stage whose bars follow bar.
+func New(bar BarStyle) Model {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = theme.SpinnerStyle
+
+	return Model{
+		bar:      bar,
+		spinner:  s,
+		progress: newProgressBar(bar),
+		now:      time.Now,
+	}
+}
+
+// Init starts the spinner. Hosts that stop forwarding spinner ticks while
+// the view is hidden call it again to restart the spinner.
+func (m Model) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+// Update follows the progress messages of this package and the window
+// size, spinner and bar animation messages of Bubble Tea. Every other
+// message is ignored, so a host can forward all it does not handle.
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.progress.Width = m.bar.width(FitWindow(msg).Width)
+		return m, nil
+
+	case ScanProgressMsg:
+		// Track start time on first progress update
+		if m.scanStartTime.IsZero() && msg.Total > 0 {
+			m.scanStartTime = m.now()
+		}
+		m.stage = stageScanning
+		m.scanCurrent = msg.Current
+		m.scanTotal = msg.Total
+		return m, nil
+
+	case PlanReadyMsg:
+		m.stage = stagePlanned
+		m.plan = msg.Plan
+		m.estimate = msg.Estimate
+		return m, nil
+
+	case CopyProgressMsg:
+		// Progress sent before the copy ended can arrive after its
+		// result
+		if m.stage == stageDone {
+			return m, nil
+		}
+		// Track start time on first progress update
+		if m.copyStartTime.IsZero() && msg.Total > 0 {
+			m.copyStartTime = m.now()
+		}
+		if msg.File != m.currentFile {
+			m.fileStartTime = m.now()
+		}
+		m.copyProgress = msg.Current
+		m.copyTotal = msg.Total
+		m.currentFile = msg.File
+		return m.copying()
+
+	case CopyBytesMsg:
+		if m.stage == stageDone {
+			return m, nil
+		}
+		if m.copyStartTime.IsZero() && msg.BytesTotal > 0 {
+			m.copyStartTime = m.now()
+		}
+		m.fileBytes = msg
+		return m.copying()
+
+	case CopyDoneMsg:
+		m.stage = stageDone
+		m.result = msg.Result
+		m.overridesConfirmed = msg.OverridesConfirmed
+		return m, nil
+
+	case spinner.TickMsg:
+		if m.stage == stageScanning || m.stage == stageCopying {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+
+	case progress.FrameMsg:
+		progressModel, cmd := m.progress.Update(msg)
+		m.progress = progressModel.(progress.Model)
+		return m, cmd
+
+	case tickMsg:
+		if m.stage != stageCopying {
+			m.ticking = false
+			return m, nil
+		}
+		var cmds []tea.Cmd
+		if m.copyTotal > 0 {
+			cmds = append(cmds, m.progress.SetPercent(float64(m.copyProgress)/float64(m.copyTotal)))
+		}
+		cmds = append(cmds, tickCmd(), m.spinner.Tick)
+		return m, tea.Batch(cmds...)
+	}
+
+	return m, nil
+}
+
+// copying enters the copying stage and starts the ticks that animate the
+// bar, once.
+func (m Model) copying() (Model, tea.Cmd) {
+	m.stage = stageCopying
+	if m.ticking {
+		return m, nil
+	}
+	m.ticking = true
+	return m, tickCmd()
+}
+
+func tickCmd() tea.Cmd {
+	return tea.Tick(time.Millisecond*100, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// Rescan returns the view to the scanning stage for a new scan.
+func (m Model) Rescan() Model {
+	m.stage = stageScanning
+	m.scanCurrent, m.scanTotal, m.scanStartTime = 0, 0, time.Time{}
+	return m
+}
+
+// Scanned is how many files the scan inspected so far.
+func (m Model) Scanned() int {
+	return m.scanCurrent
+}
+
+// Spinner is the current frame of the spinner.
+func (m Model) Spinner() string {
+	return m.spinner.View()
+}
+
+// View renders the current stage: the scan, the planned copy, the copy or
+// its summary.
+func (m Model) View() string {
+	switch m.stage {
+	case stagePlanned:
+		return m.PlannedView()
+	case stageCopying:
+		return m.CopyView()
+	case stageDone:
+		return Completion(m.plan, m.result, Overrides{Confirmed: m.overridesConfirmed})
+	}
+	return m.ScanView()
+}
@@ -0,0 +1,152 @@
+package copyview
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testPlan() Plan {
+	return Plan{Files: 2, RAW: 1, JPEG: 1}
+}
+
+func TestViewFollowsTheStagesOfACopy(t *testing.T) {
+	m := New(BarStyle{})
+	if view := m.View(); !strings.Contains(view, "Scanning photos...") {
+		t.Fatalf("expected the scan before any progress, got:\n%s", view)
+	}
+
+	m, _ = m.Update(ScanProgressMsg{Current: 1, Total: 4})
+	if view := m.View(); !strings.Contains(view, "1/4") || !strings.Contains(view, "(25%)") {
+		t.Fatalf("expected the scan progress, got:\n%s", view)
+	}
+
+	m, _ = m.Update(PlanReadyMsg{Plan: testPlan(), Estimate: 90 * time.Second})
+	if view := m.View(); !strings.Contains(view, "2 files to copy") || !strings.Contains(view, "(estimate)") {
+		t.Fatalf("expected the planned copy, got:\n%s", view)
+	}
+
+	m, _ = m.Update(CopyProgressMsg{Current: 1, Total: 2, File: "DSC0002.JPG"})
+	if view := m.View(); !strings.Contains(view, "1/2 files") || !strings.Contains(view, "→ DSC0002.JPG") {
+		t.Fatalf("expected the copy progress, got:\n%s", view)
+	}
+
+	m, _ = m.Update(CopyDoneMsg{Result: Result{Copied: 2, RAW: 1, JPEG: 1}})
+	view := m.View()
+	if !strings.Contains(view, "Copy completed successfully!") || !strings.Contains(view, "2 files") {
+		t.Fatalf("expected the summary, got:\n%s", view)
+	}
+}
+
+func TestCopyTicksUntilDone(t *testing.T) {
+	m := New(BarStyle{})
+	m, cmd := m.Update(CopyProgressMsg{Current: 0, Total: 2, File: "DSC0001.ARW"})
+	if cmd == nil {
+		t.Fatalf("expected the first copy progress to start ticking")
+	}
+	if _, cmd = m.Update(CopyProgressMsg{Current: 1, Total: 2, File: "DSC0002.JPG"}); cmd != nil {
+		t.Fatalf("did not expect a second tick loop")
+	}
+
+	if _, cmd = m.Update(tickMsg(time.Now())); cmd == nil {
+		t.Fatalf("expected ticks to go on while copying")
+	}
+	m, _ = m.Update(CopyDoneMsg{})
+	if _, cmd = m.Update(tickMsg(time.Now())); cmd != nil {
+		t.Fatalf("expected ticks to stop once the copy is done")
+	}
+
+	// Progress that arrives after the result leaves the summary in view
+	m, _ = m.Update(CopyBytesMsg{File: "DSC0002.JPG", Written: 1, Size: 2, BytesDone: 1, BytesTotal: 2})
+	m, _ = m.Update(CopyProgressMsg{Current: 2, Total: 2})
+	if !strings.Contains(m.View(), "Copy Complete") {
+		t.Fatalf("expected late progress to be dropped, got:\n%s", m.View())
+	}
+}
+
+func TestUnknownMessagesAreIgnored(t *testing.T) {
+	m := New(BarStyle{})
+	m, _ = m.Update(PlanReadyMsg{Plan: testPlan()})
+	before := m.View()
+
+	type hostMsg struct{}
+	m, cmd := m.Update(hostMsg{})
+	if cmd != nil || m.View() != before {
+		t.Fatalf("expected a message of the host to leave the view alone")
+	}
+	if _, cmd = m.Update(m.spinner.Tick()); cmd != nil {
+		t.Fatalf("did not expect the spinner to turn once planned")
+	}
+	if _, ok := m.Init()().(spinner.TickMsg); !ok {
+		t.Fatalf("expected Init to start the spinner")
+	}
+}
+
+func TestExecutionShowsElapsedTimeWithoutByteProgress(t *testing.T) {
+	m := New(BarStyle{})
+	now := time.Date(2024, 10, 2, 15, 0, 0, 0, time.Local)
+	m.now = func() time.Time { return now }
+	m, _ = m.Update(CopyProgressMsg{Current: 0, Total: 1, File: "DSC0123.MP4"})
+
+	if strings.Contains(m.View(), "elapsed") {
+		t.Fatalf("did not expect elapsed time right after the file started")
+	}
+
+	now = now.Add(72 * time.Second)
+	if !strings.Contains(m.View(), "DSC0123.MP4 — 1m 12s elapsed") {
+		t.Fatalf("expected elapsed time on the current file, got:\n%s", m.View())
+	}
+}
+
+func TestExecutionEstimatesTheRemainingTimeFromItsClock(t *testing.T) {
+	m := New(BarStyle{})
+	now := time.Date(2024, 10, 2, 15, 0, 0, 0, time.Local)
+	m.now = func() time.Time { return now }
+	m, _ = m.Update(CopyBytesMsg{File: "DSC0001.ARW", BytesDone: 0, BytesTotal: 4 << 20})
+
+	now = now.Add(30 * time.Second)
+	m, _ = m.Update(CopyBytesMsg{File: "DSC0001.ARW", BytesDone: 1 << 20, BytesTotal: 4 << 20})
+	if view := m.View(); !strings.Contains(view, "~1m 30s remaining") || !strings.Contains(view, "34.1 KiB/s") {
+		t.Fatalf("expected the estimate of the fake clock, got:\n%s", view)
+	}
+}
+
+func TestCompletionReportsDeclinedOverrides(t *testing.T) {
+	plan := testPlan()
+	plan.Overrides = 1
+	result := Result{Failed: []Failure{{File: "DSC0002.JPG", Err: errors.New("boom")}}}
+
+	view := Completion(plan, result, Overrides{Default: true})
+	if !strings.Contains(view, "1 overrides declined (default)") {
+		t.Fatalf("expected the declined overrides, got:\n%s", view)
+	}
+	if !strings.Contains(view, "DSC0002.JPG boom") {
+		t.Fatalf("expected the failed file, got:\n%s", view)
+	}
+
+	view = Completion(plan, result, Overrides{Confirmed: 1})
+	if !strings.Contains(view, "Files overwritten:") || strings.Contains(view, "declined") {
+		t.Fatalf("expected the confirmed overrides, got:\n%s", view)
+	}
+}
+
+func TestFitWindowClampsReportedSizes(t *testing.T) {
+	tests := []struct {
+		in, want tea.WindowSizeMsg
+	}{
+		{tea.WindowSizeMsg{}, tea.WindowSizeMsg{Width: 80, Height: 24}},
+		{tea.WindowSizeMsg{Width: -1, Height: 50}, tea.WindowSizeMsg{Width: 80, Height: 50}},
+		{tea.WindowSizeMsg{Width: 10, Height: 3}, tea.WindowSizeMsg{Width: 40, Height: 10}},
+		{tea.WindowSizeMsg{Width: 5000, Height: 5000}, tea.WindowSizeMsg{Width: 300, Height: 200}},
+		{tea.WindowSizeMsg{Width: 120, Height: 40}, tea.WindowSizeMsg{Width: 120, Height: 40}},
+	}
+	for _, tt := range tests {
+		if got := FitWindow(tt.in); got != tt.want {
+			t.Errorf("FitWindow(%+v) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package copyview
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// Bounds of the window size. Some CI pseudo-terminals and multiplexers
+// report a size of 0 or thousands of columns; FitWindow keeps such sizes
+// within these bounds.
+const (
+	DefaultWindowWidth  = 80
+	DefaultWindowHeight = 24
+	MinWindowWidth      = 40
+	MaxWindowWidth      = 300
+	MinWindowHeight     = 10
+	MaxWindowHeight     = 200
+)
+
+// FitWindow clamps a reported window size to the bounds and replaces a
+// missing or negative dimension with the default size.
+func FitWindow(msg tea.WindowSizeMsg) tea.WindowSizeMsg {
+	return tea.WindowSizeMsg{
+		Width:  fit(msg.Width, DefaultWindowWidth, MinWindowWidth, MaxWindowWidth),
+		Height: fit(msg.Height, DefaultWindowHeight, MinWindowHeight, MaxWindowHeight),
+	}
+}
+
+func fit(n, def, lo, hi int) int {
+	if n <= 0 {
+		return def
+	}
+	return min(max(n, lo), hi)
+}
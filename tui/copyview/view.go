@@ -0,0 +1,253 @@
+package copyview
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"phopy/internal/format"
+	"phopy/internal/tui/theme"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ScanView renders the scan with its bar once the number of files is known.
+func (m Model) ScanView() string {
+	if m.scanTotal > 0 {
+		percent := float64(m.scanCurrent) / float64(m.scanTotal)
+		progressBar := m.progress.ViewAs(percent)
+
+		countStyle := lipgloss.NewStyle().Foreground(theme.PrimaryColor).Bold(true)
+		percentStyle := lipgloss.NewStyle().Foreground(theme.DimTextColor)
+		etaStyle := lipgloss.NewStyle().Foreground(theme.DimTextColor)
+
+		eta := estimateRemainingTime(m.since(m.scanStartTime), int64(m.scanCurrent), int64(m.scanTotal))
+		etaText := ""
+		if eta != "" {
+			etaText = etaStyle.Render(fmt.Sprintf(" • ~%s remaining", eta))
+		}
+
+		return fmt.Sprintf("%s Scanning photos...\n\n  %s\n  %s %s%s",
+			m.spinner.View(),
+			progressBar,
+			countStyle.Render(fmt.Sprintf("%d/%d", m.scanCurrent, m.scanTotal)),
+			percentStyle.Render(m.bar.percentLabel(percent)),
+			etaText,
+		)
+	}
+	return fmt.Sprintf("%s Scanning photos...", m.spinner.View())
+}
+
+// PlannedView renders what the plan is about to copy, between the scan and
+// the first file copied.
+func (m Model) PlannedView() string {
+	dimStyle := lipgloss.NewStyle().Foreground(theme.DimTextColor)
+	line := fmt.Sprintf("%s files to copy (%s %d RAW, %s %d JPEG)",
+		format.Count(m.plan.Files),
+		theme.IconRAW, m.plan.RAW,
+		theme.IconJPEG, m.plan.JPEG,
+	)
+	if m.estimate > 0 {
+		line += dimStyle.Render(" • " + format.Estimate(m.estimate) + " (estimate)")
+	}
+	return theme.SuccessStyle.Render(theme.IconSuccess) + " " + line
+}
+
+// CopyView renders the copy with its bar and the file being copied.
+func (m Model) CopyView() string {
+	var b strings.Builder
+
+	b.WriteString(theme.SectionStyle.Render("Copying Files"))
+	b.WriteString("\n\n")
+
+	// Progress bar
+	percent := 0.0
+	if m.copyTotal > 0 {
+		percent = float64(m.copyProgress) / float64(m.copyTotal)
+	}
+
+	// Spinner and progress
+	b.WriteString(fmt.Sprintf("  %s Copying...\n\n", m.spinner.View()))
+	b.WriteString(fmt.Sprintf("  %s\n", m.progress.ViewAs(percent)))
+
+	countStyle := lipgloss.NewStyle().Foreground(theme.PrimaryColor).Bold(true)
+	percentStyle := lipgloss.NewStyle().Foreground(theme.DimTextColor)
+	etaStyle := lipgloss.NewStyle().Foreground(theme.DimTextColor)
+
+	// Bytes keep the estimate steady when file sizes vary a lot
+	elapsed := m.since(m.copyStartTime)
+	eta := estimateRemainingTime(elapsed, int64(m.copyProgress), int64(m.copyTotal))
+	if m.fileBytes.BytesTotal > 0 {
+		eta = estimateRemainingTime(elapsed, m.fileBytes.BytesDone, m.fileBytes.BytesTotal)
+	}
+	etaText := ""
+	if eta != "" {
+		etaText = etaStyle.Render(fmt.Sprintf(" • ~%s remaining", eta))
+		if m.fileBytes.BytesDone > 0 {
+			rate := float64(m.fileBytes.BytesDone) / elapsed.Seconds()
+			etaText += etaStyle.Render(" • " + format.Rate(rate))
+		}
+	}
+
+	b.WriteString(fmt.Sprintf("  %s %s%s\n",
+		countStyle.Render(fmt.Sprintf("%d/%d files", m.copyProgress, m.copyTotal)),
+		percentStyle.Render(m.bar.percentLabel(percent)),
+		etaText,
+	))
+
+	if m.currentFile != "" {
+		b.WriteString(fmt.Sprintf("\n  %s %s%s\n",
+			theme.IconArrow,
+			theme.FileNameStyle.Render(m.currentFile),
+			percentStyle.Render(m.currentFileDetail()),
+		))
+	}
+
+	return b.String()
+}
+
+// currentFileDetail shows that a long copy is alive: the bytes written of the
+// current file, or the time spent on it when the copy cannot report bytes.
+func (m Model) currentFileDetail() string {
+	if m.fileBytes.File == m.currentFile && m.fileBytes.Written > 0 {
+		return fmt.Sprintf(" — %s / %s", format.Bytes(m.fileBytes.Written), format.Bytes(m.fileBytes.Size))
+	}
+	if m.fileStartTime.IsZero() {
+		return ""
+	}
+	if elapsed := m.now().Sub(m.fileStartTime); elapsed >= time.Second {
+		return fmt.Sprintf(" — %s elapsed", format.Duration(elapsed))
+	}
+	return ""
+}
+
+// Overrides is how the overrides of a plan were answered.
+type Overrides struct {
+	// Confirmed is how many existing files the copy overwrote after the
+	// user agreed to it.
+	Confirmed int
+	// Default marks overrides declined by accepting the default answer.
+	Default bool
+}
+
+// Completion renders the summary of a finished copy of plan.
+func Completion(plan Plan, result Result, overrides Overrides) string {
+	var b strings.Builder
+
+	statLabelStyle := theme.StatLabelStyle
+	statValueStyle := theme.StatValueStyle
+	errorStyle := theme.ErrorStyle
+	warningStyle := theme.WarningStyle
+	fileNameStyle := theme.FileNameStyle
+	dimStyle := lipgloss.NewStyle().Foreground(theme.DimTextColor)
+
+	b.WriteString(theme.SectionStyle.Render("Copy Complete"))
+	b.WriteString("\n\n")
+
+	// Outcome message
+	if len(result.Failed) > 0 {
+		icon := errorStyle.Render(theme.IconError)
+		msg := errorStyle.Render(fmt.Sprintf("Copy completed with %d failed files", len(result.Failed)))
+		b.WriteString(fmt.Sprintf("  %s %s\n\n", icon, msg))
+	} else {
+		icon := theme.SuccessStyle.Render(theme.IconSuccess)
+		msg := theme.SuccessStyle.Render("Copy completed successfully!")
+		b.WriteString(fmt.Sprintf("  %s %s\n\n", icon, msg))
+	}
+
+	// Statistics
+	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("RAW files copied:"), theme.RawFileStyle.Render(fmt.Sprintf("%s %d", theme.IconRAW, result.RAW))))
+	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("JPEG files copied:"), theme.JpegFileStyle.Render(fmt.Sprintf("%s %d", theme.IconJPEG, result.JPEG))))
+	b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Total copied:"), statValueStyle.Render(fmt.Sprintf("%d files", result.Copied))))
+	if result.Linked > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Linked:"), statValueStyle.Render(fmt.Sprintf("%d files, identical to ones already in the target", result.Linked))))
+	}
+	if result.SourceChanged > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Changed:"), warningStyle.Render(fmt.Sprintf("%d files changed after planning, copied as they are now", result.SourceChanged))))
+	}
+	if result.SkippedChanged > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Changed:"), warningStyle.Render(fmt.Sprintf("%s %d not copied (changed after planning)", theme.IconSkipped, result.SkippedChanged))))
+	}
+
+	if len(result.Failed) > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Failed:"), errorStyle.Render(fmt.Sprintf("%s %d", theme.IconError, len(result.Failed)))))
+		for i, failed := range result.Failed {
+			if i >= 4 {
+				b.WriteString(fmt.Sprintf("    ... and %d more\n", len(result.Failed)-4))
+				break
+			}
+			b.WriteString(fmt.Sprintf("    %s %s\n", fileNameStyle.Render(failed.File), theme.DateStyle.Render(failed.Err.Error())))
+		}
+	}
+	if result.Integrity != "" {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Verified:"), errorStyle.Render(result.Integrity)))
+	}
+	if len(result.Locked) > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Still locked:"), warningStyle.Render(fmt.Sprintf("%s %d not copied (open in another program)", theme.IconSkipped, len(result.Locked)))))
+		for i, locked := range result.Locked {
+			if i >= 4 {
+				b.WriteString(fmt.Sprintf("    ... and %d more\n", len(result.Locked)-4))
+				break
+			}
+			b.WriteString(fmt.Sprintf("    %s\n", fileNameStyle.Render(locked)))
+		}
+	}
+	if result.Cancelled > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Not copied:"), dimStyle.Render(fmt.Sprintf("%s %d (cancelled)", theme.IconSkipped, result.Cancelled))))
+	}
+
+	if plan.SkippedJPEGs > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Skipped JPEGs:"), dimStyle.Render(fmt.Sprintf("%s %d (RAW exists)", theme.IconSkipped, plan.SkippedJPEGs))))
+	}
+	if result.RunID != "" {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Run:"), statValueStyle.Render(result.RunID)))
+	}
+
+	if plan.StaleOverwrites > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Stale overwritten:"), warningStyle.Render(fmt.Sprintf("%s %d (without asking)", theme.IconOverride, plan.StaleOverwrites))))
+	}
+	if overrides.Confirmed > 0 {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Files overwritten:"), warningStyle.Render(fmt.Sprintf("%s %d", theme.IconOverride, overrides.Confirmed))))
+	} else if plan.Overrides > 0 {
+		declined := fmt.Sprintf("%s %d overrides declined", theme.IconOverride, plan.Overrides)
+		if overrides.Default {
+			declined += " (default)"
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s\n", statLabelStyle.Render("Overrides:"), warningStyle.Render(declined)))
+	}
+
+	return b.String()
+}
+
+// since is the time passed since start by the model's clock, 0 before
+// start is set.
+func (m Model) since(start time.Time) time.Duration {
+	if start.IsZero() {
+		return 0
+	}
+	return m.now().Sub(start)
+}
+
+// estimateRemainingTime calculates the estimated remaining time from the
+// progress made in elapsed
+func estimateRemainingTime(elapsed time.Duration, current, total int64) string {
+	if current <= 0 || total <= 0 {
+		return ""
+	}
+
+	if elapsed < time.Millisecond*100 {
+		// Not enough data yet
+		return ""
+	}
+
+	// Calculate rate and remaining time
+	rate := float64(current) / elapsed.Seconds()
+	if rate <= 0 {
+		return ""
+	}
+
+	remaining := float64(total-current) / rate
+	remainingDuration := time.Duration(remaining * float64(time.Second))
+
+	return format.Duration(remainingDuration)
+}